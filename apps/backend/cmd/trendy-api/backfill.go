@@ -0,0 +1,79 @@
+package main
+
+import (
+	"fmt"
+
+	"github.com/JonnyWalker81/trendy/backend/internal/config"
+	"github.com/JonnyWalker81/trendy/backend/internal/logger"
+	"github.com/JonnyWalker81/trendy/backend/internal/service"
+	"github.com/JonnyWalker81/trendy/backend/pkg/supabase"
+	"github.com/spf13/cobra"
+)
+
+var backfillAggregatesCmd = &cobra.Command{
+	Use:   "backfill-aggregates",
+	Short: "Rebuild daily aggregates from events",
+	Long: `Rebuild every daily aggregate of each user from their events and reset the
+user's aggregate cursor to the end of their change log. Aggregates are
+otherwise kept up to date from the change log, and are rebuilt automatically
+when a user's time zone changes or their change log was pruned past the
+cursor. Run this after deploying the incremental aggregates migration, or to
+repair aggregates for events changed without a change log entry.`,
+	RunE: runBackfillAggregates,
+}
+
+var backfillUsers []string
+
+func init() {
+	backfillAggregatesCmd.Flags().StringSliceVar(&backfillUsers, "user", nil, "User IDs to backfill (default: all users)")
+	backfillAggregatesCmd.Flags().StringVar(&storage, "storage", "", "Storage backend: supabase, postgres or memory (overrides config)")
+}
+
+func runBackfillAggregates(cmd *cobra.Command, args []string) error {
+	cfg, err := config.Load(func(c *config.Config) {
+		if storage != "" {
+			c.Storage.Backend = storage
+		}
+	})
+	if err != nil {
+		return fmt.Errorf("failed to load configuration: %w", err)
+	}
+
+	log := logger.NewSlogLogger(logger.Config{
+		Level:  logger.ParseLevel(cfg.LogLevelForEnv()),
+		Format: cfg.Logging.Format,
+	})
+	logger.SetDefault(log)
+	ctx := logger.WithLogger(cmd.Context(), log)
+
+	repos, closeStorage, err := openRepositories(ctx, cfg, supabase.NewClient(cfg.Supabase.URL, cfg.Supabase.ServiceKey))
+	if err != nil {
+		return err
+	}
+	defer closeStorage()
+
+	aggregates := service.NewAggregateService(repos.Events, repos.DailyAggregates, repos.AggregateStates, repos.ChangeLog, repos.UserSettings)
+
+	userIDs := backfillUsers
+	if len(userIDs) == 0 {
+		if userIDs, err = listUserIDs(ctx, repos.Users); err != nil {
+			return err
+		}
+	}
+
+	var days int
+	for _, userID := range userIDs {
+		result, err := aggregates.Backfill(ctx, userID)
+		if err != nil {
+			return fmt.Errorf("failed to backfill aggregates of user %s: %w", userID, err)
+		}
+		days += result.Days
+	}
+
+	log.Info("daily aggregate backfill complete",
+		logger.Int("users", len(userIDs)),
+		logger.Int("days", days),
+	)
+
+	return nil
+}
@@ -0,0 +1,64 @@
+package main
+
+import (
+	"fmt"
+
+	"github.com/JonnyWalker81/trendy/backend/internal/config"
+	"github.com/JonnyWalker81/trendy/backend/internal/logger"
+	"github.com/JonnyWalker81/trendy/backend/internal/service"
+	"github.com/JonnyWalker81/trendy/backend/pkg/supabase"
+	"github.com/spf13/cobra"
+)
+
+var compactChangesCmd = &cobra.Command{
+	Use:   "compact-changes",
+	Short: "Compact and prune the change log",
+	Long: `Remove change log entries superseded by a later entry for the same entity,
+then remove entries older than the configured retention window
+(CHANGE_LOG_RETENTION). Clients whose cursor predates pruned entries receive
+410 Gone and must bootstrap from GET /api/v1/sync/snapshot. The server runs
+this every CHANGE_LOG_COMPACTION_INTERVAL; use this command to run it from an
+external scheduler instead.`,
+	RunE: runCompactChanges,
+}
+
+func init() {
+	compactChangesCmd.Flags().StringVar(&storage, "storage", "", "Storage backend: supabase, postgres or memory (overrides config)")
+}
+
+func runCompactChanges(cmd *cobra.Command, args []string) error {
+	cfg, err := config.Load(func(c *config.Config) {
+		if storage != "" {
+			c.Storage.Backend = storage
+		}
+	})
+	if err != nil {
+		return fmt.Errorf("failed to load configuration: %w", err)
+	}
+
+	log := logger.NewSlogLogger(logger.Config{
+		Level:  logger.ParseLevel(cfg.LogLevelForEnv()),
+		Format: cfg.Logging.Format,
+	})
+	logger.SetDefault(log)
+	ctx := logger.WithLogger(cmd.Context(), log)
+
+	repos, closeStorage, err := openRepositories(ctx, cfg, supabase.NewClient(cfg.Supabase.URL, cfg.Supabase.ServiceKey))
+	if err != nil {
+		return err
+	}
+	defer closeStorage()
+
+	result, err := service.NewChangeLogCompactor(repos.ChangeLog, cfg.Sync.ChangeLogRetention).Run(ctx)
+	if err != nil {
+		return err
+	}
+
+	log.Info("change log compaction complete",
+		logger.Int64("compacted", result.Compacted),
+		logger.Int64("pruned", result.Pruned),
+		logger.Duration("retention", cfg.Sync.ChangeLogRetention),
+	)
+
+	return nil
+}
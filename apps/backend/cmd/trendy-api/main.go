@@ -1,5 +1,8 @@
 package main
 
+// Embedded so user time zones resolve on hosts without a zoneinfo database
+import _ "time/tzdata"
+
 func main() {
 	Execute()
 }
@@ -0,0 +1,108 @@
+package main
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/JonnyWalker81/trendy/backend/internal/config"
+	"github.com/JonnyWalker81/trendy/backend/internal/logger"
+	"github.com/JonnyWalker81/trendy/backend/internal/repository"
+	"github.com/JonnyWalker81/trendy/backend/internal/service"
+	"github.com/JonnyWalker81/trendy/backend/pkg/supabase"
+	"github.com/spf13/cobra"
+)
+
+var reconcileChangesCmd = &cobra.Command{
+	Use:   "reconcile-changes",
+	Short: "Backfill missing change log entries",
+	Long: `Compare events, event types, property definitions and geofences with the
+change log and append any entries that are missing or stale, so sync clients
+receive every change. Writes are committed atomically with their change log
+entry on the postgres and memory backends, and the server relays entries the
+supabase backend failed to append from the change outbox, so this is only
+needed to repair data written before the outbox existed.`,
+	RunE: runReconcileChanges,
+}
+
+var (
+	reconcileUsers  []string
+	reconcileDryRun bool
+)
+
+// userPageSize is the number of users listed per query
+const userPageSize = 500
+
+func init() {
+	reconcileChangesCmd.Flags().StringSliceVar(&reconcileUsers, "user", nil, "User IDs to reconcile (default: all users)")
+	reconcileChangesCmd.Flags().BoolVar(&reconcileDryRun, "dry-run", false, "Report missing entries without writing them")
+	reconcileChangesCmd.Flags().StringVar(&storage, "storage", "", "Storage backend: supabase, postgres or memory (overrides config)")
+}
+
+func runReconcileChanges(cmd *cobra.Command, args []string) error {
+	cfg, err := config.Load(func(c *config.Config) {
+		if storage != "" {
+			c.Storage.Backend = storage
+		}
+	})
+	if err != nil {
+		return fmt.Errorf("failed to load configuration: %w", err)
+	}
+
+	log := logger.NewSlogLogger(logger.Config{
+		Level:  logger.ParseLevel(cfg.LogLevelForEnv()),
+		Format: cfg.Logging.Format,
+	})
+	logger.SetDefault(log)
+	ctx := logger.WithLogger(cmd.Context(), log)
+
+	repos, closeStorage, err := openRepositories(ctx, cfg, supabase.NewClient(cfg.Supabase.URL, cfg.Supabase.ServiceKey))
+	if err != nil {
+		return err
+	}
+	defer closeStorage()
+
+	reconciler := service.NewChangeLogReconciler(repos.Events, repos.EventTypes, repos.PropertyDefinitions, repos.Geofences, repos.ChangeLog)
+
+	userIDs := reconcileUsers
+	if len(userIDs) == 0 {
+		if userIDs, err = listUserIDs(ctx, repos.Users); err != nil {
+			return err
+		}
+	}
+
+	var missing, backfilled int
+	for _, userID := range userIDs {
+		result, err := reconciler.Reconcile(ctx, userID, reconcileDryRun)
+		if err != nil {
+			return fmt.Errorf("failed to reconcile user %s: %w", userID, err)
+		}
+		missing += result.Missing
+		backfilled += result.Backfilled
+	}
+
+	log.Info("change log reconciliation complete",
+		logger.Int("users", len(userIDs)),
+		logger.Int("missing", missing),
+		logger.Int("backfilled", backfilled),
+		logger.Bool("dry_run", reconcileDryRun),
+	)
+
+	return nil
+}
+
+// listUserIDs returns the IDs of every user
+func listUserIDs(ctx context.Context, userRepo repository.UserRepository) ([]string, error) {
+	var userIDs []string
+	for offset := 0; ; offset += userPageSize {
+		users, err := userRepo.List(ctx, userPageSize, offset)
+		if err != nil {
+			return nil, err
+		}
+		for _, u := range users {
+			userIDs = append(userIDs, u.ID)
+		}
+		if len(users) < userPageSize {
+			return userIDs, nil
+		}
+	}
+}
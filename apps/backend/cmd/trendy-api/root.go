@@ -24,4 +24,7 @@ func Execute() {
 func init() {
 	// Add subcommands
 	rootCmd.AddCommand(serveCmd)
+	rootCmd.AddCommand(reconcileChangesCmd)
+	rootCmd.AddCommand(compactChangesCmd)
+	rootCmd.AddCommand(backfillAggregatesCmd)
 }
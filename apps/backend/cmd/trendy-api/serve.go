@@ -1,13 +1,18 @@
 package main
 
 import (
+	"context"
 	"fmt"
 
+	"github.com/JonnyWalker81/trendy/backend/internal/changefeed"
 	"github.com/JonnyWalker81/trendy/backend/internal/config"
 	"github.com/JonnyWalker81/trendy/backend/internal/handlers"
 	"github.com/JonnyWalker81/trendy/backend/internal/logger"
 	"github.com/JonnyWalker81/trendy/backend/internal/middleware"
+	"github.com/JonnyWalker81/trendy/backend/internal/models"
 	"github.com/JonnyWalker81/trendy/backend/internal/repository"
+	"github.com/JonnyWalker81/trendy/backend/internal/repository/memory"
+	"github.com/JonnyWalker81/trendy/backend/internal/repository/postgres"
 	"github.com/JonnyWalker81/trendy/backend/internal/service"
 	"github.com/JonnyWalker81/trendy/backend/pkg/supabase"
 	"github.com/gin-gonic/gin"
@@ -22,25 +27,29 @@ var serveCmd = &cobra.Command{
 }
 
 var (
-	port string
+	port    string
+	storage string
 )
 
 func init() {
 	serveCmd.Flags().StringVarP(&port, "port", "p", "", "Port to listen on (overrides config)")
+	serveCmd.Flags().StringVar(&storage, "storage", "", "Storage backend: supabase, postgres or memory (overrides config)")
 }
 
 func runServe(cmd *cobra.Command, args []string) error {
-	// Load configuration
-	cfg, err := config.Load()
+	// Load configuration, applying flag overrides before validation
+	cfg, err := config.Load(func(c *config.Config) {
+		if port != "" {
+			c.Server.Port = port
+		}
+		if storage != "" {
+			c.Storage.Backend = storage
+		}
+	})
 	if err != nil {
 		return fmt.Errorf("failed to load configuration: %w", err)
 	}
 
-	// Override port from flag if provided
-	if port != "" {
-		cfg.Server.Port = port
-	}
-
 	// Initialize structured logger
 	log := logger.NewSlogLogger(logger.Config{
 		Level:     logger.ParseLevel(cfg.LogLevelForEnv()),
@@ -59,44 +68,155 @@ func runServe(cmd *cobra.Command, args []string) error {
 		logger.String("url", cfg.Supabase.URL),
 	)
 
-	// Initialize Supabase client
-	supabaseClient := supabase.NewClient(cfg.Supabase.URL, cfg.Supabase.ServiceKey)
-
-	// Initialize repositories
-	eventRepo := repository.NewEventRepository(supabaseClient)
-	eventTypeRepo := repository.NewEventTypeRepository(supabaseClient)
-	userRepo := repository.NewUserRepository(supabaseClient)
-	propertyDefRepo := repository.NewPropertyDefinitionRepository(supabaseClient)
-	geofenceRepo := repository.NewGeofenceRepository(supabaseClient)
-	insightRepo := repository.NewInsightRepository(supabaseClient)
-	aggregateRepo := repository.NewDailyAggregateRepository(supabaseClient)
-	streakRepo := repository.NewStreakRepository(supabaseClient)
-	changeLogRepo := repository.NewChangeLogRepository(supabaseClient)
-	idempotencyRepo := repository.NewIdempotencyRepository(supabaseClient)
-	onboardingRepo := repository.NewOnboardingStatusRepository(supabaseClient)
+	// Initialize Supabase client; the postgres backend can run without one
+	var supabaseClient *supabase.Client
+	if cfg.HasSupabase() {
+		supabaseClient = supabase.NewClient(cfg.Supabase.URL, cfg.Supabase.ServiceKey)
+	}
+
+	// Initialize repositories for the configured storage backend
+	repos, closeStorage, err := openRepositories(cmd.Context(), cfg, supabaseClient)
+	if err != nil {
+		return err
+	}
+	defer closeStorage()
+	log.Info("storage backend initialized", logger.String("backend", cfg.Storage.Backend))
+
+	// Notify change stream subscribers of committed change log entries
+	changeBroker := changefeed.NewBroker()
+	changefeed.Attach(repos, changeBroker)
+
+	eventRepo := repos.Events
+	eventTypeRepo := repos.EventTypes
+	userRepo := repos.Users
+	propertyDefRepo := repos.PropertyDefinitions
+	geofenceRepo := repos.Geofences
+	insightRepo := repos.Insights
+	aggregateRepo := repos.DailyAggregates
+	aggregateStateRepo := repos.AggregateStates
+	streakRepo := repos.Streaks
+	changeLogRepo := repos.ChangeLog
+	idempotencyRepo := repos.Idempotency
+	onboardingRepo := repos.OnboardingStatus
+	settingsRepo := repos.UserSettings
+	importJobRepo := repos.ImportJobs
+	accountExportRepo := repos.AccountExports
+	jobRepo := repos.Jobs
+	transactor := repos.Transactor
+
+	// The memory backend has no auth provider; accept any bearer token instead.
+	// With a JWT secret tokens are verified locally, and without Supabase Auth
+	// to create them, user records are created on first sight.
+	var authMiddleware gin.HandlerFunc
+	var authUsers service.AuthUserDeleter
+	switch {
+	case cfg.Storage.Backend == config.StorageMemory:
+		log.Warn("using in-memory storage with development authentication; data is not persisted")
+		authMiddleware = middleware.DevAuth(userRepo)
+	case cfg.Auth.JWTSecret != "":
+		var users repository.UserRepository
+		if supabaseClient == nil {
+			users = userRepo
+		}
+		authMiddleware = middleware.Auth(middleware.NewJWTVerifier(cfg.Auth.JWTSecret), users)
+	default:
+		authMiddleware = middleware.Auth(supabaseClient, nil)
+	}
+	if supabaseClient != nil && cfg.Storage.Backend != config.StorageMemory {
+		authUsers = supabaseClient
+	}
 
 	// Initialize services
-	eventService := service.NewEventService(eventRepo, eventTypeRepo, changeLogRepo)
-	eventTypeService := service.NewEventTypeService(eventTypeRepo, changeLogRepo)
-	analyticsService := service.NewAnalyticsService(eventRepo)
+	eventService := service.NewEventService(eventRepo, eventTypeRepo, propertyDefRepo, changeLogRepo, transactor)
+	eventTypeService := service.NewEventTypeService(eventTypeRepo, eventRepo, propertyDefRepo, geofenceRepo, insightRepo, streakRepo, changeLogRepo, transactor)
+	aggregateService := service.NewAggregateService(eventRepo, aggregateRepo, aggregateStateRepo, changeLogRepo, settingsRepo)
+	analyticsService := service.NewAnalyticsService(eventRepo, eventTypeRepo, propertyDefRepo, settingsRepo, aggregateService)
 	authService := service.NewAuthService(supabaseClient, userRepo)
-	propertyDefService := service.NewPropertyDefinitionService(propertyDefRepo, eventTypeRepo, changeLogRepo)
-	geofenceService := service.NewGeofenceService(geofenceRepo, changeLogRepo)
-	intelligenceService := service.NewIntelligenceService(eventRepo, eventTypeRepo, insightRepo, aggregateRepo, streakRepo)
-	syncService := service.NewSyncService(eventRepo, eventTypeRepo, changeLogRepo)
+	propertyDefService := service.NewPropertyDefinitionService(propertyDefRepo, eventTypeRepo, eventRepo, changeLogRepo, transactor)
+	geofenceService := service.NewGeofenceService(geofenceRepo, changeLogRepo, transactor)
+	var jobService service.JobService
+	if cfg.Jobs.Workers > 0 {
+		jobService = service.NewJobService(jobRepo, service.JobOptions{
+			Workers:         cfg.Jobs.Workers,
+			PollInterval:    cfg.Jobs.PollInterval,
+			Debounce:        cfg.Jobs.Debounce,
+			MaxWait:         cfg.Jobs.MaxWait,
+			MaxAttempts:     cfg.Jobs.MaxAttempts,
+			RetryBackoff:    cfg.Jobs.RetryBackoff,
+			Timeout:         cfg.Jobs.Timeout,
+			MaintenanceHour: cfg.Jobs.MaintenanceHour,
+		})
+	}
+	intelligenceService := service.NewIntelligenceService(eventRepo, eventTypeRepo, insightRepo, aggregateService, streakRepo, settingsRepo, jobService)
+	syncService := service.NewSyncService(eventRepo, eventTypeRepo, propertyDefRepo, geofenceRepo, changeLogRepo, transactor)
+	syncPushService := service.NewSyncPushService(eventService, eventTypeService, propertyDefService, geofenceService, eventRepo, eventTypeRepo, transactor)
 	onboardingService := service.NewOnboardingService(onboardingRepo)
+	settingsService := service.NewUserSettingsService(settingsRepo, insightRepo)
+	trashService := service.NewTrashService(eventRepo, eventTypeRepo, cfg.Trash.Retention)
+	exportService := service.NewExportService(eventRepo, eventTypeRepo, propertyDefRepo)
+	importService := service.NewImportService(eventRepo, eventTypeRepo, propertyDefRepo, geofenceRepo, onboardingRepo, importJobRepo, changeLogRepo, transactor)
+	accountDeletionService := service.NewAccountDeletionService(repos, authUsers)
+	accountExportService := service.NewAccountExportService(accountExportRepo, exportService, userRepo, eventTypeRepo, propertyDefRepo, geofenceRepo, insightRepo, streakRepo, aggregateRepo, onboardingRepo)
+
+	// Compact and prune the change log in the background
+	if cfg.Sync.CompactionInterval > 0 {
+		compactor := service.NewChangeLogCompactor(changeLogRepo, cfg.Sync.ChangeLogRetention)
+		go service.RunChangeLogCompaction(logger.WithLogger(cmd.Context(), log), compactor, cfg.Sync.CompactionInterval)
+	}
+
+	// Append change log entries that writes failed to log
+	if cfg.Sync.RelayInterval > 0 {
+		relay := service.NewChangeLogRelay(eventRepo, eventTypeRepo, propertyDefRepo, geofenceRepo, changeLogRepo, repos.ChangeOutbox)
+		go service.RunChangeLogRelay(logger.WithLogger(cmd.Context(), log), relay, cfg.Sync.RelayInterval)
+	}
+
+	// Purge expired trash in the background
+	if cfg.Trash.PurgeInterval > 0 {
+		go service.RunTrashPurge(logger.WithLogger(cmd.Context(), log), trashService, cfg.Trash.PurgeInterval)
+	}
+
+	// Bring daily aggregates and then insights up to date in the background
+	// once a user's changes settle, and run nightly maintenance
+	if jobService != nil {
+		jobService.Handle(models.JobKindAggregates, service.NewAggregateHandler(aggregateService, jobService))
+		jobService.Handle(models.JobKindInsights, func(ctx context.Context, job *models.Job) error {
+			return intelligenceService.ComputeInsights(ctx, job.UserID)
+		})
+		jobService.Handle(models.JobKindMaintenance, service.NewMaintenanceHandler(jobService, jobRepo, userRepo, cfg.Jobs.Retention))
+		changeBroker.OnNotify(func(userID string) {
+			jobService.Debounce(models.JobKindAggregates, userID)
+		})
+		go jobService.Run(logger.WithLogger(cmd.Context(), log))
+	}
+
+	// Finish account deletions interrupted by a restart
+	go func() {
+		ctx := logger.WithLogger(cmd.Context(), log)
+		resumed, err := accountDeletionService.ResumePending(ctx)
+		if err != nil {
+			log.Error("failed to resume account deletions", logger.Err(err))
+			return
+		}
+		if resumed > 0 {
+			log.Info("resumed account deletions", logger.Int("count", resumed))
+		}
+	}()
 
 	// Initialize handlers
-	eventHandler := handlers.NewEventHandler(eventService)
+	eventHandler := handlers.NewEventHandler(eventService, exportService)
 	eventTypeHandler := handlers.NewEventTypeHandler(eventTypeService)
 	analyticsHandler := handlers.NewAnalyticsHandler(analyticsService)
 	authHandler := handlers.NewAuthHandler(authService)
 	propertyDefHandler := handlers.NewPropertyDefinitionHandler(propertyDefService)
 	geofenceHandler := handlers.NewGeofenceHandler(geofenceService)
 	insightsHandler := handlers.NewInsightsHandler(intelligenceService)
-	changesHandler := handlers.NewChangesHandler(changeLogRepo)
-	syncHandler := handlers.NewSyncHandler(syncService)
+	changesHandler := handlers.NewChangesHandler(changeLogRepo, changeBroker)
+	syncHandler := handlers.NewSyncHandler(syncService, syncPushService)
 	onboardingHandler := handlers.NewOnboardingHandler(onboardingService)
+	settingsHandler := handlers.NewUserSettingsHandler(settingsService)
+	trashHandler := handlers.NewTrashHandler(trashService)
+	importHandler := handlers.NewImportHandler(importService)
+	accountHandler := handlers.NewAccountHandler(accountExportService, accountDeletionService, importService)
 
 	// Set Gin mode based on environment
 	if cfg.Server.Env == "production" {
@@ -127,31 +247,54 @@ func runServe(cmd *cobra.Command, args []string) error {
 		auth := v1.Group("/auth")
 		auth.Use(middleware.RateLimitAuth()) // Auth rate limit: 10 req/min
 		{
-			auth.POST("/login", authHandler.Login)
-			auth.POST("/signup", authHandler.Signup)
+			// Accounts are managed by Supabase Auth when it is configured;
+			// otherwise by whatever issues the tokens JWT_SECRET verifies
+			if supabaseClient != nil {
+				auth.POST("/login", authHandler.Login)
+				auth.POST("/signup", authHandler.Signup)
+			}
 			auth.POST("/logout", authHandler.Logout)
-			auth.GET("/me", middleware.Auth(supabaseClient), authHandler.Me)
+			auth.GET("/me", authMiddleware, authHandler.Me)
 		}
 
+		// Retries an interrupted account deletion, so it is the one write
+		// allowed while a deletion is pending
+		v1.DELETE("/me", authMiddleware, accountHandler.DeleteAccount)
+
 		// Protected routes
 		protected := v1.Group("")
-		protected.Use(middleware.Auth(supabaseClient))
+		protected.Use(authMiddleware, middleware.RejectPendingDeletion(repos.AccountDeletions))
 		{
 			// Sync status route
 			protected.GET("/me/sync", syncHandler.GetSyncStatus)
+			protected.GET("/sync/snapshot", syncHandler.GetSnapshot)
+			protected.POST("/sync/push", middleware.Idempotency(idempotencyRepo), syncHandler.Push)
 
 			// Change feed routes (for sync)
 			protected.GET("/changes", changesHandler.GetChanges)
 			protected.GET("/changes/latest-cursor", changesHandler.GetLatestCursor)
+			protected.GET("/changes/stream", changesHandler.StreamChanges)
 
 			// Event routes - with idempotency for mutations
 			protected.GET("/events", eventHandler.GetEvents)
 			protected.GET("/events/export", eventHandler.ExportEvents)
+			protected.GET("/events/search", eventHandler.SearchEvents)
 			protected.POST("/events", middleware.Idempotency(idempotencyRepo), eventHandler.CreateEvent)
 			protected.POST("/events/batch", middleware.Idempotency(idempotencyRepo), eventHandler.CreateEventsBatch)
 			protected.GET("/events/:id", eventHandler.GetEvent)
 			protected.PUT("/events/:id", middleware.Idempotency(idempotencyRepo), eventHandler.UpdateEvent)
 			protected.DELETE("/events/:id", eventHandler.DeleteEvent)
+			protected.POST("/events/:id/restore", eventHandler.RestoreEvent)
+
+			// Import routes
+			protected.POST("/imports", importHandler.CreateImport)
+			protected.GET("/imports/:id", importHandler.GetImport)
+
+			// Account routes
+			protected.POST("/me/export", accountHandler.CreateExport)
+			protected.GET("/me/exports/:id", accountHandler.GetExport)
+			protected.GET("/me/exports/:id/download", accountHandler.DownloadExport)
+			protected.POST("/me/import", accountHandler.RestoreArchive)
 
 			// Event type routes - with idempotency for mutations
 			protected.GET("/event-types", eventTypeHandler.GetEventTypes)
@@ -159,6 +302,11 @@ func runServe(cmd *cobra.Command, args []string) error {
 			protected.GET("/event-types/:id", eventTypeHandler.GetEventType)
 			protected.PUT("/event-types/:id", middleware.Idempotency(idempotencyRepo), eventTypeHandler.UpdateEventType)
 			protected.DELETE("/event-types/:id", eventTypeHandler.DeleteEventType)
+			protected.POST("/event-types/:id/restore", eventTypeHandler.RestoreEventType)
+			protected.POST("/event-types/:id/merge", eventTypeHandler.MergeEventType)
+
+			// Trash route
+			protected.GET("/trash", trashHandler.GetTrash)
 
 			// Property definition routes - with idempotency for mutations
 			protected.GET("/event-types/:id/properties", propertyDefHandler.GetPropertyDefinitionsByEventType)
@@ -166,11 +314,13 @@ func runServe(cmd *cobra.Command, args []string) error {
 			protected.GET("/property-definitions/:id", propertyDefHandler.GetPropertyDefinition)
 			protected.PUT("/property-definitions/:id", middleware.Idempotency(idempotencyRepo), propertyDefHandler.UpdatePropertyDefinition)
 			protected.DELETE("/property-definitions/:id", propertyDefHandler.DeletePropertyDefinition)
+			protected.POST("/property-definitions/:id/migrate", middleware.Idempotency(idempotencyRepo), propertyDefHandler.MigratePropertyDefinition)
 
 			// Analytics routes
 			protected.GET("/analytics/summary", analyticsHandler.GetSummary)
 			protected.GET("/analytics/trends", analyticsHandler.GetTrends)
 			protected.GET("/analytics/event-type/:id", analyticsHandler.GetEventTypeAnalytics)
+			protected.GET("/analytics/event-type/:id/properties/:key", analyticsHandler.GetPropertyAnalytics)
 
 			// Geofence routes - with idempotency for mutations
 			protected.GET("/geofences", geofenceHandler.GetGeofences)
@@ -185,11 +335,16 @@ func runServe(cmd *cobra.Command, args []string) error {
 			protected.GET("/insights/streaks", insightsHandler.GetStreaks)
 			protected.GET("/insights/weekly-summary", insightsHandler.GetWeeklySummary)
 			protected.POST("/insights/refresh", insightsHandler.RefreshInsights)
+			protected.GET("/insights/status", insightsHandler.GetStatus)
 
 			// Onboarding status routes
 			protected.GET("/users/onboarding", onboardingHandler.GetOnboardingStatus)
 			protected.PATCH("/users/onboarding", onboardingHandler.UpdateOnboardingStatus)
 			protected.DELETE("/users/onboarding", onboardingHandler.ResetOnboardingStatus)
+
+			// User settings routes
+			protected.GET("/users/settings", settingsHandler.GetSettings)
+			protected.PATCH("/users/settings", settingsHandler.UpdateSettings)
 		}
 	}
 
@@ -203,3 +358,20 @@ func runServe(cmd *cobra.Command, args []string) error {
 
 	return nil
 }
+
+// openRepositories creates the repositories for the configured storage
+// backend. The returned func releases any resources held by the backend.
+func openRepositories(ctx context.Context, cfg *config.Config, supabaseClient *supabase.Client) (*repository.Repositories, func(), error) {
+	switch cfg.Storage.Backend {
+	case config.StoragePostgres:
+		db, err := postgres.Open(ctx, cfg.Storage.DatabaseURL)
+		if err != nil {
+			return nil, nil, fmt.Errorf("failed to open postgres storage: %w", err)
+		}
+		return postgres.NewRepositories(db), db.Close, nil
+	case config.StorageMemory:
+		return memory.NewRepositories(memory.NewStore()), func() {}, nil
+	default:
+		return repository.NewSupabaseRepositories(supabaseClient), func() {}, nil
+	}
+}
@@ -32,18 +32,26 @@ const (
 
 	// TypeBadRequest indicates a malformed or invalid request (400)
 	TypeBadRequest = "urn:trendy:error:bad_request"
+
+	// TypePreconditionFailed indicates an If-Match precondition did not hold (412)
+	TypePreconditionFailed = "urn:trendy:error:precondition_failed"
+
+	// TypeCursorExpired indicates a sync cursor predates the change log retention horizon (410)
+	TypeCursorExpired = "urn:trendy:error:cursor_expired"
 )
 
 // Titles for each error type - human-readable summaries
 const (
-	TitleValidation      = "Validation Error"
-	TitleNotFound        = "Resource Not Found"
-	TitleConflict        = "Resource Conflict"
-	TitleRateLimit       = "Rate Limit Exceeded"
-	TitleUnauthorized    = "Authentication Required"
-	TitleForbidden       = "Permission Denied"
-	TitleInternal        = "Internal Server Error"
-	TitleInvalidUUID     = "Invalid UUID Format"
-	TitleFutureTimestamp = "Future Timestamp Not Allowed"
-	TitleBadRequest      = "Bad Request"
+	TitleValidation         = "Validation Error"
+	TitleNotFound           = "Resource Not Found"
+	TitleConflict           = "Resource Conflict"
+	TitleRateLimit          = "Rate Limit Exceeded"
+	TitleUnauthorized       = "Authentication Required"
+	TitleForbidden          = "Permission Denied"
+	TitleInternal           = "Internal Server Error"
+	TitleInvalidUUID        = "Invalid UUID Format"
+	TitleFutureTimestamp    = "Future Timestamp Not Allowed"
+	TitleBadRequest         = "Bad Request"
+	TitleCursorExpired      = "Sync Cursor Expired"
+	TitlePreconditionFailed = "Precondition Failed"
 )
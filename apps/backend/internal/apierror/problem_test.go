@@ -299,6 +299,31 @@ func TestNewFutureTimestampError(t *testing.T) {
 	}
 }
 
+func TestNewPreconditionFailedError(t *testing.T) {
+	problem := NewPreconditionFailedError("req-pqr", "event", "abc-123")
+
+	if problem.Type != TypePreconditionFailed {
+		t.Errorf("Expected type=%q, got %q", TypePreconditionFailed, problem.Type)
+	}
+	if problem.Status != http.StatusPreconditionFailed {
+		t.Errorf("Expected status=%d, got %d", http.StatusPreconditionFailed, problem.Status)
+	}
+}
+
+func TestNewCursorExpiredError(t *testing.T) {
+	problem := NewCursorExpiredError("req-mno", 10, 42)
+
+	if problem.Type != TypeCursorExpired {
+		t.Errorf("Expected type=%q, got %q", TypeCursorExpired, problem.Type)
+	}
+	if problem.Status != http.StatusGone {
+		t.Errorf("Expected status=%d, got %d", http.StatusGone, problem.Status)
+	}
+	if problem.Action != "bootstrap_snapshot" {
+		t.Errorf("Expected action=%q, got %q", "bootstrap_snapshot", problem.Action)
+	}
+}
+
 func TestProblemDetailsError(t *testing.T) {
 	// Test Error() with detail
 	p1 := &ProblemDetails{
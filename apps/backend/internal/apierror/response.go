@@ -171,6 +171,34 @@ func NewFutureTimestampError(requestID, field string) *ProblemDetails {
 	}
 }
 
+// NewPreconditionFailedError creates a 412 Precondition Failed response for a
+// write whose If-Match ETag no longer matches the current version.
+func NewPreconditionFailedError(requestID, resource, id string) *ProblemDetails {
+	return &ProblemDetails{
+		Type:        TypePreconditionFailed,
+		Title:       TitlePreconditionFailed,
+		Status:      http.StatusPreconditionFailed,
+		Detail:      fmt.Sprintf("%s with ID '%s' has been modified since it was retrieved", resource, id),
+		RequestID:   requestID,
+		UserMessage: "This item was changed elsewhere. Refresh and try again.",
+		Action:      "refresh_resource",
+	}
+}
+
+// NewCursorExpiredError creates a 410 Gone response for a sync cursor whose
+// changes have been pruned. The client must bootstrap from a snapshot.
+func NewCursorExpiredError(requestID string, cursor, horizon int64) *ProblemDetails {
+	return &ProblemDetails{
+		Type:        TypeCursorExpired,
+		Title:       TitleCursorExpired,
+		Status:      http.StatusGone,
+		Detail:      fmt.Sprintf("Cursor %d predates the change log retention horizon %d", cursor, horizon),
+		RequestID:   requestID,
+		UserMessage: "Your data needs a full refresh",
+		Action:      "bootstrap_snapshot",
+	}
+}
+
 // NewServiceUnavailableError creates a 503 Service Unavailable response.
 // retryAfter specifies seconds until the client should retry.
 func NewServiceUnavailableError(requestID string, retryAfter int) *ProblemDetails {
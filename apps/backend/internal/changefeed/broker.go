@@ -0,0 +1,97 @@
+// Package changefeed notifies in-process subscribers when change log entries
+// are appended for a user, so streaming clients can pick them up immediately
+// instead of polling.
+//
+// Notifications carry no data: a subscriber reads the change log from its own
+// cursor when woken, which keeps delivery ordered and lossless even when
+// notifications are coalesced. Only writes made by this process notify
+// subscribers; streams pick up writes from other instances when they re-read
+// the log at their next heartbeat.
+package changefeed
+
+import "sync"
+
+// Broker fans out change notifications to the subscribers of each user
+type Broker struct {
+	mu    sync.Mutex
+	subs  map[string]map[*Subscription]struct{}
+	hooks []func(userID string)
+}
+
+// NewBroker creates an empty broker
+func NewBroker() *Broker {
+	return &Broker{subs: make(map[string]map[*Subscription]struct{})}
+}
+
+// Subscription receives a signal on C whenever the user's change log grows.
+// Signals are coalesced, so a slow reader sees at most one pending signal.
+type Subscription struct {
+	C <-chan struct{}
+
+	c      chan struct{}
+	broker *Broker
+	userID string
+	once   sync.Once
+}
+
+// Subscribe registers a subscriber for userID. Call Close when done.
+func (b *Broker) Subscribe(userID string) *Subscription {
+	c := make(chan struct{}, 1)
+	sub := &Subscription{C: c, c: c, broker: b, userID: userID}
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if b.subs[userID] == nil {
+		b.subs[userID] = make(map[*Subscription]struct{})
+	}
+	b.subs[userID][sub] = struct{}{}
+
+	return sub
+}
+
+// Close unregisters the subscription. It is safe to call more than once.
+func (s *Subscription) Close() {
+	s.once.Do(func() {
+		b := s.broker
+		b.mu.Lock()
+		defer b.mu.Unlock()
+		delete(b.subs[s.userID], s)
+		if len(b.subs[s.userID]) == 0 {
+			delete(b.subs, s.userID)
+		}
+	})
+}
+
+// OnNotify registers fn to be called with the user of every notification,
+// such as to queue background work after a user's data changes. fn runs on
+// the notifying goroutine, after the write has committed, and must not block.
+func (b *Broker) OnNotify(fn func(userID string)) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.hooks = append(b.hooks, fn)
+}
+
+// Notify wakes every subscriber of userID without blocking
+func (b *Broker) Notify(userID string) {
+	b.mu.Lock()
+	for sub := range b.subs[userID] {
+		select {
+		case sub.c <- struct{}{}:
+		default:
+			// A signal is already pending
+		}
+	}
+	hooks := b.hooks
+	b.mu.Unlock()
+
+	for _, fn := range hooks {
+		fn(userID)
+	}
+}
+
+// Subscribers returns the number of open subscriptions for userID
+func (b *Broker) Subscribers(userID string) int {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return len(b.subs[userID])
+}
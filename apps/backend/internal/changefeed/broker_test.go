@@ -0,0 +1,103 @@
+package changefeed
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/JonnyWalker81/trendy/backend/internal/models"
+	"github.com/JonnyWalker81/trendy/backend/internal/repository/memory"
+)
+
+func signalled(sub *Subscription) bool {
+	select {
+	case <-sub.C:
+		return true
+	default:
+		return false
+	}
+}
+
+func TestBrokerFansOutPerUser(t *testing.T) {
+	b := NewBroker()
+	a1 := b.Subscribe("user-a")
+	a2 := b.Subscribe("user-a")
+	other := b.Subscribe("user-b")
+	var notified []string
+	b.OnNotify(func(userID string) { notified = append(notified, userID) })
+
+	// Repeated notifications coalesce into one pending signal
+	b.Notify("user-a")
+	b.Notify("user-a")
+
+	if !signalled(a1) || !signalled(a2) {
+		t.Fatal("expected both user-a subscribers to be signalled")
+	}
+	if signalled(a1) {
+		t.Error("expected notifications to coalesce")
+	}
+	if signalled(other) {
+		t.Error("user-b subscriber should not be signalled")
+	}
+	if len(notified) != 2 || notified[0] != "user-a" {
+		t.Errorf("expected the hook to see both notifications, got %v", notified)
+	}
+
+	a1.Close()
+	a1.Close()
+	if n := b.Subscribers("user-a"); n != 1 {
+		t.Errorf("expected 1 subscriber after close, got %d", n)
+	}
+}
+
+func TestAttachNotifiesAfterCommit(t *testing.T) {
+	ctx := context.Background()
+	b := NewBroker()
+	repos := memory.NewRepositories(memory.NewStore())
+	Attach(repos, b)
+
+	sub := b.Subscribe("user-1")
+	defer sub.Close()
+
+	input := &models.ChangeLogInput{
+		EntityType: models.EntityTypeEvent,
+		Operation:  models.OperationCreate,
+		EntityID:   "event-1",
+		UserID:     "user-1",
+	}
+
+	// Outside a transaction the append notifies immediately
+	if _, err := repos.ChangeLog.Append(ctx, input); err != nil {
+		t.Fatalf("Append failed: %v", err)
+	}
+	if !signalled(sub) {
+		t.Fatal("expected notification for plain append")
+	}
+
+	// A rolled-back transaction never notifies
+	errBoom := errors.New("boom")
+	repos.Transactor.WithinTx(ctx, func(ctx context.Context) error {
+		repos.ChangeLog.Append(ctx, input)
+		return errBoom
+	})
+	if signalled(sub) {
+		t.Fatal("rolled-back append should not notify")
+	}
+
+	// A committed transaction notifies once fn returns
+	err := repos.Transactor.WithinTx(ctx, func(ctx context.Context) error {
+		if _, err := repos.ChangeLog.Append(ctx, input); err != nil {
+			return err
+		}
+		if signalled(sub) {
+			t.Error("append should not notify before commit")
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("WithinTx failed: %v", err)
+	}
+	if !signalled(sub) {
+		t.Fatal("expected notification after commit")
+	}
+}
@@ -0,0 +1,78 @@
+package changefeed
+
+import (
+	"context"
+	"sync"
+
+	"github.com/JonnyWalker81/trendy/backend/internal/models"
+	"github.com/JonnyWalker81/trendy/backend/internal/repository"
+)
+
+// Attach wraps the change log and transactor of repos so every committed
+// append notifies broker. Call it before handing the repositories to services.
+func Attach(repos *repository.Repositories, broker *Broker) {
+	repos.ChangeLog = &notifyingChangeLog{ChangeLogRepository: repos.ChangeLog, broker: broker}
+	repos.Transactor = &notifyingTransactor{tx: repos.Transactor, broker: broker}
+}
+
+type pendingKey struct{}
+
+// pending collects the users to notify once a transaction commits
+type pending struct {
+	mu    sync.Mutex
+	users map[string]struct{}
+}
+
+func (p *pending) add(userID string) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.users[userID] = struct{}{}
+}
+
+type notifyingChangeLog struct {
+	repository.ChangeLogRepository
+	broker *Broker
+}
+
+func (r *notifyingChangeLog) Append(ctx context.Context, input *models.ChangeLogInput) (int64, error) {
+	id, err := r.ChangeLogRepository.Append(ctx, input)
+	if err != nil {
+		return 0, err
+	}
+
+	// Inside a transaction the entry is not visible to readers until commit
+	if p, ok := ctx.Value(pendingKey{}).(*pending); ok {
+		p.add(input.UserID)
+	} else {
+		r.broker.Notify(input.UserID)
+	}
+
+	return id, nil
+}
+
+type notifyingTransactor struct {
+	tx     repository.Transactor
+	broker *Broker
+}
+
+func (t *notifyingTransactor) WithinTx(ctx context.Context, fn func(ctx context.Context) error) error {
+	// Nested calls join the outer transaction and notify when it commits
+	if _, ok := ctx.Value(pendingKey{}).(*pending); ok {
+		return t.tx.WithinTx(ctx, fn)
+	}
+
+	p := &pending{users: make(map[string]struct{})}
+	if err := t.tx.WithinTx(context.WithValue(ctx, pendingKey{}, p), fn); err != nil {
+		return err
+	}
+
+	for userID := range p.users {
+		t.broker.Notify(userID)
+	}
+
+	return nil
+}
+
+func (t *notifyingTransactor) WithinSnapshot(ctx context.Context, fn func(ctx context.Context) error) error {
+	return t.tx.WithinSnapshot(ctx, fn)
+}
@@ -3,6 +3,7 @@ package config
 import (
 	"fmt"
 	"strings"
+	"time"
 
 	"github.com/spf13/viper"
 )
@@ -11,9 +12,82 @@ import (
 type Config struct {
 	Server   ServerConfig   `mapstructure:"server"`
 	Supabase SupabaseConfig `mapstructure:"supabase"`
+	Auth     AuthConfig     `mapstructure:"auth"`
+	Storage  StorageConfig  `mapstructure:"storage"`
+	Sync     SyncConfig     `mapstructure:"sync"`
+	Trash    TrashConfig    `mapstructure:"trash"`
+	Jobs     JobsConfig     `mapstructure:"jobs"`
 	Logging  LoggingConfig  `mapstructure:"logging"`
 }
 
+// Storage backends supported by StorageConfig.Backend
+const (
+	StorageSupabase = "supabase"
+	StoragePostgres = "postgres"
+	StorageMemory   = "memory"
+)
+
+// StorageConfig selects the repository implementation
+type StorageConfig struct {
+	// Backend is the storage backend: supabase (PostgREST), postgres (direct
+	// pgx) or memory (in-process, development only)
+	Backend string `mapstructure:"backend"`
+	// DatabaseURL is the Postgres connection string, required for the postgres backend
+	DatabaseURL string `mapstructure:"database_url"`
+}
+
+// SyncConfig controls change log maintenance
+type SyncConfig struct {
+	// ChangeLogRetention is how long change log entries are kept. Clients whose
+	// cursor predates pruned entries must bootstrap from a snapshot. Zero keeps
+	// entries forever.
+	ChangeLogRetention time.Duration `mapstructure:"change_log_retention"`
+	// CompactionInterval is how often the server compacts and prunes the
+	// change log. Zero disables the background job.
+	CompactionInterval time.Duration `mapstructure:"compaction_interval"`
+	// RelayInterval is how often the server appends change log entries that
+	// writes recorded in the change outbox but failed to log. Zero disables
+	// the background job.
+	RelayInterval time.Duration `mapstructure:"relay_interval"`
+}
+
+// TrashConfig controls how long deleted events and event types can be restored
+type TrashConfig struct {
+	// Retention is how long deleted items stay in the trash before they are
+	// purged for good. Zero keeps them forever.
+	Retention time.Duration `mapstructure:"retention"`
+	// PurgeInterval is how often the server purges expired trash. Zero
+	// disables the background job.
+	PurgeInterval time.Duration `mapstructure:"purge_interval"`
+}
+
+// JobsConfig controls the background job workers that recompute insights
+// and run nightly maintenance
+type JobsConfig struct {
+	// Workers is how many jobs run at once. Zero disables background jobs;
+	// insights are then computed on the request path.
+	Workers int `mapstructure:"workers"`
+	// PollInterval is how often workers look for due jobs
+	PollInterval time.Duration `mapstructure:"poll_interval"`
+	// Debounce is how long after a user's last change their insights are
+	// recomputed
+	Debounce time.Duration `mapstructure:"debounce"`
+	// MaxWait is the longest a user's changes can keep putting off a queued
+	// recompute. Zero means no limit.
+	MaxWait time.Duration `mapstructure:"max_wait"`
+	// MaxAttempts is how many times a failing job runs before it is given up
+	MaxAttempts int `mapstructure:"max_attempts"`
+	// RetryBackoff is the delay before the first retry of a failed job. It
+	// doubles with each further retry, up to an hour.
+	RetryBackoff time.Duration `mapstructure:"retry_backoff"`
+	// Timeout is the longest a job may run
+	Timeout time.Duration `mapstructure:"timeout"`
+	// MaintenanceHour is the hour of the day, in UTC, of nightly maintenance
+	MaintenanceHour int `mapstructure:"maintenance_hour"`
+	// Retention is how long finished jobs are kept. Zero keeps them forever.
+	Retention time.Duration `mapstructure:"retention"`
+}
+
 // LoggingConfig holds logging-specific configuration
 type LoggingConfig struct {
 	// Level is the minimum log level: debug, info, warn, error
@@ -38,13 +112,38 @@ type SupabaseConfig struct {
 	ServiceKey string `mapstructure:"service_key"`
 }
 
-// Load reads configuration from environment variables and config files
-func Load() (*Config, error) {
+// AuthConfig holds access token verification settings
+type AuthConfig struct {
+	// JWTSecret verifies HS256 access tokens locally instead of asking
+	// Supabase Auth. Supabase signs its tokens with the project's JWT secret,
+	// so the same secret also accepts them. It lets the postgres backend run
+	// without Supabase.
+	JWTSecret string `mapstructure:"jwt_secret"`
+}
+
+// Load reads configuration from environment variables and config files.
+// Overrides (e.g. from command-line flags) are applied before validation.
+func Load(overrides ...func(*Config)) (*Config, error) {
 	v := viper.New()
 
 	// Set default values
 	v.SetDefault("server.port", "8080")
 	v.SetDefault("server.env", "development")
+	v.SetDefault("storage.backend", StorageSupabase)
+	v.SetDefault("sync.change_log_retention", "2160h") // 90 days
+	v.SetDefault("sync.compaction_interval", "24h")
+	v.SetDefault("sync.relay_interval", "30s")
+	v.SetDefault("trash.retention", "720h") // 30 days
+	v.SetDefault("trash.purge_interval", "24h")
+	v.SetDefault("jobs.workers", 2)
+	v.SetDefault("jobs.poll_interval", "5s")
+	v.SetDefault("jobs.debounce", "30s")
+	v.SetDefault("jobs.max_wait", "5m")
+	v.SetDefault("jobs.max_attempts", 5)
+	v.SetDefault("jobs.retry_backoff", "1m")
+	v.SetDefault("jobs.timeout", "10m")
+	v.SetDefault("jobs.maintenance_hour", 3)
+	v.SetDefault("jobs.retention", "168h") // 7 days
 	v.SetDefault("logging.level", "info")
 	v.SetDefault("logging.format", "json")
 	v.SetDefault("logging.log_bodies", false)
@@ -59,6 +158,17 @@ func Load() (*Config, error) {
 	v.BindEnv("server.port", "PORT")
 	v.BindEnv("supabase.url", "SUPABASE_URL")
 	v.BindEnv("supabase.service_key", "SUPABASE_SERVICE_KEY")
+	v.BindEnv("auth.jwt_secret", "JWT_SECRET")
+	v.BindEnv("storage.backend", "STORAGE_BACKEND")
+	v.BindEnv("storage.database_url", "DATABASE_URL")
+	v.BindEnv("sync.change_log_retention", "CHANGE_LOG_RETENTION")
+	v.BindEnv("sync.compaction_interval", "CHANGE_LOG_COMPACTION_INTERVAL")
+	v.BindEnv("sync.relay_interval", "CHANGE_LOG_RELAY_INTERVAL")
+	v.BindEnv("trash.retention", "TRASH_RETENTION")
+	v.BindEnv("trash.purge_interval", "TRASH_PURGE_INTERVAL")
+	v.BindEnv("jobs.workers", "JOB_WORKERS")
+	v.BindEnv("jobs.debounce", "INSIGHTS_DEBOUNCE")
+	v.BindEnv("jobs.max_wait", "INSIGHTS_MAX_WAIT")
 
 	// Logging environment variables (TRENDY_ prefix via AutomaticEnv)
 	// TRENDY_LOGGING_LEVEL, TRENDY_LOGGING_FORMAT, TRENDY_LOGGING_LOG_BODIES, TRENDY_LOGGING_ADD_SOURCE
@@ -86,6 +196,10 @@ func Load() (*Config, error) {
 		return nil, fmt.Errorf("unable to decode config: %w", err)
 	}
 
+	for _, override := range overrides {
+		override(&config)
+	}
+
 	// Validate required fields
 	if err := config.Validate(); err != nil {
 		return nil, err
@@ -96,6 +210,48 @@ func Load() (*Config, error) {
 
 // Validate checks that all required configuration values are present
 func (c *Config) Validate() error {
+	if c.Sync.ChangeLogRetention < 0 || c.Sync.CompactionInterval < 0 || c.Sync.RelayInterval < 0 {
+		return fmt.Errorf("sync durations must not be negative")
+	}
+	if c.Trash.Retention < 0 || c.Trash.PurgeInterval < 0 {
+		return fmt.Errorf("trash durations must not be negative")
+	}
+	if c.Jobs.Workers < 0 || c.Jobs.MaxAttempts < 1 {
+		return fmt.Errorf("jobs need a non-negative worker count and at least one attempt")
+	}
+	if c.Jobs.Workers > 0 && (c.Jobs.PollInterval <= 0 || c.Jobs.Timeout <= 0) {
+		return fmt.Errorf("jobs poll interval and timeout must be positive")
+	}
+	if c.Jobs.Debounce < 0 || c.Jobs.MaxWait < 0 || c.Jobs.RetryBackoff < 0 || c.Jobs.Retention < 0 {
+		return fmt.Errorf("jobs durations must not be negative")
+	}
+	if c.Jobs.MaintenanceHour < 0 || c.Jobs.MaintenanceHour > 23 {
+		return fmt.Errorf("jobs maintenance hour must be from 0 to 23")
+	}
+
+	switch c.Storage.Backend {
+	case StorageMemory:
+		// The memory backend runs without any external services
+		if c.IsProduction() {
+			return fmt.Errorf("the memory storage backend cannot be used in production")
+		}
+		return nil
+	case StorageSupabase:
+	case StoragePostgres:
+		if c.Storage.DatabaseURL == "" {
+			return fmt.Errorf("DATABASE_URL is required for the postgres storage backend")
+		}
+		// Self-hosted without Supabase: tokens are verified locally
+		if !c.HasSupabase() && c.Supabase.ServiceKey == "" {
+			if c.Auth.JWTSecret == "" {
+				return fmt.Errorf("JWT_SECRET, or SUPABASE_URL and SUPABASE_SERVICE_KEY, are required for the postgres storage backend")
+			}
+			return nil
+		}
+	default:
+		return fmt.Errorf("unknown storage backend %q (expected supabase, postgres or memory)", c.Storage.Backend)
+	}
+
 	if c.Supabase.URL == "" {
 		return fmt.Errorf("SUPABASE_URL is required")
 	}
@@ -126,6 +282,11 @@ func (c *Config) LogLevelForEnv() string {
 	}
 }
 
+// HasSupabase reports whether a Supabase project is configured
+func (c *Config) HasSupabase() bool {
+	return c.Supabase.URL != ""
+}
+
 // IsProduction returns true if the server is running in production mode
 func (c *Config) IsProduction() bool {
 	return c.Server.Env == "production"
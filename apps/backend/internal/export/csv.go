@@ -0,0 +1,181 @@
+package export
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/JonnyWalker81/trendy/backend/internal/models"
+)
+
+// csvBaseHeader holds the columns every CSV export starts with
+var csvBaseHeader = []string{
+	"id",
+	"timestamp",
+	"end_date",
+	"is_all_day",
+	"event_type_id",
+	"event_type",
+	"notes",
+	"source_type",
+	"location_name",
+	"location_latitude",
+	"location_longitude",
+}
+
+// csvOtherProperties is the last column, holding the properties without a
+// definition as a JSON object
+const csvOtherProperties = "other_properties"
+
+// csvEncoder writes one row per event with its properties flattened into
+// columns
+type csvEncoder struct {
+	w       *csv.Writer
+	columns []Column
+	known   map[string]bool
+}
+
+func newCSVEncoder(w io.Writer, columns []Column) (*csvEncoder, error) {
+	e := &csvEncoder{
+		w:       csv.NewWriter(w),
+		columns: columns,
+		known:   make(map[string]bool, len(columns)),
+	}
+
+	header := append([]string(nil), csvBaseHeader...)
+	for _, col := range columns {
+		header = append(header, col.Header)
+		e.known[col.Key] = true
+	}
+	header = append(header, csvOtherProperties)
+
+	if err := e.w.Write(header); err != nil {
+		return nil, err
+	}
+	return e, nil
+}
+
+func (e *csvEncoder) Encode(event *models.Event) error {
+	record := make([]string, 0, len(csvBaseHeader)+len(e.columns)+1)
+
+	var eventTypeName string
+	if event.EventType != nil {
+		eventTypeName = event.EventType.Name
+	}
+	record = append(record,
+		event.ID,
+		event.Timestamp.UTC().Format(time.RFC3339),
+		formatTime(event.EndDate),
+		strconv.FormatBool(event.IsAllDay),
+		event.EventTypeID,
+		eventTypeName,
+		stringValue(event.Notes),
+		event.SourceType,
+		stringValue(event.LocationName),
+		floatValue(event.LocationLatitude),
+		floatValue(event.LocationLongitude),
+	)
+
+	for _, col := range e.columns {
+		value, ok := event.Properties[col.Key]
+		if !ok {
+			record = append(record, "")
+			continue
+		}
+		record = append(record, formatPropertyValue(value, col.Unit))
+	}
+
+	other := make(map[string]models.PropertyValue)
+	for key, value := range event.Properties {
+		if !e.known[key] {
+			other[key] = value
+		}
+	}
+	if len(other) > 0 {
+		data, err := json.Marshal(other)
+		if err != nil {
+			return err
+		}
+		record = append(record, string(data))
+	} else {
+		record = append(record, "")
+	}
+
+	return e.w.Write(record)
+}
+
+func (e *csvEncoder) Close() error {
+	e.w.Flush()
+	return e.w.Error()
+}
+
+// formatPropertyValue renders a property value as a CSV cell. Multi-select
+// options are joined with "; ", quantities are written in the column's unit,
+// e.g. "72.5 kg", and locations as "47.6, -122.3 (Seattle)", forms an import
+// reads back.
+func formatPropertyValue(value models.PropertyValue, unit string) string {
+	switch value.Type {
+	case models.PropertyTypeMultiSelect:
+		if values, ok := models.ParseMultiSelect(value.Value); ok {
+			return strings.Join(values, "; ")
+		}
+	case models.PropertyTypeQuantity:
+		if q, ok := models.ParseQuantity(value.Value); ok {
+			if unit != "" {
+				if converted, err := models.ConvertQuantity(q, unit); err == nil {
+					q = converted
+				}
+			}
+			return q.String()
+		}
+	case models.PropertyTypeLocation:
+		if l, ok := models.ParseLocation(value.Value); ok {
+			return l.String()
+		}
+	}
+	return formatValue(value.Value)
+}
+
+// formatValue renders a property value as a CSV cell
+func formatValue(v interface{}) string {
+	switch v := v.(type) {
+	case nil:
+		return ""
+	case string:
+		return v
+	case float64:
+		return strconv.FormatFloat(v, 'f', -1, 64)
+	case bool:
+		return strconv.FormatBool(v)
+	default:
+		data, err := json.Marshal(v)
+		if err != nil {
+			return ""
+		}
+		return string(data)
+	}
+}
+
+func formatTime(t *time.Time) string {
+	if t == nil {
+		return ""
+	}
+	return t.UTC().Format(time.RFC3339)
+}
+
+func stringValue(s *string) string {
+	if s == nil {
+		return ""
+	}
+	return *s
+}
+
+func floatValue(f *float64) string {
+	if f == nil {
+		return ""
+	}
+	return strconv.FormatFloat(*f, 'f', -1, 64)
+}
@@ -0,0 +1,119 @@
+// Package export encodes events as JSON, NDJSON, CSV or iCalendar.
+//
+// Encoders write each event as it is passed in, so an export can be streamed
+// page by page without holding the whole result in memory.
+package export
+
+import (
+	"fmt"
+	"io"
+
+	"github.com/JonnyWalker81/trendy/backend/internal/models"
+)
+
+// Encoder writes events in one export format
+type Encoder interface {
+	// Encode writes one event
+	Encode(event *models.Event) error
+	// Close writes any trailer and flushes buffered output. It does not close
+	// the underlying writer.
+	Close() error
+}
+
+// Column is a property column of a CSV export
+type Column struct {
+	Key    string
+	Header string
+	Unit   string // Unit quantities are written in
+}
+
+// NewEncoder returns an encoder writing format to w. Columns lists the
+// property columns of a CSV export and is ignored by other formats.
+func NewEncoder(format models.ExportFormat, w io.Writer, columns []Column) (Encoder, error) {
+	switch format {
+	case models.ExportFormatJSON, "":
+		return newJSONEncoder(w)
+	case models.ExportFormatNDJSON:
+		return newNDJSONEncoder(w), nil
+	case models.ExportFormatCSV:
+		return newCSVEncoder(w, columns)
+	case models.ExportFormatICS:
+		return newICSEncoder(w)
+	default:
+		return nil, fmt.Errorf("unsupported export format %q", format)
+	}
+}
+
+// ParseFormat validates an export format, defaulting to JSON
+func ParseFormat(s string) (models.ExportFormat, error) {
+	switch format := models.ExportFormat(s); format {
+	case "":
+		return models.ExportFormatJSON, nil
+	case models.ExportFormatJSON, models.ExportFormatNDJSON, models.ExportFormatCSV, models.ExportFormatICS:
+		return format, nil
+	default:
+		return "", fmt.Errorf("unsupported export format %q (expected json, ndjson, csv or ics)", s)
+	}
+}
+
+// FormatForMediaType maps an Accept header media type to an export format
+func FormatForMediaType(mediaType string) (models.ExportFormat, bool) {
+	switch mediaType {
+	case "application/json":
+		return models.ExportFormatJSON, true
+	case "application/x-ndjson", "application/ndjson":
+		return models.ExportFormatNDJSON, true
+	case "text/csv":
+		return models.ExportFormatCSV, true
+	case "text/calendar":
+		return models.ExportFormatICS, true
+	default:
+		return "", false
+	}
+}
+
+// ContentType returns the Content-Type header for an export format
+func ContentType(format models.ExportFormat) string {
+	switch format {
+	case models.ExportFormatNDJSON:
+		return "application/x-ndjson"
+	case models.ExportFormatCSV:
+		return "text/csv; charset=utf-8"
+	case models.ExportFormatICS:
+		return "text/calendar; charset=utf-8"
+	default:
+		return "application/json; charset=utf-8"
+	}
+}
+
+// PropertyColumns returns one CSV column per distinct property key, headed by
+// the label of the first definition with that key. Labels shared by different
+// keys are disambiguated with the key.
+func PropertyColumns(defs []models.PropertyDefinition) []Column {
+	seenKeys := make(map[string]bool, len(defs))
+	labelKeys := make(map[string]int, len(defs))
+	for _, def := range defs {
+		if !seenKeys[def.Key] {
+			seenKeys[def.Key] = true
+			labelKeys[def.Label]++
+		}
+	}
+
+	columns := make([]Column, 0, len(seenKeys))
+	seenKeys = make(map[string]bool, len(defs))
+	for _, def := range defs {
+		if seenKeys[def.Key] {
+			continue
+		}
+		seenKeys[def.Key] = true
+
+		header := def.Label
+		if header == "" {
+			header = def.Key
+		} else if labelKeys[def.Label] > 1 {
+			header = fmt.Sprintf("%s (%s)", def.Label, def.Key)
+		}
+		columns = append(columns, Column{Key: def.Key, Header: header, Unit: def.Unit})
+	}
+	return columns
+}
@@ -0,0 +1,165 @@
+package export
+
+import (
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/JonnyWalker81/trendy/backend/internal/models"
+)
+
+func encodeAll(t *testing.T, format models.ExportFormat, columns []Column, events ...models.Event) string {
+	t.Helper()
+	var buf bytes.Buffer
+	enc, err := NewEncoder(format, &buf, columns)
+	if err != nil {
+		t.Fatalf("NewEncoder failed: %v", err)
+	}
+	for i := range events {
+		if err := enc.Encode(&events[i]); err != nil {
+			t.Fatalf("Encode failed: %v", err)
+		}
+	}
+	if err := enc.Close(); err != nil {
+		t.Fatalf("Close failed: %v", err)
+	}
+	return buf.String()
+}
+
+func TestCSVFlattensProperties(t *testing.T) {
+	columns := PropertyColumns([]models.PropertyDefinition{
+		{Key: "distance", Label: "Distance"},
+		{Key: "km", Label: "Distance"},
+		{Key: "distance", Label: "Run distance"},
+	})
+	if len(columns) != 2 || columns[0].Header != "Distance (distance)" || columns[1].Header != "Distance (km)" {
+		t.Fatalf("unexpected columns %+v", columns)
+	}
+
+	notes := "felt good, \"fast\""
+	out := encodeAll(t, models.ExportFormatCSV, columns, models.Event{
+		ID:          "e1",
+		EventTypeID: "t1",
+		EventType:   &models.EventType{Name: "Run"},
+		Timestamp:   time.Date(2026, 1, 2, 8, 0, 0, 0, time.UTC),
+		Notes:       &notes,
+		Properties: map[string]models.PropertyValue{
+			"distance": {Type: models.PropertyTypeNumber, Value: 5.5},
+			"mood":     {Type: models.PropertyTypeText, Value: "ok"},
+		},
+	})
+
+	records, err := csv.NewReader(strings.NewReader(out)).ReadAll()
+	if err != nil {
+		t.Fatalf("output is not valid CSV: %v", err)
+	}
+	if len(records) != 2 {
+		t.Fatalf("expected header and 1 row, got %d records", len(records))
+	}
+	header, row := records[0], records[1]
+	cell := func(name string) string {
+		for i, h := range header {
+			if h == name {
+				return row[i]
+			}
+		}
+		t.Fatalf("missing column %q", name)
+		return ""
+	}
+
+	if cell("event_type") != "Run" || cell("notes") != notes || cell("timestamp") != "2026-01-02T08:00:00Z" {
+		t.Errorf("unexpected base columns %v", row)
+	}
+	if cell("Distance (distance)") != "5.5" || cell("Distance (km)") != "" {
+		t.Errorf("unexpected property columns %v", row)
+	}
+	if cell("other_properties") != `{"mood":{"type":"text","value":"ok"}}` {
+		t.Errorf("unexpected other_properties %q", cell("other_properties"))
+	}
+}
+
+func TestCSVFormatsStructuredProperties(t *testing.T) {
+	columns := PropertyColumns([]models.PropertyDefinition{
+		{Key: "tags", Label: "Tags", PropertyType: models.PropertyTypeMultiSelect},
+		{Key: "weight", Label: "Weight", PropertyType: models.PropertyTypeQuantity, Unit: "lb"},
+		{Key: "where", Label: "Where", PropertyType: models.PropertyTypeLocation},
+	})
+	out := encodeAll(t, models.ExportFormatCSV, columns, models.Event{
+		ID:        "e1",
+		Timestamp: time.Date(2026, 1, 2, 8, 0, 0, 0, time.UTC),
+		Properties: map[string]models.PropertyValue{
+			"tags":   {Type: models.PropertyTypeMultiSelect, Value: []interface{}{"easy", "outdoor"}},
+			"weight": {Type: models.PropertyTypeQuantity, Value: map[string]interface{}{"amount": 1.0, "unit": "kg"}},
+			"where":  {Type: models.PropertyTypeLocation, Value: map[string]interface{}{"latitude": 47.6, "longitude": -122.3, "name": "Seattle"}},
+		},
+	})
+
+	records, err := csv.NewReader(strings.NewReader(out)).ReadAll()
+	if err != nil {
+		t.Fatalf("output is not valid CSV: %v", err)
+	}
+	row := records[1][len(csvBaseHeader):]
+	if row[0] != "easy; outdoor" || row[1] != "2.204622622 lb" || row[2] != "47.6, -122.3 (Seattle)" {
+		t.Errorf("unexpected property columns %q", row)
+	}
+}
+
+func TestICSMapsAllDayAndEndDate(t *testing.T) {
+	start := time.Date(2026, 3, 1, 0, 0, 0, 0, time.UTC)
+	end := time.Date(2026, 3, 3, 0, 0, 0, 0, time.UTC)
+	timedEnd := time.Date(2026, 3, 5, 10, 30, 0, 0, time.UTC)
+	notes := strings.Repeat("long note; ", 10)
+
+	out := encodeAll(t, models.ExportFormatICS, nil,
+		models.Event{ID: "trip", Timestamp: start, EndDate: &end, IsAllDay: true, EventType: &models.EventType{Name: "Trip"}},
+		models.Event{ID: "run", Timestamp: time.Date(2026, 3, 5, 9, 0, 0, 0, time.UTC), EndDate: &timedEnd, Notes: &notes},
+	)
+
+	for _, want := range []string{
+		"BEGIN:VCALENDAR\r\n",
+		"DTSTART;VALUE=DATE:20260301\r\n",
+		"DTEND;VALUE=DATE:20260304\r\n", // Exclusive end
+		"SUMMARY:Trip\r\n",
+		"DTSTART:20260305T090000Z\r\n",
+		"DTEND:20260305T103000Z\r\n",
+		"SUMMARY:Event\r\n",
+		"END:VCALENDAR\r\n",
+	} {
+		if !strings.Contains(out, want) {
+			t.Errorf("expected %q in output", want)
+		}
+	}
+
+	for _, line := range strings.Split(out, "\r\n") {
+		if len(line) > icsLineLimit {
+			t.Errorf("line exceeds %d octets: %q", icsLineLimit, line)
+		}
+	}
+	unfolded := strings.ReplaceAll(out, "\r\n ", "")
+	if !strings.Contains(unfolded, "DESCRIPTION:"+strings.Repeat(`long note\; `, 10)) {
+		t.Error("expected folded description to unfold to the escaped notes")
+	}
+}
+
+func TestJSONEncodersMatchEventShape(t *testing.T) {
+	events := []models.Event{{ID: "a"}, {ID: "b"}}
+
+	var decoded []models.Event
+	if err := json.Unmarshal([]byte(encodeAll(t, models.ExportFormatJSON, nil, events...)), &decoded); err != nil {
+		t.Fatalf("JSON output is not an array of events: %v", err)
+	}
+	if len(decoded) != 2 || decoded[1].ID != "b" {
+		t.Errorf("unexpected JSON output %+v", decoded)
+	}
+	if out := encodeAll(t, models.ExportFormatJSON, nil); out != "[]" {
+		t.Errorf("expected empty array, got %q", out)
+	}
+
+	lines := strings.Split(strings.TrimSpace(encodeAll(t, models.ExportFormatNDJSON, nil, events...)), "\n")
+	if len(lines) != 2 {
+		t.Fatalf("expected 2 NDJSON lines, got %d", len(lines))
+	}
+}
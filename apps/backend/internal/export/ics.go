@@ -0,0 +1,122 @@
+package export
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/JonnyWalker81/trendy/backend/internal/models"
+)
+
+const (
+	icsDateTime = "20060102T150405Z"
+	icsDate     = "20060102"
+	// icsLineLimit is the longest content line in octets before folding (RFC 5545 3.1)
+	icsLineLimit = 75
+)
+
+// icsEncoder writes an iCalendar file with one VEVENT per event. All-day
+// events become date-valued VEVENTs; timed events without an end date are
+// zero-length.
+type icsEncoder struct {
+	w *bufio.Writer
+}
+
+func newICSEncoder(w io.Writer) (*icsEncoder, error) {
+	e := &icsEncoder{w: bufio.NewWriter(w)}
+	e.line("BEGIN:VCALENDAR")
+	e.line("VERSION:2.0")
+	e.line("PRODID:-//Trendy//Event Export//EN")
+	e.line("CALSCALE:GREGORIAN")
+	return e, nil
+}
+
+func (e *icsEncoder) Encode(event *models.Event) error {
+	summary := "Event"
+	if event.EventType != nil && event.EventType.Name != "" {
+		summary = event.EventType.Name
+	}
+
+	e.line("BEGIN:VEVENT")
+	e.line("UID:" + event.ID + "@trendy")
+	e.line("DTSTAMP:" + event.UpdatedAt.UTC().Format(icsDateTime))
+
+	if event.IsAllDay {
+		// DTEND is exclusive for date values
+		start := event.Timestamp.UTC()
+		end := start
+		if event.EndDate != nil && event.EndDate.After(start) {
+			end = event.EndDate.UTC()
+		}
+		e.line("DTSTART;VALUE=DATE:" + start.Format(icsDate))
+		e.line("DTEND;VALUE=DATE:" + end.AddDate(0, 0, 1).Format(icsDate))
+	} else {
+		e.line("DTSTART:" + event.Timestamp.UTC().Format(icsDateTime))
+		if event.EndDate != nil && event.EndDate.After(event.Timestamp) {
+			e.line("DTEND:" + event.EndDate.UTC().Format(icsDateTime))
+		}
+	}
+
+	e.line("SUMMARY:" + escapeText(summary))
+	if event.Notes != nil && *event.Notes != "" {
+		e.line("DESCRIPTION:" + escapeText(*event.Notes))
+	}
+	if event.LocationName != nil && *event.LocationName != "" {
+		e.line("LOCATION:" + escapeText(*event.LocationName))
+	}
+	if event.LocationLatitude != nil && event.LocationLongitude != nil {
+		e.line(fmt.Sprintf("GEO:%f;%f", *event.LocationLatitude, *event.LocationLongitude))
+	}
+	e.line("CREATED:" + event.CreatedAt.UTC().Format(icsDateTime))
+	e.line("LAST-MODIFIED:" + event.UpdatedAt.UTC().Format(icsDateTime))
+	e.line("END:VEVENT")
+
+	return e.flushIfFull()
+}
+
+func (e *icsEncoder) Close() error {
+	e.line("END:VCALENDAR")
+	return e.w.Flush()
+}
+
+// line writes a content line, folding it at the octet limit without
+// splitting a UTF-8 sequence. Write errors surface on the next flush.
+func (e *icsEncoder) line(s string) {
+	limit := icsLineLimit
+	for len(s) > limit {
+		cut := limit
+		for !utf8.RuneStart(s[cut]) {
+			cut--
+		}
+		e.w.WriteString(s[:cut])
+		e.w.WriteString("\r\n ")
+		s = s[cut:]
+		// The leading space of a continuation line counts towards its limit
+		limit = icsLineLimit - 1
+	}
+	e.w.WriteString(s)
+	e.w.WriteString("\r\n")
+}
+
+// flushIfFull surfaces write errors once the buffer has been written out
+func (e *icsEncoder) flushIfFull() error {
+	if e.w.Buffered() >= e.w.Size()/2 {
+		return e.w.Flush()
+	}
+	return nil
+}
+
+// icsTextEscaper escapes a TEXT property value (RFC 5545 3.3.11)
+var icsTextEscaper = strings.NewReplacer(
+	`\`, `\\`,
+	";", `\;`,
+	",", `\,`,
+	"\r\n", `\n`,
+	"\n", `\n`,
+)
+
+func escapeText(s string) string {
+	return icsTextEscaper.Replace(s)
+}
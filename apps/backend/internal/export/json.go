@@ -0,0 +1,58 @@
+package export
+
+import (
+	"encoding/json"
+	"io"
+
+	"github.com/JonnyWalker81/trendy/backend/internal/models"
+)
+
+// jsonEncoder writes a JSON array, one element per event
+type jsonEncoder struct {
+	w     io.Writer
+	first bool
+}
+
+func newJSONEncoder(w io.Writer) (*jsonEncoder, error) {
+	if _, err := io.WriteString(w, "["); err != nil {
+		return nil, err
+	}
+	return &jsonEncoder{w: w, first: true}, nil
+}
+
+func (e *jsonEncoder) Encode(event *models.Event) error {
+	data, err := json.Marshal(event)
+	if err != nil {
+		return err
+	}
+	if !e.first {
+		if _, err := io.WriteString(e.w, ","); err != nil {
+			return err
+		}
+	}
+	e.first = false
+	_, err = e.w.Write(data)
+	return err
+}
+
+func (e *jsonEncoder) Close() error {
+	_, err := io.WriteString(e.w, "]")
+	return err
+}
+
+// ndjsonEncoder writes one JSON object per line
+type ndjsonEncoder struct {
+	enc *json.Encoder
+}
+
+func newNDJSONEncoder(w io.Writer) *ndjsonEncoder {
+	return &ndjsonEncoder{enc: json.NewEncoder(w)}
+}
+
+func (e *ndjsonEncoder) Encode(event *models.Event) error {
+	return e.enc.Encode(event)
+}
+
+func (e *ndjsonEncoder) Close() error {
+	return nil
+}
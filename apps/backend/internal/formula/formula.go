@@ -0,0 +1,503 @@
+// Package formula parses and evaluates the arithmetic expressions of computed
+// properties, such as "distance / (duration / 3600)".
+//
+// A formula combines numbers and names with + - * / % ^ and parentheses, and
+// calls the functions abs, sqrt, floor, ceil, round(x[, digits]), min, max
+// and coalesce. A name is a run of letters, digits and underscores, or any
+// text in square brackets, e.g. [heart rate]. What a name stands for is up to
+// the caller's Env.
+//
+// Evaluation has no value when a name has none, when dividing by zero or when
+// the result is not finite; coalesce returns its first argument with a value.
+package formula
+
+import (
+	"fmt"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+const (
+	// MaxLength bounds the source of a formula
+	MaxLength = 500
+	// maxDepth bounds the nesting of a formula, so evaluation cannot exhaust
+	// the stack
+	maxDepth = 32
+)
+
+// Env returns the value of a name, or false if it has none
+type Env func(name string) (float64, bool)
+
+// Expr is a parsed formula
+type Expr struct {
+	src  string
+	root node
+}
+
+// Parse parses a formula
+func Parse(src string) (*Expr, error) {
+	if len(src) > MaxLength {
+		return nil, fmt.Errorf("formula must be at most %d characters", MaxLength)
+	}
+	tokens, err := lex(src)
+	if err != nil {
+		return nil, err
+	}
+	p := &parser{tokens: tokens}
+	root, err := p.expr(0)
+	if err != nil {
+		return nil, err
+	}
+	if t := p.peek(); t.kind != tokenEOF {
+		return nil, fmt.Errorf("unexpected %q at position %d", t.text, t.pos+1)
+	}
+	return &Expr{src: src, root: root}, nil
+}
+
+func (e *Expr) String() string {
+	return e.src
+}
+
+// Names returns the names a formula uses, sorted
+func (e *Expr) Names() []string {
+	seen := make(map[string]bool)
+	var walk func(n node)
+	walk = func(n node) {
+		switch n := n.(type) {
+		case nameNode:
+			seen[string(n)] = true
+		case unaryNode:
+			walk(n.x)
+		case binaryNode:
+			walk(n.left)
+			walk(n.right)
+		case callNode:
+			for _, arg := range n.args {
+				walk(arg)
+			}
+		}
+	}
+	walk(e.root)
+
+	names := make([]string, 0, len(seen))
+	for name := range seen {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// Eval evaluates a formula. It returns false if the result has no value.
+func (e *Expr) Eval(env Env) (float64, bool) {
+	v, ok := e.root.eval(env)
+	if !ok || math.IsNaN(v) || math.IsInf(v, 0) {
+		return 0, false
+	}
+	return v, true
+}
+
+// Rename returns src with the names in renames replaced, keeping everything
+// else as written
+func Rename(src string, renames map[string]string) (string, error) {
+	tokens, err := lex(src)
+	if err != nil {
+		return "", err
+	}
+
+	var b strings.Builder
+	pos := 0
+	for i, t := range tokens {
+		if t.kind != tokenName {
+			continue
+		}
+		if !t.quoted && i+1 < len(tokens) && tokens[i+1].kind == tokenOp && tokens[i+1].text == "(" {
+			continue // A function call
+		}
+		to, ok := renames[t.text]
+		if !ok {
+			continue
+		}
+		b.WriteString(src[pos:t.pos])
+		b.WriteString(formatName(to))
+		pos = t.end
+	}
+	b.WriteString(src[pos:])
+	return b.String(), nil
+}
+
+// formatName writes a name so it lexes back as one name
+func formatName(name string) string {
+	for i, r := range name {
+		if !(r == '_' || unicode.IsLetter(r) || (i > 0 && unicode.IsDigit(r))) {
+			return "[" + name + "]"
+		}
+	}
+	return name
+}
+
+// =============================================================================
+// Lexer
+// =============================================================================
+
+type tokenKind int
+
+const (
+	tokenEOF tokenKind = iota
+	tokenNumber
+	tokenName
+	tokenOp
+)
+
+type token struct {
+	kind     tokenKind
+	text     string // For a bracketed name, the name without brackets
+	quoted   bool   // A bracketed name, which is never a function
+	pos, end int    // Byte offsets in the source
+}
+
+func lex(src string) ([]token, error) {
+	var tokens []token
+	for i := 0; i < len(src); {
+		c := src[i]
+		switch {
+		case c == ' ' || c == '\t' || c == '\n' || c == '\r':
+			i++
+		case c >= '0' && c <= '9' || c == '.':
+			start := i
+			for i < len(src) && (src[i] >= '0' && src[i] <= '9' || src[i] == '.') {
+				i++
+			}
+			tokens = append(tokens, token{kind: tokenNumber, text: src[start:i], pos: start, end: i})
+		case c == '_' || c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z':
+			start := i
+			for i < len(src) && (src[i] == '_' || src[i] >= 'a' && src[i] <= 'z' || src[i] >= 'A' && src[i] <= 'Z' || src[i] >= '0' && src[i] <= '9') {
+				i++
+			}
+			tokens = append(tokens, token{kind: tokenName, text: src[start:i], pos: start, end: i})
+		case c == '[':
+			end := strings.IndexByte(src[i:], ']')
+			if end < 0 {
+				return nil, fmt.Errorf("unclosed [ at position %d", i+1)
+			}
+			name := strings.TrimSpace(src[i+1 : i+end])
+			if name == "" {
+				return nil, fmt.Errorf("empty name at position %d", i+1)
+			}
+			tokens = append(tokens, token{kind: tokenName, text: name, quoted: true, pos: i, end: i + end + 1})
+			i += end + 1
+		case strings.IndexByte("+-*/%^(),", c) >= 0:
+			tokens = append(tokens, token{kind: tokenOp, text: string(c), pos: i, end: i + 1})
+			i++
+		default:
+			return nil, fmt.Errorf("unexpected %q at position %d", src[i:i+1], i+1)
+		}
+	}
+	return append(tokens, token{kind: tokenEOF, text: "end of formula", pos: len(src), end: len(src)}), nil
+}
+
+// =============================================================================
+// Parser
+// =============================================================================
+
+type parser struct {
+	tokens []token
+	pos    int
+}
+
+func (p *parser) peek() token {
+	return p.tokens[p.pos]
+}
+
+func (p *parser) next() token {
+	t := p.tokens[p.pos]
+	if t.kind != tokenEOF {
+		p.pos++
+	}
+	return t
+}
+
+func (p *parser) expect(op string) error {
+	if t := p.next(); t.text != op || t.kind != tokenOp {
+		return fmt.Errorf("expected %q at position %d, found %q", op, t.pos+1, t.text)
+	}
+	return nil
+}
+
+// expr parses a sum: term (("+" | "-") term)*
+func (p *parser) expr(depth int) (node, error) {
+	if depth > maxDepth {
+		return nil, fmt.Errorf("formula is nested too deeply")
+	}
+	left, err := p.term(depth)
+	if err != nil {
+		return nil, err
+	}
+	for t := p.peek(); t.kind == tokenOp && (t.text == "+" || t.text == "-"); t = p.peek() {
+		p.next()
+		right, err := p.term(depth)
+		if err != nil {
+			return nil, err
+		}
+		left = binaryNode{op: t.text[0], left: left, right: right}
+	}
+	return left, nil
+}
+
+// term parses a product: unary (("*" | "/" | "%") unary)*
+func (p *parser) term(depth int) (node, error) {
+	left, err := p.unary(depth)
+	if err != nil {
+		return nil, err
+	}
+	for t := p.peek(); t.kind == tokenOp && (t.text == "*" || t.text == "/" || t.text == "%"); t = p.peek() {
+		p.next()
+		right, err := p.unary(depth)
+		if err != nil {
+			return nil, err
+		}
+		left = binaryNode{op: t.text[0], left: left, right: right}
+	}
+	return left, nil
+}
+
+// unary parses "-" unary, or a power: primary ("^" unary)?
+func (p *parser) unary(depth int) (node, error) {
+	if depth > maxDepth {
+		return nil, fmt.Errorf("formula is nested too deeply")
+	}
+	if t := p.peek(); t.kind == tokenOp && t.text == "-" {
+		p.next()
+		x, err := p.unary(depth + 1)
+		if err != nil {
+			return nil, err
+		}
+		return unaryNode{x: x}, nil
+	}
+
+	base, err := p.primary(depth)
+	if err != nil {
+		return nil, err
+	}
+	if t := p.peek(); t.kind == tokenOp && t.text == "^" {
+		p.next()
+		exp, err := p.unary(depth + 1)
+		if err != nil {
+			return nil, err
+		}
+		return binaryNode{op: '^', left: base, right: exp}, nil
+	}
+	return base, nil
+}
+
+// primary parses a number, a name, a function call or a parenthesized sum
+func (p *parser) primary(depth int) (node, error) {
+	t := p.next()
+	switch t.kind {
+	case tokenNumber:
+		v, err := strconv.ParseFloat(t.text, 64)
+		if err != nil {
+			return nil, fmt.Errorf("invalid number %q at position %d", t.text, t.pos+1)
+		}
+		return numberNode(v), nil
+	case tokenName:
+		if next := p.peek(); !t.quoted && next.kind == tokenOp && next.text == "(" {
+			return p.call(t, depth)
+		}
+		return nameNode(t.text), nil
+	case tokenOp:
+		if t.text == "(" {
+			x, err := p.expr(depth + 1)
+			if err != nil {
+				return nil, err
+			}
+			if err := p.expect(")"); err != nil {
+				return nil, err
+			}
+			return x, nil
+		}
+	}
+	return nil, fmt.Errorf("unexpected %q at position %d", t.text, t.pos+1)
+}
+
+// call parses the arguments of a function call
+func (p *parser) call(name token, depth int) (node, error) {
+	fn, ok := functions[strings.ToLower(name.text)]
+	if !ok {
+		return nil, fmt.Errorf("unknown function %q at position %d", name.text, name.pos+1)
+	}
+	p.next() // "("
+
+	var args []node
+	if t := p.peek(); !(t.kind == tokenOp && t.text == ")") {
+		for {
+			arg, err := p.expr(depth + 1)
+			if err != nil {
+				return nil, err
+			}
+			args = append(args, arg)
+			if t := p.peek(); t.kind == tokenOp && t.text == "," {
+				p.next()
+				continue
+			}
+			break
+		}
+	}
+	if err := p.expect(")"); err != nil {
+		return nil, err
+	}
+
+	if len(args) < fn.minArgs || (fn.maxArgs > 0 && len(args) > fn.maxArgs) {
+		return nil, fmt.Errorf("%s takes %s", strings.ToLower(name.text), fn.arity())
+	}
+	return callNode{fn: fn, args: args}, nil
+}
+
+// =============================================================================
+// Evaluation
+// =============================================================================
+
+type node interface {
+	eval(env Env) (float64, bool)
+}
+
+type numberNode float64
+
+func (n numberNode) eval(Env) (float64, bool) {
+	return float64(n), true
+}
+
+type nameNode string
+
+func (n nameNode) eval(env Env) (float64, bool) {
+	return env(string(n))
+}
+
+type unaryNode struct {
+	x node
+}
+
+func (n unaryNode) eval(env Env) (float64, bool) {
+	v, ok := n.x.eval(env)
+	return -v, ok
+}
+
+type binaryNode struct {
+	op          byte
+	left, right node
+}
+
+func (n binaryNode) eval(env Env) (float64, bool) {
+	a, ok := n.left.eval(env)
+	if !ok {
+		return 0, false
+	}
+	b, ok := n.right.eval(env)
+	if !ok {
+		return 0, false
+	}
+
+	switch n.op {
+	case '+':
+		return a + b, true
+	case '-':
+		return a - b, true
+	case '*':
+		return a * b, true
+	case '/':
+		return a / b, b != 0
+	case '%':
+		return math.Mod(a, b), b != 0
+	case '^':
+		return math.Pow(a, b), true
+	}
+	return 0, false
+}
+
+type callNode struct {
+	fn   function
+	args []node
+}
+
+func (n callNode) eval(env Env) (float64, bool) {
+	return n.fn.eval(env, n.args)
+}
+
+// function is a function a formula can call. maxArgs 0 means any number.
+type function struct {
+	minArgs, maxArgs int
+	eval             func(env Env, args []node) (float64, bool)
+}
+
+func (f function) arity() string {
+	switch {
+	case f.maxArgs == 0:
+		return fmt.Sprintf("at least %d arguments", f.minArgs)
+	case f.minArgs == f.maxArgs && f.minArgs == 1:
+		return "1 argument"
+	case f.minArgs == f.maxArgs:
+		return fmt.Sprintf("%d arguments", f.minArgs)
+	default:
+		return fmt.Sprintf("%d to %d arguments", f.minArgs, f.maxArgs)
+	}
+}
+
+// math1 wraps a function of one number
+func math1(f func(float64) float64) function {
+	return function{minArgs: 1, maxArgs: 1, eval: func(env Env, args []node) (float64, bool) {
+		v, ok := args[0].eval(env)
+		return f(v), ok
+	}}
+}
+
+// fold wraps a function of any number of numbers, all of which need values
+func fold(f func(a, b float64) float64) function {
+	return function{minArgs: 1, eval: func(env Env, args []node) (float64, bool) {
+		result, ok := args[0].eval(env)
+		if !ok {
+			return 0, false
+		}
+		for _, arg := range args[1:] {
+			v, ok := arg.eval(env)
+			if !ok {
+				return 0, false
+			}
+			result = f(result, v)
+		}
+		return result, true
+	}}
+}
+
+var functions = map[string]function{
+	"abs":   math1(math.Abs),
+	"sqrt":  math1(math.Sqrt),
+	"floor": math1(math.Floor),
+	"ceil":  math1(math.Ceil),
+	"min":   fold(math.Min),
+	"max":   fold(math.Max),
+	"round": {minArgs: 1, maxArgs: 2, eval: func(env Env, args []node) (float64, bool) {
+		v, ok := args[0].eval(env)
+		if !ok {
+			return 0, false
+		}
+		scale := 1.0
+		if len(args) == 2 {
+			digits, ok := args[1].eval(env)
+			if !ok {
+				return 0, false
+			}
+			scale = math.Pow(10, math.Trunc(digits))
+		}
+		return math.Round(v*scale) / scale, true
+	}},
+	"coalesce": {minArgs: 1, eval: func(env Env, args []node) (float64, bool) {
+		for _, arg := range args {
+			if v, ok := arg.eval(env); ok {
+				return v, true
+			}
+		}
+		return 0, false
+	}},
+}
@@ -0,0 +1,71 @@
+package formula
+
+import (
+	"math"
+	"slices"
+	"testing"
+)
+
+func TestEval(t *testing.T) {
+	env := Env(func(name string) (float64, bool) {
+		v, ok := map[string]float64{"distance": 10, "duration": 3000, "heart rate": 150, "zero": 0}[name]
+		return v, ok
+	})
+
+	tests := []struct {
+		src    string
+		want   float64
+		wantOK bool
+	}{
+		{"distance / (duration / 3600)", 12, true},
+		{"2 + 3 * 4 ^ 2 - -1", 51, true},
+		{"-2 ^ 2", -4, true},
+		{"round([heart rate] / 7, 2)", 21.43, true},
+		{"max(distance, 4, 12) + min(1, zero)", 12, true},
+		{"distance / zero", 0, false},
+		{"missing + 1", 0, false},
+		{"coalesce(missing, distance)", 10, true},
+		{"sqrt(0 - 1)", 0, false},
+		{"7 % 4", 3, true},
+	}
+	for _, tt := range tests {
+		expr, err := Parse(tt.src)
+		if err != nil {
+			t.Errorf("Parse(%q) failed: %v", tt.src, err)
+			continue
+		}
+		got, ok := expr.Eval(env)
+		if ok != tt.wantOK || (ok && math.Abs(got-tt.want) > 1e-9) {
+			t.Errorf("Eval(%q) = %v, %v; want %v, %v", tt.src, got, ok, tt.want, tt.wantOK)
+		}
+	}
+}
+
+func TestParseErrors(t *testing.T) {
+	for _, src := range []string{"", "1 +", "(1", "1 2", "pow(2, 3)", "abs(1, 2)", "[unclosed", "a $ b", "1..2"} {
+		if _, err := Parse(src); err == nil {
+			t.Errorf("Parse(%q): expected an error", src)
+		}
+	}
+}
+
+func TestNamesAndRename(t *testing.T) {
+	expr, err := Parse("round(pace) + [heart rate] * pace + max(1, round)")
+	if err != nil {
+		t.Fatalf("Parse failed: %v", err)
+	}
+	if got := expr.Names(); !slices.Equal(got, []string{"heart rate", "pace", "round"}) {
+		t.Errorf("unexpected names %v", got)
+	}
+
+	renamed, err := Rename("round(pace) + [heart rate]*pace", map[string]string{"pace": "speed", "heart rate": "hr", "round": "x"})
+	if err != nil {
+		t.Fatalf("Rename failed: %v", err)
+	}
+	if renamed != "round(speed) + hr*speed" {
+		t.Errorf("unexpected rename %q", renamed)
+	}
+	if got := formatName("two words"); got != "[two words]" {
+		t.Errorf("unexpected formatted name %q", got)
+	}
+}
@@ -0,0 +1,153 @@
+package handlers
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+
+	"github.com/JonnyWalker81/trendy/backend/internal/service"
+	"github.com/gin-gonic/gin"
+)
+
+// maxArchiveUploadSize caps the request body of an account archive upload
+const maxArchiveUploadSize = 64 << 20
+
+type AccountHandler struct {
+	accountExportService   service.AccountExportService
+	accountDeletionService service.AccountDeletionService
+	importService          service.ImportService
+}
+
+// NewAccountHandler creates a new account handler
+func NewAccountHandler(accountExportService service.AccountExportService, accountDeletionService service.AccountDeletionService, importService service.ImportService) *AccountHandler {
+	return &AccountHandler{
+		accountExportService:   accountExportService,
+		accountDeletionService: accountDeletionService,
+		importService:          importService,
+	}
+}
+
+// DeleteAccount handles DELETE /api/v1/me
+// Everything the user owns is erased, then the auth user. If the deletion is
+// interrupted, calling it again (or restarting the server) resumes it.
+func (h *AccountHandler) DeleteAccount(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "user not authenticated"})
+		return
+	}
+
+	// A client disconnect must not stop the erasure halfway
+	ctx := context.WithoutCancel(c.Request.Context())
+	deletion, err := h.accountDeletionService.DeleteAccount(ctx, userID.(string))
+	if err != nil {
+		c.Error(err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "account deletion did not complete; retry to resume it"})
+		return
+	}
+
+	c.JSON(http.StatusOK, deletion)
+}
+
+// CreateExport handles POST /api/v1/me/export
+// The archive is built in the background; 202 is returned with the export
+func (h *AccountHandler) CreateExport(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "user not authenticated"})
+		return
+	}
+
+	export, err := h.accountExportService.StartExport(c.Request.Context(), userID.(string))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.Header("Location", "/api/v1/me/exports/"+export.ID)
+	c.JSON(http.StatusAccepted, export)
+}
+
+// GetExport handles GET /api/v1/me/exports/:id
+func (h *AccountHandler) GetExport(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "user not authenticated"})
+		return
+	}
+
+	export, err := h.accountExportService.GetExport(c.Request.Context(), userID.(string), c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "account export not found"})
+		return
+	}
+
+	c.JSON(http.StatusOK, export)
+}
+
+// DownloadExport handles GET /api/v1/me/exports/:id/download
+func (h *AccountHandler) DownloadExport(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "user not authenticated"})
+		return
+	}
+
+	export, archive, err := h.accountExportService.GetArchive(c.Request.Context(), userID.(string), c.Param("id"))
+	if err != nil {
+		switch {
+		case errors.Is(err, service.ErrAccountExportNotReady):
+			c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+		case errors.Is(err, service.ErrAccountExportExpired):
+			c.JSON(http.StatusGone, gin.H{"error": err.Error()})
+		default:
+			c.JSON(http.StatusNotFound, gin.H{"error": "account export not found"})
+		}
+		return
+	}
+
+	filename := "trendy-export-" + export.CompletedAt.UTC().Format("2006-01-02") + ".zip"
+	c.Header("Content-Disposition", `attachment; filename="`+filename+`"`)
+	c.Data(http.StatusOK, "application/zip", archive)
+}
+
+// RestoreArchive handles POST /api/v1/me/import
+// The request is multipart form data with an account archive in "file". The
+// restore runs in the background as an import job; 202 is returned with the
+// job, which can be polled at /api/v1/imports/:id.
+func (h *AccountHandler) RestoreArchive(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "user not authenticated"})
+		return
+	}
+
+	c.Request.Body = http.MaxBytesReader(c.Writer, c.Request.Body, maxArchiveUploadSize)
+	file, _, err := c.Request.FormFile("file")
+	if err != nil {
+		var maxBytesErr *http.MaxBytesError
+		if errors.As(err, &maxBytesErr) {
+			c.JSON(http.StatusRequestEntityTooLarge, gin.H{"error": "archive is too large"})
+			return
+		}
+		c.JSON(http.StatusBadRequest, gin.H{"error": "file is required"})
+		return
+	}
+	defer file.Close()
+
+	archive, err := io.ReadAll(file)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "failed to read archive"})
+		return
+	}
+
+	job, err := h.importService.StartRestore(c.Request.Context(), userID.(string), archive)
+	if err != nil {
+		writeImportError(c, err)
+		return
+	}
+
+	c.Header("Location", "/api/v1/imports/"+job.ID)
+	c.JSON(http.StatusAccepted, job)
+}
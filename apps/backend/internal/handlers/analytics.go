@@ -1,9 +1,14 @@
 package handlers
 
 import (
+	"errors"
+	"fmt"
 	"net/http"
+	"strconv"
+	"strings"
 	"time"
 
+	"github.com/JonnyWalker81/trendy/backend/internal/models"
 	"github.com/JonnyWalker81/trendy/backend/internal/service"
 	"github.com/gin-gonic/gin"
 )
@@ -44,37 +49,15 @@ func (h *AnalyticsHandler) GetTrends(c *gin.Context) {
 		return
 	}
 
-	period := c.DefaultQuery("period", "month")
-	startDateStr := c.Query("start_date")
-	endDateStr := c.Query("end_date")
-
-	var startDate, endDate time.Time
-	var err error
-
-	if startDateStr != "" {
-		startDate, err = time.Parse(time.RFC3339, startDateStr)
-		if err != nil {
-			c.JSON(http.StatusBadRequest, gin.H{"error": "invalid start_date format"})
-			return
-		}
-	} else {
-		// Default to 30 days ago
-		startDate = time.Now().AddDate(0, 0, -30)
-	}
-
-	if endDateStr != "" {
-		endDate, err = time.Parse(time.RFC3339, endDateStr)
-		if err != nil {
-			c.JSON(http.StatusBadRequest, gin.H{"error": "invalid end_date format"})
-			return
-		}
-	} else {
-		endDate = time.Now()
+	query, err := trendQuery(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
 	}
 
-	trends, err := h.analyticsService.GetTrends(c.Request.Context(), userID.(string), period, startDate, endDate)
+	trends, err := h.analyticsService.GetTrends(c.Request.Context(), userID.(string), query, calendarOptions(c))
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		c.JSON(trendErrorStatus(err), gin.H{"error": err.Error()})
 		return
 	}
 
@@ -90,36 +73,101 @@ func (h *AnalyticsHandler) GetEventTypeAnalytics(c *gin.Context) {
 	}
 
 	eventTypeID := c.Param("id")
-	period := c.DefaultQuery("period", "month")
-	startDateStr := c.Query("start_date")
-	endDateStr := c.Query("end_date")
+	query, err := trendQuery(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	analytics, err := h.analyticsService.GetEventTypeAnalytics(c.Request.Context(), userID.(string), eventTypeID, query, calendarOptions(c))
+	if err != nil {
+		c.JSON(trendErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, analytics)
+}
 
-	var startDate, endDate time.Time
-	var err error
+// trendQuery reads the period, granularity, start_date, end_date, property
+// and percentiles query parameters. The range defaults to the last 30 days.
+func trendQuery(c *gin.Context) (*models.TrendQuery, error) {
+	query := &models.TrendQuery{
+		Period:      c.DefaultQuery("period", "month"),
+		Granularity: models.Granularity(c.Query("granularity")),
+		Property:    c.Query("property"),
+		StartDate:   time.Now().AddDate(0, 0, -30),
+		EndDate:     time.Now(),
+	}
 
-	if startDateStr != "" {
-		startDate, err = time.Parse(time.RFC3339, startDateStr)
+	if startDateStr := c.Query("start_date"); startDateStr != "" {
+		startDate, err := time.Parse(time.RFC3339, startDateStr)
 		if err != nil {
-			c.JSON(http.StatusBadRequest, gin.H{"error": "invalid start_date format"})
-			return
+			return nil, fmt.Errorf("invalid start_date format")
 		}
-	} else {
-		startDate = time.Now().AddDate(0, 0, -30)
+		query.StartDate = startDate
 	}
 
-	if endDateStr != "" {
-		endDate, err = time.Parse(time.RFC3339, endDateStr)
+	if endDateStr := c.Query("end_date"); endDateStr != "" {
+		endDate, err := time.Parse(time.RFC3339, endDateStr)
 		if err != nil {
-			c.JSON(http.StatusBadRequest, gin.H{"error": "invalid end_date format"})
+			return nil, fmt.Errorf("invalid end_date format")
+		}
+		query.EndDate = endDate
+	}
+
+	if percentilesStr := c.Query("percentiles"); percentilesStr != "" {
+		for _, part := range strings.Split(percentilesStr, ",") {
+			p, err := strconv.ParseFloat(strings.TrimSpace(part), 64)
+			if err != nil {
+				return nil, fmt.Errorf("invalid percentiles: %q is not a number", part)
+			}
+			query.Percentiles = append(query.Percentiles, p)
+		}
+	}
+
+	return query, nil
+}
+
+// trendErrorStatus returns 400 for an invalid trend query or calendar
+// override and 500 for anything else
+func trendErrorStatus(err error) int {
+	if errors.Is(err, service.ErrInvalidTrendQuery) {
+		return http.StatusBadRequest
+	}
+	return calendarErrorStatus(err)
+}
+
+// GetPropertyAnalytics handles GET /api/v1/analytics/event-type/:id/properties/:key
+func (h *AnalyticsHandler) GetPropertyAnalytics(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "user not authenticated"})
+		return
+	}
+
+	eventTypeID := c.Param("id")
+	key := c.Param("key")
+	query, err := trendQuery(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	bins := 0
+	if binsStr := c.Query("bins"); binsStr != "" {
+		if bins, err = strconv.Atoi(binsStr); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "invalid bins"})
 			return
 		}
-	} else {
-		endDate = time.Now()
 	}
 
-	analytics, err := h.analyticsService.GetEventTypeAnalytics(c.Request.Context(), userID.(string), eventTypeID, period, startDate, endDate)
+	analytics, err := h.analyticsService.GetPropertyAnalytics(c.Request.Context(), userID.(string), eventTypeID, key, query, bins, calendarOptions(c))
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		if strings.Contains(err.Error(), "not found") {
+			c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(trendErrorStatus(err), gin.H{"error": err.Error()})
 		return
 	}
 
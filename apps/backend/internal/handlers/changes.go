@@ -1,22 +1,42 @@
 package handlers
 
 import (
+	"context"
+	"encoding/json"
+	"fmt"
 	"net/http"
 	"strconv"
+	"time"
 
+	"github.com/JonnyWalker81/trendy/backend/internal/apierror"
+	"github.com/JonnyWalker81/trendy/backend/internal/changefeed"
 	"github.com/JonnyWalker81/trendy/backend/internal/logger"
+	"github.com/JonnyWalker81/trendy/backend/internal/models"
 	"github.com/JonnyWalker81/trendy/backend/internal/repository"
 	"github.com/gin-gonic/gin"
+	"github.com/gorilla/websocket"
 )
 
+// streamHeartbeatInterval keeps idle streams open through proxies and lets
+// clients detect dead connections. Each heartbeat also re-reads the change
+// log, which picks up writes made by other API instances.
+const streamHeartbeatInterval = 15 * time.Second
+
+// streamWriteTimeout bounds a single write to a streaming client
+const streamWriteTimeout = 10 * time.Second
+
 type ChangesHandler struct {
-	changeLogRepo repository.ChangeLogRepository
+	changeLogRepo     repository.ChangeLogRepository
+	broker            *changefeed.Broker
+	heartbeatInterval time.Duration
 }
 
 // NewChangesHandler creates a new changes handler
-func NewChangesHandler(changeLogRepo repository.ChangeLogRepository) *ChangesHandler {
+func NewChangesHandler(changeLogRepo repository.ChangeLogRepository, broker *changefeed.Broker) *ChangesHandler {
 	return &ChangesHandler{
-		changeLogRepo: changeLogRepo,
+		changeLogRepo:     changeLogRepo,
+		broker:            broker,
+		heartbeatInterval: streamHeartbeatInterval,
 	}
 }
 
@@ -53,6 +73,9 @@ func (h *ChangesHandler) GetLatestCursor(c *gin.Context) {
 // Query params:
 //   - since: cursor (default 0)
 //   - limit: max results (default 100, max 500)
+//
+// Responds 410 Gone if changes after the cursor have been pruned; the client
+// must bootstrap from GET /api/v1/sync/snapshot.
 func (h *ChangesHandler) GetChanges(c *gin.Context) {
 	log := logger.FromContext(c.Request.Context())
 
@@ -86,6 +109,10 @@ func (h *ChangesHandler) GetChanges(c *gin.Context) {
 		}
 	}
 
+	if !h.checkHorizon(c, userID.(string), cursor) {
+		return
+	}
+
 	log.Debug("fetching changes",
 		logger.String("user_id", userID.(string)),
 		logger.Int64("since", cursor),
@@ -111,3 +138,260 @@ func (h *ChangesHandler) GetChanges(c *gin.Context) {
 
 	c.JSON(http.StatusOK, response)
 }
+
+// StreamChanges handles GET /api/v1/changes/stream
+// Pushes change log entries as they are appended, over Server-Sent Events or,
+// when the request is a WebSocket upgrade, as one JSON message per entry.
+// The stream resumes after the cursor given by (in order of precedence):
+//   - Last-Event-ID header: the id of the last SSE event received
+//   - since: cursor query param, as for GET /api/v1/changes
+//
+// Without either, only changes appended after connecting are sent. An expired
+// cursor gets the same 410 Gone response as GET /api/v1/changes.
+func (h *ChangesHandler) StreamChanges(c *gin.Context) {
+	log := logger.FromContext(c.Request.Context())
+
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "user not authenticated"})
+		return
+	}
+
+	cursor, ok := h.streamCursor(c, userID.(string))
+	if !ok {
+		return
+	}
+
+	log = log.With(logger.String("user_id", userID.(string)))
+	ctx := logger.WithLogger(c.Request.Context(), log)
+
+	if websocket.IsWebSocketUpgrade(c.Request) {
+		h.streamWebSocket(ctx, c, userID.(string), cursor)
+		return
+	}
+	h.streamSSE(ctx, c, userID.(string), cursor)
+}
+
+// streamCursor resolves the resume cursor, writing an error response and
+// returning false if it is invalid
+func (h *ChangesHandler) streamCursor(c *gin.Context, userID string) (int64, bool) {
+	if lastEventID := c.GetHeader("Last-Event-ID"); lastEventID != "" {
+		parsed, err := strconv.ParseInt(lastEventID, 10, 64)
+		if err != nil || parsed < 0 {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "invalid Last-Event-ID header: must be a change cursor"})
+			return 0, false
+		}
+		return parsed, h.checkHorizon(c, userID, parsed)
+	}
+
+	if sinceStr := c.Query("since"); sinceStr != "" {
+		parsed, err := strconv.ParseInt(sinceStr, 10, 64)
+		if err != nil || parsed < 0 {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "invalid 'since' parameter: must be an integer"})
+			return 0, false
+		}
+		return parsed, h.checkHorizon(c, userID, parsed)
+	}
+
+	cursor, err := h.changeLogRepo.GetLatestCursor(c.Request.Context(), userID)
+	if err != nil {
+		logger.FromContext(c.Request.Context()).Error("failed to get latest cursor",
+			logger.Err(err),
+			logger.String("user_id", userID),
+		)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to get latest cursor"})
+		return 0, false
+	}
+	return cursor, true
+}
+
+// checkHorizon reports whether every change after cursor is still in the
+// change log, writing a 410 problem response if retention has pruned some
+func (h *ChangesHandler) checkHorizon(c *gin.Context, userID string, cursor int64) bool {
+	horizon, err := h.changeLogRepo.GetHorizon(c.Request.Context(), userID)
+	if err != nil {
+		logger.FromContext(c.Request.Context()).Error("failed to get change log horizon",
+			logger.Err(err),
+			logger.String("user_id", userID),
+		)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to fetch changes"})
+		return false
+	}
+
+	if cursor < horizon {
+		apierror.WriteProblem(c, apierror.NewCursorExpiredError(apierror.GetRequestID(c), cursor, horizon))
+		return false
+	}
+	return true
+}
+
+// changeStream is a connected streaming client
+type changeStream interface {
+	send(entry *models.ChangeEntry) error
+	heartbeat() error
+}
+
+// runStream sends every change after cursor, then waits for new changes
+// until ctx is cancelled or a write fails
+func (h *ChangesHandler) runStream(ctx context.Context, userID string, cursor int64, stream changeStream) error {
+	// Subscribe before the first read so no append can slip in between
+	sub := h.broker.Subscribe(userID)
+	defer sub.Close()
+
+	ticker := time.NewTicker(h.heartbeatInterval)
+	defer ticker.Stop()
+
+	for {
+		for {
+			page, err := h.changeLogRepo.GetSince(ctx, userID, cursor, 500)
+			if err != nil {
+				return fmt.Errorf("failed to fetch changes: %w", err)
+			}
+			for i := range page.Changes {
+				if err := stream.send(&page.Changes[i]); err != nil {
+					return err
+				}
+				cursor = page.Changes[i].ID
+			}
+			if !page.HasMore {
+				break
+			}
+		}
+
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-sub.C:
+		case <-ticker.C:
+			if err := stream.heartbeat(); err != nil {
+				return err
+			}
+		}
+	}
+}
+
+func (h *ChangesHandler) streamSSE(ctx context.Context, c *gin.Context, userID string, cursor int64) {
+	log := logger.FromContext(ctx)
+
+	c.Header("Content-Type", "text/event-stream")
+	c.Header("Cache-Control", "no-cache")
+	c.Header("Connection", "keep-alive")
+	c.Header("X-Accel-Buffering", "no") // Disable proxy buffering (nginx)
+	c.Status(http.StatusOK)
+
+	rc := http.NewResponseController(c.Writer)
+	// Clear per-write deadlines so they do not outlive the stream
+	defer rc.SetWriteDeadline(time.Time{})
+
+	stream := &sseStream{w: c.Writer, rc: rc}
+	if err := stream.open(); err != nil {
+		return
+	}
+
+	log.Debug("change stream opened", logger.String("transport", "sse"), logger.Int64("cursor", cursor))
+	if err := h.runStream(ctx, userID, cursor, stream); err != nil {
+		log.Warn("change stream closed", logger.Err(err), logger.String("transport", "sse"))
+		return
+	}
+	log.Debug("change stream closed", logger.String("transport", "sse"))
+}
+
+// sseStream writes Server-Sent Events. The event id is the change cursor, so
+// EventSource clients resume automatically by sending Last-Event-ID.
+type sseStream struct {
+	w  gin.ResponseWriter
+	rc *http.ResponseController
+}
+
+func (s *sseStream) open() error {
+	// Tell EventSource clients how long to wait before reconnecting
+	return s.write("retry: 3000\n\n")
+}
+
+func (s *sseStream) send(entry *models.ChangeEntry) error {
+	data, err := json.Marshal(entry)
+	if err != nil {
+		return fmt.Errorf("failed to marshal change: %w", err)
+	}
+	return s.write(fmt.Sprintf("id: %d\nevent: change\ndata: %s\n\n", entry.ID, data))
+}
+
+func (s *sseStream) heartbeat() error {
+	return s.write(": heartbeat\n\n")
+}
+
+func (s *sseStream) write(frame string) error {
+	_ = s.rc.SetWriteDeadline(time.Now().Add(streamWriteTimeout))
+	if _, err := s.w.WriteString(frame); err != nil {
+		return err
+	}
+	s.w.Flush()
+	return nil
+}
+
+var streamUpgrader = websocket.Upgrader{
+	// Origin is checked against the CORS policy before upgrading
+	CheckOrigin: func(r *http.Request) bool { return true },
+}
+
+func (h *ChangesHandler) streamWebSocket(ctx context.Context, c *gin.Context, userID string, cursor int64) {
+	log := logger.FromContext(ctx)
+
+	// Browsers send Origin; native clients do not
+	origin := c.GetHeader("Origin")
+	if origin != "" && c.Writer.Header().Get("Access-Control-Allow-Origin") != origin {
+		c.JSON(http.StatusForbidden, gin.H{"error": "origin not allowed"})
+		return
+	}
+
+	conn, err := streamUpgrader.Upgrade(c.Writer, c.Request, nil)
+	if err != nil {
+		// Upgrade has already written an HTTP error response
+		log.Debug("websocket upgrade failed", logger.Err(err))
+		return
+	}
+	defer conn.Close()
+
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	// Clients do not send messages, but reading is required to process pong
+	// and close frames. A missed pong ends the stream.
+	readTimeout := 2 * h.heartbeatInterval
+	_ = conn.SetReadDeadline(time.Now().Add(readTimeout))
+	conn.SetPongHandler(func(string) error {
+		return conn.SetReadDeadline(time.Now().Add(readTimeout))
+	})
+	go func() {
+		defer cancel()
+		for {
+			if _, _, err := conn.ReadMessage(); err != nil {
+				return
+			}
+		}
+	}()
+
+	log.Debug("change stream opened", logger.String("transport", "websocket"), logger.Int64("cursor", cursor))
+	if err := h.runStream(ctx, userID, cursor, &wsStream{conn: conn}); err != nil {
+		log.Warn("change stream closed", logger.Err(err), logger.String("transport", "websocket"))
+		_ = conn.WriteControl(websocket.CloseMessage,
+			websocket.FormatCloseMessage(websocket.CloseInternalServerErr, "stream failed"),
+			time.Now().Add(streamWriteTimeout))
+		return
+	}
+	log.Debug("change stream closed", logger.String("transport", "websocket"))
+}
+
+// wsStream sends each change as a JSON text message and heartbeats as pings
+type wsStream struct {
+	conn *websocket.Conn
+}
+
+func (s *wsStream) send(entry *models.ChangeEntry) error {
+	_ = s.conn.SetWriteDeadline(time.Now().Add(streamWriteTimeout))
+	return s.conn.WriteJSON(entry)
+}
+
+func (s *wsStream) heartbeat() error {
+	return s.conn.WriteControl(websocket.PingMessage, nil, time.Now().Add(streamWriteTimeout))
+}
@@ -0,0 +1,106 @@
+package handlers
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/JonnyWalker81/trendy/backend/internal/apierror"
+	"github.com/JonnyWalker81/trendy/backend/internal/service"
+	"github.com/gin-gonic/gin"
+)
+
+// etag returns the strong entity tag for a resource version. Every write
+// bumps updated_at, so the tag changes exactly when the resource does.
+// Timestamps are truncated to the database's microsecond precision.
+func etag(id string, updatedAt time.Time) string {
+	sum := sha256.Sum256([]byte(id + "|" + updatedAt.UTC().Truncate(time.Microsecond).Format(time.RFC3339Nano)))
+	return `"` + hex.EncodeToString(sum[:16]) + `"`
+}
+
+// setETag sets the ETag header for a resource version and returns the tag
+func setETag(c *gin.Context, id string, updatedAt time.Time) string {
+	tag := etag(id, updatedAt)
+	c.Header("ETag", tag)
+	return tag
+}
+
+// notModified writes 304 Not Modified if the request's If-None-Match matches
+// tag. Per RFC 9110 If-None-Match uses weak comparison.
+func notModified(c *gin.Context, tag string) bool {
+	if !etagListMatches(c.GetHeader("If-None-Match"), tag, true) {
+		return false
+	}
+	c.Status(http.StatusNotModified)
+	return true
+}
+
+// checkIfMatch makes a write conditional on the request's If-Match header.
+// current loads the resource and returns its tag; it is only called when the
+// request has an If-Match header. Writes a 404 or 412 response and returns
+// false if the write must not proceed.
+//
+// Otherwise it returns the context to pass to the write. The service checks
+// the precondition again against the version it claims for the write and
+// fails with service.ErrPreconditionFailed if another writer got there first;
+// see writePreconditionFailed.
+func checkIfMatch(c *gin.Context, resource, id string, current func() (string, error)) (context.Context, bool) {
+	ctx := c.Request.Context()
+	ifMatch := c.GetHeader("If-Match")
+	if ifMatch == "" {
+		return ctx, true
+	}
+
+	tag, err := current()
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": resource + " not found"})
+		return nil, false
+	}
+
+	// If-Match uses strong comparison
+	if !etagListMatches(ifMatch, tag, false) {
+		c.Header("ETag", tag)
+		apierror.WriteProblem(c, apierror.NewPreconditionFailedError(apierror.GetRequestID(c), resource, id))
+		return nil, false
+	}
+
+	return service.WithPrecondition(ctx, id, func(updatedAt time.Time) bool {
+		return etagListMatches(ifMatch, etag(id, updatedAt), false)
+	}), true
+}
+
+// writePreconditionFailed writes the 412 response for a write that lost a
+// race with another writer after checkIfMatch passed
+func writePreconditionFailed(c *gin.Context, resource, id string, current func() (string, error)) {
+	if tag, err := current(); err == nil {
+		c.Header("ETag", tag)
+	}
+	apierror.WriteProblem(c, apierror.NewPreconditionFailedError(apierror.GetRequestID(c), resource, id))
+}
+
+// etagListMatches reports whether a comma-separated If-Match/If-None-Match
+// header value matches tag. "*" matches any current version.
+func etagListMatches(header, tag string, weak bool) bool {
+	if header == "" {
+		return false
+	}
+	for _, candidate := range strings.Split(header, ",") {
+		candidate = strings.TrimSpace(candidate)
+		if candidate == "*" {
+			return true
+		}
+		if strings.HasPrefix(candidate, "W/") {
+			if !weak {
+				continue
+			}
+			candidate = strings.TrimPrefix(candidate, "W/")
+		}
+		if candidate == tag {
+			return true
+		}
+	}
+	return false
+}
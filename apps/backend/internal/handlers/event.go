@@ -2,25 +2,31 @@ package handlers
 
 import (
 	"errors"
+	"fmt"
 	"net/http"
+	"net/url"
 	"strconv"
 	"strings"
 	"time"
 
 	"github.com/JonnyWalker81/trendy/backend/internal/apierror"
+	"github.com/JonnyWalker81/trendy/backend/internal/export"
+	"github.com/JonnyWalker81/trendy/backend/internal/logger"
 	"github.com/JonnyWalker81/trendy/backend/internal/models"
 	"github.com/JonnyWalker81/trendy/backend/internal/service"
 	"github.com/gin-gonic/gin"
 )
 
 type EventHandler struct {
-	eventService service.EventService
+	eventService  service.EventService
+	exportService service.ExportService
 }
 
 // NewEventHandler creates a new event handler
-func NewEventHandler(eventService service.EventService) *EventHandler {
+func NewEventHandler(eventService service.EventService, exportService service.ExportService) *EventHandler {
 	return &EventHandler{
-		eventService: eventService,
+		eventService:  eventService,
+		exportService: exportService,
 	}
 }
 
@@ -42,92 +48,7 @@ func (h *EventHandler) CreateEvent(c *gin.Context) {
 		return
 	}
 
-	var fieldErrors []apierror.FieldError
-	var req models.CreateEventRequest
-
-	// Validate required fields
-	if raw.EventTypeID == "" {
-		fieldErrors = append(fieldErrors, apierror.FieldError{
-			Field:   "event_type_id",
-			Message: "is required",
-			Code:    "required",
-		})
-	} else {
-		req.EventTypeID = raw.EventTypeID
-	}
-
-	// Parse and validate timestamp (required)
-	if raw.Timestamp == "" {
-		fieldErrors = append(fieldErrors, apierror.FieldError{
-			Field:   "timestamp",
-			Message: "is required",
-			Code:    "required",
-		})
-	} else {
-		ts, err := time.Parse(time.RFC3339, raw.Timestamp)
-		if err != nil {
-			fieldErrors = append(fieldErrors, apierror.FieldError{
-				Field:   "timestamp",
-				Message: "must be a valid RFC3339 timestamp",
-				Code:    "invalid_format",
-			})
-		} else {
-			req.Timestamp = ts
-		}
-	}
-
-	// Parse is_all_day (optional, defaults to false)
-	if raw.IsAllDay != nil {
-		switch v := raw.IsAllDay.(type) {
-		case bool:
-			req.IsAllDay = v
-		case string:
-			b, err := strconv.ParseBool(v)
-			if err != nil {
-				fieldErrors = append(fieldErrors, apierror.FieldError{
-					Field:   "is_all_day",
-					Message: "must be a boolean value",
-					Code:    "invalid_type",
-				})
-			} else {
-				req.IsAllDay = b
-			}
-		default:
-			fieldErrors = append(fieldErrors, apierror.FieldError{
-				Field:   "is_all_day",
-				Message: "must be a boolean value",
-				Code:    "invalid_type",
-			})
-		}
-	}
-
-	// Parse end_date (optional)
-	if raw.EndDate != nil && *raw.EndDate != "" {
-		ed, err := time.Parse(time.RFC3339, *raw.EndDate)
-		if err != nil {
-			fieldErrors = append(fieldErrors, apierror.FieldError{
-				Field:   "end_date",
-				Message: "must be a valid RFC3339 timestamp",
-				Code:    "invalid_format",
-			})
-		} else {
-			req.EndDate = &ed
-		}
-	}
-
-	// Copy remaining fields
-	req.ID = raw.ID
-	req.Notes = raw.Notes
-	req.SourceType = raw.SourceType
-	req.ExternalID = raw.ExternalID
-	req.OriginalTitle = raw.OriginalTitle
-	req.GeofenceID = raw.GeofenceID
-	req.LocationLatitude = raw.LocationLatitude
-	req.LocationLongitude = raw.LocationLongitude
-	req.LocationName = raw.LocationName
-	req.HealthKitSampleID = raw.HealthKitSampleID
-	req.HealthKitCategory = raw.HealthKitCategory
-	req.Properties = raw.Properties
+	req, fieldErrors := service.ParseCreateEventRequest(&raw)
 
 	// Return aggregated errors if any
 	if len(fieldErrors) > 0 {
@@ -136,10 +57,16 @@ func (h *EventHandler) CreateEvent(c *gin.Context) {
 		return
 	}
 
-	event, wasCreated, err := h.eventService.CreateEvent(c.Request.Context(), userID.(string), &req)
+	event, wasCreated, err := h.eventService.CreateEvent(c.Request.Context(), userID.(string), req)
 	if err != nil {
 		requestID := apierror.GetRequestID(c)
 
+		var propErr *service.PropertyValidationError
+		if errors.As(err, &propErr) {
+			apierror.WriteProblem(c, apierror.NewValidationError(requestID, propErr.Errors))
+			return
+		}
+
 		// Handle UUIDv7 validation errors
 		if errors.Is(err, service.ErrInvalidUUID) || errors.Is(err, service.ErrNotUUIDv7) {
 			apierror.WriteProblem(c, apierror.NewInvalidUUIDError(requestID, "id", *req.ID))
@@ -163,6 +90,7 @@ func (h *EventHandler) CreateEvent(c *gin.Context) {
 	}
 
 	// Return 201 for new creates, 200 for duplicates (idempotent)
+	setETag(c, event.ID, event.UpdatedAt)
 	if wasCreated {
 		c.JSON(http.StatusCreated, event)
 	} else {
@@ -206,6 +134,14 @@ func (h *EventHandler) CreateEventsBatch(c *gin.Context) {
 }
 
 // GetEvents handles GET /api/v1/events
+// Events are returned newest first in pages of ?limit (default 50), with the
+// next page requested by passing back next_cursor as ?cursor. Filters:
+// event_type_ids, start_date, end_date, source_type, geofence_id, has_notes
+// and property predicates such as properties.mood>=4.
+//
+// Deprecated: requests with neither a cursor nor a filter get the old bare
+// array of events with limit/offset pagination, which existing clients
+// decode.
 func (h *EventHandler) GetEvents(c *gin.Context) {
 	userID, exists := c.Get("user_id")
 	if !exists {
@@ -213,20 +149,80 @@ func (h *EventHandler) GetEvents(c *gin.Context) {
 		return
 	}
 
-	// Parse pagination parameters
-	limit, _ := strconv.Atoi(c.DefaultQuery("limit", "50"))
-	offset, _ := strconv.Atoi(c.DefaultQuery("offset", "0"))
+	if !wantsEventPage(c.Request.URL.Query()) {
+		limit, _ := strconv.Atoi(c.DefaultQuery("limit", "50"))
+		offset, _ := strconv.Atoi(c.DefaultQuery("offset", "0"))
+
+		events, err := h.eventService.GetUserEvents(c.Request.Context(), userID.(string), limit, offset)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+
+		c.JSON(http.StatusOK, events)
+		return
+	}
+
+	query, fieldErrors := service.ParseEventListQuery(c.Request.URL.RawQuery)
+	if len(fieldErrors) > 0 {
+		apierror.WriteProblem(c, apierror.NewValidationError(apierror.GetRequestID(c), fieldErrors))
+		return
+	}
+
+	page, err := h.eventService.ListEvents(c.Request.Context(), userID.(string), query)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, page)
+}
+
+// wantsEventPage reports whether a GET /events request uses the cursor or a
+// filter, which only the page envelope supports
+func wantsEventPage(query url.Values) bool {
+	for key := range query {
+		switch key {
+		case "cursor", "event_type_ids", "start_date", "end_date", "source_type", "geofence_id", "has_notes":
+			return true
+		}
+		if strings.HasPrefix(key, "properties.") {
+			return true
+		}
+	}
+	return false
+}
+
+// SearchEvents handles GET /api/v1/events/search
+// q matches the original title, notes, location name and text or select
+// properties; "quoted phrases" match in order and a trailing * matches a
+// prefix. Results are ranked, paged with limit/offset, and take the filters
+// of GetEvents.
+func (h *EventHandler) SearchEvents(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "user not authenticated"})
+		return
+	}
+
+	query, fieldErrors := service.ParseEventSearchQuery(c.Request.URL.RawQuery)
+	if len(fieldErrors) > 0 {
+		apierror.WriteProblem(c, apierror.NewValidationError(apierror.GetRequestID(c), fieldErrors))
+		return
+	}
 
-	events, err := h.eventService.GetUserEvents(c.Request.Context(), userID.(string), limit, offset)
+	results, err := h.eventService.SearchEvents(c.Request.Context(), userID.(string), query)
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
 	}
 
-	c.JSON(http.StatusOK, events)
+	c.JSON(http.StatusOK, results)
 }
 
 // ExportEvents handles GET /api/v1/events/export
+// The format is taken from ?format=json|ndjson|csv|ics, else from the Accept
+// header, and defaults to JSON. The response is streamed.
 func (h *EventHandler) ExportEvents(c *gin.Context) {
 	userID, exists := c.Get("user_id")
 	if !exists {
@@ -234,6 +230,18 @@ func (h *EventHandler) ExportEvents(c *gin.Context) {
 		return
 	}
 
+	format, err := export.ParseFormat(c.Query("format"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if c.Query("format") == "" {
+		negotiated := c.NegotiateFormat("application/json", "application/x-ndjson", "text/csv", "text/calendar")
+		if f, ok := export.FormatForMediaType(negotiated); ok {
+			format = f
+		}
+	}
+
 	// Parse optional date range parameters
 	var startDate, endDate *time.Time
 	if startDateStr := c.Query("start_date"); startDateStr != "" {
@@ -269,14 +277,35 @@ func (h *EventHandler) ExportEvents(c *gin.Context) {
 		}
 	}
 
-	// Get events
-	events, err := h.eventService.ExportEvents(c.Request.Context(), userID.(string), startDate, endDate, eventTypeIDs)
-	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
-		return
+	req := &models.ExportEventsRequest{
+		StartDate:    startDate,
+		EndDate:      endDate,
+		EventTypeIDs: eventTypeIDs,
+		Format:       format,
 	}
 
-	c.JSON(http.StatusOK, events)
+	c.Header("Content-Type", export.ContentType(format))
+	if format == models.ExportFormatCSV || format == models.ExportFormatICS {
+		c.Header("Content-Disposition", fmt.Sprintf(`attachment; filename="trendy-events.%s"`, format))
+	}
+	c.Status(http.StatusOK)
+
+	if err := h.exportService.ExportEvents(c.Request.Context(), userID.(string), req, c.Writer); err != nil {
+		if !c.Writer.Written() {
+			// c.JSON keeps a Content-Type already set, so drop the export's
+			c.Writer.Header().Del("Content-Type")
+			c.Writer.Header().Del("Content-Disposition")
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+
+		// Headers are already sent; cut the stream short
+		logger.FromContext(c.Request.Context()).Error("event export failed mid-stream",
+			logger.Err(err),
+			logger.String("user_id", userID.(string)),
+		)
+		c.Abort()
+	}
 }
 
 // GetEvent handles GET /api/v1/events/:id
@@ -294,6 +323,10 @@ func (h *EventHandler) GetEvent(c *gin.Context) {
 		return
 	}
 
+	if notModified(c, setETag(c, event.ID, event.UpdatedAt)) {
+		return
+	}
+
 	c.JSON(http.StatusOK, event)
 }
 
@@ -327,8 +360,29 @@ func (h *EventHandler) UpdateEvent(c *gin.Context) {
 		println("📝 UpdateEvent received nil properties")
 	}
 
-	event, err := h.eventService.UpdateEvent(c.Request.Context(), userID.(string), eventID, &req)
+	currentETag := func() (string, error) {
+		current, err := h.eventService.GetEvent(c.Request.Context(), userID.(string), eventID)
+		if err != nil {
+			return "", err
+		}
+		return etag(current.ID, current.UpdatedAt), nil
+	}
+	ctx, ok := checkIfMatch(c, "event", eventID, currentETag)
+	if !ok {
+		return
+	}
+
+	event, err := h.eventService.UpdateEvent(ctx, userID.(string), eventID, &req)
 	if err != nil {
+		if errors.Is(err, service.ErrPreconditionFailed) {
+			writePreconditionFailed(c, "event", eventID, currentETag)
+			return
+		}
+		var propErr *service.PropertyValidationError
+		if errors.As(err, &propErr) {
+			apierror.WriteProblem(c, apierror.NewValidationError(apierror.GetRequestID(c), propErr.Errors))
+			return
+		}
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
 	}
@@ -343,6 +397,7 @@ func (h *EventHandler) UpdateEvent(c *gin.Context) {
 		println("📝 UpdateEvent returning", propCount, "properties:", strings.Join(propKeys, ", "))
 	}
 
+	setETag(c, event.ID, event.UpdatedAt)
 	c.JSON(http.StatusOK, event)
 }
 
@@ -356,10 +411,45 @@ func (h *EventHandler) DeleteEvent(c *gin.Context) {
 
 	eventID := c.Param("id")
 
-	if err := h.eventService.DeleteEvent(c.Request.Context(), userID.(string), eventID); err != nil {
+	currentETag := func() (string, error) {
+		current, err := h.eventService.GetEvent(c.Request.Context(), userID.(string), eventID)
+		if err != nil {
+			return "", err
+		}
+		return etag(current.ID, current.UpdatedAt), nil
+	}
+	ctx, ok := checkIfMatch(c, "event", eventID, currentETag)
+	if !ok {
+		return
+	}
+
+	if err := h.eventService.DeleteEvent(ctx, userID.(string), eventID); err != nil {
+		if errors.Is(err, service.ErrPreconditionFailed) {
+			writePreconditionFailed(c, "event", eventID, currentETag)
+			return
+		}
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
 	}
 
 	c.JSON(http.StatusNoContent, nil)
 }
+
+// RestoreEvent handles POST /api/v1/events/:id/restore
+func (h *EventHandler) RestoreEvent(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "user not authenticated"})
+		return
+	}
+
+	eventID := c.Param("id")
+	event, err := h.eventService.RestoreEvent(c.Request.Context(), userID.(string), eventID)
+	if err != nil {
+		writeRestoreError(c, "event", eventID, err)
+		return
+	}
+
+	setETag(c, event.ID, event.UpdatedAt)
+	c.JSON(http.StatusOK, event)
+}
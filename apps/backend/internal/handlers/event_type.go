@@ -2,7 +2,9 @@ package handlers
 
 import (
 	"context"
+	"errors"
 	"net/http"
+	"strings"
 
 	"github.com/JonnyWalker81/trendy/backend/internal/models"
 	"github.com/JonnyWalker81/trendy/backend/internal/service"
@@ -45,10 +47,12 @@ func (h *EventTypeHandler) CreateEventType(c *gin.Context) {
 		return
 	}
 
+	setETag(c, eventType.ID, eventType.UpdatedAt)
 	c.JSON(http.StatusCreated, eventType)
 }
 
 // GetEventTypes handles GET /api/v1/event-types
+// Archived event types are only listed with ?include_archived=true
 func (h *EventTypeHandler) GetEventTypes(c *gin.Context) {
 	userID, exists := c.Get("user_id")
 	if !exists {
@@ -56,7 +60,8 @@ func (h *EventTypeHandler) GetEventTypes(c *gin.Context) {
 		return
 	}
 
-	eventTypes, err := h.eventTypeService.GetUserEventTypes(c.Request.Context(), userID.(string))
+	includeArchived := c.Query("include_archived") == "true"
+	eventTypes, err := h.eventTypeService.GetUserEventTypes(c.Request.Context(), userID.(string), includeArchived)
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
@@ -80,6 +85,10 @@ func (h *EventTypeHandler) GetEventType(c *gin.Context) {
 		return
 	}
 
+	if notModified(c, setETag(c, eventType.ID, eventType.UpdatedAt)) {
+		return
+	}
+
 	c.JSON(http.StatusOK, eventType)
 }
 
@@ -99,16 +108,34 @@ func (h *EventTypeHandler) UpdateEventType(c *gin.Context) {
 		return
 	}
 
-	eventType, err := h.eventTypeService.UpdateEventType(c.Request.Context(), userID.(string), eventTypeID, &req)
+	currentETag := func() (string, error) {
+		current, err := h.eventTypeService.GetEventType(c.Request.Context(), userID.(string), eventTypeID)
+		if err != nil {
+			return "", err
+		}
+		return etag(current.ID, current.UpdatedAt), nil
+	}
+	ctx, ok := checkIfMatch(c, "event type", eventTypeID, currentETag)
+	if !ok {
+		return
+	}
+
+	eventType, err := h.eventTypeService.UpdateEventType(ctx, userID.(string), eventTypeID, &req)
 	if err != nil {
+		if errors.Is(err, service.ErrPreconditionFailed) {
+			writePreconditionFailed(c, "event type", eventTypeID, currentETag)
+			return
+		}
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
 	}
 
+	setETag(c, eventType.ID, eventType.UpdatedAt)
 	c.JSON(http.StatusOK, eventType)
 }
 
 // DeleteEventType handles DELETE /api/v1/event-types/:id
+// ?strategy=cascade (default), reassign (with target_event_type_id) or archive
 func (h *EventTypeHandler) DeleteEventType(c *gin.Context) {
 	userID, exists := c.Get("user_id")
 	if !exists {
@@ -118,10 +145,110 @@ func (h *EventTypeHandler) DeleteEventType(c *gin.Context) {
 
 	eventTypeID := c.Param("id")
 
-	if err := h.eventTypeService.DeleteEventType(c.Request.Context(), userID.(string), eventTypeID); err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+	var req models.DeleteEventTypeRequest
+	if err := c.ShouldBindQuery(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	currentETag := func() (string, error) {
+		current, err := h.eventTypeService.GetEventType(c.Request.Context(), userID.(string), eventTypeID)
+		if err != nil {
+			return "", err
+		}
+		return etag(current.ID, current.UpdatedAt), nil
+	}
+	ctx, ok := checkIfMatch(c, "event type", eventTypeID, currentETag)
+	if !ok {
+		return
+	}
+
+	if err := h.eventTypeService.DeleteEventType(ctx, userID.(string), eventTypeID, &req); err != nil {
+		if errors.Is(err, service.ErrPreconditionFailed) {
+			writePreconditionFailed(c, "event type", eventTypeID, currentETag)
+			return
+		}
+		writeMoveEventsError(c, err)
 		return
 	}
 
 	c.JSON(http.StatusNoContent, nil)
 }
+
+// RestoreEventType handles POST /api/v1/event-types/:id/restore
+func (h *EventTypeHandler) RestoreEventType(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "user not authenticated"})
+		return
+	}
+
+	eventTypeID := c.Param("id")
+	eventType, err := h.eventTypeService.RestoreEventType(c.Request.Context(), userID.(string), eventTypeID)
+	if err != nil {
+		writeRestoreError(c, "event type", eventTypeID, err)
+		return
+	}
+
+	setETag(c, eventType.ID, eventType.UpdatedAt)
+	c.JSON(http.StatusOK, eventType)
+}
+
+// MergeEventType handles POST /api/v1/event-types/:id/merge
+// Moves the event type's events, properties and geofences into
+// target_event_type_id and moves it to the trash.
+func (h *EventTypeHandler) MergeEventType(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "user not authenticated"})
+		return
+	}
+
+	eventTypeID := c.Param("id")
+
+	var req models.MergeEventTypeRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	currentETag := func() (string, error) {
+		current, err := h.eventTypeService.GetEventType(c.Request.Context(), userID.(string), eventTypeID)
+		if err != nil {
+			return "", err
+		}
+		return etag(current.ID, current.UpdatedAt), nil
+	}
+	ctx, ok := checkIfMatch(c, "event type", eventTypeID, currentETag)
+	if !ok {
+		return
+	}
+
+	result, err := h.eventTypeService.MergeEventType(ctx, userID.(string), eventTypeID, req.TargetEventTypeID)
+	if err != nil {
+		if errors.Is(err, service.ErrPreconditionFailed) {
+			writePreconditionFailed(c, "event type", eventTypeID, currentETag)
+			return
+		}
+		writeMoveEventsError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, result)
+}
+
+// writeMoveEventsError maps a failed reassign or merge of an event type's
+// events to a response
+func writeMoveEventsError(c *gin.Context, err error) {
+	switch {
+	case strings.Contains(err.Error(), "not found"):
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+	case errors.Is(err, service.ErrInvalidReassignTarget):
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	case strings.Contains(err.Error(), "duplicate") || strings.Contains(err.Error(), "23505"):
+		// A moved event has the same timestamp as one of the target's events
+		c.JSON(http.StatusConflict, gin.H{"error": "events conflict with existing events of the target event type"})
+	default:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+	}
+}
@@ -2,6 +2,7 @@ package handlers
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"net/http"
 
@@ -56,6 +57,7 @@ func (h *GeofenceHandler) CreateGeofence(c *gin.Context) {
 		return
 	}
 
+	setETag(c, geofence.ID, geofence.UpdatedAt)
 	c.JSON(http.StatusCreated, geofence)
 }
 
@@ -102,6 +104,10 @@ func (h *GeofenceHandler) GetGeofence(c *gin.Context) {
 		return
 	}
 
+	if notModified(c, setETag(c, geofence.ID, geofence.UpdatedAt)) {
+		return
+	}
+
 	c.JSON(http.StatusOK, geofence)
 }
 
@@ -121,12 +127,29 @@ func (h *GeofenceHandler) UpdateGeofence(c *gin.Context) {
 		return
 	}
 
-	geofence, err := h.geofenceService.UpdateGeofence(c.Request.Context(), userID.(string), geofenceID, &req)
+	currentETag := func() (string, error) {
+		current, err := h.geofenceService.GetGeofence(c.Request.Context(), userID.(string), geofenceID)
+		if err != nil {
+			return "", err
+		}
+		return etag(current.ID, current.UpdatedAt), nil
+	}
+	ctx, ok := checkIfMatch(c, "geofence", geofenceID, currentETag)
+	if !ok {
+		return
+	}
+
+	geofence, err := h.geofenceService.UpdateGeofence(ctx, userID.(string), geofenceID, &req)
 	if err != nil {
+		if errors.Is(err, service.ErrPreconditionFailed) {
+			writePreconditionFailed(c, "geofence", geofenceID, currentETag)
+			return
+		}
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
 	}
 
+	setETag(c, geofence.ID, geofence.UpdatedAt)
 	c.JSON(http.StatusOK, geofence)
 }
 
@@ -139,7 +162,24 @@ func (h *GeofenceHandler) DeleteGeofence(c *gin.Context) {
 	}
 
 	geofenceID := c.Param("id")
-	if err := h.geofenceService.DeleteGeofence(c.Request.Context(), userID.(string), geofenceID); err != nil {
+
+	currentETag := func() (string, error) {
+		current, err := h.geofenceService.GetGeofence(c.Request.Context(), userID.(string), geofenceID)
+		if err != nil {
+			return "", err
+		}
+		return etag(current.ID, current.UpdatedAt), nil
+	}
+	ctx, ok := checkIfMatch(c, "geofence", geofenceID, currentETag)
+	if !ok {
+		return
+	}
+
+	if err := h.geofenceService.DeleteGeofence(ctx, userID.(string), geofenceID); err != nil {
+		if errors.Is(err, service.ErrPreconditionFailed) {
+			writePreconditionFailed(c, "geofence", geofenceID, currentETag)
+			return
+		}
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
 	}
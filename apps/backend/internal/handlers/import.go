@@ -0,0 +1,121 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+
+	"github.com/JonnyWalker81/trendy/backend/internal/importer"
+	"github.com/JonnyWalker81/trendy/backend/internal/models"
+	"github.com/JonnyWalker81/trendy/backend/internal/service"
+	"github.com/gin-gonic/gin"
+)
+
+// maxImportUploadSize caps the request body of an import upload
+const maxImportUploadSize = 32 << 20
+
+type ImportHandler struct {
+	importService service.ImportService
+}
+
+// NewImportHandler creates a new import handler
+func NewImportHandler(importService service.ImportService) *ImportHandler {
+	return &ImportHandler{
+		importService: importService,
+	}
+}
+
+// CreateImport handles POST /api/v1/imports
+// The request is multipart form data with the file in "file", an optional
+// JSON ImportMapping in "mapping" and an optional "format" (otherwise taken
+// from the file extension). With ?dry_run=true the validation report is
+// returned and nothing is written; otherwise the import runs in the
+// background and 202 is returned with the job.
+func (h *ImportHandler) CreateImport(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "user not authenticated"})
+		return
+	}
+
+	c.Request.Body = http.MaxBytesReader(c.Writer, c.Request.Body, maxImportUploadSize)
+	file, header, err := c.Request.FormFile("file")
+	if err != nil {
+		var maxBytesErr *http.MaxBytesError
+		if errors.As(err, &maxBytesErr) {
+			c.JSON(http.StatusRequestEntityTooLarge, gin.H{"error": "import file is too large"})
+			return
+		}
+		c.JSON(http.StatusBadRequest, gin.H{"error": "file is required"})
+		return
+	}
+	defer file.Close()
+
+	var format models.ImportFormat
+	if name := c.PostForm("format"); name != "" {
+		format, err = importer.ParseFormat(name)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+	} else {
+		var ok bool
+		if format, ok = importer.FormatForFilename(header.Filename); !ok {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "format is required when the file extension is not csv, json, ndjson or ics"})
+			return
+		}
+	}
+
+	var mapping models.ImportMapping
+	if raw := c.PostForm("mapping"); raw != "" {
+		if err := json.Unmarshal([]byte(raw), &mapping); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "invalid mapping: " + err.Error()})
+			return
+		}
+	}
+
+	ctx := c.Request.Context()
+	if c.Query("dry_run") == "true" {
+		report, err := h.importService.DryRun(ctx, userID.(string), format, &mapping, file)
+		if err != nil {
+			writeImportError(c, err)
+			return
+		}
+		c.JSON(http.StatusOK, report)
+		return
+	}
+
+	job, err := h.importService.StartImport(ctx, userID.(string), format, &mapping, file)
+	if err != nil {
+		writeImportError(c, err)
+		return
+	}
+
+	c.Header("Location", "/api/v1/imports/"+job.ID)
+	c.JSON(http.StatusAccepted, job)
+}
+
+// GetImport handles GET /api/v1/imports/:id
+func (h *ImportHandler) GetImport(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "user not authenticated"})
+		return
+	}
+
+	job, err := h.importService.GetImportJob(c.Request.Context(), userID.(string), c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "import job not found"})
+		return
+	}
+
+	c.JSON(http.StatusOK, job)
+}
+
+func writeImportError(c *gin.Context, err error) {
+	if errors.Is(err, service.ErrInvalidImportFile) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+}
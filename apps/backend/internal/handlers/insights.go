@@ -39,10 +39,10 @@ func (h *InsightsHandler) GetInsights(c *gin.Context) {
 	}
 
 	// Also get weekly summary
-	weeklySummary, err := h.intelligenceService.GetWeeklySummary(c.Request.Context(), userID.(string))
+	weeklySummary, err := h.intelligenceService.GetWeeklySummary(c.Request.Context(), userID.(string), calendarOptions(c))
 	if err != nil {
 		log.Error("failed to get weekly summary", logger.Err(err), logger.String("user_id", userID.(string)))
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		c.JSON(calendarErrorStatus(err), gin.H{"error": err.Error()})
 		return
 	}
 
@@ -101,9 +101,9 @@ func (h *InsightsHandler) GetWeeklySummary(c *gin.Context) {
 		return
 	}
 
-	summary, err := h.intelligenceService.GetWeeklySummary(c.Request.Context(), userID.(string))
+	summary, err := h.intelligenceService.GetWeeklySummary(c.Request.Context(), userID.(string), calendarOptions(c))
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		c.JSON(calendarErrorStatus(err), gin.H{"error": err.Error()})
 		return
 	}
 
@@ -123,14 +123,45 @@ func (h *InsightsHandler) RefreshInsights(c *gin.Context) {
 
 	log := logger.Ctx(c.Request.Context())
 
-	if err := h.intelligenceService.ComputeInsights(c.Request.Context(), userID.(string)); err != nil {
-		log.Error("failed to compute insights", logger.Err(err), logger.String("user_id", userID.(string)))
+	job, err := h.intelligenceService.RequestRefresh(c.Request.Context(), userID.(string))
+	if err != nil {
+		log.Error("failed to refresh insights", logger.Err(err), logger.String("user_id", userID.(string)))
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
 	}
 
+	// With background jobs the refresh is queued; poll the status endpoint
+	if job != nil {
+		c.JSON(http.StatusAccepted, gin.H{
+			"status":  "queued",
+			"message": "Insights refresh queued",
+			"job":     job,
+		})
+		return
+	}
+
 	c.JSON(http.StatusOK, gin.H{
 		"status":  "success",
 		"message": "Insights refreshed successfully",
 	})
 }
+
+// GetStatus reports how current the insights are and the state of their
+// background recompute
+// GET /api/v1/insights/status
+func (h *InsightsHandler) GetStatus(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "user not authenticated"})
+		return
+	}
+
+	status, err := h.intelligenceService.GetStatus(c.Request.Context(), userID.(string))
+	if err != nil {
+		logger.Ctx(c.Request.Context()).Error("failed to get insights status", logger.Err(err), logger.String("user_id", userID.(string)))
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, status)
+}
@@ -2,7 +2,9 @@ package handlers
 
 import (
 	"context"
+	"errors"
 	"net/http"
+	"strings"
 
 	"github.com/JonnyWalker81/trendy/backend/internal/models"
 	"github.com/JonnyWalker81/trendy/backend/internal/service"
@@ -49,6 +51,7 @@ func (h *PropertyDefinitionHandler) CreatePropertyDefinition(c *gin.Context) {
 		return
 	}
 
+	setETag(c, propertyDef.ID, propertyDef.UpdatedAt)
 	c.JSON(http.StatusCreated, propertyDef)
 }
 
@@ -86,6 +89,10 @@ func (h *PropertyDefinitionHandler) GetPropertyDefinition(c *gin.Context) {
 		return
 	}
 
+	if notModified(c, setETag(c, propertyDef.ID, propertyDef.UpdatedAt)) {
+		return
+	}
+
 	c.JSON(http.StatusOK, propertyDef)
 }
 
@@ -105,12 +112,29 @@ func (h *PropertyDefinitionHandler) UpdatePropertyDefinition(c *gin.Context) {
 		return
 	}
 
-	propertyDef, err := h.propertyDefService.UpdatePropertyDefinition(c.Request.Context(), userID.(string), propertyDefID, &req)
+	currentETag := func() (string, error) {
+		current, err := h.propertyDefService.GetPropertyDefinition(c.Request.Context(), userID.(string), propertyDefID)
+		if err != nil {
+			return "", err
+		}
+		return etag(current.ID, current.UpdatedAt), nil
+	}
+	ctx, ok := checkIfMatch(c, "property definition", propertyDefID, currentETag)
+	if !ok {
+		return
+	}
+
+	propertyDef, err := h.propertyDefService.UpdatePropertyDefinition(ctx, userID.(string), propertyDefID, &req)
 	if err != nil {
+		if errors.Is(err, service.ErrPreconditionFailed) {
+			writePreconditionFailed(c, "property definition", propertyDefID, currentETag)
+			return
+		}
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
 	}
 
+	setETag(c, propertyDef.ID, propertyDef.UpdatedAt)
 	c.JSON(http.StatusOK, propertyDef)
 }
 
@@ -124,10 +148,77 @@ func (h *PropertyDefinitionHandler) DeletePropertyDefinition(c *gin.Context) {
 
 	propertyDefID := c.Param("id")
 
-	if err := h.propertyDefService.DeletePropertyDefinition(c.Request.Context(), userID.(string), propertyDefID); err != nil {
+	currentETag := func() (string, error) {
+		current, err := h.propertyDefService.GetPropertyDefinition(c.Request.Context(), userID.(string), propertyDefID)
+		if err != nil {
+			return "", err
+		}
+		return etag(current.ID, current.UpdatedAt), nil
+	}
+	ctx, ok := checkIfMatch(c, "property definition", propertyDefID, currentETag)
+	if !ok {
+		return
+	}
+
+	if err := h.propertyDefService.DeletePropertyDefinition(ctx, userID.(string), propertyDefID); err != nil {
+		if errors.Is(err, service.ErrPreconditionFailed) {
+			writePreconditionFailed(c, "property definition", propertyDefID, currentETag)
+			return
+		}
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
 	}
 
 	c.JSON(http.StatusNoContent, nil)
 }
+
+// MigratePropertyDefinition handles POST /api/v1/property-definitions/:id/migrate
+func (h *PropertyDefinitionHandler) MigratePropertyDefinition(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "user not authenticated"})
+		return
+	}
+
+	propertyDefID := c.Param("id")
+
+	var req models.MigratePropertyDefinitionRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	currentETag := func() (string, error) {
+		current, err := h.propertyDefService.GetPropertyDefinition(c.Request.Context(), userID.(string), propertyDefID)
+		if err != nil {
+			return "", err
+		}
+		return etag(current.ID, current.UpdatedAt), nil
+	}
+	ctx, ok := checkIfMatch(c, "property definition", propertyDefID, currentETag)
+	if !ok {
+		return
+	}
+
+	result, err := h.propertyDefService.MigratePropertyDefinition(ctx, userID.(string), propertyDefID, &req)
+	if err != nil {
+		switch {
+		case errors.Is(err, service.ErrPreconditionFailed):
+			writePreconditionFailed(c, "property definition", propertyDefID, currentETag)
+		case errors.Is(err, service.ErrUnconvertibleProperties):
+			c.JSON(http.StatusUnprocessableEntity, gin.H{"error": err.Error(), "unconvertible": result.Unconvertible})
+		case errors.Is(err, service.ErrInvalidPropertyMigration):
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		case strings.Contains(err.Error(), "not found"):
+			c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		default:
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		}
+		return
+	}
+
+	if !result.DryRun {
+		setETag(c, result.PropertyDefinition.ID, result.PropertyDefinition.UpdatedAt)
+	}
+	c.JSON(http.StatusOK, result)
+}
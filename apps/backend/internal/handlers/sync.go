@@ -4,16 +4,19 @@ import (
 	"net/http"
 
 	"github.com/JonnyWalker81/trendy/backend/internal/apierror"
+	"github.com/JonnyWalker81/trendy/backend/internal/logger"
+	"github.com/JonnyWalker81/trendy/backend/internal/models"
 	"github.com/JonnyWalker81/trendy/backend/internal/service"
 	"github.com/gin-gonic/gin"
 )
 
 type SyncHandler struct {
 	syncService service.SyncService
+	pushService service.SyncPushService
 }
 
-func NewSyncHandler(syncService service.SyncService) *SyncHandler {
-	return &SyncHandler{syncService: syncService}
+func NewSyncHandler(syncService service.SyncService, pushService service.SyncPushService) *SyncHandler {
+	return &SyncHandler{syncService: syncService, pushService: pushService}
 }
 
 func (h *SyncHandler) GetSyncStatus(c *gin.Context) {
@@ -34,3 +37,58 @@ func (h *SyncHandler) GetSyncStatus(c *gin.Context) {
 	c.Header("Cache-Control", "private, max-age=30")
 	c.JSON(http.StatusOK, status)
 }
+
+// GetSnapshot handles GET /api/v1/sync/snapshot
+// Returns every synced entity and the cursor to read changes from, for
+// clients bootstrapping or whose cursor has expired.
+func (h *SyncHandler) GetSnapshot(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		requestID := apierror.GetRequestID(c)
+		apierror.WriteProblem(c, apierror.NewUnauthorizedError(requestID))
+		return
+	}
+
+	snapshot, err := h.syncService.GetSnapshot(c.Request.Context(), userID.(string))
+	if err != nil {
+		requestID := apierror.GetRequestID(c)
+		apierror.WriteProblem(c, apierror.NewInternalError(requestID))
+		return
+	}
+
+	c.Header("Cache-Control", "no-store")
+	c.JSON(http.StatusOK, snapshot)
+}
+
+// Push handles POST /api/v1/sync/push
+// Applies a batch of create/update/delete mutations in order and reports a
+// result per mutation. Updates and deletes carrying base_updated_at conflict
+// if the server copy changed since; the server version wins and is returned.
+func (h *SyncHandler) Push(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		requestID := apierror.GetRequestID(c)
+		apierror.WriteProblem(c, apierror.NewUnauthorizedError(requestID))
+		return
+	}
+
+	var req models.SyncPushRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		requestID := apierror.GetRequestID(c)
+		apierror.WriteProblem(c, apierror.NewBadRequestError(requestID, err.Error(), "Invalid sync request"))
+		return
+	}
+
+	response, err := h.pushService.Push(c.Request.Context(), userID.(string), &req)
+	if err != nil {
+		logger.FromContext(c.Request.Context()).Error("failed to push sync mutations",
+			logger.Err(err),
+			logger.String("user_id", userID.(string)),
+		)
+		requestID := apierror.GetRequestID(c)
+		apierror.WriteProblem(c, apierror.NewInternalError(requestID))
+		return
+	}
+
+	c.JSON(http.StatusOK, response)
+}
@@ -0,0 +1,58 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+	"strings"
+
+	"github.com/JonnyWalker81/trendy/backend/internal/apierror"
+	"github.com/JonnyWalker81/trendy/backend/internal/service"
+	"github.com/gin-gonic/gin"
+)
+
+type TrashHandler struct {
+	trashService service.TrashService
+}
+
+// NewTrashHandler creates a new trash handler
+func NewTrashHandler(trashService service.TrashService) *TrashHandler {
+	return &TrashHandler{
+		trashService: trashService,
+	}
+}
+
+// GetTrash handles GET /api/v1/trash
+func (h *TrashHandler) GetTrash(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "user not authenticated"})
+		return
+	}
+
+	trash, err := h.trashService.GetTrash(c.Request.Context(), userID.(string))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, trash)
+}
+
+// writeRestoreError maps a failed restore of resource id to a problem response
+func writeRestoreError(c *gin.Context, resource, id string, err error) {
+	requestID := apierror.GetRequestID(c)
+
+	switch {
+	case strings.HasSuffix(err.Error(), "not found"):
+		apierror.WriteProblem(c, apierror.NewNotFoundError(requestID, resource, id))
+	case errors.Is(err, service.ErrEventTypeInTrash):
+		apierror.WriteProblem(c, apierror.NewConflictError(requestID,
+			"The event's event type is in the trash; restore the event type instead"))
+	case strings.Contains(err.Error(), "duplicate") || strings.Contains(err.Error(), "23505"):
+		// A live item has taken the restored item's name or timestamp
+		apierror.WriteProblem(c, apierror.NewConflictError(requestID,
+			"A "+resource+" that conflicts with this one already exists"))
+	default:
+		apierror.WriteProblem(c, apierror.NewInternalError(requestID))
+	}
+}
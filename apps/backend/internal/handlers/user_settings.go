@@ -0,0 +1,96 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/JonnyWalker81/trendy/backend/internal/logger"
+	"github.com/JonnyWalker81/trendy/backend/internal/models"
+	"github.com/JonnyWalker81/trendy/backend/internal/service"
+	"github.com/gin-gonic/gin"
+)
+
+type UserSettingsHandler struct {
+	settingsService service.UserSettingsService
+}
+
+// NewUserSettingsHandler creates a new user settings handler
+func NewUserSettingsHandler(settingsService service.UserSettingsService) *UserSettingsHandler {
+	return &UserSettingsHandler{
+		settingsService: settingsService,
+	}
+}
+
+// GetSettings handles GET /api/v1/users/settings
+func (h *UserSettingsHandler) GetSettings(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "user not authenticated"})
+		return
+	}
+
+	log := logger.Ctx(c.Request.Context())
+
+	settings, err := h.settingsService.GetSettings(c.Request.Context(), userID.(string))
+	if err != nil {
+		log.Error("failed to get user settings",
+			logger.String("user_id", userID.(string)),
+			logger.Err(err),
+		)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to get user settings"})
+		return
+	}
+
+	c.JSON(http.StatusOK, settings)
+}
+
+// UpdateSettings handles PATCH /api/v1/users/settings
+func (h *UserSettingsHandler) UpdateSettings(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "user not authenticated"})
+		return
+	}
+
+	log := logger.Ctx(c.Request.Context())
+
+	var req models.UpdateUserSettingsRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	settings, err := h.settingsService.UpdateSettings(c.Request.Context(), userID.(string), &req)
+	if err != nil {
+		if errors.Is(err, service.ErrInvalidUserSettings) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		log.Error("failed to update user settings",
+			logger.String("user_id", userID.(string)),
+			logger.Err(err),
+		)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to update user settings"})
+		return
+	}
+
+	c.JSON(http.StatusOK, settings)
+}
+
+// calendarOptions reads the timezone and week_start query parameters that
+// override the user's settings for one request
+func calendarOptions(c *gin.Context) models.CalendarOptions {
+	return models.CalendarOptions{
+		Timezone:  c.Query("timezone"),
+		WeekStart: models.WeekStart(c.Query("week_start")),
+	}
+}
+
+// calendarErrorStatus returns 400 for an invalid calendar override and 500
+// for anything else
+func calendarErrorStatus(err error) int {
+	if errors.Is(err, service.ErrInvalidUserSettings) {
+		return http.StatusBadRequest
+	}
+	return http.StatusInternalServerError
+}
@@ -0,0 +1,52 @@
+package importer
+
+import (
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+)
+
+// decodeCSV reads a CSV file with a header row. Empty cells are left out of
+// the record.
+func decodeCSV(r io.Reader, limit int) ([]Record, error) {
+	reader := csv.NewReader(r)
+	reader.FieldsPerRecord = -1
+
+	header, err := reader.Read()
+	if errors.Is(err, io.EOF) {
+		return []Record{}, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("invalid CSV header: %w", err)
+	}
+	// Spreadsheet exports often start with a UTF-8 byte order mark
+	if len(header) > 0 {
+		header[0] = strings.TrimPrefix(header[0], "\ufeff")
+	}
+
+	records := make([]Record, 0)
+	for {
+		row, err := reader.Read()
+		if errors.Is(err, io.EOF) {
+			return records, nil
+		}
+		if err != nil {
+			return nil, fmt.Errorf("invalid CSV: %w", err)
+		}
+
+		record := make(Record, len(header))
+		for i, value := range row {
+			if i >= len(header) || value == "" {
+				continue
+			}
+			record[strings.TrimSpace(header[i])] = value
+		}
+
+		records = append(records, record)
+		if err := checkLimit(len(records), limit); err != nil {
+			return nil, err
+		}
+	}
+}
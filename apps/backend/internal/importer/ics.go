@@ -0,0 +1,203 @@
+package importer
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+)
+
+const (
+	icsDateTimeUTC = "20060102T150405Z"
+	icsDateTime    = "20060102T150405"
+	icsDate        = "20060102"
+)
+
+// icsProperty is one unfolded content line of an iCalendar file
+type icsProperty struct {
+	name   string
+	params map[string]string
+	value  string
+}
+
+// decodeICS reads the VEVENTs of an iCalendar file. Each event becomes a
+// record with the columns of a CSV export: SUMMARY is the event type, and
+// DTSTART and DTEND become RFC 3339 timestamps. All-day events use date
+// values with an exclusive DTEND, which is converted back to the last day.
+func decodeICS(r io.Reader, limit int) ([]Record, error) {
+	lines, err := unfoldLines(r)
+	if err != nil {
+		return nil, fmt.Errorf("invalid iCalendar file: %w", err)
+	}
+
+	records := make([]Record, 0)
+	var event []icsProperty
+	inEvent := false
+	for _, line := range lines {
+		prop, ok := parseContentLine(line)
+		if !ok {
+			continue
+		}
+
+		switch {
+		case prop.name == "BEGIN" && strings.EqualFold(prop.value, "VEVENT"):
+			inEvent = true
+			event = event[:0]
+		case prop.name == "END" && strings.EqualFold(prop.value, "VEVENT"):
+			if !inEvent {
+				continue
+			}
+			inEvent = false
+			records = append(records, eventRecord(event))
+			if err := checkLimit(len(records), limit); err != nil {
+				return nil, err
+			}
+		case inEvent:
+			event = append(event, prop)
+		}
+	}
+
+	if inEvent {
+		return nil, fmt.Errorf("invalid iCalendar file: unterminated VEVENT")
+	}
+	return records, nil
+}
+
+func eventRecord(props []icsProperty) Record {
+	record := make(Record)
+	var start, end *icsProperty
+	for i := range props {
+		prop := &props[i]
+		switch prop.name {
+		case "SUMMARY":
+			record["event_type"] = unescapeText(prop.value)
+		case "DESCRIPTION":
+			record["notes"] = unescapeText(prop.value)
+		case "LOCATION":
+			record["location_name"] = unescapeText(prop.value)
+		case "GEO":
+			if lat, lon, ok := strings.Cut(prop.value, ";"); ok {
+				record["location_latitude"] = lat
+				record["location_longitude"] = lon
+			}
+		case "DTSTART":
+			start = prop
+		case "DTEND":
+			end = prop
+		}
+	}
+
+	if start == nil {
+		return record
+	}
+
+	startTime, allDay, ok := parseICSTime(start)
+	if !ok {
+		// Keep the raw value so validation reports it
+		record["timestamp"] = start.value
+		return record
+	}
+	record["timestamp"] = startTime.Format(time.RFC3339)
+	record["is_all_day"] = fmt.Sprint(allDay)
+
+	if end == nil {
+		return record
+	}
+	endTime, _, ok := parseICSTime(end)
+	if !ok {
+		record["end_date"] = end.value
+		return record
+	}
+	if allDay {
+		endTime = endTime.AddDate(0, 0, -1)
+	}
+	if endTime.After(startTime) {
+		record["end_date"] = endTime.Format(time.RFC3339)
+	}
+	return record
+}
+
+// parseICSTime parses a DATE or DATE-TIME value. Times with a TZID are
+// converted to UTC; floating times are read as UTC.
+func parseICSTime(prop *icsProperty) (time.Time, bool, bool) {
+	if prop.params["VALUE"] == "DATE" || len(prop.value) == len(icsDate) {
+		t, err := time.Parse(icsDate, prop.value)
+		return t, true, err == nil
+	}
+
+	if strings.HasSuffix(prop.value, "Z") {
+		t, err := time.Parse(icsDateTimeUTC, prop.value)
+		return t, false, err == nil
+	}
+
+	loc := time.UTC
+	if tzid := prop.params["TZID"]; tzid != "" {
+		if l, err := time.LoadLocation(tzid); err == nil {
+			loc = l
+		}
+	}
+	t, err := time.ParseInLocation(icsDateTime, prop.value, loc)
+	return t.UTC(), false, err == nil
+}
+
+// unfoldLines splits r into content lines, joining folded continuation lines
+func unfoldLines(r io.Reader) ([]string, error) {
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 0, 64*1024), 1024*1024)
+
+	var lines []string
+	for scanner.Scan() {
+		line := strings.TrimSuffix(scanner.Text(), "\r")
+		if len(lines) > 0 && (strings.HasPrefix(line, " ") || strings.HasPrefix(line, "\t")) {
+			lines[len(lines)-1] += line[1:]
+			continue
+		}
+		lines = append(lines, line)
+	}
+	return lines, scanner.Err()
+}
+
+// parseContentLine splits "NAME;PARAM=VALUE:value" into its parts
+func parseContentLine(line string) (icsProperty, bool) {
+	var prop icsProperty
+
+	// The value starts at the first colon outside a quoted parameter value
+	colon := -1
+	quoted := false
+	for i, c := range line {
+		if c == '"' {
+			quoted = !quoted
+		} else if c == ':' && !quoted {
+			colon = i
+			break
+		}
+	}
+	if colon < 0 {
+		return prop, false
+	}
+
+	parts := strings.Split(line[:colon], ";")
+	prop.name = strings.ToUpper(parts[0])
+	prop.value = line[colon+1:]
+	prop.params = make(map[string]string, len(parts)-1)
+	for _, param := range parts[1:] {
+		if name, value, ok := strings.Cut(param, "="); ok {
+			prop.params[strings.ToUpper(name)] = strings.Trim(value, `"`)
+		}
+	}
+	return prop, true
+}
+
+// icsTextUnescaper reverses TEXT escaping (RFC 5545 3.3.11)
+var icsTextUnescaper = strings.NewReplacer(
+	`\\`, `\`,
+	`\;`, ";",
+	`\,`, ",",
+	`\n`, "\n",
+	`\N`, "\n",
+)
+
+func unescapeText(s string) string {
+	return icsTextUnescaper.Replace(s)
+}
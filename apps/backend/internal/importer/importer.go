@@ -0,0 +1,79 @@
+// Package importer decodes event import files in CSV, JSON, NDJSON or
+// iCalendar format into generic records.
+//
+// Records are keyed by CSV column header, JSON object key or, for iCalendar,
+// by the column names of a CSV export, so files produced by the export
+// package decode without a mapping.
+package importer
+
+import (
+	"fmt"
+	"io"
+	"path/filepath"
+	"strings"
+
+	"github.com/JonnyWalker81/trendy/backend/internal/models"
+)
+
+// Record is one row of an import file. CSV and iCalendar values are strings;
+// JSON values keep their decoded type.
+type Record map[string]interface{}
+
+// ErrTooManyRows is returned when a file has more rows than the decode limit
+type ErrTooManyRows struct {
+	Limit int
+}
+
+func (e *ErrTooManyRows) Error() string {
+	return fmt.Sprintf("import file has more than %d rows", e.Limit)
+}
+
+// Decode reads every record of an import file. A limit greater than zero
+// caps the number of records.
+func Decode(format models.ImportFormat, r io.Reader, limit int) ([]Record, error) {
+	switch format {
+	case models.ImportFormatCSV:
+		return decodeCSV(r, limit)
+	case models.ImportFormatJSON:
+		return decodeJSON(r, limit)
+	case models.ImportFormatNDJSON:
+		return decodeNDJSON(r, limit)
+	case models.ImportFormatICS:
+		return decodeICS(r, limit)
+	default:
+		return nil, fmt.Errorf("unsupported import format %q", format)
+	}
+}
+
+// ParseFormat validates an import format
+func ParseFormat(s string) (models.ImportFormat, error) {
+	switch format := models.ImportFormat(s); format {
+	case models.ImportFormatCSV, models.ImportFormatJSON, models.ImportFormatNDJSON, models.ImportFormatICS:
+		return format, nil
+	default:
+		return "", fmt.Errorf("unsupported import format %q (expected csv, json, ndjson or ics)", s)
+	}
+}
+
+// FormatForFilename infers an import format from a file extension
+func FormatForFilename(name string) (models.ImportFormat, bool) {
+	switch strings.ToLower(filepath.Ext(name)) {
+	case ".csv":
+		return models.ImportFormatCSV, true
+	case ".json":
+		return models.ImportFormatJSON, true
+	case ".ndjson", ".jsonl":
+		return models.ImportFormatNDJSON, true
+	case ".ics", ".ical":
+		return models.ImportFormatICS, true
+	default:
+		return "", false
+	}
+}
+
+func checkLimit(count, limit int) error {
+	if limit > 0 && count > limit {
+		return &ErrTooManyRows{Limit: limit}
+	}
+	return nil
+}
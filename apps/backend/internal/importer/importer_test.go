@@ -0,0 +1,73 @@
+package importer
+
+import (
+	"bytes"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/JonnyWalker81/trendy/backend/internal/export"
+	"github.com/JonnyWalker81/trendy/backend/internal/models"
+)
+
+func TestICSRoundTripsExport(t *testing.T) {
+	start := time.Date(2026, 3, 1, 0, 0, 0, 0, time.UTC)
+	end := time.Date(2026, 3, 3, 0, 0, 0, 0, time.UTC)
+	notes := strings.Repeat("long note; with, punctuation ", 5)
+
+	var buf bytes.Buffer
+	enc, err := export.NewEncoder(models.ExportFormatICS, &buf, nil)
+	if err != nil {
+		t.Fatalf("NewEncoder failed: %v", err)
+	}
+	for _, event := range []models.Event{
+		{ID: "trip", Timestamp: start, EndDate: &end, IsAllDay: true, EventType: &models.EventType{Name: "Trip"}},
+		{ID: "day", Timestamp: start, IsAllDay: true, EventType: &models.EventType{Name: "Holiday"}},
+		{ID: "run", Timestamp: time.Date(2026, 3, 5, 9, 0, 0, 0, time.UTC), Notes: &notes, EventType: &models.EventType{Name: "Run"}},
+	} {
+		if err := enc.Encode(&event); err != nil {
+			t.Fatalf("Encode failed: %v", err)
+		}
+	}
+	if err := enc.Close(); err != nil {
+		t.Fatalf("Close failed: %v", err)
+	}
+
+	records, err := Decode(models.ImportFormatICS, &buf, 0)
+	if err != nil {
+		t.Fatalf("Decode failed: %v", err)
+	}
+	if len(records) != 3 {
+		t.Fatalf("expected 3 records, got %d", len(records))
+	}
+
+	trip, day, run := records[0], records[1], records[2]
+	if trip["event_type"] != "Trip" || trip["timestamp"] != "2026-03-01T00:00:00Z" ||
+		trip["end_date"] != "2026-03-03T00:00:00Z" || trip["is_all_day"] != "true" {
+		t.Errorf("unexpected all-day record %v", trip)
+	}
+	if _, ok := day["end_date"]; ok {
+		t.Errorf("single all-day event should have no end date, got %v", day)
+	}
+	if run["timestamp"] != "2026-03-05T09:00:00Z" || run["is_all_day"] != "false" || run["notes"] != notes {
+		t.Errorf("unexpected timed record %v", run)
+	}
+}
+
+func TestDecodeLimitsRows(t *testing.T) {
+	file := "a,b\n1,2\n3,4\n5,6\n"
+	_, err := Decode(models.ImportFormatCSV, strings.NewReader(file), 2)
+	var tooMany *ErrTooManyRows
+	if !errors.As(err, &tooMany) || tooMany.Limit != 2 {
+		t.Fatalf("expected ErrTooManyRows, got %v", err)
+	}
+
+	records, err := Decode(models.ImportFormatNDJSON, strings.NewReader("{\"a\":1}\n\n{\"a\":2}\n"), 0)
+	if err != nil || len(records) != 2 {
+		t.Fatalf("expected 2 NDJSON records, got %d (%v)", len(records), err)
+	}
+	if _, err := Decode(models.ImportFormatJSON, strings.NewReader(`{"a":1}`), 0); err == nil {
+		t.Error("expected an error for a JSON object instead of an array")
+	}
+}
@@ -0,0 +1,71 @@
+package importer
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+)
+
+// decodeJSON reads a JSON array of objects
+func decodeJSON(r io.Reader, limit int) ([]Record, error) {
+	dec := json.NewDecoder(r)
+	dec.UseNumber()
+
+	token, err := dec.Token()
+	if err != nil {
+		return nil, fmt.Errorf("invalid JSON: %w", err)
+	}
+	if delim, ok := token.(json.Delim); !ok || delim != '[' {
+		return nil, fmt.Errorf("invalid JSON: expected an array of events")
+	}
+
+	records := make([]Record, 0)
+	for dec.More() {
+		var record Record
+		if err := dec.Decode(&record); err != nil {
+			return nil, fmt.Errorf("invalid JSON at element %d: %w", len(records)+1, err)
+		}
+
+		records = append(records, record)
+		if err := checkLimit(len(records), limit); err != nil {
+			return nil, err
+		}
+	}
+
+	if _, err := dec.Token(); err != nil {
+		return nil, fmt.Errorf("invalid JSON: %w", err)
+	}
+	return records, nil
+}
+
+// decodeNDJSON reads one JSON object per line
+func decodeNDJSON(r io.Reader, limit int) ([]Record, error) {
+	dec := json.NewDecoder(r)
+	dec.UseNumber()
+
+	records := make([]Record, 0)
+	for {
+		var raw json.RawMessage
+		err := dec.Decode(&raw)
+		if errors.Is(err, io.EOF) {
+			return records, nil
+		}
+		if err != nil {
+			return nil, fmt.Errorf("invalid NDJSON at record %d: %w", len(records)+1, err)
+		}
+
+		var record Record
+		inner := json.NewDecoder(bytes.NewReader(raw))
+		inner.UseNumber()
+		if err := inner.Decode(&record); err != nil || record == nil {
+			return nil, fmt.Errorf("invalid NDJSON at record %d: expected an object", len(records)+1)
+		}
+
+		records = append(records, record)
+		if err := checkLimit(len(records), limit); err != nil {
+			return nil, err
+		}
+	}
+}
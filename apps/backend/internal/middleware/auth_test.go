@@ -0,0 +1,50 @@
+package middleware
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/JonnyWalker81/trendy/backend/internal/repository/memory"
+	"github.com/gin-gonic/gin"
+)
+
+func TestRejectPendingDeletion(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	repos := memory.NewRepositories(memory.NewStore())
+	if _, err := repos.AccountDeletions.Start(context.Background(), "deleting"); err != nil {
+		t.Fatalf("Start failed: %v", err)
+	}
+
+	router := gin.New()
+	router.Use(func(c *gin.Context) {
+		c.Set("user_id", c.GetHeader("X-User"))
+	}, RejectPendingDeletion(repos.AccountDeletions))
+	router.GET("/events", func(c *gin.Context) { c.Status(http.StatusOK) })
+	router.POST("/events", func(c *gin.Context) { c.Status(http.StatusCreated) })
+
+	tests := []struct {
+		name   string
+		method string
+		user   string
+		want   int
+	}{
+		{"write by active user", http.MethodPost, "active", http.StatusCreated},
+		{"read during deletion", http.MethodGet, "deleting", http.StatusOK},
+		{"write during deletion", http.MethodPost, "deleting", http.StatusConflict},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(tt.method, "/events", nil)
+			req.Header.Set("X-User", tt.user)
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, req)
+
+			if w.Code != tt.want {
+				t.Errorf("expected %d, got %d", tt.want, w.Code)
+			}
+		})
+	}
+}
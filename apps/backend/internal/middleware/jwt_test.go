@@ -0,0 +1,94 @@
+package middleware
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/JonnyWalker81/trendy/backend/internal/repository/memory"
+	"github.com/gin-gonic/gin"
+)
+
+const testUserID = "0b4c3f5e-7a3d-4e1b-9c2a-6d8e1f2a3b4c"
+
+// signJWT builds a token with the given header algorithm and claims, signed
+// with secret
+func signJWT(t *testing.T, alg, secret string, claims map[string]interface{}) string {
+	t.Helper()
+	encode := func(v interface{}) string {
+		raw, err := json.Marshal(v)
+		if err != nil {
+			t.Fatalf("Marshal failed: %v", err)
+		}
+		return base64.RawURLEncoding.EncodeToString(raw)
+	}
+	signed := encode(map[string]string{"alg": alg, "typ": "JWT"}) + "." + encode(claims)
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(signed))
+	return signed + "." + base64.RawURLEncoding.EncodeToString(mac.Sum(nil))
+}
+
+func TestJWTVerifier(t *testing.T) {
+	now := time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC)
+	verifier := NewJWTVerifier("secret")
+	verifier.now = func() time.Time { return now }
+
+	valid := map[string]interface{}{"sub": testUserID, "email": "a@example.com", "exp": now.Add(time.Hour).Unix()}
+	tests := []struct {
+		name  string
+		token string
+		ok    bool
+	}{
+		{"valid", signJWT(t, "HS256", "secret", valid), true},
+		{"wrong secret", signJWT(t, "HS256", "other", valid), false},
+		{"unsigned", signJWT(t, "none", "secret", valid), false},
+		{"expired", signJWT(t, "HS256", "secret", map[string]interface{}{"sub": testUserID, "exp": now.Unix()}), false},
+		{"no expiry", signJWT(t, "HS256", "secret", map[string]interface{}{"sub": testUserID}), false},
+		{"not yet valid", signJWT(t, "HS256", "secret", map[string]interface{}{"sub": testUserID, "exp": now.Add(time.Hour).Unix(), "nbf": now.Add(time.Minute).Unix()}), false},
+		{"subject not a UUID", signJWT(t, "HS256", "secret", map[string]interface{}{"sub": "alice", "exp": now.Add(time.Hour).Unix()}), false},
+		{"malformed", "not-a-token", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			user, err := verifier.VerifyToken(tt.token)
+			if tt.ok {
+				if err != nil || user.ID != testUserID || user.Email != "a@example.com" {
+					t.Errorf("expected the token's user, got %+v, %v", user, err)
+				}
+				return
+			}
+			if err == nil {
+				t.Errorf("expected the token to be refused, got %+v", user)
+			}
+		})
+	}
+}
+
+func TestAuthCreatesUserOnFirstSight(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	repos := memory.NewRepositories(memory.NewStore())
+
+	router := gin.New()
+	router.Use(Auth(NewJWTVerifier("secret"), repos.Users))
+	router.GET("/me", func(c *gin.Context) { c.String(http.StatusOK, c.GetString("user_id")) })
+
+	token := signJWT(t, "HS256", "secret", map[string]interface{}{"sub": testUserID, "exp": time.Now().Add(time.Hour).Unix()})
+	req := httptest.NewRequest(http.MethodGet, "/me", nil)
+	req.Header.Set("Authorization", "Bearer "+token)
+	rec := httptest.NewRecorder()
+	router.ServeHTTP(rec, req)
+
+	if rec.Code != http.StatusOK || rec.Body.String() != testUserID {
+		t.Fatalf("expected the token's user to be authenticated, got %d %q", rec.Code, rec.Body.String())
+	}
+	if _, err := repos.Users.GetByID(context.Background(), testUserID); err != nil {
+		t.Errorf("expected the user record to be created: %v", err)
+	}
+}
@@ -0,0 +1,74 @@
+package models
+
+import "time"
+
+// AccountDeletionStatus is the state of an account deletion
+type AccountDeletionStatus string
+
+const (
+	AccountDeletionPending   AccountDeletionStatus = "pending"
+	AccountDeletionCompleted AccountDeletionStatus = "completed"
+)
+
+// AccountDeletionStep is one stage of erasing an account. Every step can be
+// repeated, so an interrupted deletion resumes at the first step it has not
+// completed.
+type AccountDeletionStep string
+
+const (
+	AccountDeletionStepEvents              AccountDeletionStep = "events"
+	AccountDeletionStepGeofences           AccountDeletionStep = "geofences"
+	AccountDeletionStepPropertyDefinitions AccountDeletionStep = "property_definitions"
+	AccountDeletionStepEventTypes          AccountDeletionStep = "event_types"
+	AccountDeletionStepInsights            AccountDeletionStep = "insights"
+	AccountDeletionStepDailyAggregates     AccountDeletionStep = "daily_aggregates"
+	AccountDeletionStepAggregateState      AccountDeletionStep = "aggregate_state"
+	AccountDeletionStepStreaks             AccountDeletionStep = "streaks"
+	AccountDeletionStepOnboardingStatus    AccountDeletionStep = "onboarding_status"
+	AccountDeletionStepUserSettings        AccountDeletionStep = "user_settings"
+	AccountDeletionStepImportJobs          AccountDeletionStep = "import_jobs"
+	AccountDeletionStepJobs                AccountDeletionStep = "jobs"
+	AccountDeletionStepAccountExports      AccountDeletionStep = "account_exports"
+	AccountDeletionStepIdempotencyKeys     AccountDeletionStep = "idempotency_keys"
+	AccountDeletionStepChangeLog           AccountDeletionStep = "change_log"
+	AccountDeletionStepChangeOutbox        AccountDeletionStep = "change_outbox"
+	AccountDeletionStepUser                AccountDeletionStep = "user"
+	AccountDeletionStepAuthUser            AccountDeletionStep = "auth_user"
+)
+
+// AccountDeletionSteps lists the steps of an account deletion in the order
+// they run. Queued jobs are cancelled first so none runs against a partly
+// erased account. The auth user is deleted last so the account can still
+// sign in to retry a deletion that was interrupted.
+var AccountDeletionSteps = []AccountDeletionStep{
+	AccountDeletionStepJobs,
+	AccountDeletionStepEvents,
+	AccountDeletionStepGeofences,
+	AccountDeletionStepPropertyDefinitions,
+	AccountDeletionStepEventTypes,
+	AccountDeletionStepInsights,
+	AccountDeletionStepDailyAggregates,
+	AccountDeletionStepAggregateState,
+	AccountDeletionStepStreaks,
+	AccountDeletionStepOnboardingStatus,
+	AccountDeletionStepUserSettings,
+	AccountDeletionStepImportJobs,
+	AccountDeletionStepAccountExports,
+	AccountDeletionStepIdempotencyKeys,
+	AccountDeletionStepChangeLog,
+	AccountDeletionStepChangeOutbox,
+	AccountDeletionStepUser,
+	AccountDeletionStepAuthUser,
+}
+
+// AccountDeletion is the audit tombstone of an account deletion. It outlives
+// the account and records only the user ID and the deletion's progress.
+type AccountDeletion struct {
+	ID             string                `json:"id"`
+	UserID         string                `json:"user_id"`
+	Status         AccountDeletionStatus `json:"status"`
+	CompletedSteps []AccountDeletionStep `json:"completed_steps"`
+	RequestedAt    time.Time             `json:"requested_at"`
+	UpdatedAt      time.Time             `json:"updated_at"`
+	CompletedAt    *time.Time            `json:"completed_at,omitempty"`
+}
@@ -0,0 +1,66 @@
+package models
+
+import "time"
+
+// AccountExportStatus is the state of an account export
+type AccountExportStatus string
+
+const (
+	AccountExportPending   AccountExportStatus = "pending"
+	AccountExportRunning   AccountExportStatus = "running"
+	AccountExportCompleted AccountExportStatus = "completed"
+	AccountExportFailed    AccountExportStatus = "failed"
+)
+
+// AccountExport tracks the archive of everything a user owns. The archive
+// itself is downloaded separately once the export has completed.
+type AccountExport struct {
+	ID          string              `json:"id"`
+	UserID      string              `json:"user_id"`
+	Status      AccountExportStatus `json:"status"`
+	SizeBytes   int64               `json:"size_bytes"`
+	Error       *string             `json:"error,omitempty"` // Set when the export failed
+	CreatedAt   time.Time           `json:"created_at"`
+	UpdatedAt   time.Time           `json:"updated_at"`
+	CompletedAt *time.Time          `json:"completed_at,omitempty"`
+	ExpiresAt   *time.Time          `json:"expires_at,omitempty"` // The archive cannot be downloaded after this
+}
+
+const (
+	// AccountArchiveFormat identifies an account archive in its manifest
+	AccountArchiveFormat = "trendy-account-export"
+	// AccountArchiveVersion is the archive layout written by account exports.
+	// Restores accept this version and older ones.
+	AccountArchiveVersion = 1
+)
+
+// Files of an account archive
+const (
+	AccountArchiveManifestFile            = "manifest.json"
+	AccountArchiveUserFile                = "user.json"
+	AccountArchiveEventTypesFile          = "event_types.json"
+	AccountArchivePropertyDefinitionsFile = "property_definitions.json"
+	AccountArchiveGeofencesFile           = "geofences.json"
+	AccountArchiveEventsFile              = "events.ndjson"
+	AccountArchiveEventsCSVFile           = "events.csv" // For reading only; restores use events.ndjson
+	AccountArchiveInsightsFile            = "insights.json"
+	AccountArchiveStreaksFile             = "streaks.json"
+	AccountArchiveDailyAggregatesFile     = "daily_aggregates.json"
+	AccountArchiveOnboardingStatusFile    = "onboarding_status.json"
+)
+
+// AccountArchiveManifest describes the files of an account archive
+type AccountArchiveManifest struct {
+	Format     string               `json:"format"`
+	Version    int                  `json:"version"`
+	ExportedAt time.Time            `json:"exported_at"`
+	UserID     string               `json:"user_id"`
+	Files      []AccountArchiveFile `json:"files"`
+}
+
+// AccountArchiveFile is one file listed in an account archive manifest
+type AccountArchiveFile struct {
+	Name    string `json:"name"`
+	Records int    `json:"records"`
+	SHA256  string `json:"sha256"`
+}
@@ -64,3 +64,14 @@ type ChangeLogInput struct {
 	Data       interface{} // Will be marshaled to JSON
 	DeletedAt  *time.Time  // Set for delete operations
 }
+
+// OutboxEntry is a committed write to a synced entity whose change log entry
+// may be missing. Database triggers record one for every write.
+type OutboxEntry struct {
+	ID              int64      `json:"id"`
+	EntityType      EntityType `json:"entity_type"`
+	EntityID        string     `json:"entity_id"`
+	UserID          string     `json:"user_id"`
+	ChangedAt       time.Time  `json:"changed_at"`                 // When the write committed
+	LoggedOperation *Operation `json:"logged_operation,omitempty"` // Operation of the entity's latest change log entry, if any
+}
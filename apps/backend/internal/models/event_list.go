@@ -0,0 +1,89 @@
+package models
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// EventFilter narrows a list of events. Zero-valued fields do not filter.
+type EventFilter struct {
+	EventTypeIDs []string
+	StartDate    *time.Time // Inclusive
+	EndDate      *time.Time // Inclusive
+	SourceType   string
+	GeofenceID   string
+	HasNotes     *bool
+	Properties   []PropertyPredicate
+	Search       *SearchQuery // Full-text search over Event.SearchFields
+}
+
+// PropertyOperator compares a property value in a PropertyPredicate
+type PropertyOperator string
+
+const (
+	PropertyOpEq  PropertyOperator = "="
+	PropertyOpNe  PropertyOperator = "!="
+	PropertyOpGt  PropertyOperator = ">"
+	PropertyOpGte PropertyOperator = ">="
+	PropertyOpLt  PropertyOperator = "<"
+	PropertyOpLte PropertyOperator = "<="
+)
+
+// PropertyPredicate matches events whose property Key compares to Value.
+// Numeric values compare numerically and only match numeric properties;
+// other values compare as text. Events without the property never match.
+type PropertyPredicate struct {
+	Key   string
+	Op    PropertyOperator
+	Value string
+}
+
+// Number returns the predicate's value if it is a JSON number
+func (p PropertyPredicate) Number() (float64, bool) {
+	if !json.Valid([]byte(p.Value)) {
+		return 0, false
+	}
+	n, err := strconv.ParseFloat(p.Value, 64)
+	if err != nil {
+		return 0, false
+	}
+	return n, true
+}
+
+// EventPage is one page of a cursor-paginated event list. NextCursor is
+// empty when there are no more events.
+type EventPage struct {
+	Events     []Event `json:"events"`
+	NextCursor string  `json:"next_cursor,omitempty"`
+	HasMore    bool    `json:"has_more"`
+}
+
+// Encode returns the cursor as an opaque string for API clients
+func (c EventCursor) Encode() string {
+	raw := c.Timestamp.UTC().Format(time.RFC3339Nano) + "|" + c.ID
+	return base64.RawURLEncoding.EncodeToString([]byte(raw))
+}
+
+// ParseEventCursor decodes a cursor returned by EventCursor.Encode
+func ParseEventCursor(s string) (*EventCursor, error) {
+	raw, err := base64.RawURLEncoding.DecodeString(s)
+	if err != nil {
+		return nil, fmt.Errorf("invalid cursor")
+	}
+
+	ts, id, ok := strings.Cut(string(raw), "|")
+	if !ok || id == "" {
+		return nil, fmt.Errorf("invalid cursor")
+	}
+
+	timestamp, err := time.Parse(time.RFC3339Nano, ts)
+	if err != nil {
+		return nil, fmt.Errorf("invalid cursor")
+	}
+
+	return &EventCursor{Timestamp: timestamp, ID: id}, nil
+}
@@ -0,0 +1,209 @@
+package models
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"unicode"
+)
+
+// maxSearchTerms bounds the words and phrases of one search query
+const maxSearchTerms = 16
+
+// SearchTerm is one word or quoted phrase of a search query. With Prefix set
+// the last word also matches longer words it begins.
+type SearchTerm struct {
+	Words  []string
+	Prefix bool
+}
+
+// SearchQuery matches text that contains every term. Words are compared
+// after TokenizeSearchText, like Postgres' "simple" text search
+// configuration: lowercased, without stemming or stop words.
+type SearchQuery struct {
+	Terms []SearchTerm
+}
+
+// ParseSearchQuery parses a query of words and "quoted phrases". A trailing
+// * makes a word, or the last word of a phrase, a prefix.
+func ParseSearchQuery(q string) (*SearchQuery, error) {
+	var terms []SearchTerm
+	addTerm := func(raw string) {
+		prefix := strings.HasSuffix(raw, "*")
+		var words []string
+		for _, token := range TokenizeSearchText(raw) {
+			words = append(words, token.Word)
+		}
+		if len(words) > 0 {
+			terms = append(terms, SearchTerm{Words: words, Prefix: prefix})
+		}
+	}
+
+	rest := strings.TrimSpace(q)
+	for rest != "" {
+		if phrase, ok := strings.CutPrefix(rest, `"`); ok {
+			phrase, after, _ := strings.Cut(phrase, `"`)
+			// Allow both "morning ru*" and "morning ru"*
+			if strings.HasPrefix(after, "*") {
+				phrase += "*"
+			}
+			addTerm(phrase)
+			rest = strings.TrimLeft(after, "*")
+		} else {
+			end := strings.IndexFunc(rest, unicode.IsSpace)
+			if end < 0 {
+				end = len(rest)
+			}
+			addTerm(rest[:end])
+			rest = rest[end:]
+		}
+		rest = strings.TrimSpace(rest)
+	}
+
+	if len(terms) == 0 {
+		return nil, fmt.Errorf("search query has no words")
+	}
+	if len(terms) > maxSearchTerms {
+		return nil, fmt.Errorf("search query has more than %d terms", maxSearchTerms)
+	}
+
+	return &SearchQuery{Terms: terms}, nil
+}
+
+// TSQuery returns the query in Postgres to_tsquery syntax. Words only
+// contain letters and digits, so no escaping is needed.
+func (q *SearchQuery) TSQuery() string {
+	terms := make([]string, len(q.Terms))
+	for i, term := range q.Terms {
+		words := strings.Join(term.Words, " <-> ")
+		if term.Prefix {
+			words += ":*"
+		}
+		terms[i] = words
+	}
+	return strings.Join(terms, " & ")
+}
+
+// Matches reports whether tokens contain every term of the query
+func (q *SearchQuery) Matches(tokens []SearchToken) bool {
+	for _, term := range q.Terms {
+		found := false
+		for i := range tokens {
+			if term.MatchAt(tokens, i) {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return false
+		}
+	}
+	return true
+}
+
+// MatchAt reports whether the term matches the tokens starting at tokens[i]
+func (t SearchTerm) MatchAt(tokens []SearchToken, i int) bool {
+	if i+len(t.Words) > len(tokens) {
+		return false
+	}
+	for j, word := range t.Words {
+		got := tokens[i+j].Word
+		if t.Prefix && j == len(t.Words)-1 {
+			if !strings.HasPrefix(got, word) {
+				return false
+			}
+		} else if got != word {
+			return false
+		}
+	}
+	return true
+}
+
+// SearchToken is a lowercased word of a text and its byte range in the text
+type SearchToken struct {
+	Word       string
+	Start, End int
+}
+
+// TokenizeSearchText splits text into runs of letters and digits
+func TokenizeSearchText(text string) []SearchToken {
+	var tokens []SearchToken
+	start := -1
+	for i, r := range text {
+		isWord := unicode.IsLetter(r) || unicode.IsDigit(r)
+		switch {
+		case isWord && start < 0:
+			start = i
+		case !isWord && start >= 0:
+			tokens = append(tokens, SearchToken{Word: strings.ToLower(text[start:i]), Start: start, End: i})
+			start = -1
+		}
+	}
+	if start >= 0 {
+		tokens = append(tokens, SearchToken{Word: strings.ToLower(text[start:]), Start: start, End: len(text)})
+	}
+	return tokens
+}
+
+// SearchField is a searchable text of an event
+type SearchField struct {
+	Name string // e.g. "notes" or "properties.mood"
+	Text string
+}
+
+// SearchFields returns the texts full-text search looks at: the original
+// title, notes, location name and text or select property values, with
+// properties in key order. The Postgres search_vector column indexes the
+// same texts.
+func (e *Event) SearchFields() []SearchField {
+	var fields []SearchField
+	add := func(name string, text *string) {
+		if text != nil && *text != "" {
+			fields = append(fields, SearchField{Name: name, Text: *text})
+		}
+	}
+	add("original_title", e.OriginalTitle)
+	add("notes", e.Notes)
+	add("location_name", e.LocationName)
+
+	keys := make([]string, 0, len(e.Properties))
+	for key := range e.Properties {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	for _, key := range keys {
+		prop := e.Properties[key]
+		if prop.Type != PropertyTypeText && prop.Type != PropertyTypeSelect {
+			continue
+		}
+		if text, ok := prop.Value.(string); ok {
+			add("properties."+key, &text)
+		}
+	}
+
+	return fields
+}
+
+// SearchHighlight is a snippet of a matched field with every match wrapped
+// in <mark></mark>. The rest of the snippet is HTML-escaped.
+type SearchHighlight struct {
+	Field   string `json:"field"`
+	Snippet string `json:"snippet"`
+}
+
+// EventSearchResult is an event matched by a full-text search
+type EventSearchResult struct {
+	Event      Event             `json:"event"`
+	Score      float64           `json:"score"`
+	Highlights []SearchHighlight `json:"highlights"`
+}
+
+// EventSearchResponse is one page of search results, best match first
+type EventSearchResponse struct {
+	Results []EventSearchResult `json:"results"`
+	HasMore bool                `json:"has_more"`
+	// Truncated is set when more events matched than a search ranks. Only
+	// the most recent matches were ranked; narrowing the search finds the
+	// rest.
+	Truncated bool `json:"truncated"`
+}
@@ -0,0 +1,53 @@
+package models
+
+import "testing"
+
+func TestParseSearchQuery(t *testing.T) {
+	tests := []struct {
+		name        string
+		query       string
+		wantTSQuery string
+		wantErr     bool
+	}{
+		{name: "words", query: "Morning  RUN", wantTSQuery: "morning & run"},
+		{name: "prefix", query: "jog*", wantTSQuery: "jog:*"},
+		{name: "phrase", query: `"morning run" park`, wantTSQuery: "morning <-> run & park"},
+		{name: "phrase prefix inside quotes", query: `"morning ru*"`, wantTSQuery: "morning <-> ru:*"},
+		{name: "phrase prefix after quotes", query: `"morning ru"*`, wantTSQuery: "morning <-> ru:*"},
+		{name: "unterminated phrase", query: `"felt great`, wantTSQuery: "felt <-> great"},
+		{name: "operators are not passed through", query: "a&b | !c:", wantTSQuery: "a <-> b & c"},
+		{name: "no words", query: ` "" * !`, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			q, err := ParseSearchQuery(tt.query)
+			if tt.wantErr {
+				if err == nil {
+					t.Fatalf("expected an error, got %+v", q)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("ParseSearchQuery failed: %v", err)
+			}
+			if got := q.TSQuery(); got != tt.wantTSQuery {
+				t.Errorf("TSQuery() = %q, want %q", got, tt.wantTSQuery)
+			}
+		})
+	}
+}
+
+func TestSearchQueryMatches(t *testing.T) {
+	q, err := ParseSearchQuery(`"morning run" park*`)
+	if err != nil {
+		t.Fatalf("ParseSearchQuery failed: %v", err)
+	}
+
+	if !q.Matches(TokenizeSearchText("A Morning-run in Parkville")) {
+		t.Error("expected the phrase and the prefix to match")
+	}
+	if q.Matches(TokenizeSearchText("run in the morning at the park")) {
+		t.Error("a phrase must match its words in order")
+	}
+}
@@ -0,0 +1,22 @@
+package models
+
+import "time"
+
+// ExportFormat is an output format of the event export
+type ExportFormat string
+
+const (
+	ExportFormatJSON   ExportFormat = "json"
+	ExportFormatCSV    ExportFormat = "csv"
+	ExportFormatNDJSON ExportFormat = "ndjson"
+	ExportFormatICS    ExportFormat = "ics"
+)
+
+// ExportEventsRequest holds the filters of an event export. The date range
+// only applies when both ends are set.
+type ExportEventsRequest struct {
+	StartDate    *time.Time
+	EndDate      *time.Time
+	EventTypeIDs []string
+	Format       ExportFormat
+}
@@ -0,0 +1,89 @@
+package models
+
+import (
+	"time"
+
+	"github.com/JonnyWalker81/trendy/backend/internal/apierror"
+)
+
+// ImportFormat is a file format accepted by the event import
+type ImportFormat string
+
+const (
+	ImportFormatCSV    ImportFormat = "csv"
+	ImportFormatJSON   ImportFormat = "json"
+	ImportFormatNDJSON ImportFormat = "ndjson"
+	ImportFormatICS    ImportFormat = "ics"
+	// ImportFormatArchive is an account archive restored with POST /me/import
+	ImportFormatArchive ImportFormat = "archive"
+)
+
+// ImportMapping maps the fields of an import file to event fields. Field
+// names are CSV column headers or JSON object keys; iCalendar events are read
+// into the column names of a CSV export. Empty fields fall back to the names
+// used by the exports, so an export can be imported again without a mapping.
+type ImportMapping struct {
+	EventType         string `json:"event_type,omitempty"` // Event type name or ID
+	DefaultEventType  string `json:"default_event_type,omitempty"`
+	Timestamp         string `json:"timestamp,omitempty"`
+	TimestampLayout   string `json:"timestamp_layout,omitempty"` // Go time layout; RFC 3339 by default
+	EndDate           string `json:"end_date,omitempty"`
+	IsAllDay          string `json:"is_all_day,omitempty"`
+	Notes             string `json:"notes,omitempty"`
+	LocationName      string `json:"location_name,omitempty"`
+	LocationLatitude  string `json:"location_latitude,omitempty"`
+	LocationLongitude string `json:"location_longitude,omitempty"`
+	// Properties maps source fields to property keys
+	Properties map[string]string `json:"properties,omitempty"`
+	// CreateEventTypes creates event types named in the file that do not exist
+	CreateEventTypes bool `json:"create_event_types,omitempty"`
+	// CreatePropertyDefinitions creates missing property definitions, with a
+	// type inferred from the first value
+	CreatePropertyDefinitions bool `json:"create_property_definitions,omitempty"`
+}
+
+// ImportRowError holds the validation errors of one import row. Rows are
+// numbered from 1, not counting a CSV header.
+type ImportRowError struct {
+	Row    int                   `json:"row"`
+	Errors []apierror.FieldError `json:"errors"`
+}
+
+// ImportReport is the result of validating an import file
+type ImportReport struct {
+	TotalRows                   int              `json:"total_rows"`
+	ValidRows                   int              `json:"valid_rows"`
+	InvalidRows                 int              `json:"invalid_rows"`
+	EventTypesToCreate          []string         `json:"event_types_to_create"`
+	PropertyDefinitionsToCreate []string         `json:"property_definitions_to_create"` // "Event type.key"
+	UnmappedFields              []string         `json:"unmapped_fields"`                // Fields that are not imported
+	Errors                      []ImportRowError `json:"errors"`
+	ErrorsTruncated             bool             `json:"errors_truncated,omitempty"`
+}
+
+// ImportJobStatus is the state of an import job
+type ImportJobStatus string
+
+const (
+	ImportJobPending   ImportJobStatus = "pending"
+	ImportJobRunning   ImportJobStatus = "running"
+	ImportJobCompleted ImportJobStatus = "completed"
+	ImportJobFailed    ImportJobStatus = "failed"
+)
+
+// ImportJob tracks an import running in the background
+type ImportJob struct {
+	ID            string           `json:"id"`
+	UserID        string           `json:"user_id"`
+	Status        ImportJobStatus  `json:"status"`
+	Format        ImportFormat     `json:"format"`
+	TotalRows     int              `json:"total_rows"`
+	ProcessedRows int              `json:"processed_rows"`
+	CreatedCount  int              `json:"created_count"`
+	FailedCount   int              `json:"failed_count"`
+	Errors        []ImportRowError `json:"errors"`
+	Error         *string          `json:"error,omitempty"` // Set when the job failed as a whole
+	CreatedAt     time.Time        `json:"created_at"`
+	UpdatedAt     time.Time        `json:"updated_at"`
+	CompletedAt   *time.Time       `json:"completed_at,omitempty"`
+}
@@ -85,19 +85,43 @@ type DailyAggregate struct {
 	TotalDurationSeconds *float64           `json:"total_duration_seconds,omitempty"`
 	AvgNumericValue      *float64           `json:"avg_numeric_value,omitempty"`
 	PropertyAggregates   map[string]PropAgg `json:"property_aggregates,omitempty"`
+	HourCounts           []int              `json:"hour_counts,omitempty"` // Events per local hour of the day, 24 entries
 	CreatedAt            time.Time          `json:"created_at"`
 	UpdatedAt            time.Time          `json:"updated_at"`
 	// Expanded relations (populated on fetch)
 	EventType *EventType `json:"event_type,omitempty"`
 }
 
-// PropAgg holds aggregated values for a single property
+// AggregateState records how far a user's daily aggregates have applied the
+// change log, and the time zone their days are in
+type AggregateState struct {
+	UserID    string    `json:"user_id"`
+	Cursor    int64     `json:"cursor"`
+	Timezone  string    `json:"timezone"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+// AggregatedEvent records the day and event type an event is counted under,
+// so that an update or delete can take it off that day
+type AggregatedEvent struct {
+	EventID     string    `json:"event_id"`
+	UserID      string    `json:"user_id"`
+	EventTypeID string    `json:"event_type_id"`
+	Date        time.Time `json:"date"`
+}
+
+// PropAgg holds aggregated values for a single property. Numbers, durations,
+// ratings and quantities fill the statistics; select and multi-select
+// options and location names are counted in Options.
 type PropAgg struct {
-	Sum   float64 `json:"sum"`
-	Avg   float64 `json:"avg"`
-	Min   float64 `json:"min"`
-	Max   float64 `json:"max"`
-	Count int     `json:"count"`
+	Sum     float64        `json:"sum"`
+	Avg     float64        `json:"avg"`
+	Min     float64        `json:"min"`
+	Max     float64        `json:"max"`
+	Count   int            `json:"count"`
+	Unit    string         `json:"unit,omitempty"`    // Unit of the statistics of a quantity
+	Options map[string]int `json:"options,omitempty"` // Events per option or location name
 }
 
 // Streak represents a consecutive day sequence for an event type
@@ -162,6 +186,9 @@ type InsightsResponse struct {
 	DataSufficient bool            `json:"data_sufficient"`
 	MinDaysNeeded  int             `json:"min_days_needed,omitempty"`
 	TotalDays      int             `json:"total_days"`
+	// Refreshing is set while a recompute is queued or running in the
+	// background; the insights are the previous results, if any
+	Refreshing bool `json:"refreshing,omitempty"`
 }
 
 // InsightMetadata holds additional context for an insight
@@ -0,0 +1,52 @@
+package models
+
+import "time"
+
+// JobKind names the work a background job does
+type JobKind string
+
+const (
+	// JobKindInsights recomputes a user's insights
+	JobKindInsights JobKind = "insights"
+	// JobKindMaintenance is the nightly maintenance run. It belongs to no user.
+	JobKindMaintenance JobKind = "maintenance"
+	// JobKindAggregates applies a user's changes to their daily aggregates
+	JobKindAggregates JobKind = "aggregates"
+)
+
+// JobStatus is the state of a background job
+type JobStatus string
+
+const (
+	JobPending   JobStatus = "pending"
+	JobRunning   JobStatus = "running"
+	JobSucceeded JobStatus = "succeeded"
+	JobFailed    JobStatus = "failed"
+)
+
+// Job is a unit of background work in the durable job queue. A user has at
+// most one pending job of each kind; queueing another moves its run time, up
+// to a limit, so bursts of changes are debounced into one run. A failed run
+// is retried as a new pending job that carries the attempt count and error
+// forward, unless new work is already queued.
+type Job struct {
+	ID          string     `json:"id"`
+	Kind        JobKind    `json:"kind"`
+	UserID      string     `json:"user_id,omitempty"` // Empty for jobs that belong to no user
+	Status      JobStatus  `json:"status"`
+	Attempts    int        `json:"attempts"` // Runs so far, including earlier failed jobs
+	RunAt       time.Time  `json:"run_at"`   // When a pending job becomes due
+	LastError   *string    `json:"last_error,omitempty"`
+	StartedAt   *time.Time `json:"started_at,omitempty"`
+	CompletedAt *time.Time `json:"completed_at,omitempty"`
+	CreatedAt   time.Time  `json:"created_at"`
+	UpdatedAt   time.Time  `json:"updated_at"`
+}
+
+// InsightsStatus describes how current a user's insights are and the state
+// of their background recomputation
+type InsightsStatus struct {
+	ComputedAt *time.Time `json:"computed_at,omitempty"` // When the stored insights were computed
+	Stale      bool       `json:"stale"`                 // The stored insights are past their validity
+	Job        *Job       `json:"job,omitempty"`         // The latest recompute job
+}
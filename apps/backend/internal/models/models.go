@@ -12,13 +12,15 @@ type User struct {
 
 // EventType represents a category of events
 type EventType struct {
-	ID        string    `json:"id"`
-	UserID    string    `json:"user_id"`
-	Name      string    `json:"name"`
-	Color     string    `json:"color"`
-	Icon      string    `json:"icon"`
-	CreatedAt time.Time `json:"created_at"`
-	UpdatedAt time.Time `json:"updated_at"`
+	ID         string     `json:"id"`
+	UserID     string     `json:"user_id"`
+	Name       string     `json:"name"`
+	Color      string     `json:"color"`
+	Icon       string     `json:"icon"`
+	CreatedAt  time.Time  `json:"created_at"`
+	UpdatedAt  time.Time  `json:"updated_at"`
+	DeletedAt  *time.Time `json:"deleted_at,omitempty"`  // Set while the event type is in the trash
+	ArchivedAt *time.Time `json:"archived_at,omitempty"` // Set while the event type is hidden from pickers
 }
 
 // Event represents a tracked event
@@ -42,9 +44,17 @@ type Event struct {
 	Properties        map[string]PropertyValue `json:"properties,omitempty"`
 	CreatedAt         time.Time                `json:"created_at"`
 	UpdatedAt         time.Time                `json:"updated_at"`
+	DeletedAt         *time.Time               `json:"deleted_at,omitempty"` // Set while the event is in the trash
 	EventType         *EventType               `json:"event_type,omitempty"`
 }
 
+// EventCursor is the position of an event in newest-first order, used for
+// keyset pagination
+type EventCursor struct {
+	Timestamp time.Time
+	ID        string
+}
+
 // CreateEventRequest represents the request to create an event
 type CreateEventRequest struct {
 	ID                *string                  `json:"id,omitempty"` // Optional client-generated UUIDv7
@@ -138,9 +148,45 @@ type CreateEventTypeRequest struct {
 
 // UpdateEventTypeRequest represents the request to update an event type
 type UpdateEventTypeRequest struct {
-	Name  *string `json:"name"`
-	Color *string `json:"color"`
-	Icon  *string `json:"icon"`
+	Name     *string `json:"name"`
+	Color    *string `json:"color"`
+	Icon     *string `json:"icon"`
+	Archived *bool   `json:"archived,omitempty"` // Archive or unarchive the event type
+}
+
+// EventTypeDeleteStrategy selects what happens to an event type's events
+// when the event type is deleted
+type EventTypeDeleteStrategy string
+
+const (
+	// DeleteStrategyCascade moves the events to the trash with the event type
+	DeleteStrategyCascade EventTypeDeleteStrategy = "cascade"
+	// DeleteStrategyReassign moves the events to another event type first
+	DeleteStrategyReassign EventTypeDeleteStrategy = "reassign"
+	// DeleteStrategyArchive keeps the event type and its events but hides it
+	// from pickers
+	DeleteStrategyArchive EventTypeDeleteStrategy = "archive"
+)
+
+// DeleteEventTypeRequest holds the query parameters of an event type delete.
+// The zero value cascades.
+type DeleteEventTypeRequest struct {
+	Strategy          EventTypeDeleteStrategy `form:"strategy" binding:"omitempty,oneof=cascade reassign archive"`
+	TargetEventTypeID string                  `form:"target_event_type_id" binding:"required_if=Strategy reassign"`
+}
+
+// MergeEventTypeRequest represents the request to merge an event type into another
+type MergeEventTypeRequest struct {
+	TargetEventTypeID string `json:"target_event_type_id" binding:"required"`
+}
+
+// MergeEventTypeResponse summarizes what a merge moved into the target event type
+type MergeEventTypeResponse struct {
+	EventType                 *EventType        `json:"event_type"`
+	EventsMoved               int               `json:"events_moved"`
+	PropertyDefinitionsCopied int               `json:"property_definitions_copied"`
+	RenamedProperties         map[string]string `json:"renamed_properties,omitempty"` // Source key -> target key, for keys whose type conflicted
+	GeofencesUpdated          int               `json:"geofences_updated"`
 }
 
 // LoginRequest represents the login request
@@ -173,15 +219,104 @@ type AnalyticsSummary struct {
 type TrendData struct {
 	EventTypeID string                `json:"event_type_id"`
 	Period      string                `json:"period"` // "week", "month", "year"
+	Granularity Granularity           `json:"granularity"`
+	Property    string                `json:"property,omitempty"`
+	Unit        string                `json:"unit,omitempty"` // Unit of a quantity property's statistics
 	Data        []TimeSeriesDataPoint `json:"data"`
 	Average     float64               `json:"average"`
 	Trend       string                `json:"trend"` // "increasing", "decreasing", "stable"
 }
 
-// TimeSeriesDataPoint represents a data point in time series
+// TimeSeriesDataPoint represents a data point in time series. Buckets
+// without events are included with a zero count.
 type TimeSeriesDataPoint struct {
-	Date  time.Time `json:"date"`
-	Count int64     `json:"count"`
+	Date     time.Time      `json:"date"` // Start of the bucket in the user's time zone
+	Count    int64          `json:"count"`
+	Duration float64        `json:"duration"`         // Total seconds from timestamp to end_date of the bucket's events
+	Values   *PropertyStats `json:"values,omitempty"` // Statistics of the trend's property, if any values
+}
+
+// Granularity is the size of the buckets of a trend. Buckets follow the
+// calendar: a month bucket runs from the 1st to the 1st.
+type Granularity string
+
+const (
+	GranularityHour    Granularity = "hour"
+	GranularityDay     Granularity = "day"
+	GranularityWeek    Granularity = "week"
+	GranularityMonth   Granularity = "month"
+	GranularityQuarter Granularity = "quarter"
+	GranularityYear    Granularity = "year"
+)
+
+// TrendQuery selects the buckets and values of a trend
+type TrendQuery struct {
+	Period      string      // "week", "month" or "year"; sets the granularity if none is given
+	Granularity Granularity // Empty for the period's default
+	StartDate   time.Time
+	EndDate     time.Time
+	Property    string    // Key of a numeric property to summarize per bucket
+	Percentiles []float64 // Percentiles of Property to report, each from 0 to 100
+}
+
+// PropertyStats summarize the numeric values of a property
+type PropertyStats struct {
+	Count       int                `json:"count"`
+	Sum         float64            `json:"sum"`
+	Avg         float64            `json:"avg"`
+	Min         float64            `json:"min"`
+	Max         float64            `json:"max"`
+	Percentiles map[string]float64 `json:"percentiles,omitempty"` // Keyed "p50", "p90", ...
+}
+
+// PropertyAnalytics describes the values of one property of an event type
+// over a date range. Numeric properties get a Distribution, categorical ones
+// Frequencies.
+type PropertyAnalytics struct {
+	EventTypeID  string                `json:"event_type_id"`
+	PropertyKey  string                `json:"property_key"`
+	PropertyType PropertyType          `json:"property_type"`
+	Unit         string                `json:"unit,omitempty"` // Unit of a quantity property's statistics
+	Granularity  Granularity           `json:"granularity"`
+	StartDate    time.Time             `json:"start_date"`
+	EndDate      time.Time             `json:"end_date"`
+	EventCount   int                   `json:"event_count"` // Events in the range, with or without a value
+	Series       []PropertySeriesPoint `json:"series"`
+	Distribution *PropertyDistribution `json:"distribution,omitempty"`
+	Frequencies  []PropertyFrequency   `json:"frequencies,omitempty"`
+}
+
+// PropertySeriesPoint summarizes a property's values in one bucket. Buckets
+// without values are included with a zero count.
+type PropertySeriesPoint struct {
+	Date        time.Time      `json:"date"`
+	Count       int            `json:"count"` // Events with a value
+	Values      *PropertyStats `json:"values,omitempty"`
+	Frequencies map[string]int `json:"frequencies,omitempty"`
+}
+
+// PropertyDistribution describes all values of a numeric property in a range
+type PropertyDistribution struct {
+	PropertyStats
+	Median    float64        `json:"median"`
+	P90       float64        `json:"p90"`
+	Histogram []HistogramBin `json:"histogram"`
+}
+
+// HistogramBin counts the values from Min up to Max. The last bin includes
+// its Max.
+type HistogramBin struct {
+	Min   float64 `json:"min"`
+	Max   float64 `json:"max"`
+	Count int     `json:"count"`
+}
+
+// PropertyFrequency counts the events with one value of a categorical
+// property. Percent is of the events with any value.
+type PropertyFrequency struct {
+	Value   string  `json:"value"`
+	Count   int     `json:"count"`
+	Percent float64 `json:"percent"`
 }
 
 // PropertyType represents the data type of a custom property
@@ -196,6 +331,16 @@ const (
 	PropertyTypeDuration PropertyType = "duration"
 	PropertyTypeURL      PropertyType = "url"
 	PropertyTypeEmail    PropertyType = "email"
+	// PropertyTypeMultiSelect values are arrays of the definition's options
+	PropertyTypeMultiSelect PropertyType = "multi_select"
+	// PropertyTypeRating values are whole numbers from 1 to the definition's
+	// RatingMax
+	PropertyTypeRating PropertyType = "rating"
+	// PropertyTypeQuantity values are a Quantity, stored in the definition's
+	// Unit
+	PropertyTypeQuantity PropertyType = "quantity"
+	// PropertyTypeLocation values are a Location
+	PropertyTypeLocation PropertyType = "location"
 )
 
 // PropertyDefinition represents a custom property schema for an event type
@@ -208,6 +353,10 @@ type PropertyDefinition struct {
 	PropertyType PropertyType `json:"property_type"`
 	Options      []string     `json:"options,omitempty"`
 	DefaultValue interface{}  `json:"default_value,omitempty"`
+	Required     bool         `json:"required"`             // Events of the type must have a value, unless there is a default
+	Unit         string       `json:"unit,omitempty"`       // Unit quantity values are stored in
+	RatingMax    int          `json:"rating_max,omitempty"` // Top of a rating scale; DefaultRatingMax if zero
+	Formula      string       `json:"formula,omitempty"`    // Makes the property computed, see package formula
 	DisplayOrder int          `json:"display_order"`
 	CreatedAt    time.Time    `json:"created_at"`
 	UpdatedAt    time.Time    `json:"updated_at"`
@@ -228,6 +377,10 @@ type CreatePropertyDefinitionRequest struct {
 	PropertyType PropertyType `json:"property_type" binding:"required"`
 	Options      []string     `json:"options,omitempty"`
 	DefaultValue interface{}  `json:"default_value,omitempty"`
+	Required     bool         `json:"required"`
+	Unit         string       `json:"unit,omitempty"`
+	RatingMax    int          `json:"rating_max,omitempty"`
+	Formula      string       `json:"formula,omitempty"`
 	DisplayOrder int          `json:"display_order"`
 }
 
@@ -238,9 +391,52 @@ type UpdatePropertyDefinitionRequest struct {
 	PropertyType *PropertyType `json:"property_type"`
 	Options      *[]string     `json:"options"`
 	DefaultValue interface{}   `json:"default_value"`
+	Required     *bool         `json:"required"`
+	Unit         *string       `json:"unit"`
+	RatingMax    *int          `json:"rating_max"`
+	Formula      *string       `json:"formula"` // "" makes the property plain again
 	DisplayOrder *int          `json:"display_order"`
 }
 
+// MigratePropertyDefinitionRequest changes the type or key of a property
+// definition and converts the values already stored on its event type's events
+type MigratePropertyDefinitionRequest struct {
+	PropertyType *PropertyType `json:"property_type"`
+	Key          *string       `json:"key"`
+	// Options seeds the options of a select or multi-select; values found on
+	// events are added
+	Options []string `json:"options,omitempty"`
+	// Unit is the unit of a quantity; stored quantities are converted to it
+	Unit      *string `json:"unit"`
+	RatingMax *int    `json:"rating_max"`
+	// DurationUnit is the unit of numbers converted to or from a duration:
+	// "seconds" (default), "minutes" or "hours"
+	DurationUnit string `json:"duration_unit,omitempty"`
+	// ClearUnconvertible removes values that cannot be converted instead of
+	// refusing the migration
+	ClearUnconvertible bool `json:"clear_unconvertible"`
+	DryRun             bool `json:"dry_run"`
+}
+
+// PropertyMigrationFailure is an event value a migration cannot convert
+type PropertyMigrationFailure struct {
+	EventID string      `json:"event_id"`
+	Value   interface{} `json:"value"`
+	Message string      `json:"message"`
+}
+
+// MigratePropertyDefinitionResponse reports a property migration. On a dry
+// run nothing is written and PropertyDefinition is the definition as it
+// would be.
+type MigratePropertyDefinitionResponse struct {
+	PropertyDefinition *PropertyDefinition        `json:"property_definition"`
+	DryRun             bool                       `json:"dry_run"`
+	EventsConverted    int                        `json:"events_converted"`
+	EventsCleared      int                        `json:"events_cleared"`
+	DiscoveredOptions  []string                   `json:"discovered_options,omitempty"` // Select options added from event values
+	Unconvertible      []PropertyMigrationFailure `json:"unconvertible"`
+}
+
 // Geofence represents a geographic region for automatic event tracking
 type Geofence struct {
 	ID                  string     `json:"id"`
@@ -320,3 +516,40 @@ type UpdateOnboardingStatusRequest struct {
 	LocationStatus           *string    `json:"location_status"`
 	LocationCompletedAt      *time.Time `json:"location_completed_at"`
 }
+
+// WeekStart is the day a user's weeks start on
+type WeekStart string
+
+const (
+	WeekStartSunday WeekStart = "sunday"
+	WeekStartMonday WeekStart = "monday"
+)
+
+// Defaults for users who have not saved settings
+const (
+	DefaultTimezone  = "UTC"
+	DefaultWeekStart = WeekStartSunday
+)
+
+// UserSettings are a user's calendar preferences. Analytics, streaks and
+// insights place events on days, weeks and hours in this time zone.
+type UserSettings struct {
+	UserID    string    `json:"user_id"`
+	Timezone  string    `json:"timezone"` // IANA name, e.g. "Asia/Tokyo"
+	WeekStart WeekStart `json:"week_start"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+// UpdateUserSettingsRequest changes the fields that are set
+type UpdateUserSettingsRequest struct {
+	Timezone  *string    `json:"timezone"`
+	WeekStart *WeekStart `json:"week_start"`
+}
+
+// CalendarOptions override a user's stored settings for one request. Empty
+// fields keep the stored value.
+type CalendarOptions struct {
+	Timezone  string
+	WeekStart WeekStart
+}
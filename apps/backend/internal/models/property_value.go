@@ -0,0 +1,218 @@
+package models
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// DefaultRatingMax is the top of a rating scale whose definition sets none
+const DefaultRatingMax = 5
+
+// MaxRatingMax bounds the RatingMax of a definition
+const MaxRatingMax = 10
+
+// Quantity is the value of a quantity property: an amount in a unit
+type Quantity struct {
+	Amount float64 `json:"amount"`
+	Unit   string  `json:"unit"`
+}
+
+func (q Quantity) String() string {
+	return strings.TrimSpace(strconv.FormatFloat(q.Amount, 'f', -1, 64) + " " + q.Unit)
+}
+
+// Location is the value of a location property
+type Location struct {
+	Latitude  float64 `json:"latitude"`
+	Longitude float64 `json:"longitude"`
+	Name      string  `json:"name,omitempty"`
+}
+
+func (l Location) String() string {
+	s := strconv.FormatFloat(l.Latitude, 'f', -1, 64) + ", " + strconv.FormatFloat(l.Longitude, 'f', -1, 64)
+	if l.Name != "" {
+		s += " (" + l.Name + ")"
+	}
+	return s
+}
+
+// quantityUnit is a unit's dimension and its size in the dimension's base
+// unit
+type quantityUnit struct {
+	dimension string
+	factor    float64
+}
+
+// quantityUnits are the units a quantity can be in. Units convert within
+// their dimension. An "oz" is a US fluid ounce.
+var quantityUnits = map[string]quantityUnit{
+	"g":  {"mass", 0.001},
+	"kg": {"mass", 1},
+	"lb": {"mass", 0.45359237},
+	"m":  {"distance", 1},
+	"km": {"distance", 1000},
+	"mi": {"distance", 1609.344},
+	"ml": {"volume", 1},
+	"l":  {"volume", 1000},
+	"oz": {"volume", 29.5735295625},
+}
+
+// IsQuantityUnit reports whether unit is a known quantity unit
+func IsQuantityUnit(unit string) bool {
+	_, ok := quantityUnits[unit]
+	return ok
+}
+
+// CompatibleUnits returns the units unit converts to, itself included, sorted
+func CompatibleUnits(unit string) []string {
+	u, ok := quantityUnits[unit]
+	if !ok {
+		return nil
+	}
+	var units []string
+	for name, other := range quantityUnits {
+		if other.dimension == u.dimension {
+			units = append(units, name)
+		}
+	}
+	sort.Strings(units)
+	return units
+}
+
+// ConvertQuantity converts q to unit. A quantity without a unit is taken to
+// already be in unit.
+func ConvertQuantity(q Quantity, unit string) (Quantity, error) {
+	if q.Unit == "" || q.Unit == unit {
+		return Quantity{Amount: q.Amount, Unit: unit}, nil
+	}
+	from, ok := quantityUnits[q.Unit]
+	if !ok {
+		return Quantity{}, fmt.Errorf("unknown unit %q", q.Unit)
+	}
+	to, ok := quantityUnits[unit]
+	if !ok {
+		return Quantity{}, fmt.Errorf("unknown unit %q", unit)
+	}
+	if from.dimension != to.dimension {
+		return Quantity{}, fmt.Errorf("cannot convert %s to %s", q.Unit, unit)
+	}
+	// Rounded so that e.g. 1 kg in g is 1000, not 999.9999999999999
+	amount := math.Round(q.Amount*from.factor/to.factor*1e9) / 1e9
+	return Quantity{Amount: amount, Unit: unit}, nil
+}
+
+// quantityPattern matches the string form of a quantity, e.g. "72.5 kg"
+var quantityPattern = regexp.MustCompile(`^\s*(-?[0-9]*\.?[0-9]+)\s*([a-zA-Z]*)\s*$`)
+
+// ParseQuantity reads a quantity value: a Quantity, an {"amount", "unit"}
+// object, a bare number or a string such as "72.5 kg". A bare number has no
+// unit.
+func ParseQuantity(v interface{}) (Quantity, bool) {
+	switch v := v.(type) {
+	case Quantity:
+		return v, true
+	case map[string]interface{}:
+		amount, ok := jsonNumber(v["amount"])
+		if !ok {
+			return Quantity{}, false
+		}
+		unit, ok := v["unit"].(string)
+		if !ok && v["unit"] != nil {
+			return Quantity{}, false
+		}
+		return Quantity{Amount: amount, Unit: unit}, true
+	case string:
+		m := quantityPattern.FindStringSubmatch(v)
+		if m == nil {
+			return Quantity{}, false
+		}
+		amount, err := strconv.ParseFloat(m[1], 64)
+		if err != nil {
+			return Quantity{}, false
+		}
+		return Quantity{Amount: amount, Unit: strings.ToLower(m[2])}, true
+	}
+	amount, ok := jsonNumber(v)
+	return Quantity{Amount: amount}, ok
+}
+
+// locationPattern matches the string form of a location, e.g.
+// "47.6062, -122.3321 (Seattle)"
+var locationPattern = regexp.MustCompile(`^\s*(-?[0-9]*\.?[0-9]+)\s*,\s*(-?[0-9]*\.?[0-9]+)\s*(?:\((.*)\))?\s*$`)
+
+// ParseLocation reads a location value: a Location, a {"latitude",
+// "longitude", "name"} object or a string such as "47.6, -122.3 (Seattle)".
+// The coordinates are not range checked.
+func ParseLocation(v interface{}) (Location, bool) {
+	switch v := v.(type) {
+	case Location:
+		return v, true
+	case map[string]interface{}:
+		lat, ok := jsonNumber(v["latitude"])
+		if !ok {
+			return Location{}, false
+		}
+		lon, ok := jsonNumber(v["longitude"])
+		if !ok {
+			return Location{}, false
+		}
+		name, ok := v["name"].(string)
+		if !ok && v["name"] != nil {
+			return Location{}, false
+		}
+		return Location{Latitude: lat, Longitude: lon, Name: name}, true
+	case string:
+		m := locationPattern.FindStringSubmatch(v)
+		if m == nil {
+			return Location{}, false
+		}
+		lat, err := strconv.ParseFloat(m[1], 64)
+		if err != nil {
+			return Location{}, false
+		}
+		lon, err := strconv.ParseFloat(m[2], 64)
+		if err != nil {
+			return Location{}, false
+		}
+		return Location{Latitude: lat, Longitude: lon, Name: strings.TrimSpace(m[3])}, true
+	}
+	return Location{}, false
+}
+
+// ParseMultiSelect reads a multi-select value: an array of strings
+func ParseMultiSelect(v interface{}) ([]string, bool) {
+	switch v := v.(type) {
+	case []string:
+		return v, true
+	case []interface{}:
+		values := make([]string, len(v))
+		for i, item := range v {
+			s, ok := item.(string)
+			if !ok {
+				return nil, false
+			}
+			values[i] = s
+		}
+		return values, true
+	}
+	return nil, false
+}
+
+// jsonNumber returns a decoded JSON number as a float64
+func jsonNumber(v interface{}) (float64, bool) {
+	switch n := v.(type) {
+	case float64:
+		return n, !math.IsNaN(n) && !math.IsInf(n, 0)
+	case int:
+		return float64(n), true
+	case json.Number:
+		f, err := n.Float64()
+		return f, err == nil
+	}
+	return 0, false
+}
@@ -0,0 +1,48 @@
+package models
+
+import "testing"
+
+func TestConvertQuantity(t *testing.T) {
+	tests := []struct {
+		in      Quantity
+		unit    string
+		want    float64
+		wantErr bool
+	}{
+		{Quantity{Amount: 1, Unit: "kg"}, "g", 1000, false},
+		{Quantity{Amount: 10, Unit: "lb"}, "kg", 4.5359237, false},
+		{Quantity{Amount: 5, Unit: "km"}, "mi", 3.106855961, false},
+		{Quantity{Amount: 8, Unit: "oz"}, "ml", 236.5882365, false},
+		{Quantity{Amount: 3}, "l", 3, false},
+		{Quantity{Amount: 1, Unit: "kg"}, "km", 0, true},
+		{Quantity{Amount: 1, Unit: "stone"}, "kg", 0, true},
+	}
+	for _, tt := range tests {
+		got, err := ConvertQuantity(tt.in, tt.unit)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("ConvertQuantity(%v, %s): unexpected error %v", tt.in, tt.unit, err)
+			continue
+		}
+		if !tt.wantErr && (got.Amount != tt.want || got.Unit != tt.unit) {
+			t.Errorf("ConvertQuantity(%v, %s) = %v, want %v %s", tt.in, tt.unit, got, tt.want, tt.unit)
+		}
+	}
+}
+
+func TestParseStructuredValues(t *testing.T) {
+	if q, ok := ParseQuantity("72.5 KG"); !ok || q != (Quantity{Amount: 72.5, Unit: "kg"}) {
+		t.Errorf("unexpected quantity %+v", q)
+	}
+	if _, ok := ParseQuantity(map[string]interface{}{"amount": "72"}); ok {
+		t.Error("expected a string amount to be rejected")
+	}
+	if l, ok := ParseLocation("47.6062, -122.3321 (Seattle)"); !ok || l != (Location{Latitude: 47.6062, Longitude: -122.3321, Name: "Seattle"}) {
+		t.Errorf("unexpected location %+v", l)
+	}
+	if l := (Location{Latitude: 1.5, Longitude: 2}); l.String() != "1.5, 2" {
+		t.Errorf("unexpected location string %q", l.String())
+	}
+	if _, ok := ParseMultiSelect([]interface{}{"a", 1.0}); ok {
+		t.Error("expected a non-string option to be rejected")
+	}
+}
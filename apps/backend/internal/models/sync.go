@@ -0,0 +1,58 @@
+package models
+
+import (
+	"encoding/json"
+	"time"
+)
+
+// SyncMutation is a single offline change pushed by a client
+type SyncMutation struct {
+	EntityType EntityType `json:"entity_type" binding:"required"`
+	Operation  Operation  `json:"operation" binding:"required"`
+	EntityID   string     `json:"entity_id" binding:"required"` // Client-generated UUIDv7 for creates
+	// BaseUpdatedAt is the updated_at of the server version the client last
+	// saw. Updates and deletes conflict if the server copy changed since.
+	// Omit it to apply the change unconditionally.
+	BaseUpdatedAt *time.Time `json:"base_updated_at,omitempty"`
+	// Data is the create or update request body for the entity type, as
+	// accepted by the entity's own create/update route
+	Data json.RawMessage `json:"data,omitempty"`
+}
+
+// SyncPushRequest is a batch of mutations applied in order
+type SyncPushRequest struct {
+	Mutations []SyncMutation `json:"mutations" binding:"required,min=1,max=500,dive"`
+}
+
+// SyncMutationStatus is the outcome of applying one mutation
+type SyncMutationStatus string
+
+const (
+	// SyncMutationApplied means the mutation was written (or was already in effect)
+	SyncMutationApplied SyncMutationStatus = "applied"
+	// SyncMutationConflict means the server copy changed; the server version wins
+	SyncMutationConflict SyncMutationStatus = "conflict"
+	// SyncMutationRejected means the mutation was invalid and nothing was written
+	SyncMutationRejected SyncMutationStatus = "rejected"
+)
+
+// SyncMutationResult reports the outcome of one pushed mutation
+type SyncMutationResult struct {
+	Index      int                `json:"index"`
+	EntityType EntityType         `json:"entity_type"`
+	EntityID   string             `json:"entity_id"`
+	Status     SyncMutationStatus `json:"status"`
+	// Entity is the server copy after the mutation. On conflict it is the
+	// winning server version, or omitted if the entity was deleted.
+	Entity interface{} `json:"entity,omitempty"`
+	Error  string      `json:"error,omitempty"`
+}
+
+// SyncPushResponse reports per-mutation results in request order
+type SyncPushResponse struct {
+	Results   []SyncMutationResult `json:"results"`
+	Total     int                  `json:"total"`
+	Applied   int                  `json:"applied"`
+	Conflicts int                  `json:"conflicts"`
+	Rejected  int                  `json:"rejected"`
+}
@@ -0,0 +1,9 @@
+package models
+
+// Trash lists a user's deleted event types and events that can still be
+// restored. Events of a trashed event type come back when their event type is
+// restored, so they are not listed separately.
+type Trash struct {
+	EventTypes []EventType `json:"event_types"`
+	Events     []Event     `json:"events"`
+}
@@ -0,0 +1,118 @@
+package repository
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+
+	"github.com/JonnyWalker81/trendy/backend/internal/models"
+	"github.com/JonnyWalker81/trendy/backend/pkg/supabase"
+)
+
+type accountDeletionRepository struct {
+	client *supabase.Client
+}
+
+// NewAccountDeletionRepository creates a new account deletion repository
+func NewAccountDeletionRepository(client *supabase.Client) AccountDeletionRepository {
+	return &accountDeletionRepository{client: client}
+}
+
+func (r *accountDeletionRepository) Start(ctx context.Context, userID string) (*models.AccountDeletion, error) {
+	pending, err := r.GetPending(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	if pending != nil {
+		return pending, nil
+	}
+
+	data := map[string]interface{}{
+		"user_id":         userID,
+		"status":          models.AccountDeletionPending,
+		"completed_steps": []models.AccountDeletionStep{},
+	}
+
+	body, err := r.client.Insert("account_deletions", data)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create account deletion: %w", err)
+	}
+
+	var deletions []models.AccountDeletion
+	if err := json.Unmarshal(body, &deletions); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal response: %w", err)
+	}
+
+	if len(deletions) == 0 {
+		return nil, fmt.Errorf("no account deletion returned")
+	}
+
+	return &deletions[0], nil
+}
+
+func (r *accountDeletionRepository) GetPending(ctx context.Context, userID string) (*models.AccountDeletion, error) {
+	query := map[string]interface{}{
+		"user_id": fmt.Sprintf("eq.%s", userID),
+		"status":  fmt.Sprintf("eq.%s", models.AccountDeletionPending),
+	}
+
+	body, err := r.client.Query("account_deletions", query)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get account deletion: %w", err)
+	}
+
+	var deletions []models.AccountDeletion
+	if err := json.Unmarshal(body, &deletions); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal response: %w", err)
+	}
+
+	if len(deletions) == 0 {
+		return nil, nil
+	}
+
+	return &deletions[0], nil
+}
+
+func (r *accountDeletionRepository) ListPending(ctx context.Context) ([]models.AccountDeletion, error) {
+	query := map[string]interface{}{
+		"status": fmt.Sprintf("eq.%s", models.AccountDeletionPending),
+		"order":  "requested_at.asc",
+	}
+
+	body, err := r.client.Query("account_deletions", query)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list account deletions: %w", err)
+	}
+
+	var deletions []models.AccountDeletion
+	if err := json.Unmarshal(body, &deletions); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal response: %w", err)
+	}
+
+	return deletions, nil
+}
+
+func (r *accountDeletionRepository) Update(ctx context.Context, deletion *models.AccountDeletion) (*models.AccountDeletion, error) {
+	data := map[string]interface{}{
+		"status":          deletion.Status,
+		"completed_steps": deletion.CompletedSteps,
+		"completed_at":    deletion.CompletedAt,
+	}
+
+	body, err := r.client.Update("account_deletions", deletion.ID, data)
+	if err != nil {
+		return nil, fmt.Errorf("failed to update account deletion: %w", err)
+	}
+
+	var deletions []models.AccountDeletion
+	if err := json.Unmarshal(body, &deletions); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal response: %w", err)
+	}
+
+	if len(deletions) == 0 {
+		return nil, fmt.Errorf("account deletion not found")
+	}
+
+	return &deletions[0], nil
+}
@@ -0,0 +1,132 @@
+package repository
+
+import (
+	"context"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/JonnyWalker81/trendy/backend/internal/models"
+	"github.com/JonnyWalker81/trendy/backend/pkg/supabase"
+)
+
+// accountExportColumns are the columns read for export metadata, leaving out
+// the archive
+const accountExportColumns = "id,user_id,status,size_bytes,error,created_at,updated_at,completed_at,expires_at"
+
+type accountExportRepository struct {
+	client *supabase.Client
+}
+
+// NewAccountExportRepository creates a new account export repository
+func NewAccountExportRepository(client *supabase.Client) AccountExportRepository {
+	return &accountExportRepository{client: client}
+}
+
+func (r *accountExportRepository) Create(ctx context.Context, export *models.AccountExport) (*models.AccountExport, error) {
+	data := map[string]interface{}{
+		"user_id": export.UserID,
+		"status":  export.Status,
+	}
+
+	body, err := r.client.Insert("account_exports", data)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create account export: %w", err)
+	}
+
+	return decodeAccountExport(body)
+}
+
+func (r *accountExportRepository) GetByID(ctx context.Context, id string) (*models.AccountExport, error) {
+	query := map[string]interface{}{
+		"id":     fmt.Sprintf("eq.%s", id),
+		"select": accountExportColumns,
+	}
+
+	body, err := r.client.Query("account_exports", query)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get account export: %w", err)
+	}
+
+	return decodeAccountExport(body)
+}
+
+func (r *accountExportRepository) GetArchive(ctx context.Context, id string) ([]byte, error) {
+	query := map[string]interface{}{
+		"id":     fmt.Sprintf("eq.%s", id),
+		"select": "archive",
+	}
+
+	body, err := r.client.Query("account_exports", query)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get account export archive: %w", err)
+	}
+
+	var rows []struct {
+		Archive *string `json:"archive"`
+	}
+	if err := json.Unmarshal(body, &rows); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal response: %w", err)
+	}
+
+	if len(rows) == 0 || rows[0].Archive == nil {
+		return nil, fmt.Errorf("account export archive not found")
+	}
+
+	// PostgREST returns bytea columns in hex format
+	archive, err := hex.DecodeString(strings.TrimPrefix(*rows[0].Archive, `\x`))
+	if err != nil {
+		return nil, fmt.Errorf("failed to decode archive: %w", err)
+	}
+
+	return archive, nil
+}
+
+func (r *accountExportRepository) UpdateStatus(ctx context.Context, export *models.AccountExport, archive []byte) (*models.AccountExport, error) {
+	data := map[string]interface{}{
+		"status":       export.Status,
+		"size_bytes":   export.SizeBytes,
+		"error":        export.Error,
+		"completed_at": export.CompletedAt,
+		"expires_at":   export.ExpiresAt,
+	}
+	if archive != nil {
+		data["archive"] = `\x` + hex.EncodeToString(archive)
+	}
+
+	body, err := r.client.UpdateWhere("account_exports", map[string]interface{}{
+		"id":     fmt.Sprintf("eq.%s", export.ID),
+		"select": accountExportColumns,
+	}, data)
+	if err != nil {
+		return nil, fmt.Errorf("failed to update account export: %w", err)
+	}
+
+	return decodeAccountExport(body)
+}
+
+func (r *accountExportRepository) DeleteByUserID(ctx context.Context, userID string) error {
+	query := map[string]interface{}{
+		"user_id": fmt.Sprintf("eq.%s", userID),
+	}
+
+	if err := r.client.DeleteWhere("account_exports", query); err != nil {
+		return fmt.Errorf("failed to delete account exports: %w", err)
+	}
+
+	return nil
+}
+
+func decodeAccountExport(body []byte) (*models.AccountExport, error) {
+	var exports []models.AccountExport
+	if err := json.Unmarshal(body, &exports); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal response: %w", err)
+	}
+
+	if len(exports) == 0 {
+		return nil, fmt.Errorf("account export not found")
+	}
+
+	return &exports[0], nil
+}
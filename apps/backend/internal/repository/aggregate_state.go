@@ -0,0 +1,148 @@
+package repository
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/JonnyWalker81/trendy/backend/internal/models"
+	"github.com/JonnyWalker81/trendy/backend/pkg/supabase"
+)
+
+type aggregateStateRepository struct {
+	client *supabase.Client
+}
+
+// NewAggregateStateRepository creates a new aggregate state repository
+func NewAggregateStateRepository(client *supabase.Client) AggregateStateRepository {
+	return &aggregateStateRepository{client: client}
+}
+
+func (r *aggregateStateRepository) GetByUserID(ctx context.Context, userID string) (*models.AggregateState, error) {
+	query := map[string]interface{}{
+		"user_id": fmt.Sprintf("eq.%s", userID),
+	}
+
+	body, err := r.client.Query("aggregate_states", query)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get aggregate state: %w", err)
+	}
+
+	var states []models.AggregateState
+	if err := json.Unmarshal(body, &states); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal response: %w", err)
+	}
+
+	if len(states) == 0 {
+		return nil, nil
+	}
+
+	return &states[0], nil
+}
+
+func (r *aggregateStateRepository) Upsert(ctx context.Context, state *models.AggregateState) error {
+	data := map[string]interface{}{
+		"user_id":  state.UserID,
+		"cursor":   state.Cursor,
+		"timezone": state.Timezone,
+	}
+
+	if _, err := r.client.Upsert("aggregate_states", data, "user_id"); err != nil {
+		return fmt.Errorf("failed to upsert aggregate state: %w", err)
+	}
+
+	return nil
+}
+
+func (r *aggregateStateRepository) GetEvents(ctx context.Context, userID string, eventIDs []string) ([]models.AggregatedEvent, error) {
+	if len(eventIDs) == 0 {
+		return []models.AggregatedEvent{}, nil
+	}
+
+	query := map[string]interface{}{
+		"user_id":  fmt.Sprintf("eq.%s", userID),
+		"event_id": fmt.Sprintf("in.(%s)", strings.Join(eventIDs, ",")),
+	}
+
+	body, err := r.client.Query("aggregated_events", query)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get aggregated events: %w", err)
+	}
+
+	// PostgREST returns the DATE column as "2006-01-02"
+	var rows []struct {
+		models.AggregatedEvent
+		Date string `json:"date"`
+	}
+	if err := json.Unmarshal(body, &rows); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal response: %w", err)
+	}
+
+	events := make([]models.AggregatedEvent, len(rows))
+	for i, row := range rows {
+		date, err := time.Parse("2006-01-02", row.Date)
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse aggregated event date: %w", err)
+		}
+		events[i] = row.AggregatedEvent
+		events[i].Date = date
+	}
+
+	return events, nil
+}
+
+func (r *aggregateStateRepository) UpsertEvents(ctx context.Context, events []models.AggregatedEvent) error {
+	if len(events) == 0 {
+		return nil
+	}
+
+	data := make([]map[string]interface{}, len(events))
+	for i, e := range events {
+		data[i] = map[string]interface{}{
+			"event_id":      e.EventID,
+			"user_id":       e.UserID,
+			"event_type_id": e.EventTypeID,
+			"date":          e.Date.Format("2006-01-02"),
+		}
+	}
+
+	if _, err := r.client.Upsert("aggregated_events", data, "event_id"); err != nil {
+		return fmt.Errorf("failed to upsert aggregated events: %w", err)
+	}
+
+	return nil
+}
+
+func (r *aggregateStateRepository) DeleteEvents(ctx context.Context, userID string, eventIDs []string) error {
+	if len(eventIDs) == 0 {
+		return nil
+	}
+
+	query := map[string]interface{}{
+		"user_id":  fmt.Sprintf("eq.%s", userID),
+		"event_id": fmt.Sprintf("in.(%s)", strings.Join(eventIDs, ",")),
+	}
+
+	if err := r.client.DeleteWhere("aggregated_events", query); err != nil {
+		return fmt.Errorf("failed to delete aggregated events: %w", err)
+	}
+
+	return nil
+}
+
+func (r *aggregateStateRepository) DeleteByUserID(ctx context.Context, userID string) error {
+	query := map[string]interface{}{
+		"user_id": fmt.Sprintf("eq.%s", userID),
+	}
+
+	if err := r.client.DeleteWhere("aggregated_events", query); err != nil {
+		return fmt.Errorf("failed to delete aggregated events: %w", err)
+	}
+	if err := r.client.DeleteWhere("aggregate_states", query); err != nil {
+		return fmt.Errorf("failed to delete aggregate state: %w", err)
+	}
+
+	return nil
+}
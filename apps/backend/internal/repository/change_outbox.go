@@ -0,0 +1,62 @@
+package repository
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/JonnyWalker81/trendy/backend/internal/models"
+	"github.com/JonnyWalker81/trendy/backend/pkg/supabase"
+)
+
+type changeOutboxRepository struct {
+	client *supabase.Client
+}
+
+// NewChangeOutboxRepository creates a new change outbox repository
+func NewChangeOutboxRepository(client *supabase.Client) ChangeOutboxRepository {
+	return &changeOutboxRepository{client: client}
+}
+
+func (r *changeOutboxRepository) Claim(ctx context.Context, settledBefore time.Time, lease time.Duration, limit int) ([]models.OutboxEntry, error) {
+	body, err := r.client.RPC("claim_change_outbox", map[string]interface{}{
+		"p_settled_before": settledBefore.UTC().Format(time.RFC3339Nano),
+		"p_lease_seconds":  int(lease.Seconds()),
+		"p_limit":          limit,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to claim change outbox: %w", err)
+	}
+
+	var entries []models.OutboxEntry
+	if err := json.Unmarshal(body, &entries); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal response: %w", err)
+	}
+
+	return entries, nil
+}
+
+func (r *changeOutboxRepository) Delete(ctx context.Context, id int64) error {
+	query := map[string]interface{}{
+		"id": fmt.Sprintf("eq.%d", id),
+	}
+
+	if err := r.client.DeleteWhere("change_outbox", query); err != nil {
+		return fmt.Errorf("failed to delete change outbox entry: %w", err)
+	}
+
+	return nil
+}
+
+func (r *changeOutboxRepository) DeleteByUserID(ctx context.Context, userID string) error {
+	query := map[string]interface{}{
+		"user_id": fmt.Sprintf("eq.%s", userID),
+	}
+
+	if err := r.client.DeleteWhere("change_outbox", query); err != nil {
+		return fmt.Errorf("failed to delete change outbox entries: %w", err)
+	}
+
+	return nil
+}
@@ -4,7 +4,9 @@ import (
 	"context"
 	"encoding/json"
 	"fmt"
+	"time"
 
+	"github.com/JonnyWalker81/trendy/backend/internal/logger"
 	"github.com/JonnyWalker81/trendy/backend/internal/models"
 	"github.com/JonnyWalker81/trendy/backend/pkg/supabase"
 )
@@ -17,8 +19,32 @@ type ChangeLogRepository interface {
 	// GetSince retrieves changes since the given cursor for a user
 	GetSince(ctx context.Context, userID string, cursor int64, limit int) (*models.ChangeFeedResponse, error)
 
-	// GetLatestCursor returns the maximum change_log ID for a user
+	// GetLatestCursor returns the maximum change_log ID for a user, including
+	// entries removed by retention
 	GetLatestCursor(ctx context.Context, userID string) (int64, error)
+
+	// GetSettledCursor returns the cursor just before the user's first entry
+	// recorded at or after the given time, or the latest cursor if there is
+	// none. Entry IDs are taken in insert order but become visible in commit
+	// order, so only entries older than any open transaction can be passed
+	// without missing one that commits later.
+	GetSettledCursor(ctx context.Context, userID string, before time.Time) (int64, error)
+
+	// GetHorizon returns the highest cursor removed by retention for a user,
+	// or 0 if none. Clients with an older cursor must bootstrap from a snapshot.
+	GetHorizon(ctx context.Context, userID string) (int64, error)
+
+	// Compact removes entries superseded by a later entry for the same entity
+	// and returns the number removed
+	Compact(ctx context.Context) (int64, error)
+
+	// Prune removes entries created before the given time, advancing each
+	// affected user's horizon, and returns the number removed. The latest
+	// entry of an entity that has not been deleted is always kept.
+	Prune(ctx context.Context, before time.Time) (int64, error)
+
+	// DeleteByUserID removes every entry and the horizon of a user
+	DeleteByUserID(ctx context.Context, userID string) error
 }
 
 type changeLogRepository struct {
@@ -58,6 +84,16 @@ func (r *changeLogRepository) Append(ctx context.Context, input *models.ChangeLo
 
 	body, err := r.client.Insert("change_log", data)
 	if err != nil {
+		if relayed(ctx) {
+			// The entity write has committed and its outbox entry will be
+			// relayed to the change log
+			logger.FromContext(ctx).Warn("failed to append to change log, leaving it to the relay",
+				logger.String("entity_type", string(input.EntityType)),
+				logger.String("entity_id", input.EntityID),
+				logger.Err(err),
+			)
+			return 0, nil
+		}
 		return 0, fmt.Errorf("failed to append to change log: %w", err)
 	}
 
@@ -145,9 +181,118 @@ func (r *changeLogRepository) GetLatestCursor(ctx context.Context, userID string
 		return 0, fmt.Errorf("failed to unmarshal change log entries: %w", err)
 	}
 
-	if len(entries) == 0 {
-		return 0, nil // No entries for this user
+	// Entries may all have been removed by retention
+	horizon, err := r.GetHorizon(ctx, userID)
+	if err != nil {
+		return 0, err
+	}
+
+	if len(entries) == 0 || entries[0].ID < horizon {
+		return horizon, nil
 	}
 
 	return entries[0].ID, nil
 }
+
+func (r *changeLogRepository) GetSettledCursor(ctx context.Context, userID string, before time.Time) (int64, error) {
+	query := map[string]interface{}{
+		"user_id":    fmt.Sprintf("eq.%s", userID),
+		"created_at": fmt.Sprintf("gte.%s", before.UTC().Format(time.RFC3339Nano)),
+		"select":     "id",
+		"order":      "id.asc",
+		"limit":      1,
+	}
+
+	body, err := r.client.Query("change_log", query)
+	if err != nil {
+		return 0, fmt.Errorf("failed to query change log: %w", err)
+	}
+
+	var entries []struct {
+		ID int64 `json:"id"`
+	}
+	if err := json.Unmarshal(body, &entries); err != nil {
+		return 0, fmt.Errorf("failed to unmarshal change log entries: %w", err)
+	}
+
+	if len(entries) == 0 {
+		return r.GetLatestCursor(ctx, userID)
+	}
+
+	horizon, err := r.GetHorizon(ctx, userID)
+	if err != nil {
+		return 0, err
+	}
+
+	return max(entries[0].ID-1, horizon), nil
+}
+
+func (r *changeLogRepository) GetHorizon(ctx context.Context, userID string) (int64, error) {
+	query := map[string]interface{}{
+		"user_id": fmt.Sprintf("eq.%s", userID),
+		"select":  "cursor",
+	}
+
+	body, err := r.client.Query("change_log_horizons", query)
+	if err != nil {
+		return 0, fmt.Errorf("failed to query change log horizon: %w", err)
+	}
+
+	var horizons []struct {
+		Cursor int64 `json:"cursor"`
+	}
+	if err := json.Unmarshal(body, &horizons); err != nil {
+		return 0, fmt.Errorf("failed to unmarshal change log horizon: %w", err)
+	}
+
+	if len(horizons) == 0 {
+		return 0, nil
+	}
+
+	return horizons[0].Cursor, nil
+}
+
+func (r *changeLogRepository) Compact(ctx context.Context) (int64, error) {
+	body, err := r.client.RPC("compact_change_log", nil)
+	if err != nil {
+		return 0, fmt.Errorf("failed to compact change log: %w", err)
+	}
+
+	var removed int64
+	if err := json.Unmarshal(body, &removed); err != nil {
+		return 0, fmt.Errorf("failed to unmarshal compaction result: %w", err)
+	}
+
+	return removed, nil
+}
+
+func (r *changeLogRepository) Prune(ctx context.Context, before time.Time) (int64, error) {
+	body, err := r.client.RPC("prune_change_log", map[string]interface{}{
+		"p_before": before.UTC().Format(time.RFC3339Nano),
+	})
+	if err != nil {
+		return 0, fmt.Errorf("failed to prune change log: %w", err)
+	}
+
+	var removed int64
+	if err := json.Unmarshal(body, &removed); err != nil {
+		return 0, fmt.Errorf("failed to unmarshal prune result: %w", err)
+	}
+
+	return removed, nil
+}
+
+func (r *changeLogRepository) DeleteByUserID(ctx context.Context, userID string) error {
+	query := map[string]interface{}{
+		"user_id": fmt.Sprintf("eq.%s", userID),
+	}
+
+	if err := r.client.DeleteWhere("change_log", query); err != nil {
+		return fmt.Errorf("failed to delete change log: %w", err)
+	}
+	if err := r.client.DeleteWhere("change_log_horizons", query); err != nil {
+		return fmt.Errorf("failed to delete change log horizon: %w", err)
+	}
+
+	return nil
+}
@@ -19,32 +19,62 @@ func NewDailyAggregateRepository(client *supabase.Client) DailyAggregateReposito
 	return &dailyAggregateRepository{client: client}
 }
 
-func (r *dailyAggregateRepository) Upsert(ctx context.Context, agg *models.DailyAggregate) (*models.DailyAggregate, error) {
-	data := map[string]interface{}{
-		"user_id":       agg.UserID,
-		"date":          agg.Date.Format("2006-01-02"),
-		"event_type_id": agg.EventTypeID,
-		"event_count":   agg.EventCount,
+// dailyAggregateRow decodes a daily_aggregates row. PostgREST returns the
+// DATE column as "2006-01-02", which time.Time cannot decode.
+type dailyAggregateRow struct {
+	models.DailyAggregate
+	Date string `json:"date"`
+}
+
+// decodeDailyAggregates decodes a PostgREST response of daily_aggregates rows
+func decodeDailyAggregates(body []byte) ([]models.DailyAggregate, error) {
+	var rows []dailyAggregateRow
+	if err := json.Unmarshal(body, &rows); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal response: %w", err)
 	}
 
-	if agg.TotalDurationSeconds != nil {
-		data["total_duration_seconds"] = *agg.TotalDurationSeconds
+	aggs := make([]models.DailyAggregate, len(rows))
+	for i, row := range rows {
+		date, err := time.Parse("2006-01-02", row.Date)
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse daily aggregate date: %w", err)
+		}
+		aggs[i] = row.DailyAggregate
+		aggs[i].Date = date
 	}
-	if agg.AvgNumericValue != nil {
-		data["avg_numeric_value"] = *agg.AvgNumericValue
+
+	return aggs, nil
+}
+
+// dailyAggregateData returns the columns of a daily aggregate row. Every row
+// has the same keys, as PostgREST requires for a bulk upsert.
+func dailyAggregateData(agg models.DailyAggregate) map[string]interface{} {
+	propertyAggregates := agg.PropertyAggregates
+	if propertyAggregates == nil {
+		propertyAggregates = map[string]models.PropAgg{}
 	}
-	if agg.PropertyAggregates != nil && len(agg.PropertyAggregates) > 0 {
-		data["property_aggregates"] = agg.PropertyAggregates
+
+	return map[string]interface{}{
+		"user_id":                agg.UserID,
+		"date":                   agg.Date.Format("2006-01-02"),
+		"event_type_id":          agg.EventTypeID,
+		"event_count":            agg.EventCount,
+		"total_duration_seconds": agg.TotalDurationSeconds,
+		"avg_numeric_value":      agg.AvgNumericValue,
+		"property_aggregates":    propertyAggregates,
+		"hour_counts":            agg.HourCounts,
 	}
+}
 
-	body, err := r.client.Upsert("daily_aggregates", data, "user_id,date,event_type_id")
+func (r *dailyAggregateRepository) Upsert(ctx context.Context, agg *models.DailyAggregate) (*models.DailyAggregate, error) {
+	body, err := r.client.Upsert("daily_aggregates", dailyAggregateData(*agg), "user_id,date,event_type_id")
 	if err != nil {
 		return nil, fmt.Errorf("failed to upsert daily aggregate: %w", err)
 	}
 
-	var aggs []models.DailyAggregate
-	if err := json.Unmarshal(body, &aggs); err != nil {
-		return nil, fmt.Errorf("failed to unmarshal response: %w", err)
+	aggs, err := decodeDailyAggregates(body)
+	if err != nil {
+		return nil, err
 	}
 
 	if len(aggs) == 0 {
@@ -61,24 +91,7 @@ func (r *dailyAggregateRepository) BulkUpsert(ctx context.Context, aggs []models
 
 	data := make([]map[string]interface{}, len(aggs))
 	for i, agg := range aggs {
-		item := map[string]interface{}{
-			"user_id":       agg.UserID,
-			"date":          agg.Date.Format("2006-01-02"),
-			"event_type_id": agg.EventTypeID,
-			"event_count":   agg.EventCount,
-		}
-
-		if agg.TotalDurationSeconds != nil {
-			item["total_duration_seconds"] = *agg.TotalDurationSeconds
-		}
-		if agg.AvgNumericValue != nil {
-			item["avg_numeric_value"] = *agg.AvgNumericValue
-		}
-		if agg.PropertyAggregates != nil && len(agg.PropertyAggregates) > 0 {
-			item["property_aggregates"] = agg.PropertyAggregates
-		}
-
-		data[i] = item
+		data[i] = dailyAggregateData(agg)
 	}
 
 	_, err := r.client.Upsert("daily_aggregates", data, "user_id,date,event_type_id")
@@ -102,9 +115,9 @@ func (r *dailyAggregateRepository) GetByUserID(ctx context.Context, userID strin
 		return nil, fmt.Errorf("failed to get daily aggregates: %w", err)
 	}
 
-	var aggs []models.DailyAggregate
-	if err := json.Unmarshal(body, &aggs); err != nil {
-		return nil, fmt.Errorf("failed to unmarshal response: %w", err)
+	aggs, err := decodeDailyAggregates(body)
+	if err != nil {
+		return nil, err
 	}
 
 	return aggs, nil
@@ -124,9 +137,9 @@ func (r *dailyAggregateRepository) GetByUserIDAndDateRange(ctx context.Context,
 		return nil, fmt.Errorf("failed to get daily aggregates: %w", err)
 	}
 
-	var aggs []models.DailyAggregate
-	if err := json.Unmarshal(body, &aggs); err != nil {
-		return nil, fmt.Errorf("failed to unmarshal response: %w", err)
+	aggs, err := decodeDailyAggregates(body)
+	if err != nil {
+		return nil, err
 	}
 
 	return aggs, nil
@@ -147,9 +160,9 @@ func (r *dailyAggregateRepository) GetByUserIDAndEventType(ctx context.Context,
 		return nil, fmt.Errorf("failed to get daily aggregates: %w", err)
 	}
 
-	var aggs []models.DailyAggregate
-	if err := json.Unmarshal(body, &aggs); err != nil {
-		return nil, fmt.Errorf("failed to unmarshal response: %w", err)
+	aggs, err := decodeDailyAggregates(body)
+	if err != nil {
+		return nil, err
 	}
 
 	return aggs, nil
@@ -167,6 +180,19 @@ func (r *dailyAggregateRepository) DeleteByUserID(ctx context.Context, userID st
 	return nil
 }
 
+func (r *dailyAggregateRepository) DeleteByEventType(ctx context.Context, userID, eventTypeID string) error {
+	query := map[string]interface{}{
+		"user_id":       fmt.Sprintf("eq.%s", userID),
+		"event_type_id": fmt.Sprintf("eq.%s", eventTypeID),
+	}
+
+	if err := r.client.DeleteWhere("daily_aggregates", query); err != nil {
+		return fmt.Errorf("failed to delete daily aggregates by event type: %w", err)
+	}
+
+	return nil
+}
+
 func (r *dailyAggregateRepository) DeleteOlderThan(ctx context.Context, userID string, date time.Time) error {
 	query := map[string]interface{}{
 		"user_id": fmt.Sprintf("eq.%s", userID),
@@ -179,3 +205,17 @@ func (r *dailyAggregateRepository) DeleteOlderThan(ctx context.Context, userID s
 
 	return nil
 }
+
+func (r *dailyAggregateRepository) Delete(ctx context.Context, userID, eventTypeID string, date time.Time) error {
+	query := map[string]interface{}{
+		"user_id":       fmt.Sprintf("eq.%s", userID),
+		"event_type_id": fmt.Sprintf("eq.%s", eventTypeID),
+		"date":          fmt.Sprintf("eq.%s", date.Format("2006-01-02")),
+	}
+
+	if err := r.client.DeleteWhere("daily_aggregates", query); err != nil {
+		return fmt.Errorf("failed to delete daily aggregate: %w", err)
+	}
+
+	return nil
+}
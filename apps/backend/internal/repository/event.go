@@ -4,6 +4,7 @@ import (
 	"context"
 	"encoding/json"
 	"fmt"
+	"strings"
 	"time"
 
 	"github.com/JonnyWalker81/trendy/backend/internal/models"
@@ -130,8 +131,9 @@ func (r *eventRepository) CreateBatch(ctx context.Context, events []models.Event
 
 func (r *eventRepository) GetByID(ctx context.Context, id string) (*models.Event, error) {
 	query := map[string]interface{}{
-		"id":     fmt.Sprintf("eq.%s", id),
-		"select": "*,event_type:event_types(*)",
+		"id":         fmt.Sprintf("eq.%s", id),
+		"deleted_at": "is.null",
+		"select":     "*,event_type:event_types(*)",
 	}
 
 	body, err := r.client.Query("events", query)
@@ -151,13 +153,39 @@ func (r *eventRepository) GetByID(ctx context.Context, id string) (*models.Event
 	return &events[0], nil
 }
 
+// ClaimVersion cannot hold a lock across PostgREST requests. It claims the
+// version with a conditional update instead, which moves updated_at on, so a
+// concurrent writer claiming the same version finds it gone.
+func (r *eventRepository) ClaimVersion(ctx context.Context, id string, updatedAt time.Time) (bool, error) {
+	query := map[string]interface{}{
+		"id":         fmt.Sprintf("eq.%s", id),
+		"updated_at": fmt.Sprintf("eq.%s", updatedAt.UTC().Format(time.RFC3339Nano)),
+		"deleted_at": "is.null",
+	}
+
+	body, err := r.client.UpdateWhere("events", query, map[string]interface{}{"updated_at": updatedAt})
+	if err != nil {
+		return false, fmt.Errorf("failed to claim event: %w", err)
+	}
+
+	var claimed []struct {
+		ID string `json:"id"`
+	}
+	if err := json.Unmarshal(body, &claimed); err != nil {
+		return false, fmt.Errorf("failed to unmarshal response: %w", err)
+	}
+
+	return len(claimed) > 0, nil
+}
+
 func (r *eventRepository) GetByUserID(ctx context.Context, userID string, limit, offset int) ([]models.Event, error) {
 	query := map[string]interface{}{
-		"user_id": fmt.Sprintf("eq.%s", userID),
-		"select":  "*,event_type:event_types(*)",
-		"order":   "timestamp.desc",
-		"limit":   limit,
-		"offset":  offset,
+		"user_id":    fmt.Sprintf("eq.%s", userID),
+		"deleted_at": "is.null",
+		"select":     "*,event_type:event_types(*)",
+		"order":      "timestamp.desc",
+		"limit":      limit,
+		"offset":     offset,
 	}
 
 	body, err := r.client.Query("events", query)
@@ -175,10 +203,11 @@ func (r *eventRepository) GetByUserID(ctx context.Context, userID string, limit,
 
 func (r *eventRepository) GetByUserIDAndDateRange(ctx context.Context, userID string, startDate, endDate time.Time) ([]models.Event, error) {
 	query := map[string]interface{}{
-		"user_id": fmt.Sprintf("eq.%s", userID),
-		"and":     fmt.Sprintf("(timestamp.gte.%s,timestamp.lte.%s)", startDate.Format(time.RFC3339), endDate.Format(time.RFC3339)),
-		"select":  "*,event_type:event_types(*)",
-		"order":   "timestamp.desc",
+		"user_id":    fmt.Sprintf("eq.%s", userID),
+		"deleted_at": "is.null",
+		"and":        fmt.Sprintf("(timestamp.gte.%s,timestamp.lte.%s)", startDate.Format(time.RFC3339), endDate.Format(time.RFC3339)),
+		"select":     "*,event_type:event_types(*)",
+		"order":      "timestamp.desc",
 	}
 
 	body, err := r.client.Query("events", query)
@@ -314,8 +343,9 @@ func (r *eventRepository) Delete(ctx context.Context, id string) error {
 
 func (r *eventRepository) CountByEventType(ctx context.Context, userID string) (map[string]int64, error) {
 	query := map[string]interface{}{
-		"user_id": fmt.Sprintf("eq.%s", userID),
-		"select":  "event_type_id",
+		"user_id":    fmt.Sprintf("eq.%s", userID),
+		"deleted_at": "is.null",
+		"select":     "event_type_id",
 	}
 
 	body, err := r.client.Query("events", query)
@@ -568,9 +598,10 @@ func (r *eventRepository) UpsertBatch(ctx context.Context, events []models.Event
 
 func (r *eventRepository) GetForExport(ctx context.Context, userID string, startDate, endDate *time.Time, eventTypeIDs []string) ([]models.Event, error) {
 	query := map[string]interface{}{
-		"user_id": fmt.Sprintf("eq.%s", userID),
-		"select":  "*,event_type:event_types(*)",
-		"order":   "timestamp.desc",
+		"user_id":    fmt.Sprintf("eq.%s", userID),
+		"deleted_at": "is.null",
+		"select":     "*,event_type:event_types(*)",
+		"order":      "timestamp.desc",
 	}
 
 	// Add date range filter if provided
@@ -605,6 +636,135 @@ func (r *eventRepository) GetForExport(ctx context.Context, userID string, start
 	return events, nil
 }
 
+func (r *eventRepository) GetForExportPage(ctx context.Context, userID string, startDate, endDate *time.Time, eventTypeIDs []string, after *models.EventCursor, limit int) ([]models.Event, error) {
+	query := map[string]interface{}{
+		"user_id":    fmt.Sprintf("eq.%s", userID),
+		"deleted_at": "is.null",
+		"select":     "*,event_type:event_types(*)",
+		"order":      "timestamp.desc,id.desc",
+		"limit":      limit,
+	}
+
+	if startDate != nil && endDate != nil {
+		query["and"] = fmt.Sprintf("(timestamp.gte.%s,timestamp.lte.%s)", startDate.Format(time.RFC3339), endDate.Format(time.RFC3339))
+	}
+
+	if len(eventTypeIDs) > 0 {
+		query["event_type_id"] = fmt.Sprintf("in.(%s)", strings.Join(eventTypeIDs, ","))
+	}
+
+	// Keyset condition: older than the cursor, or as old with a lower ID
+	if after != nil {
+		ts := after.Timestamp.UTC().Format(time.RFC3339Nano)
+		query["or"] = fmt.Sprintf("(timestamp.lt.%s,and(timestamp.eq.%s,id.lt.%s))", ts, ts, after.ID)
+	}
+
+	body, err := r.client.Query("events", query)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get events for export: %w", err)
+	}
+
+	var events []models.Event
+	if err := json.Unmarshal(body, &events); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal response: %w", err)
+	}
+
+	return events, nil
+}
+
+func (r *eventRepository) GetPage(ctx context.Context, userID string, filter *models.EventFilter, after *models.EventCursor, limit int) ([]models.Event, error) {
+	query := map[string]interface{}{
+		"user_id":    fmt.Sprintf("eq.%s", userID),
+		"deleted_at": "is.null",
+		"select":     "*,event_type:event_types(*)",
+		"order":      "timestamp.desc,id.desc",
+		"limit":      limit,
+	}
+
+	// Every condition goes into one and=(...) so a column can be filtered
+	// more than once
+	var conditions []string
+	if len(filter.EventTypeIDs) > 0 {
+		conditions = append(conditions, fmt.Sprintf("event_type_id.in.(%s)", strings.Join(filter.EventTypeIDs, ",")))
+	}
+	if filter.StartDate != nil {
+		conditions = append(conditions, "timestamp.gte."+filter.StartDate.UTC().Format(time.RFC3339Nano))
+	}
+	if filter.EndDate != nil {
+		conditions = append(conditions, "timestamp.lte."+filter.EndDate.UTC().Format(time.RFC3339Nano))
+	}
+	if filter.SourceType != "" {
+		conditions = append(conditions, "source_type.eq."+postgrestQuote(filter.SourceType))
+	}
+	if filter.GeofenceID != "" {
+		conditions = append(conditions, "geofence_id.eq."+filter.GeofenceID)
+	}
+	if filter.HasNotes != nil {
+		if *filter.HasNotes {
+			conditions = append(conditions, "notes.not.is.null", `notes.neq.""`)
+		} else {
+			conditions = append(conditions, `or(notes.is.null,notes.eq."")`)
+		}
+	}
+	for _, p := range filter.Properties {
+		conditions = append(conditions, propertyCondition(p))
+	}
+	if filter.Search != nil {
+		conditions = append(conditions, "search_vector.fts(simple)."+postgrestQuote(filter.Search.TSQuery()))
+	}
+
+	// Keyset condition: older than the cursor, or as old with a lower ID
+	if after != nil {
+		ts := after.Timestamp.UTC().Format(time.RFC3339Nano)
+		conditions = append(conditions, fmt.Sprintf("or(timestamp.lt.%s,and(timestamp.eq.%s,id.lt.%s))", ts, ts, after.ID))
+	}
+
+	if len(conditions) > 0 {
+		query["and"] = "(" + strings.Join(conditions, ",") + ")"
+	}
+
+	body, err := r.client.Query("events", query)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get events: %w", err)
+	}
+
+	var events []models.Event
+	if err := json.Unmarshal(body, &events); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal response: %w", err)
+	}
+
+	return events, nil
+}
+
+var postgrestOperators = map[models.PropertyOperator]string{
+	models.PropertyOpEq:  "eq",
+	models.PropertyOpNe:  "neq",
+	models.PropertyOpGt:  "gt",
+	models.PropertyOpGte: "gte",
+	models.PropertyOpLt:  "lt",
+	models.PropertyOpLte: "lte",
+}
+
+// propertyCondition builds a PostgREST condition for a property predicate.
+// Numeric values are compared as JSONB, which orders numbers numerically;
+// PostgREST cannot also check the JSON type, so values of other types order
+// by type. Other values are compared as text.
+func propertyCondition(p models.PropertyPredicate) string {
+	op := postgrestOperators[p.Op]
+	if _, ok := p.Number(); ok {
+		return fmt.Sprintf("properties->%s->value.%s.%s", p.Key, op, p.Value)
+	}
+	return fmt.Sprintf("properties->%s->>value.%s.%s", p.Key, op, postgrestQuote(p.Value))
+}
+
+// postgrestQuote quotes a value for use inside a PostgREST logic tree, where
+// commas and parentheses are reserved
+func postgrestQuote(v string) string {
+	v = strings.ReplaceAll(v, `\`, `\\`)
+	v = strings.ReplaceAll(v, `"`, `\"`)
+	return `"` + v + `"`
+}
+
 // GetByHealthKitSampleIDs retrieves events by their HealthKit sample IDs for a user.
 // Used to determine which events already exist before upserting.
 func (r *eventRepository) GetByHealthKitSampleIDs(ctx context.Context, userID string, sampleIDs []string) ([]models.Event, error) {
@@ -673,7 +833,7 @@ func (r *eventRepository) UpsertHealthKitEvent(ctx context.Context, event *model
 		return nil, false, fmt.Errorf("failed to check existing event by content: %w", err)
 	}
 
-	contentKey := healthKitContentKey(event.UserID, event.EventTypeID, event.Timestamp, event.HealthKitCategory)
+	contentKey := HealthKitContentKey(event.UserID, event.EventTypeID, event.Timestamp, event.HealthKitCategory)
 	if existingEvent, exists := existingByContent[contentKey]; exists {
 		// Content duplicate found - return existing event instead of creating duplicate
 		return &existingEvent, false, nil
@@ -801,7 +961,7 @@ func (r *eventRepository) UpsertHealthKitEventsBatch(ctx context.Context, events
 		}
 
 		// Check 2: Duplicate by content (different sample ID but same event)
-		contentKey := healthKitContentKey(userID, event.EventTypeID, event.Timestamp, event.HealthKitCategory)
+		contentKey := HealthKitContentKey(userID, event.EventTypeID, event.Timestamp, event.HealthKitCategory)
 		if existingEvent, exists := existingByContent[contentKey]; exists {
 			// Content duplicate found - return existing event instead of creating duplicate
 			// The existing event has a different sample ID but represents the same workout
@@ -860,9 +1020,9 @@ func (r *eventRepository) UpsertHealthKitEventsBatch(ctx context.Context, events
 	return allResults, createdIDs, nil
 }
 
-// healthKitContentKey generates a unique key for HealthKit content-based deduplication.
+// HealthKitContentKey generates a unique key for HealthKit content-based deduplication.
 // This is used when sample IDs differ but the event content is identical.
-func healthKitContentKey(userID, eventTypeID string, timestamp time.Time, healthKitCategory *string) string {
+func HealthKitContentKey(userID, eventTypeID string, timestamp time.Time, healthKitCategory *string) string {
 	category := ""
 	if healthKitCategory != nil {
 		category = *healthKitCategory
@@ -889,7 +1049,7 @@ func (r *eventRepository) GetByHealthKitContent(ctx context.Context, userID stri
 	}
 	uniqueKeys := make(map[string]contentKey)
 	for _, event := range events {
-		key := healthKitContentKey(userID, event.EventTypeID, event.Timestamp, event.HealthKitCategory)
+		key := HealthKitContentKey(userID, event.EventTypeID, event.Timestamp, event.HealthKitCategory)
 		uniqueKeys[key] = contentKey{
 			eventTypeID:       event.EventTypeID,
 			timestamp:         event.Timestamp,
@@ -938,7 +1098,7 @@ func (r *eventRepository) GetByHealthKitContent(ctx context.Context, userID stri
 
 	// Build result map - only include events that match our content keys
 	for _, existing := range existingEvents {
-		key := healthKitContentKey(userID, existing.EventTypeID, existing.Timestamp, existing.HealthKitCategory)
+		key := HealthKitContentKey(userID, existing.EventTypeID, existing.Timestamp, existing.HealthKitCategory)
 		if _, wanted := uniqueKeys[key]; wanted {
 			result[key] = existing
 		}
@@ -950,8 +1110,9 @@ func (r *eventRepository) GetByHealthKitContent(ctx context.Context, userID stri
 // CountByUser returns total events for a user
 func (r *eventRepository) CountByUser(ctx context.Context, userID string) (int64, error) {
 	query := map[string]interface{}{
-		"user_id": fmt.Sprintf("eq.%s", userID),
-		"select":  "id",
+		"user_id":    fmt.Sprintf("eq.%s", userID),
+		"deleted_at": "is.null",
+		"select":     "id",
 	}
 	body, err := r.client.Query("events", query)
 	if err != nil {
@@ -968,6 +1129,7 @@ func (r *eventRepository) CountByUser(ctx context.Context, userID string) (int64
 func (r *eventRepository) CountHealthKitByUser(ctx context.Context, userID string) (int64, error) {
 	query := map[string]interface{}{
 		"user_id":             fmt.Sprintf("eq.%s", userID),
+		"deleted_at":          "is.null",
 		"healthkit_sample_id": "not.is.null",
 		"select":              "id",
 	}
@@ -985,10 +1147,11 @@ func (r *eventRepository) CountHealthKitByUser(ctx context.Context, userID strin
 // GetLatestTimestamp returns the most recent event updated_at for a user
 func (r *eventRepository) GetLatestTimestamp(ctx context.Context, userID string) (*time.Time, error) {
 	query := map[string]interface{}{
-		"user_id": fmt.Sprintf("eq.%s", userID),
-		"select":  "updated_at",
-		"order":   "updated_at.desc",
-		"limit":   1,
+		"user_id":    fmt.Sprintf("eq.%s", userID),
+		"deleted_at": "is.null",
+		"select":     "updated_at",
+		"order":      "updated_at.desc",
+		"limit":      1,
 	}
 	body, err := r.client.Query("events", query)
 	if err != nil {
@@ -1010,6 +1173,7 @@ func (r *eventRepository) GetLatestTimestamp(ctx context.Context, userID string)
 func (r *eventRepository) GetLatestHealthKitTimestamp(ctx context.Context, userID string) (*time.Time, error) {
 	query := map[string]interface{}{
 		"user_id":             fmt.Sprintf("eq.%s", userID),
+		"deleted_at":          "is.null",
 		"healthkit_sample_id": "not.is.null",
 		"select":              "updated_at",
 		"order":               "updated_at.desc",
@@ -1030,3 +1194,191 @@ func (r *eventRepository) GetLatestHealthKitTimestamp(ctx context.Context, userI
 	}
 	return events[0].UpdatedAt, nil
 }
+
+// SoftDelete moves an event to the trash
+func (r *eventRepository) SoftDelete(ctx context.Context, id string, deletedAt time.Time) error {
+	query := map[string]interface{}{
+		"id":         fmt.Sprintf("eq.%s", id),
+		"deleted_at": "is.null",
+	}
+
+	if _, err := r.client.UpdateWhere("events", query, map[string]interface{}{"deleted_at": deletedAt}); err != nil {
+		return fmt.Errorf("failed to delete event: %w", err)
+	}
+	return nil
+}
+
+// SoftDeleteByEventType moves the live events of an event type to the trash
+// and returns their IDs
+func (r *eventRepository) SoftDeleteByEventType(ctx context.Context, eventTypeID string, deletedAt time.Time) ([]string, error) {
+	query := map[string]interface{}{
+		"event_type_id": fmt.Sprintf("eq.%s", eventTypeID),
+		"deleted_at":    "is.null",
+		"select":        "id",
+	}
+
+	body, err := r.client.UpdateWhere("events", query, map[string]interface{}{"deleted_at": deletedAt})
+	if err != nil {
+		return nil, fmt.Errorf("failed to delete events: %w", err)
+	}
+
+	var events []struct {
+		ID string `json:"id"`
+	}
+	if err := json.Unmarshal(body, &events); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal response: %w", err)
+	}
+
+	ids := make([]string, len(events))
+	for i, event := range events {
+		ids[i] = event.ID
+	}
+
+	return ids, nil
+}
+
+// GetDeletedByID retrieves a trashed event
+func (r *eventRepository) GetDeletedByID(ctx context.Context, id string) (*models.Event, error) {
+	query := map[string]interface{}{
+		"id":         fmt.Sprintf("eq.%s", id),
+		"deleted_at": "not.is.null",
+		"select":     "*,event_type:event_types(*)",
+	}
+
+	body, err := r.client.Query("events", query)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get event: %w", err)
+	}
+
+	var events []models.Event
+	if err := json.Unmarshal(body, &events); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal response: %w", err)
+	}
+
+	if len(events) == 0 {
+		return nil, fmt.Errorf("event not found")
+	}
+
+	return &events[0], nil
+}
+
+// GetDeletedByUserID returns a user's trashed events, most recently deleted first
+func (r *eventRepository) GetDeletedByUserID(ctx context.Context, userID string) ([]models.Event, error) {
+	query := map[string]interface{}{
+		"user_id":    fmt.Sprintf("eq.%s", userID),
+		"deleted_at": "not.is.null",
+		"select":     "*,event_type:event_types(*)",
+		"order":      "deleted_at.desc",
+	}
+
+	body, err := r.client.Query("events", query)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get deleted events: %w", err)
+	}
+
+	var events []models.Event
+	if err := json.Unmarshal(body, &events); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal response: %w", err)
+	}
+
+	return events, nil
+}
+
+// Restore takes a trashed event out of the trash
+func (r *eventRepository) Restore(ctx context.Context, id string) (*models.Event, error) {
+	query := map[string]interface{}{
+		"id":         fmt.Sprintf("eq.%s", id),
+		"deleted_at": "not.is.null",
+		"select":     "*,event_type:event_types(*)",
+	}
+
+	body, err := r.client.UpdateWhere("events", query, map[string]interface{}{"deleted_at": nil})
+	if err != nil {
+		return nil, fmt.Errorf("failed to restore event: %w", err)
+	}
+
+	var events []models.Event
+	if err := json.Unmarshal(body, &events); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal response: %w", err)
+	}
+
+	if len(events) == 0 {
+		return nil, fmt.Errorf("event not found")
+	}
+
+	return &events[0], nil
+}
+
+// RestoreByEventType restores the events of an event type that were trashed
+// at deletedAt
+func (r *eventRepository) RestoreByEventType(ctx context.Context, eventTypeID string, deletedAt time.Time) ([]models.Event, error) {
+	query := map[string]interface{}{
+		"event_type_id": fmt.Sprintf("eq.%s", eventTypeID),
+		"deleted_at":    fmt.Sprintf("eq.%s", deletedAt.UTC().Format(time.RFC3339Nano)),
+		"select":        "*,event_type:event_types(*)",
+		"order":         "timestamp.desc",
+	}
+
+	body, err := r.client.UpdateWhere("events", query, map[string]interface{}{"deleted_at": nil})
+	if err != nil {
+		return nil, fmt.Errorf("failed to restore events: %w", err)
+	}
+
+	var events []models.Event
+	if err := json.Unmarshal(body, &events); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal response: %w", err)
+	}
+
+	return events, nil
+}
+
+// PurgeDeleted permanently removes events trashed before the given time
+func (r *eventRepository) PurgeDeleted(ctx context.Context, before time.Time) (int64, error) {
+	body, err := r.client.RPC("purge_deleted_events", map[string]interface{}{
+		"p_before": before.UTC().Format(time.RFC3339Nano),
+	})
+	if err != nil {
+		return 0, fmt.Errorf("failed to purge events: %w", err)
+	}
+
+	var purged int64
+	if err := json.Unmarshal(body, &purged); err != nil {
+		return 0, fmt.Errorf("failed to unmarshal purge result: %w", err)
+	}
+
+	return purged, nil
+}
+
+// ReassignEventType moves the live events of one event type to another
+func (r *eventRepository) ReassignEventType(ctx context.Context, fromEventTypeID, toEventTypeID string) ([]models.Event, error) {
+	query := map[string]interface{}{
+		"event_type_id": fmt.Sprintf("eq.%s", fromEventTypeID),
+		"deleted_at":    "is.null",
+		"select":        "*,event_type:event_types(*)",
+		"order":         "timestamp.desc",
+	}
+
+	body, err := r.client.UpdateWhere("events", query, map[string]interface{}{"event_type_id": toEventTypeID})
+	if err != nil {
+		return nil, fmt.Errorf("failed to reassign events: %w", err)
+	}
+
+	var events []models.Event
+	if err := json.Unmarshal(body, &events); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal response: %w", err)
+	}
+
+	return events, nil
+}
+
+func (r *eventRepository) DeleteByUserID(ctx context.Context, userID string) error {
+	query := map[string]interface{}{
+		"user_id": fmt.Sprintf("eq.%s", userID),
+	}
+
+	if err := r.client.DeleteWhere("events", query); err != nil {
+		return fmt.Errorf("failed to delete events: %w", err)
+	}
+
+	return nil
+}
@@ -59,7 +59,8 @@ func (r *eventTypeRepository) Create(ctx context.Context, eventType *models.Even
 
 func (r *eventTypeRepository) GetByID(ctx context.Context, id string) (*models.EventType, error) {
 	query := map[string]interface{}{
-		"id": fmt.Sprintf("eq.%s", id),
+		"id":         fmt.Sprintf("eq.%s", id),
+		"deleted_at": "is.null",
 	}
 
 	body, err := r.client.Query("event_types", query)
@@ -79,10 +80,36 @@ func (r *eventTypeRepository) GetByID(ctx context.Context, id string) (*models.E
 	return &eventTypes[0], nil
 }
 
+// ClaimVersion cannot hold a lock across PostgREST requests. It claims the
+// version with a conditional update instead, which moves updated_at on, so a
+// concurrent writer claiming the same version finds it gone.
+func (r *eventTypeRepository) ClaimVersion(ctx context.Context, id string, updatedAt time.Time) (bool, error) {
+	query := map[string]interface{}{
+		"id":         fmt.Sprintf("eq.%s", id),
+		"updated_at": fmt.Sprintf("eq.%s", updatedAt.UTC().Format(time.RFC3339Nano)),
+		"deleted_at": "is.null",
+	}
+
+	body, err := r.client.UpdateWhere("event_types", query, map[string]interface{}{"updated_at": updatedAt})
+	if err != nil {
+		return false, fmt.Errorf("failed to claim event type: %w", err)
+	}
+
+	var claimed []struct {
+		ID string `json:"id"`
+	}
+	if err := json.Unmarshal(body, &claimed); err != nil {
+		return false, fmt.Errorf("failed to unmarshal response: %w", err)
+	}
+
+	return len(claimed) > 0, nil
+}
+
 func (r *eventTypeRepository) GetByUserID(ctx context.Context, userID string) ([]models.EventType, error) {
 	query := map[string]interface{}{
-		"user_id": fmt.Sprintf("eq.%s", userID),
-		"order":   "created_at.asc",
+		"user_id":    fmt.Sprintf("eq.%s", userID),
+		"deleted_at": "is.null",
+		"order":      "created_at.asc",
 	}
 
 	body, err := r.client.Query("event_types", query)
@@ -138,8 +165,9 @@ func (r *eventTypeRepository) Delete(ctx context.Context, id string) error {
 // CountByUser returns total event types for a user
 func (r *eventTypeRepository) CountByUser(ctx context.Context, userID string) (int64, error) {
 	query := map[string]interface{}{
-		"user_id": fmt.Sprintf("eq.%s", userID),
-		"select":  "id",
+		"user_id":    fmt.Sprintf("eq.%s", userID),
+		"deleted_at": "is.null",
+		"select":     "id",
 	}
 	body, err := r.client.Query("event_types", query)
 	if err != nil {
@@ -155,10 +183,11 @@ func (r *eventTypeRepository) CountByUser(ctx context.Context, userID string) (i
 // GetLatestTimestamp returns the most recent event_type updated_at for a user
 func (r *eventTypeRepository) GetLatestTimestamp(ctx context.Context, userID string) (*time.Time, error) {
 	query := map[string]interface{}{
-		"user_id": fmt.Sprintf("eq.%s", userID),
-		"select":  "updated_at",
-		"order":   "updated_at.desc",
-		"limit":   1,
+		"user_id":    fmt.Sprintf("eq.%s", userID),
+		"deleted_at": "is.null",
+		"select":     "updated_at",
+		"order":      "updated_at.desc",
+		"limit":      1,
 	}
 	body, err := r.client.Query("event_types", query)
 	if err != nil {
@@ -175,3 +204,139 @@ func (r *eventTypeRepository) GetLatestTimestamp(ctx context.Context, userID str
 	}
 	return eventTypes[0].UpdatedAt, nil
 }
+
+// SoftDelete moves an event type to the trash
+func (r *eventTypeRepository) SoftDelete(ctx context.Context, id string, deletedAt time.Time) error {
+	query := map[string]interface{}{
+		"id":         fmt.Sprintf("eq.%s", id),
+		"deleted_at": "is.null",
+	}
+
+	if _, err := r.client.UpdateWhere("event_types", query, map[string]interface{}{"deleted_at": deletedAt}); err != nil {
+		return fmt.Errorf("failed to delete event type: %w", err)
+	}
+	return nil
+}
+
+// GetDeletedByID retrieves a trashed event type
+func (r *eventTypeRepository) GetDeletedByID(ctx context.Context, id string) (*models.EventType, error) {
+	query := map[string]interface{}{
+		"id":         fmt.Sprintf("eq.%s", id),
+		"deleted_at": "not.is.null",
+	}
+
+	body, err := r.client.Query("event_types", query)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get event type: %w", err)
+	}
+
+	var eventTypes []models.EventType
+	if err := json.Unmarshal(body, &eventTypes); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal response: %w", err)
+	}
+
+	if len(eventTypes) == 0 {
+		return nil, fmt.Errorf("event type not found")
+	}
+
+	return &eventTypes[0], nil
+}
+
+// GetDeletedByUserID returns a user's trashed event types, most recently deleted first
+func (r *eventTypeRepository) GetDeletedByUserID(ctx context.Context, userID string) ([]models.EventType, error) {
+	query := map[string]interface{}{
+		"user_id":    fmt.Sprintf("eq.%s", userID),
+		"deleted_at": "not.is.null",
+		"order":      "deleted_at.desc",
+	}
+
+	body, err := r.client.Query("event_types", query)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get deleted event types: %w", err)
+	}
+
+	var eventTypes []models.EventType
+	if err := json.Unmarshal(body, &eventTypes); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal response: %w", err)
+	}
+
+	return eventTypes, nil
+}
+
+// Restore takes a trashed event type out of the trash
+func (r *eventTypeRepository) Restore(ctx context.Context, id string) (*models.EventType, error) {
+	query := map[string]interface{}{
+		"id":         fmt.Sprintf("eq.%s", id),
+		"deleted_at": "not.is.null",
+	}
+
+	body, err := r.client.UpdateWhere("event_types", query, map[string]interface{}{"deleted_at": nil})
+	if err != nil {
+		return nil, fmt.Errorf("failed to restore event type: %w", err)
+	}
+
+	var eventTypes []models.EventType
+	if err := json.Unmarshal(body, &eventTypes); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal response: %w", err)
+	}
+
+	if len(eventTypes) == 0 {
+		return nil, fmt.Errorf("event type not found")
+	}
+
+	return &eventTypes[0], nil
+}
+
+// PurgeDeleted permanently removes event types trashed before the given time.
+// Their events and property definitions cascade.
+func (r *eventTypeRepository) PurgeDeleted(ctx context.Context, before time.Time) (int64, error) {
+	body, err := r.client.RPC("purge_deleted_event_types", map[string]interface{}{
+		"p_before": before.UTC().Format(time.RFC3339Nano),
+	})
+	if err != nil {
+		return 0, fmt.Errorf("failed to purge event types: %w", err)
+	}
+
+	var purged int64
+	if err := json.Unmarshal(body, &purged); err != nil {
+		return 0, fmt.Errorf("failed to unmarshal purge result: %w", err)
+	}
+
+	return purged, nil
+}
+
+// SetArchived archives or unarchives an event type
+func (r *eventTypeRepository) SetArchived(ctx context.Context, id string, archivedAt *time.Time) (*models.EventType, error) {
+	query := map[string]interface{}{
+		"id":         fmt.Sprintf("eq.%s", id),
+		"deleted_at": "is.null",
+	}
+
+	body, err := r.client.UpdateWhere("event_types", query, map[string]interface{}{"archived_at": archivedAt})
+	if err != nil {
+		return nil, fmt.Errorf("failed to archive event type: %w", err)
+	}
+
+	var eventTypes []models.EventType
+	if err := json.Unmarshal(body, &eventTypes); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal response: %w", err)
+	}
+
+	if len(eventTypes) == 0 {
+		return nil, fmt.Errorf("event type not found")
+	}
+
+	return &eventTypes[0], nil
+}
+
+func (r *eventTypeRepository) DeleteByUserID(ctx context.Context, userID string) error {
+	query := map[string]interface{}{
+		"user_id": fmt.Sprintf("eq.%s", userID),
+	}
+
+	if err := r.client.DeleteWhere("event_types", query); err != nil {
+		return fmt.Errorf("failed to delete event types: %w", err)
+	}
+
+	return nil
+}
@@ -4,6 +4,7 @@ import (
 	"context"
 	"encoding/json"
 	"fmt"
+	"time"
 
 	"github.com/JonnyWalker81/trendy/backend/internal/models"
 	"github.com/JonnyWalker81/trendy/backend/pkg/supabase"
@@ -99,6 +100,30 @@ func (r *geofenceRepository) GetByID(ctx context.Context, id string) (*models.Ge
 	return &geofences[0], nil
 }
 
+// ClaimVersion cannot hold a lock across PostgREST requests. It claims the
+// version with a conditional update instead, which moves updated_at on, so a
+// concurrent writer claiming the same version finds it gone.
+func (r *geofenceRepository) ClaimVersion(ctx context.Context, id string, updatedAt time.Time) (bool, error) {
+	query := map[string]interface{}{
+		"id":         fmt.Sprintf("eq.%s", id),
+		"updated_at": fmt.Sprintf("eq.%s", updatedAt.UTC().Format(time.RFC3339Nano)),
+	}
+
+	body, err := r.client.UpdateWhere("geofences", query, map[string]interface{}{"updated_at": updatedAt})
+	if err != nil {
+		return false, fmt.Errorf("failed to claim geofence: %w", err)
+	}
+
+	var claimed []struct {
+		ID string `json:"id"`
+	}
+	if err := json.Unmarshal(body, &claimed); err != nil {
+		return false, fmt.Errorf("failed to unmarshal response: %w", err)
+	}
+
+	return len(claimed) > 0, nil
+}
+
 func (r *geofenceRepository) GetByUserID(ctx context.Context, userID string) ([]models.Geofence, error) {
 	query := map[string]interface{}{
 		"user_id": fmt.Sprintf("eq.%s", userID),
@@ -199,3 +224,15 @@ func (r *geofenceRepository) Delete(ctx context.Context, id string) error {
 	}
 	return nil
 }
+
+func (r *geofenceRepository) DeleteByUserID(ctx context.Context, userID string) error {
+	query := map[string]interface{}{
+		"user_id": fmt.Sprintf("eq.%s", userID),
+	}
+
+	if err := r.client.DeleteWhere("geofences", query); err != nil {
+		return fmt.Errorf("failed to delete geofences: %w", err)
+	}
+
+	return nil
+}
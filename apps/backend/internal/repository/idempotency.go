@@ -16,6 +16,9 @@ type IdempotencyRepository interface {
 
 	// Store saves a new idempotency record
 	Store(ctx context.Context, key, route, userID string, responseBody []byte, statusCode int) error
+
+	// DeleteByUserID removes every idempotency record of a user
+	DeleteByUserID(ctx context.Context, userID string) error
 }
 
 type idempotencyRepository struct {
@@ -67,3 +70,15 @@ func (r *idempotencyRepository) Store(ctx context.Context, key, route, userID st
 
 	return nil
 }
+
+func (r *idempotencyRepository) DeleteByUserID(ctx context.Context, userID string) error {
+	query := map[string]interface{}{
+		"user_id": fmt.Sprintf("eq.%s", userID),
+	}
+
+	if err := r.client.DeleteWhere("idempotency_keys", query); err != nil {
+		return fmt.Errorf("failed to delete idempotency keys: %w", err)
+	}
+
+	return nil
+}
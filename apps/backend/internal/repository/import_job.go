@@ -0,0 +1,116 @@
+package repository
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+
+	"github.com/JonnyWalker81/trendy/backend/internal/models"
+	"github.com/JonnyWalker81/trendy/backend/pkg/supabase"
+)
+
+type importJobRepository struct {
+	client *supabase.Client
+}
+
+// NewImportJobRepository creates a new import job repository
+func NewImportJobRepository(client *supabase.Client) ImportJobRepository {
+	return &importJobRepository{client: client}
+}
+
+func (r *importJobRepository) Create(ctx context.Context, job *models.ImportJob) (*models.ImportJob, error) {
+	data := map[string]interface{}{
+		"user_id":    job.UserID,
+		"status":     job.Status,
+		"format":     job.Format,
+		"total_rows": job.TotalRows,
+		"errors":     importErrors(job),
+	}
+
+	body, err := r.client.Insert("import_jobs", data)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create import job: %w", err)
+	}
+
+	var jobs []models.ImportJob
+	if err := json.Unmarshal(body, &jobs); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal response: %w", err)
+	}
+
+	if len(jobs) == 0 {
+		return nil, fmt.Errorf("no import job returned")
+	}
+
+	return &jobs[0], nil
+}
+
+func (r *importJobRepository) GetByID(ctx context.Context, id string) (*models.ImportJob, error) {
+	query := map[string]interface{}{
+		"id": fmt.Sprintf("eq.%s", id),
+	}
+
+	body, err := r.client.Query("import_jobs", query)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get import job: %w", err)
+	}
+
+	var jobs []models.ImportJob
+	if err := json.Unmarshal(body, &jobs); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal response: %w", err)
+	}
+
+	if len(jobs) == 0 {
+		return nil, fmt.Errorf("import job not found")
+	}
+
+	return &jobs[0], nil
+}
+
+func (r *importJobRepository) UpdateProgress(ctx context.Context, job *models.ImportJob) (*models.ImportJob, error) {
+	data := map[string]interface{}{
+		"status":         job.Status,
+		"processed_rows": job.ProcessedRows,
+		"created_count":  job.CreatedCount,
+		"failed_count":   job.FailedCount,
+		"errors":         importErrors(job),
+		"error":          job.Error,
+		"completed_at":   job.CompletedAt,
+	}
+
+	body, err := r.client.Update("import_jobs", job.ID, data)
+	if err != nil {
+		return nil, fmt.Errorf("failed to update import job: %w", err)
+	}
+
+	var jobs []models.ImportJob
+	if err := json.Unmarshal(body, &jobs); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal response: %w", err)
+	}
+
+	if len(jobs) == 0 {
+		return nil, fmt.Errorf("import job not found")
+	}
+
+	return &jobs[0], nil
+}
+
+// importErrors returns the job's row errors, never nil so the column stays
+// a JSON array
+func importErrors(job *models.ImportJob) []models.ImportRowError {
+	if job.Errors == nil {
+		return []models.ImportRowError{}
+	}
+	return job.Errors
+}
+
+func (r *importJobRepository) DeleteByUserID(ctx context.Context, userID string) error {
+	query := map[string]interface{}{
+		"user_id": fmt.Sprintf("eq.%s", userID),
+	}
+
+	if err := r.client.DeleteWhere("import_jobs", query); err != nil {
+		return fmt.Errorf("failed to delete import jobs: %w", err)
+	}
+
+	return nil
+}
@@ -20,9 +20,21 @@ type EventRepository interface {
 	Create(ctx context.Context, event *models.Event) (*models.Event, error)
 	CreateBatch(ctx context.Context, events []models.Event) ([]models.Event, error)
 	GetByID(ctx context.Context, id string) (*models.Event, error)
+	// ClaimVersion reports whether a live event is still at version
+	// updatedAt and, if so, locks it against other writers for the rest of
+	// the transaction
+	ClaimVersion(ctx context.Context, id string, updatedAt time.Time) (bool, error)
 	GetByUserID(ctx context.Context, userID string, limit, offset int) ([]models.Event, error)
 	GetByUserIDAndDateRange(ctx context.Context, userID string, startDate, endDate time.Time) ([]models.Event, error)
 	GetForExport(ctx context.Context, userID string, startDate, endDate *time.Time, eventTypeIDs []string) ([]models.Event, error)
+	// GetForExportPage returns up to limit events matching the GetForExport
+	// filters that come after the given cursor, newest first with ties broken
+	// by descending ID. A nil cursor starts at the newest event.
+	GetForExportPage(ctx context.Context, userID string, startDate, endDate *time.Time, eventTypeIDs []string, after *models.EventCursor, limit int) ([]models.Event, error)
+	// GetPage returns up to limit live events matching filter that come after
+	// the given cursor, newest first with ties broken by descending ID. A nil
+	// cursor starts at the newest event.
+	GetPage(ctx context.Context, userID string, filter *models.EventFilter, after *models.EventCursor, limit int) ([]models.Event, error)
 	Update(ctx context.Context, id string, event *models.Event) (*models.Event, error)
 	// UpdateFields updates specific fields of an event.
 	// The fields map contains field names (snake_case) mapped to their new values.
@@ -58,12 +70,40 @@ type EventRepository interface {
 	GetLatestTimestamp(ctx context.Context, userID string) (*time.Time, error)
 	// GetLatestHealthKitTimestamp returns the most recent HealthKit event timestamp for a user
 	GetLatestHealthKitTimestamp(ctx context.Context, userID string) (*time.Time, error)
+	// SoftDelete moves an event to the trash. Trashed events are hidden from
+	// every other read except GetDeletedByID and GetDeletedByUserID.
+	SoftDelete(ctx context.Context, id string, deletedAt time.Time) error
+	// SoftDeleteByEventType moves the live events of an event type to the
+	// trash and returns their IDs
+	SoftDeleteByEventType(ctx context.Context, eventTypeID string, deletedAt time.Time) ([]string, error)
+	// GetDeletedByID retrieves a trashed event
+	GetDeletedByID(ctx context.Context, id string) (*models.Event, error)
+	// GetDeletedByUserID returns a user's trashed events, most recently deleted first
+	GetDeletedByUserID(ctx context.Context, userID string) ([]models.Event, error)
+	// Restore takes a trashed event out of the trash
+	Restore(ctx context.Context, id string) (*models.Event, error)
+	// RestoreByEventType restores the events of an event type that were
+	// trashed at deletedAt, i.e. together with the event type
+	RestoreByEventType(ctx context.Context, eventTypeID string, deletedAt time.Time) ([]models.Event, error)
+	// PurgeDeleted permanently removes events trashed before the given time
+	PurgeDeleted(ctx context.Context, before time.Time) (int64, error)
+	// ReassignEventType moves the live events of one event type to another
+	// and returns them. It fails without moving any event if one would
+	// duplicate an event of the target type.
+	ReassignEventType(ctx context.Context, fromEventTypeID, toEventTypeID string) ([]models.Event, error)
+	// DeleteByUserID permanently removes every event of a user, including
+	// trashed ones
+	DeleteByUserID(ctx context.Context, userID string) error
 }
 
 // EventTypeRepository defines the interface for event type data access
 type EventTypeRepository interface {
 	Create(ctx context.Context, eventType *models.EventType) (*models.EventType, error)
 	GetByID(ctx context.Context, id string) (*models.EventType, error)
+	// ClaimVersion reports whether a live event type is still at version
+	// updatedAt and, if so, locks it against other writers for the rest of
+	// the transaction
+	ClaimVersion(ctx context.Context, id string, updatedAt time.Time) (bool, error)
 	GetByUserID(ctx context.Context, userID string) ([]models.EventType, error)
 	Update(ctx context.Context, id string, eventType *models.EventType) (*models.EventType, error)
 	Delete(ctx context.Context, id string) error
@@ -71,6 +111,24 @@ type EventTypeRepository interface {
 	CountByUser(ctx context.Context, userID string) (int64, error)
 	// GetLatestTimestamp returns the most recent event_type updated_at for a user
 	GetLatestTimestamp(ctx context.Context, userID string) (*time.Time, error)
+	// SoftDelete moves an event type to the trash. Its events are trashed
+	// separately with EventRepository.SoftDeleteByEventType.
+	SoftDelete(ctx context.Context, id string, deletedAt time.Time) error
+	// GetDeletedByID retrieves a trashed event type
+	GetDeletedByID(ctx context.Context, id string) (*models.EventType, error)
+	// GetDeletedByUserID returns a user's trashed event types, most recently deleted first
+	GetDeletedByUserID(ctx context.Context, userID string) ([]models.EventType, error)
+	// Restore takes a trashed event type out of the trash
+	Restore(ctx context.Context, id string) (*models.EventType, error)
+	// PurgeDeleted permanently removes event types trashed before the given
+	// time, cascading to their events and property definitions
+	PurgeDeleted(ctx context.Context, before time.Time) (int64, error)
+	// SetArchived archives an event type at archivedAt, or unarchives it if
+	// archivedAt is nil
+	SetArchived(ctx context.Context, id string, archivedAt *time.Time) (*models.EventType, error)
+	// DeleteByUserID permanently removes every event type of a user along
+	// with their events and property definitions
+	DeleteByUserID(ctx context.Context, userID string) error
 }
 
 // UserRepository defines the interface for user data access
@@ -78,25 +136,41 @@ type UserRepository interface {
 	GetByID(ctx context.Context, id string) (*models.User, error)
 	GetByEmail(ctx context.Context, email string) (*models.User, error)
 	Create(ctx context.Context, user *models.User) (*models.User, error)
+	// List returns users ordered by creation time, for maintenance jobs
+	List(ctx context.Context, limit, offset int) ([]models.User, error)
+	// Delete removes a user and everything the user still owns
+	Delete(ctx context.Context, id string) error
 }
 
 // PropertyDefinitionRepository defines the interface for property definition data access
 type PropertyDefinitionRepository interface {
 	Create(ctx context.Context, def *models.PropertyDefinition) (*models.PropertyDefinition, error)
 	GetByID(ctx context.Context, id string) (*models.PropertyDefinition, error)
+	// ClaimVersion reports whether a property definition is still at version
+	// updatedAt and, if so, locks it against other writers for the rest of
+	// the transaction
+	ClaimVersion(ctx context.Context, id string, updatedAt time.Time) (bool, error)
 	GetByEventTypeID(ctx context.Context, eventTypeID string) ([]models.PropertyDefinition, error)
+	// Update sets the non-zero fields of def, and Required and Formula
+	// whatever their values, so callers pass the current ones to keep them
 	Update(ctx context.Context, id string, def *models.PropertyDefinition) (*models.PropertyDefinition, error)
 	Delete(ctx context.Context, id string) error
+	DeleteByUserID(ctx context.Context, userID string) error
 }
 
 // GeofenceRepository defines the interface for geofence data access
 type GeofenceRepository interface {
 	Create(ctx context.Context, geofence *models.Geofence) (*models.Geofence, error)
 	GetByID(ctx context.Context, id string) (*models.Geofence, error)
+	// ClaimVersion reports whether a geofence is still at version updatedAt
+	// and, if so, locks it against other writers for the rest of the
+	// transaction
+	ClaimVersion(ctx context.Context, id string, updatedAt time.Time) (bool, error)
 	GetByUserID(ctx context.Context, userID string) ([]models.Geofence, error)
 	GetActiveByUserID(ctx context.Context, userID string) ([]models.Geofence, error)
 	Update(ctx context.Context, id string, geofence *models.Geofence) (*models.Geofence, error)
 	Delete(ctx context.Context, id string) error
+	DeleteByUserID(ctx context.Context, userID string) error
 }
 
 // InsightRepository defines the interface for insight data access
@@ -119,7 +193,29 @@ type DailyAggregateRepository interface {
 	GetByUserIDAndDateRange(ctx context.Context, userID string, startDate, endDate time.Time) ([]models.DailyAggregate, error)
 	GetByUserIDAndEventType(ctx context.Context, userID, eventTypeID string, startDate, endDate time.Time) ([]models.DailyAggregate, error)
 	DeleteByUserID(ctx context.Context, userID string) error
+	DeleteByEventType(ctx context.Context, userID, eventTypeID string) error
 	DeleteOlderThan(ctx context.Context, userID string, date time.Time) error
+	// Delete removes the aggregate of one event type on one day
+	Delete(ctx context.Context, userID, eventTypeID string, date time.Time) error
+}
+
+// AggregateStateRepository defines the interface for the bookkeeping that
+// keeps daily aggregates up to date from the change log
+type AggregateStateRepository interface {
+	// GetByUserID returns the user's aggregate state, or nil if their
+	// aggregates have never been built
+	GetByUserID(ctx context.Context, userID string) (*models.AggregateState, error)
+	// Upsert creates or replaces the user's aggregate state
+	Upsert(ctx context.Context, state *models.AggregateState) error
+	// GetEvents returns where each of the given events is counted. Events
+	// that are not counted are left out.
+	GetEvents(ctx context.Context, userID string, eventIDs []string) ([]models.AggregatedEvent, error)
+	// UpsertEvents records where events are counted
+	UpsertEvents(ctx context.Context, events []models.AggregatedEvent) error
+	// DeleteEvents forgets where events are counted
+	DeleteEvents(ctx context.Context, userID string, eventIDs []string) error
+	// DeleteByUserID removes the user's aggregate state and counted events
+	DeleteByUserID(ctx context.Context, userID string) error
 }
 
 // StreakRepository defines the interface for streak data access
@@ -132,6 +228,16 @@ type StreakRepository interface {
 	DeleteByEventType(ctx context.Context, userID, eventTypeID string) error
 }
 
+// UserSettingsRepository defines the interface for user settings data access
+type UserSettingsRepository interface {
+	// GetByUserID returns the user's settings, or nil if they have saved none
+	GetByUserID(ctx context.Context, userID string) (*models.UserSettings, error)
+	// Upsert creates or replaces the user's settings
+	Upsert(ctx context.Context, settings *models.UserSettings) (*models.UserSettings, error)
+	// DeleteByUserID removes the user's settings
+	DeleteByUserID(ctx context.Context, userID string) error
+}
+
 // OnboardingStatusRepository defines the interface for onboarding status data access
 type OnboardingStatusRepository interface {
 	// GetOrCreate returns the user's onboarding status, creating a default record if none exists
@@ -140,4 +246,85 @@ type OnboardingStatusRepository interface {
 	Update(ctx context.Context, userID string, status *models.OnboardingStatus) (*models.OnboardingStatus, error)
 	// SoftReset clears step completion but preserves permission data
 	SoftReset(ctx context.Context, userID string) (*models.OnboardingStatus, error)
+	// DeleteByUserID removes the user's onboarding status
+	DeleteByUserID(ctx context.Context, userID string) error
+}
+
+// ImportJobRepository stores the progress of background event imports
+type ImportJobRepository interface {
+	Create(ctx context.Context, job *models.ImportJob) (*models.ImportJob, error)
+	GetByID(ctx context.Context, id string) (*models.ImportJob, error)
+	// UpdateProgress replaces the job's status, counters and errors
+	UpdateProgress(ctx context.Context, job *models.ImportJob) (*models.ImportJob, error)
+	// DeleteByUserID removes every import job of a user
+	DeleteByUserID(ctx context.Context, userID string) error
+}
+
+// JobRepository is the durable queue of background jobs
+type JobRepository interface {
+	// Enqueue adds a pending job. If the user already has a pending job of
+	// the same kind, that job is moved to job.RunAt instead, but no later
+	// than maxWait after it was queued; zero means no limit. A retry, with
+	// more attempts than the pending job, leaves it unchanged. Otherwise the
+	// pending job takes job's attempts and error.
+	Enqueue(ctx context.Context, job *models.Job, maxWait time.Duration) (*models.Job, error)
+	// Claim marks up to limit due pending jobs as running, earliest first,
+	// and returns them. A job is not claimed while another job of the same
+	// kind and user is running.
+	Claim(ctx context.Context, limit int) ([]models.Job, error)
+	// Finish records the status, error and completion time of a job
+	Finish(ctx context.Context, job *models.Job) (*models.Job, error)
+	// GetLatest returns the most recent job of a kind for userID, or nil if
+	// there is none. An empty userID matches jobs that belong to no user.
+	GetLatest(ctx context.Context, kind models.JobKind, userID string) (*models.Job, error)
+	// GetStale returns the jobs that have been running since before the
+	// given time
+	GetStale(ctx context.Context, before time.Time) ([]models.Job, error)
+	// DeleteFinished removes succeeded and failed jobs completed before the
+	// given time
+	DeleteFinished(ctx context.Context, before time.Time) error
+	// DeleteByUserID removes every job of a user
+	DeleteByUserID(ctx context.Context, userID string) error
+}
+
+// ChangeOutboxRepository reads the writes that database triggers record for
+// synced entities, so change log entries lost by a failed append can be
+// relayed
+type ChangeOutboxRepository interface {
+	// Claim removes the entries whose change has since been logged, then
+	// leases up to limit of the remaining entries that changed before
+	// settledBefore and returns them. A leased entry is not claimed again
+	// until lease has passed.
+	Claim(ctx context.Context, settledBefore time.Time, lease time.Duration, limit int) ([]models.OutboxEntry, error)
+	// Delete removes an entry once its change has been logged
+	Delete(ctx context.Context, id int64) error
+	// DeleteByUserID removes every entry of a user
+	DeleteByUserID(ctx context.Context, userID string) error
+}
+
+// AccountExportRepository stores account export archives
+type AccountExportRepository interface {
+	Create(ctx context.Context, export *models.AccountExport) (*models.AccountExport, error)
+	// GetByID returns an export without its archive
+	GetByID(ctx context.Context, id string) (*models.AccountExport, error)
+	// GetArchive returns the zip archive of a completed export
+	GetArchive(ctx context.Context, id string) ([]byte, error)
+	// UpdateStatus records the export's status, error and expiry, and stores
+	// archive if it is not nil
+	UpdateStatus(ctx context.Context, export *models.AccountExport, archive []byte) (*models.AccountExport, error)
+	// DeleteByUserID removes every export of a user
+	DeleteByUserID(ctx context.Context, userID string) error
+}
+
+// AccountDeletionRepository stores the audit tombstones of account deletions
+type AccountDeletionRepository interface {
+	// Start returns the user's pending deletion, recording a new one if
+	// there is none
+	Start(ctx context.Context, userID string) (*models.AccountDeletion, error)
+	// GetPending returns the user's pending deletion, or nil if there is none
+	GetPending(ctx context.Context, userID string) (*models.AccountDeletion, error)
+	// ListPending returns the deletions that have not completed, oldest first
+	ListPending(ctx context.Context) ([]models.AccountDeletion, error)
+	// Update records the deletion's status and completed steps
+	Update(ctx context.Context, deletion *models.AccountDeletion) (*models.AccountDeletion, error)
 }
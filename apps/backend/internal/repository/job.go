@@ -0,0 +1,174 @@
+package repository
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/JonnyWalker81/trendy/backend/internal/models"
+	"github.com/JonnyWalker81/trendy/backend/pkg/supabase"
+)
+
+type jobRepository struct {
+	client *supabase.Client
+}
+
+// NewJobRepository creates a new job repository
+func NewJobRepository(client *supabase.Client) JobRepository {
+	return &jobRepository{client: client}
+}
+
+// Enqueue debounces through the enqueue_job function, since PostgREST cannot
+// upsert against the partial index of pending jobs
+func (r *jobRepository) Enqueue(ctx context.Context, job *models.Job, maxWait time.Duration) (*models.Job, error) {
+	var wait interface{}
+	if maxWait > 0 {
+		wait = fmt.Sprintf("%d microseconds", maxWait.Microseconds())
+	}
+
+	body, err := r.client.RPC("enqueue_job", map[string]interface{}{
+		"p_kind":       job.Kind,
+		"p_user_id":    jobUserID(job.UserID),
+		"p_run_at":     job.RunAt.UTC().Format(time.RFC3339Nano),
+		"p_attempts":   job.Attempts,
+		"p_last_error": job.LastError,
+		"p_max_wait":   wait,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to enqueue job: %w", err)
+	}
+
+	var jobs []models.Job
+	if err := json.Unmarshal(body, &jobs); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal response: %w", err)
+	}
+
+	if len(jobs) == 0 {
+		return nil, fmt.Errorf("no job returned")
+	}
+
+	return &jobs[0], nil
+}
+
+func (r *jobRepository) Claim(ctx context.Context, limit int) ([]models.Job, error) {
+	body, err := r.client.RPC("claim_jobs", map[string]interface{}{
+		"p_limit": limit,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to claim jobs: %w", err)
+	}
+
+	var jobs []models.Job
+	if err := json.Unmarshal(body, &jobs); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal response: %w", err)
+	}
+
+	return jobs, nil
+}
+
+func (r *jobRepository) Finish(ctx context.Context, job *models.Job) (*models.Job, error) {
+	data := map[string]interface{}{
+		"status":       job.Status,
+		"last_error":   job.LastError,
+		"completed_at": job.CompletedAt,
+	}
+
+	body, err := r.client.Update("jobs", job.ID, data)
+	if err != nil {
+		return nil, fmt.Errorf("failed to finish job: %w", err)
+	}
+
+	var jobs []models.Job
+	if err := json.Unmarshal(body, &jobs); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal response: %w", err)
+	}
+
+	if len(jobs) == 0 {
+		return nil, fmt.Errorf("job not found")
+	}
+
+	return &jobs[0], nil
+}
+
+func (r *jobRepository) GetLatest(ctx context.Context, kind models.JobKind, userID string) (*models.Job, error) {
+	query := map[string]interface{}{
+		"kind":    fmt.Sprintf("eq.%s", kind),
+		"user_id": "is.null",
+		"order":   "created_at.desc,id.desc",
+		"limit":   1,
+	}
+	if userID != "" {
+		query["user_id"] = fmt.Sprintf("eq.%s", userID)
+	}
+
+	body, err := r.client.Query("jobs", query)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get job: %w", err)
+	}
+
+	var jobs []models.Job
+	if err := json.Unmarshal(body, &jobs); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal response: %w", err)
+	}
+
+	if len(jobs) == 0 {
+		return nil, nil
+	}
+
+	return &jobs[0], nil
+}
+
+func (r *jobRepository) GetStale(ctx context.Context, before time.Time) ([]models.Job, error) {
+	query := map[string]interface{}{
+		"status":     fmt.Sprintf("eq.%s", models.JobRunning),
+		"started_at": fmt.Sprintf("lt.%s", before.UTC().Format(time.RFC3339Nano)),
+		"order":      "started_at.asc",
+	}
+
+	body, err := r.client.Query("jobs", query)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get stale jobs: %w", err)
+	}
+
+	var jobs []models.Job
+	if err := json.Unmarshal(body, &jobs); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal response: %w", err)
+	}
+
+	return jobs, nil
+}
+
+func (r *jobRepository) DeleteFinished(ctx context.Context, before time.Time) error {
+	query := map[string]interface{}{
+		"status":       fmt.Sprintf("in.(%s,%s)", models.JobSucceeded, models.JobFailed),
+		"completed_at": fmt.Sprintf("lt.%s", before.UTC().Format(time.RFC3339Nano)),
+	}
+
+	if err := r.client.DeleteWhere("jobs", query); err != nil {
+		return fmt.Errorf("failed to delete finished jobs: %w", err)
+	}
+
+	return nil
+}
+
+// jobUserID returns the user_id column value of a job, NULL for jobs that
+// belong to no user
+func jobUserID(userID string) interface{} {
+	if userID == "" {
+		return nil
+	}
+	return userID
+}
+
+func (r *jobRepository) DeleteByUserID(ctx context.Context, userID string) error {
+	query := map[string]interface{}{
+		"user_id": fmt.Sprintf("eq.%s", userID),
+	}
+
+	if err := r.client.DeleteWhere("jobs", query); err != nil {
+		return fmt.Errorf("failed to delete jobs: %w", err)
+	}
+
+	return nil
+}
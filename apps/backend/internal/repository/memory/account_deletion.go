@@ -0,0 +1,97 @@
+package memory
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/JonnyWalker81/trendy/backend/internal/models"
+	"github.com/JonnyWalker81/trendy/backend/internal/repository"
+)
+
+type accountDeletionRepository struct {
+	store *Store
+}
+
+// NewAccountDeletionRepository creates a new in-memory account deletion repository
+func NewAccountDeletionRepository(store *Store) repository.AccountDeletionRepository {
+	return &accountDeletionRepository{store: store}
+}
+
+func (r *accountDeletionRepository) Start(ctx context.Context, userID string) (*models.AccountDeletion, error) {
+	var deletion models.AccountDeletion
+	r.store.write(ctx, func(t *tables) error {
+		for _, d := range t.accountDeletions {
+			if d.UserID == userID && d.Status == models.AccountDeletionPending {
+				deletion = d
+				return nil
+			}
+		}
+
+		deletion = models.AccountDeletion{
+			ID:             newID(),
+			UserID:         userID,
+			Status:         models.AccountDeletionPending,
+			CompletedSteps: []models.AccountDeletionStep{},
+			RequestedAt:    now(),
+		}
+		deletion.UpdatedAt = deletion.RequestedAt
+		t.accountDeletions[deletion.ID] = deletion
+		return nil
+	})
+
+	return cloneAccountDeletion(deletion), nil
+}
+
+func (r *accountDeletionRepository) GetPending(ctx context.Context, userID string) (*models.AccountDeletion, error) {
+	var pending *models.AccountDeletion
+	r.store.read(ctx, func(t *tables) {
+		for _, d := range t.accountDeletions {
+			if d.UserID == userID && d.Status == models.AccountDeletionPending {
+				pending = cloneAccountDeletion(d)
+				return
+			}
+		}
+	})
+	return pending, nil
+}
+
+func (r *accountDeletionRepository) ListPending(ctx context.Context) ([]models.AccountDeletion, error) {
+	var deletions []models.AccountDeletion
+	r.store.read(ctx, func(t *tables) {
+		deletions = sortedValues(t.accountDeletions,
+			func(d models.AccountDeletion) bool { return d.Status == models.AccountDeletionPending },
+			func(a, b models.AccountDeletion) bool { return a.RequestedAt.Before(b.RequestedAt) })
+	})
+
+	for i := range deletions {
+		deletions[i] = *cloneAccountDeletion(deletions[i])
+	}
+	return deletions, nil
+}
+
+func (r *accountDeletionRepository) Update(ctx context.Context, deletion *models.AccountDeletion) (*models.AccountDeletion, error) {
+	var updated models.AccountDeletion
+	var found bool
+	r.store.write(ctx, func(t *tables) error {
+		if updated, found = t.accountDeletions[deletion.ID]; !found {
+			return nil
+		}
+		updated.Status = deletion.Status
+		updated.CompletedSteps = cloneAccountDeletion(*deletion).CompletedSteps
+		updated.CompletedAt = truncateTime(deletion.CompletedAt)
+		updated.UpdatedAt = now()
+		t.accountDeletions[updated.ID] = updated
+		return nil
+	})
+
+	if !found {
+		return nil, fmt.Errorf("account deletion not found")
+	}
+
+	return cloneAccountDeletion(updated), nil
+}
+
+func cloneAccountDeletion(d models.AccountDeletion) *models.AccountDeletion {
+	d.CompletedSteps = append([]models.AccountDeletionStep{}, d.CompletedSteps...)
+	return &d
+}
@@ -0,0 +1,121 @@
+package memory
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/JonnyWalker81/trendy/backend/internal/models"
+	"github.com/JonnyWalker81/trendy/backend/internal/repository"
+)
+
+// storedAccountExport is an account export row with its archive
+type storedAccountExport struct {
+	export  models.AccountExport
+	archive []byte
+}
+
+type accountExportRepository struct {
+	store *Store
+}
+
+// NewAccountExportRepository creates a new in-memory account export repository
+func NewAccountExportRepository(store *Store) repository.AccountExportRepository {
+	return &accountExportRepository{store: store}
+}
+
+func (r *accountExportRepository) Create(ctx context.Context, export *models.AccountExport) (*models.AccountExport, error) {
+	created := models.AccountExport{
+		ID:     newID(),
+		UserID: export.UserID,
+		Status: export.Status,
+	}
+	if created.Status == "" {
+		created.Status = models.AccountExportPending
+	}
+	created.CreatedAt = now()
+	created.UpdatedAt = created.CreatedAt
+
+	r.store.write(ctx, func(t *tables) error {
+		t.accountExports[created.ID] = storedAccountExport{export: created}
+		return nil
+	})
+
+	return &created, nil
+}
+
+func (r *accountExportRepository) GetByID(ctx context.Context, id string) (*models.AccountExport, error) {
+	var stored storedAccountExport
+	var found bool
+	r.store.read(ctx, func(t *tables) {
+		stored, found = t.accountExports[id]
+	})
+
+	if !found {
+		return nil, fmt.Errorf("account export not found")
+	}
+
+	return &stored.export, nil
+}
+
+func (r *accountExportRepository) GetArchive(ctx context.Context, id string) ([]byte, error) {
+	var stored storedAccountExport
+	var found bool
+	r.store.read(ctx, func(t *tables) {
+		stored, found = t.accountExports[id]
+	})
+
+	if !found || stored.archive == nil {
+		return nil, fmt.Errorf("account export archive not found")
+	}
+
+	return append([]byte(nil), stored.archive...), nil
+}
+
+func (r *accountExportRepository) UpdateStatus(ctx context.Context, export *models.AccountExport, archive []byte) (*models.AccountExport, error) {
+	var stored storedAccountExport
+	var found bool
+	r.store.write(ctx, func(t *tables) error {
+		if stored, found = t.accountExports[export.ID]; !found {
+			return nil
+		}
+		stored.export.Status = export.Status
+		stored.export.SizeBytes = export.SizeBytes
+		stored.export.Error = export.Error
+		stored.export.CompletedAt = truncateTime(export.CompletedAt)
+		stored.export.ExpiresAt = truncateTime(export.ExpiresAt)
+		stored.export.UpdatedAt = now()
+		if archive != nil {
+			stored.archive = append([]byte(nil), archive...)
+		}
+		t.accountExports[export.ID] = stored
+		return nil
+	})
+
+	if !found {
+		return nil, fmt.Errorf("account export not found")
+	}
+
+	return &stored.export, nil
+}
+
+func (r *accountExportRepository) DeleteByUserID(ctx context.Context, userID string) error {
+	r.store.write(ctx, func(t *tables) error {
+		for id, stored := range t.accountExports {
+			if stored.export.UserID == userID {
+				delete(t.accountExports, id)
+			}
+		}
+		return nil
+	})
+	return nil
+}
+
+// truncateTime matches the microsecond precision of Postgres timestamps
+func truncateTime(t *time.Time) *time.Time {
+	if t == nil {
+		return nil
+	}
+	truncated := t.UTC().Truncate(time.Microsecond)
+	return &truncated
+}
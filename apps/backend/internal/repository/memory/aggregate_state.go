@@ -0,0 +1,98 @@
+package memory
+
+import (
+	"context"
+
+	"github.com/JonnyWalker81/trendy/backend/internal/models"
+	"github.com/JonnyWalker81/trendy/backend/internal/repository"
+)
+
+type aggregateStateRepository struct {
+	store *Store
+}
+
+// NewAggregateStateRepository creates a new in-memory aggregate state repository
+func NewAggregateStateRepository(store *Store) repository.AggregateStateRepository {
+	return &aggregateStateRepository{store: store}
+}
+
+func (r *aggregateStateRepository) GetByUserID(ctx context.Context, userID string) (*models.AggregateState, error) {
+	var state models.AggregateState
+	var found bool
+	r.store.read(ctx, func(t *tables) {
+		state, found = t.aggregateStates[userID]
+	})
+
+	if !found {
+		return nil, nil
+	}
+
+	return &state, nil
+}
+
+func (r *aggregateStateRepository) Upsert(ctx context.Context, state *models.AggregateState) error {
+	return r.store.write(ctx, func(t *tables) error {
+		upserted := *state
+		upserted.UpdatedAt = now()
+		if existing, ok := t.aggregateStates[state.UserID]; ok {
+			upserted.CreatedAt = existing.CreatedAt
+		} else {
+			upserted.CreatedAt = upserted.UpdatedAt
+		}
+		t.aggregateStates[state.UserID] = upserted
+		return nil
+	})
+}
+
+func (r *aggregateStateRepository) GetEvents(ctx context.Context, userID string, eventIDs []string) ([]models.AggregatedEvent, error) {
+	events := []models.AggregatedEvent{}
+	r.store.read(ctx, func(t *tables) {
+		for _, id := range eventIDs {
+			if e, ok := t.aggregatedEvents[id]; ok && e.UserID == userID {
+				events = append(events, e)
+			}
+		}
+	})
+	return events, nil
+}
+
+func (r *aggregateStateRepository) UpsertEvents(ctx context.Context, events []models.AggregatedEvent) error {
+	if len(events) == 0 {
+		return nil
+	}
+
+	return r.store.write(ctx, func(t *tables) error {
+		for _, e := range events {
+			e.Date = truncateDate(e.Date)
+			t.aggregatedEvents[e.EventID] = e
+		}
+		return nil
+	})
+}
+
+func (r *aggregateStateRepository) DeleteEvents(ctx context.Context, userID string, eventIDs []string) error {
+	if len(eventIDs) == 0 {
+		return nil
+	}
+
+	return r.store.write(ctx, func(t *tables) error {
+		for _, id := range eventIDs {
+			if e, ok := t.aggregatedEvents[id]; ok && e.UserID == userID {
+				delete(t.aggregatedEvents, id)
+			}
+		}
+		return nil
+	})
+}
+
+func (r *aggregateStateRepository) DeleteByUserID(ctx context.Context, userID string) error {
+	return r.store.write(ctx, func(t *tables) error {
+		for id, e := range t.aggregatedEvents {
+			if e.UserID == userID {
+				delete(t.aggregatedEvents, id)
+			}
+		}
+		delete(t.aggregateStates, userID)
+		return nil
+	})
+}
@@ -0,0 +1,32 @@
+package memory
+
+import (
+	"context"
+	"time"
+
+	"github.com/JonnyWalker81/trendy/backend/internal/models"
+	"github.com/JonnyWalker81/trendy/backend/internal/repository"
+)
+
+type changeOutboxRepository struct {
+	store *Store
+}
+
+// NewChangeOutboxRepository creates a new in-memory change outbox repository.
+// Writes are committed together with their change log entries, so the store
+// never has changes left to relay and the outbox is always empty.
+func NewChangeOutboxRepository(store *Store) repository.ChangeOutboxRepository {
+	return &changeOutboxRepository{store: store}
+}
+
+func (r *changeOutboxRepository) Claim(ctx context.Context, settledBefore time.Time, lease time.Duration, limit int) ([]models.OutboxEntry, error) {
+	return []models.OutboxEntry{}, nil
+}
+
+func (r *changeOutboxRepository) Delete(ctx context.Context, id int64) error {
+	return nil
+}
+
+func (r *changeOutboxRepository) DeleteByUserID(ctx context.Context, userID string) error {
+	return nil
+}
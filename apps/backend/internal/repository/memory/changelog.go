@@ -0,0 +1,213 @@
+package memory
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/JonnyWalker81/trendy/backend/internal/models"
+	"github.com/JonnyWalker81/trendy/backend/internal/repository"
+)
+
+type changeLogRepository struct {
+	store *Store
+}
+
+// NewChangeLogRepository creates a new in-memory change log repository.
+// Entry IDs are assigned from a single counter per Store, so cursors increase
+// monotonically across all users like the BIGSERIAL column they replace.
+func NewChangeLogRepository(store *Store) repository.ChangeLogRepository {
+	return &changeLogRepository{store: store}
+}
+
+func (r *changeLogRepository) Append(ctx context.Context, input *models.ChangeLogInput) (int64, error) {
+	var data json.RawMessage
+	if input.Data != nil {
+		dataBytes, err := json.Marshal(input.Data)
+		if err != nil {
+			return 0, fmt.Errorf("failed to marshal entity data: %w", err)
+		}
+		data = dataBytes
+	}
+
+	var id int64
+	r.store.write(ctx, func(t *tables) error {
+		t.lastChangeID++
+		id = t.lastChangeID
+		t.changeLog = append(t.changeLog, models.ChangeEntry{
+			ID:         id,
+			EntityType: input.EntityType,
+			Operation:  input.Operation,
+			EntityID:   input.EntityID,
+			UserID:     input.UserID,
+			Data:       data,
+			DeletedAt:  input.DeletedAt,
+			CreatedAt:  now(),
+		})
+		return nil
+	})
+
+	return id, nil
+}
+
+func (r *changeLogRepository) GetSince(ctx context.Context, userID string, cursor int64, limit int) (*models.ChangeFeedResponse, error) {
+	if limit <= 0 {
+		limit = 100
+	}
+	if limit > 500 {
+		limit = 500
+	}
+
+	entries := make([]models.ChangeEntry, 0)
+	hasMore := false
+	r.store.read(ctx, func(t *tables) {
+		// The log is ordered by ID, so start after the cursor
+		start := sort.Search(len(t.changeLog), func(i int) bool { return t.changeLog[i].ID > cursor })
+		for _, entry := range t.changeLog[start:] {
+			if entry.UserID != userID {
+				continue
+			}
+			if len(entries) == limit {
+				hasMore = true
+				break
+			}
+			entries = append(entries, entry)
+		}
+	})
+
+	// When there are no new entries, preserve the original cursor
+	// to prevent the client from treating the next sync as a first-time sync
+	nextCursor := cursor
+	if len(entries) > 0 {
+		nextCursor = entries[len(entries)-1].ID
+	}
+
+	return &models.ChangeFeedResponse{
+		Changes:    entries,
+		NextCursor: nextCursor,
+		HasMore:    hasMore,
+	}, nil
+}
+
+func (r *changeLogRepository) GetLatestCursor(ctx context.Context, userID string) (int64, error) {
+	var latest int64
+	r.store.read(ctx, func(t *tables) {
+		// Entries may all have been removed by retention
+		latest = t.changeLogHorizons[userID]
+		for i := len(t.changeLog) - 1; i >= 0 && t.changeLog[i].ID > latest; i-- {
+			if t.changeLog[i].UserID == userID {
+				latest = t.changeLog[i].ID
+				return
+			}
+		}
+	})
+	return latest, nil
+}
+
+func (r *changeLogRepository) GetSettledCursor(ctx context.Context, userID string, before time.Time) (int64, error) {
+	settled, err := r.GetLatestCursor(ctx, userID)
+	if err != nil {
+		return 0, err
+	}
+
+	r.store.read(ctx, func(t *tables) {
+		for _, entry := range t.changeLog {
+			if entry.UserID == userID && !entry.CreatedAt.Before(before) {
+				settled = max(entry.ID-1, t.changeLogHorizons[userID])
+				return
+			}
+		}
+	})
+	return settled, nil
+}
+
+func (r *changeLogRepository) GetHorizon(ctx context.Context, userID string) (int64, error) {
+	var horizon int64
+	r.store.read(ctx, func(t *tables) {
+		horizon = t.changeLogHorizons[userID]
+	})
+	return horizon, nil
+}
+
+// entityKey identifies the entity a change log entry belongs to
+type entityKey struct {
+	userID     string
+	entityType models.EntityType
+	entityID   string
+}
+
+// latestEntries returns the ID of the latest entry of each entity
+func latestEntries(changeLog []models.ChangeEntry) map[entityKey]int64 {
+	latest := make(map[entityKey]int64, len(changeLog))
+	for _, entry := range changeLog {
+		latest[entityKey{entry.UserID, entry.EntityType, entry.EntityID}] = entry.ID
+	}
+	return latest
+}
+
+func (r *changeLogRepository) Compact(ctx context.Context) (int64, error) {
+	var removed int64
+	r.store.write(ctx, func(t *tables) error {
+		latest := latestEntries(t.changeLog)
+
+		kept := make([]models.ChangeEntry, 0, len(latest))
+		for _, entry := range t.changeLog {
+			if latest[entityKey{entry.UserID, entry.EntityType, entry.EntityID}] == entry.ID {
+				kept = append(kept, entry)
+			}
+		}
+		removed = int64(len(t.changeLog) - len(kept))
+		t.changeLog = kept
+		return nil
+	})
+
+	return removed, nil
+}
+
+func (r *changeLogRepository) Prune(ctx context.Context, before time.Time) (int64, error) {
+	var removed int64
+	r.store.write(ctx, func(t *tables) error {
+		latest := latestEntries(t.changeLog)
+
+		kept := make([]models.ChangeEntry, 0, len(t.changeLog))
+		for _, entry := range t.changeLog {
+			// The latest entry of a live entity is all that records it, so it
+			// is kept however old it is
+			live := entry.Operation != models.OperationDelete &&
+				latest[entityKey{entry.UserID, entry.EntityType, entry.EntityID}] == entry.ID
+			if live || !entry.CreatedAt.Before(before) {
+				kept = append(kept, entry)
+				continue
+			}
+			if entry.ID > t.changeLogHorizons[entry.UserID] {
+				t.changeLogHorizons[entry.UserID] = entry.ID
+			}
+		}
+		removed = int64(len(t.changeLog) - len(kept))
+		t.changeLog = kept
+		return nil
+	})
+
+	return removed, nil
+}
+
+func (r *changeLogRepository) DeleteByUserID(ctx context.Context, userID string) error {
+	return r.store.write(ctx, func(t *tables) error {
+		deleteUserChanges(t, userID)
+		return nil
+	})
+}
+
+func deleteUserChanges(t *tables, userID string) {
+	// The slice's backing array is shared with snapshots, so build a new one
+	kept := make([]models.ChangeEntry, 0, len(t.changeLog))
+	for _, entry := range t.changeLog {
+		if entry.UserID != userID {
+			kept = append(kept, entry)
+		}
+	}
+	t.changeLog = kept
+	delete(t.changeLogHorizons, userID)
+}
@@ -0,0 +1,163 @@
+package memory
+
+import (
+	"context"
+	"maps"
+	"slices"
+	"time"
+
+	"github.com/JonnyWalker81/trendy/backend/internal/models"
+	"github.com/JonnyWalker81/trendy/backend/internal/repository"
+)
+
+type dailyAggregateRepository struct {
+	store *Store
+}
+
+// NewDailyAggregateRepository creates a new in-memory daily aggregate repository
+func NewDailyAggregateRepository(store *Store) repository.DailyAggregateRepository {
+	return &dailyAggregateRepository{store: store}
+}
+
+// copyDailyAggregate returns agg with its own property aggregates maps
+func copyDailyAggregate(agg models.DailyAggregate) models.DailyAggregate {
+	props := make(map[string]models.PropAgg, len(agg.PropertyAggregates))
+	for k, v := range agg.PropertyAggregates {
+		if v.Options != nil {
+			v.Options = maps.Clone(v.Options)
+		}
+		props[k] = v
+	}
+	agg.PropertyAggregates = props
+	agg.HourCounts = slices.Clone(agg.HourCounts)
+	return agg
+}
+
+// upsertDailyAggregate inserts agg or replaces the row with the same
+// (user_id, date, event_type_id)
+func upsertDailyAggregate(t *tables, agg models.DailyAggregate) models.DailyAggregate {
+	a := copyDailyAggregate(agg)
+	a.Date = truncateDate(a.Date)
+	a.EventType = nil
+	a.UpdatedAt = now()
+
+	for id, existing := range t.dailyAggregates {
+		if existing.UserID == a.UserID && existing.EventTypeID == a.EventTypeID && dateKey(existing.Date) == dateKey(a.Date) {
+			a.ID = id
+			a.CreatedAt = existing.CreatedAt
+			t.dailyAggregates[id] = a
+			return copyDailyAggregate(a)
+		}
+	}
+
+	a.ID = newID()
+	a.CreatedAt = a.UpdatedAt
+	t.dailyAggregates[a.ID] = a
+	return copyDailyAggregate(a)
+}
+
+func (r *dailyAggregateRepository) Upsert(ctx context.Context, agg *models.DailyAggregate) (*models.DailyAggregate, error) {
+	var upserted models.DailyAggregate
+	r.store.write(ctx, func(t *tables) error {
+		upserted = upsertDailyAggregate(t, *agg)
+		return nil
+	})
+	return &upserted, nil
+}
+
+func (r *dailyAggregateRepository) BulkUpsert(ctx context.Context, aggs []models.DailyAggregate) error {
+	if len(aggs) == 0 {
+		return nil
+	}
+
+	return r.store.write(ctx, func(t *tables) error {
+		for _, agg := range aggs {
+			upsertDailyAggregate(t, agg)
+		}
+		return nil
+	})
+}
+
+func (r *dailyAggregateRepository) list(ctx context.Context, keep func(a models.DailyAggregate) bool, ascending bool) []models.DailyAggregate {
+	var aggs []models.DailyAggregate
+	r.store.read(ctx, func(t *tables) {
+		aggs = sortedValues(t.dailyAggregates, keep, func(a, b models.DailyAggregate) bool {
+			if ascending {
+				return a.Date.Before(b.Date)
+			}
+			return a.Date.After(b.Date)
+		})
+	})
+	for i := range aggs {
+		aggs[i] = copyDailyAggregate(aggs[i])
+	}
+	return aggs
+}
+
+// inDateRange compares calendar dates, matching the DATE column semantics
+func inDateRange(date, startDate, endDate time.Time) bool {
+	key := dateKey(date)
+	return key >= dateKey(startDate) && key <= dateKey(endDate)
+}
+
+func (r *dailyAggregateRepository) GetByUserID(ctx context.Context, userID string) ([]models.DailyAggregate, error) {
+	return r.list(ctx, func(a models.DailyAggregate) bool { return a.UserID == userID }, false), nil
+}
+
+func (r *dailyAggregateRepository) GetByUserIDAndDateRange(ctx context.Context, userID string, startDate, endDate time.Time) ([]models.DailyAggregate, error) {
+	return r.list(ctx, func(a models.DailyAggregate) bool {
+		return a.UserID == userID && inDateRange(a.Date, startDate, endDate)
+	}, true), nil
+}
+
+func (r *dailyAggregateRepository) GetByUserIDAndEventType(ctx context.Context, userID, eventTypeID string, startDate, endDate time.Time) ([]models.DailyAggregate, error) {
+	return r.list(ctx, func(a models.DailyAggregate) bool {
+		return a.UserID == userID && a.EventTypeID == eventTypeID && inDateRange(a.Date, startDate, endDate)
+	}, true), nil
+}
+
+func (r *dailyAggregateRepository) DeleteByUserID(ctx context.Context, userID string) error {
+	return r.store.write(ctx, func(t *tables) error {
+		for id, a := range t.dailyAggregates {
+			if a.UserID == userID {
+				delete(t.dailyAggregates, id)
+			}
+		}
+		return nil
+	})
+}
+
+func (r *dailyAggregateRepository) DeleteByEventType(ctx context.Context, userID, eventTypeID string) error {
+	return r.store.write(ctx, func(t *tables) error {
+		for id, a := range t.dailyAggregates {
+			if a.UserID == userID && a.EventTypeID == eventTypeID {
+				delete(t.dailyAggregates, id)
+			}
+		}
+		return nil
+	})
+}
+
+func (r *dailyAggregateRepository) DeleteOlderThan(ctx context.Context, userID string, date time.Time) error {
+	cutoff := dateKey(date)
+	return r.store.write(ctx, func(t *tables) error {
+		for id, a := range t.dailyAggregates {
+			if a.UserID == userID && dateKey(a.Date) < cutoff {
+				delete(t.dailyAggregates, id)
+			}
+		}
+		return nil
+	})
+}
+
+func (r *dailyAggregateRepository) Delete(ctx context.Context, userID, eventTypeID string, date time.Time) error {
+	key := dateKey(date)
+	return r.store.write(ctx, func(t *tables) error {
+		for id, a := range t.dailyAggregates {
+			if a.UserID == userID && a.EventTypeID == eventTypeID && dateKey(a.Date) == key {
+				delete(t.dailyAggregates, id)
+			}
+		}
+		return nil
+	})
+}
@@ -0,0 +1,999 @@
+package memory
+
+import (
+	"cmp"
+	"context"
+	"encoding/json"
+	"fmt"
+	"slices"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/JonnyWalker81/trendy/backend/internal/models"
+	"github.com/JonnyWalker81/trendy/backend/internal/repository"
+)
+
+type eventRepository struct {
+	store *Store
+}
+
+// NewEventRepository creates a new in-memory event repository
+func NewEventRepository(store *Store) repository.EventRepository {
+	return &eventRepository{store: store}
+}
+
+// copyEvent returns e with its own properties map so callers cannot mutate
+// stored data
+func copyEvent(e models.Event) models.Event {
+	if e.Properties != nil {
+		props := make(map[string]models.PropertyValue, len(e.Properties))
+		for k, v := range e.Properties {
+			props[k] = v
+		}
+		e.Properties = props
+	}
+	return e
+}
+
+// withEventType returns a copy of e with its event type embedded, matching
+// the "*,event_type:event_types(*)" projection of the other backends
+func withEventType(t *tables, e models.Event) models.Event {
+	e = copyEvent(e)
+	if et, ok := t.eventTypes[e.EventTypeID]; ok {
+		e.EventType = &et
+	}
+	return e
+}
+
+// checkEventUnique enforces the primary key and the HealthKit and manual
+// dedupe indexes of the events table. excludeID skips the row being updated.
+// Like the partial index, manual dedupe ignores trashed events.
+func checkEventUnique(t *tables, e models.Event, excludeID string) error {
+	if excludeID == "" {
+		if _, exists := t.events[e.ID]; exists {
+			return uniqueViolation("events_pkey")
+		}
+	}
+
+	for _, other := range t.events {
+		if other.ID == excludeID || other.ID == e.ID || other.UserID != e.UserID {
+			continue
+		}
+		if e.SourceType == "healthkit" {
+			if other.SourceType == "healthkit" && e.HealthKitSampleID != nil && other.HealthKitSampleID != nil &&
+				*e.HealthKitSampleID == *other.HealthKitSampleID {
+				return uniqueViolation("idx_events_healthkit_dedupe")
+			}
+			continue
+		}
+		if other.SourceType != "healthkit" && other.DeletedAt == nil && e.DeletedAt == nil &&
+			other.EventTypeID == e.EventTypeID && other.Timestamp.Equal(e.Timestamp) {
+			return uniqueViolation("idx_events_manual_dedupe")
+		}
+	}
+
+	return nil
+}
+
+// insertEvent applies column defaults to e and stores it
+func insertEvent(t *tables, e models.Event) (models.Event, error) {
+	e = copyEvent(e)
+	if e.ID == "" {
+		e.ID = newID()
+	}
+	if e.SourceType == "" {
+		e.SourceType = "manual"
+	}
+	// Properties column has NOT NULL constraint - use empty object {} if no properties
+	if e.Properties == nil {
+		e.Properties = map[string]models.PropertyValue{}
+	}
+	e.EventType = nil
+	e.DeletedAt = nil
+	e.CreatedAt = now()
+	e.UpdatedAt = e.CreatedAt
+
+	if err := checkEventUnique(t, e, ""); err != nil {
+		return models.Event{}, err
+	}
+
+	t.events[e.ID] = e
+	return copyEvent(e), nil
+}
+
+func (r *eventRepository) Create(ctx context.Context, event *models.Event) (*models.Event, error) {
+	var created models.Event
+	err := r.store.write(ctx, func(t *tables) error {
+		var err error
+		created, err = insertEvent(t, *event)
+		return err
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to create event: %w", err)
+	}
+
+	return &created, nil
+}
+
+func (r *eventRepository) CreateBatch(ctx context.Context, events []models.Event) ([]models.Event, error) {
+	if len(events) == 0 {
+		return []models.Event{}, nil
+	}
+
+	created := make([]models.Event, 0, len(events))
+	err := r.store.write(ctx, func(t *tables) error {
+		// A multi-row insert is atomic, so stage against a copy of the table
+		staged := *t
+		staged.events = cloneMap(t.events)
+		for _, event := range events {
+			e, err := insertEvent(&staged, event)
+			if err != nil {
+				return err
+			}
+			created = append(created, e)
+		}
+		t.events = staged.events
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to batch create events: %w", err)
+	}
+
+	return created, nil
+}
+
+func (r *eventRepository) GetByID(ctx context.Context, id string) (*models.Event, error) {
+	var event models.Event
+	var found bool
+	r.store.read(ctx, func(t *tables) {
+		var e models.Event
+		if e, found = t.events[id]; found && e.DeletedAt == nil {
+			event = withEventType(t, e)
+		}
+	})
+
+	if !found || event.ID == "" {
+		return nil, fmt.Errorf("event not found")
+	}
+
+	return &event, nil
+}
+
+// ClaimVersion needs no lock: transactions are serialized with all other
+// writes
+func (r *eventRepository) ClaimVersion(ctx context.Context, id string, updatedAt time.Time) (bool, error) {
+	var claimed bool
+	r.store.read(ctx, func(t *tables) {
+		current, found := t.events[id]
+		claimed = found && current.DeletedAt == nil && current.UpdatedAt.Equal(updatedAt.UTC().Truncate(time.Microsecond))
+	})
+	return claimed, nil
+}
+
+// listEvents returns the events matching keep with their event types
+// embedded, newest first
+func (r *eventRepository) listEvents(ctx context.Context, keep func(e models.Event) bool) []models.Event {
+	var events []models.Event
+	r.store.read(ctx, func(t *tables) {
+		matches := sortedValues(t.events, keep, func(a, b models.Event) bool {
+			return a.Timestamp.After(b.Timestamp)
+		})
+		events = make([]models.Event, len(matches))
+		for i, e := range matches {
+			events[i] = withEventType(t, e)
+		}
+	})
+	return events
+}
+
+func (r *eventRepository) GetByUserID(ctx context.Context, userID string, limit, offset int) ([]models.Event, error) {
+	events := r.listEvents(ctx, func(e models.Event) bool { return e.UserID == userID && e.DeletedAt == nil })
+	return paginate(events, limit, offset), nil
+}
+
+func (r *eventRepository) GetByUserIDAndDateRange(ctx context.Context, userID string, startDate, endDate time.Time) ([]models.Event, error) {
+	return r.listEvents(ctx, func(e models.Event) bool {
+		return e.UserID == userID && e.DeletedAt == nil && !e.Timestamp.Before(startDate) && !e.Timestamp.After(endDate)
+	}), nil
+}
+
+func (r *eventRepository) GetForExport(ctx context.Context, userID string, startDate, endDate *time.Time, eventTypeIDs []string) ([]models.Event, error) {
+	typeFilter := make(map[string]bool, len(eventTypeIDs))
+	for _, id := range eventTypeIDs {
+		typeFilter[id] = true
+	}
+
+	return r.listEvents(ctx, func(e models.Event) bool {
+		if e.UserID != userID || e.DeletedAt != nil {
+			return false
+		}
+		if startDate != nil && endDate != nil && (e.Timestamp.Before(*startDate) || e.Timestamp.After(*endDate)) {
+			return false
+		}
+		return len(typeFilter) == 0 || typeFilter[e.EventTypeID]
+	}), nil
+}
+
+func (r *eventRepository) GetForExportPage(ctx context.Context, userID string, startDate, endDate *time.Time, eventTypeIDs []string, after *models.EventCursor, limit int) ([]models.Event, error) {
+	typeFilter := make(map[string]bool, len(eventTypeIDs))
+	for _, id := range eventTypeIDs {
+		typeFilter[id] = true
+	}
+
+	var events []models.Event
+	r.store.read(ctx, func(t *tables) {
+		matches := sortedValues(t.events, func(e models.Event) bool {
+			if e.UserID != userID || e.DeletedAt != nil {
+				return false
+			}
+			if startDate != nil && endDate != nil && (e.Timestamp.Before(*startDate) || e.Timestamp.After(*endDate)) {
+				return false
+			}
+			if len(typeFilter) > 0 && !typeFilter[e.EventTypeID] {
+				return false
+			}
+			return after == nil || e.Timestamp.Before(after.Timestamp) ||
+				(e.Timestamp.Equal(after.Timestamp) && e.ID < after.ID)
+		}, func(a, b models.Event) bool {
+			if a.Timestamp.Equal(b.Timestamp) {
+				return a.ID > b.ID
+			}
+			return a.Timestamp.After(b.Timestamp)
+		})
+
+		matches = paginate(matches, limit, 0)
+		events = make([]models.Event, len(matches))
+		for i, e := range matches {
+			events[i] = withEventType(t, e)
+		}
+	})
+
+	return events, nil
+}
+
+func (r *eventRepository) GetPage(ctx context.Context, userID string, filter *models.EventFilter, after *models.EventCursor, limit int) ([]models.Event, error) {
+	var events []models.Event
+	r.store.read(ctx, func(t *tables) {
+		matches := sortedValues(t.events, func(e models.Event) bool {
+			if e.UserID != userID || e.DeletedAt != nil || !matchesEventFilter(e, filter) {
+				return false
+			}
+			return after == nil || e.Timestamp.Before(after.Timestamp) ||
+				(e.Timestamp.Equal(after.Timestamp) && e.ID < after.ID)
+		}, func(a, b models.Event) bool {
+			if a.Timestamp.Equal(b.Timestamp) {
+				return a.ID > b.ID
+			}
+			return a.Timestamp.After(b.Timestamp)
+		})
+
+		matches = paginate(matches, limit, 0)
+		events = make([]models.Event, len(matches))
+		for i, e := range matches {
+			events[i] = withEventType(t, e)
+		}
+	})
+
+	return events, nil
+}
+
+func matchesEventFilter(e models.Event, filter *models.EventFilter) bool {
+	if len(filter.EventTypeIDs) > 0 && !slices.Contains(filter.EventTypeIDs, e.EventTypeID) {
+		return false
+	}
+	if filter.StartDate != nil && e.Timestamp.Before(*filter.StartDate) {
+		return false
+	}
+	if filter.EndDate != nil && e.Timestamp.After(*filter.EndDate) {
+		return false
+	}
+	if filter.SourceType != "" && e.SourceType != filter.SourceType {
+		return false
+	}
+	if filter.GeofenceID != "" && (e.GeofenceID == nil || *e.GeofenceID != filter.GeofenceID) {
+		return false
+	}
+	if filter.HasNotes != nil && *filter.HasNotes != (e.Notes != nil && *e.Notes != "") {
+		return false
+	}
+	for _, p := range filter.Properties {
+		prop, ok := e.Properties[p.Key]
+		if !ok || prop.Value == nil || !matchesPropertyPredicate(prop.Value, p) {
+			return false
+		}
+	}
+	if filter.Search != nil {
+		// Like the search_vector column, the fields form one document
+		var tokens []models.SearchToken
+		for _, field := range e.SearchFields() {
+			tokens = append(tokens, models.TokenizeSearchText(field.Text)...)
+		}
+		if !filter.Search.Matches(tokens) {
+			return false
+		}
+	}
+	return true
+}
+
+// matchesPropertyPredicate compares like the Postgres backend: numbers
+// numerically, anything else as its text form
+func matchesPropertyPredicate(value interface{}, p models.PropertyPredicate) bool {
+	var order int
+	if want, ok := p.Number(); ok {
+		got, ok := numberValue(value)
+		if !ok {
+			return false
+		}
+		order = cmp.Compare(got, want)
+	} else {
+		order = strings.Compare(textValue(value), p.Value)
+	}
+
+	switch p.Op {
+	case models.PropertyOpEq:
+		return order == 0
+	case models.PropertyOpNe:
+		return order != 0
+	case models.PropertyOpGt:
+		return order > 0
+	case models.PropertyOpGte:
+		return order >= 0
+	case models.PropertyOpLt:
+		return order < 0
+	case models.PropertyOpLte:
+		return order <= 0
+	default:
+		return false
+	}
+}
+
+func numberValue(v interface{}) (float64, bool) {
+	switch n := v.(type) {
+	case float64:
+		return n, true
+	case float32:
+		return float64(n), true
+	case int:
+		return float64(n), true
+	case int64:
+		return float64(n), true
+	case json.Number:
+		f, err := n.Float64()
+		return f, err == nil
+	default:
+		return 0, false
+	}
+}
+
+// textValue renders a value the way Postgres' ->> operator does
+func textValue(v interface{}) string {
+	if s, ok := v.(string); ok {
+		return s
+	}
+	data, _ := json.Marshal(v)
+	return string(data)
+}
+
+func (r *eventRepository) Update(ctx context.Context, id string, event *models.Event) (*models.Event, error) {
+	data := make(map[string]interface{})
+
+	if event.EventTypeID != "" {
+		data["event_type_id"] = event.EventTypeID
+	}
+	if !event.Timestamp.IsZero() {
+		data["timestamp"] = event.Timestamp
+	}
+	if event.Notes != nil {
+		data["notes"] = *event.Notes
+	}
+	// Always include is_all_day since it's a boolean
+	data["is_all_day"] = event.IsAllDay
+
+	if event.EndDate != nil {
+		data["end_date"] = *event.EndDate
+	}
+	if event.SourceType != "" {
+		data["source_type"] = event.SourceType
+	}
+	if event.ExternalID != nil {
+		data["external_id"] = *event.ExternalID
+	}
+	if event.OriginalTitle != nil {
+		data["original_title"] = *event.OriginalTitle
+	}
+	if event.HealthKitSampleID != nil {
+		data["healthkit_sample_id"] = *event.HealthKitSampleID
+	}
+	if event.HealthKitCategory != nil {
+		data["healthkit_category"] = *event.HealthKitCategory
+	}
+	// nil means "don't update", empty map means "clear all properties"
+	if event.Properties != nil {
+		data["properties"] = event.Properties
+	}
+
+	return r.UpdateFields(ctx, id, data)
+}
+
+// UpdateFields updates specific fields of an event.
+// The fields map contains field names (snake_case) mapped to their new values.
+// A nil value explicitly sets the field to NULL in the database.
+// Only fields present in the map are updated.
+func (r *eventRepository) UpdateFields(ctx context.Context, id string, fields map[string]interface{}) (*models.Event, error) {
+	if len(fields) == 0 {
+		return r.GetByID(ctx, id)
+	}
+
+	var updated models.Event
+	var found bool
+	err := r.store.write(ctx, func(t *tables) error {
+		var existing models.Event
+		if existing, found = t.events[id]; !found {
+			return nil
+		}
+
+		if properties, ok := fields["properties"]; ok && properties == nil {
+			fields = cloneMap(fields)
+			fields["properties"] = map[string]interface{}{}
+		}
+
+		e, err := applyFields(existing, fields)
+		if err != nil {
+			return err
+		}
+		e.ID = existing.ID
+		e.CreatedAt = existing.CreatedAt
+		e.UpdatedAt = now()
+		if e.Properties == nil {
+			e.Properties = map[string]models.PropertyValue{}
+		}
+
+		if err := checkEventUnique(t, e, id); err != nil {
+			return err
+		}
+
+		t.events[id] = e
+		updated = copyEvent(e)
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to update event: %w", err)
+	}
+
+	if !found {
+		return nil, fmt.Errorf("event not found")
+	}
+
+	return &updated, nil
+}
+
+func (r *eventRepository) Delete(ctx context.Context, id string) error {
+	return r.store.write(ctx, func(t *tables) error {
+		delete(t.events, id)
+		return nil
+	})
+}
+
+func (r *eventRepository) CountByEventType(ctx context.Context, userID string) (map[string]int64, error) {
+	counts := make(map[string]int64)
+	r.store.read(ctx, func(t *tables) {
+		for _, e := range t.events {
+			if e.UserID == userID && e.DeletedAt == nil {
+				counts[e.EventTypeID]++
+			}
+		}
+	})
+	return counts, nil
+}
+
+// GetByIDs retrieves events by their IDs.
+func (r *eventRepository) GetByIDs(ctx context.Context, ids []string) ([]models.Event, error) {
+	if len(ids) == 0 {
+		return []models.Event{}, nil
+	}
+
+	wanted := make(map[string]bool, len(ids))
+	for _, id := range ids {
+		wanted[id] = true
+	}
+
+	return r.listEvents(ctx, func(e models.Event) bool { return wanted[e.ID] }), nil
+}
+
+// Upsert creates or updates an event by ID.
+// Returns (event, wasCreated, error) where wasCreated indicates if this was a new insert.
+func (r *eventRepository) Upsert(ctx context.Context, event *models.Event) (*models.Event, bool, error) {
+	if event.ID == "" {
+		return nil, false, fmt.Errorf("event ID is required for upsert")
+	}
+
+	var upserted models.Event
+	var wasCreated bool
+	err := r.store.write(ctx, func(t *tables) error {
+		existing, exists := t.events[event.ID]
+		if !exists {
+			e, err := insertEvent(t, *event)
+			if err != nil {
+				return fmt.Errorf("failed to insert event: %w", err)
+			}
+			upserted, wasCreated = e, true
+			return nil
+		}
+
+		if existing.UserID != event.UserID {
+			return fmt.Errorf("event ID belongs to another user")
+		}
+
+		e := copyEvent(*event)
+		e.EventType = nil
+		e.DeletedAt = existing.DeletedAt
+		e.CreatedAt = existing.CreatedAt
+		e.UpdatedAt = now()
+		if e.SourceType == "" {
+			e.SourceType = existing.SourceType
+		}
+		if e.Properties == nil {
+			e.Properties = map[string]models.PropertyValue{}
+		}
+		if err := checkEventUnique(t, e, e.ID); err != nil {
+			return fmt.Errorf("failed to update event: %w", err)
+		}
+
+		t.events[e.ID] = e
+		upserted = copyEvent(e)
+		return nil
+	})
+	if err != nil {
+		return nil, false, err
+	}
+
+	return &upserted, wasCreated, nil
+}
+
+// UpsertBatch creates or updates multiple events by ID.
+// Returns (events, results) where results contains per-item status.
+// Events whose ID already exists are returned unchanged as "deduplicated".
+func (r *eventRepository) UpsertBatch(ctx context.Context, events []models.Event) ([]models.Event, []repository.UpsertResult, error) {
+	if len(events) == 0 {
+		return []models.Event{}, []repository.UpsertResult{}, nil
+	}
+
+	results := make([]repository.UpsertResult, len(events))
+	var allResults []models.Event
+
+	err := r.store.write(ctx, func(t *tables) error {
+		for i, event := range events {
+			if event.ID == "" {
+				results[i] = repository.UpsertResult{
+					Index:  i,
+					Action: "failed",
+					Error:  fmt.Errorf("event ID is required for upsert"),
+				}
+				continue
+			}
+
+			if existing, exists := t.events[event.ID]; exists {
+				// DEDUPLICATED: Return the existing event as-is
+				allResults = append(allResults, copyEvent(existing))
+				results[i] = repository.UpsertResult{Index: i, ID: event.ID, Action: "deduplicated"}
+				continue
+			}
+
+			created, err := insertEvent(t, event)
+			if err != nil {
+				results[i] = repository.UpsertResult{Index: i, ID: event.ID, Action: "failed", Error: err}
+				continue
+			}
+			allResults = append(allResults, created)
+			results[i] = repository.UpsertResult{Index: i, ID: event.ID, Action: "created"}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return allResults, results, nil
+}
+
+// GetByHealthKitSampleIDs retrieves events by their HealthKit sample IDs for a user.
+func (r *eventRepository) GetByHealthKitSampleIDs(ctx context.Context, userID string, sampleIDs []string) ([]models.Event, error) {
+	if len(sampleIDs) == 0 {
+		return []models.Event{}, nil
+	}
+
+	var events []models.Event
+	r.store.read(ctx, func(t *tables) {
+		events = findBySampleIDs(t, userID, sampleIDs)
+	})
+	return events, nil
+}
+
+func findBySampleIDs(t *tables, userID string, sampleIDs []string) []models.Event {
+	wanted := make(map[string]bool, len(sampleIDs))
+	for _, id := range sampleIDs {
+		wanted[id] = true
+	}
+
+	events := make([]models.Event, 0)
+	for _, e := range t.events {
+		if e.UserID == userID && e.HealthKitSampleID != nil && wanted[*e.HealthKitSampleID] {
+			events = append(events, copyEvent(e))
+		}
+	}
+	return events
+}
+
+// UpsertHealthKitEvent inserts a HealthKit event unless an event with the same
+// sample ID or the same content already exists, in which case the existing
+// event is returned unchanged (HealthKit data is immutable).
+func (r *eventRepository) UpsertHealthKitEvent(ctx context.Context, event *models.Event) (*models.Event, bool, error) {
+	if event.HealthKitSampleID == nil || *event.HealthKitSampleID == "" {
+		return nil, false, fmt.Errorf("healthkit_sample_id is required for upsert")
+	}
+
+	upserted, createdIDs, err := r.UpsertHealthKitEventsBatch(ctx, []models.Event{*event})
+	if err != nil {
+		return nil, false, err
+	}
+
+	if len(upserted) == 0 {
+		return nil, false, fmt.Errorf("no event returned from upsert")
+	}
+
+	return &upserted[0], len(createdIDs) > 0, nil
+}
+
+// UpsertHealthKitEventsBatch inserts or updates multiple HealthKit events.
+// Returns (events, createdIDs, error) where createdIDs contains IDs of newly created events.
+//
+// Duplicates are detected by sample ID first and then by content
+// (event_type_id, timestamp, healthkit_category), matching the other backends.
+// The whole batch is checked and inserted atomically.
+func (r *eventRepository) UpsertHealthKitEventsBatch(ctx context.Context, events []models.Event) ([]models.Event, []string, error) {
+	if len(events) == 0 {
+		return []models.Event{}, []string{}, nil
+	}
+
+	userID := events[0].UserID
+	var createdIDs []string
+	var allResults []models.Event
+
+	err := r.store.write(ctx, func(t *tables) error {
+		for _, event := range events {
+			if event.HealthKitSampleID == nil || *event.HealthKitSampleID == "" {
+				continue // Skip events without sample ID
+			}
+
+			if existing := findBySampleIDs(t, userID, []string{*event.HealthKitSampleID}); len(existing) > 0 {
+				allResults = append(allResults, existing[0])
+				continue
+			}
+
+			contentKey := repository.HealthKitContentKey(userID, event.EventTypeID, event.Timestamp, event.HealthKitCategory)
+			if existing, ok := findByHealthKitContent(t, userID, map[string]bool{contentKey: true})[contentKey]; ok {
+				allResults = append(allResults, existing)
+				continue
+			}
+
+			created, err := insertEvent(t, event)
+			if err != nil {
+				return fmt.Errorf("failed to batch insert HealthKit events: %w", err)
+			}
+			allResults = append(allResults, created)
+			createdIDs = append(createdIDs, created.ID)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return allResults, createdIDs, nil
+}
+
+// GetByHealthKitContent retrieves HealthKit events by content to detect
+// content-based duplicates. Returns a map of content key -> existing event.
+func (r *eventRepository) GetByHealthKitContent(ctx context.Context, userID string, events []models.Event) (map[string]models.Event, error) {
+	wanted := make(map[string]bool, len(events))
+	for _, event := range events {
+		wanted[repository.HealthKitContentKey(userID, event.EventTypeID, event.Timestamp, event.HealthKitCategory)] = true
+	}
+
+	result := make(map[string]models.Event)
+	if len(wanted) == 0 {
+		return result, nil
+	}
+
+	r.store.read(ctx, func(t *tables) {
+		result = findByHealthKitContent(t, userID, wanted)
+	})
+	return result, nil
+}
+
+func findByHealthKitContent(t *tables, userID string, wanted map[string]bool) map[string]models.Event {
+	result := make(map[string]models.Event)
+	for _, e := range t.events {
+		if e.UserID != userID || e.SourceType != "healthkit" {
+			continue
+		}
+		key := repository.HealthKitContentKey(userID, e.EventTypeID, e.Timestamp, e.HealthKitCategory)
+		if wanted[key] {
+			result[key] = copyEvent(e)
+		}
+	}
+	return result
+}
+
+// CountByUser returns total events for a user
+func (r *eventRepository) CountByUser(ctx context.Context, userID string) (int64, error) {
+	var count int64
+	r.store.read(ctx, func(t *tables) {
+		for _, e := range t.events {
+			if e.UserID == userID && e.DeletedAt == nil {
+				count++
+			}
+		}
+	})
+	return count, nil
+}
+
+// CountHealthKitByUser returns HealthKit events for a user
+func (r *eventRepository) CountHealthKitByUser(ctx context.Context, userID string) (int64, error) {
+	var count int64
+	r.store.read(ctx, func(t *tables) {
+		for _, e := range t.events {
+			if e.UserID == userID && e.DeletedAt == nil && e.HealthKitSampleID != nil {
+				count++
+			}
+		}
+	})
+	return count, nil
+}
+
+// GetLatestTimestamp returns the most recent event updated_at for a user
+func (r *eventRepository) GetLatestTimestamp(ctx context.Context, userID string) (*time.Time, error) {
+	return r.latestUpdatedAt(ctx, func(e models.Event) bool { return e.UserID == userID }), nil
+}
+
+// GetLatestHealthKitTimestamp returns the most recent HealthKit event timestamp for a user
+func (r *eventRepository) GetLatestHealthKitTimestamp(ctx context.Context, userID string) (*time.Time, error) {
+	return r.latestUpdatedAt(ctx, func(e models.Event) bool {
+		return e.UserID == userID && e.HealthKitSampleID != nil
+	}), nil
+}
+
+func (r *eventRepository) latestUpdatedAt(ctx context.Context, keep func(e models.Event) bool) *time.Time {
+	var latest *time.Time
+	r.store.read(ctx, func(t *tables) {
+		for _, e := range t.events {
+			if e.DeletedAt == nil && keep(e) && (latest == nil || e.UpdatedAt.After(*latest)) {
+				updatedAt := e.UpdatedAt
+				latest = &updatedAt
+			}
+		}
+	})
+	return latest
+}
+
+// SoftDelete moves an event to the trash
+func (r *eventRepository) SoftDelete(ctx context.Context, id string, deletedAt time.Time) error {
+	return r.store.write(ctx, func(t *tables) error {
+		e, exists := t.events[id]
+		if !exists || e.DeletedAt != nil {
+			return nil
+		}
+		e.DeletedAt = &deletedAt
+		e.UpdatedAt = now()
+		t.events[id] = e
+		return nil
+	})
+}
+
+// SoftDeleteByEventType moves the live events of an event type to the trash
+// and returns their IDs
+func (r *eventRepository) SoftDeleteByEventType(ctx context.Context, eventTypeID string, deletedAt time.Time) ([]string, error) {
+	ids := make([]string, 0)
+	err := r.store.write(ctx, func(t *tables) error {
+		updatedAt := now()
+		for id, e := range t.events {
+			if e.EventTypeID != eventTypeID || e.DeletedAt != nil {
+				continue
+			}
+			e.DeletedAt = &deletedAt
+			e.UpdatedAt = updatedAt
+			t.events[id] = e
+			ids = append(ids, id)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to delete events: %w", err)
+	}
+
+	return ids, nil
+}
+
+// GetDeletedByID retrieves a trashed event
+func (r *eventRepository) GetDeletedByID(ctx context.Context, id string) (*models.Event, error) {
+	var event models.Event
+	var found bool
+	r.store.read(ctx, func(t *tables) {
+		var e models.Event
+		if e, found = t.events[id]; found && e.DeletedAt != nil {
+			event = withEventType(t, e)
+		}
+	})
+
+	if !found || event.ID == "" {
+		return nil, fmt.Errorf("event not found")
+	}
+
+	return &event, nil
+}
+
+// GetDeletedByUserID returns a user's trashed events, most recently deleted first
+func (r *eventRepository) GetDeletedByUserID(ctx context.Context, userID string) ([]models.Event, error) {
+	var events []models.Event
+	r.store.read(ctx, func(t *tables) {
+		matches := sortedValues(t.events,
+			func(e models.Event) bool { return e.UserID == userID && e.DeletedAt != nil },
+			func(a, b models.Event) bool { return a.DeletedAt.After(*b.DeletedAt) })
+		events = make([]models.Event, len(matches))
+		for i, e := range matches {
+			events[i] = withEventType(t, e)
+		}
+	})
+	return events, nil
+}
+
+// Restore takes a trashed event out of the trash
+func (r *eventRepository) Restore(ctx context.Context, id string) (*models.Event, error) {
+	var restored models.Event
+	var found bool
+	err := r.store.write(ctx, func(t *tables) error {
+		e, exists := t.events[id]
+		if !exists || e.DeletedAt == nil {
+			return nil
+		}
+		found = true
+
+		e.DeletedAt = nil
+		e.UpdatedAt = now()
+		if err := checkEventUnique(t, e, id); err != nil {
+			return err
+		}
+
+		t.events[id] = e
+		restored = withEventType(t, e)
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to restore event: %w", err)
+	}
+
+	if !found {
+		return nil, fmt.Errorf("event not found")
+	}
+
+	return &restored, nil
+}
+
+// RestoreByEventType restores the events of an event type that were trashed
+// at deletedAt
+func (r *eventRepository) RestoreByEventType(ctx context.Context, eventTypeID string, deletedAt time.Time) ([]models.Event, error) {
+	var restored []models.Event
+	err := r.store.write(ctx, func(t *tables) error {
+		// A multi-row update is atomic, so stage against a copy of the table
+		staged := *t
+		staged.events = cloneMap(t.events)
+		updatedAt := now()
+		var ids []string
+		for id, e := range t.events {
+			if e.EventTypeID != eventTypeID || e.DeletedAt == nil || !e.DeletedAt.Equal(deletedAt) {
+				continue
+			}
+			e.DeletedAt = nil
+			e.UpdatedAt = updatedAt
+			staged.events[id] = e
+			ids = append(ids, id)
+		}
+		for _, id := range ids {
+			e := staged.events[id]
+			if err := checkEventUnique(&staged, e, id); err != nil {
+				return err
+			}
+			restored = append(restored, withEventType(&staged, e))
+		}
+		t.events = staged.events
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to restore events: %w", err)
+	}
+
+	sort.Slice(restored, func(i, j int) bool { return restored[i].Timestamp.After(restored[j].Timestamp) })
+	return restored, nil
+}
+
+// PurgeDeleted permanently removes events trashed before the given time
+func (r *eventRepository) PurgeDeleted(ctx context.Context, before time.Time) (int64, error) {
+	var purged int64
+	err := r.store.write(ctx, func(t *tables) error {
+		for id, e := range t.events {
+			if e.DeletedAt != nil && e.DeletedAt.Before(before) {
+				delete(t.events, id)
+				purged++
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return 0, fmt.Errorf("failed to purge events: %w", err)
+	}
+
+	return purged, nil
+}
+
+// ReassignEventType moves the live events of one event type to another
+func (r *eventRepository) ReassignEventType(ctx context.Context, fromEventTypeID, toEventTypeID string) ([]models.Event, error) {
+	var moved []models.Event
+	err := r.store.write(ctx, func(t *tables) error {
+		// A multi-row update is atomic, so stage against a copy of the table
+		staged := *t
+		staged.events = cloneMap(t.events)
+		updatedAt := now()
+		var ids []string
+		for id, e := range t.events {
+			if e.EventTypeID != fromEventTypeID || e.DeletedAt != nil {
+				continue
+			}
+			e.EventTypeID = toEventTypeID
+			e.UpdatedAt = updatedAt
+			staged.events[id] = e
+			ids = append(ids, id)
+		}
+		for _, id := range ids {
+			e := staged.events[id]
+			if err := checkEventUnique(&staged, e, id); err != nil {
+				return err
+			}
+			moved = append(moved, withEventType(&staged, e))
+		}
+		t.events = staged.events
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to reassign events: %w", err)
+	}
+
+	sort.Slice(moved, func(i, j int) bool { return moved[i].Timestamp.After(moved[j].Timestamp) })
+	return moved, nil
+}
+
+// paginate applies LIMIT/OFFSET semantics to items
+func paginate[T any](items []T, limit, offset int) []T {
+	if offset < 0 {
+		offset = 0
+	}
+	if offset >= len(items) {
+		return []T{}
+	}
+	items = items[offset:]
+	if limit >= 0 && limit < len(items) {
+		items = items[:limit]
+	}
+	return items
+}
+
+func (r *eventRepository) DeleteByUserID(ctx context.Context, userID string) error {
+	return r.store.write(ctx, func(t *tables) error {
+		for id, e := range t.events {
+			if e.UserID == userID {
+				delete(t.events, id)
+			}
+		}
+		return nil
+	})
+}
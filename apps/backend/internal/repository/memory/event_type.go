@@ -0,0 +1,343 @@
+package memory
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/JonnyWalker81/trendy/backend/internal/models"
+	"github.com/JonnyWalker81/trendy/backend/internal/repository"
+)
+
+type eventTypeRepository struct {
+	store *Store
+}
+
+// NewEventTypeRepository creates a new in-memory event type repository
+func NewEventTypeRepository(store *Store) repository.EventTypeRepository {
+	return &eventTypeRepository{store: store}
+}
+
+// checkEventTypeUnique enforces the unique (user_id, name) index, which only
+// covers event types that are not in the trash
+func checkEventTypeUnique(t *tables, et models.EventType) error {
+	for _, other := range t.eventTypes {
+		if other.ID != et.ID && other.DeletedAt == nil && other.UserID == et.UserID && other.Name == et.Name {
+			return uniqueViolation("event_types_user_id_name_key")
+		}
+	}
+	return nil
+}
+
+func (r *eventTypeRepository) Create(ctx context.Context, eventType *models.EventType) (*models.EventType, error) {
+	et := *eventType
+	err := r.store.write(ctx, func(t *tables) error {
+		if et.ID == "" {
+			et.ID = newID()
+		}
+		if _, exists := t.eventTypes[et.ID]; exists {
+			return uniqueViolation("event_types_pkey")
+		}
+		if err := checkEventTypeUnique(t, et); err != nil {
+			return err
+		}
+		et.DeletedAt = nil
+		et.CreatedAt = now()
+		et.UpdatedAt = et.CreatedAt
+		t.eventTypes[et.ID] = et
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to create event type: %w", err)
+	}
+
+	return &et, nil
+}
+
+func (r *eventTypeRepository) GetByID(ctx context.Context, id string) (*models.EventType, error) {
+	var et models.EventType
+	var found bool
+	r.store.read(ctx, func(t *tables) {
+		et, found = t.eventTypes[id]
+	})
+
+	if !found || et.DeletedAt != nil {
+		return nil, fmt.Errorf("event type not found")
+	}
+
+	return &et, nil
+}
+
+// ClaimVersion needs no lock: transactions are serialized with all other
+// writes
+func (r *eventTypeRepository) ClaimVersion(ctx context.Context, id string, updatedAt time.Time) (bool, error) {
+	var claimed bool
+	r.store.read(ctx, func(t *tables) {
+		current, found := t.eventTypes[id]
+		claimed = found && current.DeletedAt == nil && current.UpdatedAt.Equal(updatedAt.UTC().Truncate(time.Microsecond))
+	})
+	return claimed, nil
+}
+
+func (r *eventTypeRepository) GetByUserID(ctx context.Context, userID string) ([]models.EventType, error) {
+	var eventTypes []models.EventType
+	r.store.read(ctx, func(t *tables) {
+		eventTypes = sortedValues(t.eventTypes,
+			func(et models.EventType) bool { return et.UserID == userID && et.DeletedAt == nil },
+			func(a, b models.EventType) bool { return a.CreatedAt.Before(b.CreatedAt) })
+	})
+	return eventTypes, nil
+}
+
+func (r *eventTypeRepository) Update(ctx context.Context, id string, eventType *models.EventType) (*models.EventType, error) {
+	var et models.EventType
+	var found bool
+	err := r.store.write(ctx, func(t *tables) error {
+		if et, found = t.eventTypes[id]; !found {
+			return nil
+		}
+
+		if eventType.Name != "" {
+			et.Name = eventType.Name
+		}
+		if eventType.Color != "" {
+			et.Color = eventType.Color
+		}
+		if eventType.Icon != "" {
+			et.Icon = eventType.Icon
+		}
+		if err := checkEventTypeUnique(t, et); err != nil {
+			return err
+		}
+
+		et.UpdatedAt = now()
+		t.eventTypes[id] = et
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to update event type: %w", err)
+	}
+
+	if !found {
+		return nil, fmt.Errorf("event type not found")
+	}
+
+	return &et, nil
+}
+
+// Delete removes the event type and, like the foreign keys in the schema,
+// cascades to its events, property definitions and analytics rows and clears
+// geofence references to it
+func (r *eventTypeRepository) Delete(ctx context.Context, id string) error {
+	return r.store.write(ctx, func(t *tables) error {
+		deleteEventType(t, id)
+		return nil
+	})
+}
+
+func deleteEventType(t *tables, id string) {
+	if _, exists := t.eventTypes[id]; !exists {
+		return
+	}
+	delete(t.eventTypes, id)
+
+	for key, e := range t.events {
+		if e.EventTypeID == id {
+			delete(t.events, key)
+		}
+	}
+	for key, def := range t.propertyDefinitions {
+		if def.EventTypeID == id {
+			delete(t.propertyDefinitions, key)
+		}
+	}
+	for key, agg := range t.dailyAggregates {
+		if agg.EventTypeID == id {
+			delete(t.dailyAggregates, key)
+		}
+	}
+	for key, streak := range t.streaks {
+		if streak.EventTypeID == id {
+			delete(t.streaks, key)
+		}
+	}
+	for key, insight := range t.insights {
+		if (insight.EventTypeAID != nil && *insight.EventTypeAID == id) ||
+			(insight.EventTypeBID != nil && *insight.EventTypeBID == id) {
+			delete(t.insights, key)
+		}
+	}
+	for key, g := range t.geofences {
+		changed := false
+		if g.EventTypeEntryID != nil && *g.EventTypeEntryID == id {
+			g.EventTypeEntryID = nil
+			changed = true
+		}
+		if g.EventTypeExitID != nil && *g.EventTypeExitID == id {
+			g.EventTypeExitID = nil
+			changed = true
+		}
+		if changed {
+			t.geofences[key] = g
+		}
+	}
+}
+
+// CountByUser returns total event types for a user
+func (r *eventTypeRepository) CountByUser(ctx context.Context, userID string) (int64, error) {
+	var count int64
+	r.store.read(ctx, func(t *tables) {
+		for _, et := range t.eventTypes {
+			if et.UserID == userID && et.DeletedAt == nil {
+				count++
+			}
+		}
+	})
+	return count, nil
+}
+
+// GetLatestTimestamp returns the most recent event_type updated_at for a user
+func (r *eventTypeRepository) GetLatestTimestamp(ctx context.Context, userID string) (*time.Time, error) {
+	var latest *time.Time
+	r.store.read(ctx, func(t *tables) {
+		for _, et := range t.eventTypes {
+			if et.UserID == userID && et.DeletedAt == nil && (latest == nil || et.UpdatedAt.After(*latest)) {
+				updatedAt := et.UpdatedAt
+				latest = &updatedAt
+			}
+		}
+	})
+	return latest, nil
+}
+
+// SoftDelete moves an event type to the trash
+func (r *eventTypeRepository) SoftDelete(ctx context.Context, id string, deletedAt time.Time) error {
+	return r.store.write(ctx, func(t *tables) error {
+		et, exists := t.eventTypes[id]
+		if !exists || et.DeletedAt != nil {
+			return nil
+		}
+		et.DeletedAt = &deletedAt
+		et.UpdatedAt = now()
+		t.eventTypes[id] = et
+		return nil
+	})
+}
+
+// GetDeletedByID retrieves a trashed event type
+func (r *eventTypeRepository) GetDeletedByID(ctx context.Context, id string) (*models.EventType, error) {
+	var et models.EventType
+	var found bool
+	r.store.read(ctx, func(t *tables) {
+		et, found = t.eventTypes[id]
+	})
+
+	if !found || et.DeletedAt == nil {
+		return nil, fmt.Errorf("event type not found")
+	}
+
+	return &et, nil
+}
+
+// GetDeletedByUserID returns a user's trashed event types, most recently deleted first
+func (r *eventTypeRepository) GetDeletedByUserID(ctx context.Context, userID string) ([]models.EventType, error) {
+	var eventTypes []models.EventType
+	r.store.read(ctx, func(t *tables) {
+		eventTypes = sortedValues(t.eventTypes,
+			func(et models.EventType) bool { return et.UserID == userID && et.DeletedAt != nil },
+			func(a, b models.EventType) bool { return a.DeletedAt.After(*b.DeletedAt) })
+	})
+	return eventTypes, nil
+}
+
+// Restore takes a trashed event type out of the trash
+func (r *eventTypeRepository) Restore(ctx context.Context, id string) (*models.EventType, error) {
+	var et models.EventType
+	var found bool
+	err := r.store.write(ctx, func(t *tables) error {
+		if et, found = t.eventTypes[id]; !found || et.DeletedAt == nil {
+			found = false
+			return nil
+		}
+
+		et.DeletedAt = nil
+		if err := checkEventTypeUnique(t, et); err != nil {
+			return err
+		}
+
+		et.UpdatedAt = now()
+		t.eventTypes[id] = et
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to restore event type: %w", err)
+	}
+
+	if !found {
+		return nil, fmt.Errorf("event type not found")
+	}
+
+	return &et, nil
+}
+
+// PurgeDeleted permanently removes event types trashed before the given time,
+// cascading like Delete
+func (r *eventTypeRepository) PurgeDeleted(ctx context.Context, before time.Time) (int64, error) {
+	var purged int64
+	err := r.store.write(ctx, func(t *tables) error {
+		for id, et := range t.eventTypes {
+			if et.DeletedAt != nil && et.DeletedAt.Before(before) {
+				deleteEventType(t, id)
+				purged++
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return 0, fmt.Errorf("failed to purge event types: %w", err)
+	}
+
+	return purged, nil
+}
+
+// SetArchived archives or unarchives an event type
+func (r *eventTypeRepository) SetArchived(ctx context.Context, id string, archivedAt *time.Time) (*models.EventType, error) {
+	var et models.EventType
+	var found bool
+	err := r.store.write(ctx, func(t *tables) error {
+		if et, found = t.eventTypes[id]; !found || et.DeletedAt != nil {
+			found = false
+			return nil
+		}
+
+		et.ArchivedAt = nil
+		if archivedAt != nil {
+			// Stored like a timestamptz column
+			at := archivedAt.UTC().Truncate(time.Microsecond)
+			et.ArchivedAt = &at
+		}
+		et.UpdatedAt = now()
+		t.eventTypes[id] = et
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to archive event type: %w", err)
+	}
+
+	if !found {
+		return nil, fmt.Errorf("event type not found")
+	}
+
+	return &et, nil
+}
+
+func (r *eventTypeRepository) DeleteByUserID(ctx context.Context, userID string) error {
+	return r.store.write(ctx, func(t *tables) error {
+		for id, et := range t.eventTypes {
+			if et.UserID == userID {
+				deleteEventType(t, id)
+			}
+		}
+		return nil
+	})
+}
@@ -0,0 +1,198 @@
+package memory
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/JonnyWalker81/trendy/backend/internal/models"
+	"github.com/JonnyWalker81/trendy/backend/internal/repository"
+)
+
+type geofenceRepository struct {
+	store *Store
+}
+
+// NewGeofenceRepository creates a new in-memory geofence repository
+func NewGeofenceRepository(store *Store) repository.GeofenceRepository {
+	return &geofenceRepository{store: store}
+}
+
+// checkGeofenceUnique enforces UNIQUE(user_id, name)
+func checkGeofenceUnique(t *tables, g models.Geofence) error {
+	for _, other := range t.geofences {
+		if other.ID != g.ID && other.UserID == g.UserID && other.Name == g.Name {
+			return uniqueViolation("geofences_user_id_name_key")
+		}
+	}
+	return nil
+}
+
+func boolPtr(b bool) *bool {
+	return &b
+}
+
+func (r *geofenceRepository) Create(ctx context.Context, geofence *models.Geofence) (*models.Geofence, error) {
+	g := *geofence
+	g.EventTypeEntry = nil
+	g.EventTypeExit = nil
+	g.IsActive = boolPtr(geofence.IsActive != nil && *geofence.IsActive)
+	g.NotifyOnEntry = boolPtr(geofence.NotifyOnEntry != nil && *geofence.NotifyOnEntry)
+	g.NotifyOnExit = boolPtr(geofence.NotifyOnExit != nil && *geofence.NotifyOnExit)
+
+	err := r.store.write(ctx, func(t *tables) error {
+		if g.ID == "" {
+			g.ID = newID()
+		}
+		if _, exists := t.geofences[g.ID]; exists {
+			return uniqueViolation("geofences_pkey")
+		}
+		if err := checkGeofenceUnique(t, g); err != nil {
+			return err
+		}
+		g.CreatedAt = now()
+		g.UpdatedAt = g.CreatedAt
+		t.geofences[g.ID] = g
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to create geofence: %w", err)
+	}
+
+	return &g, nil
+}
+
+func (r *geofenceRepository) GetByID(ctx context.Context, id string) (*models.Geofence, error) {
+	var g models.Geofence
+	var found bool
+	r.store.read(ctx, func(t *tables) {
+		g, found = t.geofences[id]
+	})
+
+	if !found {
+		return nil, fmt.Errorf("geofence not found")
+	}
+
+	return &g, nil
+}
+
+// ClaimVersion needs no lock: transactions are serialized with all other
+// writes
+func (r *geofenceRepository) ClaimVersion(ctx context.Context, id string, updatedAt time.Time) (bool, error) {
+	var claimed bool
+	r.store.read(ctx, func(t *tables) {
+		current, found := t.geofences[id]
+		claimed = found && current.UpdatedAt.Equal(updatedAt.UTC().Truncate(time.Microsecond))
+	})
+	return claimed, nil
+}
+
+func (r *geofenceRepository) list(ctx context.Context, keep func(g models.Geofence) bool) []models.Geofence {
+	var geofences []models.Geofence
+	r.store.read(ctx, func(t *tables) {
+		geofences = sortedValues(t.geofences, keep, func(a, b models.Geofence) bool {
+			return a.CreatedAt.After(b.CreatedAt)
+		})
+	})
+	return geofences
+}
+
+func (r *geofenceRepository) GetByUserID(ctx context.Context, userID string) ([]models.Geofence, error) {
+	return r.list(ctx, func(g models.Geofence) bool { return g.UserID == userID }), nil
+}
+
+func (r *geofenceRepository) GetActiveByUserID(ctx context.Context, userID string) ([]models.Geofence, error) {
+	return r.list(ctx, func(g models.Geofence) bool {
+		return g.UserID == userID && g.IsActive != nil && *g.IsActive
+	}), nil
+}
+
+func (r *geofenceRepository) Update(ctx context.Context, id string, geofence *models.Geofence) (*models.Geofence, error) {
+	var g models.Geofence
+	var found bool
+	err := r.store.write(ctx, func(t *tables) error {
+		if g, found = t.geofences[id]; !found {
+			return nil
+		}
+
+		if geofence.Name != "" {
+			g.Name = geofence.Name
+		}
+		if geofence.Latitude != 0 {
+			g.Latitude = geofence.Latitude
+		}
+		if geofence.Longitude != 0 {
+			g.Longitude = geofence.Longitude
+		}
+		if geofence.Radius != 0 {
+			g.Radius = geofence.Radius
+		}
+		if geofence.IsActive != nil {
+			g.IsActive = boolPtr(*geofence.IsActive)
+		}
+		if geofence.NotifyOnEntry != nil {
+			g.NotifyOnEntry = boolPtr(*geofence.NotifyOnEntry)
+		}
+		if geofence.NotifyOnExit != nil {
+			g.NotifyOnExit = boolPtr(*geofence.NotifyOnExit)
+		}
+		if geofence.EventTypeEntryID != nil {
+			g.EventTypeEntryID = geofence.EventTypeEntryID
+		}
+		if geofence.EventTypeExitID != nil {
+			g.EventTypeExitID = geofence.EventTypeExitID
+		}
+		if geofence.IOSRegionIdentifier != nil {
+			g.IOSRegionIdentifier = geofence.IOSRegionIdentifier
+		}
+		if err := checkGeofenceUnique(t, g); err != nil {
+			return err
+		}
+
+		g.UpdatedAt = now()
+		t.geofences[id] = g
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to update geofence: %w", err)
+	}
+
+	if !found {
+		return nil, fmt.Errorf("geofence not found")
+	}
+
+	return &g, nil
+}
+
+// Delete removes the geofence and clears event references to it
+func (r *geofenceRepository) Delete(ctx context.Context, id string) error {
+	return r.store.write(ctx, func(t *tables) error {
+		delete(t.geofences, id)
+		for key, e := range t.events {
+			if e.GeofenceID != nil && *e.GeofenceID == id {
+				e.GeofenceID = nil
+				t.events[key] = e
+			}
+		}
+		return nil
+	})
+}
+
+func (r *geofenceRepository) DeleteByUserID(ctx context.Context, userID string) error {
+	return r.store.write(ctx, func(t *tables) error {
+		for id, g := range t.geofences {
+			if g.UserID == userID {
+				delete(t.geofences, id)
+			}
+		}
+		for key, e := range t.events {
+			if e.GeofenceID != nil {
+				if _, exists := t.geofences[*e.GeofenceID]; !exists {
+					e.GeofenceID = nil
+					t.events[key] = e
+				}
+			}
+		}
+		return nil
+	})
+}
@@ -0,0 +1,67 @@
+package memory
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/JonnyWalker81/trendy/backend/internal/models"
+	"github.com/JonnyWalker81/trendy/backend/internal/repository"
+)
+
+type idempotencyRepository struct {
+	store *Store
+}
+
+// NewIdempotencyRepository creates a new in-memory idempotency repository
+func NewIdempotencyRepository(store *Store) repository.IdempotencyRepository {
+	return &idempotencyRepository{store: store}
+}
+
+func idempotencyKey(key, route, userID string) string {
+	return key + "\x00" + route + "\x00" + userID
+}
+
+func (r *idempotencyRepository) Get(ctx context.Context, key, route, userID string) (*models.IdempotencyKey, error) {
+	var record models.IdempotencyKey
+	var found bool
+	r.store.read(ctx, func(t *tables) {
+		record, found = t.idempotencyKeys[idempotencyKey(key, route, userID)]
+	})
+
+	if !found {
+		return nil, nil // Not found - this is not an error
+	}
+
+	record.ResponseBody = append([]byte(nil), record.ResponseBody...)
+	return &record, nil
+}
+
+func (r *idempotencyRepository) Store(ctx context.Context, key, route, userID string, responseBody []byte, statusCode int) error {
+	return r.store.write(ctx, func(t *tables) error {
+		k := idempotencyKey(key, route, userID)
+		if _, exists := t.idempotencyKeys[k]; exists {
+			return fmt.Errorf("failed to store idempotency key: %w", uniqueViolation("idempotency_keys_unique"))
+		}
+		t.idempotencyKeys[k] = models.IdempotencyKey{
+			ID:           newID(),
+			Key:          key,
+			Route:        route,
+			UserID:       userID,
+			ResponseBody: append([]byte(nil), responseBody...),
+			StatusCode:   statusCode,
+			CreatedAt:    now(),
+		}
+		return nil
+	})
+}
+
+func (r *idempotencyRepository) DeleteByUserID(ctx context.Context, userID string) error {
+	return r.store.write(ctx, func(t *tables) error {
+		for key, k := range t.idempotencyKeys {
+			if k.UserID == userID {
+				delete(t.idempotencyKeys, key)
+			}
+		}
+		return nil
+	})
+}
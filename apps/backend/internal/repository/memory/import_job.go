@@ -0,0 +1,97 @@
+package memory
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/JonnyWalker81/trendy/backend/internal/models"
+	"github.com/JonnyWalker81/trendy/backend/internal/repository"
+)
+
+type importJobRepository struct {
+	store *Store
+}
+
+// NewImportJobRepository creates a new in-memory import job repository
+func NewImportJobRepository(store *Store) repository.ImportJobRepository {
+	return &importJobRepository{store: store}
+}
+
+func (r *importJobRepository) Create(ctx context.Context, job *models.ImportJob) (*models.ImportJob, error) {
+	created := *job
+	created.ID = newID()
+	if created.Status == "" {
+		created.Status = models.ImportJobPending
+	}
+	created.Errors = cloneRowErrors(job.Errors)
+	created.CreatedAt = now()
+	created.UpdatedAt = created.CreatedAt
+
+	r.store.write(ctx, func(t *tables) error {
+		t.importJobs[created.ID] = created
+		return nil
+	})
+
+	result := created
+	result.Errors = cloneRowErrors(created.Errors)
+	return &result, nil
+}
+
+func (r *importJobRepository) GetByID(ctx context.Context, id string) (*models.ImportJob, error) {
+	var job models.ImportJob
+	var found bool
+	r.store.read(ctx, func(t *tables) {
+		job, found = t.importJobs[id]
+	})
+
+	if !found {
+		return nil, fmt.Errorf("import job not found")
+	}
+
+	job.Errors = cloneRowErrors(job.Errors)
+	return &job, nil
+}
+
+func (r *importJobRepository) UpdateProgress(ctx context.Context, job *models.ImportJob) (*models.ImportJob, error) {
+	var updated models.ImportJob
+	var found bool
+	r.store.write(ctx, func(t *tables) error {
+		if updated, found = t.importJobs[job.ID]; !found {
+			return nil
+		}
+		updated.Status = job.Status
+		updated.ProcessedRows = job.ProcessedRows
+		updated.CreatedCount = job.CreatedCount
+		updated.FailedCount = job.FailedCount
+		updated.Errors = cloneRowErrors(job.Errors)
+		updated.Error = job.Error
+		updated.CompletedAt = truncateTime(job.CompletedAt)
+		updated.UpdatedAt = now()
+		t.importJobs[job.ID] = updated
+		return nil
+	})
+
+	if !found {
+		return nil, fmt.Errorf("import job not found")
+	}
+
+	updated.Errors = cloneRowErrors(updated.Errors)
+	return &updated, nil
+}
+
+// cloneRowErrors copies errors so callers cannot mutate a stored job. The
+// result is never nil, matching the JSON array column.
+func cloneRowErrors(errors []models.ImportRowError) []models.ImportRowError {
+	return append([]models.ImportRowError{}, errors...)
+}
+
+func (r *importJobRepository) DeleteByUserID(ctx context.Context, userID string) error {
+	return r.store.write(ctx, func(t *tables) error {
+		for id, job := range t.importJobs {
+			if job.UserID == userID {
+				delete(t.importJobs, id)
+			}
+		}
+		return nil
+	})
+}
@@ -0,0 +1,131 @@
+package memory
+
+import (
+	"context"
+	"time"
+
+	"github.com/JonnyWalker81/trendy/backend/internal/models"
+	"github.com/JonnyWalker81/trendy/backend/internal/repository"
+)
+
+type insightRepository struct {
+	store *Store
+}
+
+// NewInsightRepository creates a new in-memory insight repository
+func NewInsightRepository(store *Store) repository.InsightRepository {
+	return &insightRepository{store: store}
+}
+
+// copyInsight returns insight with its own metadata map
+func copyInsight(insight models.Insight) models.Insight {
+	metadata := make(map[string]interface{}, len(insight.Metadata))
+	for k, v := range insight.Metadata {
+		metadata[k] = v
+	}
+	insight.Metadata = metadata
+	return insight
+}
+
+func insertInsight(t *tables, insight models.Insight) models.Insight {
+	i := copyInsight(insight)
+	i.ID = newID()
+	i.CreatedAt = now()
+	i.EventTypeA = nil
+	i.EventTypeB = nil
+	t.insights[i.ID] = i
+	return copyInsight(i)
+}
+
+func (r *insightRepository) Create(ctx context.Context, insight *models.Insight) (*models.Insight, error) {
+	var created models.Insight
+	r.store.write(ctx, func(t *tables) error {
+		created = insertInsight(t, *insight)
+		return nil
+	})
+	return &created, nil
+}
+
+func (r *insightRepository) BulkCreate(ctx context.Context, insights []models.Insight) error {
+	if len(insights) == 0 {
+		return nil
+	}
+
+	return r.store.write(ctx, func(t *tables) error {
+		for _, insight := range insights {
+			insertInsight(t, insight)
+		}
+		return nil
+	})
+}
+
+func (r *insightRepository) list(ctx context.Context, keep func(i models.Insight) bool, less func(a, b models.Insight) bool) []models.Insight {
+	var insights []models.Insight
+	r.store.read(ctx, func(t *tables) {
+		insights = sortedValues(t.insights, keep, less)
+	})
+	for i := range insights {
+		insights[i] = copyInsight(insights[i])
+	}
+	return insights
+}
+
+func byComputedAtDesc(a, b models.Insight) bool {
+	return a.ComputedAt.After(b.ComputedAt)
+}
+
+func (r *insightRepository) GetByUserID(ctx context.Context, userID string) ([]models.Insight, error) {
+	return r.list(ctx, func(i models.Insight) bool { return i.UserID == userID }, byComputedAtDesc), nil
+}
+
+func (r *insightRepository) GetValidByUserID(ctx context.Context, userID string) ([]models.Insight, error) {
+	cutoff := time.Now()
+	return r.list(ctx, func(i models.Insight) bool {
+		return i.UserID == userID && i.ValidUntil.After(cutoff)
+	}, byComputedAtDesc), nil
+}
+
+func (r *insightRepository) GetByType(ctx context.Context, userID string, insightType models.InsightType) ([]models.Insight, error) {
+	cutoff := time.Now()
+	return r.list(ctx, func(i models.Insight) bool {
+		return i.UserID == userID && i.InsightType == insightType && i.ValidUntil.After(cutoff)
+	}, func(a, b models.Insight) bool {
+		return a.MetricValue > b.MetricValue
+	}), nil
+}
+
+func (r *insightRepository) DeleteByUserID(ctx context.Context, userID string) error {
+	return r.store.write(ctx, func(t *tables) error {
+		for id, i := range t.insights {
+			if i.UserID == userID {
+				delete(t.insights, id)
+			}
+		}
+		return nil
+	})
+}
+
+func (r *insightRepository) DeleteExpired(ctx context.Context, userID string) error {
+	cutoff := time.Now()
+	return r.store.write(ctx, func(t *tables) error {
+		for id, i := range t.insights {
+			if i.UserID == userID && i.ValidUntil.Before(cutoff) {
+				delete(t.insights, id)
+			}
+		}
+		return nil
+	})
+}
+
+func (r *insightRepository) InvalidateAll(ctx context.Context, userID string) error {
+	validUntil := time.Now().Add(-1 * time.Hour) // Set to past
+	return r.store.write(ctx, func(t *tables) error {
+		for id, i := range t.insights {
+			if i.UserID == userID {
+				i.ValidUntil = validUntil
+				t.insights[id] = i
+			}
+		}
+		return nil
+	})
+}
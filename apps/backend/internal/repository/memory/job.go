@@ -0,0 +1,183 @@
+package memory
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/JonnyWalker81/trendy/backend/internal/models"
+	"github.com/JonnyWalker81/trendy/backend/internal/repository"
+)
+
+type jobRepository struct {
+	store *Store
+}
+
+// NewJobRepository creates a new in-memory job repository
+func NewJobRepository(store *Store) repository.JobRepository {
+	return &jobRepository{store: store}
+}
+
+func (r *jobRepository) Enqueue(ctx context.Context, job *models.Job, maxWait time.Duration) (*models.Job, error) {
+	var enqueued models.Job
+	err := r.store.write(ctx, func(t *tables) error {
+		for _, existing := range t.jobs {
+			if existing.Status != models.JobPending || existing.Kind != job.Kind || existing.UserID != job.UserID {
+				continue
+			}
+			// A retry leaves a job queued for new work as it is
+			enqueued = existing
+			if job.Attempts <= existing.Attempts {
+				enqueued.RunAt = job.RunAt.UTC().Truncate(time.Microsecond)
+				if deadline := existing.CreatedAt.Add(maxWait); maxWait > 0 && deadline.Before(enqueued.RunAt) {
+					enqueued.RunAt = deadline
+				}
+				enqueued.Attempts = job.Attempts
+				enqueued.LastError = job.LastError
+			}
+			enqueued.UpdatedAt = now()
+			t.jobs[enqueued.ID] = enqueued
+			return nil
+		}
+
+		enqueued = models.Job{
+			ID:        newID(),
+			Kind:      job.Kind,
+			UserID:    job.UserID,
+			Status:    models.JobPending,
+			Attempts:  job.Attempts,
+			RunAt:     job.RunAt.UTC().Truncate(time.Microsecond),
+			LastError: job.LastError,
+			CreatedAt: now(),
+		}
+		enqueued.UpdatedAt = enqueued.CreatedAt
+		t.jobs[enqueued.ID] = enqueued
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return &enqueued, nil
+}
+
+func (r *jobRepository) Claim(ctx context.Context, limit int) ([]models.Job, error) {
+	claimed := []models.Job{}
+	err := r.store.write(ctx, func(t *tables) error {
+		type runKey struct {
+			kind   models.JobKind
+			userID string
+		}
+		running := make(map[runKey]bool)
+		due := []models.Job{}
+		current := now()
+		for _, job := range t.jobs {
+			switch {
+			case job.Status == models.JobRunning:
+				running[runKey{job.Kind, job.UserID}] = true
+			case job.Status == models.JobPending && !job.RunAt.After(current):
+				due = append(due, job)
+			}
+		}
+		sort.Slice(due, func(i, j int) bool {
+			return due[i].RunAt.Before(due[j].RunAt)
+		})
+
+		for _, job := range due {
+			if len(claimed) == limit {
+				break
+			}
+			if running[runKey{job.Kind, job.UserID}] {
+				continue
+			}
+			job.Status = models.JobRunning
+			job.Attempts++
+			job.StartedAt = &current
+			job.UpdatedAt = current
+			t.jobs[job.ID] = job
+			claimed = append(claimed, job)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return claimed, nil
+}
+
+func (r *jobRepository) Finish(ctx context.Context, job *models.Job) (*models.Job, error) {
+	var finished models.Job
+	var found bool
+	err := r.store.write(ctx, func(t *tables) error {
+		if finished, found = t.jobs[job.ID]; !found {
+			return nil
+		}
+		finished.Status = job.Status
+		finished.LastError = job.LastError
+		finished.CompletedAt = truncateTime(job.CompletedAt)
+		finished.UpdatedAt = now()
+		t.jobs[job.ID] = finished
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	if !found {
+		return nil, fmt.Errorf("job not found")
+	}
+
+	return &finished, nil
+}
+
+func (r *jobRepository) GetLatest(ctx context.Context, kind models.JobKind, userID string) (*models.Job, error) {
+	var latest *models.Job
+	r.store.read(ctx, func(t *tables) {
+		for _, job := range t.jobs {
+			if job.Kind != kind || job.UserID != userID {
+				continue
+			}
+			if latest == nil || job.CreatedAt.After(latest.CreatedAt) ||
+				(job.CreatedAt.Equal(latest.CreatedAt) && job.ID > latest.ID) {
+				latest = &job
+			}
+		}
+	})
+	return latest, nil
+}
+
+func (r *jobRepository) GetStale(ctx context.Context, before time.Time) ([]models.Job, error) {
+	stale := []models.Job{}
+	r.store.read(ctx, func(t *tables) {
+		for _, job := range t.jobs {
+			if job.Status == models.JobRunning && job.StartedAt != nil && job.StartedAt.Before(before) {
+				stale = append(stale, job)
+			}
+		}
+	})
+	sort.Slice(stale, func(i, j int) bool {
+		return stale[i].StartedAt.Before(*stale[j].StartedAt)
+	})
+	return stale, nil
+}
+
+func (r *jobRepository) DeleteFinished(ctx context.Context, before time.Time) error {
+	return r.store.write(ctx, func(t *tables) error {
+		deleteWhere(t.jobs, func(job models.Job) bool {
+			finished := job.Status == models.JobSucceeded || job.Status == models.JobFailed
+			return finished && job.CompletedAt != nil && job.CompletedAt.Before(before)
+		})
+		return nil
+	})
+}
+
+func (r *jobRepository) DeleteByUserID(ctx context.Context, userID string) error {
+	return r.store.write(ctx, func(t *tables) error {
+		deleteWhere(t.jobs, func(job models.Job) bool {
+			return job.UserID == userID
+		})
+		return nil
+	})
+}
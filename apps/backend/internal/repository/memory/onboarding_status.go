@@ -0,0 +1,87 @@
+package memory
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/JonnyWalker81/trendy/backend/internal/models"
+	"github.com/JonnyWalker81/trendy/backend/internal/repository"
+)
+
+type onboardingStatusRepository struct {
+	store *Store
+}
+
+// NewOnboardingStatusRepository creates a new in-memory onboarding status repository
+func NewOnboardingStatusRepository(store *Store) repository.OnboardingStatusRepository {
+	return &onboardingStatusRepository{store: store}
+}
+
+// GetOrCreate returns the user's onboarding status, creating a default record if none exists
+func (r *onboardingStatusRepository) GetOrCreate(ctx context.Context, userID string) (*models.OnboardingStatus, error) {
+	var status models.OnboardingStatus
+	r.store.write(ctx, func(t *tables) error {
+		var exists bool
+		if status, exists = t.onboardingStatus[userID]; exists {
+			return nil
+		}
+		status = models.OnboardingStatus{UserID: userID, CreatedAt: now()}
+		status.UpdatedAt = status.CreatedAt
+		t.onboardingStatus[userID] = status
+		return nil
+	})
+	return &status, nil
+}
+
+// Update updates the user's onboarding status (full replacement of the mutable fields)
+func (r *onboardingStatusRepository) Update(ctx context.Context, userID string, status *models.OnboardingStatus) (*models.OnboardingStatus, error) {
+	return r.update(ctx, userID, func(s *models.OnboardingStatus) {
+		s.Completed = status.Completed
+		s.WelcomeCompletedAt = status.WelcomeCompletedAt
+		s.AuthCompletedAt = status.AuthCompletedAt
+		s.PermissionsCompletedAt = status.PermissionsCompletedAt
+		s.NotificationsStatus = status.NotificationsStatus
+		s.NotificationsCompletedAt = status.NotificationsCompletedAt
+		s.HealthkitStatus = status.HealthkitStatus
+		s.HealthkitCompletedAt = status.HealthkitCompletedAt
+		s.LocationStatus = status.LocationStatus
+		s.LocationCompletedAt = status.LocationCompletedAt
+	})
+}
+
+// SoftReset clears step completion but preserves permission data
+func (r *onboardingStatusRepository) SoftReset(ctx context.Context, userID string) (*models.OnboardingStatus, error) {
+	return r.update(ctx, userID, func(s *models.OnboardingStatus) {
+		s.Completed = false
+		s.WelcomeCompletedAt = nil
+		s.AuthCompletedAt = nil
+		s.PermissionsCompletedAt = nil
+	})
+}
+
+func (r *onboardingStatusRepository) update(ctx context.Context, userID string, apply func(s *models.OnboardingStatus)) (*models.OnboardingStatus, error) {
+	var status models.OnboardingStatus
+	var found bool
+	r.store.write(ctx, func(t *tables) error {
+		if status, found = t.onboardingStatus[userID]; !found {
+			return nil
+		}
+		apply(&status)
+		status.UpdatedAt = now()
+		t.onboardingStatus[userID] = status
+		return nil
+	})
+
+	if !found {
+		return nil, fmt.Errorf("onboarding status not found")
+	}
+
+	return &status, nil
+}
+
+func (r *onboardingStatusRepository) DeleteByUserID(ctx context.Context, userID string) error {
+	return r.store.write(ctx, func(t *tables) error {
+		delete(t.onboardingStatus, userID)
+		return nil
+	})
+}
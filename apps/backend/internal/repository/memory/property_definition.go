@@ -0,0 +1,179 @@
+package memory
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/JonnyWalker81/trendy/backend/internal/models"
+	"github.com/JonnyWalker81/trendy/backend/internal/repository"
+)
+
+type propertyDefinitionRepository struct {
+	store *Store
+}
+
+// NewPropertyDefinitionRepository creates a new in-memory property definition repository
+func NewPropertyDefinitionRepository(store *Store) repository.PropertyDefinitionRepository {
+	return &propertyDefinitionRepository{store: store}
+}
+
+// copyPropertyDefinition returns def with its own options slice
+func copyPropertyDefinition(def models.PropertyDefinition) models.PropertyDefinition {
+	if def.Options != nil {
+		def.Options = append([]string(nil), def.Options...)
+	}
+	return def
+}
+
+// checkPropertyDefinitionUnique enforces UNIQUE(event_type_id, key)
+func checkPropertyDefinitionUnique(t *tables, def models.PropertyDefinition) error {
+	for _, other := range t.propertyDefinitions {
+		if other.ID != def.ID && other.EventTypeID == def.EventTypeID && other.Key == def.Key {
+			return uniqueViolation("property_definitions_event_type_id_key_key")
+		}
+	}
+	return nil
+}
+
+func (r *propertyDefinitionRepository) Create(ctx context.Context, def *models.PropertyDefinition) (*models.PropertyDefinition, error) {
+	d := copyPropertyDefinition(*def)
+	err := r.store.write(ctx, func(t *tables) error {
+		if d.ID == "" {
+			d.ID = newID()
+		}
+		if _, exists := t.propertyDefinitions[d.ID]; exists {
+			return uniqueViolation("property_definitions_pkey")
+		}
+		if err := checkPropertyDefinitionUnique(t, d); err != nil {
+			return err
+		}
+		d.CreatedAt = now()
+		d.UpdatedAt = d.CreatedAt
+		t.propertyDefinitions[d.ID] = d
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to create property definition: %w", err)
+	}
+
+	d = copyPropertyDefinition(d)
+	return &d, nil
+}
+
+func (r *propertyDefinitionRepository) GetByID(ctx context.Context, id string) (*models.PropertyDefinition, error) {
+	var def models.PropertyDefinition
+	var found bool
+	r.store.read(ctx, func(t *tables) {
+		def, found = t.propertyDefinitions[id]
+	})
+
+	if !found {
+		return nil, fmt.Errorf("property definition not found")
+	}
+
+	def = copyPropertyDefinition(def)
+	return &def, nil
+}
+
+// ClaimVersion needs no lock: transactions are serialized with all other
+// writes
+func (r *propertyDefinitionRepository) ClaimVersion(ctx context.Context, id string, updatedAt time.Time) (bool, error) {
+	var claimed bool
+	r.store.read(ctx, func(t *tables) {
+		current, found := t.propertyDefinitions[id]
+		claimed = found && current.UpdatedAt.Equal(updatedAt.UTC().Truncate(time.Microsecond))
+	})
+	return claimed, nil
+}
+
+func (r *propertyDefinitionRepository) GetByEventTypeID(ctx context.Context, eventTypeID string) ([]models.PropertyDefinition, error) {
+	var definitions []models.PropertyDefinition
+	r.store.read(ctx, func(t *tables) {
+		definitions = sortedValues(t.propertyDefinitions,
+			func(def models.PropertyDefinition) bool { return def.EventTypeID == eventTypeID },
+			func(a, b models.PropertyDefinition) bool {
+				if a.DisplayOrder != b.DisplayOrder {
+					return a.DisplayOrder < b.DisplayOrder
+				}
+				return a.CreatedAt.Before(b.CreatedAt)
+			})
+	})
+
+	for i := range definitions {
+		definitions[i] = copyPropertyDefinition(definitions[i])
+	}
+	return definitions, nil
+}
+
+func (r *propertyDefinitionRepository) Update(ctx context.Context, id string, def *models.PropertyDefinition) (*models.PropertyDefinition, error) {
+	var d models.PropertyDefinition
+	var found bool
+	err := r.store.write(ctx, func(t *tables) error {
+		if d, found = t.propertyDefinitions[id]; !found {
+			return nil
+		}
+
+		if def.Key != "" {
+			d.Key = def.Key
+		}
+		if def.Label != "" {
+			d.Label = def.Label
+		}
+		if def.PropertyType != "" {
+			d.PropertyType = def.PropertyType
+		}
+		d.Required = def.Required
+		d.Formula = def.Formula
+		if def.Unit != "" {
+			d.Unit = def.Unit
+		}
+		if def.RatingMax > 0 {
+			d.RatingMax = def.RatingMax
+		}
+		if len(def.Options) > 0 {
+			d.Options = append([]string(nil), def.Options...)
+		}
+		if def.DefaultValue != nil {
+			d.DefaultValue = def.DefaultValue
+		}
+		if def.DisplayOrder >= 0 {
+			d.DisplayOrder = def.DisplayOrder
+		}
+		if err := checkPropertyDefinitionUnique(t, d); err != nil {
+			return err
+		}
+
+		d.UpdatedAt = now()
+		t.propertyDefinitions[id] = d
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to update property definition: %w", err)
+	}
+
+	if !found {
+		return nil, fmt.Errorf("property definition not found")
+	}
+
+	d = copyPropertyDefinition(d)
+	return &d, nil
+}
+
+func (r *propertyDefinitionRepository) Delete(ctx context.Context, id string) error {
+	return r.store.write(ctx, func(t *tables) error {
+		delete(t.propertyDefinitions, id)
+		return nil
+	})
+}
+
+func (r *propertyDefinitionRepository) DeleteByUserID(ctx context.Context, userID string) error {
+	return r.store.write(ctx, func(t *tables) error {
+		for id, def := range t.propertyDefinitions {
+			if def.UserID == userID {
+				delete(t.propertyDefinitions, id)
+			}
+		}
+		return nil
+	})
+}
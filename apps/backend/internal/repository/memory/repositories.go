@@ -0,0 +1,29 @@
+package memory
+
+import "github.com/JonnyWalker81/trendy/backend/internal/repository"
+
+// NewRepositories creates repositories that share store. The returned
+// Transactor discards a unit of work's writes if it fails.
+func NewRepositories(store *Store) *repository.Repositories {
+	return &repository.Repositories{
+		Events:              NewEventRepository(store),
+		EventTypes:          NewEventTypeRepository(store),
+		Users:               NewUserRepository(store),
+		PropertyDefinitions: NewPropertyDefinitionRepository(store),
+		Geofences:           NewGeofenceRepository(store),
+		Insights:            NewInsightRepository(store),
+		DailyAggregates:     NewDailyAggregateRepository(store),
+		AggregateStates:     NewAggregateStateRepository(store),
+		Streaks:             NewStreakRepository(store),
+		ChangeLog:           NewChangeLogRepository(store),
+		ChangeOutbox:        NewChangeOutboxRepository(store),
+		Idempotency:         NewIdempotencyRepository(store),
+		OnboardingStatus:    NewOnboardingStatusRepository(store),
+		UserSettings:        NewUserSettingsRepository(store),
+		ImportJobs:          NewImportJobRepository(store),
+		Jobs:                NewJobRepository(store),
+		AccountExports:      NewAccountExportRepository(store),
+		AccountDeletions:    NewAccountDeletionRepository(store),
+		Transactor:          store,
+	}
+}
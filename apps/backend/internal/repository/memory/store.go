@@ -0,0 +1,343 @@
+// Package memory implements the repository interfaces with in-process maps.
+// It needs no external services and is intended for tests and local
+// development; all data is lost when the process exits.
+//
+// Every repository created from the same Store shares its data, so the
+// cross-table behaviour of the Postgres schema (cascading deletes, unique
+// constraints, change log cursors) is emulated. A Store is safe for
+// concurrent use.
+package memory
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"reflect"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/JonnyWalker81/trendy/backend/internal/models"
+	"github.com/google/uuid"
+)
+
+// Store holds the tables shared by all in-memory repositories
+type Store struct {
+	// writeMu serializes write units (a single call or a whole transaction)
+	// so a rolled-back transaction never discards another caller's writes
+	writeMu sync.Mutex
+	// mu guards data and tx for individual reads and writes
+	mu sync.RWMutex
+	// data holds the committed tables
+	data *tables
+	// tx is the working copy of the transaction in progress, or nil. Only
+	// calls made with the transaction's context see it.
+	tx *tables
+	// txParent is the context the transaction in progress was started with
+	txParent context.Context
+}
+
+// tables is the full data set of a Store. Stored values are never mutated in
+// place; updates replace the map entry, which makes snapshots cheap.
+type tables struct {
+	users               map[string]models.User
+	events              map[string]models.Event
+	eventTypes          map[string]models.EventType
+	propertyDefinitions map[string]models.PropertyDefinition
+	geofences           map[string]models.Geofence
+	insights            map[string]models.Insight
+	dailyAggregates     map[string]models.DailyAggregate
+	aggregateStates     map[string]models.AggregateState
+	aggregatedEvents    map[string]models.AggregatedEvent
+	streaks             map[string]models.Streak
+	changeLog           []models.ChangeEntry
+	lastChangeID        int64
+	changeLogHorizons   map[string]int64
+	idempotencyKeys     map[string]models.IdempotencyKey
+	onboardingStatus    map[string]models.OnboardingStatus
+	userSettings        map[string]models.UserSettings
+	importJobs          map[string]models.ImportJob
+	jobs                map[string]models.Job
+	accountExports      map[string]storedAccountExport
+	accountDeletions    map[string]models.AccountDeletion
+}
+
+// NewStore creates an empty in-memory store
+func NewStore() *Store {
+	return &Store{
+		data: &tables{
+			users:               make(map[string]models.User),
+			events:              make(map[string]models.Event),
+			eventTypes:          make(map[string]models.EventType),
+			propertyDefinitions: make(map[string]models.PropertyDefinition),
+			geofences:           make(map[string]models.Geofence),
+			insights:            make(map[string]models.Insight),
+			dailyAggregates:     make(map[string]models.DailyAggregate),
+			aggregateStates:     make(map[string]models.AggregateState),
+			aggregatedEvents:    make(map[string]models.AggregatedEvent),
+			streaks:             make(map[string]models.Streak),
+			changeLogHorizons:   make(map[string]int64),
+			idempotencyKeys:     make(map[string]models.IdempotencyKey),
+			onboardingStatus:    make(map[string]models.OnboardingStatus),
+			userSettings:        make(map[string]models.UserSettings),
+			importJobs:          make(map[string]models.ImportJob),
+			jobs:                make(map[string]models.Job),
+			accountExports:      make(map[string]storedAccountExport),
+			accountDeletions:    make(map[string]models.AccountDeletion),
+		},
+	}
+}
+
+// clone returns a copy of every table. Entries are shared because they are
+// never mutated in place.
+func (t *tables) clone() *tables {
+	c := *t
+	c.users = cloneMap(t.users)
+	c.events = cloneMap(t.events)
+	c.eventTypes = cloneMap(t.eventTypes)
+	c.propertyDefinitions = cloneMap(t.propertyDefinitions)
+	c.geofences = cloneMap(t.geofences)
+	c.insights = cloneMap(t.insights)
+	c.dailyAggregates = cloneMap(t.dailyAggregates)
+	c.aggregateStates = cloneMap(t.aggregateStates)
+	c.aggregatedEvents = cloneMap(t.aggregatedEvents)
+	c.streaks = cloneMap(t.streaks)
+	c.changeLog = append([]models.ChangeEntry(nil), t.changeLog...)
+	c.changeLogHorizons = cloneMap(t.changeLogHorizons)
+	c.idempotencyKeys = cloneMap(t.idempotencyKeys)
+	c.onboardingStatus = cloneMap(t.onboardingStatus)
+	c.userSettings = cloneMap(t.userSettings)
+	c.importJobs = cloneMap(t.importJobs)
+	c.jobs = cloneMap(t.jobs)
+	c.accountExports = cloneMap(t.accountExports)
+	c.accountDeletions = cloneMap(t.accountDeletions)
+	return &c
+}
+
+func cloneMap[V any](m map[string]V) map[string]V {
+	c := make(map[string]V, len(m))
+	for k, v := range m {
+		c[k] = v
+	}
+	return c
+}
+
+func deleteWhere[V any](m map[string]V, match func(V) bool) {
+	for key, v := range m {
+		if match(v) {
+			delete(m, key)
+		}
+	}
+}
+
+type txKey struct{}
+
+type snapshotKey struct{}
+
+// errReadOnly is returned for a write made with a snapshot's context
+var errReadOnly = errors.New("memory store: cannot write in a read-only snapshot")
+
+// errWriteOutsideTx is returned for a write made inside WithinTx with the
+// context the transaction was started with rather than the one passed to fn.
+// The write would otherwise wait for its own transaction to finish.
+var errWriteOutsideTx = errors.New("memory store: write inside a transaction must use the transaction's context")
+
+// txOf returns the working copy of the transaction ctx was passed to, or nil
+// when that transaction is no longer in progress or ctx belongs to none. The
+// context carries the working copy itself, so a context kept past commit can
+// never reach a later transaction's data. Callers hold mu.
+func (s *Store) txOf(ctx context.Context) *tables {
+	if tx, ok := ctx.Value(txKey{}).(*tables); ok && tx == s.tx {
+		return tx
+	}
+	return nil
+}
+
+// startedTx reports whether ctx is the context the transaction in progress
+// was started with. Callers hold mu.
+func (s *Store) startedTx(ctx context.Context) bool {
+	if s.tx == nil || s.txParent == nil || !reflect.TypeOf(ctx).Comparable() {
+		return false
+	}
+	return ctx == s.txParent
+}
+
+// WithinTx runs fn as a single unit of work. Writes made with the context
+// passed to fn go to a working copy that replaces the committed tables when
+// fn succeeds and is discarded if it returns an error; other callers only
+// ever see committed data. Transactions are serialized with all other
+// writes; calls with the context of the transaction in progress join it.
+func (s *Store) WithinTx(ctx context.Context, fn func(ctx context.Context) error) error {
+	s.mu.RLock()
+	joined := s.txOf(ctx) != nil
+	s.mu.RUnlock()
+	if joined {
+		return fn(ctx)
+	}
+
+	s.writeMu.Lock()
+	defer s.writeMu.Unlock()
+
+	s.mu.Lock()
+	tx := s.data.clone()
+	s.tx = tx
+	s.txParent = ctx
+	s.mu.Unlock()
+
+	err := fn(context.WithValue(ctx, txKey{}, tx))
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if err != nil {
+		// Change log IDs handed out by the rolled-back transaction are never
+		// reused, so cursors stay monotonic like a Postgres sequence
+		committed := *s.data
+		committed.lastChangeID = tx.lastChangeID
+		s.data = &committed
+	} else {
+		s.data = tx
+	}
+	s.tx = nil
+	s.txParent = nil
+
+	return err
+}
+
+// WithinSnapshot runs fn against a copy of the committed tables, so every
+// read made with the context passed to fn sees the same state while other
+// callers keep writing. Writes with that context fail. Inside a transaction
+// fn simply joins it.
+func (s *Store) WithinSnapshot(ctx context.Context, fn func(ctx context.Context) error) error {
+	s.mu.RLock()
+	if s.txOf(ctx) != nil {
+		s.mu.RUnlock()
+		return fn(ctx)
+	}
+	snapshot := s.data.clone()
+	s.mu.RUnlock()
+
+	return fn(context.WithValue(ctx, snapshotKey{}, snapshot))
+}
+
+// view returns the tables visible to ctx: the transaction's working copy
+// while ctx's transaction is in progress, the snapshot ctx was given, or the
+// committed tables otherwise. Callers hold mu.
+func (s *Store) view(ctx context.Context) *tables {
+	if tx := s.txOf(ctx); tx != nil {
+		return tx
+	}
+	if snapshot, ok := ctx.Value(snapshotKey{}).(*tables); ok {
+		return snapshot
+	}
+	return s.data
+}
+
+// read runs fn with shared access to the tables visible to ctx
+func (s *Store) read(ctx context.Context, fn func(t *tables)) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	fn(s.view(ctx))
+}
+
+// write runs fn with exclusive access to the tables visible to ctx. A write
+// outside the transaction in progress, including one made with a context
+// kept past its transaction's commit, takes writeMu so it lands in the
+// committed tables after that transaction rather than being overwritten by
+// it.
+func (s *Store) write(ctx context.Context, fn func(t *tables) error) error {
+	s.mu.Lock()
+	if tx := s.txOf(ctx); tx != nil {
+		defer s.mu.Unlock()
+		return fn(tx)
+	}
+	if s.startedTx(ctx) {
+		s.mu.Unlock()
+		return errWriteOutsideTx
+	}
+	s.mu.Unlock()
+	if _, ok := ctx.Value(snapshotKey{}).(*tables); ok {
+		return errReadOnly
+	}
+
+	s.writeMu.Lock()
+	defer s.writeMu.Unlock()
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return fn(s.data)
+}
+
+// newID returns a UUIDv7, matching the database default
+func newID() string {
+	id, err := uuid.NewV7()
+	if err != nil {
+		return uuid.NewString()
+	}
+	return id.String()
+}
+
+// now returns the current time with the microsecond precision of Postgres
+func now() time.Time {
+	return time.Now().UTC().Truncate(time.Microsecond)
+}
+
+// uniqueViolation mirrors the Postgres error text so callers that detect
+// duplicates by message behave the same with every backend
+func uniqueViolation(constraint string) error {
+	return fmt.Errorf("duplicate key value violates unique constraint %q (SQLSTATE 23505)", constraint)
+}
+
+// applyFields returns a copy of src with snake_case column values overlaid
+// using its JSON field names. A nil value clears the field.
+func applyFields[T any](src T, fields map[string]interface{}) (T, error) {
+	var result T
+
+	current, err := json.Marshal(src)
+	if err != nil {
+		return result, err
+	}
+
+	doc := make(map[string]json.RawMessage)
+	if err := json.Unmarshal(current, &doc); err != nil {
+		return result, err
+	}
+
+	for key, value := range fields {
+		raw, err := json.Marshal(value)
+		if err != nil {
+			return result, fmt.Errorf("failed to marshal field %s: %w", key, err)
+		}
+		doc[key] = raw
+	}
+
+	merged, err := json.Marshal(doc)
+	if err != nil {
+		return result, err
+	}
+
+	if err := json.Unmarshal(merged, &result); err != nil {
+		return result, err
+	}
+	return result, nil
+}
+
+// sortedValues returns the entries of m that match keep, ordered by less
+func sortedValues[V any](m map[string]V, keep func(V) bool, less func(a, b V) bool) []V {
+	result := make([]V, 0)
+	for _, v := range m {
+		if keep(v) {
+			result = append(result, v)
+		}
+	}
+	sort.SliceStable(result, func(i, j int) bool { return less(result[i], result[j]) })
+	return result
+}
+
+func dateKey(t time.Time) string {
+	return t.UTC().Format("2006-01-02")
+}
+
+func truncateDate(t time.Time) time.Time {
+	y, m, d := t.UTC().Date()
+	return time.Date(y, m, d, 0, 0, 0, 0, time.UTC)
+}
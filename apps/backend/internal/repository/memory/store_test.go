@@ -0,0 +1,428 @@
+package memory
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/JonnyWalker81/trendy/backend/internal/models"
+)
+
+func strPtr(s string) *string { return &s }
+
+// TestChangeLogCursorsAreMonotonic verifies concurrent appends get unique,
+// increasing IDs and that GetSince pages through them in order.
+func TestChangeLogCursorsAreMonotonic(t *testing.T) {
+	ctx := context.Background()
+	repo := NewChangeLogRepository(NewStore())
+
+	var wg sync.WaitGroup
+	for i := 0; i < 20; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for j := 0; j < 10; j++ {
+				if _, err := repo.Append(ctx, &models.ChangeLogInput{
+					EntityType: models.EntityTypeEvent,
+					Operation:  models.OperationCreate,
+					EntityID:   newID(),
+					UserID:     "user-1",
+				}); err != nil {
+					t.Errorf("Append failed: %v", err)
+				}
+			}
+		}()
+	}
+	wg.Wait()
+
+	var cursor int64
+	total := 0
+	for {
+		page, err := repo.GetSince(ctx, "user-1", cursor, 50)
+		if err != nil {
+			t.Fatalf("GetSince failed: %v", err)
+		}
+		for _, entry := range page.Changes {
+			if entry.ID <= cursor {
+				t.Fatalf("cursor went backwards: %d after %d", entry.ID, cursor)
+			}
+			cursor = entry.ID
+		}
+		total += len(page.Changes)
+		if !page.HasMore {
+			break
+		}
+	}
+
+	if total != 200 {
+		t.Errorf("expected 200 entries, got %d", total)
+	}
+
+	latest, _ := repo.GetLatestCursor(ctx, "user-1")
+	if latest != cursor {
+		t.Errorf("GetLatestCursor = %d, want %d", latest, cursor)
+	}
+
+	empty, _ := repo.GetSince(ctx, "user-1", cursor, 50)
+	if len(empty.Changes) != 0 || empty.NextCursor != cursor {
+		t.Errorf("expected empty page preserving cursor %d, got %d entries and cursor %d",
+			cursor, len(empty.Changes), empty.NextCursor)
+	}
+}
+
+// TestUpsertHealthKitEventDedupes verifies sample-ID and content-based
+// deduplication of HealthKit events.
+func TestUpsertHealthKitEventDedupes(t *testing.T) {
+	ctx := context.Background()
+	repo := NewEventRepository(NewStore())
+	ts := time.Date(2026, 1, 2, 3, 4, 5, 0, time.UTC)
+
+	event := models.Event{
+		UserID:            "user-1",
+		EventTypeID:       "type-1",
+		Timestamp:         ts,
+		SourceType:        "healthkit",
+		HealthKitSampleID: strPtr("sample-1"),
+		HealthKitCategory: strPtr("workout"),
+	}
+
+	first, created, err := repo.UpsertHealthKitEvent(ctx, &event)
+	if err != nil || !created {
+		t.Fatalf("first upsert: created=%v err=%v", created, err)
+	}
+
+	again, created, err := repo.UpsertHealthKitEvent(ctx, &event)
+	if err != nil || created || again.ID != first.ID {
+		t.Fatalf("same sample ID should dedupe: created=%v id=%s err=%v", created, again.ID, err)
+	}
+
+	// Same content with a new sample ID (e.g. after a HealthKit restore)
+	restored := event
+	restored.HealthKitSampleID = strPtr("sample-2")
+	restored.Timestamp = ts.Add(300 * time.Millisecond)
+	dup, created, err := repo.UpsertHealthKitEvent(ctx, &restored)
+	if err != nil || created || dup.ID != first.ID {
+		t.Fatalf("same content should dedupe: created=%v id=%s err=%v", created, dup.ID, err)
+	}
+
+	count, _ := repo.CountHealthKitByUser(ctx, "user-1")
+	if count != 1 {
+		t.Errorf("expected 1 HealthKit event, got %d", count)
+	}
+}
+
+// TestManualDedupeConstraint verifies the unique (user, type, timestamp)
+// index for non-HealthKit events.
+func TestManualDedupeConstraint(t *testing.T) {
+	ctx := context.Background()
+	repo := NewEventRepository(NewStore())
+	event := models.Event{UserID: "user-1", EventTypeID: "type-1", Timestamp: time.Now()}
+
+	if _, err := repo.Create(ctx, &event); err != nil {
+		t.Fatalf("Create failed: %v", err)
+	}
+	_, err := repo.Create(ctx, &event)
+	if err == nil || !strings.Contains(err.Error(), "duplicate") {
+		t.Fatalf("expected duplicate error, got %v", err)
+	}
+}
+
+// TestUpdateFieldsReplacesAndClears verifies nil clears a column and
+// properties are replaced rather than merged.
+func TestUpdateFieldsReplacesAndClears(t *testing.T) {
+	ctx := context.Background()
+	repo := NewEventRepository(NewStore())
+
+	created, err := repo.Create(ctx, &models.Event{
+		UserID:      "user-1",
+		EventTypeID: "type-1",
+		Timestamp:   time.Now(),
+		Notes:       strPtr("note"),
+		Properties: map[string]models.PropertyValue{
+			"a": {Type: models.PropertyTypeText, Value: "x"},
+			"b": {Type: models.PropertyTypeText, Value: "y"},
+		},
+	})
+	if err != nil {
+		t.Fatalf("Create failed: %v", err)
+	}
+
+	updated, err := repo.UpdateFields(ctx, created.ID, map[string]interface{}{
+		"notes":      nil,
+		"properties": map[string]models.PropertyValue{"a": {Type: models.PropertyTypeText, Value: "z"}},
+	})
+	if err != nil {
+		t.Fatalf("UpdateFields failed: %v", err)
+	}
+
+	if updated.Notes != nil {
+		t.Errorf("expected notes to be cleared, got %q", *updated.Notes)
+	}
+	if len(updated.Properties) != 1 || updated.Properties["a"].Value != "z" {
+		t.Errorf("expected properties to be replaced, got %v", updated.Properties)
+	}
+	if !updated.CreatedAt.Equal(created.CreatedAt) {
+		t.Errorf("created_at changed on update")
+	}
+}
+
+// TestWithinTxRollsBack verifies writes in a failed transaction are discarded
+// without reusing its change log IDs, and writes in a successful one are kept.
+func TestWithinTxRollsBack(t *testing.T) {
+	ctx := context.Background()
+	store := NewStore()
+	repos := NewRepositories(store)
+	errBoom := errors.New("boom")
+
+	var rolledBack int64
+	err := repos.Transactor.WithinTx(ctx, func(ctx context.Context) error {
+		if _, err := repos.EventTypes.Create(ctx, &models.EventType{UserID: "user-1", Name: "Run"}); err != nil {
+			return err
+		}
+		id, err := repos.ChangeLog.Append(ctx, &models.ChangeLogInput{UserID: "user-1", EntityID: "x"})
+		if err != nil {
+			return err
+		}
+		rolledBack = id
+		return errBoom
+	})
+	if !errors.Is(err, errBoom) {
+		t.Fatalf("expected errBoom, got %v", err)
+	}
+
+	types, _ := repos.EventTypes.GetByUserID(ctx, "user-1")
+	cursor, _ := repos.ChangeLog.GetLatestCursor(ctx, "user-1")
+	if len(types) != 0 || cursor != 0 {
+		t.Fatalf("expected rollback, got %d event types and cursor %d", len(types), cursor)
+	}
+
+	err = repos.Transactor.WithinTx(ctx, func(ctx context.Context) error {
+		_, err := repos.EventTypes.Create(ctx, &models.EventType{UserID: "user-1", Name: "Run"})
+		return err
+	})
+	if err != nil {
+		t.Fatalf("WithinTx failed: %v", err)
+	}
+
+	types, _ = repos.EventTypes.GetByUserID(ctx, "user-1")
+	if len(types) != 1 {
+		t.Fatalf("expected committed event type, got %d", len(types))
+	}
+
+	id, _ := repos.ChangeLog.Append(ctx, &models.ChangeLogInput{UserID: "user-1", EntityID: "y"})
+	if id <= rolledBack {
+		t.Errorf("change log ID %d reused after rollback of %d", id, rolledBack)
+	}
+}
+
+// TestWithinTxHidesUncommittedWrites verifies callers outside a transaction
+// only see its writes once it commits.
+func TestWithinTxHidesUncommittedWrites(t *testing.T) {
+	ctx := context.Background()
+	repos := NewRepositories(NewStore())
+
+	written := make(chan struct{})
+	commit := make(chan struct{})
+	done := make(chan error, 1)
+	go func() {
+		done <- repos.Transactor.WithinTx(ctx, func(ctx context.Context) error {
+			if _, err := repos.EventTypes.Create(ctx, &models.EventType{UserID: "user-1", Name: "Run"}); err != nil {
+				return err
+			}
+			if types, _ := repos.EventTypes.GetByUserID(ctx, "user-1"); len(types) != 1 {
+				t.Errorf("transaction should see its own write, got %d event types", len(types))
+			}
+			close(written)
+			<-commit
+			return nil
+		})
+	}()
+
+	<-written
+	if types, _ := repos.EventTypes.GetByUserID(ctx, "user-1"); len(types) != 0 {
+		t.Errorf("read outside the transaction saw %d uncommitted event types", len(types))
+	}
+	close(commit)
+	if err := <-done; err != nil {
+		t.Fatalf("WithinTx failed: %v", err)
+	}
+
+	if types, _ := repos.EventTypes.GetByUserID(ctx, "user-1"); len(types) != 1 {
+		t.Errorf("expected committed event type, got %d", len(types))
+	}
+}
+
+// TestWithinTxIgnoresStaleContext verifies a context kept past its
+// transaction's commit writes committed data that a later transaction does
+// not overwrite, and that a write with the transaction's starting context
+// fails rather than waiting for the transaction.
+func TestWithinTxIgnoresStaleContext(t *testing.T) {
+	ctx := context.Background()
+	repos := NewRepositories(NewStore())
+
+	var stale context.Context
+	err := repos.Transactor.WithinTx(ctx, func(txCtx context.Context) error {
+		stale = txCtx
+		if _, err := repos.EventTypes.Create(ctx, &models.EventType{UserID: "user-1", Name: "Run"}); !errors.Is(err, errWriteOutsideTx) {
+			t.Errorf("expected errWriteOutsideTx, got %v", err)
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("WithinTx failed: %v", err)
+	}
+
+	started := make(chan struct{})
+	commit := make(chan struct{})
+	done := make(chan error, 1)
+	go func() {
+		done <- repos.Transactor.WithinTx(ctx, func(ctx context.Context) error {
+			close(started)
+			<-commit
+			_, err := repos.EventTypes.Create(ctx, &models.EventType{UserID: "user-1", Name: "Walk"})
+			return err
+		})
+	}()
+
+	<-started
+	written := make(chan error, 1)
+	go func() {
+		_, err := repos.EventTypes.Create(stale, &models.EventType{UserID: "user-1", Name: "Swim"})
+		written <- err
+	}()
+	close(commit)
+	if err := <-done; err != nil {
+		t.Fatalf("WithinTx failed: %v", err)
+	}
+	if err := <-written; err != nil {
+		t.Fatalf("Create with a stale context failed: %v", err)
+	}
+
+	if types, _ := repos.EventTypes.GetByUserID(ctx, "user-1"); len(types) != 2 {
+		t.Errorf("expected the stale write and the transaction's write, got %d event types", len(types))
+	}
+}
+
+// TestWithinSnapshotIsStable verifies reads in a snapshot ignore writes
+// committed after it began and that the snapshot cannot be written.
+func TestWithinSnapshotIsStable(t *testing.T) {
+	ctx := context.Background()
+	repos := NewRepositories(NewStore())
+
+	err := repos.Transactor.WithinSnapshot(ctx, func(snapCtx context.Context) error {
+		if _, err := repos.EventTypes.Create(ctx, &models.EventType{UserID: "user-1", Name: "Run"}); err != nil {
+			t.Fatalf("Create failed: %v", err)
+		}
+		if types, _ := repos.EventTypes.GetByUserID(snapCtx, "user-1"); len(types) != 0 {
+			t.Errorf("snapshot saw %d event types created after it began", len(types))
+		}
+		if _, err := repos.EventTypes.Create(snapCtx, &models.EventType{UserID: "user-1", Name: "Walk"}); !errors.Is(err, errReadOnly) {
+			t.Errorf("expected errReadOnly, got %v", err)
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("WithinSnapshot failed: %v", err)
+	}
+}
+
+// TestDeleteEventTypeCascades verifies events and property definitions are
+// removed with their event type.
+func TestDeleteEventTypeCascades(t *testing.T) {
+	ctx := context.Background()
+	repos := NewRepositories(NewStore())
+
+	et, _ := repos.EventTypes.Create(ctx, &models.EventType{UserID: "user-1", Name: "Run"})
+	repos.Events.Create(ctx, &models.Event{UserID: "user-1", EventTypeID: et.ID, Timestamp: time.Now()})
+	repos.PropertyDefinitions.Create(ctx, &models.PropertyDefinition{EventTypeID: et.ID, UserID: "user-1", Key: "k"})
+
+	if err := repos.EventTypes.Delete(ctx, et.ID); err != nil {
+		t.Fatalf("Delete failed: %v", err)
+	}
+
+	count, _ := repos.Events.CountByUser(ctx, "user-1")
+	defs, _ := repos.PropertyDefinitions.GetByEventTypeID(ctx, et.ID)
+	if count != 0 || len(defs) != 0 {
+		t.Errorf("expected cascade, got %d events and %d definitions", count, len(defs))
+	}
+}
+
+// TestChangeLogCompactAndPrune verifies compaction keeps each entity's latest
+// entry, pruning keeps the latest entry of live entities, and pruning advances
+// the user's horizon past removed entries.
+func TestChangeLogCompactAndPrune(t *testing.T) {
+	ctx := context.Background()
+	repo := NewChangeLogRepository(NewStore())
+
+	appendEntry := func(entityID string, op models.Operation) int64 {
+		id, err := repo.Append(ctx, &models.ChangeLogInput{
+			EntityType: models.EntityTypeEvent,
+			Operation:  op,
+			EntityID:   entityID,
+			UserID:     "user-1",
+		})
+		if err != nil {
+			t.Fatalf("Append failed: %v", err)
+		}
+		return id
+	}
+
+	appendEntry("a", models.OperationCreate)
+	appendEntry("a", models.OperationUpdate)
+	appendEntry("b", models.OperationCreate)
+	latestA := appendEntry("a", models.OperationDelete)
+
+	compacted, err := repo.Compact(ctx)
+	if err != nil {
+		t.Fatalf("Compact failed: %v", err)
+	}
+	if compacted != 2 {
+		t.Errorf("expected 2 compacted entries, got %d", compacted)
+	}
+
+	page, err := repo.GetSince(ctx, "user-1", 0, 10)
+	if err != nil {
+		t.Fatalf("GetSince failed: %v", err)
+	}
+	if len(page.Changes) != 2 || page.Changes[1].ID != latestA || page.Changes[1].Operation != models.OperationDelete {
+		t.Fatalf("expected b's create and a's delete to remain, got %+v", page.Changes)
+	}
+
+	// Prune everything appended so far except b's create, the only record of
+	// a live entity; the horizon moves to the newest removed entry
+	cutoff := time.Now().Add(time.Second)
+	pruned, err := repo.Prune(ctx, cutoff)
+	if err != nil {
+		t.Fatalf("Prune failed: %v", err)
+	}
+	if pruned != 1 {
+		t.Errorf("expected 1 pruned entry, got %d", pruned)
+	}
+
+	page, err = repo.GetSince(ctx, "user-1", 0, 10)
+	if err != nil {
+		t.Fatalf("GetSince failed: %v", err)
+	}
+	if len(page.Changes) != 1 || page.Changes[0].EntityID != "b" {
+		t.Fatalf("expected b's create to remain, got %+v", page.Changes)
+	}
+
+	horizon, err := repo.GetHorizon(ctx, "user-1")
+	if err != nil {
+		t.Fatalf("GetHorizon failed: %v", err)
+	}
+	if horizon != latestA {
+		t.Errorf("expected horizon %d, got %d", latestA, horizon)
+	}
+
+	latest, err := repo.GetLatestCursor(ctx, "user-1")
+	if err != nil {
+		t.Fatalf("GetLatestCursor failed: %v", err)
+	}
+	if latest != latestA {
+		t.Errorf("expected latest cursor to stay at %d after pruning, got %d", latestA, latest)
+	}
+}
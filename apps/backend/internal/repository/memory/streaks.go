@@ -0,0 +1,97 @@
+package memory
+
+import (
+	"context"
+
+	"github.com/JonnyWalker81/trendy/backend/internal/models"
+	"github.com/JonnyWalker81/trendy/backend/internal/repository"
+)
+
+type streakRepository struct {
+	store *Store
+}
+
+// NewStreakRepository creates a new in-memory streak repository
+func NewStreakRepository(store *Store) repository.StreakRepository {
+	return &streakRepository{store: store}
+}
+
+// Upsert inserts streak or replaces the row with the same
+// (user_id, event_type_id, streak_type)
+func (r *streakRepository) Upsert(ctx context.Context, streak *models.Streak) (*models.Streak, error) {
+	s := *streak
+	s.StartDate = truncateDate(s.StartDate)
+	if s.EndDate != nil {
+		endDate := truncateDate(*s.EndDate)
+		s.EndDate = &endDate
+	}
+	s.EventType = nil
+
+	r.store.write(ctx, func(t *tables) error {
+		s.UpdatedAt = now()
+		for id, existing := range t.streaks {
+			if existing.UserID == s.UserID && existing.EventTypeID == s.EventTypeID && existing.StreakType == s.StreakType {
+				s.ID = id
+				s.CreatedAt = existing.CreatedAt
+				t.streaks[id] = s
+				return nil
+			}
+		}
+		s.ID = newID()
+		s.CreatedAt = s.UpdatedAt
+		t.streaks[s.ID] = s
+		return nil
+	})
+
+	return &s, nil
+}
+
+func (r *streakRepository) list(ctx context.Context, keep func(s models.Streak) bool, less func(a, b models.Streak) bool) []models.Streak {
+	var streaks []models.Streak
+	r.store.read(ctx, func(t *tables) {
+		streaks = sortedValues(t.streaks, keep, less)
+	})
+	return streaks
+}
+
+func byLengthDesc(a, b models.Streak) bool {
+	return a.Length > b.Length
+}
+
+func (r *streakRepository) GetByUserID(ctx context.Context, userID string) ([]models.Streak, error) {
+	return r.list(ctx, func(s models.Streak) bool { return s.UserID == userID }, byLengthDesc), nil
+}
+
+func (r *streakRepository) GetByUserIDAndEventType(ctx context.Context, userID, eventTypeID string) ([]models.Streak, error) {
+	return r.list(ctx, func(s models.Streak) bool {
+		return s.UserID == userID && s.EventTypeID == eventTypeID
+	}, func(a, b models.Streak) bool {
+		return a.StreakType < b.StreakType
+	}), nil
+}
+
+func (r *streakRepository) GetActiveByUserID(ctx context.Context, userID string) ([]models.Streak, error) {
+	return r.list(ctx, func(s models.Streak) bool { return s.UserID == userID && s.IsActive }, byLengthDesc), nil
+}
+
+func (r *streakRepository) DeleteByUserID(ctx context.Context, userID string) error {
+	return r.store.write(ctx, func(t *tables) error {
+		for id, s := range t.streaks {
+			if s.UserID == userID {
+				delete(t.streaks, id)
+			}
+		}
+		return nil
+	})
+}
+
+func (r *streakRepository) DeleteByEventType(ctx context.Context, userID, eventTypeID string) error {
+	return r.store.write(ctx, func(t *tables) error {
+		for id, s := range t.streaks {
+			if s.UserID == userID && s.EventTypeID == eventTypeID {
+				delete(t.streaks, id)
+			}
+		}
+		return nil
+	})
+}
@@ -0,0 +1,115 @@
+package memory
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/JonnyWalker81/trendy/backend/internal/models"
+	"github.com/JonnyWalker81/trendy/backend/internal/repository"
+)
+
+type userRepository struct {
+	store *Store
+}
+
+// NewUserRepository creates a new in-memory user repository
+func NewUserRepository(store *Store) repository.UserRepository {
+	return &userRepository{store: store}
+}
+
+func (r *userRepository) GetByID(ctx context.Context, id string) (*models.User, error) {
+	var user models.User
+	var found bool
+	r.store.read(ctx, func(t *tables) {
+		user, found = t.users[id]
+	})
+
+	if !found {
+		return nil, fmt.Errorf("user not found")
+	}
+
+	return &user, nil
+}
+
+func (r *userRepository) GetByEmail(ctx context.Context, email string) (*models.User, error) {
+	var user models.User
+	var found bool
+	r.store.read(ctx, func(t *tables) {
+		for _, u := range t.users {
+			if u.Email == email {
+				user, found = u, true
+				return
+			}
+		}
+	})
+
+	if !found {
+		return nil, fmt.Errorf("user not found")
+	}
+
+	return &user, nil
+}
+
+func (r *userRepository) Create(ctx context.Context, user *models.User) (*models.User, error) {
+	u := *user
+	err := r.store.write(ctx, func(t *tables) error {
+		if _, exists := t.users[u.ID]; exists {
+			return uniqueViolation("users_pkey")
+		}
+		for _, other := range t.users {
+			if other.Email == u.Email {
+				return uniqueViolation("users_email_key")
+			}
+		}
+		u.CreatedAt = now()
+		u.UpdatedAt = u.CreatedAt
+		t.users[u.ID] = u
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to create user: %w", err)
+	}
+
+	return &u, nil
+}
+
+func (r *userRepository) List(ctx context.Context, limit, offset int) ([]models.User, error) {
+	var users []models.User
+	r.store.read(ctx, func(t *tables) {
+		users = sortedValues(t.users,
+			func(models.User) bool { return true },
+			func(a, b models.User) bool {
+				if !a.CreatedAt.Equal(b.CreatedAt) {
+					return a.CreatedAt.Before(b.CreatedAt)
+				}
+				return a.ID < b.ID
+			})
+	})
+
+	return paginate(users, limit, offset), nil
+}
+
+// Delete removes a user and, like the foreign keys of the Postgres schema,
+// everything the user owns
+func (r *userRepository) Delete(ctx context.Context, id string) error {
+	return r.store.write(ctx, func(t *tables) error {
+		delete(t.users, id)
+		deleteUserRows(t, id)
+		return nil
+	})
+}
+
+func deleteUserRows(t *tables, userID string) {
+	deleteWhere(t.events, func(e models.Event) bool { return e.UserID == userID })
+	deleteWhere(t.eventTypes, func(et models.EventType) bool { return et.UserID == userID })
+	deleteWhere(t.propertyDefinitions, func(def models.PropertyDefinition) bool { return def.UserID == userID })
+	deleteWhere(t.geofences, func(g models.Geofence) bool { return g.UserID == userID })
+	deleteWhere(t.insights, func(i models.Insight) bool { return i.UserID == userID })
+	deleteWhere(t.dailyAggregates, func(agg models.DailyAggregate) bool { return agg.UserID == userID })
+	deleteWhere(t.streaks, func(s models.Streak) bool { return s.UserID == userID })
+	deleteWhere(t.idempotencyKeys, func(k models.IdempotencyKey) bool { return k.UserID == userID })
+	deleteWhere(t.importJobs, func(job models.ImportJob) bool { return job.UserID == userID })
+	deleteWhere(t.accountExports, func(stored storedAccountExport) bool { return stored.export.UserID == userID })
+	delete(t.onboardingStatus, userID)
+	deleteUserChanges(t, userID)
+}
@@ -0,0 +1,54 @@
+package memory
+
+import (
+	"context"
+
+	"github.com/JonnyWalker81/trendy/backend/internal/models"
+	"github.com/JonnyWalker81/trendy/backend/internal/repository"
+)
+
+type userSettingsRepository struct {
+	store *Store
+}
+
+// NewUserSettingsRepository creates a new in-memory user settings repository
+func NewUserSettingsRepository(store *Store) repository.UserSettingsRepository {
+	return &userSettingsRepository{store: store}
+}
+
+func (r *userSettingsRepository) GetByUserID(ctx context.Context, userID string) (*models.UserSettings, error) {
+	var settings models.UserSettings
+	var found bool
+	r.store.read(ctx, func(t *tables) {
+		settings, found = t.userSettings[userID]
+	})
+
+	if !found {
+		return nil, nil
+	}
+
+	return &settings, nil
+}
+
+func (r *userSettingsRepository) Upsert(ctx context.Context, settings *models.UserSettings) (*models.UserSettings, error) {
+	var upserted models.UserSettings
+	r.store.write(ctx, func(t *tables) error {
+		upserted = *settings
+		upserted.UpdatedAt = now()
+		if existing, ok := t.userSettings[settings.UserID]; ok {
+			upserted.CreatedAt = existing.CreatedAt
+		} else {
+			upserted.CreatedAt = upserted.UpdatedAt
+		}
+		t.userSettings[settings.UserID] = upserted
+		return nil
+	})
+	return &upserted, nil
+}
+
+func (r *userSettingsRepository) DeleteByUserID(ctx context.Context, userID string) error {
+	return r.store.write(ctx, func(t *tables) error {
+		delete(t.userSettings, userID)
+		return nil
+	})
+}
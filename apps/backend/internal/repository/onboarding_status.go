@@ -109,3 +109,15 @@ func (r *onboardingStatusRepository) SoftReset(ctx context.Context, userID strin
 
 	return &statuses[0], nil
 }
+
+func (r *onboardingStatusRepository) DeleteByUserID(ctx context.Context, userID string) error {
+	query := map[string]interface{}{
+		"user_id": fmt.Sprintf("eq.%s", userID),
+	}
+
+	if err := r.client.DeleteWhere("onboarding_status", query); err != nil {
+		return fmt.Errorf("failed to delete onboarding status: %w", err)
+	}
+
+	return nil
+}
@@ -0,0 +1,83 @@
+package postgres
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/JonnyWalker81/trendy/backend/internal/models"
+	"github.com/JonnyWalker81/trendy/backend/internal/repository"
+)
+
+type accountDeletionRepository struct {
+	db *DB
+}
+
+// NewAccountDeletionRepository creates a new Postgres-backed account deletion repository
+func NewAccountDeletionRepository(db *DB) repository.AccountDeletionRepository {
+	return &accountDeletionRepository{db: db}
+}
+
+func (r *accountDeletionRepository) Start(ctx context.Context, userID string) (*models.AccountDeletion, error) {
+	// The partial unique index allows one pending deletion per user, so a
+	// concurrent Start falls through to the existing row
+	if _, err := r.db.conn(ctx).Exec(ctx,
+		`INSERT INTO account_deletions (user_id) VALUES ($1) ON CONFLICT (user_id) WHERE status = 'pending' DO NOTHING`,
+		userID); err != nil {
+		return nil, fmt.Errorf("failed to create account deletion: %w", err)
+	}
+
+	deletion, err := selectOne[models.AccountDeletion](ctx, r.db.conn(ctx),
+		`SELECT to_jsonb(t) FROM account_deletions t WHERE t.user_id = $1 AND t.status = 'pending'`, userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get account deletion: %w", err)
+	}
+
+	if deletion == nil {
+		return nil, fmt.Errorf("no account deletion returned")
+	}
+
+	return deletion, nil
+}
+
+func (r *accountDeletionRepository) GetPending(ctx context.Context, userID string) (*models.AccountDeletion, error) {
+	deletion, err := selectOne[models.AccountDeletion](ctx, r.db.conn(ctx),
+		`SELECT to_jsonb(t) FROM account_deletions t WHERE t.user_id = $1 AND t.status = 'pending'`, userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get account deletion: %w", err)
+	}
+	return deletion, nil
+}
+
+func (r *accountDeletionRepository) ListPending(ctx context.Context) ([]models.AccountDeletion, error) {
+	deletions, err := selectJSON[models.AccountDeletion](ctx, r.db.conn(ctx),
+		`SELECT to_jsonb(t) FROM account_deletions t WHERE t.status = 'pending' ORDER BY t.requested_at`)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list account deletions: %w", err)
+	}
+	return deletions, nil
+}
+
+func (r *accountDeletionRepository) Update(ctx context.Context, deletion *models.AccountDeletion) (*models.AccountDeletion, error) {
+	steps := make([]string, len(deletion.CompletedSteps))
+	for i, step := range deletion.CompletedSteps {
+		steps[i] = string(step)
+	}
+
+	data := map[string]interface{}{
+		"status":          deletion.Status,
+		"completed_steps": steps,
+		"completed_at":    deletion.CompletedAt,
+	}
+
+	sql, args := updateSQL("account_deletions", data, "t.id = $1", []any{deletion.ID}, "to_jsonb(t)")
+	updated, err := selectOne[models.AccountDeletion](ctx, r.db.conn(ctx), sql, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to update account deletion: %w", err)
+	}
+
+	if updated == nil {
+		return nil, fmt.Errorf("account deletion not found")
+	}
+
+	return updated, nil
+}
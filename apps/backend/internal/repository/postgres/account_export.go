@@ -0,0 +1,103 @@
+package postgres
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/JonnyWalker81/trendy/backend/internal/models"
+	"github.com/JonnyWalker81/trendy/backend/internal/repository"
+	"github.com/jackc/pgx/v5"
+)
+
+// accountExportJSON projects an export row without its archive
+const accountExportJSON = "to_jsonb(t) - 'archive'"
+
+type accountExportRepository struct {
+	db *DB
+}
+
+// NewAccountExportRepository creates a new Postgres-backed account export repository
+func NewAccountExportRepository(db *DB) repository.AccountExportRepository {
+	return &accountExportRepository{db: db}
+}
+
+func (r *accountExportRepository) Create(ctx context.Context, export *models.AccountExport) (*models.AccountExport, error) {
+	data := map[string]interface{}{
+		"user_id": export.UserID,
+		"status":  export.Status,
+	}
+
+	sql, args := insertSQL("account_exports", []map[string]interface{}{data}, "", accountExportJSON)
+	created, err := selectOne[models.AccountExport](ctx, r.db.conn(ctx), sql, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create account export: %w", err)
+	}
+
+	if created == nil {
+		return nil, fmt.Errorf("no account export returned")
+	}
+
+	return created, nil
+}
+
+func (r *accountExportRepository) GetByID(ctx context.Context, id string) (*models.AccountExport, error) {
+	export, err := selectOne[models.AccountExport](ctx, r.db.conn(ctx),
+		`SELECT `+accountExportJSON+` FROM account_exports t WHERE t.id = $1`, id)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get account export: %w", err)
+	}
+
+	if export == nil {
+		return nil, fmt.Errorf("account export not found")
+	}
+
+	return export, nil
+}
+
+func (r *accountExportRepository) GetArchive(ctx context.Context, id string) ([]byte, error) {
+	var archive []byte
+	err := r.db.conn(ctx).QueryRow(ctx,
+		`SELECT archive FROM account_exports WHERE id = $1 AND archive IS NOT NULL`, id).Scan(&archive)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, fmt.Errorf("account export archive not found")
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get account export archive: %w", err)
+	}
+
+	return archive, nil
+}
+
+func (r *accountExportRepository) UpdateStatus(ctx context.Context, export *models.AccountExport, archive []byte) (*models.AccountExport, error) {
+	data := map[string]interface{}{
+		"status":       export.Status,
+		"size_bytes":   export.SizeBytes,
+		"error":        export.Error,
+		"completed_at": export.CompletedAt,
+		"expires_at":   export.ExpiresAt,
+	}
+	if archive != nil {
+		data["archive"] = archive
+	}
+
+	sql, args := updateSQL("account_exports", data, "t.id = $1", []any{export.ID}, accountExportJSON)
+	updated, err := selectOne[models.AccountExport](ctx, r.db.conn(ctx), sql, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to update account export: %w", err)
+	}
+
+	if updated == nil {
+		return nil, fmt.Errorf("account export not found")
+	}
+
+	return updated, nil
+}
+
+func (r *accountExportRepository) DeleteByUserID(ctx context.Context, userID string) error {
+	if _, err := r.db.conn(ctx).Exec(ctx, `DELETE FROM account_exports WHERE user_id = $1`, userID); err != nil {
+		return fmt.Errorf("failed to delete account exports: %w", err)
+	}
+
+	return nil
+}
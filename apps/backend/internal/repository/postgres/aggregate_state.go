@@ -0,0 +1,115 @@
+package postgres
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/JonnyWalker81/trendy/backend/internal/models"
+	"github.com/JonnyWalker81/trendy/backend/internal/repository"
+)
+
+// aggregatedEventJSON projects an aggregated_events row with its DATE column
+// as a UTC timestamp so it decodes into time.Time
+const aggregatedEventJSON = `to_jsonb(t) || jsonb_build_object('date', t.date::timestamp AT TIME ZONE 'UTC')`
+
+type aggregateStateRepository struct {
+	db *DB
+}
+
+// NewAggregateStateRepository creates a new Postgres-backed aggregate state repository
+func NewAggregateStateRepository(db *DB) repository.AggregateStateRepository {
+	return &aggregateStateRepository{db: db}
+}
+
+func (r *aggregateStateRepository) GetByUserID(ctx context.Context, userID string) (*models.AggregateState, error) {
+	state, err := selectOne[models.AggregateState](ctx, r.db.conn(ctx),
+		`SELECT to_jsonb(t) FROM aggregate_states t WHERE t.user_id = $1`, userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get aggregate state: %w", err)
+	}
+
+	return state, nil
+}
+
+func (r *aggregateStateRepository) Upsert(ctx context.Context, state *models.AggregateState) error {
+	data := map[string]interface{}{
+		"user_id":  state.UserID,
+		"cursor":   state.Cursor,
+		"timezone": state.Timezone,
+	}
+
+	suffix := `ON CONFLICT (user_id) DO UPDATE SET
+		cursor = EXCLUDED.cursor,
+		timezone = EXCLUDED.timezone`
+
+	sql, args := insertSQL("aggregate_states", []map[string]interface{}{data}, suffix, "")
+	if _, err := r.db.conn(ctx).Exec(ctx, sql, args...); err != nil {
+		return fmt.Errorf("failed to upsert aggregate state: %w", err)
+	}
+
+	return nil
+}
+
+func (r *aggregateStateRepository) GetEvents(ctx context.Context, userID string, eventIDs []string) ([]models.AggregatedEvent, error) {
+	if len(eventIDs) == 0 {
+		return []models.AggregatedEvent{}, nil
+	}
+
+	events, err := selectJSON[models.AggregatedEvent](ctx, r.db.conn(ctx),
+		`SELECT `+aggregatedEventJSON+` FROM aggregated_events t
+		WHERE t.user_id = $1 AND t.event_id = ANY($2::text[]::uuid[])`, userID, eventIDs)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get aggregated events: %w", err)
+	}
+
+	return events, nil
+}
+
+func (r *aggregateStateRepository) UpsertEvents(ctx context.Context, events []models.AggregatedEvent) error {
+	if len(events) == 0 {
+		return nil
+	}
+
+	rows := make([]map[string]interface{}, len(events))
+	for i, e := range events {
+		rows[i] = map[string]interface{}{
+			"event_id":      e.EventID,
+			"user_id":       e.UserID,
+			"event_type_id": e.EventTypeID,
+			"date":          e.Date.Format("2006-01-02"),
+		}
+	}
+
+	suffix := `ON CONFLICT (event_id) DO UPDATE SET
+		event_type_id = EXCLUDED.event_type_id,
+		date = EXCLUDED.date`
+
+	sql, args := insertSQL("aggregated_events", rows, suffix, "")
+	if _, err := r.db.conn(ctx).Exec(ctx, sql, args...); err != nil {
+		return fmt.Errorf("failed to upsert aggregated events: %w", err)
+	}
+
+	return nil
+}
+
+func (r *aggregateStateRepository) DeleteEvents(ctx context.Context, userID string, eventIDs []string) error {
+	if len(eventIDs) == 0 {
+		return nil
+	}
+
+	if _, err := r.db.conn(ctx).Exec(ctx,
+		`DELETE FROM aggregated_events WHERE user_id = $1 AND event_id = ANY($2::text[]::uuid[])`, userID, eventIDs); err != nil {
+		return fmt.Errorf("failed to delete aggregated events: %w", err)
+	}
+	return nil
+}
+
+func (r *aggregateStateRepository) DeleteByUserID(ctx context.Context, userID string) error {
+	if _, err := r.db.conn(ctx).Exec(ctx, `DELETE FROM aggregated_events WHERE user_id = $1`, userID); err != nil {
+		return fmt.Errorf("failed to delete aggregated events: %w", err)
+	}
+	if _, err := r.db.conn(ctx).Exec(ctx, `DELETE FROM aggregate_states WHERE user_id = $1`, userID); err != nil {
+		return fmt.Errorf("failed to delete aggregate state: %w", err)
+	}
+	return nil
+}
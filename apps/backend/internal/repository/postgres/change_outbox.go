@@ -0,0 +1,45 @@
+package postgres
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/JonnyWalker81/trendy/backend/internal/models"
+	"github.com/JonnyWalker81/trendy/backend/internal/repository"
+)
+
+type changeOutboxRepository struct {
+	db *DB
+}
+
+// NewChangeOutboxRepository creates a new Postgres-backed change outbox
+// repository. Entries are appended in the same transaction as their write,
+// so Claim normally just clears the outbox.
+func NewChangeOutboxRepository(db *DB) repository.ChangeOutboxRepository {
+	return &changeOutboxRepository{db: db}
+}
+
+func (r *changeOutboxRepository) Claim(ctx context.Context, settledBefore time.Time, lease time.Duration, limit int) ([]models.OutboxEntry, error) {
+	entries, err := selectJSON[models.OutboxEntry](ctx, r.db.conn(ctx),
+		`SELECT entry FROM claim_change_outbox($1, $2, $3) entry`,
+		settledBefore, int(lease.Seconds()), limit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to claim change outbox: %w", err)
+	}
+	return entries, nil
+}
+
+func (r *changeOutboxRepository) Delete(ctx context.Context, id int64) error {
+	if _, err := r.db.conn(ctx).Exec(ctx, `DELETE FROM change_outbox WHERE id = $1`, id); err != nil {
+		return fmt.Errorf("failed to delete change outbox entry: %w", err)
+	}
+	return nil
+}
+
+func (r *changeOutboxRepository) DeleteByUserID(ctx context.Context, userID string) error {
+	if _, err := r.db.conn(ctx).Exec(ctx, `DELETE FROM change_outbox WHERE user_id = $1`, userID); err != nil {
+		return fmt.Errorf("failed to delete change outbox entries: %w", err)
+	}
+	return nil
+}
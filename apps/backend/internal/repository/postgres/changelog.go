@@ -0,0 +1,148 @@
+package postgres
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/JonnyWalker81/trendy/backend/internal/models"
+	"github.com/JonnyWalker81/trendy/backend/internal/repository"
+)
+
+type changeLogRepository struct {
+	db *DB
+}
+
+// NewChangeLogRepository creates a new Postgres-backed change log repository
+func NewChangeLogRepository(db *DB) repository.ChangeLogRepository {
+	return &changeLogRepository{db: db}
+}
+
+func (r *changeLogRepository) Append(ctx context.Context, input *models.ChangeLogInput) (int64, error) {
+	var data interface{}
+	if input.Data != nil {
+		dataBytes, err := json.Marshal(input.Data)
+		if err != nil {
+			return 0, fmt.Errorf("failed to marshal entity data: %w", err)
+		}
+		data = json.RawMessage(dataBytes)
+	}
+
+	var id int64
+	err := r.db.conn(ctx).QueryRow(ctx,
+		`INSERT INTO change_log (entity_type, operation, entity_id, user_id, data, deleted_at)
+		VALUES ($1, $2, $3, $4, $5, $6)
+		RETURNING id`,
+		string(input.EntityType), string(input.Operation), input.EntityID, input.UserID, data, input.DeletedAt,
+	).Scan(&id)
+	if err != nil {
+		return 0, fmt.Errorf("failed to append to change log: %w", err)
+	}
+
+	return id, nil
+}
+
+func (r *changeLogRepository) GetSince(ctx context.Context, userID string, cursor int64, limit int) (*models.ChangeFeedResponse, error) {
+	if limit <= 0 {
+		limit = 100
+	}
+	if limit > 500 {
+		limit = 500
+	}
+
+	// We fetch limit+1 to detect if there are more results
+	entries, err := selectJSON[models.ChangeEntry](ctx, r.db.conn(ctx),
+		`SELECT to_jsonb(t) FROM change_log t WHERE t.user_id = $1 AND t.id > $2 ORDER BY t.id ASC LIMIT $3`,
+		userID, cursor, limit+1)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query change log: %w", err)
+	}
+
+	hasMore := len(entries) > limit
+	if hasMore {
+		entries = entries[:limit]
+	}
+
+	// When there are no new entries, preserve the original cursor
+	// to prevent the client from treating the next sync as a first-time sync
+	nextCursor := cursor
+	if len(entries) > 0 {
+		nextCursor = entries[len(entries)-1].ID
+	}
+
+	return &models.ChangeFeedResponse{
+		Changes:    entries,
+		NextCursor: nextCursor,
+		HasMore:    hasMore,
+	}, nil
+}
+
+func (r *changeLogRepository) GetLatestCursor(ctx context.Context, userID string) (int64, error) {
+	var latest int64
+	if err := r.db.conn(ctx).QueryRow(ctx,
+		`SELECT GREATEST(
+			(SELECT COALESCE(MAX(id), 0) FROM change_log WHERE user_id = $1),
+			(SELECT COALESCE(MAX(cursor), 0) FROM change_log_horizons WHERE user_id = $1))`,
+		userID).Scan(&latest); err != nil {
+		return 0, fmt.Errorf("failed to query change log: %w", err)
+	}
+	return latest, nil
+}
+
+func (r *changeLogRepository) GetSettledCursor(ctx context.Context, userID string, before time.Time) (int64, error) {
+	var settled int64
+	if err := r.db.conn(ctx).QueryRow(ctx,
+		`SELECT COALESCE(
+			(SELECT MIN(id) - 1 FROM change_log WHERE user_id = $1 AND created_at >= $2),
+			(SELECT COALESCE(MAX(id), 0) FROM change_log WHERE user_id = $1),
+			0)`,
+		userID, before).Scan(&settled); err != nil {
+		return 0, fmt.Errorf("failed to query change log: %w", err)
+	}
+
+	horizon, err := r.GetHorizon(ctx, userID)
+	if err != nil {
+		return 0, err
+	}
+
+	return max(settled, horizon), nil
+}
+
+func (r *changeLogRepository) GetHorizon(ctx context.Context, userID string) (int64, error) {
+	var horizon int64
+	if err := r.db.conn(ctx).QueryRow(ctx,
+		`SELECT COALESCE(MAX(cursor), 0) FROM change_log_horizons WHERE user_id = $1`, userID).Scan(&horizon); err != nil {
+		return 0, fmt.Errorf("failed to query change log horizon: %w", err)
+	}
+	return horizon, nil
+}
+
+// Compact and Prune call the functions from the retention migration so both
+// Postgres-backed storage backends share one definition
+
+func (r *changeLogRepository) Compact(ctx context.Context) (int64, error) {
+	var removed int64
+	if err := r.db.conn(ctx).QueryRow(ctx, `SELECT compact_change_log()`).Scan(&removed); err != nil {
+		return 0, fmt.Errorf("failed to compact change log: %w", err)
+	}
+	return removed, nil
+}
+
+func (r *changeLogRepository) Prune(ctx context.Context, before time.Time) (int64, error) {
+	var removed int64
+	if err := r.db.conn(ctx).QueryRow(ctx, `SELECT prune_change_log($1)`, before).Scan(&removed); err != nil {
+		return 0, fmt.Errorf("failed to prune change log: %w", err)
+	}
+	return removed, nil
+}
+
+func (r *changeLogRepository) DeleteByUserID(ctx context.Context, userID string) error {
+	if _, err := r.db.conn(ctx).Exec(ctx, `DELETE FROM change_log WHERE user_id = $1`, userID); err != nil {
+		return fmt.Errorf("failed to delete change log: %w", err)
+	}
+	if _, err := r.db.conn(ctx).Exec(ctx, `DELETE FROM change_log_horizons WHERE user_id = $1`, userID); err != nil {
+		return fmt.Errorf("failed to delete change log horizon: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,162 @@
+package postgres
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/JonnyWalker81/trendy/backend/internal/models"
+	"github.com/JonnyWalker81/trendy/backend/internal/repository"
+)
+
+// dailyAggregateJSON projects a daily_aggregates row with its DATE column as
+// a UTC timestamp so it decodes into time.Time
+const dailyAggregateJSON = `to_jsonb(t) || jsonb_build_object('date', t.date::timestamp AT TIME ZONE 'UTC')`
+
+type dailyAggregateRepository struct {
+	db *DB
+}
+
+// NewDailyAggregateRepository creates a new Postgres-backed daily aggregate repository
+func NewDailyAggregateRepository(db *DB) repository.DailyAggregateRepository {
+	return &dailyAggregateRepository{db: db}
+}
+
+// upsertDailyAggregatesSQL builds an upsert keyed on (user_id, date, event_type_id)
+func upsertDailyAggregatesSQL(aggs []models.DailyAggregate, returning string) (string, []any, error) {
+	rows := make([]map[string]interface{}, len(aggs))
+	for i, agg := range aggs {
+		var propertyAggregates interface{} = map[string]models.PropAgg{}
+		if len(agg.PropertyAggregates) > 0 {
+			propertyAggregates = agg.PropertyAggregates
+		}
+		encoded, err := jsonb(propertyAggregates)
+		if err != nil {
+			return "", nil, fmt.Errorf("failed to marshal property aggregates: %w", err)
+		}
+
+		rows[i] = map[string]interface{}{
+			"user_id":                agg.UserID,
+			"date":                   agg.Date.Format("2006-01-02"),
+			"event_type_id":          agg.EventTypeID,
+			"event_count":            agg.EventCount,
+			"total_duration_seconds": agg.TotalDurationSeconds,
+			"avg_numeric_value":      agg.AvgNumericValue,
+			"property_aggregates":    encoded,
+			"hour_counts":            agg.HourCounts,
+		}
+	}
+
+	suffix := `ON CONFLICT (user_id, date, event_type_id) DO UPDATE SET
+		event_count = EXCLUDED.event_count,
+		total_duration_seconds = EXCLUDED.total_duration_seconds,
+		avg_numeric_value = EXCLUDED.avg_numeric_value,
+		property_aggregates = EXCLUDED.property_aggregates,
+		hour_counts = EXCLUDED.hour_counts`
+
+	sql, args := insertSQL("daily_aggregates", rows, suffix, returning)
+	return sql, args, nil
+}
+
+func (r *dailyAggregateRepository) Upsert(ctx context.Context, agg *models.DailyAggregate) (*models.DailyAggregate, error) {
+	sql, args, err := upsertDailyAggregatesSQL([]models.DailyAggregate{*agg}, dailyAggregateJSON)
+	if err != nil {
+		return nil, err
+	}
+
+	upserted, err := selectOne[models.DailyAggregate](ctx, r.db.conn(ctx), sql, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to upsert daily aggregate: %w", err)
+	}
+
+	if upserted == nil {
+		return nil, fmt.Errorf("no daily aggregate returned")
+	}
+
+	return upserted, nil
+}
+
+func (r *dailyAggregateRepository) BulkUpsert(ctx context.Context, aggs []models.DailyAggregate) error {
+	if len(aggs) == 0 {
+		return nil
+	}
+
+	sql, args, err := upsertDailyAggregatesSQL(aggs, "")
+	if err != nil {
+		return err
+	}
+
+	if _, err := r.db.conn(ctx).Exec(ctx, sql, args...); err != nil {
+		return fmt.Errorf("failed to bulk upsert daily aggregates: %w", err)
+	}
+
+	return nil
+}
+
+func (r *dailyAggregateRepository) GetByUserID(ctx context.Context, userID string) ([]models.DailyAggregate, error) {
+	aggs, err := selectJSON[models.DailyAggregate](ctx, r.db.conn(ctx),
+		`SELECT `+dailyAggregateJSON+` FROM daily_aggregates t WHERE t.user_id = $1 ORDER BY t.date DESC`, userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get daily aggregates: %w", err)
+	}
+
+	return aggs, nil
+}
+
+func (r *dailyAggregateRepository) GetByUserIDAndDateRange(ctx context.Context, userID string, startDate, endDate time.Time) ([]models.DailyAggregate, error) {
+	aggs, err := selectJSON[models.DailyAggregate](ctx, r.db.conn(ctx),
+		`SELECT `+dailyAggregateJSON+` FROM daily_aggregates t
+		WHERE t.user_id = $1 AND t.date >= $2::date AND t.date <= $3::date
+		ORDER BY t.date ASC`,
+		userID, startDate.Format("2006-01-02"), endDate.Format("2006-01-02"))
+	if err != nil {
+		return nil, fmt.Errorf("failed to get daily aggregates: %w", err)
+	}
+
+	return aggs, nil
+}
+
+func (r *dailyAggregateRepository) GetByUserIDAndEventType(ctx context.Context, userID, eventTypeID string, startDate, endDate time.Time) ([]models.DailyAggregate, error) {
+	aggs, err := selectJSON[models.DailyAggregate](ctx, r.db.conn(ctx),
+		`SELECT `+dailyAggregateJSON+` FROM daily_aggregates t
+		WHERE t.user_id = $1 AND t.event_type_id = $2 AND t.date >= $3::date AND t.date <= $4::date
+		ORDER BY t.date ASC`,
+		userID, eventTypeID, startDate.Format("2006-01-02"), endDate.Format("2006-01-02"))
+	if err != nil {
+		return nil, fmt.Errorf("failed to get daily aggregates: %w", err)
+	}
+
+	return aggs, nil
+}
+
+func (r *dailyAggregateRepository) DeleteByUserID(ctx context.Context, userID string) error {
+	if _, err := r.db.conn(ctx).Exec(ctx, `DELETE FROM daily_aggregates WHERE user_id = $1`, userID); err != nil {
+		return fmt.Errorf("failed to delete daily aggregates: %w", err)
+	}
+	return nil
+}
+
+func (r *dailyAggregateRepository) DeleteByEventType(ctx context.Context, userID, eventTypeID string) error {
+	if _, err := r.db.conn(ctx).Exec(ctx,
+		`DELETE FROM daily_aggregates WHERE user_id = $1 AND event_type_id = $2`, userID, eventTypeID); err != nil {
+		return fmt.Errorf("failed to delete daily aggregates by event type: %w", err)
+	}
+	return nil
+}
+
+func (r *dailyAggregateRepository) DeleteOlderThan(ctx context.Context, userID string, date time.Time) error {
+	if _, err := r.db.conn(ctx).Exec(ctx,
+		`DELETE FROM daily_aggregates WHERE user_id = $1 AND date < $2::date`, userID, date.Format("2006-01-02")); err != nil {
+		return fmt.Errorf("failed to delete old daily aggregates: %w", err)
+	}
+	return nil
+}
+
+func (r *dailyAggregateRepository) Delete(ctx context.Context, userID, eventTypeID string, date time.Time) error {
+	if _, err := r.db.conn(ctx).Exec(ctx,
+		`DELETE FROM daily_aggregates WHERE user_id = $1 AND event_type_id = $2 AND date = $3::date`,
+		userID, eventTypeID, date.Format("2006-01-02")); err != nil {
+		return fmt.Errorf("failed to delete daily aggregate: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,261 @@
+// Package postgres implements the repository interfaces directly on top of
+// PostgreSQL using pgx, as an alternative to the Supabase/PostgREST backend.
+//
+// Rows are read with to_jsonb() and decoded into the models package types so
+// both backends share the same JSON field mapping. Writes made with a context
+// returned by WithinTx run inside a single database transaction.
+package postgres
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// DB wraps a pgx connection pool shared by all Postgres repositories
+type DB struct {
+	pool *pgxpool.Pool
+}
+
+// Open connects to Postgres using the given connection string and verifies
+// the connection with a ping
+func Open(ctx context.Context, databaseURL string) (*DB, error) {
+	pool, err := pgxpool.New(ctx, databaseURL)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create connection pool: %w", err)
+	}
+
+	if err := pool.Ping(ctx); err != nil {
+		pool.Close()
+		return nil, fmt.Errorf("failed to connect to database: %w", err)
+	}
+
+	return &DB{pool: pool}, nil
+}
+
+// Close releases all pooled connections
+func (db *DB) Close() {
+	db.pool.Close()
+}
+
+// querier is the subset of pgx shared by *pgxpool.Pool and pgx.Tx
+type querier interface {
+	Exec(ctx context.Context, sql string, args ...any) (pgconn.CommandTag, error)
+	Query(ctx context.Context, sql string, args ...any) (pgx.Rows, error)
+	QueryRow(ctx context.Context, sql string, args ...any) pgx.Row
+}
+
+type txKey struct{}
+
+// conn returns the transaction bound to ctx, or the pool when there is none
+func (db *DB) conn(ctx context.Context) querier {
+	if tx, ok := ctx.Value(txKey{}).(pgx.Tx); ok {
+		return tx
+	}
+	return db.pool
+}
+
+// WithinTx runs fn inside a database transaction. Repository calls made with
+// the context passed to fn join the transaction. The transaction is committed
+// if fn returns nil and rolled back otherwise. Nested calls reuse the outer
+// transaction.
+func (db *DB) WithinTx(ctx context.Context, fn func(ctx context.Context) error) error {
+	return db.within(ctx, pgx.TxOptions{}, fn)
+}
+
+// WithinSnapshot runs fn inside a read-only repeatable read transaction, so
+// every query made with the context passed to fn sees the same snapshot.
+// Nested calls reuse the outer transaction.
+func (db *DB) WithinSnapshot(ctx context.Context, fn func(ctx context.Context) error) error {
+	return db.within(ctx, pgx.TxOptions{IsoLevel: pgx.RepeatableRead, AccessMode: pgx.ReadOnly}, fn)
+}
+
+func (db *DB) within(ctx context.Context, opts pgx.TxOptions, fn func(ctx context.Context) error) error {
+	if _, ok := ctx.Value(txKey{}).(pgx.Tx); ok {
+		return fn(ctx)
+	}
+
+	tx, err := db.pool.BeginTx(ctx, opts)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+
+	if err := fn(context.WithValue(ctx, txKey{}, tx)); err != nil {
+		if rbErr := tx.Rollback(ctx); rbErr != nil && !errors.Is(rbErr, pgx.ErrTxClosed) {
+			return fmt.Errorf("%w (rollback failed: %v)", err, rbErr)
+		}
+		return err
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return fmt.Errorf("failed to commit transaction: %w", err)
+	}
+
+	return nil
+}
+
+// selectJSON runs a query whose single column is a JSON document and decodes
+// each row into T
+func selectJSON[T any](ctx context.Context, q querier, sql string, args ...any) ([]T, error) {
+	rows, err := q.Query(ctx, sql, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	result := make([]T, 0)
+	for rows.Next() {
+		var raw []byte
+		if err := rows.Scan(&raw); err != nil {
+			return nil, err
+		}
+		var item T
+		if err := json.Unmarshal(raw, &item); err != nil {
+			return nil, fmt.Errorf("failed to unmarshal row: %w", err)
+		}
+		result = append(result, item)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return result, nil
+}
+
+// selectOne runs a query like selectJSON and returns the first row, or nil
+// when the query matched nothing
+func selectOne[T any](ctx context.Context, q querier, sql string, args ...any) (*T, error) {
+	items, err := selectJSON[T](ctx, q, sql, args...)
+	if err != nil {
+		return nil, err
+	}
+	if len(items) == 0 {
+		return nil, nil
+	}
+	return &items[0], nil
+}
+
+// insertSQL builds a multi-row INSERT for table aliased as t. Columns are the
+// union of keys across rows; a row missing a key inserts DEFAULT for it.
+// suffix is appended after VALUES (e.g. an ON CONFLICT clause) and returning
+// is the RETURNING projection.
+func insertSQL(table string, rows []map[string]interface{}, suffix, returning string) (string, []any) {
+	colSet := make(map[string]bool)
+	for _, row := range rows {
+		for col := range row {
+			colSet[col] = true
+		}
+	}
+	columns := make([]string, 0, len(colSet))
+	for col := range colSet {
+		columns = append(columns, col)
+	}
+	sort.Strings(columns)
+
+	args := make([]any, 0, len(rows)*len(columns))
+	tuples := make([]string, 0, len(rows))
+	for _, row := range rows {
+		values := make([]string, len(columns))
+		for i, col := range columns {
+			value, ok := row[col]
+			if !ok {
+				values[i] = "DEFAULT"
+				continue
+			}
+			args = append(args, value)
+			values[i] = fmt.Sprintf("$%d", len(args))
+		}
+		tuples = append(tuples, "("+strings.Join(values, ", ")+")")
+	}
+
+	quoted := make([]string, len(columns))
+	for i, col := range columns {
+		quoted[i] = pgx.Identifier{col}.Sanitize()
+	}
+
+	sql := fmt.Sprintf("INSERT INTO %s AS t (%s) VALUES %s", table, strings.Join(quoted, ", "), strings.Join(tuples, ", "))
+	if suffix != "" {
+		sql += " " + suffix
+	}
+	if returning != "" {
+		sql += " RETURNING " + returning
+	}
+	return sql, args
+}
+
+// updateSQL builds an UPDATE for table aliased as t that sets every column in
+// fields. The where clause may reference $1..$n for whereArgs; field values
+// are numbered after them.
+func updateSQL(table string, fields map[string]interface{}, where string, whereArgs []any, returning string) (string, []any) {
+	columns := make([]string, 0, len(fields))
+	for col := range fields {
+		columns = append(columns, col)
+	}
+	sort.Strings(columns)
+
+	args := append([]any{}, whereArgs...)
+	sets := make([]string, 0, len(columns)+1)
+	for _, col := range columns {
+		args = append(args, fields[col])
+		sets = append(sets, fmt.Sprintf("%s = $%d", pgx.Identifier{col}.Sanitize(), len(args)))
+	}
+
+	sql := fmt.Sprintf("UPDATE %s AS t SET %s WHERE %s", table, strings.Join(sets, ", "), where)
+	if returning != "" {
+		sql += " RETURNING " + returning
+	}
+	return sql, args
+}
+
+// jsonb marshals v for a JSONB column. Values are pre-encoded so strings and
+// other scalars are stored as JSON rather than passed through as raw text.
+// A nil value maps to SQL NULL.
+func jsonb(v interface{}) (interface{}, error) {
+	if v == nil {
+		return nil, nil
+	}
+	data, err := json.Marshal(v)
+	if err != nil {
+		return nil, err
+	}
+	return json.RawMessage(data), nil
+}
+
+// excludedSet builds an ON CONFLICT DO UPDATE assignment list that copies
+// each column from the proposed row
+func excludedSet(columns map[string]interface{}) string {
+	names := make([]string, 0, len(columns))
+	for col := range columns {
+		names = append(names, col)
+	}
+	sort.Strings(names)
+
+	sets := make([]string, len(names))
+	for i, col := range names {
+		quoted := pgx.Identifier{col}.Sanitize()
+		sets[i] = fmt.Sprintf("%s = EXCLUDED.%s", quoted, quoted)
+	}
+	return strings.Join(sets, ", ")
+}
+
+// decodeOne unmarshals a single JSON row into T
+func decodeOne[T any](raw []byte) (*T, error) {
+	var item T
+	if err := json.Unmarshal(raw, &item); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal row: %w", err)
+	}
+	return &item, nil
+}
+
+// isNoRows reports whether err means a query matched no rows
+func isNoRows(err error) bool {
+	return errors.Is(err, pgx.ErrNoRows)
+}
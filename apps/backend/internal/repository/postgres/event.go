@@ -0,0 +1,887 @@
+package postgres
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/JonnyWalker81/trendy/backend/internal/models"
+	"github.com/JonnyWalker81/trendy/backend/internal/repository"
+)
+
+// eventSelect selects events with their event type embedded, matching the
+// PostgREST "*,event_type:event_types(*)" projection
+const eventSelect = `SELECT to_jsonb(t) || jsonb_build_object('event_type', to_jsonb(et))
+FROM events t
+LEFT JOIN event_types et ON et.id = t.event_type_id`
+
+type eventRepository struct {
+	db *DB
+}
+
+// NewEventRepository creates a new Postgres-backed event repository
+func NewEventRepository(db *DB) repository.EventRepository {
+	return &eventRepository{db: db}
+}
+
+// buildEventData converts an Event model to a column map for inserts
+func buildEventData(event *models.Event) (map[string]interface{}, error) {
+	data := map[string]interface{}{
+		"user_id":             event.UserID,
+		"event_type_id":       event.EventTypeID,
+		"timestamp":           event.Timestamp,
+		"is_all_day":          event.IsAllDay,
+		"source_type":         event.SourceType,
+		"notes":               event.Notes,
+		"end_date":            event.EndDate,
+		"external_id":         event.ExternalID,
+		"original_title":      event.OriginalTitle,
+		"geofence_id":         event.GeofenceID,
+		"location_latitude":   event.LocationLatitude,
+		"location_longitude":  event.LocationLongitude,
+		"location_name":       event.LocationName,
+		"healthkit_sample_id": event.HealthKitSampleID,
+		"healthkit_category":  event.HealthKitCategory,
+	}
+
+	// Use client-provided ID if present (for offline-first/UUIDv7 support)
+	if event.ID != "" {
+		data["id"] = event.ID
+	}
+
+	// Properties column has NOT NULL constraint - use empty object {} if no properties
+	var properties interface{} = map[string]interface{}{}
+	if len(event.Properties) > 0 {
+		properties = event.Properties
+	}
+	encoded, err := jsonb(properties)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal properties: %w", err)
+	}
+	data["properties"] = encoded
+
+	return data, nil
+}
+
+func (r *eventRepository) insert(ctx context.Context, events []models.Event, suffix string) ([]models.Event, error) {
+	rows := make([]map[string]interface{}, 0, len(events))
+	for i := range events {
+		data, err := buildEventData(&events[i])
+		if err != nil {
+			return nil, err
+		}
+		rows = append(rows, data)
+	}
+
+	sql, args := insertSQL("events", rows, suffix, "to_jsonb(t)")
+	return selectJSON[models.Event](ctx, r.db.conn(ctx), sql, args...)
+}
+
+func (r *eventRepository) Create(ctx context.Context, event *models.Event) (*models.Event, error) {
+	created, err := r.insert(ctx, []models.Event{*event}, "")
+	if err != nil {
+		return nil, fmt.Errorf("failed to create event: %w", err)
+	}
+
+	if len(created) == 0 {
+		return nil, fmt.Errorf("no event returned")
+	}
+
+	return &created[0], nil
+}
+
+func (r *eventRepository) CreateBatch(ctx context.Context, events []models.Event) ([]models.Event, error) {
+	if len(events) == 0 {
+		return []models.Event{}, nil
+	}
+
+	created, err := r.insert(ctx, events, "")
+	if err != nil {
+		return nil, fmt.Errorf("failed to batch create events: %w", err)
+	}
+
+	return created, nil
+}
+
+func (r *eventRepository) GetByID(ctx context.Context, id string) (*models.Event, error) {
+	events, err := selectJSON[models.Event](ctx, r.db.conn(ctx), eventSelect+` WHERE t.id = $1 AND t.deleted_at IS NULL`, id)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get event: %w", err)
+	}
+
+	if len(events) == 0 {
+		return nil, fmt.Errorf("event not found")
+	}
+
+	return &events[0], nil
+}
+
+func (r *eventRepository) ClaimVersion(ctx context.Context, id string, updatedAt time.Time) (bool, error) {
+	// The version is compared and the row locked in one statement, so a
+	// concurrent writer waits and then sees the version it replaced
+	rows, err := r.db.conn(ctx).Query(ctx,
+		`SELECT 1 FROM events WHERE id = $1 AND updated_at = $2 AND deleted_at IS NULL FOR UPDATE`, id, updatedAt)
+	if err != nil {
+		return false, fmt.Errorf("failed to claim event: %w", err)
+	}
+	defer rows.Close()
+
+	claimed := rows.Next()
+	if err := rows.Err(); err != nil {
+		return false, fmt.Errorf("failed to claim event: %w", err)
+	}
+
+	return claimed, nil
+}
+
+func (r *eventRepository) GetByUserID(ctx context.Context, userID string, limit, offset int) ([]models.Event, error) {
+	events, err := selectJSON[models.Event](ctx, r.db.conn(ctx),
+		eventSelect+` WHERE t.user_id = $1 AND t.deleted_at IS NULL ORDER BY t.timestamp DESC LIMIT $2 OFFSET $3`,
+		userID, limit, offset)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get events: %w", err)
+	}
+
+	return events, nil
+}
+
+func (r *eventRepository) GetByUserIDAndDateRange(ctx context.Context, userID string, startDate, endDate time.Time) ([]models.Event, error) {
+	events, err := selectJSON[models.Event](ctx, r.db.conn(ctx),
+		eventSelect+` WHERE t.user_id = $1 AND t.deleted_at IS NULL AND t.timestamp >= $2 AND t.timestamp <= $3 ORDER BY t.timestamp DESC`,
+		userID, startDate, endDate)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get events: %w", err)
+	}
+
+	return events, nil
+}
+
+func (r *eventRepository) GetForExport(ctx context.Context, userID string, startDate, endDate *time.Time, eventTypeIDs []string) ([]models.Event, error) {
+	sql := eventSelect + ` WHERE t.user_id = $1 AND t.deleted_at IS NULL`
+	args := []any{userID}
+
+	if startDate != nil && endDate != nil {
+		args = append(args, *startDate, *endDate)
+		sql += fmt.Sprintf(` AND t.timestamp >= $%d AND t.timestamp <= $%d`, len(args)-1, len(args))
+	}
+
+	if len(eventTypeIDs) > 0 {
+		args = append(args, eventTypeIDs)
+		sql += fmt.Sprintf(` AND t.event_type_id = ANY($%d::text[]::uuid[])`, len(args))
+	}
+
+	sql += ` ORDER BY t.timestamp DESC`
+
+	events, err := selectJSON[models.Event](ctx, r.db.conn(ctx), sql, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get events for export: %w", err)
+	}
+
+	return events, nil
+}
+
+func (r *eventRepository) GetForExportPage(ctx context.Context, userID string, startDate, endDate *time.Time, eventTypeIDs []string, after *models.EventCursor, limit int) ([]models.Event, error) {
+	sql := eventSelect + ` WHERE t.user_id = $1 AND t.deleted_at IS NULL`
+	args := []any{userID}
+
+	if startDate != nil && endDate != nil {
+		args = append(args, *startDate, *endDate)
+		sql += fmt.Sprintf(` AND t.timestamp >= $%d AND t.timestamp <= $%d`, len(args)-1, len(args))
+	}
+
+	if len(eventTypeIDs) > 0 {
+		args = append(args, eventTypeIDs)
+		sql += fmt.Sprintf(` AND t.event_type_id = ANY($%d::text[]::uuid[])`, len(args))
+	}
+
+	if after != nil {
+		args = append(args, after.Timestamp, after.ID)
+		sql += fmt.Sprintf(` AND (t.timestamp, t.id) < ($%d, $%d::uuid)`, len(args)-1, len(args))
+	}
+
+	args = append(args, limit)
+	sql += fmt.Sprintf(` ORDER BY t.timestamp DESC, t.id DESC LIMIT $%d`, len(args))
+
+	events, err := selectJSON[models.Event](ctx, r.db.conn(ctx), sql, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get events for export: %w", err)
+	}
+
+	return events, nil
+}
+
+var sqlOperators = map[models.PropertyOperator]string{
+	models.PropertyOpEq:  "=",
+	models.PropertyOpNe:  "<>",
+	models.PropertyOpGt:  ">",
+	models.PropertyOpGte: ">=",
+	models.PropertyOpLt:  "<",
+	models.PropertyOpLte: "<=",
+}
+
+func (r *eventRepository) GetPage(ctx context.Context, userID string, filter *models.EventFilter, after *models.EventCursor, limit int) ([]models.Event, error) {
+	sql := eventSelect + ` WHERE t.user_id = $1 AND t.deleted_at IS NULL`
+	args := []any{userID}
+
+	if len(filter.EventTypeIDs) > 0 {
+		args = append(args, filter.EventTypeIDs)
+		sql += fmt.Sprintf(` AND t.event_type_id = ANY($%d::text[]::uuid[])`, len(args))
+	}
+	if filter.StartDate != nil {
+		args = append(args, *filter.StartDate)
+		sql += fmt.Sprintf(` AND t.timestamp >= $%d`, len(args))
+	}
+	if filter.EndDate != nil {
+		args = append(args, *filter.EndDate)
+		sql += fmt.Sprintf(` AND t.timestamp <= $%d`, len(args))
+	}
+	if filter.SourceType != "" {
+		args = append(args, filter.SourceType)
+		sql += fmt.Sprintf(` AND t.source_type = $%d`, len(args))
+	}
+	if filter.GeofenceID != "" {
+		args = append(args, filter.GeofenceID)
+		sql += fmt.Sprintf(` AND t.geofence_id = $%d::uuid`, len(args))
+	}
+	if filter.HasNotes != nil {
+		if *filter.HasNotes {
+			sql += ` AND COALESCE(t.notes, '') <> ''`
+		} else {
+			sql += ` AND COALESCE(t.notes, '') = ''`
+		}
+	}
+	for _, p := range filter.Properties {
+		op, ok := sqlOperators[p.Op]
+		if !ok {
+			return nil, fmt.Errorf("unsupported property operator %q", p.Op)
+		}
+		args = append(args, p.Key, p.Value)
+		key, value := len(args)-1, len(args)
+		if _, ok := p.Number(); ok {
+			// CASE keeps the cast from running on non-numeric values
+			sql += fmt.Sprintf(` AND CASE WHEN jsonb_typeof(t.properties->$%d->'value') = 'number'
+				THEN (t.properties->$%d->>'value')::numeric %s $%d::numeric ELSE false END`, key, key, op, value)
+		} else {
+			sql += fmt.Sprintf(` AND t.properties->$%d->>'value' %s $%d`, key, op, value)
+		}
+	}
+	if filter.Search != nil {
+		args = append(args, filter.Search.TSQuery())
+		sql += fmt.Sprintf(` AND t.search_vector @@ to_tsquery('simple', $%d)`, len(args))
+	}
+
+	if after != nil {
+		args = append(args, after.Timestamp, after.ID)
+		sql += fmt.Sprintf(` AND (t.timestamp, t.id) < ($%d, $%d::uuid)`, len(args)-1, len(args))
+	}
+
+	args = append(args, limit)
+	sql += fmt.Sprintf(` ORDER BY t.timestamp DESC, t.id DESC LIMIT $%d`, len(args))
+
+	events, err := selectJSON[models.Event](ctx, r.db.conn(ctx), sql, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get events: %w", err)
+	}
+
+	return events, nil
+}
+
+func (r *eventRepository) Update(ctx context.Context, id string, event *models.Event) (*models.Event, error) {
+	data := make(map[string]interface{})
+
+	if event.EventTypeID != "" {
+		data["event_type_id"] = event.EventTypeID
+	}
+	if !event.Timestamp.IsZero() {
+		data["timestamp"] = event.Timestamp
+	}
+	if event.Notes != nil {
+		data["notes"] = *event.Notes
+	}
+	// Always include is_all_day since it's a boolean
+	data["is_all_day"] = event.IsAllDay
+
+	if event.EndDate != nil {
+		data["end_date"] = *event.EndDate
+	}
+	if event.SourceType != "" {
+		data["source_type"] = event.SourceType
+	}
+	if event.ExternalID != nil {
+		data["external_id"] = *event.ExternalID
+	}
+	if event.OriginalTitle != nil {
+		data["original_title"] = *event.OriginalTitle
+	}
+	if event.HealthKitSampleID != nil {
+		data["healthkit_sample_id"] = *event.HealthKitSampleID
+	}
+	if event.HealthKitCategory != nil {
+		data["healthkit_category"] = *event.HealthKitCategory
+	}
+	// nil means "don't update", empty map means "clear all properties"
+	if event.Properties != nil {
+		data["properties"] = event.Properties
+	}
+
+	return r.UpdateFields(ctx, id, data)
+}
+
+// UpdateFields updates specific fields of an event.
+// The fields map contains field names (snake_case) mapped to their new values.
+// A nil value explicitly sets the field to NULL in the database.
+// Only fields present in the map are updated.
+func (r *eventRepository) UpdateFields(ctx context.Context, id string, fields map[string]interface{}) (*models.Event, error) {
+	if len(fields) == 0 {
+		// No fields to update - just return the current event
+		return r.GetByID(ctx, id)
+	}
+
+	data := make(map[string]interface{}, len(fields))
+	for key, value := range fields {
+		data[key] = value
+	}
+	if properties, ok := data["properties"]; ok {
+		if properties == nil {
+			properties = map[string]interface{}{}
+		}
+		encoded, err := jsonb(properties)
+		if err != nil {
+			return nil, fmt.Errorf("failed to marshal properties: %w", err)
+		}
+		data["properties"] = encoded
+	}
+
+	sql, args := updateSQL("events", data, "t.id = $1", []any{id}, "to_jsonb(t)")
+	events, err := selectJSON[models.Event](ctx, r.db.conn(ctx), sql, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to update event: %w", err)
+	}
+
+	if len(events) == 0 {
+		return nil, fmt.Errorf("event not found")
+	}
+
+	return &events[0], nil
+}
+
+func (r *eventRepository) Delete(ctx context.Context, id string) error {
+	if _, err := r.db.conn(ctx).Exec(ctx, `DELETE FROM events WHERE id = $1`, id); err != nil {
+		return fmt.Errorf("failed to delete event: %w", err)
+	}
+	return nil
+}
+
+func (r *eventRepository) CountByEventType(ctx context.Context, userID string) (map[string]int64, error) {
+	rows, err := r.db.conn(ctx).Query(ctx,
+		`SELECT event_type_id::text, COUNT(*) FROM events WHERE user_id = $1 AND deleted_at IS NULL GROUP BY event_type_id`, userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to count events: %w", err)
+	}
+	defer rows.Close()
+
+	counts := make(map[string]int64)
+	for rows.Next() {
+		var eventTypeID string
+		var count int64
+		if err := rows.Scan(&eventTypeID, &count); err != nil {
+			return nil, fmt.Errorf("failed to count events: %w", err)
+		}
+		counts[eventTypeID] = count
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to count events: %w", err)
+	}
+
+	return counts, nil
+}
+
+// GetByIDs retrieves events by their IDs.
+func (r *eventRepository) GetByIDs(ctx context.Context, ids []string) ([]models.Event, error) {
+	if len(ids) == 0 {
+		return []models.Event{}, nil
+	}
+
+	events, err := selectJSON[models.Event](ctx, r.db.conn(ctx), eventSelect+` WHERE t.id = ANY($1::text[]::uuid[])`, ids)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get events by IDs: %w", err)
+	}
+
+	return events, nil
+}
+
+// Upsert creates or updates an event by ID.
+// Returns (event, wasCreated, error) where wasCreated indicates if this was a new insert.
+func (r *eventRepository) Upsert(ctx context.Context, event *models.Event) (*models.Event, bool, error) {
+	if event.ID == "" {
+		return nil, false, fmt.Errorf("event ID is required for upsert")
+	}
+
+	data, err := buildEventData(event)
+	if err != nil {
+		return nil, false, err
+	}
+
+	// Update every column except the key and owner on conflict. The WHERE clause
+	// keeps one user from overwriting another user's event with a colliding ID.
+	updates := make(map[string]interface{}, len(data))
+	for col := range data {
+		if col != "id" && col != "user_id" {
+			updates[col] = nil
+		}
+	}
+	suffix := "ON CONFLICT (id) DO UPDATE SET " + excludedSet(updates) + " WHERE t.user_id = EXCLUDED.user_id"
+
+	sql, args := insertSQL("events", []map[string]interface{}{data}, suffix, "to_jsonb(t), (xmax = 0)")
+
+	var raw []byte
+	var wasCreated bool
+	if err := r.db.conn(ctx).QueryRow(ctx, sql, args...).Scan(&raw, &wasCreated); err != nil {
+		if isNoRows(err) {
+			return nil, false, fmt.Errorf("event ID belongs to another user")
+		}
+		return nil, false, fmt.Errorf("failed to upsert event: %w", err)
+	}
+
+	upserted, err := decodeOne[models.Event](raw)
+	if err != nil {
+		return nil, false, err
+	}
+
+	return upserted, wasCreated, nil
+}
+
+// UpsertBatch creates or updates multiple events by ID.
+// Returns (events, results) where results contains per-item status.
+func (r *eventRepository) UpsertBatch(ctx context.Context, events []models.Event) ([]models.Event, []repository.UpsertResult, error) {
+	if len(events) == 0 {
+		return []models.Event{}, []repository.UpsertResult{}, nil
+	}
+
+	ids := make([]string, 0, len(events))
+	for _, event := range events {
+		if event.ID != "" {
+			ids = append(ids, event.ID)
+		}
+	}
+
+	existingEvents, err := r.GetByIDs(ctx, ids)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to check existing events: %w", err)
+	}
+
+	existingIDMap := make(map[string]models.Event)
+	for _, e := range existingEvents {
+		existingIDMap[e.ID] = e
+	}
+
+	toInsert := make([]models.Event, 0)
+	insertIndices := make([]int, 0)
+	results := make([]repository.UpsertResult, len(events))
+	var allResults []models.Event
+
+	for i, event := range events {
+		if event.ID == "" {
+			results[i] = repository.UpsertResult{
+				Index:  i,
+				Action: "failed",
+				Error:  fmt.Errorf("event ID is required for upsert"),
+			}
+			continue
+		}
+
+		if existingEvent, exists := existingIDMap[event.ID]; exists {
+			// DEDUPLICATED: Return the existing event as-is
+			allResults = append(allResults, existingEvent)
+			results[i] = repository.UpsertResult{
+				Index:  i,
+				ID:     event.ID,
+				Action: "deduplicated",
+			}
+			continue
+		}
+
+		toInsert = append(toInsert, event)
+		insertIndices = append(insertIndices, i)
+	}
+
+	if len(toInsert) == 0 {
+		return allResults, results, nil
+	}
+
+	// ON CONFLICT DO NOTHING covers events inserted concurrently since the lookup
+	inserted, err := r.insert(ctx, toInsert, "ON CONFLICT (id) DO NOTHING")
+	if err != nil {
+		for _, idx := range insertIndices {
+			results[idx] = repository.UpsertResult{
+				Index:  idx,
+				ID:     events[idx].ID,
+				Action: "failed",
+				Error:  err,
+			}
+		}
+		return allResults, results, nil
+	}
+
+	insertedByID := make(map[string]models.Event)
+	for _, e := range inserted {
+		insertedByID[e.ID] = e
+	}
+
+	for _, idx := range insertIndices {
+		eventID := events[idx].ID
+		if insertedEvent, ok := insertedByID[eventID]; ok {
+			allResults = append(allResults, insertedEvent)
+			results[idx] = repository.UpsertResult{
+				Index:  idx,
+				ID:     eventID,
+				Action: "created",
+			}
+		} else {
+			results[idx] = repository.UpsertResult{
+				Index:  idx,
+				ID:     eventID,
+				Action: "failed",
+				Error:  fmt.Errorf("event not returned from insert"),
+			}
+		}
+	}
+
+	return allResults, results, nil
+}
+
+// GetByHealthKitSampleIDs retrieves events by their HealthKit sample IDs for a user.
+func (r *eventRepository) GetByHealthKitSampleIDs(ctx context.Context, userID string, sampleIDs []string) ([]models.Event, error) {
+	if len(sampleIDs) == 0 {
+		return []models.Event{}, nil
+	}
+
+	events, err := selectJSON[models.Event](ctx, r.db.conn(ctx),
+		`SELECT to_jsonb(t) FROM events t WHERE t.user_id = $1 AND t.healthkit_sample_id = ANY($2::text[])`,
+		userID, sampleIDs)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get events by sample IDs: %w", err)
+	}
+
+	return events, nil
+}
+
+// UpsertHealthKitEvent inserts a HealthKit event unless an event with the same
+// sample ID or the same content already exists, in which case the existing
+// event is returned unchanged (HealthKit data is immutable).
+func (r *eventRepository) UpsertHealthKitEvent(ctx context.Context, event *models.Event) (*models.Event, bool, error) {
+	if event.HealthKitSampleID == nil || *event.HealthKitSampleID == "" {
+		return nil, false, fmt.Errorf("healthkit_sample_id is required for upsert")
+	}
+
+	upserted, createdIDs, err := r.UpsertHealthKitEventsBatch(ctx, []models.Event{*event})
+	if err != nil {
+		return nil, false, err
+	}
+
+	if len(upserted) == 0 {
+		return nil, false, fmt.Errorf("no event returned from upsert")
+	}
+
+	return &upserted[0], len(createdIDs) > 0, nil
+}
+
+// UpsertHealthKitEventsBatch inserts or updates multiple HealthKit events.
+// Returns (events, createdIDs, error) where createdIDs contains IDs of newly created events.
+//
+// Duplicates are detected by sample ID first and then by content
+// (event_type_id, timestamp, healthkit_category), matching the Supabase backend.
+func (r *eventRepository) UpsertHealthKitEventsBatch(ctx context.Context, events []models.Event) ([]models.Event, []string, error) {
+	if len(events) == 0 {
+		return []models.Event{}, []string{}, nil
+	}
+
+	userID := events[0].UserID
+
+	sampleIDs := make([]string, 0, len(events))
+	for _, event := range events {
+		if event.HealthKitSampleID != nil && *event.HealthKitSampleID != "" {
+			sampleIDs = append(sampleIDs, *event.HealthKitSampleID)
+		}
+	}
+
+	existingBySampleID, err := r.GetByHealthKitSampleIDs(ctx, userID, sampleIDs)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to check existing events by sample ID: %w", err)
+	}
+
+	existingSampleIDToEvent := make(map[string]models.Event)
+	for _, e := range existingBySampleID {
+		if e.HealthKitSampleID != nil {
+			existingSampleIDToEvent[*e.HealthKitSampleID] = e
+		}
+	}
+
+	eventsToCheckContent := make([]models.Event, 0)
+	for _, event := range events {
+		if event.HealthKitSampleID == nil || *event.HealthKitSampleID == "" {
+			continue
+		}
+		if _, exists := existingSampleIDToEvent[*event.HealthKitSampleID]; !exists {
+			eventsToCheckContent = append(eventsToCheckContent, event)
+		}
+	}
+
+	existingByContent, err := r.GetByHealthKitContent(ctx, userID, eventsToCheckContent)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to check existing events by content: %w", err)
+	}
+
+	toInsert := make([]models.Event, 0)
+	var createdIDs []string
+	var allResults []models.Event
+
+	for _, event := range events {
+		if event.HealthKitSampleID == nil || *event.HealthKitSampleID == "" {
+			continue // Skip events without sample ID
+		}
+
+		if existingEvent, exists := existingSampleIDToEvent[*event.HealthKitSampleID]; exists {
+			allResults = append(allResults, existingEvent)
+			continue
+		}
+
+		contentKey := repository.HealthKitContentKey(userID, event.EventTypeID, event.Timestamp, event.HealthKitCategory)
+		if existingEvent, exists := existingByContent[contentKey]; exists {
+			allResults = append(allResults, existingEvent)
+			continue
+		}
+
+		toInsert = append(toInsert, event)
+	}
+
+	if len(toInsert) > 0 {
+		inserted, err := r.insert(ctx, toInsert, "")
+		if err != nil {
+			return nil, nil, fmt.Errorf("failed to batch insert HealthKit events: %w", err)
+		}
+		allResults = append(allResults, inserted...)
+		for _, e := range inserted {
+			createdIDs = append(createdIDs, e.ID)
+		}
+	}
+
+	return allResults, createdIDs, nil
+}
+
+// GetByHealthKitContent retrieves HealthKit events by content to detect
+// content-based duplicates. Returns a map of content key -> existing event.
+func (r *eventRepository) GetByHealthKitContent(ctx context.Context, userID string, events []models.Event) (map[string]models.Event, error) {
+	result := make(map[string]models.Event)
+	if len(events) == 0 {
+		return result, nil
+	}
+
+	wanted := make(map[string]bool)
+	var minTime, maxTime time.Time
+	for _, event := range events {
+		wanted[repository.HealthKitContentKey(userID, event.EventTypeID, event.Timestamp, event.HealthKitCategory)] = true
+		if minTime.IsZero() || event.Timestamp.Before(minTime) {
+			minTime = event.Timestamp
+		}
+		if maxTime.IsZero() || event.Timestamp.After(maxTime) {
+			maxTime = event.Timestamp
+		}
+	}
+
+	// Expand range slightly to account for timestamp precision issues
+	existingEvents, err := selectJSON[models.Event](ctx, r.db.conn(ctx),
+		`SELECT to_jsonb(t) FROM events t
+		WHERE t.user_id = $1 AND t.source_type = 'healthkit' AND t.timestamp >= $2 AND t.timestamp <= $3`,
+		userID, minTime.Add(-time.Second), maxTime.Add(time.Second))
+	if err != nil {
+		return nil, fmt.Errorf("failed to get events by content: %w", err)
+	}
+
+	for _, existing := range existingEvents {
+		key := repository.HealthKitContentKey(userID, existing.EventTypeID, existing.Timestamp, existing.HealthKitCategory)
+		if wanted[key] {
+			result[key] = existing
+		}
+	}
+
+	return result, nil
+}
+
+// CountByUser returns total events for a user
+func (r *eventRepository) CountByUser(ctx context.Context, userID string) (int64, error) {
+	var count int64
+	if err := r.db.conn(ctx).QueryRow(ctx, `SELECT COUNT(*) FROM events WHERE user_id = $1 AND deleted_at IS NULL`, userID).Scan(&count); err != nil {
+		return 0, fmt.Errorf("failed to count events: %w", err)
+	}
+	return count, nil
+}
+
+// CountHealthKitByUser returns HealthKit events for a user
+func (r *eventRepository) CountHealthKitByUser(ctx context.Context, userID string) (int64, error) {
+	var count int64
+	if err := r.db.conn(ctx).QueryRow(ctx,
+		`SELECT COUNT(*) FROM events WHERE user_id = $1 AND deleted_at IS NULL AND healthkit_sample_id IS NOT NULL`, userID).Scan(&count); err != nil {
+		return 0, fmt.Errorf("failed to count HealthKit events: %w", err)
+	}
+	return count, nil
+}
+
+// GetLatestTimestamp returns the most recent event updated_at for a user
+func (r *eventRepository) GetLatestTimestamp(ctx context.Context, userID string) (*time.Time, error) {
+	var latest *time.Time
+	if err := r.db.conn(ctx).QueryRow(ctx,
+		`SELECT MAX(updated_at) FROM events WHERE user_id = $1 AND deleted_at IS NULL`, userID).Scan(&latest); err != nil {
+		return nil, fmt.Errorf("failed to get latest event timestamp: %w", err)
+	}
+	return latest, nil
+}
+
+// GetLatestHealthKitTimestamp returns the most recent HealthKit event timestamp for a user
+func (r *eventRepository) GetLatestHealthKitTimestamp(ctx context.Context, userID string) (*time.Time, error) {
+	var latest *time.Time
+	if err := r.db.conn(ctx).QueryRow(ctx,
+		`SELECT MAX(updated_at) FROM events WHERE user_id = $1 AND deleted_at IS NULL AND healthkit_sample_id IS NOT NULL`, userID).Scan(&latest); err != nil {
+		return nil, fmt.Errorf("failed to get latest HealthKit timestamp: %w", err)
+	}
+	return latest, nil
+}
+
+// SoftDelete moves an event to the trash
+func (r *eventRepository) SoftDelete(ctx context.Context, id string, deletedAt time.Time) error {
+	if _, err := r.db.conn(ctx).Exec(ctx,
+		`UPDATE events SET deleted_at = $2 WHERE id = $1 AND deleted_at IS NULL`, id, deletedAt); err != nil {
+		return fmt.Errorf("failed to delete event: %w", err)
+	}
+	return nil
+}
+
+// SoftDeleteByEventType moves the live events of an event type to the trash
+// and returns their IDs
+func (r *eventRepository) SoftDeleteByEventType(ctx context.Context, eventTypeID string, deletedAt time.Time) ([]string, error) {
+	ids, err := r.updateReturningIDs(ctx,
+		`UPDATE events SET deleted_at = $2 WHERE event_type_id = $1 AND deleted_at IS NULL RETURNING id::text`,
+		eventTypeID, deletedAt)
+	if err != nil {
+		return nil, fmt.Errorf("failed to delete events: %w", err)
+	}
+
+	return ids, nil
+}
+
+// updateReturningIDs runs an UPDATE ... RETURNING id::text statement
+func (r *eventRepository) updateReturningIDs(ctx context.Context, sql string, args ...any) ([]string, error) {
+	rows, err := r.db.conn(ctx).Query(ctx, sql, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	ids := make([]string, 0)
+	for rows.Next() {
+		var id string
+		if err := rows.Scan(&id); err != nil {
+			return nil, err
+		}
+		ids = append(ids, id)
+	}
+
+	return ids, rows.Err()
+}
+
+// GetDeletedByID retrieves a trashed event
+func (r *eventRepository) GetDeletedByID(ctx context.Context, id string) (*models.Event, error) {
+	event, err := selectOne[models.Event](ctx, r.db.conn(ctx), eventSelect+` WHERE t.id = $1 AND t.deleted_at IS NOT NULL`, id)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get event: %w", err)
+	}
+
+	if event == nil {
+		return nil, fmt.Errorf("event not found")
+	}
+
+	return event, nil
+}
+
+// GetDeletedByUserID returns a user's trashed events, most recently deleted first
+func (r *eventRepository) GetDeletedByUserID(ctx context.Context, userID string) ([]models.Event, error) {
+	events, err := selectJSON[models.Event](ctx, r.db.conn(ctx),
+		eventSelect+` WHERE t.user_id = $1 AND t.deleted_at IS NOT NULL ORDER BY t.deleted_at DESC`, userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get deleted events: %w", err)
+	}
+
+	return events, nil
+}
+
+// Restore takes a trashed event out of the trash
+func (r *eventRepository) Restore(ctx context.Context, id string) (*models.Event, error) {
+	if _, err := r.db.conn(ctx).Exec(ctx,
+		`UPDATE events SET deleted_at = NULL WHERE id = $1 AND deleted_at IS NOT NULL`, id); err != nil {
+		return nil, fmt.Errorf("failed to restore event: %w", err)
+	}
+
+	return r.GetByID(ctx, id)
+}
+
+// RestoreByEventType restores the events of an event type that were trashed
+// at deletedAt
+func (r *eventRepository) RestoreByEventType(ctx context.Context, eventTypeID string, deletedAt time.Time) ([]models.Event, error) {
+	ids, err := r.updateReturningIDs(ctx,
+		`UPDATE events SET deleted_at = NULL WHERE event_type_id = $1 AND deleted_at = $2 RETURNING id::text`,
+		eventTypeID, deletedAt)
+	if err != nil {
+		return nil, fmt.Errorf("failed to restore events: %w", err)
+	}
+
+	// Read the restored rows back with their event types embedded
+	events, err := r.getLiveByIDs(ctx, ids)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get restored events: %w", err)
+	}
+
+	return events, nil
+}
+
+// getLiveByIDs returns the live events with the given IDs, newest first
+func (r *eventRepository) getLiveByIDs(ctx context.Context, ids []string) ([]models.Event, error) {
+	if len(ids) == 0 {
+		return []models.Event{}, nil
+	}
+
+	return selectJSON[models.Event](ctx, r.db.conn(ctx),
+		eventSelect+` WHERE t.id = ANY($1::text[]::uuid[]) AND t.deleted_at IS NULL ORDER BY t.timestamp DESC`, ids)
+}
+
+// PurgeDeleted permanently removes events trashed before the given time
+func (r *eventRepository) PurgeDeleted(ctx context.Context, before time.Time) (int64, error) {
+	var purged int64
+	if err := r.db.conn(ctx).QueryRow(ctx, `SELECT purge_deleted_events($1)`, before).Scan(&purged); err != nil {
+		return 0, fmt.Errorf("failed to purge events: %w", err)
+	}
+	return purged, nil
+}
+
+// ReassignEventType moves the live events of one event type to another
+func (r *eventRepository) ReassignEventType(ctx context.Context, fromEventTypeID, toEventTypeID string) ([]models.Event, error) {
+	ids, err := r.updateReturningIDs(ctx,
+		`UPDATE events SET event_type_id = $2 WHERE event_type_id = $1 AND deleted_at IS NULL RETURNING id::text`,
+		fromEventTypeID, toEventTypeID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to reassign events: %w", err)
+	}
+
+	events, err := r.getLiveByIDs(ctx, ids)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get reassigned events: %w", err)
+	}
+
+	return events, nil
+}
+
+func (r *eventRepository) DeleteByUserID(ctx context.Context, userID string) error {
+	if _, err := r.db.conn(ctx).Exec(ctx, `DELETE FROM events WHERE user_id = $1`, userID); err != nil {
+		return fmt.Errorf("failed to delete events: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,225 @@
+package postgres
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/JonnyWalker81/trendy/backend/internal/models"
+	"github.com/JonnyWalker81/trendy/backend/internal/repository"
+)
+
+type eventTypeRepository struct {
+	db *DB
+}
+
+// NewEventTypeRepository creates a new Postgres-backed event type repository
+func NewEventTypeRepository(db *DB) repository.EventTypeRepository {
+	return &eventTypeRepository{db: db}
+}
+
+func (r *eventTypeRepository) Create(ctx context.Context, eventType *models.EventType) (*models.EventType, error) {
+	data := map[string]interface{}{
+		"user_id": eventType.UserID,
+		"name":    eventType.Name,
+		"color":   eventType.Color,
+		"icon":    eventType.Icon,
+	}
+
+	// Use client-provided ID if present (for offline-first/UUIDv7 support)
+	if eventType.ID != "" {
+		data["id"] = eventType.ID
+	}
+
+	sql, args := insertSQL("event_types", []map[string]interface{}{data}, "", "to_jsonb(t)")
+	created, err := selectOne[models.EventType](ctx, r.db.conn(ctx), sql, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create event type: %w", err)
+	}
+
+	if created == nil {
+		return nil, fmt.Errorf("no event type returned")
+	}
+
+	return created, nil
+}
+
+func (r *eventTypeRepository) GetByID(ctx context.Context, id string) (*models.EventType, error) {
+	eventType, err := selectOne[models.EventType](ctx, r.db.conn(ctx),
+		`SELECT to_jsonb(t) FROM event_types t WHERE t.id = $1 AND t.deleted_at IS NULL`, id)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get event type: %w", err)
+	}
+
+	if eventType == nil {
+		return nil, fmt.Errorf("event type not found")
+	}
+
+	return eventType, nil
+}
+
+func (r *eventTypeRepository) ClaimVersion(ctx context.Context, id string, updatedAt time.Time) (bool, error) {
+	// The version is compared and the row locked in one statement, so a
+	// concurrent writer waits and then sees the version it replaced
+	rows, err := r.db.conn(ctx).Query(ctx,
+		`SELECT 1 FROM event_types WHERE id = $1 AND updated_at = $2 AND deleted_at IS NULL FOR UPDATE`, id, updatedAt)
+	if err != nil {
+		return false, fmt.Errorf("failed to claim event type: %w", err)
+	}
+	defer rows.Close()
+
+	claimed := rows.Next()
+	if err := rows.Err(); err != nil {
+		return false, fmt.Errorf("failed to claim event type: %w", err)
+	}
+
+	return claimed, nil
+}
+
+func (r *eventTypeRepository) GetByUserID(ctx context.Context, userID string) ([]models.EventType, error) {
+	eventTypes, err := selectJSON[models.EventType](ctx, r.db.conn(ctx),
+		`SELECT to_jsonb(t) FROM event_types t WHERE t.user_id = $1 AND t.deleted_at IS NULL ORDER BY t.created_at ASC`, userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get event types: %w", err)
+	}
+
+	return eventTypes, nil
+}
+
+func (r *eventTypeRepository) Update(ctx context.Context, id string, eventType *models.EventType) (*models.EventType, error) {
+	data := make(map[string]interface{})
+
+	if eventType.Name != "" {
+		data["name"] = eventType.Name
+	}
+	if eventType.Color != "" {
+		data["color"] = eventType.Color
+	}
+	if eventType.Icon != "" {
+		data["icon"] = eventType.Icon
+	}
+
+	if len(data) == 0 {
+		return r.GetByID(ctx, id)
+	}
+
+	sql, args := updateSQL("event_types", data, "t.id = $1", []any{id}, "to_jsonb(t)")
+	updated, err := selectOne[models.EventType](ctx, r.db.conn(ctx), sql, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to update event type: %w", err)
+	}
+
+	if updated == nil {
+		return nil, fmt.Errorf("event type not found")
+	}
+
+	return updated, nil
+}
+
+func (r *eventTypeRepository) Delete(ctx context.Context, id string) error {
+	if _, err := r.db.conn(ctx).Exec(ctx, `DELETE FROM event_types WHERE id = $1`, id); err != nil {
+		return fmt.Errorf("failed to delete event type: %w", err)
+	}
+	return nil
+}
+
+// CountByUser returns total event types for a user
+func (r *eventTypeRepository) CountByUser(ctx context.Context, userID string) (int64, error) {
+	var count int64
+	if err := r.db.conn(ctx).QueryRow(ctx, `SELECT COUNT(*) FROM event_types WHERE user_id = $1 AND deleted_at IS NULL`, userID).Scan(&count); err != nil {
+		return 0, fmt.Errorf("failed to count event types: %w", err)
+	}
+	return count, nil
+}
+
+// GetLatestTimestamp returns the most recent event_type updated_at for a user
+func (r *eventTypeRepository) GetLatestTimestamp(ctx context.Context, userID string) (*time.Time, error) {
+	var latest *time.Time
+	if err := r.db.conn(ctx).QueryRow(ctx,
+		`SELECT MAX(updated_at) FROM event_types WHERE user_id = $1 AND deleted_at IS NULL`, userID).Scan(&latest); err != nil {
+		return nil, fmt.Errorf("failed to get latest event_type timestamp: %w", err)
+	}
+	return latest, nil
+}
+
+// SoftDelete moves an event type to the trash
+func (r *eventTypeRepository) SoftDelete(ctx context.Context, id string, deletedAt time.Time) error {
+	if _, err := r.db.conn(ctx).Exec(ctx,
+		`UPDATE event_types SET deleted_at = $2 WHERE id = $1 AND deleted_at IS NULL`, id, deletedAt); err != nil {
+		return fmt.Errorf("failed to delete event type: %w", err)
+	}
+	return nil
+}
+
+// GetDeletedByID retrieves a trashed event type
+func (r *eventTypeRepository) GetDeletedByID(ctx context.Context, id string) (*models.EventType, error) {
+	eventType, err := selectOne[models.EventType](ctx, r.db.conn(ctx),
+		`SELECT to_jsonb(t) FROM event_types t WHERE t.id = $1 AND t.deleted_at IS NOT NULL`, id)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get event type: %w", err)
+	}
+
+	if eventType == nil {
+		return nil, fmt.Errorf("event type not found")
+	}
+
+	return eventType, nil
+}
+
+// GetDeletedByUserID returns a user's trashed event types, most recently deleted first
+func (r *eventTypeRepository) GetDeletedByUserID(ctx context.Context, userID string) ([]models.EventType, error) {
+	eventTypes, err := selectJSON[models.EventType](ctx, r.db.conn(ctx),
+		`SELECT to_jsonb(t) FROM event_types t WHERE t.user_id = $1 AND t.deleted_at IS NOT NULL ORDER BY t.deleted_at DESC`, userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get deleted event types: %w", err)
+	}
+
+	return eventTypes, nil
+}
+
+// Restore takes a trashed event type out of the trash
+func (r *eventTypeRepository) Restore(ctx context.Context, id string) (*models.EventType, error) {
+	eventType, err := selectOne[models.EventType](ctx, r.db.conn(ctx),
+		`UPDATE event_types t SET deleted_at = NULL WHERE t.id = $1 AND t.deleted_at IS NOT NULL RETURNING to_jsonb(t)`, id)
+	if err != nil {
+		return nil, fmt.Errorf("failed to restore event type: %w", err)
+	}
+
+	if eventType == nil {
+		return nil, fmt.Errorf("event type not found")
+	}
+
+	return eventType, nil
+}
+
+// PurgeDeleted permanently removes event types trashed before the given time.
+// Their events and property definitions cascade.
+func (r *eventTypeRepository) PurgeDeleted(ctx context.Context, before time.Time) (int64, error) {
+	var purged int64
+	if err := r.db.conn(ctx).QueryRow(ctx, `SELECT purge_deleted_event_types($1)`, before).Scan(&purged); err != nil {
+		return 0, fmt.Errorf("failed to purge event types: %w", err)
+	}
+	return purged, nil
+}
+
+// SetArchived archives or unarchives an event type
+func (r *eventTypeRepository) SetArchived(ctx context.Context, id string, archivedAt *time.Time) (*models.EventType, error) {
+	eventType, err := selectOne[models.EventType](ctx, r.db.conn(ctx),
+		`UPDATE event_types t SET archived_at = $2 WHERE t.id = $1 AND t.deleted_at IS NULL RETURNING to_jsonb(t)`, id, archivedAt)
+	if err != nil {
+		return nil, fmt.Errorf("failed to archive event type: %w", err)
+	}
+
+	if eventType == nil {
+		return nil, fmt.Errorf("event type not found")
+	}
+
+	return eventType, nil
+}
+
+func (r *eventTypeRepository) DeleteByUserID(ctx context.Context, userID string) error {
+	if _, err := r.db.conn(ctx).Exec(ctx, `DELETE FROM event_types WHERE user_id = $1`, userID); err != nil {
+		return fmt.Errorf("failed to delete event types: %w", err)
+	}
+	return nil
+}
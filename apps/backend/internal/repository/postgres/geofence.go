@@ -0,0 +1,170 @@
+package postgres
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/JonnyWalker81/trendy/backend/internal/models"
+	"github.com/JonnyWalker81/trendy/backend/internal/repository"
+)
+
+type geofenceRepository struct {
+	db *DB
+}
+
+// NewGeofenceRepository creates a new Postgres-backed geofence repository
+func NewGeofenceRepository(db *DB) repository.GeofenceRepository {
+	return &geofenceRepository{db: db}
+}
+
+func (r *geofenceRepository) Create(ctx context.Context, geofence *models.Geofence) (*models.Geofence, error) {
+	data := map[string]interface{}{
+		"id":              geofence.ID, // Client-provided UUID
+		"user_id":         geofence.UserID,
+		"name":            geofence.Name,
+		"latitude":        geofence.Latitude,
+		"longitude":       geofence.Longitude,
+		"radius":          geofence.Radius,
+		"is_active":       geofence.IsActive != nil && *geofence.IsActive,
+		"notify_on_entry": geofence.NotifyOnEntry != nil && *geofence.NotifyOnEntry,
+		"notify_on_exit":  geofence.NotifyOnExit != nil && *geofence.NotifyOnExit,
+	}
+
+	if geofence.EventTypeEntryID != nil {
+		data["event_type_entry_id"] = *geofence.EventTypeEntryID
+	}
+	if geofence.EventTypeExitID != nil {
+		data["event_type_exit_id"] = *geofence.EventTypeExitID
+	}
+
+	sql, args := insertSQL("geofences", []map[string]interface{}{data}, "", "to_jsonb(t)")
+	created, err := selectOne[models.Geofence](ctx, r.db.conn(ctx), sql, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create geofence: %w", err)
+	}
+
+	if created == nil {
+		return nil, fmt.Errorf("no geofence returned")
+	}
+
+	return created, nil
+}
+
+func (r *geofenceRepository) GetByID(ctx context.Context, id string) (*models.Geofence, error) {
+	geofence, err := selectOne[models.Geofence](ctx, r.db.conn(ctx), `SELECT to_jsonb(t) FROM geofences t WHERE t.id = $1`, id)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get geofence: %w", err)
+	}
+
+	if geofence == nil {
+		return nil, fmt.Errorf("geofence not found")
+	}
+
+	return geofence, nil
+}
+
+func (r *geofenceRepository) ClaimVersion(ctx context.Context, id string, updatedAt time.Time) (bool, error) {
+	// The version is compared and the row locked in one statement, so a
+	// concurrent writer waits and then sees the version it replaced
+	rows, err := r.db.conn(ctx).Query(ctx,
+		`SELECT 1 FROM geofences WHERE id = $1 AND updated_at = $2 FOR UPDATE`, id, updatedAt)
+	if err != nil {
+		return false, fmt.Errorf("failed to claim geofence: %w", err)
+	}
+	defer rows.Close()
+
+	claimed := rows.Next()
+	if err := rows.Err(); err != nil {
+		return false, fmt.Errorf("failed to claim geofence: %w", err)
+	}
+
+	return claimed, nil
+}
+
+func (r *geofenceRepository) GetByUserID(ctx context.Context, userID string) ([]models.Geofence, error) {
+	geofences, err := selectJSON[models.Geofence](ctx, r.db.conn(ctx),
+		`SELECT to_jsonb(t) FROM geofences t WHERE t.user_id = $1 ORDER BY t.created_at DESC`, userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get geofences: %w", err)
+	}
+
+	return geofences, nil
+}
+
+func (r *geofenceRepository) GetActiveByUserID(ctx context.Context, userID string) ([]models.Geofence, error) {
+	geofences, err := selectJSON[models.Geofence](ctx, r.db.conn(ctx),
+		`SELECT to_jsonb(t) FROM geofences t WHERE t.user_id = $1 AND t.is_active ORDER BY t.created_at DESC`, userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get active geofences: %w", err)
+	}
+
+	return geofences, nil
+}
+
+func (r *geofenceRepository) Update(ctx context.Context, id string, geofence *models.Geofence) (*models.Geofence, error) {
+	data := make(map[string]interface{})
+
+	if geofence.Name != "" {
+		data["name"] = geofence.Name
+	}
+	if geofence.Latitude != 0 {
+		data["latitude"] = geofence.Latitude
+	}
+	if geofence.Longitude != 0 {
+		data["longitude"] = geofence.Longitude
+	}
+	if geofence.Radius != 0 {
+		data["radius"] = geofence.Radius
+	}
+
+	if geofence.IsActive != nil {
+		data["is_active"] = *geofence.IsActive
+	}
+	if geofence.NotifyOnEntry != nil {
+		data["notify_on_entry"] = *geofence.NotifyOnEntry
+	}
+	if geofence.NotifyOnExit != nil {
+		data["notify_on_exit"] = *geofence.NotifyOnExit
+	}
+
+	if geofence.EventTypeEntryID != nil {
+		data["event_type_entry_id"] = *geofence.EventTypeEntryID
+	}
+	if geofence.EventTypeExitID != nil {
+		data["event_type_exit_id"] = *geofence.EventTypeExitID
+	}
+	if geofence.IOSRegionIdentifier != nil {
+		data["ios_region_identifier"] = *geofence.IOSRegionIdentifier
+	}
+
+	if len(data) == 0 {
+		return r.GetByID(ctx, id)
+	}
+
+	sql, args := updateSQL("geofences", data, "t.id = $1", []any{id}, "to_jsonb(t)")
+	updated, err := selectOne[models.Geofence](ctx, r.db.conn(ctx), sql, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to update geofence: %w", err)
+	}
+
+	if updated == nil {
+		return nil, fmt.Errorf("geofence not found")
+	}
+
+	return updated, nil
+}
+
+func (r *geofenceRepository) Delete(ctx context.Context, id string) error {
+	if _, err := r.db.conn(ctx).Exec(ctx, `DELETE FROM geofences WHERE id = $1`, id); err != nil {
+		return fmt.Errorf("failed to delete geofence: %w", err)
+	}
+	return nil
+}
+
+func (r *geofenceRepository) DeleteByUserID(ctx context.Context, userID string) error {
+	if _, err := r.db.conn(ctx).Exec(ctx, `DELETE FROM geofences WHERE user_id = $1`, userID); err != nil {
+		return fmt.Errorf("failed to delete geofences: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,49 @@
+package postgres
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+
+	"github.com/JonnyWalker81/trendy/backend/internal/models"
+	"github.com/JonnyWalker81/trendy/backend/internal/repository"
+)
+
+type idempotencyRepository struct {
+	db *DB
+}
+
+// NewIdempotencyRepository creates a new Postgres-backed idempotency repository
+func NewIdempotencyRepository(db *DB) repository.IdempotencyRepository {
+	return &idempotencyRepository{db: db}
+}
+
+func (r *idempotencyRepository) Get(ctx context.Context, key, route, userID string) (*models.IdempotencyKey, error) {
+	record, err := selectOne[models.IdempotencyKey](ctx, r.db.conn(ctx),
+		`SELECT to_jsonb(t) FROM idempotency_keys t WHERE t.key = $1 AND t.route = $2 AND t.user_id = $3`,
+		key, route, userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query idempotency key: %w", err)
+	}
+
+	// Not found is not an error
+	return record, nil
+}
+
+func (r *idempotencyRepository) Store(ctx context.Context, key, route, userID string, responseBody []byte, statusCode int) error {
+	_, err := r.db.conn(ctx).Exec(ctx,
+		`INSERT INTO idempotency_keys (key, route, user_id, response_body, status_code) VALUES ($1, $2, $3, $4, $5)`,
+		key, route, userID, json.RawMessage(responseBody), statusCode)
+	if err != nil {
+		return fmt.Errorf("failed to store idempotency key: %w", err)
+	}
+
+	return nil
+}
+
+func (r *idempotencyRepository) DeleteByUserID(ctx context.Context, userID string) error {
+	if _, err := r.db.conn(ctx).Exec(ctx, `DELETE FROM idempotency_keys WHERE user_id = $1`, userID); err != nil {
+		return fmt.Errorf("failed to delete idempotency keys: %w", err)
+	}
+	return nil
+}
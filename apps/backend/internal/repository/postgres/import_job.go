@@ -0,0 +1,94 @@
+package postgres
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/JonnyWalker81/trendy/backend/internal/models"
+	"github.com/JonnyWalker81/trendy/backend/internal/repository"
+)
+
+type importJobRepository struct {
+	db *DB
+}
+
+// NewImportJobRepository creates a new Postgres-backed import job repository
+func NewImportJobRepository(db *DB) repository.ImportJobRepository {
+	return &importJobRepository{db: db}
+}
+
+func (r *importJobRepository) Create(ctx context.Context, job *models.ImportJob) (*models.ImportJob, error) {
+	data := map[string]interface{}{
+		"user_id":    job.UserID,
+		"status":     job.Status,
+		"format":     job.Format,
+		"total_rows": job.TotalRows,
+		"errors":     rowErrors(job),
+	}
+
+	sql, args := insertSQL("import_jobs", []map[string]interface{}{data}, "", "to_jsonb(t)")
+	created, err := selectOne[models.ImportJob](ctx, r.db.conn(ctx), sql, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create import job: %w", err)
+	}
+
+	if created == nil {
+		return nil, fmt.Errorf("no import job returned")
+	}
+
+	return created, nil
+}
+
+func (r *importJobRepository) GetByID(ctx context.Context, id string) (*models.ImportJob, error) {
+	job, err := selectOne[models.ImportJob](ctx, r.db.conn(ctx),
+		`SELECT to_jsonb(t) FROM import_jobs t WHERE t.id = $1`, id)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get import job: %w", err)
+	}
+
+	if job == nil {
+		return nil, fmt.Errorf("import job not found")
+	}
+
+	return job, nil
+}
+
+func (r *importJobRepository) UpdateProgress(ctx context.Context, job *models.ImportJob) (*models.ImportJob, error) {
+	data := map[string]interface{}{
+		"status":         job.Status,
+		"processed_rows": job.ProcessedRows,
+		"created_count":  job.CreatedCount,
+		"failed_count":   job.FailedCount,
+		"errors":         rowErrors(job),
+		"error":          job.Error,
+		"completed_at":   job.CompletedAt,
+	}
+
+	sql, args := updateSQL("import_jobs", data, "t.id = $1", []any{job.ID}, "to_jsonb(t)")
+	updated, err := selectOne[models.ImportJob](ctx, r.db.conn(ctx), sql, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to update import job: %w", err)
+	}
+
+	if updated == nil {
+		return nil, fmt.Errorf("import job not found")
+	}
+
+	return updated, nil
+}
+
+// rowErrors returns the job's row errors, never nil so the column stays a
+// JSON array
+func rowErrors(job *models.ImportJob) []models.ImportRowError {
+	if job.Errors == nil {
+		return []models.ImportRowError{}
+	}
+	return job.Errors
+}
+
+func (r *importJobRepository) DeleteByUserID(ctx context.Context, userID string) error {
+	if _, err := r.db.conn(ctx).Exec(ctx, `DELETE FROM import_jobs WHERE user_id = $1`, userID); err != nil {
+		return fmt.Errorf("failed to delete import jobs: %w", err)
+	}
+	return nil
+}
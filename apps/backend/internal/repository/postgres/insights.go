@@ -0,0 +1,149 @@
+package postgres
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/JonnyWalker81/trendy/backend/internal/models"
+	"github.com/JonnyWalker81/trendy/backend/internal/repository"
+)
+
+type insightRepository struct {
+	db *DB
+}
+
+// NewInsightRepository creates a new Postgres-backed insight repository
+func NewInsightRepository(db *DB) repository.InsightRepository {
+	return &insightRepository{db: db}
+}
+
+// buildInsightData converts an Insight model to a column map for inserts.
+// Every optional column is present so multi-row inserts share one column list.
+func buildInsightData(insight *models.Insight) (map[string]interface{}, error) {
+	metadata := map[string]interface{}{}
+	if len(insight.Metadata) > 0 {
+		metadata = insight.Metadata
+	}
+	encoded, err := jsonb(metadata)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal metadata: %w", err)
+	}
+
+	return map[string]interface{}{
+		"user_id":         insight.UserID,
+		"insight_type":    insight.InsightType,
+		"category":        insight.Category,
+		"title":           insight.Title,
+		"description":     insight.Description,
+		"metric_value":    insight.MetricValue,
+		"sample_size":     insight.SampleSize,
+		"confidence":      insight.Confidence,
+		"direction":       insight.Direction,
+		"computed_at":     insight.ComputedAt,
+		"valid_until":     insight.ValidUntil,
+		"event_type_a_id": insight.EventTypeAID,
+		"event_type_b_id": insight.EventTypeBID,
+		"property_key":    insight.PropertyKey,
+		"p_value":         insight.PValue,
+		"metadata":        encoded,
+	}, nil
+}
+
+func (r *insightRepository) Create(ctx context.Context, insight *models.Insight) (*models.Insight, error) {
+	data, err := buildInsightData(insight)
+	if err != nil {
+		return nil, err
+	}
+
+	sql, args := insertSQL("insights", []map[string]interface{}{data}, "", "to_jsonb(t)")
+	created, err := selectOne[models.Insight](ctx, r.db.conn(ctx), sql, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create insight: %w", err)
+	}
+
+	if created == nil {
+		return nil, fmt.Errorf("no insight returned")
+	}
+
+	return created, nil
+}
+
+func (r *insightRepository) BulkCreate(ctx context.Context, insights []models.Insight) error {
+	if len(insights) == 0 {
+		return nil
+	}
+
+	rows := make([]map[string]interface{}, len(insights))
+	for i := range insights {
+		data, err := buildInsightData(&insights[i])
+		if err != nil {
+			return err
+		}
+		rows[i] = data
+	}
+
+	sql, args := insertSQL("insights", rows, "", "")
+	if _, err := r.db.conn(ctx).Exec(ctx, sql, args...); err != nil {
+		return fmt.Errorf("failed to bulk create insights: %w", err)
+	}
+
+	return nil
+}
+
+func (r *insightRepository) GetByUserID(ctx context.Context, userID string) ([]models.Insight, error) {
+	insights, err := selectJSON[models.Insight](ctx, r.db.conn(ctx),
+		`SELECT to_jsonb(t) FROM insights t WHERE t.user_id = $1 ORDER BY t.computed_at DESC`, userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get insights: %w", err)
+	}
+
+	return insights, nil
+}
+
+func (r *insightRepository) GetValidByUserID(ctx context.Context, userID string) ([]models.Insight, error) {
+	insights, err := selectJSON[models.Insight](ctx, r.db.conn(ctx),
+		`SELECT to_jsonb(t) FROM insights t WHERE t.user_id = $1 AND t.valid_until > $2 ORDER BY t.computed_at DESC`,
+		userID, time.Now())
+	if err != nil {
+		return nil, fmt.Errorf("failed to get valid insights: %w", err)
+	}
+
+	return insights, nil
+}
+
+func (r *insightRepository) GetByType(ctx context.Context, userID string, insightType models.InsightType) ([]models.Insight, error) {
+	insights, err := selectJSON[models.Insight](ctx, r.db.conn(ctx),
+		`SELECT to_jsonb(t) FROM insights t
+		WHERE t.user_id = $1 AND t.insight_type = $2 AND t.valid_until > $3
+		ORDER BY t.metric_value DESC`,
+		userID, string(insightType), time.Now())
+	if err != nil {
+		return nil, fmt.Errorf("failed to get insights by type: %w", err)
+	}
+
+	return insights, nil
+}
+
+func (r *insightRepository) DeleteByUserID(ctx context.Context, userID string) error {
+	if _, err := r.db.conn(ctx).Exec(ctx, `DELETE FROM insights WHERE user_id = $1`, userID); err != nil {
+		return fmt.Errorf("failed to delete insights: %w", err)
+	}
+	return nil
+}
+
+func (r *insightRepository) DeleteExpired(ctx context.Context, userID string) error {
+	if _, err := r.db.conn(ctx).Exec(ctx,
+		`DELETE FROM insights WHERE user_id = $1 AND valid_until < $2`, userID, time.Now()); err != nil {
+		return fmt.Errorf("failed to delete expired insights: %w", err)
+	}
+	return nil
+}
+
+func (r *insightRepository) InvalidateAll(ctx context.Context, userID string) error {
+	if _, err := r.db.conn(ctx).Exec(ctx,
+		`UPDATE insights SET valid_until = $2 WHERE user_id = $1`, userID, time.Now().Add(-1*time.Hour)); err != nil {
+		return fmt.Errorf("failed to invalidate insights: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,120 @@
+package postgres
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/JonnyWalker81/trendy/backend/internal/models"
+	"github.com/JonnyWalker81/trendy/backend/internal/repository"
+)
+
+type jobRepository struct {
+	db *DB
+}
+
+// NewJobRepository creates a new Postgres-backed job repository
+func NewJobRepository(db *DB) repository.JobRepository {
+	return &jobRepository{db: db}
+}
+
+func (r *jobRepository) Enqueue(ctx context.Context, job *models.Job, maxWait time.Duration) (*models.Job, error) {
+	var userID any
+	if job.UserID != "" {
+		userID = job.UserID
+	}
+
+	enqueued, err := selectOne[models.Job](ctx, r.db.conn(ctx),
+		`SELECT to_jsonb(t) FROM enqueue_job($1, $2, $3, $4, $5, $6::interval) t`,
+		string(job.Kind), userID, job.RunAt, job.Attempts, job.LastError, jobMaxWait(maxWait))
+	if err != nil {
+		return nil, fmt.Errorf("failed to enqueue job: %w", err)
+	}
+
+	if enqueued == nil {
+		return nil, fmt.Errorf("no job returned")
+	}
+
+	return enqueued, nil
+}
+
+// jobMaxWait returns maxWait as an interval for enqueue_job, or nil for no
+// limit
+func jobMaxWait(maxWait time.Duration) any {
+	if maxWait <= 0 {
+		return nil
+	}
+	return fmt.Sprintf("%d microseconds", maxWait.Microseconds())
+}
+
+func (r *jobRepository) Claim(ctx context.Context, limit int) ([]models.Job, error) {
+	jobs, err := selectJSON[models.Job](ctx, r.db.conn(ctx),
+		`SELECT to_jsonb(t) FROM claim_jobs($1) t`, limit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to claim jobs: %w", err)
+	}
+	return jobs, nil
+}
+
+func (r *jobRepository) Finish(ctx context.Context, job *models.Job) (*models.Job, error) {
+	data := map[string]interface{}{
+		"status":       job.Status,
+		"last_error":   job.LastError,
+		"completed_at": job.CompletedAt,
+	}
+
+	sql, args := updateSQL("jobs", data, "t.id = $1", []any{job.ID}, "to_jsonb(t)")
+	finished, err := selectOne[models.Job](ctx, r.db.conn(ctx), sql, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to finish job: %w", err)
+	}
+
+	if finished == nil {
+		return nil, fmt.Errorf("job not found")
+	}
+
+	return finished, nil
+}
+
+func (r *jobRepository) GetLatest(ctx context.Context, kind models.JobKind, userID string) (*models.Job, error) {
+	var userArg any
+	if userID != "" {
+		userArg = userID
+	}
+
+	job, err := selectOne[models.Job](ctx, r.db.conn(ctx),
+		`SELECT to_jsonb(t) FROM jobs t
+		WHERE t.kind = $1 AND t.user_id IS NOT DISTINCT FROM $2::uuid
+		ORDER BY t.created_at DESC, t.id DESC LIMIT 1`, string(kind), userArg)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get job: %w", err)
+	}
+
+	return job, nil
+}
+
+func (r *jobRepository) GetStale(ctx context.Context, before time.Time) ([]models.Job, error) {
+	jobs, err := selectJSON[models.Job](ctx, r.db.conn(ctx),
+		`SELECT to_jsonb(t) FROM jobs t
+		WHERE t.status = 'running' AND t.started_at < $1
+		ORDER BY t.started_at`, before)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get stale jobs: %w", err)
+	}
+	return jobs, nil
+}
+
+func (r *jobRepository) DeleteFinished(ctx context.Context, before time.Time) error {
+	if _, err := r.db.conn(ctx).Exec(ctx,
+		`DELETE FROM jobs WHERE status IN ('succeeded', 'failed') AND completed_at < $1`, before); err != nil {
+		return fmt.Errorf("failed to delete finished jobs: %w", err)
+	}
+	return nil
+}
+
+func (r *jobRepository) DeleteByUserID(ctx context.Context, userID string) error {
+	if _, err := r.db.conn(ctx).Exec(ctx, `DELETE FROM jobs WHERE user_id = $1`, userID); err != nil {
+		return fmt.Errorf("failed to delete jobs: %w", err)
+	}
+	return nil
+}
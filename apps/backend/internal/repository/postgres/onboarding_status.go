@@ -0,0 +1,90 @@
+package postgres
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/JonnyWalker81/trendy/backend/internal/models"
+	"github.com/JonnyWalker81/trendy/backend/internal/repository"
+)
+
+type onboardingStatusRepository struct {
+	db *DB
+}
+
+// NewOnboardingStatusRepository creates a new Postgres-backed onboarding status repository
+func NewOnboardingStatusRepository(db *DB) repository.OnboardingStatusRepository {
+	return &onboardingStatusRepository{db: db}
+}
+
+// GetOrCreate returns the user's onboarding status, creating a default record if none exists
+func (r *onboardingStatusRepository) GetOrCreate(ctx context.Context, userID string) (*models.OnboardingStatus, error) {
+	if _, err := r.db.conn(ctx).Exec(ctx,
+		`INSERT INTO onboarding_status (user_id, completed) VALUES ($1, false) ON CONFLICT (user_id) DO NOTHING`,
+		userID); err != nil {
+		return nil, fmt.Errorf("failed to get or create onboarding status: %w", err)
+	}
+
+	status, err := selectOne[models.OnboardingStatus](ctx, r.db.conn(ctx),
+		`SELECT to_jsonb(t) FROM onboarding_status t WHERE t.user_id = $1`, userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get or create onboarding status: %w", err)
+	}
+
+	if status == nil {
+		return nil, fmt.Errorf("no onboarding status returned")
+	}
+
+	return status, nil
+}
+
+// Update updates the user's onboarding status (full replacement of the mutable fields)
+func (r *onboardingStatusRepository) Update(ctx context.Context, userID string, status *models.OnboardingStatus) (*models.OnboardingStatus, error) {
+	data := map[string]interface{}{
+		"completed":                  status.Completed,
+		"welcome_completed_at":       status.WelcomeCompletedAt,
+		"auth_completed_at":          status.AuthCompletedAt,
+		"permissions_completed_at":   status.PermissionsCompletedAt,
+		"notifications_status":       status.NotificationsStatus,
+		"notifications_completed_at": status.NotificationsCompletedAt,
+		"healthkit_status":           status.HealthkitStatus,
+		"healthkit_completed_at":     status.HealthkitCompletedAt,
+		"location_status":            status.LocationStatus,
+		"location_completed_at":      status.LocationCompletedAt,
+	}
+
+	return r.update(ctx, userID, data, "failed to update onboarding status")
+}
+
+// SoftReset clears step completion but preserves permission data
+func (r *onboardingStatusRepository) SoftReset(ctx context.Context, userID string) (*models.OnboardingStatus, error) {
+	data := map[string]interface{}{
+		"completed":                false,
+		"welcome_completed_at":     nil,
+		"auth_completed_at":        nil,
+		"permissions_completed_at": nil,
+	}
+
+	return r.update(ctx, userID, data, "failed to soft reset onboarding status")
+}
+
+func (r *onboardingStatusRepository) update(ctx context.Context, userID string, data map[string]interface{}, errMsg string) (*models.OnboardingStatus, error) {
+	sql, args := updateSQL("onboarding_status", data, "t.user_id = $1", []any{userID}, "to_jsonb(t)")
+	updated, err := selectOne[models.OnboardingStatus](ctx, r.db.conn(ctx), sql, args...)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", errMsg, err)
+	}
+
+	if updated == nil {
+		return nil, fmt.Errorf("onboarding status not found")
+	}
+
+	return updated, nil
+}
+
+func (r *onboardingStatusRepository) DeleteByUserID(ctx context.Context, userID string) error {
+	if _, err := r.db.conn(ctx).Exec(ctx, `DELETE FROM onboarding_status WHERE user_id = $1`, userID); err != nil {
+		return fmt.Errorf("failed to delete onboarding status: %w", err)
+	}
+	return nil
+}
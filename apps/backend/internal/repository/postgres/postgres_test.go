@@ -0,0 +1,399 @@
+package postgres
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"sort"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/JonnyWalker81/trendy/backend/internal/models"
+)
+
+// These tests run against the database in TEST_DATABASE_URL and are skipped
+// when it is unset. An empty database is set up from supabase/standalone.sql
+// and the migrations first. Each test works on its own new user.
+
+const schemaDir = "../../../../../supabase"
+
+func openTestDB(t *testing.T) *DB {
+	t.Helper()
+
+	url := os.Getenv("TEST_DATABASE_URL")
+	if url == "" {
+		t.Skip("TEST_DATABASE_URL is not set")
+	}
+
+	ctx := context.Background()
+	db, err := Open(ctx, url)
+	if err != nil {
+		t.Fatalf("Open failed: %v", err)
+	}
+	t.Cleanup(db.Close)
+
+	var migrated bool
+	if err := db.pool.QueryRow(ctx, `SELECT to_regclass('public.events') IS NOT NULL`).Scan(&migrated); err != nil {
+		t.Fatalf("failed to check schema: %v", err)
+	}
+	if !migrated {
+		migrate(t, db)
+	}
+
+	return db
+}
+
+// migrate applies the standalone setup and every migration in order
+func migrate(t *testing.T, db *DB) {
+	t.Helper()
+
+	migrations, err := filepath.Glob(filepath.Join(schemaDir, "migrations", "*.sql"))
+	if err != nil {
+		t.Fatalf("failed to list migrations: %v", err)
+	}
+	sort.Strings(migrations)
+
+	for _, path := range append([]string{filepath.Join(schemaDir, "standalone.sql")}, migrations...) {
+		sql, err := os.ReadFile(path)
+		if err != nil {
+			t.Fatalf("failed to read %s: %v", path, err)
+		}
+		if _, err := db.pool.Exec(context.Background(), string(sql)); err != nil {
+			t.Fatalf("failed to apply %s: %v", filepath.Base(path), err)
+		}
+	}
+}
+
+// newTestUser creates a user and an event type for them, removing both
+// with everything the user owns when the test ends
+func newTestUser(t *testing.T, db *DB) (string, string) {
+	t.Helper()
+	ctx := context.Background()
+
+	userID := uuid.NewString()
+	if _, err := NewUserRepository(db).Create(ctx, &models.User{ID: userID, Email: userID + "@example.com"}); err != nil {
+		t.Fatalf("failed to create user: %v", err)
+	}
+	t.Cleanup(func() {
+		ctx := context.Background()
+		db.pool.Exec(ctx, `DELETE FROM change_log WHERE user_id = $1`, userID)
+		db.pool.Exec(ctx, `DELETE FROM change_outbox WHERE user_id = $1`, userID)
+		db.pool.Exec(ctx, `DELETE FROM auth.users WHERE id = $1`, userID)
+	})
+
+	eventType, err := NewEventTypeRepository(db).Create(ctx, &models.EventType{
+		UserID: userID,
+		Name:   "Workout",
+		Color:  "#ff0000",
+		Icon:   "figure.run",
+	})
+	if err != nil {
+		t.Fatalf("failed to create event type: %v", err)
+	}
+
+	return userID, eventType.ID
+}
+
+func TestUserCreateIsIdempotent(t *testing.T) {
+	db := openTestDB(t)
+	ctx := context.Background()
+	userID, _ := newTestUser(t, db)
+
+	user, err := NewUserRepository(db).Create(ctx, &models.User{ID: userID, Email: "other@example.com"})
+	if err != nil {
+		t.Fatalf("second Create failed: %v", err)
+	}
+	if user.ID != userID || user.Email != userID+"@example.com" {
+		t.Errorf("got user %s <%s>, want the existing user", user.ID, user.Email)
+	}
+}
+
+func TestEventPaging(t *testing.T) {
+	db := openTestDB(t)
+	ctx := context.Background()
+	userID, eventTypeID := newTestUser(t, db)
+	repo := NewEventRepository(db)
+
+	base := time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC)
+	var ids []string
+	for i := 0; i < 5; i++ {
+		created, err := repo.Create(ctx, &models.Event{
+			UserID:      userID,
+			EventTypeID: eventTypeID,
+			Timestamp:   base.Add(time.Duration(i) * time.Hour),
+			SourceType:  "manual",
+			Properties: map[string]models.PropertyValue{
+				"reps": {Type: models.PropertyTypeNumber, Value: float64(i)},
+			},
+		})
+		if err != nil {
+			t.Fatalf("Create failed: %v", err)
+		}
+		ids = append(ids, created.ID)
+	}
+
+	got, err := repo.GetByID(ctx, ids[2])
+	if err != nil {
+		t.Fatalf("GetByID failed: %v", err)
+	}
+	if got.EventTypeID != eventTypeID || !got.Timestamp.Equal(base.Add(2*time.Hour)) {
+		t.Errorf("GetByID returned %+v", got)
+	}
+	if got.Properties["reps"].Value != float64(2) {
+		t.Errorf("reps = %v, want 2", got.Properties["reps"].Value)
+	}
+
+	var exported []string
+	var after *models.EventCursor
+	for {
+		page, err := repo.GetForExportPage(ctx, userID, nil, nil, []string{eventTypeID}, after, 2)
+		if err != nil {
+			t.Fatalf("GetForExportPage failed: %v", err)
+		}
+		for _, event := range page {
+			exported = append(exported, event.ID)
+		}
+		if len(page) < 2 {
+			break
+		}
+		last := page[len(page)-1]
+		after = &models.EventCursor{Timestamp: last.Timestamp, ID: last.ID}
+	}
+	if len(exported) != 5 {
+		t.Fatalf("exported %d events, want 5", len(exported))
+	}
+	for i, id := range exported {
+		if id != ids[4-i] {
+			t.Fatalf("export order = %v, want newest first", exported)
+		}
+	}
+
+	start := base.Add(time.Hour)
+	page, err := repo.GetPage(ctx, userID, &models.EventFilter{StartDate: &start}, &models.EventCursor{Timestamp: base.Add(3 * time.Hour), ID: ids[3]}, 10)
+	if err != nil {
+		t.Fatalf("GetPage failed: %v", err)
+	}
+	if len(page) != 2 || page[0].ID != ids[2] || page[1].ID != ids[1] {
+		t.Errorf("GetPage returned %d events, want the two after the cursor from the start date", len(page))
+	}
+}
+
+func TestEventClaimVersion(t *testing.T) {
+	db := openTestDB(t)
+	ctx := context.Background()
+	userID, eventTypeID := newTestUser(t, db)
+	repo := NewEventRepository(db)
+
+	event, err := repo.Create(ctx, &models.Event{
+		UserID:      userID,
+		EventTypeID: eventTypeID,
+		Timestamp:   time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC),
+		SourceType:  "manual",
+	})
+	if err != nil {
+		t.Fatalf("Create failed: %v", err)
+	}
+
+	err = db.WithinTx(ctx, func(ctx context.Context) error {
+		claimed, err := repo.ClaimVersion(ctx, event.ID, event.UpdatedAt)
+		if err != nil {
+			return err
+		}
+		if !claimed {
+			t.Error("ClaimVersion refused the current version")
+		}
+
+		claimed, err = repo.ClaimVersion(ctx, event.ID, event.UpdatedAt.Add(-time.Second))
+		if err != nil {
+			return err
+		}
+		if claimed {
+			t.Error("ClaimVersion accepted a stale version")
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("WithinTx failed: %v", err)
+	}
+
+	if err := repo.Delete(ctx, event.ID); err != nil {
+		t.Fatalf("Delete failed: %v", err)
+	}
+	claimed, err := repo.ClaimVersion(ctx, event.ID, event.UpdatedAt)
+	if err != nil {
+		t.Fatalf("ClaimVersion failed: %v", err)
+	}
+	if claimed {
+		t.Error("ClaimVersion accepted a deleted event")
+	}
+}
+
+func TestChangeLogPaging(t *testing.T) {
+	db := openTestDB(t)
+	ctx := context.Background()
+	userID, _ := newTestUser(t, db)
+	repo := NewChangeLogRepository(db)
+
+	// The database triggers may already have logged the event type
+	start, err := repo.GetLatestCursor(ctx, userID)
+	if err != nil {
+		t.Fatalf("GetLatestCursor failed: %v", err)
+	}
+
+	var appended []int64
+	for i := 0; i < 3; i++ {
+		id, err := repo.Append(ctx, &models.ChangeLogInput{
+			EntityType: models.EntityTypeEvent,
+			Operation:  models.OperationCreate,
+			EntityID:   uuid.NewString(),
+			UserID:     userID,
+			Data:       map[string]interface{}{"index": i},
+		})
+		if err != nil {
+			t.Fatalf("Append failed: %v", err)
+		}
+		appended = append(appended, id)
+	}
+
+	first, err := repo.GetSince(ctx, userID, start, 2)
+	if err != nil {
+		t.Fatalf("GetSince failed: %v", err)
+	}
+	if len(first.Changes) != 2 || !first.HasMore || first.NextCursor != appended[1] {
+		t.Fatalf("first page = %d changes, has more %v, cursor %d", len(first.Changes), first.HasMore, first.NextCursor)
+	}
+	if first.Changes[0].EntityType != models.EntityTypeEvent || first.Changes[0].Operation != models.OperationCreate {
+		t.Errorf("first change = %+v", first.Changes[0])
+	}
+
+	second, err := repo.GetSince(ctx, userID, first.NextCursor, 2)
+	if err != nil {
+		t.Fatalf("GetSince failed: %v", err)
+	}
+	if len(second.Changes) != 1 || second.HasMore || second.NextCursor != appended[2] {
+		t.Fatalf("second page = %d changes, has more %v, cursor %d", len(second.Changes), second.HasMore, second.NextCursor)
+	}
+
+	empty, err := repo.GetSince(ctx, userID, second.NextCursor, 2)
+	if err != nil {
+		t.Fatalf("GetSince failed: %v", err)
+	}
+	if len(empty.Changes) != 0 || empty.NextCursor != second.NextCursor {
+		t.Errorf("GetSince past the end = %d changes, cursor %d", len(empty.Changes), empty.NextCursor)
+	}
+
+	latest, err := repo.GetLatestCursor(ctx, userID)
+	if err != nil {
+		t.Fatalf("GetLatestCursor failed: %v", err)
+	}
+	if latest != appended[2] {
+		t.Errorf("GetLatestCursor = %d, want %d", latest, appended[2])
+	}
+
+	settled, err := repo.GetSettledCursor(ctx, userID, time.Now().Add(time.Hour))
+	if err != nil {
+		t.Fatalf("GetSettledCursor failed: %v", err)
+	}
+	if settled != latest {
+		t.Errorf("GetSettledCursor with every entry settled = %d, want %d", settled, latest)
+	}
+	if settled, err = repo.GetSettledCursor(ctx, userID, time.Now().Add(-time.Hour)); err != nil || settled >= appended[0] {
+		t.Errorf("GetSettledCursor with no entry settled = %d, %v, want below %d", settled, err, appended[0])
+	}
+}
+
+func TestJobQueue(t *testing.T) {
+	db := openTestDB(t)
+	ctx := context.Background()
+	userID, _ := newTestUser(t, db)
+	repo := NewJobRepository(db)
+
+	now := time.Now().UTC().Truncate(time.Microsecond)
+	first, err := repo.Enqueue(ctx, &models.Job{Kind: models.JobKindInsights, UserID: userID, RunAt: now.Add(time.Hour)}, 0)
+	if err != nil {
+		t.Fatalf("Enqueue failed: %v", err)
+	}
+	if first.Status != models.JobPending {
+		t.Errorf("status = %s, want pending", first.Status)
+	}
+
+	// A second write moves the pending job instead of adding another, but
+	// no later than the maximum wait after it was queued
+	capped, err := repo.Enqueue(ctx, &models.Job{Kind: models.JobKindInsights, UserID: userID, RunAt: now.Add(3 * time.Hour)}, 2*time.Hour)
+	if err != nil {
+		t.Fatalf("Enqueue failed: %v", err)
+	}
+	if capped.ID != first.ID || !capped.RunAt.Equal(first.CreatedAt.Add(2*time.Hour)) {
+		t.Fatalf("Enqueue moved job %s to %v, want job %s at %v", capped.ID, capped.RunAt, first.ID, first.CreatedAt.Add(2*time.Hour))
+	}
+
+	// A retry leaves the job queued for new work as it is
+	boom := "boom"
+	kept, err := repo.Enqueue(ctx, &models.Job{Kind: models.JobKindInsights, UserID: userID, RunAt: now.Add(time.Minute), Attempts: 2, LastError: &boom}, 0)
+	if err != nil {
+		t.Fatalf("Enqueue failed: %v", err)
+	}
+	if kept.Attempts != 0 || kept.LastError != nil || !kept.RunAt.Equal(capped.RunAt) {
+		t.Errorf("retry changed the pending job to %+v", kept)
+	}
+
+	second, err := repo.Enqueue(ctx, &models.Job{Kind: models.JobKindInsights, UserID: userID, RunAt: now.Add(-time.Second)}, 0)
+	if err != nil {
+		t.Fatalf("Enqueue failed: %v", err)
+	}
+	if second.ID != first.ID {
+		t.Fatalf("Enqueue added job %s next to pending job %s", second.ID, first.ID)
+	}
+
+	claimed, err := repo.Claim(ctx, 100)
+	if err != nil {
+		t.Fatalf("Claim failed: %v", err)
+	}
+	var job *models.Job
+	for i := range claimed {
+		if claimed[i].ID == first.ID {
+			job = &claimed[i]
+		}
+	}
+	if job == nil {
+		t.Fatal("Claim did not return the due job")
+	}
+	if job.Status != models.JobRunning || job.StartedAt == nil {
+		t.Errorf("claimed job = %+v, want running with a start time", job)
+	}
+
+	// A job of the same kind is not claimed while the first one runs
+	if _, err := repo.Enqueue(ctx, &models.Job{Kind: models.JobKindInsights, UserID: userID, RunAt: now.Add(-time.Second)}, 0); err != nil {
+		t.Fatalf("Enqueue failed: %v", err)
+	}
+	claimed, err = repo.Claim(ctx, 100)
+	if err != nil {
+		t.Fatalf("Claim failed: %v", err)
+	}
+	for _, c := range claimed {
+		if c.UserID == userID {
+			t.Fatalf("Claim returned job %s while job %s is running", c.ID, first.ID)
+		}
+	}
+
+	completed := time.Now().UTC()
+	job.Status = models.JobSucceeded
+	job.CompletedAt = &completed
+	finished, err := repo.Finish(ctx, job)
+	if err != nil {
+		t.Fatalf("Finish failed: %v", err)
+	}
+	if finished.Status != models.JobSucceeded || finished.CompletedAt == nil {
+		t.Errorf("finished job = %+v", finished)
+	}
+
+	latest, err := repo.GetLatest(ctx, models.JobKindInsights, userID)
+	if err != nil {
+		t.Fatalf("GetLatest failed: %v", err)
+	}
+	if latest == nil || latest.ID == first.ID || latest.Status != models.JobPending {
+		t.Errorf("GetLatest = %+v, want the pending job queued while the first ran", latest)
+	}
+}
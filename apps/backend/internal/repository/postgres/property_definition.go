@@ -0,0 +1,184 @@
+package postgres
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/JonnyWalker81/trendy/backend/internal/models"
+	"github.com/JonnyWalker81/trendy/backend/internal/repository"
+)
+
+type propertyDefinitionRepository struct {
+	db *DB
+}
+
+// NewPropertyDefinitionRepository creates a new Postgres-backed property definition repository
+func NewPropertyDefinitionRepository(db *DB) repository.PropertyDefinitionRepository {
+	return &propertyDefinitionRepository{db: db}
+}
+
+func (r *propertyDefinitionRepository) Create(ctx context.Context, def *models.PropertyDefinition) (*models.PropertyDefinition, error) {
+	data := map[string]interface{}{
+		"event_type_id": def.EventTypeID,
+		"user_id":       def.UserID,
+		"key":           def.Key,
+		"label":         def.Label,
+		"property_type": def.PropertyType,
+		"required":      def.Required,
+		"display_order": def.DisplayOrder,
+	}
+
+	// Use client-provided ID if present (for offline-first/UUIDv7 support)
+	if def.ID != "" {
+		data["id"] = def.ID
+	}
+	if def.Formula != "" {
+		data["formula"] = def.Formula
+	}
+	if def.Unit != "" {
+		data["unit"] = def.Unit
+	}
+	if def.RatingMax > 0 {
+		data["rating_max"] = def.RatingMax
+	}
+
+	if err := setPropertyDefinitionJSON(data, def); err != nil {
+		return nil, err
+	}
+
+	sql, args := insertSQL("property_definitions", []map[string]interface{}{data}, "", "to_jsonb(t)")
+	created, err := selectOne[models.PropertyDefinition](ctx, r.db.conn(ctx), sql, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create property definition: %w", err)
+	}
+
+	if created == nil {
+		return nil, fmt.Errorf("no property definition returned")
+	}
+
+	return created, nil
+}
+
+func (r *propertyDefinitionRepository) GetByID(ctx context.Context, id string) (*models.PropertyDefinition, error) {
+	def, err := selectOne[models.PropertyDefinition](ctx, r.db.conn(ctx),
+		`SELECT to_jsonb(t) FROM property_definitions t WHERE t.id = $1`, id)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get property definition: %w", err)
+	}
+
+	if def == nil {
+		return nil, fmt.Errorf("property definition not found")
+	}
+
+	return def, nil
+}
+
+func (r *propertyDefinitionRepository) ClaimVersion(ctx context.Context, id string, updatedAt time.Time) (bool, error) {
+	// The version is compared and the row locked in one statement, so a
+	// concurrent writer waits and then sees the version it replaced
+	rows, err := r.db.conn(ctx).Query(ctx,
+		`SELECT 1 FROM property_definitions WHERE id = $1 AND updated_at = $2 FOR UPDATE`, id, updatedAt)
+	if err != nil {
+		return false, fmt.Errorf("failed to claim property definition: %w", err)
+	}
+	defer rows.Close()
+
+	claimed := rows.Next()
+	if err := rows.Err(); err != nil {
+		return false, fmt.Errorf("failed to claim property definition: %w", err)
+	}
+
+	return claimed, nil
+}
+
+func (r *propertyDefinitionRepository) GetByEventTypeID(ctx context.Context, eventTypeID string) ([]models.PropertyDefinition, error) {
+	definitions, err := selectJSON[models.PropertyDefinition](ctx, r.db.conn(ctx),
+		`SELECT to_jsonb(t) FROM property_definitions t WHERE t.event_type_id = $1
+		ORDER BY t.display_order ASC, t.created_at ASC`, eventTypeID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get property definitions: %w", err)
+	}
+
+	return definitions, nil
+}
+
+func (r *propertyDefinitionRepository) Update(ctx context.Context, id string, def *models.PropertyDefinition) (*models.PropertyDefinition, error) {
+	data := make(map[string]interface{})
+
+	if def.Key != "" {
+		data["key"] = def.Key
+	}
+	if def.Label != "" {
+		data["label"] = def.Label
+	}
+	if def.PropertyType != "" {
+		data["property_type"] = def.PropertyType
+	}
+	data["required"] = def.Required
+	data["formula"] = nil
+	if def.Formula != "" {
+		data["formula"] = def.Formula
+	}
+	if def.Unit != "" {
+		data["unit"] = def.Unit
+	}
+	if def.RatingMax > 0 {
+		data["rating_max"] = def.RatingMax
+	}
+	if def.DisplayOrder >= 0 {
+		data["display_order"] = def.DisplayOrder
+	}
+	if err := setPropertyDefinitionJSON(data, def); err != nil {
+		return nil, err
+	}
+
+	if len(data) == 0 {
+		return r.GetByID(ctx, id)
+	}
+
+	sql, args := updateSQL("property_definitions", data, "t.id = $1", []any{id}, "to_jsonb(t)")
+	updated, err := selectOne[models.PropertyDefinition](ctx, r.db.conn(ctx), sql, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to update property definition: %w", err)
+	}
+
+	if updated == nil {
+		return nil, fmt.Errorf("property definition not found")
+	}
+
+	return updated, nil
+}
+
+func (r *propertyDefinitionRepository) Delete(ctx context.Context, id string) error {
+	if _, err := r.db.conn(ctx).Exec(ctx, `DELETE FROM property_definitions WHERE id = $1`, id); err != nil {
+		return fmt.Errorf("failed to delete property definition: %w", err)
+	}
+	return nil
+}
+
+// setPropertyDefinitionJSON adds the JSONB columns of def to data when set
+func setPropertyDefinitionJSON(data map[string]interface{}, def *models.PropertyDefinition) error {
+	if len(def.Options) > 0 {
+		options, err := jsonb(def.Options)
+		if err != nil {
+			return fmt.Errorf("failed to marshal options: %w", err)
+		}
+		data["options"] = options
+	}
+	if def.DefaultValue != nil {
+		defaultValue, err := jsonb(def.DefaultValue)
+		if err != nil {
+			return fmt.Errorf("failed to marshal default value: %w", err)
+		}
+		data["default_value"] = defaultValue
+	}
+	return nil
+}
+
+func (r *propertyDefinitionRepository) DeleteByUserID(ctx context.Context, userID string) error {
+	if _, err := r.db.conn(ctx).Exec(ctx, `DELETE FROM property_definitions WHERE user_id = $1`, userID); err != nil {
+		return fmt.Errorf("failed to delete property definitions: %w", err)
+	}
+	return nil
+}
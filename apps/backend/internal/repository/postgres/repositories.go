@@ -0,0 +1,29 @@
+package postgres
+
+import "github.com/JonnyWalker81/trendy/backend/internal/repository"
+
+// NewRepositories creates repositories backed by db. The returned Transactor
+// runs work in a real database transaction.
+func NewRepositories(db *DB) *repository.Repositories {
+	return &repository.Repositories{
+		Events:              NewEventRepository(db),
+		EventTypes:          NewEventTypeRepository(db),
+		Users:               NewUserRepository(db),
+		PropertyDefinitions: NewPropertyDefinitionRepository(db),
+		Geofences:           NewGeofenceRepository(db),
+		Insights:            NewInsightRepository(db),
+		DailyAggregates:     NewDailyAggregateRepository(db),
+		AggregateStates:     NewAggregateStateRepository(db),
+		Streaks:             NewStreakRepository(db),
+		ChangeLog:           NewChangeLogRepository(db),
+		ChangeOutbox:        NewChangeOutboxRepository(db),
+		Idempotency:         NewIdempotencyRepository(db),
+		OnboardingStatus:    NewOnboardingStatusRepository(db),
+		UserSettings:        NewUserSettingsRepository(db),
+		ImportJobs:          NewImportJobRepository(db),
+		Jobs:                NewJobRepository(db),
+		AccountExports:      NewAccountExportRepository(db),
+		AccountDeletions:    NewAccountDeletionRepository(db),
+		Transactor:          db,
+	}
+}
@@ -0,0 +1,104 @@
+package postgres
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/JonnyWalker81/trendy/backend/internal/models"
+	"github.com/JonnyWalker81/trendy/backend/internal/repository"
+)
+
+// streakJSON projects a streaks row with its DATE columns as UTC timestamps
+// so they decode into time.Time
+const streakJSON = `to_jsonb(t) || jsonb_build_object(
+	'start_date', t.start_date::timestamp AT TIME ZONE 'UTC',
+	'end_date', t.end_date::timestamp AT TIME ZONE 'UTC')`
+
+type streakRepository struct {
+	db *DB
+}
+
+// NewStreakRepository creates a new Postgres-backed streak repository
+func NewStreakRepository(db *DB) repository.StreakRepository {
+	return &streakRepository{db: db}
+}
+
+func (r *streakRepository) Upsert(ctx context.Context, streak *models.Streak) (*models.Streak, error) {
+	data := map[string]interface{}{
+		"user_id":       streak.UserID,
+		"event_type_id": streak.EventTypeID,
+		"streak_type":   streak.StreakType,
+		"start_date":    streak.StartDate.Format("2006-01-02"),
+		"end_date":      nil,
+		"length":        streak.Length,
+		"is_active":     streak.IsActive,
+	}
+
+	if streak.EndDate != nil {
+		data["end_date"] = streak.EndDate.Format("2006-01-02")
+	}
+
+	suffix := `ON CONFLICT (user_id, event_type_id, streak_type) DO UPDATE SET
+		start_date = EXCLUDED.start_date,
+		end_date = EXCLUDED.end_date,
+		length = EXCLUDED.length,
+		is_active = EXCLUDED.is_active`
+
+	sql, args := insertSQL("streaks", []map[string]interface{}{data}, suffix, streakJSON)
+	upserted, err := selectOne[models.Streak](ctx, r.db.conn(ctx), sql, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to upsert streak: %w", err)
+	}
+
+	if upserted == nil {
+		return nil, fmt.Errorf("no streak returned")
+	}
+
+	return upserted, nil
+}
+
+func (r *streakRepository) GetByUserID(ctx context.Context, userID string) ([]models.Streak, error) {
+	streaks, err := selectJSON[models.Streak](ctx, r.db.conn(ctx),
+		`SELECT `+streakJSON+` FROM streaks t WHERE t.user_id = $1 ORDER BY t.length DESC`, userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get streaks: %w", err)
+	}
+
+	return streaks, nil
+}
+
+func (r *streakRepository) GetByUserIDAndEventType(ctx context.Context, userID, eventTypeID string) ([]models.Streak, error) {
+	streaks, err := selectJSON[models.Streak](ctx, r.db.conn(ctx),
+		`SELECT `+streakJSON+` FROM streaks t WHERE t.user_id = $1 AND t.event_type_id = $2 ORDER BY t.streak_type ASC`,
+		userID, eventTypeID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get streaks: %w", err)
+	}
+
+	return streaks, nil
+}
+
+func (r *streakRepository) GetActiveByUserID(ctx context.Context, userID string) ([]models.Streak, error) {
+	streaks, err := selectJSON[models.Streak](ctx, r.db.conn(ctx),
+		`SELECT `+streakJSON+` FROM streaks t WHERE t.user_id = $1 AND t.is_active ORDER BY t.length DESC`, userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get active streaks: %w", err)
+	}
+
+	return streaks, nil
+}
+
+func (r *streakRepository) DeleteByUserID(ctx context.Context, userID string) error {
+	if _, err := r.db.conn(ctx).Exec(ctx, `DELETE FROM streaks WHERE user_id = $1`, userID); err != nil {
+		return fmt.Errorf("failed to delete streaks: %w", err)
+	}
+	return nil
+}
+
+func (r *streakRepository) DeleteByEventType(ctx context.Context, userID, eventTypeID string) error {
+	if _, err := r.db.conn(ctx).Exec(ctx,
+		`DELETE FROM streaks WHERE user_id = $1 AND event_type_id = $2`, userID, eventTypeID); err != nil {
+		return fmt.Errorf("failed to delete streaks by event type: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,102 @@
+package postgres
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/JonnyWalker81/trendy/backend/internal/models"
+	"github.com/JonnyWalker81/trendy/backend/internal/repository"
+)
+
+type userRepository struct {
+	db *DB
+}
+
+// NewUserRepository creates a new Postgres-backed user repository
+func NewUserRepository(db *DB) repository.UserRepository {
+	return &userRepository{db: db}
+}
+
+func (r *userRepository) GetByID(ctx context.Context, id string) (*models.User, error) {
+	user, err := selectOne[models.User](ctx, r.db.conn(ctx), `SELECT to_jsonb(t) FROM users t WHERE t.id = $1`, id)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get user: %w", err)
+	}
+
+	if user == nil {
+		return nil, fmt.Errorf("user not found")
+	}
+
+	return user, nil
+}
+
+func (r *userRepository) GetByEmail(ctx context.Context, email string) (*models.User, error) {
+	user, err := selectOne[models.User](ctx, r.db.conn(ctx), `SELECT to_jsonb(t) FROM users t WHERE t.email = $1`, email)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get user: %w", err)
+	}
+
+	if user == nil {
+		return nil, fmt.Errorf("user not found")
+	}
+
+	return user, nil
+}
+
+// Create adds the user's auth.users row before the users row. With Supabase
+// Auth the auth row already exists. On a standalone database (see
+// supabase/standalone.sql) inserting it runs the same triggers as a Supabase
+// signup, which create the users row. A user that already exists is returned
+// as it is.
+func (r *userRepository) Create(ctx context.Context, user *models.User) (*models.User, error) {
+	var created *models.User
+	err := r.db.WithinTx(ctx, func(ctx context.Context) error {
+		if _, err := r.db.conn(ctx).Exec(ctx,
+			`INSERT INTO auth.users (id, email) VALUES ($1, $2) ON CONFLICT (id) DO NOTHING`, user.ID, user.Email); err != nil {
+			return fmt.Errorf("failed to create auth user: %w", err)
+		}
+
+		data := map[string]interface{}{
+			"id":    user.ID,
+			"email": user.Email,
+		}
+		sql, args := insertSQL("users", []map[string]interface{}{data}, "ON CONFLICT (id) DO NOTHING", "to_jsonb(t)")
+		var err error
+		if created, err = selectOne[models.User](ctx, r.db.conn(ctx), sql, args...); err != nil {
+			return fmt.Errorf("failed to create user: %w", err)
+		}
+		if created == nil {
+			// Created by the auth.users trigger
+			if created, err = selectOne[models.User](ctx, r.db.conn(ctx), `SELECT to_jsonb(t) FROM users t WHERE t.id = $1`, user.ID); err != nil {
+				return fmt.Errorf("failed to get user: %w", err)
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	if created == nil {
+		return nil, fmt.Errorf("no user returned")
+	}
+
+	return created, nil
+}
+
+func (r *userRepository) List(ctx context.Context, limit, offset int) ([]models.User, error) {
+	users, err := selectJSON[models.User](ctx, r.db.conn(ctx),
+		`SELECT to_jsonb(t) FROM users t ORDER BY t.created_at ASC, t.id ASC LIMIT $1 OFFSET $2`, limit, offset)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list users: %w", err)
+	}
+
+	return users, nil
+}
+
+func (r *userRepository) Delete(ctx context.Context, id string) error {
+	if _, err := r.db.conn(ctx).Exec(ctx, `DELETE FROM users WHERE id = $1`, id); err != nil {
+		return fmt.Errorf("failed to delete user: %w", err)
+	}
+	return nil
+}
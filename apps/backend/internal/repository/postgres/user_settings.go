@@ -0,0 +1,59 @@
+package postgres
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/JonnyWalker81/trendy/backend/internal/models"
+	"github.com/JonnyWalker81/trendy/backend/internal/repository"
+)
+
+type userSettingsRepository struct {
+	db *DB
+}
+
+// NewUserSettingsRepository creates a new Postgres-backed user settings repository
+func NewUserSettingsRepository(db *DB) repository.UserSettingsRepository {
+	return &userSettingsRepository{db: db}
+}
+
+func (r *userSettingsRepository) GetByUserID(ctx context.Context, userID string) (*models.UserSettings, error) {
+	settings, err := selectOne[models.UserSettings](ctx, r.db.conn(ctx),
+		`SELECT to_jsonb(t) FROM user_settings t WHERE t.user_id = $1`, userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get user settings: %w", err)
+	}
+
+	return settings, nil
+}
+
+func (r *userSettingsRepository) Upsert(ctx context.Context, settings *models.UserSettings) (*models.UserSettings, error) {
+	data := map[string]interface{}{
+		"user_id":    settings.UserID,
+		"timezone":   settings.Timezone,
+		"week_start": settings.WeekStart,
+	}
+
+	suffix := `ON CONFLICT (user_id) DO UPDATE SET
+		timezone = EXCLUDED.timezone,
+		week_start = EXCLUDED.week_start`
+
+	sql, args := insertSQL("user_settings", []map[string]interface{}{data}, suffix, "to_jsonb(t)")
+	upserted, err := selectOne[models.UserSettings](ctx, r.db.conn(ctx), sql, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to upsert user settings: %w", err)
+	}
+
+	if upserted == nil {
+		return nil, fmt.Errorf("no user settings returned")
+	}
+
+	return upserted, nil
+}
+
+func (r *userSettingsRepository) DeleteByUserID(ctx context.Context, userID string) error {
+	if _, err := r.db.conn(ctx).Exec(ctx, `DELETE FROM user_settings WHERE user_id = $1`, userID); err != nil {
+		return fmt.Errorf("failed to delete user settings: %w", err)
+	}
+	return nil
+}
@@ -4,6 +4,7 @@ import (
 	"context"
 	"encoding/json"
 	"fmt"
+	"time"
 
 	"github.com/JonnyWalker81/trendy/backend/internal/models"
 	"github.com/JonnyWalker81/trendy/backend/pkg/supabase"
@@ -25,6 +26,7 @@ func (r *propertyDefinitionRepository) Create(ctx context.Context, def *models.P
 		"key":           def.Key,
 		"label":         def.Label,
 		"property_type": def.PropertyType,
+		"required":      def.Required,
 		"display_order": def.DisplayOrder,
 	}
 
@@ -32,6 +34,15 @@ func (r *propertyDefinitionRepository) Create(ctx context.Context, def *models.P
 	if def.ID != "" {
 		data["id"] = def.ID
 	}
+	if def.Formula != "" {
+		data["formula"] = def.Formula
+	}
+	if def.Unit != "" {
+		data["unit"] = def.Unit
+	}
+	if def.RatingMax > 0 {
+		data["rating_max"] = def.RatingMax
+	}
 
 	// Add optional fields
 	if len(def.Options) > 0 {
@@ -88,6 +99,30 @@ func (r *propertyDefinitionRepository) GetByID(ctx context.Context, id string) (
 	return &definitions[0], nil
 }
 
+// ClaimVersion cannot hold a lock across PostgREST requests. It claims the
+// version with a conditional update instead, which moves updated_at on, so a
+// concurrent writer claiming the same version finds it gone.
+func (r *propertyDefinitionRepository) ClaimVersion(ctx context.Context, id string, updatedAt time.Time) (bool, error) {
+	query := map[string]interface{}{
+		"id":         fmt.Sprintf("eq.%s", id),
+		"updated_at": fmt.Sprintf("eq.%s", updatedAt.UTC().Format(time.RFC3339Nano)),
+	}
+
+	body, err := r.client.UpdateWhere("property_definitions", query, map[string]interface{}{"updated_at": updatedAt})
+	if err != nil {
+		return false, fmt.Errorf("failed to claim property definition: %w", err)
+	}
+
+	var claimed []struct {
+		ID string `json:"id"`
+	}
+	if err := json.Unmarshal(body, &claimed); err != nil {
+		return false, fmt.Errorf("failed to unmarshal response: %w", err)
+	}
+
+	return len(claimed) > 0, nil
+}
+
 func (r *propertyDefinitionRepository) GetByEventTypeID(ctx context.Context, eventTypeID string) ([]models.PropertyDefinition, error) {
 	query := map[string]interface{}{
 		"event_type_id": fmt.Sprintf("eq.%s", eventTypeID),
@@ -119,6 +154,17 @@ func (r *propertyDefinitionRepository) Update(ctx context.Context, id string, de
 	if def.PropertyType != "" {
 		data["property_type"] = def.PropertyType
 	}
+	data["required"] = def.Required
+	data["formula"] = nil
+	if def.Formula != "" {
+		data["formula"] = def.Formula
+	}
+	if def.Unit != "" {
+		data["unit"] = def.Unit
+	}
+	if def.RatingMax > 0 {
+		data["rating_max"] = def.RatingMax
+	}
 	if len(def.Options) > 0 {
 		data["options"] = def.Options
 	}
@@ -152,3 +198,15 @@ func (r *propertyDefinitionRepository) Delete(ctx context.Context, id string) er
 	}
 	return nil
 }
+
+func (r *propertyDefinitionRepository) DeleteByUserID(ctx context.Context, userID string) error {
+	query := map[string]interface{}{
+		"user_id": fmt.Sprintf("eq.%s", userID),
+	}
+
+	if err := r.client.DeleteWhere("property_definitions", query); err != nil {
+		return fmt.Errorf("failed to delete property definitions: %w", err)
+	}
+
+	return nil
+}
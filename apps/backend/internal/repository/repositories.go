@@ -0,0 +1,94 @@
+package repository
+
+import (
+	"context"
+
+	"github.com/JonnyWalker81/trendy/backend/pkg/supabase"
+)
+
+// Transactor runs a unit of work atomically. Repository calls made with the
+// context passed to fn participate in the same transaction when the backend
+// supports it.
+type Transactor interface {
+	WithinTx(ctx context.Context, fn func(ctx context.Context) error) error
+	// WithinSnapshot runs fn as a read-only unit of work. Repository calls
+	// made with the context passed to fn all read the same committed state
+	// when the backend supports it.
+	WithinSnapshot(ctx context.Context, fn func(ctx context.Context) error) error
+}
+
+// Repositories groups every repository implementation for a storage backend
+type Repositories struct {
+	Events              EventRepository
+	EventTypes          EventTypeRepository
+	Users               UserRepository
+	PropertyDefinitions PropertyDefinitionRepository
+	Geofences           GeofenceRepository
+	Insights            InsightRepository
+	DailyAggregates     DailyAggregateRepository
+	AggregateStates     AggregateStateRepository
+	Streaks             StreakRepository
+	ChangeLog           ChangeLogRepository
+	ChangeOutbox        ChangeOutboxRepository
+	Idempotency         IdempotencyRepository
+	OnboardingStatus    OnboardingStatusRepository
+	UserSettings        UserSettingsRepository
+	ImportJobs          ImportJobRepository
+	Jobs                JobRepository
+	AccountExports      AccountExportRepository
+	AccountDeletions    AccountDeletionRepository
+	Transactor          Transactor
+}
+
+// NewSupabaseRepositories creates repositories backed by the Supabase REST API
+func NewSupabaseRepositories(client *supabase.Client) *Repositories {
+	return &Repositories{
+		Events:              NewEventRepository(client),
+		EventTypes:          NewEventTypeRepository(client),
+		Users:               NewUserRepository(client),
+		PropertyDefinitions: NewPropertyDefinitionRepository(client),
+		Geofences:           NewGeofenceRepository(client),
+		Insights:            NewInsightRepository(client),
+		DailyAggregates:     NewDailyAggregateRepository(client),
+		AggregateStates:     NewAggregateStateRepository(client),
+		Streaks:             NewStreakRepository(client),
+		ChangeLog:           NewChangeLogRepository(client),
+		ChangeOutbox:        NewChangeOutboxRepository(client),
+		Idempotency:         NewIdempotencyRepository(client),
+		OnboardingStatus:    NewOnboardingStatusRepository(client),
+		UserSettings:        NewUserSettingsRepository(client),
+		ImportJobs:          NewImportJobRepository(client),
+		Jobs:                NewJobRepository(client),
+		AccountExports:      NewAccountExportRepository(client),
+		AccountDeletions:    NewAccountDeletionRepository(client),
+		Transactor:          outboxTransactor{},
+	}
+}
+
+// outboxTransactor runs fn directly. PostgREST requests are independent HTTP
+// calls, so the Supabase backend cannot group them into one transaction.
+// Instead triggers record every write to a synced table in the change outbox
+// within the write's own transaction, and the change log relay appends any
+// entry the unit of work failed to write. A failed change log append within
+// fn is therefore not returned: the entity write has already committed, and
+// failing the request would only make the client repeat it.
+type outboxTransactor struct{}
+
+type relayedKey struct{}
+
+func (outboxTransactor) WithinTx(ctx context.Context, fn func(ctx context.Context) error) error {
+	return fn(context.WithValue(ctx, relayedKey{}, true))
+}
+
+// WithinSnapshot runs fn directly; each PostgREST read sees the data committed
+// when it runs
+func (outboxTransactor) WithinSnapshot(ctx context.Context, fn func(ctx context.Context) error) error {
+	return fn(ctx)
+}
+
+// relayed reports whether change log entries appended with ctx are
+// guaranteed by the change outbox
+func relayed(ctx context.Context) bool {
+	_, ok := ctx.Value(relayedKey{}).(bool)
+	return ok
+}
@@ -84,3 +84,32 @@ func (r *userRepository) Create(ctx context.Context, user *models.User) (*models
 
 	return &users[0], nil
 }
+
+func (r *userRepository) List(ctx context.Context, limit, offset int) ([]models.User, error) {
+	query := map[string]interface{}{
+		"select": "*",
+		"order":  "created_at.asc,id.asc",
+		"limit":  limit,
+		"offset": offset,
+	}
+
+	body, err := r.client.Query("users", query)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list users: %w", err)
+	}
+
+	var users []models.User
+	if err := json.Unmarshal(body, &users); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal response: %w", err)
+	}
+
+	return users, nil
+}
+
+func (r *userRepository) Delete(ctx context.Context, id string) error {
+	if err := r.client.Delete("users", id); err != nil {
+		return fmt.Errorf("failed to delete user: %w", err)
+	}
+
+	return nil
+}
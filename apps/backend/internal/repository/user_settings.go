@@ -0,0 +1,77 @@
+package repository
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+
+	"github.com/JonnyWalker81/trendy/backend/internal/models"
+	"github.com/JonnyWalker81/trendy/backend/pkg/supabase"
+)
+
+type userSettingsRepository struct {
+	client *supabase.Client
+}
+
+// NewUserSettingsRepository creates a new user settings repository
+func NewUserSettingsRepository(client *supabase.Client) UserSettingsRepository {
+	return &userSettingsRepository{client: client}
+}
+
+func (r *userSettingsRepository) GetByUserID(ctx context.Context, userID string) (*models.UserSettings, error) {
+	query := map[string]interface{}{
+		"user_id": fmt.Sprintf("eq.%s", userID),
+	}
+
+	body, err := r.client.Query("user_settings", query)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get user settings: %w", err)
+	}
+
+	var settings []models.UserSettings
+	if err := json.Unmarshal(body, &settings); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal response: %w", err)
+	}
+
+	if len(settings) == 0 {
+		return nil, nil
+	}
+
+	return &settings[0], nil
+}
+
+func (r *userSettingsRepository) Upsert(ctx context.Context, settings *models.UserSettings) (*models.UserSettings, error) {
+	data := map[string]interface{}{
+		"user_id":    settings.UserID,
+		"timezone":   settings.Timezone,
+		"week_start": settings.WeekStart,
+	}
+
+	body, err := r.client.Upsert("user_settings", data, "user_id")
+	if err != nil {
+		return nil, fmt.Errorf("failed to upsert user settings: %w", err)
+	}
+
+	var upserted []models.UserSettings
+	if err := json.Unmarshal(body, &upserted); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal response: %w", err)
+	}
+
+	if len(upserted) == 0 {
+		return nil, fmt.Errorf("no user settings returned")
+	}
+
+	return &upserted[0], nil
+}
+
+func (r *userSettingsRepository) DeleteByUserID(ctx context.Context, userID string) error {
+	query := map[string]interface{}{
+		"user_id": fmt.Sprintf("eq.%s", userID),
+	}
+
+	if err := r.client.DeleteWhere("user_settings", query); err != nil {
+		return fmt.Errorf("failed to delete user settings: %w", err)
+	}
+
+	return nil
+}
@@ -0,0 +1,135 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"time"
+
+	"github.com/JonnyWalker81/trendy/backend/internal/logger"
+	"github.com/JonnyWalker81/trendy/backend/internal/models"
+	"github.com/JonnyWalker81/trendy/backend/internal/repository"
+)
+
+// AuthUserDeleter removes users from the auth provider. *supabase.Client
+// implements it.
+type AuthUserDeleter interface {
+	DeleteUser(userID string) error
+}
+
+type accountDeletionService struct {
+	repos     *repository.Repositories
+	authUsers AuthUserDeleter
+}
+
+// NewAccountDeletionService creates a new account deletion service. Erasure
+// touches every repository, so it takes the whole set. authUsers may be nil
+// when there is no auth provider, as with the memory backend.
+func NewAccountDeletionService(repos *repository.Repositories, authUsers AuthUserDeleter) AccountDeletionService {
+	return &accountDeletionService{
+		repos:     repos,
+		authUsers: authUsers,
+	}
+}
+
+func (s *accountDeletionService) DeleteAccount(ctx context.Context, userID string) (*models.AccountDeletion, error) {
+	deletion, err := s.repos.AccountDeletions.Start(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	return s.resume(ctx, deletion)
+}
+
+func (s *accountDeletionService) ResumePending(ctx context.Context) (int, error) {
+	deletions, err := s.repos.AccountDeletions.ListPending(ctx)
+	if err != nil {
+		return 0, err
+	}
+
+	resumed := 0
+	for i := range deletions {
+		if _, err := s.resume(ctx, &deletions[i]); err != nil {
+			logger.Ctx(ctx).Error("failed to resume account deletion",
+				logger.Err(err),
+				logger.String("deletion_id", deletions[i].ID),
+			)
+			continue
+		}
+		resumed++
+	}
+
+	return resumed, nil
+}
+
+// resume runs the steps the deletion has not completed, recording each one
+// as it finishes
+func (s *accountDeletionService) resume(ctx context.Context, deletion *models.AccountDeletion) (*models.AccountDeletion, error) {
+	for _, step := range models.AccountDeletionSteps {
+		if slices.Contains(deletion.CompletedSteps, step) {
+			continue
+		}
+
+		if err := s.runStep(ctx, step, deletion.UserID); err != nil {
+			return nil, fmt.Errorf("failed to delete %s: %w", step, err)
+		}
+
+		deletion.CompletedSteps = append(deletion.CompletedSteps, step)
+		updated, err := s.repos.AccountDeletions.Update(ctx, deletion)
+		if err != nil {
+			return nil, err
+		}
+		deletion = updated
+	}
+
+	completedAt := time.Now().UTC()
+	deletion.Status = models.AccountDeletionCompleted
+	deletion.CompletedAt = &completedAt
+	return s.repos.AccountDeletions.Update(ctx, deletion)
+}
+
+func (s *accountDeletionService) runStep(ctx context.Context, step models.AccountDeletionStep, userID string) error {
+	switch step {
+	case models.AccountDeletionStepEvents:
+		return s.repos.Events.DeleteByUserID(ctx, userID)
+	case models.AccountDeletionStepGeofences:
+		return s.repos.Geofences.DeleteByUserID(ctx, userID)
+	case models.AccountDeletionStepPropertyDefinitions:
+		return s.repos.PropertyDefinitions.DeleteByUserID(ctx, userID)
+	case models.AccountDeletionStepEventTypes:
+		return s.repos.EventTypes.DeleteByUserID(ctx, userID)
+	case models.AccountDeletionStepInsights:
+		return s.repos.Insights.DeleteByUserID(ctx, userID)
+	case models.AccountDeletionStepDailyAggregates:
+		return s.repos.DailyAggregates.DeleteByUserID(ctx, userID)
+	case models.AccountDeletionStepAggregateState:
+		return s.repos.AggregateStates.DeleteByUserID(ctx, userID)
+	case models.AccountDeletionStepStreaks:
+		return s.repos.Streaks.DeleteByUserID(ctx, userID)
+	case models.AccountDeletionStepOnboardingStatus:
+		return s.repos.OnboardingStatus.DeleteByUserID(ctx, userID)
+	case models.AccountDeletionStepUserSettings:
+		return s.repos.UserSettings.DeleteByUserID(ctx, userID)
+	case models.AccountDeletionStepImportJobs:
+		return s.repos.ImportJobs.DeleteByUserID(ctx, userID)
+	case models.AccountDeletionStepJobs:
+		return s.repos.Jobs.DeleteByUserID(ctx, userID)
+	case models.AccountDeletionStepAccountExports:
+		return s.repos.AccountExports.DeleteByUserID(ctx, userID)
+	case models.AccountDeletionStepIdempotencyKeys:
+		return s.repos.Idempotency.DeleteByUserID(ctx, userID)
+	case models.AccountDeletionStepChangeLog:
+		return s.repos.ChangeLog.DeleteByUserID(ctx, userID)
+	case models.AccountDeletionStepChangeOutbox:
+		return s.repos.ChangeOutbox.DeleteByUserID(ctx, userID)
+	case models.AccountDeletionStepUser:
+		return s.repos.Users.Delete(ctx, userID)
+	case models.AccountDeletionStepAuthUser:
+		if s.authUsers == nil {
+			return nil
+		}
+		return s.authUsers.DeleteUser(userID)
+	default:
+		return fmt.Errorf("unknown account deletion step %q", step)
+	}
+}
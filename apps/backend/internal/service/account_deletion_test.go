@@ -0,0 +1,127 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/JonnyWalker81/trendy/backend/internal/models"
+	"github.com/JonnyWalker81/trendy/backend/internal/repository/memory"
+)
+
+type fakeAuthUsers struct {
+	err     error
+	deleted []string
+}
+
+func (f *fakeAuthUsers) DeleteUser(userID string) error {
+	if f.err != nil {
+		return f.err
+	}
+	f.deleted = append(f.deleted, userID)
+	return nil
+}
+
+func TestAccountDeletionResumesAndErasesEverything(t *testing.T) {
+	ctx := context.Background()
+	repos := memory.NewRepositories(memory.NewStore())
+
+	for _, user := range []models.User{{ID: "user-1", Email: "one@example.com"}, {ID: "user-2", Email: "two@example.com"}} {
+		if _, err := repos.Users.Create(ctx, &user); err != nil {
+			t.Fatalf("Create user failed: %v", err)
+		}
+	}
+
+	eventTypeService := NewEventTypeService(repos.EventTypes, repos.Events, repos.PropertyDefinitions, repos.Geofences, repos.Insights, repos.Streaks, repos.ChangeLog, repos.Transactor)
+	eventService := NewEventService(repos.Events, repos.EventTypes, repos.PropertyDefinitions, repos.ChangeLog, repos.Transactor)
+	for _, userID := range []string{"user-1", "user-2"} {
+		et, err := eventTypeService.CreateEventType(ctx, userID, &models.CreateEventTypeRequest{Name: "Run", Color: "#0f0", Icon: "run"})
+		if err != nil {
+			t.Fatalf("CreateEventType failed: %v", err)
+		}
+		if _, _, err := eventService.CreateEvent(ctx, userID, &models.CreateEventRequest{EventTypeID: et.ID, Timestamp: time.Now()}); err != nil {
+			t.Fatalf("CreateEvent failed: %v", err)
+		}
+		if _, err := repos.OnboardingStatus.GetOrCreate(ctx, userID); err != nil {
+			t.Fatalf("GetOrCreate failed: %v", err)
+		}
+		if err := repos.Idempotency.Store(ctx, "key", "POST /api/v1/events", userID, []byte(`{}`), 201); err != nil {
+			t.Fatalf("Store failed: %v", err)
+		}
+		if _, err := repos.Jobs.Enqueue(ctx, &models.Job{Kind: models.JobKindInsights, UserID: userID, RunAt: time.Now()}, 0); err != nil {
+			t.Fatalf("Enqueue failed: %v", err)
+		}
+	}
+
+	// The auth provider fails on the first attempt, after the data is gone
+	authUsers := &fakeAuthUsers{err: errors.New("auth unavailable")}
+	deletionService := NewAccountDeletionService(repos, authUsers)
+	if _, err := deletionService.DeleteAccount(ctx, "user-1"); err == nil {
+		t.Fatal("expected the deletion to fail at the auth user step")
+	}
+
+	pending, err := repos.AccountDeletions.ListPending(ctx)
+	if err != nil || len(pending) != 1 {
+		t.Fatalf("expected 1 pending deletion, got %d (%v)", len(pending), err)
+	}
+	if got := len(pending[0].CompletedSteps); got != len(models.AccountDeletionSteps)-1 {
+		t.Errorf("expected every step but the auth user to be recorded, got %v", pending[0].CompletedSteps)
+	}
+	if pending[0].CompletedSteps[0] != models.AccountDeletionStepJobs {
+		t.Errorf("expected queued jobs to be cancelled first, got %v", pending[0].CompletedSteps)
+	}
+	if got, err := repos.AccountDeletions.GetPending(ctx, "user-1"); err != nil || got == nil || got.ID != pending[0].ID {
+		t.Errorf("expected the pending deletion of user-1, got %+v (%v)", got, err)
+	}
+
+	authUsers.err = nil
+	if resumed, err := deletionService.ResumePending(ctx); err != nil || resumed != 1 {
+		t.Fatalf("expected 1 resumed deletion, got %d (%v)", resumed, err)
+	}
+	if len(authUsers.deleted) != 1 || authUsers.deleted[0] != "user-1" {
+		t.Errorf("expected the auth user to be deleted, got %v", authUsers.deleted)
+	}
+	if got, _ := repos.AccountDeletions.GetPending(ctx, "user-1"); got != nil {
+		t.Errorf("expected no pending deletion once completed, got %+v", got)
+	}
+
+	// A repeated request records a new tombstone and completes immediately
+	deletion, err := deletionService.DeleteAccount(ctx, "user-1")
+	if err != nil {
+		t.Fatalf("DeleteAccount failed: %v", err)
+	}
+	if deletion.Status != models.AccountDeletionCompleted || deletion.CompletedAt == nil || deletion.UserID != "user-1" {
+		t.Errorf("unexpected tombstone %+v", deletion)
+	}
+
+	if _, err := repos.Users.GetByID(ctx, "user-1"); err == nil {
+		t.Error("user-1 must be deleted")
+	}
+	if events, _ := repos.Events.GetByUserID(ctx, "user-1", 100, 0); len(events) != 0 {
+		t.Errorf("expected no events for user-1, got %d", len(events))
+	}
+	if types, _ := repos.EventTypes.GetByUserID(ctx, "user-1"); len(types) != 0 {
+		t.Errorf("expected no event types for user-1, got %d", len(types))
+	}
+	if feed, _ := repos.ChangeLog.GetSince(ctx, "user-1", 0, 100); len(feed.Changes) != 0 {
+		t.Errorf("expected no change log entries for user-1, got %d", len(feed.Changes))
+	}
+	if key, _ := repos.Idempotency.Get(ctx, "key", "POST /api/v1/events", "user-1"); key != nil {
+		t.Error("expected the idempotency key of user-1 to be deleted")
+	}
+
+	// Nothing of user-2 is touched
+	if events, _ := repos.Events.GetByUserID(ctx, "user-2", 100, 0); len(events) != 1 {
+		t.Errorf("expected user-2 to keep 1 event, got %d", len(events))
+	}
+	if feed, _ := repos.ChangeLog.GetSince(ctx, "user-2", 0, 100); len(feed.Changes) != 2 {
+		t.Errorf("expected user-2 to keep 2 change log entries, got %d", len(feed.Changes))
+	}
+	if key, _ := repos.Idempotency.Get(ctx, "key", "POST /api/v1/events", "user-2"); key == nil {
+		t.Error("expected user-2 to keep its idempotency key")
+	}
+	if job, _ := repos.Jobs.GetLatest(ctx, models.JobKindInsights, "user-2"); job == nil {
+		t.Error("expected user-2 to keep its queued job")
+	}
+}
@@ -0,0 +1,355 @@
+package service
+
+import (
+	"archive/zip"
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"hash"
+	"io"
+	"time"
+
+	"github.com/JonnyWalker81/trendy/backend/internal/logger"
+	"github.com/JonnyWalker81/trendy/backend/internal/models"
+	"github.com/JonnyWalker81/trendy/backend/internal/repository"
+)
+
+// accountExportRetention is how long a completed archive can be downloaded
+const accountExportRetention = 7 * 24 * time.Hour
+
+var (
+	// ErrAccountExportNotReady is returned when downloading an export that
+	// has not completed
+	ErrAccountExportNotReady = errors.New("account export is not ready")
+	// ErrAccountExportExpired is returned when downloading an export past
+	// its expiry
+	ErrAccountExportExpired = errors.New("account export has expired")
+)
+
+type accountExportService struct {
+	accountExportRepo repository.AccountExportRepository
+	exportService     ExportService
+	userRepo          repository.UserRepository
+	eventTypeRepo     repository.EventTypeRepository
+	propertyDefRepo   repository.PropertyDefinitionRepository
+	geofenceRepo      repository.GeofenceRepository
+	insightRepo       repository.InsightRepository
+	streakRepo        repository.StreakRepository
+	aggregateRepo     repository.DailyAggregateRepository
+	onboardingRepo    repository.OnboardingStatusRepository
+}
+
+// NewAccountExportService creates a new account export service. Events are
+// written to the archive with exportService. Exports are built in a
+// goroutine of the process that accepted them.
+func NewAccountExportService(
+	accountExportRepo repository.AccountExportRepository,
+	exportService ExportService,
+	userRepo repository.UserRepository,
+	eventTypeRepo repository.EventTypeRepository,
+	propertyDefRepo repository.PropertyDefinitionRepository,
+	geofenceRepo repository.GeofenceRepository,
+	insightRepo repository.InsightRepository,
+	streakRepo repository.StreakRepository,
+	aggregateRepo repository.DailyAggregateRepository,
+	onboardingRepo repository.OnboardingStatusRepository,
+) AccountExportService {
+	return &accountExportService{
+		accountExportRepo: accountExportRepo,
+		exportService:     exportService,
+		userRepo:          userRepo,
+		eventTypeRepo:     eventTypeRepo,
+		propertyDefRepo:   propertyDefRepo,
+		geofenceRepo:      geofenceRepo,
+		insightRepo:       insightRepo,
+		streakRepo:        streakRepo,
+		aggregateRepo:     aggregateRepo,
+		onboardingRepo:    onboardingRepo,
+	}
+}
+
+func (s *accountExportService) StartExport(ctx context.Context, userID string) (*models.AccountExport, error) {
+	// Only the latest export is kept
+	if err := s.accountExportRepo.DeleteByUserID(ctx, userID); err != nil {
+		return nil, err
+	}
+
+	export, err := s.accountExportRepo.Create(ctx, &models.AccountExport{
+		UserID: userID,
+		Status: models.AccountExportPending,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	background := *export
+	go s.run(context.WithoutCancel(ctx), &background)
+
+	return export, nil
+}
+
+func (s *accountExportService) GetExport(ctx context.Context, userID, exportID string) (*models.AccountExport, error) {
+	export, err := s.accountExportRepo.GetByID(ctx, exportID)
+	if err != nil {
+		return nil, err
+	}
+
+	if export.UserID != userID {
+		return nil, fmt.Errorf("account export not found")
+	}
+
+	return export, nil
+}
+
+func (s *accountExportService) GetArchive(ctx context.Context, userID, exportID string) (*models.AccountExport, []byte, error) {
+	export, err := s.GetExport(ctx, userID, exportID)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	if export.Status != models.AccountExportCompleted {
+		return nil, nil, ErrAccountExportNotReady
+	}
+	if export.ExpiresAt != nil && time.Now().After(*export.ExpiresAt) {
+		return nil, nil, ErrAccountExportExpired
+	}
+
+	archive, err := s.accountExportRepo.GetArchive(ctx, exportID)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return export, archive, nil
+}
+
+func (s *accountExportService) run(ctx context.Context, export *models.AccountExport) {
+	export.Status = models.AccountExportRunning
+	s.saveStatus(ctx, export, nil)
+
+	var buf bytes.Buffer
+	if err := s.writeArchive(ctx, export.UserID, &buf); err != nil {
+		logger.Ctx(ctx).Error("account export failed",
+			logger.Err(err),
+			logger.String("export_id", export.ID),
+		)
+		message := err.Error()
+		export.Status = models.AccountExportFailed
+		export.Error = &message
+		completedAt := time.Now().UTC()
+		export.CompletedAt = &completedAt
+		s.saveStatus(ctx, export, nil)
+		return
+	}
+
+	completedAt := time.Now().UTC()
+	expiresAt := completedAt.Add(accountExportRetention)
+	export.Status = models.AccountExportCompleted
+	export.SizeBytes = int64(buf.Len())
+	export.CompletedAt = &completedAt
+	export.ExpiresAt = &expiresAt
+	s.saveStatus(ctx, export, buf.Bytes())
+}
+
+func (s *accountExportService) saveStatus(ctx context.Context, export *models.AccountExport, archive []byte) {
+	if _, err := s.accountExportRepo.UpdateStatus(ctx, export, archive); err != nil {
+		logger.Ctx(ctx).Error("failed to update account export",
+			logger.Err(err),
+			logger.String("export_id", export.ID),
+		)
+	}
+}
+
+// writeArchive writes a zip of everything the user owns to w. Each file is
+// listed in manifest.json with its record count and SHA-256.
+func (s *accountExportService) writeArchive(ctx context.Context, userID string, w io.Writer) error {
+	a := &archiveWriter{
+		zw: zip.NewWriter(w),
+		manifest: models.AccountArchiveManifest{
+			Format:     models.AccountArchiveFormat,
+			Version:    models.AccountArchiveVersion,
+			ExportedAt: time.Now().UTC(),
+			UserID:     userID,
+			Files:      []models.AccountArchiveFile{},
+		},
+	}
+
+	user, err := s.userRepo.GetByID(ctx, userID)
+	if err != nil {
+		return fmt.Errorf("failed to get user: %w", err)
+	}
+	if err := a.writeJSON(models.AccountArchiveUserFile, user, 1); err != nil {
+		return err
+	}
+
+	eventTypes, err := s.eventTypeRepo.GetByUserID(ctx, userID)
+	if err != nil {
+		return fmt.Errorf("failed to get event types: %w", err)
+	}
+	if err := a.writeJSON(models.AccountArchiveEventTypesFile, eventTypes, len(eventTypes)); err != nil {
+		return err
+	}
+
+	defs := make([]models.PropertyDefinition, 0)
+	for _, et := range eventTypes {
+		typeDefs, err := s.propertyDefRepo.GetByEventTypeID(ctx, et.ID)
+		if err != nil {
+			return fmt.Errorf("failed to get property definitions: %w", err)
+		}
+		defs = append(defs, typeDefs...)
+	}
+	if err := a.writeJSON(models.AccountArchivePropertyDefinitionsFile, defs, len(defs)); err != nil {
+		return err
+	}
+
+	geofences, err := s.geofenceRepo.GetByUserID(ctx, userID)
+	if err != nil {
+		return fmt.Errorf("failed to get geofences: %w", err)
+	}
+	if err := a.writeJSON(models.AccountArchiveGeofencesFile, geofences, len(geofences)); err != nil {
+		return err
+	}
+
+	// events.csv holds the same events as events.ndjson, flattened for
+	// spreadsheets
+	var eventCount int
+	for _, format := range []models.ExportFormat{models.ExportFormatNDJSON, models.ExportFormatCSV} {
+		name := models.AccountArchiveEventsFile
+		if format == models.ExportFormatCSV {
+			name = models.AccountArchiveEventsCSVFile
+		}
+
+		f, err := a.create(name)
+		if err != nil {
+			return err
+		}
+		counter := &lineCounter{w: f}
+		if err := s.exportService.ExportEvents(ctx, userID, &models.ExportEventsRequest{Format: format}, counter); err != nil {
+			return fmt.Errorf("failed to export events: %w", err)
+		}
+		if format == models.ExportFormatNDJSON {
+			eventCount = counter.lines
+		}
+		a.finish(eventCount)
+	}
+
+	insights, err := s.insightRepo.GetByUserID(ctx, userID)
+	if err != nil {
+		return fmt.Errorf("failed to get insights: %w", err)
+	}
+	if err := a.writeJSON(models.AccountArchiveInsightsFile, insights, len(insights)); err != nil {
+		return err
+	}
+
+	streaks, err := s.streakRepo.GetByUserID(ctx, userID)
+	if err != nil {
+		return fmt.Errorf("failed to get streaks: %w", err)
+	}
+	if err := a.writeJSON(models.AccountArchiveStreaksFile, streaks, len(streaks)); err != nil {
+		return err
+	}
+
+	aggregates, err := s.aggregateRepo.GetByUserID(ctx, userID)
+	if err != nil {
+		return fmt.Errorf("failed to get daily aggregates: %w", err)
+	}
+	if err := a.writeJSON(models.AccountArchiveDailyAggregatesFile, aggregates, len(aggregates)); err != nil {
+		return err
+	}
+
+	onboarding, err := s.onboardingRepo.GetOrCreate(ctx, userID)
+	if err != nil {
+		return fmt.Errorf("failed to get onboarding status: %w", err)
+	}
+	if err := a.writeJSON(models.AccountArchiveOnboardingStatusFile, onboarding, 1); err != nil {
+		return err
+	}
+
+	return a.close()
+}
+
+// archiveWriter writes the files of an account archive and collects the
+// manifest entry of each
+type archiveWriter struct {
+	zw       *zip.Writer
+	manifest models.AccountArchiveManifest
+	name     string
+	hash     hash.Hash
+}
+
+// create starts a file. It must be completed with finish before the next
+// file is created.
+func (a *archiveWriter) create(name string) (io.Writer, error) {
+	f, err := a.zw.CreateHeader(&zip.FileHeader{
+		Name:     name,
+		Method:   zip.Deflate,
+		Modified: a.manifest.ExportedAt,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to add %s to archive: %w", name, err)
+	}
+	a.name = name
+	a.hash = sha256.New()
+	return io.MultiWriter(f, a.hash), nil
+}
+
+func (a *archiveWriter) finish(records int) {
+	a.manifest.Files = append(a.manifest.Files, models.AccountArchiveFile{
+		Name:    a.name,
+		Records: records,
+		SHA256:  hex.EncodeToString(a.hash.Sum(nil)),
+	})
+}
+
+func (a *archiveWriter) writeJSON(name string, v interface{}, records int) error {
+	data, err := json.MarshalIndent(v, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to encode %s: %w", name, err)
+	}
+
+	f, err := a.create(name)
+	if err != nil {
+		return err
+	}
+	if _, err := f.Write(data); err != nil {
+		return fmt.Errorf("failed to write %s: %w", name, err)
+	}
+	a.finish(records)
+	return nil
+}
+
+// close writes the manifest and the zip directory
+func (a *archiveWriter) close() error {
+	data, err := json.MarshalIndent(a.manifest, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to encode manifest: %w", err)
+	}
+
+	f, err := a.zw.CreateHeader(&zip.FileHeader{
+		Name:     models.AccountArchiveManifestFile,
+		Method:   zip.Deflate,
+		Modified: a.manifest.ExportedAt,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to add manifest to archive: %w", err)
+	}
+	if _, err := f.Write(data); err != nil {
+		return fmt.Errorf("failed to write manifest: %w", err)
+	}
+
+	return a.zw.Close()
+}
+
+// lineCounter counts the newlines written through it, one per NDJSON record
+type lineCounter struct {
+	w     io.Writer
+	lines int
+}
+
+func (c *lineCounter) Write(p []byte) (int, error) {
+	c.lines += bytes.Count(p, []byte{'\n'})
+	return c.w.Write(p)
+}
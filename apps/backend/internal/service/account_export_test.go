@@ -0,0 +1,190 @@
+package service
+
+import (
+	"archive/zip"
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"testing"
+	"time"
+
+	"github.com/JonnyWalker81/trendy/backend/internal/models"
+	"github.com/JonnyWalker81/trendy/backend/internal/repository/memory"
+)
+
+func TestAccountExportAndRestore(t *testing.T) {
+	ctx := context.Background()
+	repos := memory.NewRepositories(memory.NewStore())
+
+	for _, user := range []models.User{{ID: "user-1", Email: "one@example.com"}, {ID: "user-2", Email: "two@example.com"}} {
+		if _, err := repos.Users.Create(ctx, &user); err != nil {
+			t.Fatalf("Create user failed: %v", err)
+		}
+	}
+
+	eventTypeService := NewEventTypeService(repos.EventTypes, repos.Events, repos.PropertyDefinitions, repos.Geofences, repos.Insights, repos.Streaks, repos.ChangeLog, repos.Transactor)
+	propertyDefService := NewPropertyDefinitionService(repos.PropertyDefinitions, repos.EventTypes, repos.Events, repos.ChangeLog, repos.Transactor)
+	eventService := NewEventService(repos.Events, repos.EventTypes, repos.PropertyDefinitions, repos.ChangeLog, repos.Transactor)
+	exportService := NewExportService(repos.Events, repos.EventTypes, repos.PropertyDefinitions)
+	importService := NewImportService(repos.Events, repos.EventTypes, repos.PropertyDefinitions, repos.Geofences, repos.OnboardingStatus, repos.ImportJobs, repos.ChangeLog, repos.Transactor)
+	accountExportService := NewAccountExportService(repos.AccountExports, exportService, repos.Users, repos.EventTypes, repos.PropertyDefinitions, repos.Geofences, repos.Insights, repos.Streaks, repos.DailyAggregates, repos.OnboardingStatus)
+
+	run, err := eventTypeService.CreateEventType(ctx, "user-1", &models.CreateEventTypeRequest{Name: "Run", Color: "#0f0", Icon: "run"})
+	if err != nil {
+		t.Fatalf("CreateEventType failed: %v", err)
+	}
+	if _, err := propertyDefService.CreatePropertyDefinition(ctx, "user-1", &models.CreatePropertyDefinitionRequest{
+		EventTypeID: run.ID, Key: "distance", Label: "Distance", PropertyType: models.PropertyTypeNumber,
+	}); err != nil {
+		t.Fatalf("CreatePropertyDefinition failed: %v", err)
+	}
+	for i := 0; i < 3; i++ {
+		if _, _, err := eventService.CreateEvent(ctx, "user-1", &models.CreateEventRequest{
+			EventTypeID: run.ID,
+			Timestamp:   time.Date(2026, 1, 1+i, 8, 0, 0, 0, time.UTC),
+			Properties: map[string]models.PropertyValue{
+				"distance": {Type: models.PropertyTypeNumber, Value: float64(5 + i)},
+			},
+		}); err != nil {
+			t.Fatalf("CreateEvent failed: %v", err)
+		}
+	}
+
+	export, err := accountExportService.StartExport(ctx, "user-1")
+	if err != nil {
+		t.Fatalf("StartExport failed: %v", err)
+	}
+	if _, _, err := accountExportService.GetArchive(ctx, "user-1", export.ID); !errors.Is(err, ErrAccountExportNotReady) && err != nil {
+		t.Fatalf("unexpected GetArchive error before completion: %v", err)
+	}
+
+	deadline := time.Now().Add(5 * time.Second)
+	for export.Status != models.AccountExportCompleted && export.Status != models.AccountExportFailed {
+		if time.Now().After(deadline) {
+			t.Fatalf("export did not finish, last status %s", export.Status)
+		}
+		time.Sleep(10 * time.Millisecond)
+		if export, err = accountExportService.GetExport(ctx, "user-1", export.ID); err != nil {
+			t.Fatalf("GetExport failed: %v", err)
+		}
+	}
+	if export.Status != models.AccountExportCompleted || export.ExpiresAt == nil {
+		t.Fatalf("unexpected export %+v", export)
+	}
+	if _, err := accountExportService.GetExport(ctx, "user-2", export.ID); err == nil {
+		t.Error("another user must not see the export")
+	}
+
+	_, archive, err := accountExportService.GetArchive(ctx, "user-1", export.ID)
+	if err != nil {
+		t.Fatalf("GetArchive failed: %v", err)
+	}
+	if int64(len(archive)) != export.SizeBytes {
+		t.Errorf("expected %d bytes, got %d", export.SizeBytes, len(archive))
+	}
+
+	manifest := readManifest(t, archive)
+	records := make(map[string]int)
+	for _, f := range manifest.Files {
+		records[f.Name] = f.Records
+	}
+	if manifest.Version != models.AccountArchiveVersion || len(manifest.Files) != 10 {
+		t.Fatalf("unexpected manifest %+v", manifest)
+	}
+	if records[models.AccountArchiveEventsFile] != 3 || records[models.AccountArchiveEventTypesFile] != 1 || records[models.AccountArchivePropertyDefinitionsFile] != 1 {
+		t.Errorf("unexpected record counts %v", records)
+	}
+
+	job, err := importService.StartRestore(ctx, "user-2", archive)
+	if err != nil {
+		t.Fatalf("StartRestore failed: %v", err)
+	}
+	for job.Status != models.ImportJobCompleted && job.Status != models.ImportJobFailed {
+		if time.Now().After(deadline) {
+			t.Fatalf("restore did not finish, last status %s", job.Status)
+		}
+		time.Sleep(10 * time.Millisecond)
+		if job, err = importService.GetImportJob(ctx, "user-2", job.ID); err != nil {
+			t.Fatalf("GetImportJob failed: %v", err)
+		}
+	}
+	if job.Status != models.ImportJobCompleted || job.Format != models.ImportFormatArchive || job.CreatedCount != 3 {
+		t.Fatalf("unexpected restore job %+v", job)
+	}
+
+	types, err := repos.EventTypes.GetByUserID(ctx, "user-2")
+	if err != nil || len(types) != 1 || types[0].Name != "Run" || types[0].ID == run.ID {
+		t.Fatalf("expected a new Run event type, got %+v (%v)", types, err)
+	}
+	defs, err := repos.PropertyDefinitions.GetByEventTypeID(ctx, types[0].ID)
+	if err != nil || len(defs) != 1 || defs[0].Key != "distance" {
+		t.Errorf("expected the distance definition to be restored, got %+v (%v)", defs, err)
+	}
+	events, err := repos.Events.GetByUserID(ctx, "user-2", 100, 0)
+	if err != nil || len(events) != 3 {
+		t.Fatalf("expected 3 restored events, got %d (%v)", len(events), err)
+	}
+	for _, event := range events {
+		if event.EventTypeID != types[0].ID || event.Properties["distance"].Value == nil {
+			t.Errorf("unexpected restored event %+v", event)
+		}
+	}
+
+	// A tampered file fails the checksum check
+	tampered := rewriteArchiveFile(t, archive, models.AccountArchiveEventsFile, []byte("{}\n"))
+	if _, err := importService.StartRestore(ctx, "user-2", tampered); !errors.Is(err, ErrInvalidImportFile) {
+		t.Errorf("expected ErrInvalidImportFile for a tampered archive, got %v", err)
+	}
+}
+
+func readManifest(t *testing.T, archive []byte) models.AccountArchiveManifest {
+	t.Helper()
+	zr, err := zip.NewReader(bytes.NewReader(archive), int64(len(archive)))
+	if err != nil {
+		t.Fatalf("invalid zip: %v", err)
+	}
+	rc, err := zr.Open(models.AccountArchiveManifestFile)
+	if err != nil {
+		t.Fatalf("missing manifest: %v", err)
+	}
+	defer rc.Close()
+
+	var manifest models.AccountArchiveManifest
+	if err := json.NewDecoder(rc).Decode(&manifest); err != nil {
+		t.Fatalf("invalid manifest: %v", err)
+	}
+	return manifest
+}
+
+func rewriteArchiveFile(t *testing.T, archive []byte, name string, content []byte) []byte {
+	t.Helper()
+	zr, err := zip.NewReader(bytes.NewReader(archive), int64(len(archive)))
+	if err != nil {
+		t.Fatalf("invalid zip: %v", err)
+	}
+
+	var buf bytes.Buffer
+	zw := zip.NewWriter(&buf)
+	for _, f := range zr.File {
+		w, err := zw.Create(f.Name)
+		if err != nil {
+			t.Fatalf("Create failed: %v", err)
+		}
+		if f.Name == name {
+			w.Write(content)
+			continue
+		}
+		rc, err := f.Open()
+		if err != nil {
+			t.Fatalf("Open failed: %v", err)
+		}
+		io.Copy(w, rc)
+		rc.Close()
+	}
+	if err := zw.Close(); err != nil {
+		t.Fatalf("Close failed: %v", err)
+	}
+	return buf.Bytes()
+}
@@ -0,0 +1,399 @@
+package service
+
+import (
+	"archive/zip"
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+
+	"github.com/JonnyWalker81/trendy/backend/internal/apierror"
+	"github.com/JonnyWalker81/trendy/backend/internal/logger"
+	"github.com/JonnyWalker81/trendy/backend/internal/models"
+	"github.com/google/uuid"
+)
+
+// accountArchive is the restorable content of an account archive. Insights,
+// streaks and daily aggregates are derived from events and are rebuilt
+// instead of restored.
+type accountArchive struct {
+	eventTypes []models.EventType
+	defs       []models.PropertyDefinition
+	geofences  []models.Geofence
+	events     []models.Event
+	onboarding *models.OnboardingStatus
+}
+
+// StartRestore reads an account archive and starts a job restoring it into
+// the user's account. Entities get new IDs, so an archive can be restored
+// into any account; event types are matched to existing ones by name.
+func (s *importService) StartRestore(ctx context.Context, userID string, data []byte) (*models.ImportJob, error) {
+	archive, err := readAccountArchive(data)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidImportFile, err)
+	}
+
+	job, err := s.importJobRepo.Create(ctx, &models.ImportJob{
+		UserID:    userID,
+		Status:    models.ImportJobPending,
+		Format:    models.ImportFormatArchive,
+		TotalRows: len(archive.events),
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to create import job: %w", err)
+	}
+
+	background := *job
+	go s.runRestore(context.WithoutCancel(ctx), &background, archive)
+
+	return job, nil
+}
+
+func (s *importService) runRestore(ctx context.Context, job *models.ImportJob, archive *accountArchive) {
+	job.Status = models.ImportJobRunning
+	s.saveProgress(ctx, job)
+
+	if err := s.restore(ctx, job, archive); err != nil {
+		logger.Ctx(ctx).Error("account restore failed",
+			logger.Err(err),
+			logger.String("job_id", job.ID),
+		)
+		message := err.Error()
+		job.Status = models.ImportJobFailed
+		job.Error = &message
+	} else {
+		job.Status = models.ImportJobCompleted
+	}
+
+	completedAt := time.Now().UTC()
+	job.CompletedAt = &completedAt
+	s.saveProgress(ctx, job)
+}
+
+func (s *importService) restore(ctx context.Context, job *models.ImportJob, archive *accountArchive) error {
+	userID := job.UserID
+
+	existingTypes, err := s.eventTypeRepo.GetByUserID(ctx, userID)
+	if err != nil {
+		return fmt.Errorf("failed to get event types: %w", err)
+	}
+	typesByName := make(map[string]string, len(existingTypes))
+	for _, et := range existingTypes {
+		typesByName[strings.ToLower(et.Name)] = et.ID
+	}
+
+	// Archived IDs to the IDs in this account
+	typeIDs := make(map[string]string, len(archive.eventTypes))
+	for _, et := range archive.eventTypes {
+		if id, ok := typesByName[strings.ToLower(et.Name)]; ok {
+			typeIDs[et.ID] = id
+			continue
+		}
+
+		created, err := s.createEventType(ctx, &models.EventType{
+			UserID: userID,
+			Name:   et.Name,
+			Color:  et.Color,
+			Icon:   et.Icon,
+		})
+		if err != nil {
+			return fmt.Errorf("failed to create event type %q: %w", et.Name, err)
+		}
+		if et.ArchivedAt != nil {
+			if err := s.archiveEventType(ctx, created.ID, *et.ArchivedAt); err != nil {
+				return fmt.Errorf("failed to archive event type %q: %w", et.Name, err)
+			}
+		}
+		typeIDs[et.ID] = created.ID
+		typesByName[strings.ToLower(et.Name)] = created.ID
+	}
+
+	// Definitions an existing event type already has for a key are kept
+	existingKeys := make(map[string]bool)
+	for _, eventTypeID := range typeIDs {
+		defs, err := s.propertyDefRepo.GetByEventTypeID(ctx, eventTypeID)
+		if err != nil {
+			return fmt.Errorf("failed to get property definitions: %w", err)
+		}
+		for _, def := range defs {
+			existingKeys[eventTypeID+"\x00"+def.Key] = true
+		}
+	}
+	for _, def := range archive.defs {
+		eventTypeID, ok := typeIDs[def.EventTypeID]
+		if !ok || existingKeys[eventTypeID+"\x00"+def.Key] {
+			continue
+		}
+		if _, err := s.createPropertyDefinition(ctx, &models.PropertyDefinition{
+			EventTypeID:  eventTypeID,
+			UserID:       userID,
+			Key:          def.Key,
+			Label:        def.Label,
+			PropertyType: def.PropertyType,
+			Options:      def.Options,
+			DefaultValue: def.DefaultValue,
+			Required:     def.Required,
+			Unit:         def.Unit,
+			RatingMax:    def.RatingMax,
+			Formula:      def.Formula,
+			DisplayOrder: def.DisplayOrder,
+		}); err != nil {
+			return fmt.Errorf("failed to create property definition %q: %w", def.Key, err)
+		}
+		existingKeys[eventTypeID+"\x00"+def.Key] = true
+	}
+
+	geofenceIDs := make(map[string]string, len(archive.geofences))
+	for _, g := range archive.geofences {
+		created, err := s.createGeofence(ctx, &models.Geofence{
+			ID:               uuid.Must(uuid.NewV7()).String(),
+			UserID:           userID,
+			Name:             g.Name,
+			Latitude:         g.Latitude,
+			Longitude:        g.Longitude,
+			Radius:           g.Radius,
+			EventTypeEntryID: remapID(typeIDs, g.EventTypeEntryID),
+			EventTypeExitID:  remapID(typeIDs, g.EventTypeExitID),
+			IsActive:         g.IsActive,
+			NotifyOnEntry:    g.NotifyOnEntry,
+			NotifyOnExit:     g.NotifyOnExit,
+		})
+		if err != nil {
+			return fmt.Errorf("failed to create geofence %q: %w", g.Name, err)
+		}
+		geofenceIDs[g.ID] = created.ID
+	}
+
+	events := make([]models.Event, 0, len(archive.events))
+	rows := make([]int, 0, len(archive.events))
+	for i, e := range archive.events {
+		eventTypeID, ok := typeIDs[e.EventTypeID]
+		if !ok {
+			job.ProcessedRows++
+			job.FailedCount++
+			job.Errors = appendRowError(job.Errors, models.ImportRowError{
+				Row: i + 1,
+				Errors: []apierror.FieldError{{
+					Field:   "event_type_id",
+					Message: "is not in the archive's event types",
+					Code:    "not_found",
+				}},
+			})
+			continue
+		}
+
+		events = append(events, models.Event{
+			UserID:            userID,
+			EventTypeID:       eventTypeID,
+			Timestamp:         e.Timestamp,
+			Notes:             e.Notes,
+			IsAllDay:          e.IsAllDay,
+			EndDate:           e.EndDate,
+			SourceType:        e.SourceType,
+			ExternalID:        e.ExternalID,
+			OriginalTitle:     e.OriginalTitle,
+			GeofenceID:        remapID(geofenceIDs, e.GeofenceID),
+			LocationLatitude:  e.LocationLatitude,
+			LocationLongitude: e.LocationLongitude,
+			LocationName:      e.LocationName,
+			HealthKitSampleID: e.HealthKitSampleID,
+			HealthKitCategory: e.HealthKitCategory,
+			Properties:        e.Properties,
+		})
+		rows = append(rows, i+1)
+	}
+	s.insertBatches(ctx, job, events, rows)
+
+	// Onboarding is only restored into an account that has not finished it
+	if archive.onboarding != nil {
+		current, err := s.onboardingRepo.GetOrCreate(ctx, userID)
+		if err != nil {
+			return fmt.Errorf("failed to get onboarding status: %w", err)
+		}
+		if !current.Completed {
+			if _, err := s.onboardingRepo.Update(ctx, userID, archive.onboarding); err != nil {
+				return fmt.Errorf("failed to restore onboarding status: %w", err)
+			}
+		}
+	}
+
+	return nil
+}
+
+// archiveEventType archives a restored event type and logs the update
+func (s *importService) archiveEventType(ctx context.Context, eventTypeID string, archivedAt time.Time) error {
+	return s.tx.WithinTx(ctx, func(ctx context.Context) error {
+		updated, err := s.eventTypeRepo.SetArchived(ctx, eventTypeID, &archivedAt)
+		if err != nil {
+			return err
+		}
+
+		_, err = s.changeLogRepo.Append(ctx, &models.ChangeLogInput{
+			EntityType: models.EntityTypeEventType,
+			Operation:  models.OperationUpdate,
+			EntityID:   updated.ID,
+			UserID:     updated.UserID,
+			Data:       updated,
+		})
+		return err
+	})
+}
+
+func (s *importService) createGeofence(ctx context.Context, geofence *models.Geofence) (*models.Geofence, error) {
+	var created *models.Geofence
+	err := s.tx.WithinTx(ctx, func(ctx context.Context) error {
+		var err error
+		created, err = s.geofenceRepo.Create(ctx, geofence)
+		if err != nil {
+			return err
+		}
+
+		_, err = s.changeLogRepo.Append(ctx, &models.ChangeLogInput{
+			EntityType: models.EntityTypeGeofence,
+			Operation:  models.OperationCreate,
+			EntityID:   created.ID,
+			UserID:     created.UserID,
+			Data:       created,
+		})
+		return err
+	})
+	return created, err
+}
+
+// remapID translates an archived ID, dropping references to entities that
+// were not restored
+func remapID(ids map[string]string, id *string) *string {
+	if id == nil {
+		return nil
+	}
+	mapped, ok := ids[*id]
+	if !ok {
+		return nil
+	}
+	return &mapped
+}
+
+// readAccountArchive checks an archive's manifest and checksums and decodes
+// the files a restore needs
+func readAccountArchive(data []byte) (*accountArchive, error) {
+	zr, err := zip.NewReader(bytes.NewReader(data), int64(len(data)))
+	if err != nil {
+		return nil, fmt.Errorf("not a zip archive: %w", err)
+	}
+
+	files := make(map[string]*zip.File, len(zr.File))
+	for _, f := range zr.File {
+		files[f.Name] = f
+	}
+
+	manifestFile, ok := files[models.AccountArchiveManifestFile]
+	if !ok {
+		return nil, fmt.Errorf("archive has no %s", models.AccountArchiveManifestFile)
+	}
+	manifestData, err := readZipFile(manifestFile)
+	if err != nil {
+		return nil, err
+	}
+	var manifest models.AccountArchiveManifest
+	if err := json.Unmarshal(manifestData, &manifest); err != nil {
+		return nil, fmt.Errorf("invalid manifest: %w", err)
+	}
+	if manifest.Format != models.AccountArchiveFormat {
+		return nil, fmt.Errorf("not an account archive")
+	}
+	if manifest.Version < 1 || manifest.Version > models.AccountArchiveVersion {
+		return nil, fmt.Errorf("unsupported archive version %d", manifest.Version)
+	}
+
+	listed := make(map[string]models.AccountArchiveFile, len(manifest.Files))
+	for _, f := range manifest.Files {
+		listed[f.Name] = f
+	}
+
+	// read returns a listed file after checking it against the manifest, or
+	// nil if the archive does not have it
+	read := func(name string, required bool) ([]byte, error) {
+		entry, ok := listed[name]
+		if !ok {
+			if required {
+				return nil, fmt.Errorf("archive has no %s", name)
+			}
+			return nil, nil
+		}
+		f, ok := files[name]
+		if !ok {
+			return nil, fmt.Errorf("archive is missing %s", name)
+		}
+		content, err := readZipFile(f)
+		if err != nil {
+			return nil, err
+		}
+		sum := sha256.Sum256(content)
+		if hex.EncodeToString(sum[:]) != entry.SHA256 {
+			return nil, fmt.Errorf("checksum mismatch for %s", name)
+		}
+		return content, nil
+	}
+
+	archive := &accountArchive{}
+	for _, file := range []struct {
+		name     string
+		required bool
+		dest     interface{}
+	}{
+		{models.AccountArchiveEventTypesFile, true, &archive.eventTypes},
+		{models.AccountArchivePropertyDefinitionsFile, false, &archive.defs},
+		{models.AccountArchiveGeofencesFile, false, &archive.geofences},
+		{models.AccountArchiveOnboardingStatusFile, false, &archive.onboarding},
+	} {
+		content, err := read(file.name, file.required)
+		if err != nil {
+			return nil, err
+		}
+		if content == nil {
+			continue
+		}
+		if err := json.Unmarshal(content, file.dest); err != nil {
+			return nil, fmt.Errorf("invalid %s: %w", file.name, err)
+		}
+	}
+
+	content, err := read(models.AccountArchiveEventsFile, true)
+	if err != nil {
+		return nil, err
+	}
+	dec := json.NewDecoder(bytes.NewReader(content))
+	for {
+		var event models.Event
+		err := dec.Decode(&event)
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("invalid %s at record %d: %w", models.AccountArchiveEventsFile, len(archive.events)+1, err)
+		}
+		archive.events = append(archive.events, event)
+	}
+
+	return archive, nil
+}
+
+func readZipFile(f *zip.File) ([]byte, error) {
+	rc, err := f.Open()
+	if err != nil {
+		return nil, fmt.Errorf("failed to open %s: %w", f.Name, err)
+	}
+	defer rc.Close()
+
+	content, err := io.ReadAll(rc)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read %s: %w", f.Name, err)
+	}
+	return content, nil
+}
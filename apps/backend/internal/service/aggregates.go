@@ -0,0 +1,474 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/JonnyWalker81/trendy/backend/internal/models"
+	"github.com/JonnyWalker81/trendy/backend/internal/repository"
+)
+
+// aggregateSyncPageSize is the number of change log entries applied at a time
+const aggregateSyncPageSize = 200
+
+// aggregateBackfillPageSize is the number of events loaded, and aggregates
+// written, per query during a backfill
+const aggregateBackfillPageSize = 1000
+
+// aggregateSettleWindow is how old a change log entry must be before a sync
+// moves the cursor past it. Change log IDs become visible in commit order,
+// so an entry is only passed once any transaction that could still commit a
+// lower ID has finished. It must exceed the longest write transaction.
+const aggregateSettleWindow = 30 * time.Second
+
+// AggregateSyncResult summarizes an aggregate sync or backfill for one user
+type AggregateSyncResult struct {
+	UserID   string `json:"user_id"`
+	Timezone string `json:"timezone"` // Time zone of the aggregates' days
+	Rebuilt  bool   `json:"rebuilt"`  // Every aggregate was rebuilt from events
+	Changes  int    `json:"changes"`  // Event change log entries applied
+	Days     int    `json:"days"`     // Local days recomputed
+	Cursor   int64  `json:"cursor"`   // Change log cursor the aggregates are at
+	Pending  bool   `json:"pending"`  // Later entries are waiting to settle
+}
+
+type aggregateService struct {
+	eventRepo     repository.EventRepository
+	aggregateRepo repository.DailyAggregateRepository
+	stateRepo     repository.AggregateStateRepository
+	changeLogRepo repository.ChangeLogRepository
+	settingsRepo  repository.UserSettingsRepository
+	now           func() time.Time
+}
+
+// NewAggregateService creates a service that keeps daily aggregates up to
+// date from the change log
+func NewAggregateService(
+	eventRepo repository.EventRepository,
+	aggregateRepo repository.DailyAggregateRepository,
+	stateRepo repository.AggregateStateRepository,
+	changeLogRepo repository.ChangeLogRepository,
+	settingsRepo repository.UserSettingsRepository,
+) AggregateService {
+	return &aggregateService{
+		eventRepo:     eventRepo,
+		aggregateRepo: aggregateRepo,
+		stateRepo:     stateRepo,
+		changeLogRepo: changeLogRepo,
+		settingsRepo:  settingsRepo,
+		now:           time.Now,
+	}
+}
+
+func (s *aggregateService) Sync(ctx context.Context, userID string) (*AggregateSyncResult, error) {
+	// Aggregates are days in the user's stored time zone
+	cal, err := loadCalendar(ctx, s.settingsRepo, userID, models.CalendarOptions{})
+	if err != nil {
+		return nil, fmt.Errorf("failed to get user settings: %w", err)
+	}
+
+	state, err := s.stateRepo.GetByUserID(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	horizon, err := s.changeLogRepo.GetHorizon(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	if state == nil || state.Timezone != cal.loc.String() || state.Cursor < horizon {
+		return s.backfill(ctx, userID, cal)
+	}
+
+	settled, err := s.changeLogRepo.GetSettledCursor(ctx, userID, s.now().Add(-aggregateSettleWindow))
+	if err != nil {
+		return nil, err
+	}
+
+	result := &AggregateSyncResult{UserID: userID, Timezone: state.Timezone, Cursor: state.Cursor}
+	for result.Cursor < settled {
+		feed, err := s.changeLogRepo.GetSince(ctx, userID, result.Cursor, aggregateSyncPageSize)
+		if err != nil {
+			return nil, err
+		}
+		page := feed.Changes
+		for len(page) > 0 && page[len(page)-1].ID > settled {
+			page = page[:len(page)-1]
+		}
+		if len(page) == 0 {
+			break
+		}
+
+		changes, days, err := s.apply(ctx, userID, cal, page)
+		if err != nil {
+			return nil, err
+		}
+		result.Changes += changes
+		result.Days += days
+
+		// The cursor only moves past a page once its days are recomputed
+		result.Cursor = page[len(page)-1].ID
+		if err := s.stateRepo.Upsert(ctx, &models.AggregateState{UserID: userID, Cursor: result.Cursor, Timezone: state.Timezone}); err != nil {
+			return nil, err
+		}
+
+		if !feed.HasMore {
+			break
+		}
+	}
+
+	latest, err := s.changeLogRepo.GetLatestCursor(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	result.Pending = latest > result.Cursor
+
+	return result, nil
+}
+
+// apply recomputes the days touched by a page of change log entries: the
+// day each changed event was counted under and the day it is on now. It
+// returns the number of event entries and of days recomputed.
+//
+// Days are recomputed before the counted events are recorded, so applying a
+// page again after a failure finds the same days.
+func (s *aggregateService) apply(ctx context.Context, userID string, cal calendar, changes []models.ChangeEntry) (int, int, error) {
+	var eventIDs []string
+	latest := make(map[string]*models.Event) // nil once deleted
+	applied := 0
+	for _, change := range changes {
+		if change.EntityType != models.EntityTypeEvent {
+			continue
+		}
+		applied++
+
+		var event *models.Event
+		if change.Operation != models.OperationDelete && len(change.Data) > 0 {
+			event = &models.Event{}
+			if err := json.Unmarshal(change.Data, event); err != nil {
+				return 0, 0, fmt.Errorf("failed to decode change %d: %w", change.ID, err)
+			}
+			if event.DeletedAt != nil {
+				event = nil
+			}
+		}
+
+		if _, seen := latest[change.EntityID]; !seen {
+			eventIDs = append(eventIDs, change.EntityID)
+		}
+		latest[change.EntityID] = event
+	}
+	if len(eventIDs) == 0 {
+		return 0, 0, nil
+	}
+
+	counted, err := s.stateRepo.GetEvents(ctx, userID, eventIDs)
+	if err != nil {
+		return 0, 0, err
+	}
+
+	dirty := make(map[time.Time]bool)
+	for _, e := range counted {
+		dirty[e.Date] = true
+	}
+	var upserts []models.AggregatedEvent
+	var deletes []string
+	for _, id := range eventIDs {
+		if event := latest[id]; event != nil {
+			dirty[cal.date(event.Timestamp)] = true
+			upserts = append(upserts, aggregatedEvent(*event, userID, cal))
+		} else {
+			deletes = append(deletes, id)
+		}
+	}
+
+	if err := s.recompute(ctx, userID, cal, dirty); err != nil {
+		return 0, 0, err
+	}
+	if err := s.stateRepo.UpsertEvents(ctx, upserts); err != nil {
+		return 0, 0, err
+	}
+	if err := s.stateRepo.DeleteEvents(ctx, userID, deletes); err != nil {
+		return 0, 0, err
+	}
+
+	return applied, len(dirty), nil
+}
+
+// recompute rebuilds the aggregates of the given local dates from the
+// user's events, loading each run of consecutive dates with one query
+func (s *aggregateService) recompute(ctx context.Context, userID string, cal calendar, dates map[time.Time]bool) error {
+	sorted := make([]time.Time, 0, len(dates))
+	for date := range dates {
+		sorted = append(sorted, date)
+	}
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i].Before(sorted[j]) })
+
+	for i := 0; i < len(sorted); {
+		j := i + 1
+		for j < len(sorted) && sorted[j].Equal(sorted[j-1].AddDate(0, 0, 1)) {
+			j++
+		}
+		first, last := sorted[i], sorted[j-1]
+		i = j
+
+		aggregates, err := loadDailyAggregates(ctx, s.eventRepo, userID, cal, first, last)
+		if err != nil {
+			return err
+		}
+
+		// Remove the aggregates of event types no longer on a day
+		existing, err := s.aggregateRepo.GetByUserIDAndDateRange(ctx, userID, first, last)
+		if err != nil {
+			return err
+		}
+		fresh := make(map[string]bool, len(aggregates))
+		for _, agg := range aggregates {
+			fresh[aggregateKey(agg)] = true
+		}
+		for _, agg := range existing {
+			if !fresh[aggregateKey(agg)] {
+				if err := s.aggregateRepo.Delete(ctx, userID, agg.EventTypeID, agg.Date); err != nil {
+					return err
+				}
+			}
+		}
+
+		if err := s.aggregateRepo.BulkUpsert(ctx, aggregates); err != nil {
+			return fmt.Errorf("failed to store daily aggregates: %w", err)
+		}
+	}
+
+	return nil
+}
+
+func (s *aggregateService) Backfill(ctx context.Context, userID string) (*AggregateSyncResult, error) {
+	cal, err := loadCalendar(ctx, s.settingsRepo, userID, models.CalendarOptions{})
+	if err != nil {
+		return nil, fmt.Errorf("failed to get user settings: %w", err)
+	}
+
+	return s.backfill(ctx, userID, cal)
+}
+
+// backfill rebuilds every aggregate of the user from their events. The
+// settled change log cursor is read first, so changes made during the
+// rebuild, or still unsettled, are applied again by the next sync.
+func (s *aggregateService) backfill(ctx context.Context, userID string, cal calendar) (*AggregateSyncResult, error) {
+	cursor, err := s.changeLogRepo.GetSettledCursor(ctx, userID, s.now().Add(-aggregateSettleWindow))
+	if err != nil {
+		return nil, err
+	}
+
+	if err := s.aggregateRepo.DeleteByUserID(ctx, userID); err != nil {
+		return nil, err
+	}
+	if err := s.stateRepo.DeleteByUserID(ctx, userID); err != nil {
+		return nil, err
+	}
+
+	aggregator := newDailyAggregator(userID, cal)
+	var after *models.EventCursor
+	for {
+		events, err := s.eventRepo.GetForExportPage(ctx, userID, nil, nil, nil, after, aggregateBackfillPageSize)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get events: %w", err)
+		}
+
+		counted := make([]models.AggregatedEvent, len(events))
+		for i, event := range events {
+			aggregator.add(event)
+			counted[i] = aggregatedEvent(event, userID, cal)
+		}
+		if err := s.stateRepo.UpsertEvents(ctx, counted); err != nil {
+			return nil, err
+		}
+
+		if len(events) < aggregateBackfillPageSize {
+			break
+		}
+		last := events[len(events)-1]
+		after = &models.EventCursor{Timestamp: last.Timestamp, ID: last.ID}
+	}
+
+	aggregates := aggregator.aggregates()
+	days := make(map[time.Time]bool)
+	for start := 0; start < len(aggregates); start += aggregateBackfillPageSize {
+		batch := aggregates[start:min(start+aggregateBackfillPageSize, len(aggregates))]
+		if err := s.aggregateRepo.BulkUpsert(ctx, batch); err != nil {
+			return nil, fmt.Errorf("failed to store daily aggregates: %w", err)
+		}
+		for _, agg := range batch {
+			days[agg.Date] = true
+		}
+	}
+
+	state := &models.AggregateState{UserID: userID, Cursor: cursor, Timezone: cal.loc.String()}
+	if err := s.stateRepo.Upsert(ctx, state); err != nil {
+		return nil, err
+	}
+
+	latest, err := s.changeLogRepo.GetLatestCursor(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	return &AggregateSyncResult{
+		UserID:   userID,
+		Timezone: state.Timezone,
+		Rebuilt:  true,
+		Days:     len(days),
+		Cursor:   cursor,
+		Pending:  latest > cursor,
+	}, nil
+}
+
+// GetDailyAggregates only reads; the aggregates are brought up to date by
+// the aggregates job (see NewAggregateHandler) and by insight recomputes
+func (s *aggregateService) GetDailyAggregates(ctx context.Context, userID, timezone string, startDate, endDate time.Time) ([]models.DailyAggregate, bool, error) {
+	state, err := s.stateRepo.GetByUserID(ctx, userID)
+	if err != nil {
+		return nil, false, err
+	}
+	if state == nil || state.Timezone != timezone {
+		return nil, false, nil
+	}
+	latest, err := s.changeLogRepo.GetLatestCursor(ctx, userID)
+	if err != nil {
+		return nil, false, err
+	}
+	if state.Cursor < latest {
+		return nil, false, nil
+	}
+
+	aggregates, err := s.aggregateRepo.GetByUserIDAndDateRange(ctx, userID, startDate, endDate)
+	if err != nil {
+		return nil, false, err
+	}
+
+	return aggregates, true, nil
+}
+
+// NewAggregateHandler returns the job handler that syncs a user's daily
+// aggregates and then recomputes their insights. While some changes are too
+// recent to apply, it runs again once they have settled instead.
+func NewAggregateHandler(aggregates AggregateService, jobs JobService) JobHandler {
+	return func(ctx context.Context, job *models.Job) error {
+		result, err := aggregates.Sync(ctx, job.UserID)
+		if err != nil {
+			return fmt.Errorf("failed to sync daily aggregates: %w", err)
+		}
+
+		if result.Pending {
+			_, err = jobs.Schedule(ctx, models.JobKindAggregates, job.UserID, aggregateSettleWindow)
+		} else {
+			_, err = jobs.Schedule(ctx, models.JobKindInsights, job.UserID, 0)
+		}
+		return err
+	}
+}
+
+// loadDailyAggregates builds the aggregates of local dates from first to
+// last inclusive from the user's events
+func loadDailyAggregates(ctx context.Context, eventRepo repository.EventRepository, userID string, cal calendar, first, last time.Time) ([]models.DailyAggregate, error) {
+	start := cal.fromDate(first)
+	end := cal.addDays(cal.fromDate(last), 1)
+	events, err := eventRepo.GetByUserIDAndDateRange(ctx, userID, start, end)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get events: %w", err)
+	}
+	inRange := make([]models.Event, 0, len(events))
+	for _, event := range events {
+		if event.Timestamp.Before(end) {
+			inRange = append(inRange, event)
+		}
+	}
+	return buildDailyAggregates(inRange, userID, cal), nil
+}
+
+// aggregatedEvent records the day and event type event is counted under
+func aggregatedEvent(event models.Event, userID string, cal calendar) models.AggregatedEvent {
+	return models.AggregatedEvent{
+		EventID:     event.ID,
+		UserID:      userID,
+		EventTypeID: event.EventTypeID,
+		Date:        cal.date(event.Timestamp),
+	}
+}
+
+// aggregateKey identifies the aggregate of an event type on a day
+func aggregateKey(agg models.DailyAggregate) string {
+	return agg.Date.Format("2006-01-02") + "|" + agg.EventTypeID
+}
+
+// dailyAggregator builds daily aggregates from events added one at a time
+type dailyAggregator struct {
+	userID string
+	cal    calendar
+	byKey  map[string]*models.DailyAggregate
+}
+
+func newDailyAggregator(userID string, cal calendar) *dailyAggregator {
+	return &dailyAggregator{userID: userID, cal: cal, byKey: make(map[string]*models.DailyAggregate)}
+}
+
+// add counts event on its local day
+func (a *dailyAggregator) add(event models.Event) {
+	date := a.cal.date(event.Timestamp)
+	key := date.Format("2006-01-02") + "|" + event.EventTypeID
+
+	agg, exists := a.byKey[key]
+	if !exists {
+		agg = &models.DailyAggregate{
+			UserID:      a.userID,
+			Date:        date,
+			EventTypeID: event.EventTypeID,
+			HourCounts:  make([]int, 24),
+		}
+		a.byKey[key] = agg
+	}
+
+	agg.EventCount++
+	agg.HourCounts[a.cal.hour(event.Timestamp)]++
+	if event.EndDate != nil && event.EndDate.After(event.Timestamp) {
+		total := event.EndDate.Sub(event.Timestamp).Seconds()
+		if agg.TotalDurationSeconds != nil {
+			total += *agg.TotalDurationSeconds
+		}
+		agg.TotalDurationSeconds = &total
+	}
+
+	for propKey, value := range event.Properties {
+		if agg.PropertyAggregates == nil {
+			agg.PropertyAggregates = make(map[string]models.PropAgg)
+		}
+		if prop, ok := aggregatePropertyValue(agg.PropertyAggregates[propKey], value); ok {
+			agg.PropertyAggregates[propKey] = prop
+		}
+	}
+}
+
+// aggregates returns the aggregates built so far, ordered by date and event
+// type
+func (a *dailyAggregator) aggregates() []models.DailyAggregate {
+	aggregates := make([]models.DailyAggregate, 0, len(a.byKey))
+	for _, agg := range a.byKey {
+		aggregates = append(aggregates, *agg)
+	}
+	sort.Slice(aggregates, func(i, j int) bool {
+		return aggregateKey(aggregates[i]) < aggregateKey(aggregates[j])
+	})
+	return aggregates
+}
+
+// buildDailyAggregates creates daily aggregates from events, one per event
+// type and local day of the user's calendar
+func buildDailyAggregates(events []models.Event, userID string, cal calendar) []models.DailyAggregate {
+	aggregator := newDailyAggregator(userID, cal)
+	for _, event := range events {
+		aggregator.add(event)
+	}
+	return aggregator.aggregates()
+}
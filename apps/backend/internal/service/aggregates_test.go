@@ -0,0 +1,305 @@
+package service
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/JonnyWalker81/trendy/backend/internal/models"
+	"github.com/JonnyWalker81/trendy/backend/internal/repository"
+	"github.com/JonnyWalker81/trendy/backend/internal/repository/memory"
+)
+
+// settledAggregateService returns an aggregate service whose clock is past
+// the settle window of every change log entry written during the test
+func settledAggregateService(repos *repository.Repositories) *aggregateService {
+	svc := NewAggregateService(repos.Events, repos.DailyAggregates, repos.AggregateStates, repos.ChangeLog, repos.UserSettings).(*aggregateService)
+	svc.now = func() time.Time { return time.Now().Add(aggregateSettleWindow) }
+	return svc
+}
+
+func TestAggregateSync(t *testing.T) {
+	ctx := context.Background()
+	repos := memory.NewRepositories(memory.NewStore())
+	svc := settledAggregateService(repos)
+
+	logChange := func(op models.Operation, event *models.Event) {
+		t.Helper()
+		input := &models.ChangeLogInput{EntityType: models.EntityTypeEvent, Operation: op, EntityID: event.ID, UserID: "user-1"}
+		if op != models.OperationDelete {
+			input.Data = event
+		}
+		if _, err := repos.ChangeLog.Append(ctx, input); err != nil {
+			t.Fatalf("Append failed: %v", err)
+		}
+	}
+	create := func(eventTypeID, ts string, duration time.Duration) *models.Event {
+		t.Helper()
+		timestamp, _ := time.Parse(time.RFC3339, ts)
+		event := &models.Event{UserID: "user-1", EventTypeID: eventTypeID, Timestamp: timestamp, SourceType: "manual"}
+		if duration > 0 {
+			endDate := timestamp.Add(duration)
+			event.EndDate = &endDate
+		}
+		created, err := repos.Events.Create(ctx, event)
+		if err != nil {
+			t.Fatalf("Create event failed: %v", err)
+		}
+		logChange(models.OperationCreate, created)
+		return created
+	}
+	aggregates := func() map[string]models.DailyAggregate {
+		t.Helper()
+		aggs, err := repos.DailyAggregates.GetByUserID(ctx, "user-1")
+		if err != nil {
+			t.Fatalf("GetByUserID failed: %v", err)
+		}
+		byKey := make(map[string]models.DailyAggregate, len(aggs))
+		for _, agg := range aggs {
+			byKey[aggregateKey(agg)] = agg
+		}
+		return byKey
+	}
+
+	a := create("run", "2026-03-01T10:00:00Z", 30*time.Minute)
+	create("run", "2026-03-01T18:00:00Z", 0)
+	c := create("run", "2026-03-02T09:00:00Z", 0)
+
+	// Aggregates that were never built are built from every event
+	result, err := svc.Sync(ctx, "user-1")
+	if err != nil {
+		t.Fatalf("Sync failed: %v", err)
+	}
+	if !result.Rebuilt || result.Days != 2 || result.Timezone != "UTC" {
+		t.Errorf("expected a rebuild of 2 UTC days, got %+v", result)
+	}
+	first := aggregates()["2026-03-01|run"]
+	if first.EventCount != 2 || first.HourCounts[10] != 1 || first.HourCounts[18] != 1 {
+		t.Errorf("expected 2 runs at 10:00 and 18:00 on 1 March, got %+v", first)
+	}
+	if first.TotalDurationSeconds == nil || *first.TotalDurationSeconds != 1800 {
+		t.Errorf("expected 1800 seconds on 1 March, got %v", first.TotalDurationSeconds)
+	}
+
+	// Moving an event recomputes the day it left as well as the one it joined
+	moved := *c
+	moved.Timestamp = time.Date(2026, 3, 5, 9, 0, 0, 0, time.UTC)
+	updated, err := repos.Events.Update(ctx, c.ID, &moved)
+	if err != nil {
+		t.Fatalf("Update failed: %v", err)
+	}
+	logChange(models.OperationUpdate, updated)
+	if err := repos.Events.Delete(ctx, a.ID); err != nil {
+		t.Fatalf("Delete failed: %v", err)
+	}
+	logChange(models.OperationDelete, a)
+	create("walk", "2026-03-03T07:00:00Z", 0)
+
+	result, err = svc.Sync(ctx, "user-1")
+	if err != nil {
+		t.Fatalf("Sync failed: %v", err)
+	}
+	if result.Rebuilt || result.Changes != 3 || result.Days != 4 {
+		t.Errorf("expected 3 changes over 4 days, got %+v", result)
+	}
+	got := aggregates()
+	if len(got) != 3 || got["2026-03-01|run"].EventCount != 1 || got["2026-03-03|walk"].EventCount != 1 || got["2026-03-05|run"].EventCount != 1 {
+		t.Errorf("expected runs on 1 and 5 March and a walk on 3 March, got %v", got)
+	}
+	if got["2026-03-01|run"].TotalDurationSeconds != nil {
+		t.Errorf("expected no duration once the timed run was deleted, got %v", *got["2026-03-01|run"].TotalDurationSeconds)
+	}
+
+	if result, err = svc.Sync(ctx, "user-1"); err != nil || result.Changes != 0 || result.Days != 0 {
+		t.Errorf("expected nothing to apply, got %+v, %v", result, err)
+	}
+
+	// A new time zone moves every day, so the aggregates are rebuilt
+	if _, err := repos.UserSettings.Upsert(ctx, &models.UserSettings{UserID: "user-1", Timezone: "Asia/Tokyo", WeekStart: models.WeekStartSunday}); err != nil {
+		t.Fatalf("Upsert failed: %v", err)
+	}
+	if _, ok, _ := svc.GetDailyAggregates(ctx, "user-1", "Asia/Tokyo", time.Date(2026, 3, 1, 0, 0, 0, 0, time.UTC), time.Date(2026, 3, 31, 0, 0, 0, 0, time.UTC)); ok {
+		t.Error("expected UTC aggregates not to be used for Tokyo before a sync")
+	}
+	if result, err = svc.Sync(ctx, "user-1"); err != nil || !result.Rebuilt {
+		t.Fatalf("expected a rebuild, got %+v, %v", result, err)
+	}
+	aggs, ok, err := svc.GetDailyAggregates(ctx, "user-1", "Asia/Tokyo", time.Date(2026, 3, 1, 0, 0, 0, 0, time.UTC), time.Date(2026, 3, 31, 0, 0, 0, 0, time.UTC))
+	if err != nil || !ok {
+		t.Fatalf("GetDailyAggregates failed: %v, %v", ok, err)
+	}
+	if len(aggs) != 3 || aggs[0].Date.Day() != 2 || aggs[0].HourCounts[3] != 1 {
+		t.Errorf("expected the 18:00 UTC run at 03:00 on 2 March in Tokyo, got %+v", aggs)
+	}
+	if _, ok, _ := svc.GetDailyAggregates(ctx, "user-1", "UTC", aggs[0].Date, aggs[0].Date); ok {
+		t.Error("expected aggregates in Tokyo time not to be used for UTC")
+	}
+}
+
+func TestAggregateSyncFollowsEventTypeChanges(t *testing.T) {
+	ctx := context.Background()
+	repos := memory.NewRepositories(memory.NewStore())
+	userID := "user-1"
+
+	eventService := NewEventService(repos.Events, repos.EventTypes, repos.PropertyDefinitions, repos.ChangeLog, repos.Transactor)
+	eventTypeService := NewEventTypeService(repos.EventTypes, repos.Events, repos.PropertyDefinitions, repos.Geofences, repos.Insights, repos.Streaks, repos.ChangeLog, repos.Transactor)
+	svc := settledAggregateService(repos)
+
+	jog, err := eventTypeService.CreateEventType(ctx, userID, &models.CreateEventTypeRequest{Name: "Jog", Color: "#f00", Icon: "run"})
+	if err != nil {
+		t.Fatalf("CreateEventType failed: %v", err)
+	}
+	run, err := eventTypeService.CreateEventType(ctx, userID, &models.CreateEventTypeRequest{Name: "Run", Color: "#0f0", Icon: "run"})
+	if err != nil {
+		t.Fatalf("CreateEventType failed: %v", err)
+	}
+	for hour, eventTypeID := range []string{jog.ID, run.ID} {
+		if _, _, err := eventService.CreateEvent(ctx, userID, &models.CreateEventRequest{
+			EventTypeID: eventTypeID,
+			Timestamp:   time.Date(2026, 3, 1, 10+hour, 0, 0, 0, time.UTC),
+		}); err != nil {
+			t.Fatalf("CreateEvent failed: %v", err)
+		}
+	}
+	if _, err := svc.Sync(ctx, userID); err != nil {
+		t.Fatalf("Sync failed: %v", err)
+	}
+
+	if _, err := eventTypeService.MergeEventType(ctx, userID, jog.ID, run.ID); err != nil {
+		t.Fatalf("MergeEventType failed: %v", err)
+	}
+
+	// The moved event's change log entry recomputes its day without a rebuild
+	result, err := svc.Sync(ctx, userID)
+	if err != nil {
+		t.Fatalf("Sync failed: %v", err)
+	}
+	if result.Rebuilt || result.Days != 1 {
+		t.Errorf("expected 1 day recomputed incrementally, got %+v", result)
+	}
+	aggs, err := repos.DailyAggregates.GetByUserID(ctx, userID)
+	if err != nil {
+		t.Fatalf("GetByUserID failed: %v", err)
+	}
+	if len(aggs) != 1 || aggs[0].EventTypeID != run.ID || aggs[0].EventCount != 2 {
+		t.Errorf("expected both events counted under the target, got %+v", aggs)
+	}
+
+	// Archiving keeps the events, so their aggregates stay
+	if err := eventTypeService.DeleteEventType(ctx, userID, run.ID, &models.DeleteEventTypeRequest{Strategy: models.DeleteStrategyArchive}); err != nil {
+		t.Fatalf("DeleteEventType failed: %v", err)
+	}
+	if _, err := svc.Sync(ctx, userID); err != nil {
+		t.Fatalf("Sync failed: %v", err)
+	}
+	aggs, err = repos.DailyAggregates.GetByUserID(ctx, userID)
+	if err != nil {
+		t.Fatalf("GetByUserID failed: %v", err)
+	}
+	if len(aggs) != 1 || aggs[0].EventCount != 2 {
+		t.Errorf("expected the archived type's aggregates to stay, got %+v", aggs)
+	}
+}
+
+func TestAggregateSyncWaitsForEntriesToSettle(t *testing.T) {
+	ctx := context.Background()
+	repos := memory.NewRepositories(memory.NewStore())
+	svc := NewAggregateService(repos.Events, repos.DailyAggregates, repos.AggregateStates, repos.ChangeLog, repos.UserSettings).(*aggregateService)
+	clock := time.Now()
+	svc.now = func() time.Time { return clock }
+
+	create := func(hour int) {
+		t.Helper()
+		event, err := repos.Events.Create(ctx, &models.Event{UserID: "user-1", EventTypeID: "run", Timestamp: time.Date(2026, 3, 1, hour, 0, 0, 0, time.UTC), SourceType: "manual"})
+		if err != nil {
+			t.Fatalf("Create event failed: %v", err)
+		}
+		if _, err := repos.ChangeLog.Append(ctx, &models.ChangeLogInput{EntityType: models.EntityTypeEvent, Operation: models.OperationCreate, EntityID: event.ID, UserID: "user-1", Data: event}); err != nil {
+			t.Fatalf("Append failed: %v", err)
+		}
+	}
+	count := func() int {
+		t.Helper()
+		aggs, err := repos.DailyAggregates.GetByUserID(ctx, "user-1")
+		if err != nil {
+			t.Fatalf("GetByUserID failed: %v", err)
+		}
+		total := 0
+		for _, agg := range aggs {
+			total += agg.EventCount
+		}
+		return total
+	}
+	day := time.Date(2026, 3, 1, 0, 0, 0, 0, time.UTC)
+
+	create(9)
+	clock = time.Now().Add(aggregateSettleWindow)
+	if result, err := svc.Sync(ctx, "user-1"); err != nil || !result.Rebuilt || result.Pending {
+		t.Fatalf("expected a settled rebuild, got %+v, %v", result, err)
+	}
+
+	// An entry younger than the window may still be joined by a lower ID
+	// from an open transaction, so the cursor stops before it
+	create(10)
+	clock = time.Now()
+	result, err := svc.Sync(ctx, "user-1")
+	if err != nil {
+		t.Fatalf("Sync failed: %v", err)
+	}
+	if result.Changes != 0 || !result.Pending || count() != 1 {
+		t.Errorf("expected the new entry to wait, got %+v with %d events counted", result, count())
+	}
+
+	// Reads never sync; aggregates behind the change log are not used
+	if _, ok, err := svc.GetDailyAggregates(ctx, "user-1", "UTC", day, day); err != nil || ok {
+		t.Errorf("expected aggregates behind the change log not to be used, got %v, %v", ok, err)
+	}
+	if count() != 1 {
+		t.Errorf("expected GetDailyAggregates not to write, got %d events counted", count())
+	}
+
+	clock = time.Now().Add(aggregateSettleWindow)
+	if result, err = svc.Sync(ctx, "user-1"); err != nil || result.Changes != 1 || result.Pending {
+		t.Fatalf("expected the settled entry to be applied, got %+v, %v", result, err)
+	}
+	aggs, ok, err := svc.GetDailyAggregates(ctx, "user-1", "UTC", day, day)
+	if err != nil || !ok || len(aggs) != 1 || aggs[0].EventCount != 2 {
+		t.Errorf("expected 2 events on 1 March, got %+v, %v, %v", aggs, ok, err)
+	}
+}
+
+func TestAggregateHandlerWaitsThenRecomputesInsights(t *testing.T) {
+	ctx := context.Background()
+	repos := memory.NewRepositories(memory.NewStore())
+	jobs := NewJobService(repos.Jobs, JobOptions{Workers: 1, PollInterval: time.Hour, Debounce: time.Hour, MaxAttempts: 3, Timeout: time.Minute})
+	svc := NewAggregateService(repos.Events, repos.DailyAggregates, repos.AggregateStates, repos.ChangeLog, repos.UserSettings).(*aggregateService)
+	clock := time.Now()
+	svc.now = func() time.Time { return clock }
+	handle := NewAggregateHandler(svc, jobs)
+	job := &models.Job{Kind: models.JobKindAggregates, UserID: "user-1"}
+
+	if _, err := repos.ChangeLog.Append(ctx, &models.ChangeLogInput{EntityType: models.EntityTypeEventType, Operation: models.OperationCreate, EntityID: "run", UserID: "user-1"}); err != nil {
+		t.Fatalf("Append failed: %v", err)
+	}
+
+	// An unsettled entry runs the job again after the settle window
+	if err := handle(ctx, job); err != nil {
+		t.Fatalf("handler failed: %v", err)
+	}
+	retry, err := jobs.GetLatest(ctx, models.JobKindAggregates, "user-1")
+	if err != nil || retry == nil || retry.RunAt.Before(time.Now().Add(aggregateSettleWindow-time.Second)) {
+		t.Fatalf("expected the job to run again once the entry settles, got %+v, %v", retry, err)
+	}
+	if insights, _ := jobs.GetLatest(ctx, models.JobKindInsights, "user-1"); insights != nil {
+		t.Errorf("expected no insights job before the aggregates catch up, got %+v", insights)
+	}
+
+	// Once every entry is applied, insights are recomputed
+	clock = time.Now().Add(aggregateSettleWindow)
+	if err := handle(ctx, job); err != nil {
+		t.Fatalf("handler failed: %v", err)
+	}
+	if insights, err := jobs.GetLatest(ctx, models.JobKindInsights, "user-1"); err != nil || insights == nil {
+		t.Errorf("expected an insights job, got %+v, %v", insights, err)
+	}
+}
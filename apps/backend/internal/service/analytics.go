@@ -2,22 +2,39 @@ package service
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"math"
+	"sort"
+	"strconv"
 	"time"
 
 	"github.com/JonnyWalker81/trendy/backend/internal/models"
 	"github.com/JonnyWalker81/trendy/backend/internal/repository"
 )
 
+// MaxTrendBuckets bounds the number of buckets in one trend
+const MaxTrendBuckets = 5000
+
+// ErrInvalidTrendQuery is returned for a trend query that cannot be answered
+var ErrInvalidTrendQuery = errors.New("invalid trend query")
+
 type analyticsService struct {
-	eventRepo repository.EventRepository
+	eventRepo       repository.EventRepository
+	eventTypeRepo   repository.EventTypeRepository
+	propertyDefRepo repository.PropertyDefinitionRepository
+	settingsRepo    repository.UserSettingsRepository
+	aggregates      AggregateService
 }
 
 // NewAnalyticsService creates a new analytics service
-func NewAnalyticsService(eventRepo repository.EventRepository) AnalyticsService {
+func NewAnalyticsService(eventRepo repository.EventRepository, eventTypeRepo repository.EventTypeRepository, propertyDefRepo repository.PropertyDefinitionRepository, settingsRepo repository.UserSettingsRepository, aggregates AggregateService) AnalyticsService {
 	return &analyticsService{
-		eventRepo: eventRepo,
+		eventRepo:       eventRepo,
+		eventTypeRepo:   eventTypeRepo,
+		propertyDefRepo: propertyDefRepo,
+		settingsRepo:    settingsRepo,
+		aggregates:      aggregates,
 	}
 }
 
@@ -46,14 +63,23 @@ func (s *analyticsService) GetSummary(ctx context.Context, userID string) (*mode
 	}, nil
 }
 
-func (s *analyticsService) GetTrends(ctx context.Context, userID string, period string, startDate, endDate time.Time) ([]models.TrendData, error) {
-	// Get all events in the date range
-	events, err := s.eventRepo.GetByUserIDAndDateRange(ctx, userID, startDate, endDate)
+func (s *analyticsService) GetTrends(ctx context.Context, userID string, query *models.TrendQuery, opts models.CalendarOptions) ([]models.TrendData, error) {
+	cal, buckets, err := s.prepareTrend(ctx, userID, query, opts)
+	if err != nil {
+		return nil, err
+	}
+
+	// Get the aggregates and events in the buckets
+	aggregates, events, err := s.trendSources(ctx, userID, cal, query, buckets)
 	if err != nil {
-		return nil, fmt.Errorf("failed to get events: %w", err)
+		return nil, err
 	}
 
-	// Group events by event type
+	// Group aggregates and events by event type
+	aggregatesByType := make(map[string][]models.DailyAggregate)
+	for _, agg := range aggregates {
+		aggregatesByType[agg.EventTypeID] = append(aggregatesByType[agg.EventTypeID], agg)
+	}
 	eventsByType := make(map[string][]models.Event)
 	for _, event := range events {
 		eventsByType[event.EventTypeID] = append(eventsByType[event.EventTypeID], event)
@@ -61,22 +87,39 @@ func (s *analyticsService) GetTrends(ctx context.Context, userID string, period
 
 	// Calculate trends for each event type
 	trends := []models.TrendData{}
-	for eventTypeID, typeEvents := range eventsByType {
-		trendData := s.calculateTrend(eventTypeID, typeEvents, period, startDate, endDate)
+	for eventTypeID, typeAggregates := range aggregatesByType {
+		trendData := s.calculateTrend(cal, eventTypeID, typeAggregates, eventsByType[eventTypeID], query, buckets)
 		trends = append(trends, *trendData)
 	}
+	for eventTypeID, typeEvents := range eventsByType {
+		if _, ok := aggregatesByType[eventTypeID]; !ok {
+			trendData := s.calculateTrend(cal, eventTypeID, nil, typeEvents, query, buckets)
+			trends = append(trends, *trendData)
+		}
+	}
 
 	return trends, nil
 }
 
-func (s *analyticsService) GetEventTypeAnalytics(ctx context.Context, userID, eventTypeID string, period string, startDate, endDate time.Time) (*models.TrendData, error) {
-	// Get events for this event type in the date range
-	allEvents, err := s.eventRepo.GetByUserIDAndDateRange(ctx, userID, startDate, endDate)
+func (s *analyticsService) GetEventTypeAnalytics(ctx context.Context, userID, eventTypeID string, query *models.TrendQuery, opts models.CalendarOptions) (*models.TrendData, error) {
+	cal, buckets, err := s.prepareTrend(ctx, userID, query, opts)
+	if err != nil {
+		return nil, err
+	}
+
+	// Get aggregates and events for this event type in the buckets
+	allAggregates, allEvents, err := s.trendSources(ctx, userID, cal, query, buckets)
 	if err != nil {
-		return nil, fmt.Errorf("failed to get events: %w", err)
+		return nil, err
 	}
 
 	// Filter for the specific event type
+	aggregates := []models.DailyAggregate{}
+	for _, agg := range allAggregates {
+		if agg.EventTypeID == eventTypeID {
+			aggregates = append(aggregates, agg)
+		}
+	}
 	events := []models.Event{}
 	for _, event := range allEvents {
 		if event.EventTypeID == eventTypeID {
@@ -84,25 +127,107 @@ func (s *analyticsService) GetEventTypeAnalytics(ctx context.Context, userID, ev
 		}
 	}
 
-	return s.calculateTrend(eventTypeID, events, period, startDate, endDate), nil
+	return s.calculateTrend(cal, eventTypeID, aggregates, events, query, buckets), nil
+}
+
+// trendSources returns what a trend is counted from. Daily aggregates cover
+// the whole days of the buckets when they can: the trend has a granularity
+// of a day or more, no property, and is in the time zone of the aggregates.
+// Events cover the rest, up to the query's end date.
+func (s *analyticsService) trendSources(ctx context.Context, userID string, cal calendar, query *models.TrendQuery, buckets []time.Time) ([]models.DailyAggregate, []models.Event, error) {
+	from := buckets[0]
+	var aggregates []models.DailyAggregate
+	if query.Granularity != models.GranularityHour && query.Property == "" {
+		// Buckets start at local midnight, so the aggregates end the day
+		// before the end date's
+		last := cal.startOfDay(query.EndDate)
+		if last.After(from) {
+			aggs, ok, err := s.aggregates.GetDailyAggregates(ctx, userID, cal.loc.String(), cal.date(from), cal.date(last).AddDate(0, 0, -1))
+			if err != nil {
+				return nil, nil, err
+			}
+			if ok {
+				aggregates, from = aggs, last
+			}
+		}
+	}
+
+	events, err := s.eventRepo.GetByUserIDAndDateRange(ctx, userID, from, query.EndDate)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to get events: %w", err)
+	}
+
+	return aggregates, events, nil
+}
+
+// prepareTrend validates query, filling in its granularity, and returns the
+// user's calendar and the trend's buckets
+func (s *analyticsService) prepareTrend(ctx context.Context, userID string, query *models.TrendQuery, opts models.CalendarOptions) (calendar, []time.Time, error) {
+	if query.Granularity == "" {
+		query.Granularity = periodGranularity(query.Period)
+	}
+	if err := validateTrendQuery(query); err != nil {
+		return calendar{}, nil, err
+	}
+
+	cal, err := loadCalendar(ctx, s.settingsRepo, userID, opts)
+	if err != nil {
+		return calendar{}, nil, err
+	}
+
+	buckets, err := createTimeBuckets(cal, query.Granularity, query.StartDate, query.EndDate)
+	if err != nil {
+		return calendar{}, nil, err
+	}
+
+	return cal, buckets, nil
 }
 
-func (s *analyticsService) calculateTrend(eventTypeID string, events []models.Event, period string, startDate, endDate time.Time) *models.TrendData {
-	// Group events by time bucket based on period
-	buckets := s.createTimeBuckets(period, startDate, endDate)
-	dataPoints := []models.TimeSeriesDataPoint{}
+// calculateTrend buckets the counts and durations of daily aggregates and
+// events of one event type. Aggregates are counted on the local midnight of
+// their day.
+func (s *analyticsService) calculateTrend(cal calendar, eventTypeID string, aggregates []models.DailyAggregate, events []models.Event, query *models.TrendQuery, buckets []time.Time) *models.TrendData {
+	// Group aggregates and events by time bucket
+	dataPoints := make([]models.TimeSeriesDataPoint, len(buckets))
+	values := make([][]float64, len(buckets))
+	unit := ""
+	for i, bucket := range buckets {
+		dataPoints[i].Date = bucket
+	}
+
+	end := cal.next(query.Granularity, buckets[len(buckets)-1])
+	for _, agg := range aggregates {
+		i := bucketIndex(buckets, end, cal.fromDate(agg.Date))
+		if i < 0 {
+			continue
+		}
+
+		dataPoints[i].Count += int64(agg.EventCount)
+		if agg.TotalDurationSeconds != nil {
+			dataPoints[i].Duration += *agg.TotalDurationSeconds
+		}
+	}
+	for _, event := range events {
+		i := bucketIndex(buckets, end, event.Timestamp)
+		if i < 0 {
+			continue
+		}
 
-	for _, bucket := range buckets {
-		count := int64(0)
-		for _, event := range events {
-			if event.Timestamp.After(bucket) && event.Timestamp.Before(bucket.Add(s.getBucketDuration(period))) {
-				count++
+		dataPoints[i].Count++
+		if event.EndDate != nil && event.EndDate.After(event.Timestamp) {
+			dataPoints[i].Duration += event.EndDate.Sub(event.Timestamp).Seconds()
+		}
+		if query.Property != "" {
+			if n, ok := trendValue(event.Properties[query.Property], &unit); ok {
+				values[i] = append(values[i], n)
 			}
 		}
-		dataPoints = append(dataPoints, models.TimeSeriesDataPoint{
-			Date:  bucket,
-			Count: count,
-		})
+	}
+
+	if query.Property != "" {
+		for i := range dataPoints {
+			dataPoints[i].Values = propertyStats(values[i], query.Percentiles)
+		}
 	}
 
 	// Calculate average
@@ -117,37 +242,134 @@ func (s *analyticsService) calculateTrend(eventTypeID string, events []models.Ev
 
 	return &models.TrendData{
 		EventTypeID: eventTypeID,
-		Period:      period,
+		Period:      query.Period,
+		Granularity: query.Granularity,
+		Property:    query.Property,
+		Unit:        unit,
 		Data:        dataPoints,
 		Average:     average,
 		Trend:       trend,
 	}
 }
 
-func (s *analyticsService) createTimeBuckets(period string, startDate, endDate time.Time) []time.Time {
-	buckets := []time.Time{}
-	current := startDate
-	duration := s.getBucketDuration(period)
+// periodGranularity returns the default granularity of a period
+func periodGranularity(period string) models.Granularity {
+	switch period {
+	case "week":
+		return models.GranularityDay // Daily buckets for week view
+	case "month":
+		return models.GranularityDay // Daily buckets for month view
+	case "year":
+		return models.GranularityWeek // Weekly buckets for year view
+	default:
+		return models.GranularityDay
+	}
+}
+
+// validateTrendQuery checks the granularity, dates and percentiles of a
+// trend query
+func validateTrendQuery(query *models.TrendQuery) error {
+	switch query.Granularity {
+	case models.GranularityHour, models.GranularityDay, models.GranularityWeek,
+		models.GranularityMonth, models.GranularityQuarter, models.GranularityYear:
+	default:
+		return fmt.Errorf("%w: granularity must be hour, day, week, month, quarter or year", ErrInvalidTrendQuery)
+	}
+	if query.EndDate.Before(query.StartDate) {
+		return fmt.Errorf("%w: end_date is before start_date", ErrInvalidTrendQuery)
+	}
+	if len(query.Percentiles) > 0 && query.Property == "" {
+		return fmt.Errorf("%w: percentiles need a property", ErrInvalidTrendQuery)
+	}
+	for _, p := range query.Percentiles {
+		if p < 0 || p > 100 || math.IsNaN(p) {
+			return fmt.Errorf("%w: percentiles must be from 0 to 100", ErrInvalidTrendQuery)
+		}
+	}
+	return nil
+}
 
-	for current.Before(endDate) {
+// createTimeBuckets returns the starts of the buckets of granularity g
+// covering startDate to endDate, aligned to the user's calendar, so the
+// first one may start before startDate. There is always at least one.
+func createTimeBuckets(cal calendar, g models.Granularity, startDate, endDate time.Time) ([]time.Time, error) {
+	current := cal.startOf(g, startDate)
+	buckets := []time.Time{current}
+	for current = cal.next(g, current); current.Before(endDate); current = cal.next(g, current) {
+		if len(buckets) == MaxTrendBuckets {
+			return nil, fmt.Errorf("%w: more than %d buckets; use a coarser granularity or a shorter range", ErrInvalidTrendQuery, MaxTrendBuckets)
+		}
 		buckets = append(buckets, current)
-		current = current.Add(duration)
 	}
 
-	return buckets
+	return buckets, nil
 }
 
-func (s *analyticsService) getBucketDuration(period string) time.Duration {
-	switch period {
-	case "week":
-		return 24 * time.Hour // Daily buckets for week view
-	case "month":
-		return 24 * time.Hour // Daily buckets for month view
-	case "year":
-		return 7 * 24 * time.Hour // Weekly buckets for year view
-	default:
-		return 24 * time.Hour
+// bucketIndex returns the index of the bucket containing t, or -1 if t is
+// outside the buckets, which end at end
+func bucketIndex(buckets []time.Time, end, t time.Time) int {
+	if t.Before(buckets[0]) || !t.Before(end) {
+		return -1
+	}
+	return sort.Search(len(buckets), func(i int) bool { return buckets[i].After(t) }) - 1
+}
+
+// trendValue returns the number a trend summarizes for a property value.
+// Quantities are converted to unit, which is set from the first one seen.
+func trendValue(value models.PropertyValue, unit *string) (float64, bool) {
+	switch value.Type {
+	case models.PropertyTypeNumber, models.PropertyTypeDuration, models.PropertyTypeRating:
+		return propertyNumber(value.Value)
+	case models.PropertyTypeQuantity:
+		q, ok := models.ParseQuantity(value.Value)
+		if !ok {
+			return 0, false
+		}
+		if *unit == "" {
+			*unit = q.Unit
+		}
+		q, err := models.ConvertQuantity(q, *unit)
+		return q.Amount, err == nil
+	}
+	return 0, false
+}
+
+// propertyStats summarizes values, or returns nil if there are none.
+// Percentiles interpolate linearly between the closest ranks.
+func propertyStats(values []float64, percentiles []float64) *models.PropertyStats {
+	if len(values) == 0 {
+		return nil
 	}
+
+	sorted := append([]float64(nil), values...)
+	sort.Float64s(sorted)
+
+	stats := &models.PropertyStats{
+		Count: len(sorted),
+		Min:   sorted[0],
+		Max:   sorted[len(sorted)-1],
+	}
+	for _, v := range sorted {
+		stats.Sum += v
+	}
+	stats.Avg = stats.Sum / float64(len(sorted))
+
+	if len(percentiles) > 0 {
+		stats.Percentiles = make(map[string]float64, len(percentiles))
+		for _, p := range percentiles {
+			stats.Percentiles["p"+strconv.FormatFloat(p, 'f', -1, 64)] = percentile(sorted, p)
+		}
+	}
+
+	return stats
+}
+
+// percentile returns the pth percentile of sorted values
+func percentile(sorted []float64, p float64) float64 {
+	rank := p / 100 * float64(len(sorted)-1)
+	lower := int(math.Floor(rank))
+	upper := int(math.Ceil(rank))
+	return sorted[lower] + (sorted[upper]-sorted[lower])*(rank-float64(lower))
 }
 
 func (s *analyticsService) determineTrend(dataPoints []models.TimeSeriesDataPoint) string {
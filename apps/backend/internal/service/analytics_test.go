@@ -0,0 +1,108 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/JonnyWalker81/trendy/backend/internal/models"
+	"github.com/JonnyWalker81/trendy/backend/internal/repository/memory"
+)
+
+func TestTrendGranularity(t *testing.T) {
+	ctx := context.Background()
+	repos := memory.NewRepositories(memory.NewStore())
+	aggregates := NewAggregateService(repos.Events, repos.DailyAggregates, repos.AggregateStates, repos.ChangeLog, repos.UserSettings)
+	svc := NewAnalyticsService(repos.Events, repos.EventTypes, repos.PropertyDefinitions, repos.UserSettings, aggregates)
+
+	if _, err := repos.UserSettings.Upsert(ctx, &models.UserSettings{UserID: "user-1", Timezone: "America/New_York", WeekStart: models.WeekStartMonday}); err != nil {
+		t.Fatalf("Upsert failed: %v", err)
+	}
+	create := func(ts string, minutes int, distance float64) {
+		t.Helper()
+		timestamp, _ := time.Parse(time.RFC3339, ts)
+		event := &models.Event{UserID: "user-1", EventTypeID: "run", Timestamp: timestamp, SourceType: "manual",
+			Properties: map[string]models.PropertyValue{"distance": {Type: models.PropertyTypeNumber, Value: distance}}}
+		if minutes > 0 {
+			end := timestamp.Add(time.Duration(minutes) * time.Minute)
+			event.EndDate = &end
+		}
+		if _, err := repos.Events.Create(ctx, event); err != nil {
+			t.Fatalf("Create event failed: %v", err)
+		}
+	}
+	create("2026-01-31T23:00:00-05:00", 30, 5)
+	create("2026-02-01T07:00:00-05:00", 60, 10)
+	create("2026-02-15T07:00:00-05:00", 0, 15)
+	create("2026-04-01T07:00:00-04:00", 45, 20)
+
+	trend := func(query models.TrendQuery) *models.TrendData {
+		t.Helper()
+		result, err := svc.GetEventTypeAnalytics(ctx, "user-1", "run", &query, models.CalendarOptions{})
+		if err != nil {
+			t.Fatalf("GetEventTypeAnalytics failed: %v", err)
+		}
+		return result
+	}
+	start := time.Date(2026, 1, 10, 0, 0, 0, 0, time.UTC)
+	end := time.Date(2026, 4, 20, 0, 0, 0, 0, time.UTC)
+
+	// Months run from the 1st to the 1st in the user's time zone, and March
+	// is filled in although it has no events
+	months := trend(models.TrendQuery{Granularity: models.GranularityMonth, StartDate: start, EndDate: end, Property: "distance", Percentiles: []float64{50, 90}})
+	if len(months.Data) != 4 {
+		t.Fatalf("expected 4 months, got %d", len(months.Data))
+	}
+	for i, want := range []struct {
+		date     string
+		count    int64
+		duration float64
+	}{
+		{"2026-01-01T00:00:00-05:00", 1, 1800},
+		{"2026-02-01T00:00:00-05:00", 2, 3600},
+		{"2026-03-01T00:00:00-05:00", 0, 0},
+		{"2026-04-01T00:00:00-04:00", 1, 2700},
+	} {
+		got := months.Data[i]
+		if got.Date.Format(time.RFC3339) != want.date || got.Count != want.count || got.Duration != want.duration {
+			t.Errorf("month %d: expected %+v, got %v %d %v", i, want, got.Date, got.Count, got.Duration)
+		}
+	}
+	feb := months.Data[1].Values
+	if feb == nil || feb.Sum != 25 || feb.Avg != 12.5 || feb.Min != 10 || feb.Max != 15 || feb.Percentiles["p50"] != 12.5 || feb.Percentiles["p90"] != 14.5 {
+		t.Errorf("unexpected February statistics %+v", feb)
+	}
+	if months.Data[2].Values != nil {
+		t.Errorf("expected no statistics for an empty month, got %+v", months.Data[2].Values)
+	}
+
+	quarters := trend(models.TrendQuery{Granularity: models.GranularityQuarter, StartDate: start, EndDate: end})
+	if len(quarters.Data) != 2 || quarters.Data[0].Count != 3 || quarters.Data[1].Date.Format(time.RFC3339) != "2026-04-01T00:00:00-04:00" {
+		t.Errorf("unexpected quarters %+v", quarters.Data)
+	}
+
+	weeks := trend(models.TrendQuery{Granularity: models.GranularityWeek, StartDate: start, EndDate: end})
+	if first := weeks.Data[0].Date; first.Weekday() != time.Monday || first.Format(time.RFC3339) != "2026-01-05T00:00:00-05:00" {
+		t.Errorf("expected weeks to start on Monday, got %v", first)
+	}
+
+	// Clocks go back on 1 November 2026, so that day has 25 hourly buckets
+	day := time.Date(2026, 11, 1, 4, 0, 0, 0, time.UTC) // Midnight in New York
+	hours := trend(models.TrendQuery{Granularity: models.GranularityHour, StartDate: day, EndDate: day.Add(25 * time.Hour)})
+	if len(hours.Data) != 25 || hours.Data[24].Date.Format(time.RFC3339) != "2026-11-01T23:00:00-05:00" {
+		t.Errorf("expected 25 hours ending at 11 PM, got %d", len(hours.Data))
+	}
+
+	for _, query := range []models.TrendQuery{
+		{Granularity: "fortnight", StartDate: start, EndDate: end},
+		{Granularity: models.GranularityDay, StartDate: end, EndDate: start},
+		{Granularity: models.GranularityDay, StartDate: start, EndDate: end, Percentiles: []float64{50}},
+		{Granularity: models.GranularityDay, StartDate: start, EndDate: end, Property: "distance", Percentiles: []float64{101}},
+		{Granularity: models.GranularityHour, StartDate: start, EndDate: start.AddDate(1, 0, 0)},
+	} {
+		if _, err := svc.GetTrends(ctx, "user-1", &query, models.CalendarOptions{}); !errors.Is(err, ErrInvalidTrendQuery) {
+			t.Errorf("%+v: expected ErrInvalidTrendQuery, got %v", query, err)
+		}
+	}
+}
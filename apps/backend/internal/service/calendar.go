@@ -0,0 +1,156 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/JonnyWalker81/trendy/backend/internal/models"
+	"github.com/JonnyWalker81/trendy/backend/internal/repository"
+)
+
+// ErrInvalidUserSettings is returned for an unknown time zone or week start,
+// whether saved or sent as a request override
+var ErrInvalidUserSettings = errors.New("invalid user settings")
+
+// calendar places instants on a user's days, weeks and hours. Days start at
+// local midnight, so they are 23 or 25 hours long across DST changes; step
+// between them with addDays, never with a fixed duration.
+type calendar struct {
+	loc       *time.Location
+	weekStart time.Weekday
+}
+
+// utcCalendar is the calendar of a user without settings
+var utcCalendar = calendar{loc: time.UTC, weekStart: time.Sunday}
+
+// newCalendar returns the calendar for an IANA time zone and week start.
+// Empty values take the defaults.
+func newCalendar(timezone string, weekStart models.WeekStart) (calendar, error) {
+	cal := utcCalendar
+	if timezone != "" {
+		// LoadLocation also accepts "Local", which means the server's zone
+		loc, err := time.LoadLocation(timezone)
+		if err != nil || timezone == "Local" {
+			return calendar{}, fmt.Errorf("%w: unknown time zone %q", ErrInvalidUserSettings, timezone)
+		}
+		cal.loc = loc
+	}
+
+	switch weekStart {
+	case "", models.WeekStartSunday:
+	case models.WeekStartMonday:
+		cal.weekStart = time.Monday
+	default:
+		return calendar{}, fmt.Errorf("%w: week_start must be sunday or monday", ErrInvalidUserSettings)
+	}
+
+	return cal, nil
+}
+
+// loadCalendar returns the calendar from the user's stored settings with
+// opts applied over them
+func loadCalendar(ctx context.Context, settingsRepo repository.UserSettingsRepository, userID string, opts models.CalendarOptions) (calendar, error) {
+	var timezone string
+	var weekStart models.WeekStart
+	if opts.Timezone == "" || opts.WeekStart == "" {
+		settings, err := settingsRepo.GetByUserID(ctx, userID)
+		if err != nil {
+			return calendar{}, err
+		}
+		if settings != nil {
+			timezone, weekStart = settings.Timezone, settings.WeekStart
+		}
+	}
+
+	if opts.Timezone != "" {
+		timezone = opts.Timezone
+	}
+	if opts.WeekStart != "" {
+		weekStart = opts.WeekStart
+	}
+
+	return newCalendar(timezone, weekStart)
+}
+
+// startOfDay returns local midnight of t's day
+func (c calendar) startOfDay(t time.Time) time.Time {
+	t = t.In(c.loc)
+	return time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, c.loc)
+}
+
+// startOfWeek returns local midnight of the first day of t's week
+func (c calendar) startOfWeek(t time.Time) time.Time {
+	day := c.startOfDay(t)
+	offset := (int(day.Weekday()) - int(c.weekStart) + 7) % 7
+	return c.addDays(day, -offset)
+}
+
+// addDays moves t by whole local days, keeping its wall clock time
+func (c calendar) addDays(t time.Time, days int) time.Time {
+	t = t.In(c.loc)
+	return time.Date(t.Year(), t.Month(), t.Day()+days, t.Hour(), t.Minute(), t.Second(), t.Nanosecond(), c.loc)
+}
+
+// date returns t's local day as midnight UTC, the form stored in DATE
+// columns such as daily_aggregates.date
+func (c calendar) date(t time.Time) time.Time {
+	t = t.In(c.loc)
+	return time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, time.UTC)
+}
+
+// fromDate returns local midnight of a date in the form returned by date
+func (c calendar) fromDate(date time.Time) time.Time {
+	return time.Date(date.Year(), date.Month(), date.Day(), 0, 0, 0, 0, c.loc)
+}
+
+// weekday returns t's local day of the week
+func (c calendar) weekday(t time.Time) time.Weekday {
+	return t.In(c.loc).Weekday()
+}
+
+// hour returns t's local hour of the day
+func (c calendar) hour(t time.Time) int {
+	return t.In(c.loc).Hour()
+}
+
+// startOf returns the start of the bucket of granularity g that contains t
+func (c calendar) startOf(g models.Granularity, t time.Time) time.Time {
+	t = t.In(c.loc)
+	switch g {
+	case models.GranularityHour:
+		return time.Date(t.Year(), t.Month(), t.Day(), t.Hour(), 0, 0, 0, c.loc)
+	case models.GranularityWeek:
+		return c.startOfWeek(t)
+	case models.GranularityMonth:
+		return time.Date(t.Year(), t.Month(), 1, 0, 0, 0, 0, c.loc)
+	case models.GranularityQuarter:
+		return time.Date(t.Year(), (t.Month()-1)/3*3+1, 1, 0, 0, 0, 0, c.loc)
+	case models.GranularityYear:
+		return time.Date(t.Year(), time.January, 1, 0, 0, 0, 0, c.loc)
+	default:
+		return c.startOfDay(t)
+	}
+}
+
+// next returns the start of the bucket of granularity g after the one
+// starting at start. Hours are elapsed time; longer buckets follow the
+// calendar, so across a DST change a day is 23 or 25 hours long.
+func (c calendar) next(g models.Granularity, start time.Time) time.Time {
+	start = start.In(c.loc)
+	switch g {
+	case models.GranularityHour:
+		return start.Add(time.Hour)
+	case models.GranularityWeek:
+		return c.addDays(start, 7)
+	case models.GranularityMonth:
+		return time.Date(start.Year(), start.Month()+1, 1, 0, 0, 0, 0, c.loc)
+	case models.GranularityQuarter:
+		return time.Date(start.Year(), start.Month()+3, 1, 0, 0, 0, 0, c.loc)
+	case models.GranularityYear:
+		return time.Date(start.Year()+1, time.January, 1, 0, 0, 0, 0, c.loc)
+	default:
+		return c.addDays(start, 1)
+	}
+}
@@ -0,0 +1,148 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/JonnyWalker81/trendy/backend/internal/models"
+	"github.com/JonnyWalker81/trendy/backend/internal/repository/memory"
+)
+
+func mustCalendar(t *testing.T, timezone string, weekStart models.WeekStart) calendar {
+	t.Helper()
+	cal, err := newCalendar(timezone, weekStart)
+	if err != nil {
+		t.Fatalf("newCalendar failed: %v", err)
+	}
+	return cal
+}
+
+func TestCalendar(t *testing.T) {
+	tokyo := mustCalendar(t, "Asia/Tokyo", models.WeekStartMonday)
+
+	// 23:30 on Sunday 1 March in Tokyo
+	late := time.Date(2026, 3, 1, 14, 30, 0, 0, time.UTC)
+	if got := tokyo.date(late); !got.Equal(time.Date(2026, 3, 1, 0, 0, 0, 0, time.UTC)) {
+		t.Errorf("expected 1 March, got %v", got)
+	}
+	if got := tokyo.date(late.Add(time.Hour)); !got.Equal(time.Date(2026, 3, 2, 0, 0, 0, 0, time.UTC)) {
+		t.Errorf("expected 2 March, got %v", got)
+	}
+	if got := tokyo.hour(late); got != 23 {
+		t.Errorf("expected hour 23, got %d", got)
+	}
+	if got := tokyo.startOfWeek(late); got.Format(time.RFC3339) != "2026-02-23T00:00:00+09:00" {
+		t.Errorf("expected the week to start on Monday 23 February, got %v", got)
+	}
+	sundays := mustCalendar(t, "Asia/Tokyo", models.WeekStartSunday)
+	if got := sundays.startOfWeek(late); got.Format(time.RFC3339) != "2026-03-01T00:00:00+09:00" {
+		t.Errorf("expected the week to start on Sunday 1 March, got %v", got)
+	}
+
+	// Clocks in New York go forward on 8 March 2026, so that day is 23 hours
+	newYork := mustCalendar(t, "America/New_York", "")
+	day := newYork.startOfDay(time.Date(2026, 3, 8, 12, 0, 0, 0, time.UTC))
+	next := newYork.addDays(day, 1)
+	if next.Sub(day) != 23*time.Hour || next.Format(time.RFC3339) != "2026-03-09T00:00:00-04:00" {
+		t.Errorf("expected a 23 hour day ending at local midnight, got %v to %v", day, next)
+	}
+
+	for _, tt := range []struct {
+		timezone  string
+		weekStart models.WeekStart
+	}{
+		{"Mars/Olympus", ""},
+		{"Local", ""},
+		{"UTC", "tuesday"},
+	} {
+		if _, err := newCalendar(tt.timezone, tt.weekStart); !errors.Is(err, ErrInvalidUserSettings) {
+			t.Errorf("%q %q: expected ErrInvalidUserSettings, got %v", tt.timezone, tt.weekStart, err)
+		}
+	}
+}
+
+func TestInsightsUseTimeZone(t *testing.T) {
+	tokyo := mustCalendar(t, "Asia/Tokyo", "")
+
+	// Runs at 23:00 Tokyo time on three days in a row, then one at 08:00 on
+	// 4 March, which is still 3 March in UTC
+	var events []models.Event
+	for day := 1; day <= 3; day++ {
+		events = append(events, models.Event{EventTypeID: "run", Timestamp: time.Date(2026, 3, day, 14, 0, 0, 0, time.UTC)})
+	}
+	events = append(events, models.Event{EventTypeID: "run", Timestamp: time.Date(2026, 3, 3, 23, 0, 0, 0, time.UTC)})
+
+	aggregates := buildDailyAggregates(events, "user-1", tokyo)
+	counts := make(map[string]int)
+	for _, agg := range aggregates {
+		counts[agg.Date.Format("2006-01-02")] = agg.EventCount
+	}
+	if len(counts) != 4 || counts["2026-03-04"] != 1 {
+		t.Errorf("expected the 08:00 run on 4 March, got %v", counts)
+	}
+
+	_, longest := calculateStreaksForEventType(aggregates, "run", tokyo)
+	if longest.Length != 4 {
+		t.Errorf("expected a 4 day streak in Tokyo, got %d", longest.Length)
+	}
+	if _, longest := calculateStreaksForEventType(buildDailyAggregates(events, "user-1", utcCalendar), "run", utcCalendar); longest.Length != 3 {
+		t.Errorf("expected a 3 day streak in UTC, got %d", longest.Length)
+	}
+
+	if pattern := calculateHourPattern(aggregates); pattern.PeakValue != 23 {
+		t.Errorf("expected the peak at 11 PM, got %s", pattern.PeakLabel)
+	}
+}
+
+func TestTrendsUseTimeZone(t *testing.T) {
+	ctx := context.Background()
+	repos := memory.NewRepositories(memory.NewStore())
+	aggregates := NewAggregateService(repos.Events, repos.DailyAggregates, repos.AggregateStates, repos.ChangeLog, repos.UserSettings)
+	svc := NewAnalyticsService(repos.Events, repos.EventTypes, repos.PropertyDefinitions, repos.UserSettings, aggregates)
+
+	if _, err := repos.UserSettings.Upsert(ctx, &models.UserSettings{UserID: "user-1", Timezone: "America/New_York", WeekStart: models.WeekStartSunday}); err != nil {
+		t.Fatalf("Upsert failed: %v", err)
+	}
+	for _, ts := range []string{
+		"2026-03-08T03:30:00Z", // 22:30 on 7 March in New York
+		"2026-03-08T05:00:00Z", // Midnight on 8 March
+		"2026-03-09T03:30:00Z", // 23:30 on 8 March, after the clocks went forward
+	} {
+		timestamp, _ := time.Parse(time.RFC3339, ts)
+		if _, err := repos.Events.Create(ctx, &models.Event{UserID: "user-1", EventTypeID: "run", Timestamp: timestamp, SourceType: "manual"}); err != nil {
+			t.Fatalf("Create event failed: %v", err)
+		}
+	}
+
+	start := time.Date(2026, 3, 7, 12, 0, 0, 0, time.UTC)
+	end := time.Date(2026, 3, 9, 12, 0, 0, 0, time.UTC)
+	trend, err := svc.GetEventTypeAnalytics(ctx, "user-1", "run", &models.TrendQuery{Period: "month", StartDate: start, EndDate: end}, models.CalendarOptions{})
+	if err != nil {
+		t.Fatalf("GetEventTypeAnalytics failed: %v", err)
+	}
+	var got []int64
+	for _, dp := range trend.Data {
+		got = append(got, dp.Count)
+	}
+	if len(got) != 3 || got[0] != 1 || got[1] != 2 || got[2] != 0 {
+		t.Errorf("expected daily counts [1 2 0], got %v", got)
+	}
+	if first := trend.Data[0].Date; first.Format(time.RFC3339) != "2026-03-07T00:00:00-05:00" {
+		t.Errorf("expected the first bucket at local midnight, got %v", first)
+	}
+
+	// A request can override the stored time zone
+	trend, err = svc.GetEventTypeAnalytics(ctx, "user-1", "run", &models.TrendQuery{Period: "month", StartDate: start, EndDate: end}, models.CalendarOptions{Timezone: "UTC"})
+	if err != nil {
+		t.Fatalf("GetEventTypeAnalytics failed: %v", err)
+	}
+	if got := trend.Data[1].Count; got != 2 || trend.Data[1].Date.Format(time.RFC3339) != "2026-03-08T00:00:00Z" {
+		t.Errorf("expected 2 events on 8 March UTC, got %d at %v", got, trend.Data[1].Date)
+	}
+
+	if _, err := svc.GetTrends(ctx, "user-1", &models.TrendQuery{Period: "month", StartDate: start, EndDate: end}, models.CalendarOptions{Timezone: "Nowhere/Special"}); !errors.Is(err, ErrInvalidUserSettings) {
+		t.Errorf("expected an invalid override to fail, got %v", err)
+	}
+}
@@ -0,0 +1,75 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/JonnyWalker81/trendy/backend/internal/logger"
+	"github.com/JonnyWalker81/trendy/backend/internal/repository"
+)
+
+// CompactionResult summarizes one change log maintenance run
+type CompactionResult struct {
+	Compacted int64 `json:"compacted"` // Entries superseded by a later entry for the same entity
+	Pruned    int64 `json:"pruned"`    // Entries older than the retention window
+}
+
+type changeLogCompactor struct {
+	changeLogRepo repository.ChangeLogRepository
+	retention     time.Duration
+}
+
+// NewChangeLogCompactor creates a compactor that collapses superseded change
+// log entries and prunes entries older than retention. A zero retention keeps
+// entries forever.
+func NewChangeLogCompactor(changeLogRepo repository.ChangeLogRepository, retention time.Duration) ChangeLogCompactor {
+	return &changeLogCompactor{changeLogRepo: changeLogRepo, retention: retention}
+}
+
+func (c *changeLogCompactor) Run(ctx context.Context) (*CompactionResult, error) {
+	result := &CompactionResult{}
+
+	// Compaction never strands a client: the entity's latest entry remains, so
+	// replaying from any cursor still converges
+	compacted, err := c.changeLogRepo.Compact(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to compact change log: %w", err)
+	}
+	result.Compacted = compacted
+
+	if c.retention > 0 {
+		pruned, err := c.changeLogRepo.Prune(ctx, time.Now().Add(-c.retention))
+		if err != nil {
+			return nil, fmt.Errorf("failed to prune change log: %w", err)
+		}
+		result.Pruned = pruned
+	}
+
+	return result, nil
+}
+
+// RunChangeLogCompaction runs the compactor every interval until ctx is done.
+// Failures are logged and retried on the next tick.
+func RunChangeLogCompaction(ctx context.Context, compactor ChangeLogCompactor, interval time.Duration) {
+	log := logger.FromContext(ctx)
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			result, err := compactor.Run(ctx)
+			if err != nil {
+				log.Error("change log compaction failed", logger.Err(err))
+				continue
+			}
+			log.Info("change log compaction complete",
+				logger.Int64("compacted", result.Compacted),
+				logger.Int64("pruned", result.Pruned),
+			)
+		}
+	}
+}
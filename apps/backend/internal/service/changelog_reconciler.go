@@ -0,0 +1,232 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/JonnyWalker81/trendy/backend/internal/logger"
+	"github.com/JonnyWalker81/trendy/backend/internal/models"
+	"github.com/JonnyWalker81/trendy/backend/internal/repository"
+)
+
+// reconcileGracePeriod skips entities changed this recently, since their
+// change log entry may still be in flight on backends without transactions
+const reconcileGracePeriod = 5 * time.Minute
+
+// reconcileEventPageSize is the number of events loaded per query
+const reconcileEventPageSize = 1000
+
+// ReconcileResult summarizes a change log reconciliation run for one user
+type ReconcileResult struct {
+	UserID     string `json:"user_id"`
+	Checked    int    `json:"checked"`    // Entities compared against the change log
+	Missing    int    `json:"missing"`    // Entries that were absent or stale
+	Backfilled int    `json:"backfilled"` // Entries appended (0 on a dry run)
+}
+
+type changeLogReconciler struct {
+	eventRepo       repository.EventRepository
+	eventTypeRepo   repository.EventTypeRepository
+	propertyDefRepo repository.PropertyDefinitionRepository
+	geofenceRepo    repository.GeofenceRepository
+	changeLogRepo   repository.ChangeLogRepository
+	gracePeriod     time.Duration
+}
+
+// NewChangeLogReconciler creates a reconciler that compares synced entities
+// with the change log and backfills entries that were never written
+func NewChangeLogReconciler(
+	eventRepo repository.EventRepository,
+	eventTypeRepo repository.EventTypeRepository,
+	propertyDefRepo repository.PropertyDefinitionRepository,
+	geofenceRepo repository.GeofenceRepository,
+	changeLogRepo repository.ChangeLogRepository,
+) ChangeLogReconciler {
+	return &changeLogReconciler{
+		eventRepo:       eventRepo,
+		eventTypeRepo:   eventTypeRepo,
+		propertyDefRepo: propertyDefRepo,
+		geofenceRepo:    geofenceRepo,
+		changeLogRepo:   changeLogRepo,
+		gracePeriod:     reconcileGracePeriod,
+	}
+}
+
+// entityKey identifies an entity across entity types
+type entityKey struct {
+	entityType models.EntityType
+	id         string
+}
+
+// syncedEntity is the current state of an entity that clients sync
+type syncedEntity struct {
+	key       entityKey
+	updatedAt time.Time
+	data      interface{}
+	// logsUpdates is false for entities whose updates are intentionally not
+	// logged, such as HealthKit events refreshed by batch imports
+	logsUpdates bool
+}
+
+// Reconcile detects entities whose latest state is missing from the change
+// log and appends the missing entries:
+//   - an entity with no entry gets a create
+//   - an entity updated after its latest entry gets an update
+//   - a logged entity that no longer exists gets a delete
+func (r *changeLogReconciler) Reconcile(ctx context.Context, userID string, dryRun bool) (*ReconcileResult, error) {
+	log := logger.FromContext(ctx).With(logger.String("user_id", userID))
+
+	latest, err := r.latestEntries(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	entities, err := r.loadEntities(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	result := &ReconcileResult{UserID: userID, Checked: len(entities)}
+	cutoff := time.Now().Add(-r.gracePeriod)
+
+	// Entities are loaded parents first, so backfilled creates keep that order
+	var missing []models.ChangeLogInput
+	exists := make(map[entityKey]bool, len(entities))
+	for _, entity := range entities {
+		key := entity.key
+		exists[key] = true
+		if entity.updatedAt.After(cutoff) {
+			continue
+		}
+
+		entry, logged := latest[key]
+		switch {
+		case !logged || entry.Operation == models.OperationDelete:
+			missing = append(missing, models.ChangeLogInput{
+				EntityType: key.entityType,
+				Operation:  models.OperationCreate,
+				EntityID:   key.id,
+				UserID:     userID,
+				Data:       entity.data,
+			})
+		case entity.logsUpdates && entry.CreatedAt.Before(entity.updatedAt):
+			missing = append(missing, models.ChangeLogInput{
+				EntityType: key.entityType,
+				Operation:  models.OperationUpdate,
+				EntityID:   key.id,
+				UserID:     userID,
+				Data:       entity.data,
+			})
+		}
+	}
+
+	deletes := make([]models.ChangeEntry, 0)
+	for key, entry := range latest {
+		if exists[key] || entry.Operation == models.OperationDelete || entry.CreatedAt.After(cutoff) {
+			continue
+		}
+		deletes = append(deletes, entry)
+	}
+	sort.Slice(deletes, func(i, j int) bool { return deletes[i].ID < deletes[j].ID })
+
+	for _, entry := range deletes {
+		deletedAt := time.Now()
+		missing = append(missing, models.ChangeLogInput{
+			EntityType: entry.EntityType,
+			Operation:  models.OperationDelete,
+			EntityID:   entry.EntityID,
+			UserID:     userID,
+			DeletedAt:  &deletedAt,
+		})
+	}
+
+	result.Missing = len(missing)
+	for i := range missing {
+		input := &missing[i]
+		log.Info("missing change log entry",
+			logger.String("entity_type", string(input.EntityType)),
+			logger.String("entity_id", input.EntityID),
+			logger.String("operation", string(input.Operation)),
+			logger.Bool("dry_run", dryRun),
+		)
+		if dryRun {
+			continue
+		}
+		if _, err := r.changeLogRepo.Append(ctx, input); err != nil {
+			return result, fmt.Errorf("failed to backfill change log: %w", err)
+		}
+		result.Backfilled++
+	}
+
+	return result, nil
+}
+
+// latestEntries pages through the user's change log and returns the most
+// recent entry for each entity
+func (r *changeLogReconciler) latestEntries(ctx context.Context, userID string) (map[entityKey]models.ChangeEntry, error) {
+	latest := make(map[entityKey]models.ChangeEntry)
+
+	var cursor int64
+	for {
+		page, err := r.changeLogRepo.GetSince(ctx, userID, cursor, 500)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read change log: %w", err)
+		}
+		for _, entry := range page.Changes {
+			// Data is not needed to compare and can be large
+			entry.Data = nil
+			latest[entityKey{entry.EntityType, entry.EntityID}] = entry
+		}
+		if !page.HasMore {
+			return latest, nil
+		}
+		cursor = page.NextCursor
+	}
+}
+
+// loadEntities returns every synced entity the user owns, parents before
+// their children
+func (r *changeLogReconciler) loadEntities(ctx context.Context, userID string) ([]syncedEntity, error) {
+	var entities []syncedEntity
+	var defs []models.PropertyDefinition
+
+	eventTypes, err := r.eventTypeRepo.GetByUserID(ctx, userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get event types: %w", err)
+	}
+	for _, et := range eventTypes {
+		entities = append(entities, syncedEntity{entityKey{models.EntityTypeEventType, et.ID}, et.UpdatedAt, et, true})
+
+		etDefs, err := r.propertyDefRepo.GetByEventTypeID(ctx, et.ID)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get property definitions: %w", err)
+		}
+		defs = append(defs, etDefs...)
+	}
+	for _, def := range defs {
+		entities = append(entities, syncedEntity{entityKey{models.EntityTypePropertyDefinition, def.ID}, def.UpdatedAt, def, true})
+	}
+
+	geofences, err := r.geofenceRepo.GetByUserID(ctx, userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get geofences: %w", err)
+	}
+	for _, g := range geofences {
+		entities = append(entities, syncedEntity{entityKey{models.EntityTypeGeofence, g.ID}, g.UpdatedAt, g, true})
+	}
+
+	for offset := 0; ; offset += reconcileEventPageSize {
+		events, err := r.eventRepo.GetByUserID(ctx, userID, reconcileEventPageSize, offset)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get events: %w", err)
+		}
+		for _, e := range events {
+			entities = append(entities, syncedEntity{entityKey{models.EntityTypeEvent, e.ID}, e.UpdatedAt, e, e.SourceType != "healthkit"})
+		}
+		if len(events) < reconcileEventPageSize {
+			return entities, nil
+		}
+	}
+}
@@ -0,0 +1,141 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/JonnyWalker81/trendy/backend/internal/models"
+	"github.com/JonnyWalker81/trendy/backend/internal/repository"
+	"github.com/JonnyWalker81/trendy/backend/internal/repository/memory"
+)
+
+func TestReconcileBackfillsMissingEntries(t *testing.T) {
+	ctx := context.Background()
+	repos := memory.NewRepositories(memory.NewStore())
+	userID := "user-1"
+
+	// Written through the service: create and update are both logged
+	eventTypeService := NewEventTypeService(repos.EventTypes, repos.Events, repos.PropertyDefinitions, repos.Geofences, repos.Insights, repos.Streaks, repos.ChangeLog, repos.Transactor)
+	logged, err := eventTypeService.CreateEventType(ctx, userID, &models.CreateEventTypeRequest{Name: "Run"})
+	if err != nil {
+		t.Fatalf("CreateEventType failed: %v", err)
+	}
+
+	// Written directly: no change log entry
+	unlogged, _ := repos.EventTypes.Create(ctx, &models.EventType{UserID: userID, Name: "Swim"})
+	event, _ := repos.Events.Create(ctx, &models.Event{UserID: userID, EventTypeID: logged.ID, Timestamp: time.Now()})
+
+	// Logged, then deleted without an entry
+	geofence, _ := repos.Geofences.Create(ctx, &models.Geofence{UserID: userID, Name: "Home", Radius: 100})
+	repos.ChangeLog.Append(ctx, &models.ChangeLogInput{
+		EntityType: models.EntityTypeGeofence,
+		Operation:  models.OperationCreate,
+		EntityID:   geofence.ID,
+		UserID:     userID,
+		Data:       geofence,
+	})
+	repos.Geofences.Delete(ctx, geofence.ID)
+
+	// Logged, then updated without an entry
+	time.Sleep(time.Millisecond)
+	repos.EventTypes.Update(ctx, logged.ID, &models.EventType{Name: "Running"})
+
+	reconciler := NewChangeLogReconciler(repos.Events, repos.EventTypes, repos.PropertyDefinitions, repos.Geofences, repos.ChangeLog)
+	reconciler.(*changeLogReconciler).gracePeriod = 0
+
+	dryRun, err := reconciler.Reconcile(ctx, userID, true)
+	if err != nil {
+		t.Fatalf("dry run failed: %v", err)
+	}
+	if dryRun.Missing != 4 || dryRun.Backfilled != 0 {
+		t.Fatalf("dry run: expected 4 missing and 0 backfilled, got %d and %d", dryRun.Missing, dryRun.Backfilled)
+	}
+
+	result, err := reconciler.Reconcile(ctx, userID, false)
+	if err != nil {
+		t.Fatalf("Reconcile failed: %v", err)
+	}
+	if result.Backfilled != 4 {
+		t.Fatalf("expected 4 backfilled entries, got %d", result.Backfilled)
+	}
+
+	page, _ := repos.ChangeLog.GetSince(ctx, userID, 0, 100)
+	ops := make(map[string]models.Operation)
+	for _, entry := range page.Changes {
+		ops[entry.EntityID] = entry.Operation
+	}
+	expected := map[string]models.Operation{
+		logged.ID:   models.OperationUpdate,
+		unlogged.ID: models.OperationCreate,
+		event.ID:    models.OperationCreate,
+		geofence.ID: models.OperationDelete,
+	}
+	for id, op := range expected {
+		if ops[id] != op {
+			t.Errorf("entity %s: expected latest operation %s, got %s", id, op, ops[id])
+		}
+	}
+
+	again, err := reconciler.Reconcile(ctx, userID, false)
+	if err != nil {
+		t.Fatalf("second Reconcile failed: %v", err)
+	}
+	if again.Missing != 0 {
+		t.Errorf("expected reconciled log to be complete, got %d missing", again.Missing)
+	}
+}
+
+// TestReconcileAfterPrune verifies retention keeps an unchanged entity
+// logged, so reconciling does not append a second create for it.
+func TestReconcileAfterPrune(t *testing.T) {
+	ctx := context.Background()
+	repos := memory.NewRepositories(memory.NewStore())
+	userID := "user-1"
+
+	eventTypeService := NewEventTypeService(repos.EventTypes, repos.Events, repos.PropertyDefinitions, repos.Geofences, repos.Insights, repos.Streaks, repos.ChangeLog, repos.Transactor)
+	if _, err := eventTypeService.CreateEventType(ctx, userID, &models.CreateEventTypeRequest{Name: "Run"}); err != nil {
+		t.Fatalf("CreateEventType failed: %v", err)
+	}
+
+	if _, err := repos.ChangeLog.Prune(ctx, time.Now().Add(time.Second)); err != nil {
+		t.Fatalf("Prune failed: %v", err)
+	}
+
+	reconciler := NewChangeLogReconciler(repos.Events, repos.EventTypes, repos.PropertyDefinitions, repos.Geofences, repos.ChangeLog)
+	reconciler.(*changeLogReconciler).gracePeriod = 0
+
+	result, err := reconciler.Reconcile(ctx, userID, true)
+	if err != nil {
+		t.Fatalf("Reconcile failed: %v", err)
+	}
+	if result.Missing != 0 {
+		t.Errorf("expected no missing entries after pruning, got %d", result.Missing)
+	}
+}
+
+func TestWriteRollsBackWhenChangeLogFails(t *testing.T) {
+	ctx := context.Background()
+	repos := memory.NewRepositories(memory.NewStore())
+
+	service := NewEventTypeService(repos.EventTypes, repos.Events, repos.PropertyDefinitions, repos.Geofences, repos.Insights, repos.Streaks, failingChangeLog{repos.ChangeLog}, repos.Transactor)
+	if _, err := service.CreateEventType(ctx, "user-1", &models.CreateEventTypeRequest{Name: "Run"}); err == nil {
+		t.Fatal("expected change log failure to be returned")
+	}
+
+	types, _ := repos.EventTypes.GetByUserID(ctx, "user-1")
+	if len(types) != 0 {
+		t.Errorf("expected event type write to roll back, got %d event types", len(types))
+	}
+}
+
+// failingChangeLog fails every append the way an unreachable database does
+type failingChangeLog struct {
+	repository.ChangeLogRepository
+}
+
+func (failingChangeLog) Append(ctx context.Context, input *models.ChangeLogInput) (int64, error) {
+	return 0, fmt.Errorf("failed to append to change log: %w", errors.New("connection refused"))
+}
@@ -0,0 +1,197 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/JonnyWalker81/trendy/backend/internal/logger"
+	"github.com/JonnyWalker81/trendy/backend/internal/models"
+	"github.com/JonnyWalker81/trendy/backend/internal/repository"
+)
+
+// relaySettlePeriod is how long the request that wrote an entity has to
+// append its change log entry before the relay steps in
+const relaySettlePeriod = time.Minute
+
+// relayLease is how long a claimed outbox entry is reserved for one relay run
+const relayLease = 5 * time.Minute
+
+// relayBatchSize is the number of outbox entries claimed at a time
+const relayBatchSize = 100
+
+type changeLogRelay struct {
+	eventRepo       repository.EventRepository
+	eventTypeRepo   repository.EventTypeRepository
+	propertyDefRepo repository.PropertyDefinitionRepository
+	geofenceRepo    repository.GeofenceRepository
+	changeLogRepo   repository.ChangeLogRepository
+	outboxRepo      repository.ChangeOutboxRepository
+}
+
+// NewChangeLogRelay creates a relay that appends the change log entries of
+// writes recorded in the change outbox but never logged
+func NewChangeLogRelay(
+	eventRepo repository.EventRepository,
+	eventTypeRepo repository.EventTypeRepository,
+	propertyDefRepo repository.PropertyDefinitionRepository,
+	geofenceRepo repository.GeofenceRepository,
+	changeLogRepo repository.ChangeLogRepository,
+	outboxRepo repository.ChangeOutboxRepository,
+) ChangeLogRelay {
+	return &changeLogRelay{
+		eventRepo:       eventRepo,
+		eventTypeRepo:   eventTypeRepo,
+		propertyDefRepo: propertyDefRepo,
+		geofenceRepo:    geofenceRepo,
+		changeLogRepo:   changeLogRepo,
+		outboxRepo:      outboxRepo,
+	}
+}
+
+// Relay drains the settled outbox entries and returns the number of change
+// log entries appended. An entry that fails stays leased and is retried by a
+// later run.
+func (r *changeLogRelay) Relay(ctx context.Context) (int, error) {
+	log := logger.FromContext(ctx)
+
+	appended := 0
+	for {
+		entries, err := r.outboxRepo.Claim(ctx, time.Now().Add(-relaySettlePeriod), relayLease, relayBatchSize)
+		if err != nil {
+			return appended, fmt.Errorf("failed to claim change outbox: %w", err)
+		}
+
+		for i := range entries {
+			entry := &entries[i]
+			ok, err := r.relay(ctx, entry)
+			if err != nil {
+				log.Error("failed to relay change",
+					logger.String("entity_type", string(entry.EntityType)),
+					logger.String("entity_id", entry.EntityID),
+					logger.Err(err),
+				)
+				continue
+			}
+			if ok {
+				appended++
+			}
+		}
+
+		if len(entries) < relayBatchSize {
+			return appended, nil
+		}
+	}
+}
+
+// relay appends the entry that brings the change log up to date with the
+// entity's current state, if one is missing, and removes the outbox entry.
+// It reports whether an entry was appended.
+func (r *changeLogRelay) relay(ctx context.Context, entry *models.OutboxEntry) (bool, error) {
+	entity, logsUpdates, err := r.load(ctx, entry.EntityType, entry.EntityID)
+	if err != nil {
+		return false, err
+	}
+
+	logged := entry.LoggedOperation != nil && *entry.LoggedOperation != models.OperationDelete
+	input := &models.ChangeLogInput{
+		EntityType: entry.EntityType,
+		EntityID:   entry.EntityID,
+		UserID:     entry.UserID,
+	}
+	switch {
+	case entity != nil && !logged:
+		input.Operation = models.OperationCreate
+		input.Data = entity
+	case entity != nil && logsUpdates:
+		input.Operation = models.OperationUpdate
+		input.Data = entity
+	case entity == nil && logged:
+		deletedAt := time.Now()
+		input.Operation = models.OperationDelete
+		input.DeletedAt = &deletedAt
+	default:
+		// Clients already have the entity's current state
+		input = nil
+	}
+
+	if input != nil {
+		if _, err := r.changeLogRepo.Append(ctx, input); err != nil {
+			return false, fmt.Errorf("failed to append to change log: %w", err)
+		}
+	}
+
+	if err := r.outboxRepo.Delete(ctx, entry.ID); err != nil {
+		return input != nil, err
+	}
+
+	return input != nil, nil
+}
+
+// load returns the current state of an entity, or nil if it was deleted or
+// is in the trash, and whether updates to it are logged
+func (r *changeLogRelay) load(ctx context.Context, entityType models.EntityType, id string) (interface{}, bool, error) {
+	var entity interface{}
+	logsUpdates := true
+	var err error
+
+	switch entityType {
+	case models.EntityTypeEvent:
+		var e *models.Event
+		if e, err = r.eventRepo.GetByID(ctx, id); err == nil {
+			entity = e
+			// Batch refreshes of HealthKit events are intentionally not logged
+			logsUpdates = e.SourceType != "healthkit"
+		}
+	case models.EntityTypeEventType:
+		var et *models.EventType
+		if et, err = r.eventTypeRepo.GetByID(ctx, id); err == nil {
+			entity = et
+		}
+	case models.EntityTypePropertyDefinition:
+		var def *models.PropertyDefinition
+		if def, err = r.propertyDefRepo.GetByID(ctx, id); err == nil {
+			entity = def
+		}
+	case models.EntityTypeGeofence:
+		var g *models.Geofence
+		if g, err = r.geofenceRepo.GetByID(ctx, id); err == nil {
+			entity = g
+		}
+	default:
+		return nil, false, fmt.Errorf("unknown entity type %q", entityType)
+	}
+
+	if err != nil {
+		if strings.Contains(err.Error(), "not found") {
+			return nil, false, nil
+		}
+		return nil, false, fmt.Errorf("failed to get %s: %w", entityType, err)
+	}
+
+	return entity, logsUpdates, nil
+}
+
+// RunChangeLogRelay runs the relay every interval until ctx is done.
+// Failures are logged and retried on the next tick.
+func RunChangeLogRelay(ctx context.Context, relay ChangeLogRelay, interval time.Duration) {
+	log := logger.FromContext(ctx)
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			appended, err := relay.Relay(ctx)
+			if err != nil {
+				log.Error("change log relay failed", logger.Err(err))
+			}
+			if appended > 0 {
+				log.Warn("relayed change log entries missed by their writes", logger.Int("appended", appended))
+			}
+		}
+	}
+}
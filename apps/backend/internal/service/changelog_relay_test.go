@@ -0,0 +1,89 @@
+package service
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/JonnyWalker81/trendy/backend/internal/models"
+	"github.com/JonnyWalker81/trendy/backend/internal/repository/memory"
+)
+
+// stubOutboxRepository hands out fixed outbox entries, standing in for the
+// database triggers the memory backend does not have
+type stubOutboxRepository struct {
+	entries []models.OutboxEntry
+	deleted []int64
+}
+
+func (r *stubOutboxRepository) Claim(ctx context.Context, settledBefore time.Time, lease time.Duration, limit int) ([]models.OutboxEntry, error) {
+	claimed := r.entries
+	r.entries = nil
+	return claimed, nil
+}
+
+func (r *stubOutboxRepository) Delete(ctx context.Context, id int64) error {
+	r.deleted = append(r.deleted, id)
+	return nil
+}
+
+func (r *stubOutboxRepository) DeleteByUserID(ctx context.Context, userID string) error {
+	return nil
+}
+
+// TestRelayAppendsUnloggedChanges verifies the relay logs writes whose entry
+// was lost from the entity's current state and skips those already logged.
+func TestRelayAppendsUnloggedChanges(t *testing.T) {
+	ctx := context.Background()
+	repos := memory.NewRepositories(memory.NewStore())
+	userID := "user-1"
+	created := models.OperationCreate
+
+	// Written without an entry
+	unlogged, _ := repos.EventTypes.Create(ctx, &models.EventType{UserID: userID, Name: "Run"})
+	// Logged, then deleted without an entry
+	geofence, _ := repos.Geofences.Create(ctx, &models.Geofence{UserID: userID, Name: "Home", Radius: 100})
+	repos.ChangeLog.Append(ctx, &models.ChangeLogInput{
+		EntityType: models.EntityTypeGeofence,
+		Operation:  models.OperationCreate,
+		EntityID:   geofence.ID,
+		UserID:     userID,
+		Data:       geofence,
+	})
+	repos.Geofences.Delete(ctx, geofence.ID)
+	// HealthKit refresh, whose updates are not logged
+	healthKit, _ := repos.Events.Create(ctx, &models.Event{UserID: userID, EventTypeID: unlogged.ID, Timestamp: time.Now(), SourceType: "healthkit"})
+
+	outbox := &stubOutboxRepository{entries: []models.OutboxEntry{
+		{ID: 1, EntityType: models.EntityTypeEventType, EntityID: unlogged.ID, UserID: userID},
+		{ID: 2, EntityType: models.EntityTypeGeofence, EntityID: geofence.ID, UserID: userID, LoggedOperation: &created},
+		{ID: 3, EntityType: models.EntityTypeEvent, EntityID: healthKit.ID, UserID: userID, LoggedOperation: &created},
+	}}
+	relay := NewChangeLogRelay(repos.Events, repos.EventTypes, repos.PropertyDefinitions, repos.Geofences, repos.ChangeLog, outbox)
+
+	appended, err := relay.Relay(ctx)
+	if err != nil {
+		t.Fatalf("Relay failed: %v", err)
+	}
+	if appended != 2 {
+		t.Errorf("expected 2 entries appended, got %d", appended)
+	}
+	if len(outbox.deleted) != 3 {
+		t.Errorf("expected every outbox entry removed, got %v", outbox.deleted)
+	}
+
+	page, _ := repos.ChangeLog.GetSince(ctx, userID, 0, 100)
+	got := make(map[string]models.Operation)
+	for _, entry := range page.Changes {
+		got[entry.EntityID] = entry.Operation
+	}
+	if got[unlogged.ID] != models.OperationCreate {
+		t.Errorf("expected create for unlogged event type, got %q", got[unlogged.ID])
+	}
+	if got[geofence.ID] != models.OperationDelete {
+		t.Errorf("expected delete for removed geofence, got %q", got[geofence.ID])
+	}
+	if _, ok := got[healthKit.ID]; ok {
+		t.Errorf("expected no entry for HealthKit refresh, got %q", got[healthKit.ID])
+	}
+}
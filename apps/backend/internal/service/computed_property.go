@@ -0,0 +1,273 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+	"slices"
+	"time"
+
+	"github.com/JonnyWalker81/trendy/backend/internal/formula"
+	"github.com/JonnyWalker81/trendy/backend/internal/models"
+)
+
+// Event fields a formula can use by name, as Unix seconds. A property with
+// the same key takes precedence.
+const (
+	formulaTimestamp = "timestamp"
+	formulaEndDate   = "end_date"
+)
+
+// computedProperty is a computed property definition with its parsed formula
+type computedProperty struct {
+	def  models.PropertyDefinition
+	expr *formula.Expr
+}
+
+// checkFormula checks the formula of a computed property definition against
+// the other definitions of its event type: it must parse, use only known
+// properties and event fields, and not depend on itself
+func checkFormula(def models.PropertyDefinition, siblings []models.PropertyDefinition) error {
+	expr, err := formula.Parse(def.Formula)
+	if err != nil {
+		return fmt.Errorf("invalid formula: %v", err)
+	}
+
+	switch def.PropertyType {
+	case models.PropertyTypeNumber, models.PropertyTypeDuration, models.PropertyTypeQuantity:
+	default:
+		return fmt.Errorf("computed properties must have type number, duration or quantity")
+	}
+	if def.Required || def.DefaultValue != nil {
+		return fmt.Errorf("computed properties cannot be required or have a default value")
+	}
+
+	defs := []models.PropertyDefinition{def}
+	for _, sibling := range siblings {
+		if sibling.ID != def.ID && sibling.Key != def.Key {
+			defs = append(defs, sibling)
+		}
+	}
+	for _, name := range expr.Names() {
+		if name == def.Key {
+			return fmt.Errorf("formula cannot use its own property")
+		}
+		if name != formulaTimestamp && name != formulaEndDate &&
+			!slices.ContainsFunc(defs, func(d models.PropertyDefinition) bool { return d.Key == name }) {
+			return fmt.Errorf("formula uses unknown property %q", name)
+		}
+	}
+
+	_, err = computedOrder(defs)
+	return err
+}
+
+// computedOrder returns the computed definitions of defs ordered so that each
+// comes after the computed properties its formula uses. It fails if formulas
+// depend on each other in a cycle. Formulas that do not parse are skipped.
+func computedOrder(defs []models.PropertyDefinition) ([]computedProperty, error) {
+	byKey := make(map[string]computedProperty)
+	var keys []string
+	for _, def := range defs {
+		if def.Formula == "" {
+			continue
+		}
+		expr, err := formula.Parse(def.Formula)
+		if err != nil {
+			continue
+		}
+		byKey[def.Key] = computedProperty{def: def, expr: expr}
+		keys = append(keys, def.Key)
+	}
+
+	const (
+		visiting = 1
+		done     = 2
+	)
+	state := make(map[string]int, len(keys))
+	order := make([]computedProperty, 0, len(keys))
+	var visit func(key string) error
+	visit = func(key string) error {
+		switch state[key] {
+		case visiting:
+			return fmt.Errorf("formula of %q depends on itself", key)
+		case done:
+			return nil
+		}
+		state[key] = visiting
+		for _, name := range byKey[key].expr.Names() {
+			if _, ok := byKey[name]; ok {
+				if err := visit(name); err != nil {
+					return err
+				}
+			}
+		}
+		state[key] = done
+		order = append(order, byKey[key])
+		return nil
+	}
+	for _, key := range keys {
+		if err := visit(key); err != nil {
+			return nil, err
+		}
+	}
+	return order, nil
+}
+
+// computeProperties returns properties with the values of the computed
+// definitions among defs evaluated from the other properties and the event's
+// times. Values sent for computed properties are replaced; a formula without
+// a valid result leaves its property unset. The input map is not modified.
+func computeProperties(properties map[string]models.PropertyValue, defs []models.PropertyDefinition, timestamp time.Time, endDate *time.Time) map[string]models.PropertyValue {
+	order, err := computedOrder(defs)
+	if err != nil || len(order) == 0 {
+		return properties
+	}
+
+	result := make(map[string]models.PropertyValue, len(properties)+len(order))
+	for key, value := range properties {
+		result[key] = value
+	}
+	env := func(name string) (float64, bool) {
+		if prop, ok := result[name]; ok {
+			return formulaNumber(prop)
+		}
+		switch name {
+		case formulaTimestamp:
+			return float64(timestamp.Unix()), true
+		case formulaEndDate:
+			if endDate != nil {
+				return float64(endDate.Unix()), true
+			}
+		}
+		return 0, false
+	}
+
+	for _, cp := range order {
+		delete(result, cp.def.Key)
+		v, ok := cp.expr.Eval(env)
+		if !ok {
+			continue
+		}
+		var value interface{} = v
+		if cp.def.PropertyType == models.PropertyTypeQuantity {
+			value = models.Quantity{Amount: v, Unit: cp.def.Unit}
+		}
+		if _, code := checkPropertyValue(cp.def, value); code != "" {
+			continue // e.g. a negative duration
+		}
+		result[cp.def.Key] = models.PropertyValue{Type: cp.def.PropertyType, Value: value}
+	}
+	return result
+}
+
+// formulaNumber returns the number a formula sees for a property value:
+// numbers, durations and ratings as they are, the amount of a quantity,
+// booleans as 1 or 0 and dates as Unix seconds
+func formulaNumber(prop models.PropertyValue) (float64, bool) {
+	switch prop.Type {
+	case models.PropertyTypeNumber, models.PropertyTypeDuration, models.PropertyTypeRating:
+		return propertyNumber(prop.Value)
+	case models.PropertyTypeQuantity:
+		q, ok := models.ParseQuantity(prop.Value)
+		return q.Amount, ok
+	case models.PropertyTypeBoolean:
+		b, ok := prop.Value.(bool)
+		if b {
+			return 1, ok
+		}
+		return 0, ok
+	case models.PropertyTypeDate:
+		s, _ := prop.Value.(string)
+		if t, err := time.Parse(time.RFC3339, s); err == nil {
+			return float64(t.Unix()), true
+		}
+		if t, err := time.Parse(time.DateOnly, s); err == nil {
+			return float64(t.Unix()), true
+		}
+	}
+	return 0, false
+}
+
+// formulaDependents returns the keys of the computed definitions whose
+// formulas use key
+func formulaDependents(defs []models.PropertyDefinition, key string) []string {
+	var dependents []string
+	for _, def := range defs {
+		if def.Formula == "" || def.Key == key {
+			continue
+		}
+		if expr, err := formula.Parse(def.Formula); err == nil && slices.Contains(expr.Names(), key) {
+			dependents = append(dependents, def.Key)
+		}
+	}
+	return dependents
+}
+
+// recomputeProperties re-evaluates the computed properties of every event of
+// an event type, writing and logging an update for each event whose values
+// change. It returns the number of events updated.
+func (s *propertyDefinitionService) recomputeProperties(ctx context.Context, userID, eventTypeID string) (int, error) {
+	defs, err := s.propertyDefRepo.GetByEventTypeID(ctx, eventTypeID)
+	if err != nil {
+		return 0, fmt.Errorf("failed to get property definitions: %w", err)
+	}
+	order, err := computedOrder(defs)
+	if err != nil || len(order) == 0 {
+		return 0, err
+	}
+
+	events, err := s.eventRepo.GetForExport(ctx, userID, nil, nil, []string{eventTypeID})
+	if err != nil {
+		return 0, fmt.Errorf("failed to get events: %w", err)
+	}
+
+	var entries []models.ChangeLogInput
+	for _, event := range events {
+		computed := computeProperties(event.Properties, defs, event.Timestamp, event.EndDate)
+		changed := false
+		for _, cp := range order {
+			before, had := event.Properties[cp.def.Key]
+			after, has := computed[cp.def.Key]
+			if had != has || (has && !sameComputedValue(before, after)) {
+				changed = true
+				break
+			}
+		}
+		if !changed {
+			continue
+		}
+
+		updated, err := s.eventRepo.UpdateFields(ctx, event.ID, map[string]interface{}{"properties": computed})
+		if err != nil {
+			return 0, err
+		}
+		entries = append(entries, models.ChangeLogInput{
+			EntityType: models.EntityTypeEvent,
+			Operation:  models.OperationUpdate,
+			EntityID:   updated.ID,
+			UserID:     userID,
+			Data:       updated,
+		})
+	}
+
+	for i := range entries {
+		if _, err := s.changeLogRepo.Append(ctx, &entries[i]); err != nil {
+			return 0, err
+		}
+	}
+	return len(entries), nil
+}
+
+// sameComputedValue compares a stored computed value with a fresh one. A
+// stored quantity may have been decoded from JSON into a map.
+func sameComputedValue(stored, fresh models.PropertyValue) bool {
+	if stored.Type != fresh.Type {
+		return false
+	}
+	if q, ok := fresh.Value.(models.Quantity); ok {
+		storedQ, ok := models.ParseQuantity(stored.Value)
+		return ok && storedQ == q
+	}
+	return reflect.DeepEqual(stored.Value, fresh.Value)
+}
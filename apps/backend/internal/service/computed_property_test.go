@@ -0,0 +1,166 @@
+package service
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/JonnyWalker81/trendy/backend/internal/models"
+	"github.com/JonnyWalker81/trendy/backend/internal/repository/memory"
+)
+
+func TestComputedProperties(t *testing.T) {
+	ctx := context.Background()
+	repos := memory.NewRepositories(memory.NewStore())
+	eventService := NewEventService(repos.Events, repos.EventTypes, repos.PropertyDefinitions, repos.ChangeLog, repos.Transactor)
+	defService := NewPropertyDefinitionService(repos.PropertyDefinitions, repos.EventTypes, repos.Events, repos.ChangeLog, repos.Transactor)
+
+	et, err := repos.EventTypes.Create(ctx, &models.EventType{UserID: "user-1", Name: "Run"})
+	if err != nil {
+		t.Fatalf("Create event type failed: %v", err)
+	}
+	createDef := func(req models.CreatePropertyDefinitionRequest) (*models.PropertyDefinition, error) {
+		req.EventTypeID = et.ID
+		req.Label = req.Key
+		return defService.CreatePropertyDefinition(ctx, "user-1", &req)
+	}
+	if _, err := createDef(models.CreatePropertyDefinitionRequest{Key: "distance", PropertyType: models.PropertyTypeNumber}); err != nil {
+		t.Fatalf("CreatePropertyDefinition failed: %v", err)
+	}
+
+	start := time.Date(2026, 3, 1, 7, 0, 0, 0, time.UTC)
+	end := start.Add(50 * time.Minute)
+	event, _, err := eventService.CreateEvent(ctx, "user-1", &models.CreateEventRequest{
+		EventTypeID: et.ID,
+		Timestamp:   start,
+		EndDate:     &end,
+		Properties:  map[string]models.PropertyValue{"distance": {Value: 10.0}},
+	})
+	if err != nil {
+		t.Fatalf("CreateEvent failed: %v", err)
+	}
+
+	// Adding computed properties fills them in on existing events
+	if _, err := createDef(models.CreatePropertyDefinitionRequest{Key: "elapsed", PropertyType: models.PropertyTypeDuration, Formula: "end_date - timestamp"}); err != nil {
+		t.Fatalf("CreatePropertyDefinition failed: %v", err)
+	}
+	speed, err := createDef(models.CreatePropertyDefinitionRequest{Key: "speed", PropertyType: models.PropertyTypeNumber, Formula: "round(distance / (elapsed / 3600), 1)"})
+	if err != nil {
+		t.Fatalf("CreatePropertyDefinition failed: %v", err)
+	}
+	stored, _ := repos.Events.GetByID(ctx, event.ID)
+	if got := stored.Properties["elapsed"].Value; got != 3000.0 {
+		t.Errorf("expected elapsed to be computed, got %v", got)
+	}
+	if got := stored.Properties["speed"].Value; got != 12.0 {
+		t.Errorf("expected speed to be computed, got %v", got)
+	}
+
+	// Sent values of computed properties are replaced
+	props := map[string]models.PropertyValue{"distance": {Value: 5.0}, "speed": {Value: 99.0}}
+	updated, err := eventService.UpdateEvent(ctx, "user-1", event.ID, &models.UpdateEventRequest{Properties: &props})
+	if err != nil {
+		t.Fatalf("UpdateEvent failed: %v", err)
+	}
+	if got := updated.Properties["speed"].Value; got != 6.0 {
+		t.Errorf("expected speed to be recomputed, got %v", got)
+	}
+
+	// Changing only the times recomputes too
+	later := start.Add(30 * time.Minute)
+	updated, err = eventService.UpdateEvent(ctx, "user-1", event.ID, &models.UpdateEventRequest{EndDate: models.NullableTime{Value: later, Valid: true, Set: true}})
+	if err != nil {
+		t.Fatalf("UpdateEvent failed: %v", err)
+	}
+	if got := updated.Properties["speed"].Value; got != 10.0 {
+		t.Errorf("expected speed from the new end date, got %v", got)
+	}
+
+	// Changing a formula recomputes stored values
+	formula := "distance * 1000 / elapsed"
+	if _, err := defService.UpdatePropertyDefinition(ctx, "user-1", speed.ID, &models.UpdatePropertyDefinitionRequest{Formula: &formula}); err != nil {
+		t.Fatalf("UpdatePropertyDefinition failed: %v", err)
+	}
+	stored, _ = repos.Events.GetByID(ctx, event.ID)
+	if got := stored.Properties["speed"].Value; got != 5000.0/1800 {
+		t.Errorf("expected speed from the new formula, got %v", got)
+	}
+
+	for _, tt := range []struct {
+		formula, want string
+	}{
+		{"elapsed + cadence", "unknown property"},
+		{"speed * 2", "own property"},
+		{"distance +", "invalid formula"},
+	} {
+		formula := tt.formula
+		_, err := defService.UpdatePropertyDefinition(ctx, "user-1", speed.ID, &models.UpdatePropertyDefinitionRequest{Formula: &formula})
+		if err == nil || !strings.Contains(err.Error(), tt.want) {
+			t.Errorf("formula %q: expected %q error, got %v", tt.formula, tt.want, err)
+		}
+	}
+	if _, err := createDef(models.CreatePropertyDefinitionRequest{Key: "loop", PropertyType: models.PropertyTypeNumber, Formula: "loop2"}); err == nil {
+		t.Error("expected a formula using an unknown property to be rejected")
+	}
+
+	defs, _ := repos.PropertyDefinitions.GetByEventTypeID(ctx, et.ID)
+	for _, def := range defs {
+		if def.Key == "distance" {
+			if err := defService.DeletePropertyDefinition(ctx, "user-1", def.ID); err == nil || !strings.Contains(err.Error(), "speed") {
+				t.Errorf("expected deleting a used property to be refused, got %v", err)
+			}
+
+			key := "km"
+			if _, err := defService.MigratePropertyDefinition(ctx, "user-1", def.ID, &models.MigratePropertyDefinitionRequest{Key: &key}); err != nil {
+				t.Fatalf("MigratePropertyDefinition failed: %v", err)
+			}
+		}
+	}
+	renamed, _ := repos.PropertyDefinitions.GetByID(ctx, speed.ID)
+	if renamed.Formula != "km * 1000 / elapsed" {
+		t.Errorf("expected the formula to follow the rename, got %q", renamed.Formula)
+	}
+}
+
+func TestComputedOrderRejectsCycles(t *testing.T) {
+	defs := []models.PropertyDefinition{
+		{Key: "a", PropertyType: models.PropertyTypeNumber, Formula: "b + 1"},
+		{Key: "b", PropertyType: models.PropertyTypeNumber, Formula: "c * 2"},
+		{Key: "c", PropertyType: models.PropertyTypeNumber},
+	}
+	order, err := computedOrder(defs)
+	if err != nil || len(order) != 2 || order[0].def.Key != "b" {
+		t.Fatalf("expected b before a, got %v, %v", order, err)
+	}
+
+	cyclic := models.PropertyDefinition{Key: "c", PropertyType: models.PropertyTypeNumber, Formula: "a"}
+	if err := checkFormula(cyclic, defs); err == nil {
+		t.Error("expected a cycle to be rejected")
+	}
+}
+
+func TestComputePropertyCorrelations(t *testing.T) {
+	eventTypes := []models.EventType{{ID: "run", Name: "Run"}, {ID: "coffee", Name: "Coffee"}}
+	var aggregates []models.DailyAggregate
+	start := time.Date(2026, 3, 1, 0, 0, 0, 0, time.UTC)
+	for day := 0; day < 20; day++ {
+		date := start.AddDate(0, 0, day)
+		cups := day % 4
+		aggregates = append(aggregates,
+			models.DailyAggregate{Date: date, EventTypeID: "run", EventCount: 1, PropertyAggregates: map[string]models.PropAgg{
+				"pace": {Avg: 5 + float64(cups), Count: 1},
+			}},
+			models.DailyAggregate{Date: date, EventTypeID: "coffee", EventCount: cups},
+		)
+	}
+
+	insights := computePropertyCorrelations("user-1", aggregates, eventTypes)
+	if len(insights) != 1 {
+		t.Fatalf("expected 1 insight, got %d", len(insights))
+	}
+	got := insights[0]
+	if got.Category != models.InsightCategoryProperty || *got.PropertyKey != "pace" || *got.EventTypeBID != "coffee" || got.MetricValue < 0.99 {
+		t.Errorf("unexpected insight %+v", got)
+	}
+}
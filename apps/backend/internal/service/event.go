@@ -3,25 +3,31 @@ package service
 import (
 	"context"
 	"fmt"
+	"slices"
 	"time"
 
-	"github.com/JonnyWalker81/trendy/backend/internal/logger"
 	"github.com/JonnyWalker81/trendy/backend/internal/models"
 	"github.com/JonnyWalker81/trendy/backend/internal/repository"
 )
 
 type eventService struct {
-	eventRepo     repository.EventRepository
-	eventTypeRepo repository.EventTypeRepository
-	changeLogRepo repository.ChangeLogRepository
+	eventRepo       repository.EventRepository
+	eventTypeRepo   repository.EventTypeRepository
+	propertyDefRepo repository.PropertyDefinitionRepository
+	changeLogRepo   repository.ChangeLogRepository
+	tx              repository.Transactor
 }
 
-// NewEventService creates a new event service
-func NewEventService(eventRepo repository.EventRepository, eventTypeRepo repository.EventTypeRepository, changeLogRepo repository.ChangeLogRepository) EventService {
+// NewEventService creates a new event service. Event properties are checked
+// against the property definitions of their event type. Event writes and
+// their change log entries are committed together through tx.
+func NewEventService(eventRepo repository.EventRepository, eventTypeRepo repository.EventTypeRepository, propertyDefRepo repository.PropertyDefinitionRepository, changeLogRepo repository.ChangeLogRepository, tx repository.Transactor) EventService {
 	return &eventService{
-		eventRepo:     eventRepo,
-		eventTypeRepo: eventTypeRepo,
-		changeLogRepo: changeLogRepo,
+		eventRepo:       eventRepo,
+		eventTypeRepo:   eventTypeRepo,
+		propertyDefRepo: propertyDefRepo,
+		changeLogRepo:   changeLogRepo,
+		tx:              tx,
 	}
 }
 
@@ -44,6 +50,11 @@ func (s *eventService) CreateEvent(ctx context.Context, userID string, req *mode
 		return nil, false, fmt.Errorf("event type does not belong to user")
 	}
 
+	properties, err := s.checkProperties(ctx, req.EventTypeID, req.Properties, req.Timestamp, req.EndDate)
+	if err != nil {
+		return nil, false, err
+	}
+
 	// Set default source_type if not provided
 	sourceType := req.SourceType
 	if sourceType == "" {
@@ -62,7 +73,7 @@ func (s *eventService) CreateEvent(ctx context.Context, userID string, req *mode
 		OriginalTitle:     req.OriginalTitle,
 		HealthKitSampleID: req.HealthKitSampleID,
 		HealthKitCategory: req.HealthKitCategory,
-		Properties:        req.Properties,
+		Properties:        properties,
 	}
 
 	// Use client-provided ID if present (for offline-first/UUIDv7 support)
@@ -81,47 +92,43 @@ func (s *eventService) CreateEvent(ctx context.Context, userID string, req *mode
 
 	var created *models.Event
 	var wasCreated bool
-	var operation models.Operation
 
-	if isHealthKit {
-		// Use upsert for HealthKit events - idempotent by sample ID
-		created, wasCreated, err = s.eventRepo.UpsertHealthKitEvent(ctx, event)
-		if err != nil {
-			return nil, false, err
-		}
-	} else if hasClientID {
-		// Use upsert for events with client-provided ID - idempotent by event ID
-		created, wasCreated, err = s.eventRepo.Upsert(ctx, event)
-		if err != nil {
-			return nil, false, err
+	// Write the event and its change log entry in one transaction so sync
+	// clients never miss a change
+	err = s.tx.WithinTx(ctx, func(ctx context.Context) error {
+		var err error
+		if isHealthKit {
+			// Use upsert for HealthKit events - idempotent by sample ID
+			created, wasCreated, err = s.eventRepo.UpsertHealthKitEvent(ctx, event)
+		} else if hasClientID {
+			// Use upsert for events with client-provided ID - idempotent by event ID
+			created, wasCreated, err = s.eventRepo.Upsert(ctx, event)
+		} else {
+			// Standard insert for events without client ID
+			created, err = s.eventRepo.Create(ctx, event)
+			wasCreated = true
 		}
-	} else {
-		// Standard insert for events without client ID
-		created, err = s.eventRepo.Create(ctx, event)
 		if err != nil {
-			return nil, false, err
+			return err
 		}
-		wasCreated = true
-	}
 
-	// Determine operation for change log
-	if wasCreated {
-		operation = models.OperationCreate
-	} else {
-		operation = models.OperationUpdate
-	}
+		// Determine operation for change log
+		operation := models.OperationUpdate
+		if wasCreated {
+			operation = models.OperationCreate
+		}
 
-	// Append to change log with correct operation
-	if _, err := s.changeLogRepo.Append(ctx, &models.ChangeLogInput{
-		EntityType: models.EntityTypeEvent,
-		Operation:  operation,
-		EntityID:   created.ID,
-		UserID:     userID,
-		Data:       created,
-	}); err != nil {
-		// Log but don't fail - the event was created successfully
-		log := logger.FromContext(ctx)
-		log.Warn("failed to append to change log", logger.Err(err), logger.String("event_id", created.ID))
+		_, err = s.changeLogRepo.Append(ctx, &models.ChangeLogInput{
+			EntityType: models.EntityTypeEvent,
+			Operation:  operation,
+			EntityID:   created.ID,
+			UserID:     userID,
+			Data:       created,
+		})
+		return err
+	})
+	if err != nil {
+		return nil, false, err
 	}
 
 	return created, wasCreated, nil
@@ -145,6 +152,7 @@ func (s *eventService) CreateEventsBatch(ctx context.Context, userID string, req
 	for _, et := range eventTypes {
 		eventTypeMap[et.ID] = true
 	}
+	defsByType := make(map[string][]models.PropertyDefinition)
 
 	// Validate and prepare events, separating HealthKit from regular events
 	regularEvents := make([]models.Event, 0, len(req.Events))
@@ -160,6 +168,23 @@ func (s *eventService) CreateEventsBatch(ctx context.Context, userID string, req
 			continue
 		}
 
+		defs, ok := defsByType[eventReq.EventTypeID]
+		if !ok {
+			if defs, err = s.propertyDefRepo.GetByEventTypeID(ctx, eventReq.EventTypeID); err != nil {
+				return nil, fmt.Errorf("failed to get property definitions: %w", err)
+			}
+			defsByType[eventReq.EventTypeID] = defs
+		}
+		properties, fieldErrors := applyPropertySchema(eventReq.Properties, defs)
+		if len(fieldErrors) > 0 {
+			response.Errors = append(response.Errors, models.BatchError{
+				Index:   i,
+				Message: (&PropertyValidationError{Errors: fieldErrors}).Error(),
+			})
+			continue
+		}
+		properties = computeProperties(properties, defs, eventReq.Timestamp, eventReq.EndDate)
+
 		// Set default source_type if not provided
 		sourceType := eventReq.SourceType
 		if sourceType == "" {
@@ -182,7 +207,7 @@ func (s *eventService) CreateEventsBatch(ctx context.Context, userID string, req
 			LocationName:      eventReq.LocationName,
 			HealthKitSampleID: eventReq.HealthKitSampleID,
 			HealthKitCategory: eventReq.HealthKitCategory,
-			Properties:        eventReq.Properties,
+			Properties:        properties,
 		}
 
 		// Use client-provided ID if present (for offline-first/UUIDv7 support)
@@ -202,49 +227,17 @@ func (s *eventService) CreateEventsBatch(ctx context.Context, userID string, req
 		}
 	}
 
-	log := logger.FromContext(ctx)
-
-	// Batch insert regular events (non-HealthKit)
-	if len(regularEvents) > 0 {
-		created, err := s.eventRepo.CreateBatch(ctx, regularEvents)
-		if err != nil {
-			return nil, fmt.Errorf("failed to batch create events: %w", err)
-		}
-		response.Created = append(response.Created, created...)
-
-		// Append to change log for each created event
-		for _, event := range created {
-			if _, err := s.changeLogRepo.Append(ctx, &models.ChangeLogInput{
-				EntityType: models.EntityTypeEvent,
-				Operation:  models.OperationCreate,
-				EntityID:   event.ID,
-				UserID:     userID,
-				Data:       event,
-			}); err != nil {
-				log.Warn("failed to append batch event to change log", logger.Err(err), logger.String("event_id", event.ID))
+	err = s.tx.WithinTx(ctx, func(ctx context.Context) error {
+		// Batch insert regular events (non-HealthKit)
+		if len(regularEvents) > 0 {
+			created, err := s.eventRepo.CreateBatch(ctx, regularEvents)
+			if err != nil {
+				return fmt.Errorf("failed to batch create events: %w", err)
 			}
-		}
-	}
-
-	// Batch upsert HealthKit events (idempotent)
-	if len(healthKitEvents) > 0 {
-		upserted, createdIDs, err := s.eventRepo.UpsertHealthKitEventsBatch(ctx, healthKitEvents)
-		if err != nil {
-			return nil, fmt.Errorf("failed to batch upsert HealthKit events: %w", err)
-		}
-		response.Created = append(response.Created, upserted...)
-
-		// Build set of created IDs for quick lookup
-		createdIDSet := make(map[string]bool)
-		for _, id := range createdIDs {
-			createdIDSet[id] = true
-		}
+			response.Created = append(response.Created, created...)
 
-		// Append to change log ONLY for genuinely new events (CREATE operations)
-		// Skip UPDATE operations for HealthKit batch imports - the client already has
-		// this data and logging updates floods the change_log unnecessarily
-		for _, event := range upserted {
-			if createdIDSet[event.ID] {
+			// Append to change log for each created event
+			for _, event := range created {
 				if _, err := s.changeLogRepo.Append(ctx, &models.ChangeLogInput{
 					EntityType: models.EntityTypeEvent,
 					Operation:  models.OperationCreate,
@@ -252,13 +245,50 @@ func (s *eventService) CreateEventsBatch(ctx context.Context, userID string, req
 					UserID:     userID,
 					Data:       event,
 				}); err != nil {
-					log.Warn("failed to append HealthKit event to change log", logger.Err(err), logger.String("event_id", event.ID))
+					return err
 				}
 			}
-			// Note: Intentionally skip change_log for UPDATE operations in batch imports
-			// The importing client already has the data, and other clients will get it
-			// via bootstrap or the next CREATE that triggers a sync
 		}
+
+		// Batch upsert HealthKit events (idempotent)
+		if len(healthKitEvents) > 0 {
+			upserted, createdIDs, err := s.eventRepo.UpsertHealthKitEventsBatch(ctx, healthKitEvents)
+			if err != nil {
+				return fmt.Errorf("failed to batch upsert HealthKit events: %w", err)
+			}
+			response.Created = append(response.Created, upserted...)
+
+			// Build set of created IDs for quick lookup
+			createdIDSet := make(map[string]bool)
+			for _, id := range createdIDs {
+				createdIDSet[id] = true
+			}
+
+			// Append to change log ONLY for genuinely new events (CREATE operations)
+			// Skip UPDATE operations for HealthKit batch imports - the client already has
+			// this data and logging updates floods the change_log unnecessarily
+			for _, event := range upserted {
+				if createdIDSet[event.ID] {
+					if _, err := s.changeLogRepo.Append(ctx, &models.ChangeLogInput{
+						EntityType: models.EntityTypeEvent,
+						Operation:  models.OperationCreate,
+						EntityID:   event.ID,
+						UserID:     userID,
+						Data:       event,
+					}); err != nil {
+						return err
+					}
+				}
+				// Note: Intentionally skip change_log for UPDATE operations in batch imports
+				// The importing client already has the data, and other clients will get it
+				// via bootstrap or the next CREATE that triggers a sync
+			}
+		}
+
+		return nil
+	})
+	if err != nil {
+		return nil, err
 	}
 
 	response.Success = len(response.Created)
@@ -294,10 +324,6 @@ func (s *eventService) GetUserEvents(ctx context.Context, userID string, limit,
 	return s.eventRepo.GetByUserID(ctx, userID, limit, offset)
 }
 
-func (s *eventService) ExportEvents(ctx context.Context, userID string, startDate, endDate *time.Time, eventTypeIDs []string) ([]models.Event, error) {
-	return s.eventRepo.GetForExport(ctx, userID, startDate, endDate, eventTypeIDs)
-}
-
 func (s *eventService) UpdateEvent(ctx context.Context, userID, eventID string, req *models.UpdateEventRequest) (*models.Event, error) {
 	// Get existing event to verify ownership
 	existingEvent, err := s.eventRepo.GetByID(ctx, eventID)
@@ -378,8 +404,43 @@ func (s *eventService) UpdateEvent(ctx context.Context, userID, eventID string,
 	if req.HealthKitCategory != nil {
 		fields["healthkit_category"] = *req.HealthKitCategory
 	}
-	if req.Properties != nil {
-		fields["properties"] = *req.Properties
+	// Properties are checked when they change and when the event moves to
+	// another event type, whose definitions may differ. Computed properties
+	// are also recomputed when the event's times change.
+	timestamp, endDate := existingEvent.Timestamp, existingEvent.EndDate
+	if req.Timestamp != nil {
+		timestamp = *req.Timestamp
+	}
+	if req.EndDate.Set {
+		endDate = nil
+		if req.EndDate.Valid {
+			endDate = &req.EndDate.Value
+		}
+	}
+	if req.Properties != nil || (req.EventTypeID != nil && *req.EventTypeID != existingEvent.EventTypeID) {
+		eventTypeID, properties := existingEvent.EventTypeID, existingEvent.Properties
+		if req.EventTypeID != nil {
+			eventTypeID = *req.EventTypeID
+		}
+		if req.Properties != nil {
+			properties = *req.Properties
+		}
+		checked, err := s.checkProperties(ctx, eventTypeID, properties, timestamp, endDate)
+		if err != nil {
+			return nil, err
+		}
+		if checked == nil {
+			checked = map[string]models.PropertyValue{}
+		}
+		fields["properties"] = checked
+	} else if req.Timestamp != nil || req.EndDate.Set {
+		defs, err := s.propertyDefRepo.GetByEventTypeID(ctx, existingEvent.EventTypeID)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get property definitions: %w", err)
+		}
+		if slices.ContainsFunc(defs, func(d models.PropertyDefinition) bool { return d.Formula != "" }) {
+			fields["properties"] = computeProperties(existingEvent.Properties, defs, timestamp, endDate)
+		}
 	}
 	// GeofenceID: use NullableString
 	if req.GeofenceID.Set {
@@ -405,23 +466,31 @@ func (s *eventService) UpdateEvent(ctx context.Context, userID, eventID string,
 		}
 	}
 
-	updated, err := s.eventRepo.UpdateFields(ctx, eventID, fields)
+	var updated *models.Event
+	err = s.tx.WithinTx(ctx, func(ctx context.Context) error {
+		if err := checkPrecondition(ctx, eventID, existingEvent.UpdatedAt, s.eventRepo.ClaimVersion); err != nil {
+			return err
+		}
+
+		var err error
+		updated, err = s.eventRepo.UpdateFields(ctx, eventID, fields)
+		if err != nil {
+			return err
+		}
+
+		_, err = s.changeLogRepo.Append(ctx, &models.ChangeLogInput{
+			EntityType: models.EntityTypeEvent,
+			Operation:  models.OperationUpdate,
+			EntityID:   updated.ID,
+			UserID:     userID,
+			Data:       updated,
+		})
+		return err
+	})
 	if err != nil {
 		return nil, err
 	}
 
-	// Append to change log
-	if _, err := s.changeLogRepo.Append(ctx, &models.ChangeLogInput{
-		EntityType: models.EntityTypeEvent,
-		Operation:  models.OperationUpdate,
-		EntityID:   updated.ID,
-		UserID:     userID,
-		Data:       updated,
-	}); err != nil {
-		log := logger.FromContext(ctx)
-		log.Warn("failed to append update to change log", logger.Err(err), logger.String("event_id", updated.ID))
-	}
-
 	return updated, nil
 }
 
@@ -436,22 +505,84 @@ func (s *eventService) DeleteEvent(ctx context.Context, userID, eventID string)
 		return fmt.Errorf("event not found")
 	}
 
-	if err := s.eventRepo.Delete(ctx, eventID); err != nil {
+	// Deleting moves the event to the trash; clients see a delete either way
+	return s.tx.WithinTx(ctx, func(ctx context.Context) error {
+		if err := checkPrecondition(ctx, eventID, event.UpdatedAt, s.eventRepo.ClaimVersion); err != nil {
+			return err
+		}
+
+		deletedAt := trashTimestamp()
+		if err := s.eventRepo.SoftDelete(ctx, eventID, deletedAt); err != nil {
+			return err
+		}
+
+		_, err := s.changeLogRepo.Append(ctx, &models.ChangeLogInput{
+			EntityType: models.EntityTypeEvent,
+			Operation:  models.OperationDelete,
+			EntityID:   eventID,
+			UserID:     userID,
+			DeletedAt:  &deletedAt,
+		})
 		return err
+	})
+}
+
+func (s *eventService) RestoreEvent(ctx context.Context, userID, eventID string) (*models.Event, error) {
+	// Verify the trashed event exists and belongs to user
+	event, err := s.eventRepo.GetDeletedByID(ctx, eventID)
+	if err != nil {
+		return nil, err
+	}
+
+	if event.UserID != userID {
+		return nil, fmt.Errorf("event not found")
 	}
 
-	// Append to change log
-	now := time.Now()
-	if _, err := s.changeLogRepo.Append(ctx, &models.ChangeLogInput{
-		EntityType: models.EntityTypeEvent,
-		Operation:  models.OperationDelete,
-		EntityID:   eventID,
-		UserID:     userID,
-		DeletedAt:  &now,
-	}); err != nil {
-		log := logger.FromContext(ctx)
-		log.Warn("failed to append delete to change log", logger.Err(err), logger.String("event_id", eventID))
+	// An event cannot outlive its event type; restoring the type brings the
+	// event back with it
+	if _, err := s.eventTypeRepo.GetByID(ctx, event.EventTypeID); err != nil {
+		return nil, ErrEventTypeInTrash
 	}
 
-	return nil
+	var restored *models.Event
+	err = s.tx.WithinTx(ctx, func(ctx context.Context) error {
+		var err error
+		restored, err = s.eventRepo.Restore(ctx, eventID)
+		if err != nil {
+			return err
+		}
+
+		// Clients dropped the event when it was deleted, so it comes back as
+		// a create
+		_, err = s.changeLogRepo.Append(ctx, &models.ChangeLogInput{
+			EntityType: models.EntityTypeEvent,
+			Operation:  models.OperationCreate,
+			EntityID:   restored.ID,
+			UserID:     userID,
+			Data:       restored,
+		})
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return restored, nil
+}
+
+// checkProperties applies the property definitions of an event type to an
+// event's properties, returning a PropertyValidationError if any value does
+// not match its definition, and computes its computed properties from the
+// checked values and the event's times
+func (s *eventService) checkProperties(ctx context.Context, eventTypeID string, properties map[string]models.PropertyValue, timestamp time.Time, endDate *time.Time) (map[string]models.PropertyValue, error) {
+	defs, err := s.propertyDefRepo.GetByEventTypeID(ctx, eventTypeID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get property definitions: %w", err)
+	}
+
+	checked, fieldErrors := applyPropertySchema(properties, defs)
+	if len(fieldErrors) > 0 {
+		return nil, &PropertyValidationError{Errors: fieldErrors}
+	}
+	return computeProperties(checked, defs, timestamp, endDate), nil
 }
@@ -0,0 +1,174 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/JonnyWalker81/trendy/backend/internal/apierror"
+	"github.com/JonnyWalker81/trendy/backend/internal/models"
+	"github.com/google/uuid"
+)
+
+const (
+	defaultEventPageSize = 50
+	maxEventPageSize     = 1000
+	// maxPropertyPredicates bounds the property filters of one request
+	maxPropertyPredicates = 10
+)
+
+// propertyPredicatePattern matches a raw query parameter such as
+// "properties.mood>=4". Two-character operators are listed first so ">="
+// is not read as ">" followed by "=4".
+var propertyPredicatePattern = regexp.MustCompile(`^properties\.([A-Za-z0-9_]+)(>=|<=|!=|=|>|<)(.*)$`)
+
+// EventListQuery is a parsed request for a page of events
+type EventListQuery struct {
+	Filter models.EventFilter
+	Cursor *models.EventCursor
+	Limit  int
+}
+
+// ParseEventListQuery parses the raw query string of GET /events, collecting
+// every field error instead of stopping at the first.
+func ParseEventListQuery(rawQuery string) (*EventListQuery, []apierror.FieldError) {
+	query := &EventListQuery{Limit: defaultEventPageSize}
+	fieldErrors := parseEventQuery(rawQuery, &query.Filter, func(key, value string, invalid func(field, message, code string)) {
+		switch key {
+		case "limit":
+			limit, err := strconv.Atoi(value)
+			if err != nil || limit < 1 || limit > maxEventPageSize {
+				invalid("limit", fmt.Sprintf("must be an integer from 1 to %d", maxEventPageSize), "out_of_range")
+				return
+			}
+			query.Limit = limit
+		case "cursor":
+			// An empty cursor asks for the first page
+			if value == "" {
+				return
+			}
+			cursor, err := models.ParseEventCursor(value)
+			if err == nil {
+				_, err = uuid.Parse(cursor.ID)
+			}
+			if err != nil {
+				invalid("cursor", "is not a cursor returned by this endpoint", "invalid_format")
+				return
+			}
+			query.Cursor = cursor
+		}
+	})
+
+	return query, fieldErrors
+}
+
+// parseEventQuery parses the event filters of a raw query string into filter
+// and passes every other parameter to other. It reads the raw string because
+// property predicates such as "properties.mood>=4" do not split into
+// key=value pairs.
+func parseEventQuery(rawQuery string, filter *models.EventFilter, other func(key, value string, invalid func(field, message, code string))) []apierror.FieldError {
+	var fieldErrors []apierror.FieldError
+	invalid := func(field, message, code string) {
+		fieldErrors = append(fieldErrors, apierror.FieldError{Field: field, Message: message, Code: code})
+	}
+
+	for _, part := range strings.Split(rawQuery, "&") {
+		if part == "" {
+			continue
+		}
+		part, err := url.QueryUnescape(part)
+		if err != nil {
+			invalid("query", "is not a valid query string", "invalid_format")
+			continue
+		}
+
+		if strings.HasPrefix(part, "properties.") {
+			m := propertyPredicatePattern.FindStringSubmatch(part)
+			if m == nil {
+				invalid(strings.SplitN(part, "=", 2)[0], "must look like properties.<key><op><value> with op one of =, !=, >, >=, <, <=", "invalid_format")
+				continue
+			}
+			filter.Properties = append(filter.Properties, models.PropertyPredicate{
+				Key:   m[1],
+				Op:    models.PropertyOperator(m[2]),
+				Value: m[3],
+			})
+			continue
+		}
+
+		key, value, _ := strings.Cut(part, "=")
+		switch key {
+		case "event_type_ids":
+			for _, id := range strings.Split(value, ",") {
+				id = strings.TrimSpace(id)
+				if _, err := uuid.Parse(id); err != nil {
+					invalid("event_type_ids", "must be a comma-separated list of UUIDs", "invalid_uuid")
+					break
+				}
+				filter.EventTypeIDs = append(filter.EventTypeIDs, id)
+			}
+		case "start_date", "end_date":
+			ts, err := time.Parse(time.RFC3339, value)
+			if err != nil {
+				invalid(key, "must be a valid RFC3339 timestamp", "invalid_format")
+				continue
+			}
+			if key == "start_date" {
+				filter.StartDate = &ts
+			} else {
+				filter.EndDate = &ts
+			}
+		case "source_type":
+			filter.SourceType = value
+		case "geofence_id":
+			if _, err := uuid.Parse(value); err != nil {
+				invalid("geofence_id", "must be a UUID", "invalid_uuid")
+				continue
+			}
+			filter.GeofenceID = value
+		case "has_notes":
+			hasNotes, err := strconv.ParseBool(value)
+			if err != nil {
+				invalid("has_notes", "must be true or false", "invalid_type")
+				continue
+			}
+			filter.HasNotes = &hasNotes
+		default:
+			other(key, value, invalid)
+		}
+	}
+
+	if start, end := filter.StartDate, filter.EndDate; start != nil && end != nil && start.After(*end) {
+		invalid("start_date", "must be before or equal to end_date", "invalid_range")
+	}
+	if len(filter.Properties) > maxPropertyPredicates {
+		invalid("properties", fmt.Sprintf("at most %d property filters are allowed", maxPropertyPredicates), "too_many")
+	}
+
+	return fieldErrors
+}
+
+func (s *eventService) ListEvents(ctx context.Context, userID string, query *EventListQuery) (*models.EventPage, error) {
+	// One extra event tells whether another page follows
+	events, err := s.eventRepo.GetPage(ctx, userID, &query.Filter, query.Cursor, query.Limit+1)
+	if err != nil {
+		return nil, err
+	}
+
+	page := &models.EventPage{Events: events}
+	if len(events) > query.Limit {
+		page.Events = events[:query.Limit]
+		last := page.Events[query.Limit-1]
+		page.NextCursor = models.EventCursor{Timestamp: last.Timestamp, ID: last.ID}.Encode()
+		page.HasMore = true
+	}
+	if page.Events == nil {
+		page.Events = []models.Event{}
+	}
+
+	return page, nil
+}
@@ -0,0 +1,127 @@
+package service
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/JonnyWalker81/trendy/backend/internal/models"
+	"github.com/JonnyWalker81/trendy/backend/internal/repository/memory"
+)
+
+func TestListEventsPagesWithFilters(t *testing.T) {
+	ctx := context.Background()
+	repos := memory.NewRepositories(memory.NewStore())
+	eventService := NewEventService(repos.Events, repos.EventTypes, repos.PropertyDefinitions, repos.ChangeLog, repos.Transactor)
+
+	et, err := repos.EventTypes.Create(ctx, &models.EventType{UserID: "user-1", Name: "Mood"})
+	if err != nil {
+		t.Fatalf("Create event type failed: %v", err)
+	}
+
+	// Events share timestamps in pairs so paging must break ties by ID. One
+	// of each pair comes from HealthKit, which manual dedupe ignores.
+	base := time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC)
+	notes := "felt good"
+	for i := 0; i < 10; i++ {
+		req := &models.CreateEventRequest{
+			EventTypeID: et.ID,
+			Timestamp:   base.Add(time.Duration(i/2) * time.Hour),
+			Properties:  map[string]models.PropertyValue{"mood": {Type: models.PropertyTypeNumber, Value: float64(i % 5)}},
+		}
+		if i%2 == 0 {
+			req.Notes = &notes
+		} else {
+			req.SourceType = "healthkit"
+		}
+		if _, _, err := eventService.CreateEvent(ctx, "user-1", req); err != nil {
+			t.Fatalf("CreateEvent failed: %v", err)
+		}
+	}
+
+	query, fieldErrors := ParseEventListQuery("limit=3")
+	if len(fieldErrors) > 0 {
+		t.Fatalf("unexpected field errors %v", fieldErrors)
+	}
+	var all []models.Event
+	for {
+		page, err := eventService.ListEvents(ctx, "user-1", query)
+		if err != nil {
+			t.Fatalf("ListEvents failed: %v", err)
+		}
+		all = append(all, page.Events...)
+		if !page.HasMore {
+			if page.NextCursor != "" {
+				t.Error("the last page must not have a next cursor")
+			}
+			break
+		}
+		if query, fieldErrors = ParseEventListQuery("limit=3&cursor=" + page.NextCursor); len(fieldErrors) > 0 {
+			t.Fatalf("next cursor did not parse: %v", fieldErrors)
+		}
+	}
+	if len(all) != 10 {
+		t.Fatalf("expected 10 events across pages, got %d", len(all))
+	}
+	seen := map[string]bool{}
+	for i, e := range all {
+		if seen[e.ID] {
+			t.Errorf("event %s returned twice", e.ID)
+		}
+		seen[e.ID] = true
+		if i > 0 && e.Timestamp.After(all[i-1].Timestamp) {
+			t.Errorf("events are not newest first at %d", i)
+		}
+	}
+
+	tests := []struct {
+		query string
+		want  int
+	}{
+		{"properties.mood%3E%3D3", 4},
+		{"properties.mood=0&has_notes=true", 1},
+		{"has_notes=false", 5},
+		{"start_date=2026-03-01T13:00:00Z&end_date=2026-03-01T14:00:00Z", 4},
+		{"event_type_ids=" + et.ID, 10},
+		{"source_type=healthkit", 5},
+		{"source_type=healthkit&has_notes=true", 0},
+	}
+	for _, tt := range tests {
+		query, fieldErrors := ParseEventListQuery(tt.query)
+		if len(fieldErrors) > 0 {
+			t.Errorf("%s: unexpected field errors %v", tt.query, fieldErrors)
+			continue
+		}
+		page, err := eventService.ListEvents(ctx, "user-1", query)
+		if err != nil {
+			t.Fatalf("%s: ListEvents failed: %v", tt.query, err)
+		}
+		if len(page.Events) != tt.want {
+			t.Errorf("%s: expected %d events, got %d", tt.query, tt.want, len(page.Events))
+		}
+	}
+}
+
+func TestParseEventListQueryRejectsInvalidParameters(t *testing.T) {
+	_, fieldErrors := ParseEventListQuery("limit=0&cursor=abc&has_notes=maybe&geofence_id=nope&properties.mood~4&start_date=2026-03-02T00:00:00Z&end_date=2026-03-01T00:00:00Z")
+
+	fields := map[string]bool{}
+	for _, fe := range fieldErrors {
+		fields[fe.Field] = true
+	}
+	for _, field := range []string{"limit", "cursor", "has_notes", "geofence_id", "properties.mood~4", "start_date"} {
+		if !fields[field] {
+			t.Errorf("expected a field error for %s, got %v", field, fieldErrors)
+		}
+	}
+}
+
+func TestParseEventListQueryEmptyCursorIsFirstPage(t *testing.T) {
+	query, fieldErrors := ParseEventListQuery("cursor=&limit=10")
+	if len(fieldErrors) > 0 {
+		t.Fatalf("unexpected field errors: %v", fieldErrors)
+	}
+	if query.Cursor != nil || query.Limit != 10 {
+		t.Errorf("expected the first page of 10, got %+v", query)
+	}
+}
@@ -0,0 +1,188 @@
+package service
+
+import (
+	"cmp"
+	"context"
+	"fmt"
+	"html"
+	"math"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/JonnyWalker81/trendy/backend/internal/apierror"
+	"github.com/JonnyWalker81/trendy/backend/internal/models"
+)
+
+const (
+	defaultSearchPageSize = 20
+	maxSearchPageSize     = 100
+	// maxSearchCandidates bounds the matches ranked by one search. When more
+	// events match, the most recent ones are ranked and the response says so.
+	maxSearchCandidates = 500
+	// snippetContextWords is how many words a snippet keeps around a match
+	snippetContextWords = 8
+)
+
+// searchFieldWeights ranks a match in the original title above one in the
+// notes or location, and those above one in a property
+var searchFieldWeights = map[string]float64{
+	"original_title": 3,
+	"notes":          2,
+	"location_name":  2,
+}
+
+// EventSearchQuery is a parsed request for a page of search results
+type EventSearchQuery struct {
+	Filter models.EventFilter
+	Limit  int
+	Offset int
+}
+
+// ParseEventSearchQuery parses the raw query string of GET /events/search:
+// the q search query, limit, offset and the filters of GET /events
+func ParseEventSearchQuery(rawQuery string) (*EventSearchQuery, []apierror.FieldError) {
+	query := &EventSearchQuery{Limit: defaultSearchPageSize}
+	fieldErrors := parseEventQuery(rawQuery, &query.Filter, func(key, value string, invalid func(field, message, code string)) {
+		switch key {
+		case "q":
+			search, err := models.ParseSearchQuery(value)
+			if err != nil {
+				invalid("q", err.Error(), "invalid_format")
+				return
+			}
+			query.Filter.Search = search
+		case "limit":
+			limit, err := strconv.Atoi(value)
+			if err != nil || limit < 1 || limit > maxSearchPageSize {
+				invalid("limit", fmt.Sprintf("must be an integer from 1 to %d", maxSearchPageSize), "out_of_range")
+				return
+			}
+			query.Limit = limit
+		case "offset":
+			offset, err := strconv.Atoi(value)
+			if err != nil || offset < 0 || offset >= maxSearchCandidates {
+				invalid("offset", fmt.Sprintf("must be an integer from 0 to %d; narrow the search to see more", maxSearchCandidates-1), "out_of_range")
+				return
+			}
+			query.Offset = offset
+		}
+	})
+
+	if query.Filter.Search == nil && !slices.ContainsFunc(fieldErrors, func(fe apierror.FieldError) bool { return fe.Field == "q" }) {
+		fieldErrors = append(fieldErrors, apierror.FieldError{Field: "q", Message: "is required", Code: "required"})
+	}
+
+	return query, fieldErrors
+}
+
+func (s *eventService) SearchEvents(ctx context.Context, userID string, query *EventSearchQuery) (*models.EventSearchResponse, error) {
+	// One extra event tells whether the matches were cut short
+	events, err := s.eventRepo.GetPage(ctx, userID, &query.Filter, nil, maxSearchCandidates+1)
+	if err != nil {
+		return nil, err
+	}
+	truncated := len(events) > maxSearchCandidates
+	if truncated {
+		events = events[:maxSearchCandidates]
+	}
+
+	results := make([]models.EventSearchResult, 0, len(events))
+	for _, event := range events {
+		score, highlights := rankSearchMatch(&event, query.Filter.Search)
+		results = append(results, models.EventSearchResult{Event: event, Score: score, Highlights: highlights})
+	}
+	// Events come newest first, so a stable sort keeps ties newest first
+	slices.SortStableFunc(results, func(a, b models.EventSearchResult) int {
+		return cmp.Compare(b.Score, a.Score)
+	})
+
+	response := &models.EventSearchResponse{Results: []models.EventSearchResult{}, Truncated: truncated}
+	if query.Offset < len(results) {
+		end := min(query.Offset+query.Limit, len(results))
+		response.Results = results[query.Offset:end]
+		response.HasMore = end < len(results)
+	}
+
+	return response, nil
+}
+
+// rankSearchMatch scores an event against a search and highlights its
+// matched fields. Each match adds its field's weight, half as much for a
+// prefix match, and the score is damped by the length of the field so a
+// match in a short text ranks above one in a long text.
+func rankSearchMatch(event *models.Event, search *models.SearchQuery) (float64, []models.SearchHighlight) {
+	var score float64
+	highlights := []models.SearchHighlight{}
+	for _, field := range event.SearchFields() {
+		tokens := models.TokenizeSearchText(field.Text)
+
+		var spans [][2]int // Token index ranges of the matches
+		var fieldScore float64
+		for _, term := range search.Terms {
+			for i := range tokens {
+				if !term.MatchAt(tokens, i) {
+					continue
+				}
+				spans = append(spans, [2]int{i, i + len(term.Words)})
+				if term.Prefix && tokens[i+len(term.Words)-1].Word != term.Words[len(term.Words)-1] {
+					fieldScore += 0.5 * float64(len(term.Words))
+				} else {
+					fieldScore += float64(len(term.Words))
+				}
+			}
+		}
+		if len(spans) == 0 {
+			continue
+		}
+
+		weight, ok := searchFieldWeights[field.Name]
+		if !ok {
+			weight = 1
+		}
+		score += weight * fieldScore / (1 + math.Log(float64(len(tokens))))
+		highlights = append(highlights, models.SearchHighlight{Field: field.Name, Snippet: searchSnippet(field.Text, tokens, spans)})
+	}
+
+	return math.Round(score*1000) / 1000, highlights
+}
+
+// searchSnippet marks the matches of a text. A long text is cut to the words
+// around its first match.
+func searchSnippet(text string, tokens []models.SearchToken, spans [][2]int) string {
+	slices.SortFunc(spans, func(a, b [2]int) int { return cmp.Compare(a[0], b[0]) })
+
+	first, last := 0, len(tokens)
+	if len(tokens) > 2*snippetContextWords+1 {
+		first = max(spans[0][0]-snippetContextWords, 0)
+		last = min(spans[0][1]+snippetContextWords, len(tokens))
+	}
+
+	var b strings.Builder
+	start, end := 0, len(text)
+	if first > 0 {
+		start = tokens[first].Start
+		b.WriteString("…")
+	}
+	if last < len(tokens) {
+		end = tokens[last-1].End
+	}
+
+	pos := start
+	for _, span := range spans {
+		if span[0] < first || span[1] > last || tokens[span[0]].Start < pos {
+			continue // Outside the snippet or overlapping the previous match
+		}
+		b.WriteString(html.EscapeString(text[pos:tokens[span[0]].Start]))
+		b.WriteString("<mark>")
+		b.WriteString(html.EscapeString(text[tokens[span[0]].Start:tokens[span[1]-1].End]))
+		b.WriteString("</mark>")
+		pos = tokens[span[1]-1].End
+	}
+	b.WriteString(html.EscapeString(text[pos:end]))
+	if end < len(text) {
+		b.WriteString("…")
+	}
+
+	return b.String()
+}
@@ -0,0 +1,135 @@
+package service
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/JonnyWalker81/trendy/backend/internal/models"
+	"github.com/JonnyWalker81/trendy/backend/internal/repository/memory"
+)
+
+func TestSearchEventsRanksAndHighlights(t *testing.T) {
+	ctx := context.Background()
+	repos := memory.NewRepositories(memory.NewStore())
+	eventService := NewEventService(repos.Events, repos.EventTypes, repos.PropertyDefinitions, repos.ChangeLog, repos.Transactor)
+
+	et, err := repos.EventTypes.Create(ctx, &models.EventType{UserID: "user-1", Name: "Run"})
+	if err != nil {
+		t.Fatalf("Create event type failed: %v", err)
+	}
+
+	str := func(s string) *string { return &s }
+	base := time.Date(2026, 3, 1, 8, 0, 0, 0, time.UTC)
+	events := []models.Event{
+		{OriginalTitle: str("Morning run")},
+		{Notes: str("Long <slow> run along the river before a morning coffee, legs felt heavy but the weather was great")},
+		{LocationName: str("Riverside Park"), Properties: map[string]models.PropertyValue{
+			"route": {Type: models.PropertyTypeSelect, Value: "river loop"},
+		}},
+		{Notes: str("rest day")},
+	}
+	for i, e := range events {
+		e.UserID = "user-1"
+		e.EventTypeID = et.ID
+		e.SourceType = "manual"
+		e.Timestamp = base.Add(time.Duration(i) * time.Hour)
+		if _, err := repos.Events.Create(ctx, &e); err != nil {
+			t.Fatalf("Create event failed: %v", err)
+		}
+	}
+
+	search := func(rawQuery string) *models.EventSearchResponse {
+		t.Helper()
+		query, fieldErrors := ParseEventSearchQuery(rawQuery)
+		if len(fieldErrors) > 0 {
+			t.Fatalf("%s: unexpected field errors %v", rawQuery, fieldErrors)
+		}
+		response, err := eventService.SearchEvents(ctx, "user-1", query)
+		if err != nil {
+			t.Fatalf("%s: SearchEvents failed: %v", rawQuery, err)
+		}
+		return response
+	}
+
+	// The short title ranks above the long notes
+	response := search("q=run")
+	if len(response.Results) != 2 {
+		t.Fatalf("expected 2 results for run, got %d", len(response.Results))
+	}
+	if got := response.Results[0].Highlights; len(got) != 1 || got[0].Field != "original_title" || got[0].Snippet != "Morning <mark>run</mark>" {
+		t.Errorf("unexpected highlights %+v", got)
+	}
+	if got := response.Results[1].Highlights[0].Snippet; got != "Long &lt;slow&gt; <mark>run</mark> along the river before a morning coffee, legs…" {
+		t.Errorf("unexpected snippet %q", got)
+	}
+
+	// Prefixes match the location and a select property
+	response = search("q=river*")
+	if len(response.Results) != 2 {
+		t.Fatalf("expected 2 results for river*, got %d", len(response.Results))
+	}
+	fields := map[string]bool{}
+	for _, h := range response.Results[0].Highlights {
+		fields[h.Field] = true
+	}
+	if !fields["location_name"] || !fields["properties.route"] {
+		t.Errorf("expected the location and route to be highlighted, got %+v", response.Results[0].Highlights)
+	}
+
+	// Phrases match in order, and the list filters apply
+	if got := len(search(`q=%22morning+run%22`).Results); got != 1 {
+		t.Errorf("expected 1 result for the phrase, got %d", got)
+	}
+	if got := len(search("q=run&has_notes=false").Results); got != 1 {
+		t.Errorf("expected 1 result without notes, got %d", got)
+	}
+
+	response = search("q=run&limit=1&offset=1")
+	if len(response.Results) != 1 || response.HasMore || response.Truncated {
+		t.Errorf("expected the last result on the second page, got %d (has_more %v, truncated %v)", len(response.Results), response.HasMore, response.Truncated)
+	}
+
+	if _, fieldErrors := ParseEventSearchQuery("limit=5"); len(fieldErrors) != 1 || fieldErrors[0].Field != "q" {
+		t.Errorf("expected q to be required, got %v", fieldErrors)
+	}
+}
+
+func TestSearchEventsReportsTruncation(t *testing.T) {
+	ctx := context.Background()
+	repos := memory.NewRepositories(memory.NewStore())
+	eventService := NewEventService(repos.Events, repos.EventTypes, repos.PropertyDefinitions, repos.ChangeLog, repos.Transactor)
+
+	et, err := repos.EventTypes.Create(ctx, &models.EventType{UserID: "user-1", Name: "Run"})
+	if err != nil {
+		t.Fatalf("Create event type failed: %v", err)
+	}
+
+	title := "Morning run"
+	base := time.Date(2026, 3, 1, 8, 0, 0, 0, time.UTC)
+	for i := range maxSearchCandidates + 1 {
+		if _, err := repos.Events.Create(ctx, &models.Event{
+			UserID: "user-1", EventTypeID: et.ID, SourceType: "manual", OriginalTitle: &title,
+			Timestamp: base.Add(time.Duration(i) * time.Minute),
+		}); err != nil {
+			t.Fatalf("Create event failed: %v", err)
+		}
+	}
+
+	query, fieldErrors := ParseEventSearchQuery("q=run&limit=100&offset=400")
+	if len(fieldErrors) > 0 {
+		t.Fatalf("unexpected field errors %v", fieldErrors)
+	}
+	response, err := eventService.SearchEvents(ctx, "user-1", query)
+	if err != nil {
+		t.Fatalf("SearchEvents failed: %v", err)
+	}
+	if !response.Truncated || response.HasMore || len(response.Results) != 100 {
+		t.Errorf("expected the last ranked page to be truncated, got %d results (has_more %v, truncated %v)",
+			len(response.Results), response.HasMore, response.Truncated)
+	}
+
+	if _, fieldErrors := ParseEventSearchQuery("q=run&offset=500"); len(fieldErrors) != 1 || fieldErrors[0].Field != "offset" {
+		t.Errorf("expected an offset past the ranked matches to be rejected, got %v", fieldErrors)
+	}
+}
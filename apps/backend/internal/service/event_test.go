@@ -2,6 +2,7 @@ package service
 
 import (
 	"context"
+	"fmt"
 	"testing"
 	"time"
 
@@ -55,6 +56,11 @@ func (m *mockEventRepository) GetByID(ctx context.Context, id string) (*models.E
 	return nil, nil
 }
 
+func (m *mockEventRepository) ClaimVersion(ctx context.Context, id string, updatedAt time.Time) (bool, error) {
+	event, ok := m.events[id]
+	return ok && event.UpdatedAt.Equal(updatedAt), nil
+}
+
 func (m *mockEventRepository) GetByUserID(ctx context.Context, userID string, limit, offset int) ([]models.Event, error) {
 	var result []models.Event
 	for _, event := range m.events {
@@ -73,6 +79,14 @@ func (m *mockEventRepository) GetForExport(ctx context.Context, userID string, s
 	return m.GetByUserID(ctx, userID, 0, 0)
 }
 
+func (m *mockEventRepository) GetForExportPage(ctx context.Context, userID string, startDate, endDate *time.Time, eventTypeIDs []string, after *models.EventCursor, limit int) ([]models.Event, error) {
+	return nil, nil
+}
+
+func (m *mockEventRepository) GetPage(ctx context.Context, userID string, filter *models.EventFilter, after *models.EventCursor, limit int) ([]models.Event, error) {
+	return nil, nil
+}
+
 func (m *mockEventRepository) Update(ctx context.Context, id string, event *models.Event) (*models.Event, error) {
 	if existing, ok := m.events[id]; ok {
 		if event.EventTypeID != "" {
@@ -349,6 +363,91 @@ func (m *mockEventRepository) GetLatestHealthKitTimestamp(ctx context.Context, u
 	return latest, nil
 }
 
+func (m *mockEventRepository) SoftDelete(ctx context.Context, id string, deletedAt time.Time) error {
+	if event, ok := m.events[id]; ok {
+		event.DeletedAt = &deletedAt
+	}
+	return nil
+}
+
+func (m *mockEventRepository) SoftDeleteByEventType(ctx context.Context, eventTypeID string, deletedAt time.Time) ([]string, error) {
+	var ids []string
+	for id, event := range m.events {
+		if event.EventTypeID == eventTypeID && event.DeletedAt == nil {
+			event.DeletedAt = &deletedAt
+			ids = append(ids, id)
+		}
+	}
+	return ids, nil
+}
+
+func (m *mockEventRepository) GetDeletedByID(ctx context.Context, id string) (*models.Event, error) {
+	if event, ok := m.events[id]; ok && event.DeletedAt != nil {
+		return event, nil
+	}
+	return nil, nil
+}
+
+func (m *mockEventRepository) GetDeletedByUserID(ctx context.Context, userID string) ([]models.Event, error) {
+	var result []models.Event
+	for _, event := range m.events {
+		if event.UserID == userID && event.DeletedAt != nil {
+			result = append(result, *event)
+		}
+	}
+	return result, nil
+}
+
+func (m *mockEventRepository) Restore(ctx context.Context, id string) (*models.Event, error) {
+	if event, ok := m.events[id]; ok && event.DeletedAt != nil {
+		event.DeletedAt = nil
+		return event, nil
+	}
+	return nil, nil
+}
+
+func (m *mockEventRepository) RestoreByEventType(ctx context.Context, eventTypeID string, deletedAt time.Time) ([]models.Event, error) {
+	var result []models.Event
+	for _, event := range m.events {
+		if event.EventTypeID == eventTypeID && event.DeletedAt != nil && event.DeletedAt.Equal(deletedAt) {
+			event.DeletedAt = nil
+			result = append(result, *event)
+		}
+	}
+	return result, nil
+}
+
+func (m *mockEventRepository) PurgeDeleted(ctx context.Context, before time.Time) (int64, error) {
+	var purged int64
+	for id, event := range m.events {
+		if event.DeletedAt != nil && event.DeletedAt.Before(before) {
+			delete(m.events, id)
+			purged++
+		}
+	}
+	return purged, nil
+}
+
+func (m *mockEventRepository) ReassignEventType(ctx context.Context, fromEventTypeID, toEventTypeID string) ([]models.Event, error) {
+	var result []models.Event
+	for _, event := range m.events {
+		if event.EventTypeID == fromEventTypeID && event.DeletedAt == nil {
+			event.EventTypeID = toEventTypeID
+			result = append(result, *event)
+		}
+	}
+	return result, nil
+}
+
+func (m *mockEventRepository) DeleteByUserID(ctx context.Context, userID string) error {
+	for id, e := range m.events {
+		if e.UserID == userID {
+			delete(m.events, id)
+		}
+	}
+	return nil
+}
+
 // mockEventTypeRepository is a mock implementation of EventTypeRepository
 type mockEventTypeRepository struct {
 	eventTypes map[string]*models.EventType
@@ -375,6 +474,11 @@ func (m *mockEventTypeRepository) GetByID(ctx context.Context, id string) (*mode
 	return nil, nil
 }
 
+func (m *mockEventTypeRepository) ClaimVersion(ctx context.Context, id string, updatedAt time.Time) (bool, error) {
+	et, ok := m.eventTypes[id]
+	return ok && et.UpdatedAt.Equal(updatedAt), nil
+}
+
 func (m *mockEventTypeRepository) GetByUserID(ctx context.Context, userID string) ([]models.EventType, error) {
 	var result []models.EventType
 	for _, et := range m.eventTypes {
@@ -423,6 +527,112 @@ func (m *mockEventTypeRepository) GetLatestTimestamp(ctx context.Context, userID
 	return latest, nil
 }
 
+func (m *mockEventTypeRepository) SoftDelete(ctx context.Context, id string, deletedAt time.Time) error {
+	if et, ok := m.eventTypes[id]; ok {
+		et.DeletedAt = &deletedAt
+	}
+	return nil
+}
+
+func (m *mockEventTypeRepository) GetDeletedByID(ctx context.Context, id string) (*models.EventType, error) {
+	if et, ok := m.eventTypes[id]; ok && et.DeletedAt != nil {
+		return et, nil
+	}
+	return nil, nil
+}
+
+func (m *mockEventTypeRepository) GetDeletedByUserID(ctx context.Context, userID string) ([]models.EventType, error) {
+	var result []models.EventType
+	for _, et := range m.eventTypes {
+		if et.UserID == userID && et.DeletedAt != nil {
+			result = append(result, *et)
+		}
+	}
+	return result, nil
+}
+
+func (m *mockEventTypeRepository) Restore(ctx context.Context, id string) (*models.EventType, error) {
+	if et, ok := m.eventTypes[id]; ok && et.DeletedAt != nil {
+		et.DeletedAt = nil
+		return et, nil
+	}
+	return nil, nil
+}
+
+func (m *mockEventTypeRepository) PurgeDeleted(ctx context.Context, before time.Time) (int64, error) {
+	var purged int64
+	for id, et := range m.eventTypes {
+		if et.DeletedAt != nil && et.DeletedAt.Before(before) {
+			delete(m.eventTypes, id)
+			purged++
+		}
+	}
+	return purged, nil
+}
+
+func (m *mockEventTypeRepository) SetArchived(ctx context.Context, id string, archivedAt *time.Time) (*models.EventType, error) {
+	if et, ok := m.eventTypes[id]; ok {
+		et.ArchivedAt = archivedAt
+		return et, nil
+	}
+	return nil, nil
+}
+
+func (m *mockEventTypeRepository) DeleteByUserID(ctx context.Context, userID string) error {
+	for id, et := range m.eventTypes {
+		if et.UserID == userID {
+			delete(m.eventTypes, id)
+		}
+	}
+	return nil
+}
+
+// mockPropertyDefinitionRepository holds the definitions of every event type
+type mockPropertyDefinitionRepository struct {
+	defs []models.PropertyDefinition
+}
+
+func (m *mockPropertyDefinitionRepository) Create(ctx context.Context, def *models.PropertyDefinition) (*models.PropertyDefinition, error) {
+	m.defs = append(m.defs, *def)
+	return def, nil
+}
+
+func (m *mockPropertyDefinitionRepository) GetByID(ctx context.Context, id string) (*models.PropertyDefinition, error) {
+	for _, def := range m.defs {
+		if def.ID == id {
+			return &def, nil
+		}
+	}
+	return nil, fmt.Errorf("property definition not found")
+}
+
+func (m *mockPropertyDefinitionRepository) ClaimVersion(ctx context.Context, id string, updatedAt time.Time) (bool, error) {
+	def, err := m.GetByID(ctx, id)
+	return err == nil && def.UpdatedAt.Equal(updatedAt), nil
+}
+
+func (m *mockPropertyDefinitionRepository) GetByEventTypeID(ctx context.Context, eventTypeID string) ([]models.PropertyDefinition, error) {
+	var result []models.PropertyDefinition
+	for _, def := range m.defs {
+		if def.EventTypeID == eventTypeID {
+			result = append(result, def)
+		}
+	}
+	return result, nil
+}
+
+func (m *mockPropertyDefinitionRepository) Update(ctx context.Context, id string, def *models.PropertyDefinition) (*models.PropertyDefinition, error) {
+	return def, nil
+}
+
+func (m *mockPropertyDefinitionRepository) Delete(ctx context.Context, id string) error {
+	return nil
+}
+
+func (m *mockPropertyDefinitionRepository) DeleteByUserID(ctx context.Context, userID string) error {
+	return nil
+}
+
 // mockChangeLogRepository is a mock implementation of ChangeLogRepository
 type mockChangeLogRepository struct {
 	entries []models.ChangeLogInput
@@ -447,6 +657,37 @@ func (m *mockChangeLogRepository) GetLatestCursor(ctx context.Context, userID st
 	return int64(len(m.entries)), nil
 }
 
+func (m *mockChangeLogRepository) GetSettledCursor(ctx context.Context, userID string, before time.Time) (int64, error) {
+	return int64(len(m.entries)), nil
+}
+
+func (m *mockChangeLogRepository) GetHorizon(ctx context.Context, userID string) (int64, error) {
+	return 0, nil
+}
+
+func (m *mockChangeLogRepository) Compact(ctx context.Context) (int64, error) {
+	return 0, nil
+}
+
+func (m *mockChangeLogRepository) Prune(ctx context.Context, before time.Time) (int64, error) {
+	return 0, nil
+}
+
+func (m *mockChangeLogRepository) DeleteByUserID(ctx context.Context, userID string) error {
+	return nil
+}
+
+// mockTransactor runs the unit of work directly
+type mockTransactor struct{}
+
+func (mockTransactor) WithinTx(ctx context.Context, fn func(ctx context.Context) error) error {
+	return fn(ctx)
+}
+
+func (mockTransactor) WithinSnapshot(ctx context.Context, fn func(ctx context.Context) error) error {
+	return fn(ctx)
+}
+
 // Helper to generate mock IDs
 var mockIDCounter int
 
@@ -470,7 +711,7 @@ func TestCreateEvent_HealthKit_Idempotency(t *testing.T) {
 	eventTypeRepo := newMockEventTypeRepository()
 	changeLogRepo := newMockChangeLogRepository()
 
-	service := NewEventService(eventRepo, eventTypeRepo, changeLogRepo)
+	service := NewEventService(eventRepo, eventTypeRepo, &mockPropertyDefinitionRepository{}, changeLogRepo, mockTransactor{})
 
 	// Create an event type
 	userID := "user-123"
@@ -546,7 +787,7 @@ func TestCreateEvent_HealthKit_Immutable(t *testing.T) {
 	eventTypeRepo := newMockEventTypeRepository()
 	changeLogRepo := newMockChangeLogRepository()
 
-	service := NewEventService(eventRepo, eventTypeRepo, changeLogRepo)
+	service := NewEventService(eventRepo, eventTypeRepo, &mockPropertyDefinitionRepository{}, changeLogRepo, mockTransactor{})
 
 	userID := "user-123"
 	eventType, _ := eventTypeRepo.Create(ctx, &models.EventType{
@@ -603,7 +844,7 @@ func TestCreateEvent_MultipleWorkoutsAtSameTimestamp(t *testing.T) {
 	eventTypeRepo := newMockEventTypeRepository()
 	changeLogRepo := newMockChangeLogRepository()
 
-	service := NewEventService(eventRepo, eventTypeRepo, changeLogRepo)
+	service := NewEventService(eventRepo, eventTypeRepo, &mockPropertyDefinitionRepository{}, changeLogRepo, mockTransactor{})
 
 	userID := "user-123"
 	eventType, _ := eventTypeRepo.Create(ctx, &models.EventType{
@@ -660,7 +901,7 @@ func TestCreateEvent_SameTimestampSameCategory_Deduped(t *testing.T) {
 	eventTypeRepo := newMockEventTypeRepository()
 	changeLogRepo := newMockChangeLogRepository()
 
-	service := NewEventService(eventRepo, eventTypeRepo, changeLogRepo)
+	service := NewEventService(eventRepo, eventTypeRepo, &mockPropertyDefinitionRepository{}, changeLogRepo, mockTransactor{})
 
 	userID := "user-123"
 	eventType, _ := eventTypeRepo.Create(ctx, &models.EventType{
@@ -717,7 +958,7 @@ func TestCreateEvent_ManualAndHealthKitAtSameTimestamp(t *testing.T) {
 	eventTypeRepo := newMockEventTypeRepository()
 	changeLogRepo := newMockChangeLogRepository()
 
-	service := NewEventService(eventRepo, eventTypeRepo, changeLogRepo)
+	service := NewEventService(eventRepo, eventTypeRepo, &mockPropertyDefinitionRepository{}, changeLogRepo, mockTransactor{})
 
 	userID := "user-123"
 	eventType, _ := eventTypeRepo.Create(ctx, &models.EventType{
@@ -770,7 +1011,7 @@ func TestCreateEventsBatch_HealthKit_Idempotency(t *testing.T) {
 	eventTypeRepo := newMockEventTypeRepository()
 	changeLogRepo := newMockChangeLogRepository()
 
-	service := NewEventService(eventRepo, eventTypeRepo, changeLogRepo)
+	service := NewEventService(eventRepo, eventTypeRepo, &mockPropertyDefinitionRepository{}, changeLogRepo, mockTransactor{})
 
 	userID := "user-123"
 	eventType, _ := eventTypeRepo.Create(ctx, &models.EventType{
@@ -851,7 +1092,7 @@ func TestCreateEventsBatch_MixedHealthKitAndManual(t *testing.T) {
 	eventTypeRepo := newMockEventTypeRepository()
 	changeLogRepo := newMockChangeLogRepository()
 
-	service := NewEventService(eventRepo, eventTypeRepo, changeLogRepo)
+	service := NewEventService(eventRepo, eventTypeRepo, &mockPropertyDefinitionRepository{}, changeLogRepo, mockTransactor{})
 
 	userID := "user-123"
 	eventType, _ := eventTypeRepo.Create(ctx, &models.EventType{
@@ -920,7 +1161,7 @@ func TestCreateEventsBatch_PartialDuplicates(t *testing.T) {
 	eventTypeRepo := newMockEventTypeRepository()
 	changeLogRepo := newMockChangeLogRepository()
 
-	service := NewEventService(eventRepo, eventTypeRepo, changeLogRepo)
+	service := NewEventService(eventRepo, eventTypeRepo, &mockPropertyDefinitionRepository{}, changeLogRepo, mockTransactor{})
 
 	userID := "user-123"
 	eventType, _ := eventTypeRepo.Create(ctx, &models.EventType{
@@ -1030,7 +1271,7 @@ func TestCreateEvent_HealthKit_ContentBasedDedupe(t *testing.T) {
 	eventTypeRepo := newMockEventTypeRepository()
 	changeLogRepo := newMockChangeLogRepository()
 
-	service := NewEventService(eventRepo, eventTypeRepo, changeLogRepo)
+	service := NewEventService(eventRepo, eventTypeRepo, &mockPropertyDefinitionRepository{}, changeLogRepo, mockTransactor{})
 
 	userID := "user-123"
 	eventType, _ := eventTypeRepo.Create(ctx, &models.EventType{
@@ -1099,7 +1340,7 @@ func TestCreateEvent_HealthKit_DifferentCategoriesNotDeduplicated(t *testing.T)
 	eventTypeRepo := newMockEventTypeRepository()
 	changeLogRepo := newMockChangeLogRepository()
 
-	service := NewEventService(eventRepo, eventTypeRepo, changeLogRepo)
+	service := NewEventService(eventRepo, eventTypeRepo, &mockPropertyDefinitionRepository{}, changeLogRepo, mockTransactor{})
 
 	userID := "user-123"
 	eventType, _ := eventTypeRepo.Create(ctx, &models.EventType{
@@ -1161,7 +1402,7 @@ func TestCreateEventsBatch_HealthKit_ContentBasedDedupe(t *testing.T) {
 	eventTypeRepo := newMockEventTypeRepository()
 	changeLogRepo := newMockChangeLogRepository()
 
-	service := NewEventService(eventRepo, eventTypeRepo, changeLogRepo)
+	service := NewEventService(eventRepo, eventTypeRepo, &mockPropertyDefinitionRepository{}, changeLogRepo, mockTransactor{})
 
 	userID := "user-123"
 	eventType, _ := eventTypeRepo.Create(ctx, &models.EventType{
@@ -1257,7 +1498,7 @@ func TestCreateEventsBatch_HealthKit_MixedDeduplication(t *testing.T) {
 	eventTypeRepo := newMockEventTypeRepository()
 	changeLogRepo := newMockChangeLogRepository()
 
-	service := NewEventService(eventRepo, eventTypeRepo, changeLogRepo)
+	service := NewEventService(eventRepo, eventTypeRepo, &mockPropertyDefinitionRepository{}, changeLogRepo, mockTransactor{})
 
 	userID := "user-123"
 	eventType, _ := eventTypeRepo.Create(ctx, &models.EventType{
@@ -2,24 +2,52 @@ package service
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"time"
 
-	"github.com/JonnyWalker81/trendy/backend/internal/logger"
 	"github.com/JonnyWalker81/trendy/backend/internal/models"
 	"github.com/JonnyWalker81/trendy/backend/internal/repository"
 )
 
+// ErrInvalidReassignTarget is returned when an event type is reassigned or
+// merged into itself
+var ErrInvalidReassignTarget = errors.New("events must be moved to a different event type")
+
 type eventTypeService struct {
-	eventTypeRepo repository.EventTypeRepository
-	changeLogRepo repository.ChangeLogRepository
+	eventTypeRepo   repository.EventTypeRepository
+	eventRepo       repository.EventRepository
+	propertyDefRepo repository.PropertyDefinitionRepository
+	geofenceRepo    repository.GeofenceRepository
+	insightRepo     repository.InsightRepository
+	streakRepo      repository.StreakRepository
+	changeLogRepo   repository.ChangeLogRepository
+	tx              repository.Transactor
 }
 
-// NewEventTypeService creates a new event type service
-func NewEventTypeService(eventTypeRepo repository.EventTypeRepository, changeLogRepo repository.ChangeLogRepository) EventTypeService {
+// NewEventTypeService creates a new event type service. Deleting or
+// restoring an event type also moves its events in or out of the trash, and
+// invalidates the analytics derived from them. Merging an event type also
+// repoints the geofences that log it.
+func NewEventTypeService(
+	eventTypeRepo repository.EventTypeRepository,
+	eventRepo repository.EventRepository,
+	propertyDefRepo repository.PropertyDefinitionRepository,
+	geofenceRepo repository.GeofenceRepository,
+	insightRepo repository.InsightRepository,
+	streakRepo repository.StreakRepository,
+	changeLogRepo repository.ChangeLogRepository,
+	tx repository.Transactor,
+) EventTypeService {
 	return &eventTypeService{
-		eventTypeRepo: eventTypeRepo,
-		changeLogRepo: changeLogRepo,
+		eventTypeRepo:   eventTypeRepo,
+		eventRepo:       eventRepo,
+		propertyDefRepo: propertyDefRepo,
+		geofenceRepo:    geofenceRepo,
+		insightRepo:     insightRepo,
+		streakRepo:      streakRepo,
+		changeLogRepo:   changeLogRepo,
+		tx:              tx,
 	}
 }
 
@@ -36,23 +64,27 @@ func (s *eventTypeService) CreateEventType(ctx context.Context, userID string, r
 		eventType.ID = *req.ID
 	}
 
-	created, err := s.eventTypeRepo.Create(ctx, eventType)
+	var created *models.EventType
+	err := s.tx.WithinTx(ctx, func(ctx context.Context) error {
+		var err error
+		created, err = s.eventTypeRepo.Create(ctx, eventType)
+		if err != nil {
+			return err
+		}
+
+		_, err = s.changeLogRepo.Append(ctx, &models.ChangeLogInput{
+			EntityType: models.EntityTypeEventType,
+			Operation:  models.OperationCreate,
+			EntityID:   created.ID,
+			UserID:     userID,
+			Data:       created,
+		})
+		return err
+	})
 	if err != nil {
 		return nil, err
 	}
 
-	// Append to change log
-	if _, err := s.changeLogRepo.Append(ctx, &models.ChangeLogInput{
-		EntityType: models.EntityTypeEventType,
-		Operation:  models.OperationCreate,
-		EntityID:   created.ID,
-		UserID:     userID,
-		Data:       created,
-	}); err != nil {
-		log := logger.FromContext(ctx)
-		log.Warn("failed to append to change log", logger.Err(err), logger.String("event_type_id", created.ID))
-	}
-
 	return created, nil
 }
 
@@ -70,8 +102,19 @@ func (s *eventTypeService) GetEventType(ctx context.Context, userID, eventTypeID
 	return eventType, nil
 }
 
-func (s *eventTypeService) GetUserEventTypes(ctx context.Context, userID string) ([]models.EventType, error) {
-	return s.eventTypeRepo.GetByUserID(ctx, userID)
+func (s *eventTypeService) GetUserEventTypes(ctx context.Context, userID string, includeArchived bool) ([]models.EventType, error) {
+	eventTypes, err := s.eventTypeRepo.GetByUserID(ctx, userID)
+	if err != nil || includeArchived {
+		return eventTypes, err
+	}
+
+	active := make([]models.EventType, 0, len(eventTypes))
+	for _, et := range eventTypes {
+		if et.ArchivedAt == nil {
+			active = append(active, et)
+		}
+	}
+	return active, nil
 }
 
 func (s *eventTypeService) UpdateEventType(ctx context.Context, userID, eventTypeID string, req *models.UpdateEventTypeRequest) (*models.EventType, error) {
@@ -97,27 +140,46 @@ func (s *eventTypeService) UpdateEventType(ctx context.Context, userID, eventTyp
 		update.Icon = *req.Icon
 	}
 
-	updated, err := s.eventTypeRepo.Update(ctx, eventTypeID, update)
+	var updated *models.EventType
+	err = s.tx.WithinTx(ctx, func(ctx context.Context) error {
+		if err := checkPrecondition(ctx, eventTypeID, existingEventType.UpdatedAt, s.eventTypeRepo.ClaimVersion); err != nil {
+			return err
+		}
+
+		var err error
+		updated, err = s.eventTypeRepo.Update(ctx, eventTypeID, update)
+		if err != nil {
+			return err
+		}
+
+		if req.Archived != nil && *req.Archived != (updated.ArchivedAt != nil) {
+			var archivedAt *time.Time
+			if *req.Archived {
+				now := time.Now()
+				archivedAt = &now
+			}
+			if updated, err = s.eventTypeRepo.SetArchived(ctx, eventTypeID, archivedAt); err != nil {
+				return err
+			}
+		}
+
+		_, err = s.changeLogRepo.Append(ctx, &models.ChangeLogInput{
+			EntityType: models.EntityTypeEventType,
+			Operation:  models.OperationUpdate,
+			EntityID:   updated.ID,
+			UserID:     userID,
+			Data:       updated,
+		})
+		return err
+	})
 	if err != nil {
 		return nil, err
 	}
 
-	// Append to change log
-	if _, err := s.changeLogRepo.Append(ctx, &models.ChangeLogInput{
-		EntityType: models.EntityTypeEventType,
-		Operation:  models.OperationUpdate,
-		EntityID:   updated.ID,
-		UserID:     userID,
-		Data:       updated,
-	}); err != nil {
-		log := logger.FromContext(ctx)
-		log.Warn("failed to append update to change log", logger.Err(err), logger.String("event_type_id", updated.ID))
-	}
-
 	return updated, nil
 }
 
-func (s *eventTypeService) DeleteEventType(ctx context.Context, userID, eventTypeID string) error {
+func (s *eventTypeService) DeleteEventType(ctx context.Context, userID, eventTypeID string, req *models.DeleteEventTypeRequest) error {
 	// Verify event type exists and belongs to user
 	eventType, err := s.eventTypeRepo.GetByID(ctx, eventTypeID)
 	if err != nil {
@@ -128,22 +190,195 @@ func (s *eventTypeService) DeleteEventType(ctx context.Context, userID, eventTyp
 		return fmt.Errorf("event type not found")
 	}
 
-	if err := s.eventTypeRepo.Delete(ctx, eventTypeID); err != nil {
+	if req == nil {
+		req = &models.DeleteEventTypeRequest{}
+	}
+
+	switch req.Strategy {
+	case models.DeleteStrategyArchive:
+		return s.archive(ctx, userID, eventType)
+	case models.DeleteStrategyReassign:
+		_, err := s.MergeEventType(ctx, userID, eventType.ID, req.TargetEventTypeID)
 		return err
+	default:
+		return s.cascadeDelete(ctx, userID, eventType)
+	}
+}
+
+// cascadeDelete moves the event type and its events to the trash with the
+// same deleted_at, so restoring the type brings back exactly those events.
+// Property definitions stay in place but clients drop them with the type.
+func (s *eventTypeService) cascadeDelete(ctx context.Context, userID string, eventType *models.EventType) error {
+	defs, err := s.propertyDefRepo.GetByEventTypeID(ctx, eventType.ID)
+	if err != nil {
+		return fmt.Errorf("failed to get property definitions: %w", err)
+	}
+
+	return s.tx.WithinTx(ctx, func(ctx context.Context) error {
+		if err := checkPrecondition(ctx, eventType.ID, eventType.UpdatedAt, s.eventTypeRepo.ClaimVersion); err != nil {
+			return err
+		}
+
+		deletedAt := trashTimestamp()
+		eventIDs, err := s.eventRepo.SoftDeleteByEventType(ctx, eventType.ID, deletedAt)
+		if err != nil {
+			return err
+		}
+		if err := s.eventTypeRepo.SoftDelete(ctx, eventType.ID, deletedAt); err != nil {
+			return err
+		}
+
+		// Children are logged before their parent
+		deletes := make([]models.ChangeLogInput, 0, len(eventIDs)+len(defs)+1)
+		for _, id := range eventIDs {
+			deletes = append(deletes, deleteEntry(models.EntityTypeEvent, id, userID, deletedAt))
+		}
+		deletes = append(deletes, s.trashTypeEntries(userID, eventType.ID, defs, deletedAt)...)
+		if err := s.appendAll(ctx, deletes); err != nil {
+			return err
+		}
+
+		return s.invalidateAnalytics(ctx, userID, eventType.ID)
+	})
+}
+
+// archive hides the event type from pickers. Its events are untouched.
+func (s *eventTypeService) archive(ctx context.Context, userID string, eventType *models.EventType) error {
+	if eventType.ArchivedAt != nil {
+		return nil
+	}
+
+	return s.tx.WithinTx(ctx, func(ctx context.Context) error {
+		if err := checkPrecondition(ctx, eventType.ID, eventType.UpdatedAt, s.eventTypeRepo.ClaimVersion); err != nil {
+			return err
+		}
+
+		now := time.Now()
+		archived, err := s.eventTypeRepo.SetArchived(ctx, eventType.ID, &now)
+		if err != nil {
+			return err
+		}
+
+		if _, err := s.changeLogRepo.Append(ctx, &models.ChangeLogInput{
+			EntityType: models.EntityTypeEventType,
+			Operation:  models.OperationUpdate,
+			EntityID:   archived.ID,
+			UserID:     userID,
+			Data:       archived,
+		}); err != nil {
+			return err
+		}
+
+		return s.invalidateAnalytics(ctx, userID, eventType.ID)
+	})
+}
+
+// trashTypeEntries returns the delete entries for an event type moved to the
+// trash and its property definitions, children first
+func (s *eventTypeService) trashTypeEntries(userID, eventTypeID string, defs []models.PropertyDefinition, deletedAt time.Time) []models.ChangeLogInput {
+	entries := make([]models.ChangeLogInput, 0, len(defs)+1)
+	for _, def := range defs {
+		entries = append(entries, deleteEntry(models.EntityTypePropertyDefinition, def.ID, userID, deletedAt))
+	}
+	return append(entries, deleteEntry(models.EntityTypeEventType, eventTypeID, userID, deletedAt))
+}
+
+// invalidateAnalytics drops the event type's streaks and marks the user's
+// insights stale so they are recomputed from its events. Daily aggregates are
+// left to the next aggregate sync, which recomputes the days of the moved or
+// deleted events from their change log entries.
+func (s *eventTypeService) invalidateAnalytics(ctx context.Context, userID, eventTypeID string) error {
+	if err := s.streakRepo.DeleteByEventType(ctx, userID, eventTypeID); err != nil {
+		return fmt.Errorf("failed to delete streaks: %w", err)
 	}
+	if err := s.insightRepo.InvalidateAll(ctx, userID); err != nil {
+		return fmt.Errorf("failed to invalidate insights: %w", err)
+	}
+	return nil
+}
 
-	// Append to change log
-	now := time.Now()
-	if _, err := s.changeLogRepo.Append(ctx, &models.ChangeLogInput{
-		EntityType: models.EntityTypeEventType,
+func deleteEntry(entityType models.EntityType, id, userID string, deletedAt time.Time) models.ChangeLogInput {
+	return models.ChangeLogInput{
+		EntityType: entityType,
 		Operation:  models.OperationDelete,
-		EntityID:   eventTypeID,
+		EntityID:   id,
 		UserID:     userID,
-		DeletedAt:  &now,
-	}); err != nil {
-		log := logger.FromContext(ctx)
-		log.Warn("failed to append delete to change log", logger.Err(err), logger.String("event_type_id", eventTypeID))
+		DeletedAt:  &deletedAt,
+	}
+}
+
+func (s *eventTypeService) RestoreEventType(ctx context.Context, userID, eventTypeID string) (*models.EventType, error) {
+	// Verify the trashed event type exists and belongs to user
+	eventType, err := s.eventTypeRepo.GetDeletedByID(ctx, eventTypeID)
+	if err != nil {
+		return nil, err
 	}
 
+	if eventType.UserID != userID {
+		return nil, fmt.Errorf("event type not found")
+	}
+
+	var restored *models.EventType
+	err = s.tx.WithinTx(ctx, func(ctx context.Context) error {
+		var err error
+		restored, err = s.eventTypeRepo.Restore(ctx, eventTypeID)
+		if err != nil {
+			return err
+		}
+
+		events, err := s.eventRepo.RestoreByEventType(ctx, eventTypeID, *eventType.DeletedAt)
+		if err != nil {
+			return err
+		}
+
+		defs, err := s.propertyDefRepo.GetByEventTypeID(ctx, eventTypeID)
+		if err != nil {
+			return fmt.Errorf("failed to get property definitions: %w", err)
+		}
+
+		// Clients dropped everything when the type was deleted, so it all
+		// comes back as creates, parents first
+		creates := make([]models.ChangeLogInput, 0, len(events)+len(defs)+1)
+		creates = append(creates, models.ChangeLogInput{
+			EntityType: models.EntityTypeEventType,
+			Operation:  models.OperationCreate,
+			EntityID:   restored.ID,
+			UserID:     userID,
+			Data:       restored,
+		})
+		for i := range defs {
+			creates = append(creates, models.ChangeLogInput{
+				EntityType: models.EntityTypePropertyDefinition,
+				Operation:  models.OperationCreate,
+				EntityID:   defs[i].ID,
+				UserID:     userID,
+				Data:       defs[i],
+			})
+		}
+		for i := range events {
+			creates = append(creates, models.ChangeLogInput{
+				EntityType: models.EntityTypeEvent,
+				Operation:  models.OperationCreate,
+				EntityID:   events[i].ID,
+				UserID:     userID,
+				Data:       events[i],
+			})
+		}
+
+		return s.appendAll(ctx, creates)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return restored, nil
+}
+
+func (s *eventTypeService) appendAll(ctx context.Context, inputs []models.ChangeLogInput) error {
+	for i := range inputs {
+		if _, err := s.changeLogRepo.Append(ctx, &inputs[i]); err != nil {
+			return err
+		}
+	}
 	return nil
 }
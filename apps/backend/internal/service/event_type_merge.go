@@ -0,0 +1,293 @@
+package service
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/JonnyWalker81/trendy/backend/internal/formula"
+	"github.com/JonnyWalker81/trendy/backend/internal/models"
+)
+
+// MergeEventType moves the events, property definitions and geofence
+// references of sourceID into targetID and moves the emptied source to the
+// trash. Streaks of both event types are dropped and rebuilt from the merged
+// events on the next insights refresh; daily aggregates follow the change log
+// entries of the moved events on the next aggregate sync.
+func (s *eventTypeService) MergeEventType(ctx context.Context, userID, sourceID, targetID string) (*models.MergeEventTypeResponse, error) {
+	if sourceID == targetID {
+		return nil, ErrInvalidReassignTarget
+	}
+
+	source, err := s.eventTypeRepo.GetByID(ctx, sourceID)
+	if err != nil {
+		return nil, err
+	}
+	if source.UserID != userID {
+		return nil, fmt.Errorf("event type not found")
+	}
+
+	target, err := s.eventTypeRepo.GetByID(ctx, targetID)
+	if err != nil || target.UserID != userID {
+		return nil, fmt.Errorf("target event type not found")
+	}
+
+	result := &models.MergeEventTypeResponse{EventType: target}
+	err = s.tx.WithinTx(ctx, func(ctx context.Context) error {
+		if err := checkPrecondition(ctx, source.ID, source.UpdatedAt, s.eventTypeRepo.ClaimVersion); err != nil {
+			return err
+		}
+
+		defs, err := s.propertyDefRepo.GetByEventTypeID(ctx, source.ID)
+		if err != nil {
+			return fmt.Errorf("failed to get property definitions: %w", err)
+		}
+
+		entries, renames, err := s.mergePropertyDefinitions(ctx, userID, source, target, defs, result)
+		if err != nil {
+			return err
+		}
+
+		if len(renames) > 0 {
+			if err := s.renameEventProperties(ctx, userID, source.ID, renames); err != nil {
+				return err
+			}
+			result.RenamedProperties = renames
+		}
+
+		moved, err := s.eventRepo.ReassignEventType(ctx, source.ID, target.ID)
+		if err != nil {
+			return err
+		}
+		for i := range moved {
+			entries = append(entries, models.ChangeLogInput{
+				EntityType: models.EntityTypeEvent,
+				Operation:  models.OperationUpdate,
+				EntityID:   moved[i].ID,
+				UserID:     userID,
+				Data:       moved[i],
+			})
+		}
+		result.EventsMoved = len(moved)
+
+		geofenceEntries, err := s.repointGeofences(ctx, userID, source.ID, target.ID)
+		if err != nil {
+			return err
+		}
+		entries = append(entries, geofenceEntries...)
+		result.GeofencesUpdated = len(geofenceEntries)
+
+		deletedAt := trashTimestamp()
+		if err := s.eventTypeRepo.SoftDelete(ctx, source.ID, deletedAt); err != nil {
+			return err
+		}
+		entries = append(entries, s.trashTypeEntries(userID, source.ID, defs, deletedAt)...)
+		if err := s.appendAll(ctx, entries); err != nil {
+			return err
+		}
+
+		if err := s.invalidateAnalytics(ctx, userID, source.ID); err != nil {
+			return err
+		}
+		return s.invalidateAnalytics(ctx, userID, target.ID)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return result, nil
+}
+
+// mergePropertyDefinitions makes every source property definition available
+// on the target. A key the target lacks is copied; a key the target defines
+// with the same type is shared, merging select options; a key the target
+// defines with a different type is copied under a new key, returned in
+// renames so the moved values can follow it.
+func (s *eventTypeService) mergePropertyDefinitions(
+	ctx context.Context,
+	userID string,
+	source, target *models.EventType,
+	defs []models.PropertyDefinition,
+	result *models.MergeEventTypeResponse,
+) ([]models.ChangeLogInput, map[string]string, error) {
+	targetDefs, err := s.propertyDefRepo.GetByEventTypeID(ctx, target.ID)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to get property definitions: %w", err)
+	}
+
+	byKey := make(map[string]models.PropertyDefinition, len(targetDefs))
+	taken := make(map[string]bool, len(targetDefs)+len(defs))
+	for _, def := range targetDefs {
+		byKey[def.Key] = def
+		taken[def.Key] = true
+	}
+	for _, def := range defs {
+		taken[def.Key] = true
+	}
+
+	// Quantities in another unit, ratings on another scale and differently
+	// computed properties keep their own definition
+	shared := func(existing, def models.PropertyDefinition) bool {
+		return existing.PropertyType == def.PropertyType && existing.Unit == def.Unit &&
+			existing.RatingMax == def.RatingMax && existing.Formula == def.Formula
+	}
+	renames := make(map[string]string)
+	for _, def := range defs {
+		if existing, conflict := byKey[def.Key]; conflict && !shared(existing, def) {
+			renames[def.Key] = freeKey(def.Key, taken)
+			taken[renames[def.Key]] = true
+		}
+	}
+
+	var entries []models.ChangeLogInput
+	for _, def := range defs {
+		existing, conflict := byKey[def.Key]
+		if conflict && shared(existing, def) {
+			options, changed := unionOptions(existing.Options, def.Options)
+			if !changed {
+				continue
+			}
+			updated, err := s.propertyDefRepo.Update(ctx, existing.ID, &models.PropertyDefinition{
+				Options:      options,
+				Required:     existing.Required,
+				Formula:      existing.Formula,
+				DisplayOrder: -1, // Unchanged
+			})
+			if err != nil {
+				return nil, nil, err
+			}
+			entries = append(entries, models.ChangeLogInput{
+				EntityType: models.EntityTypePropertyDefinition,
+				Operation:  models.OperationUpdate,
+				EntityID:   updated.ID,
+				UserID:     userID,
+				Data:       updated,
+			})
+			continue
+		}
+
+		copied := def
+		copied.ID = ""
+		copied.EventTypeID = target.ID
+		if newKey, ok := renames[def.Key]; ok {
+			copied.Key = newKey
+			copied.Label = fmt.Sprintf("%s (%s)", def.Label, source.Name)
+		}
+		if copied.Formula != "" && len(renames) > 0 {
+			// The formula follows the properties it uses to their new keys
+			renamed, err := formula.Rename(copied.Formula, renames)
+			if err != nil {
+				return nil, nil, err
+			}
+			copied.Formula = renamed
+		}
+
+		created, err := s.propertyDefRepo.Create(ctx, &copied)
+		if err != nil {
+			return nil, nil, err
+		}
+		entries = append(entries, models.ChangeLogInput{
+			EntityType: models.EntityTypePropertyDefinition,
+			Operation:  models.OperationCreate,
+			EntityID:   created.ID,
+			UserID:     userID,
+			Data:       created,
+		})
+		result.PropertyDefinitionsCopied++
+	}
+
+	return entries, renames, nil
+}
+
+// renameEventProperties rewrites the property keys of an event type's events.
+// The events are logged once they have been moved.
+func (s *eventTypeService) renameEventProperties(ctx context.Context, userID, eventTypeID string, renames map[string]string) error {
+	events, err := s.eventRepo.GetForExport(ctx, userID, nil, nil, []string{eventTypeID})
+	if err != nil {
+		return fmt.Errorf("failed to get events: %w", err)
+	}
+
+	for _, event := range events {
+		properties := make(map[string]models.PropertyValue, len(event.Properties))
+		changed := false
+		for key, value := range event.Properties {
+			if renamed, ok := renames[key]; ok {
+				key = renamed
+				changed = true
+			}
+			properties[key] = value
+		}
+		if !changed {
+			continue
+		}
+
+		if _, err := s.eventRepo.UpdateFields(ctx, event.ID, map[string]interface{}{"properties": properties}); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// repointGeofences moves the entry and exit references of the user's
+// geofences from one event type to another and returns their change log entries
+func (s *eventTypeService) repointGeofences(ctx context.Context, userID, fromID, toID string) ([]models.ChangeLogInput, error) {
+	geofences, err := s.geofenceRepo.GetByUserID(ctx, userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get geofences: %w", err)
+	}
+
+	var entries []models.ChangeLogInput
+	for _, g := range geofences {
+		var update models.Geofence
+		if g.EventTypeEntryID != nil && *g.EventTypeEntryID == fromID {
+			update.EventTypeEntryID = &toID
+		}
+		if g.EventTypeExitID != nil && *g.EventTypeExitID == fromID {
+			update.EventTypeExitID = &toID
+		}
+		if update.EventTypeEntryID == nil && update.EventTypeExitID == nil {
+			continue
+		}
+
+		updated, err := s.geofenceRepo.Update(ctx, g.ID, &update)
+		if err != nil {
+			return nil, err
+		}
+		entries = append(entries, models.ChangeLogInput{
+			EntityType: models.EntityTypeGeofence,
+			Operation:  models.OperationUpdate,
+			EntityID:   updated.ID,
+			UserID:     userID,
+			Data:       updated,
+		})
+	}
+
+	return entries, nil
+}
+
+// unionOptions appends the options of b missing from a
+func unionOptions(a, b []string) ([]string, bool) {
+	seen := make(map[string]bool, len(a))
+	for _, o := range a {
+		seen[o] = true
+	}
+
+	merged := append([]string(nil), a...)
+	for _, o := range b {
+		if !seen[o] {
+			seen[o] = true
+			merged = append(merged, o)
+		}
+	}
+	return merged, len(merged) > len(a)
+}
+
+// freeKey returns the first of key_2, key_3, ... not in taken
+func freeKey(key string, taken map[string]bool) string {
+	for n := 2; ; n++ {
+		candidate := fmt.Sprintf("%s_%d", key, n)
+		if !taken[candidate] {
+			return candidate
+		}
+	}
+}
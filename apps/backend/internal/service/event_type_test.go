@@ -0,0 +1,246 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/JonnyWalker81/trendy/backend/internal/models"
+	"github.com/JonnyWalker81/trendy/backend/internal/repository/memory"
+)
+
+func TestDeleteEventTypeStrategies(t *testing.T) {
+	ctx := context.Background()
+	repos := memory.NewRepositories(memory.NewStore())
+	userID := "user-1"
+
+	eventService := NewEventService(repos.Events, repos.EventTypes, repos.PropertyDefinitions, repos.ChangeLog, repos.Transactor)
+	eventTypeService := NewEventTypeService(repos.EventTypes, repos.Events, repos.PropertyDefinitions, repos.Geofences, repos.Insights, repos.Streaks, repos.ChangeLog, repos.Transactor)
+	propertyDefService := NewPropertyDefinitionService(repos.PropertyDefinitions, repos.EventTypes, repos.Events, repos.ChangeLog, repos.Transactor)
+
+	jog, err := eventTypeService.CreateEventType(ctx, userID, &models.CreateEventTypeRequest{Name: "Jog", Color: "#f00", Icon: "run"})
+	if err != nil {
+		t.Fatalf("CreateEventType failed: %v", err)
+	}
+	run, err := eventTypeService.CreateEventType(ctx, userID, &models.CreateEventTypeRequest{Name: "Run", Color: "#0f0", Icon: "run"})
+	if err != nil {
+		t.Fatalf("CreateEventType failed: %v", err)
+	}
+	// Both types rate effort, on different scales
+	defReqs := []models.CreatePropertyDefinitionRequest{
+		{EventTypeID: jog.ID, Key: "distance", Label: "Distance", PropertyType: models.PropertyTypeNumber},
+		{EventTypeID: jog.ID, Key: "effort", Label: "Effort", PropertyType: models.PropertyTypeRating, RatingMax: 5},
+		{EventTypeID: run.ID, Key: "effort", Label: "Effort", PropertyType: models.PropertyTypeRating, RatingMax: 10},
+	}
+	for i := range defReqs {
+		if _, err := propertyDefService.CreatePropertyDefinition(ctx, userID, &defReqs[i]); err != nil {
+			t.Fatalf("CreatePropertyDefinition failed: %v", err)
+		}
+	}
+	event, _, err := eventService.CreateEvent(ctx, userID, &models.CreateEventRequest{
+		EventTypeID: jog.ID,
+		Timestamp:   time.Now(),
+		Properties: map[string]models.PropertyValue{
+			"distance": {Type: models.PropertyTypeNumber, Value: 5.0},
+			"effort":   {Type: models.PropertyTypeRating, Value: 4.0},
+		},
+	})
+	if err != nil {
+		t.Fatalf("CreateEvent failed: %v", err)
+	}
+
+	if err := eventTypeService.DeleteEventType(ctx, userID, jog.ID, &models.DeleteEventTypeRequest{
+		Strategy: models.DeleteStrategyReassign, TargetEventTypeID: jog.ID,
+	}); !errors.Is(err, ErrInvalidReassignTarget) {
+		t.Fatalf("expected ErrInvalidReassignTarget, got %v", err)
+	}
+
+	cursor, _ := repos.ChangeLog.GetLatestCursor(ctx, userID)
+	if err := eventTypeService.DeleteEventType(ctx, userID, jog.ID, &models.DeleteEventTypeRequest{
+		Strategy: models.DeleteStrategyReassign, TargetEventTypeID: run.ID,
+	}); err != nil {
+		t.Fatalf("DeleteEventType reassign failed: %v", err)
+	}
+
+	// The event and its property values move with a definition copied to the target
+	moved, err := eventService.GetEvent(ctx, userID, event.ID)
+	if err != nil {
+		t.Fatalf("reassigned event should still be readable: %v", err)
+	}
+	if moved.EventTypeID != run.ID || moved.Properties["distance"].Value != 5.0 {
+		t.Errorf("expected event moved to Run with its properties, got %s %+v", moved.EventTypeID, moved.Properties)
+	}
+	// A rating out of 5 is not a rating out of 10: it keeps its own definition
+	if _, ok := moved.Properties["effort"]; ok || moved.Properties["effort_2"].Value != 4.0 {
+		t.Errorf("expected effort moved to effort_2, got %+v", moved.Properties)
+	}
+	defs, _ := repos.PropertyDefinitions.GetByEventTypeID(ctx, run.ID)
+	ratingMax := make(map[string]int, len(defs))
+	for _, def := range defs {
+		ratingMax[def.Key] = def.RatingMax
+	}
+	if len(defs) != 3 || ratingMax["effort"] != 10 || ratingMax["effort_2"] != 5 {
+		t.Errorf("expected distance and effort_2 definitions copied to target, got %+v", defs)
+	}
+
+	changes, err := repos.ChangeLog.GetSince(ctx, userID, cursor, 100)
+	if err != nil {
+		t.Fatalf("GetSince failed: %v", err)
+	}
+	want := []struct {
+		entity models.EntityType
+		op     models.Operation
+	}{
+		{models.EntityTypePropertyDefinition, models.OperationCreate},
+		{models.EntityTypePropertyDefinition, models.OperationCreate},
+		{models.EntityTypeEvent, models.OperationUpdate},
+		{models.EntityTypePropertyDefinition, models.OperationDelete},
+		{models.EntityTypePropertyDefinition, models.OperationDelete},
+		{models.EntityTypeEventType, models.OperationDelete},
+	}
+	if len(changes.Changes) != len(want) {
+		t.Fatalf("expected %d change entries, got %d", len(want), len(changes.Changes))
+	}
+	for i, change := range changes.Changes {
+		if change.EntityType != want[i].entity || change.Operation != want[i].op {
+			t.Errorf("change %d: got %s %s, want %s %s", i, change.Operation, change.EntityType, want[i].op, want[i].entity)
+		}
+	}
+
+	// Archiving hides the type from pickers but keeps its events
+	if err := eventTypeService.DeleteEventType(ctx, userID, run.ID, &models.DeleteEventTypeRequest{
+		Strategy: models.DeleteStrategyArchive,
+	}); err != nil {
+		t.Fatalf("DeleteEventType archive failed: %v", err)
+	}
+	if types, _ := eventTypeService.GetUserEventTypes(ctx, userID, false); len(types) != 0 {
+		t.Errorf("archived event type should be hidden, got %d", len(types))
+	}
+	if types, _ := eventTypeService.GetUserEventTypes(ctx, userID, true); len(types) != 1 || types[0].ArchivedAt == nil {
+		t.Errorf("expected archived event type with include_archived, got %+v", types)
+	}
+	if _, err := eventService.GetEvent(ctx, userID, event.ID); err != nil {
+		t.Errorf("events of an archived type should stay readable: %v", err)
+	}
+
+	unarchive := false
+	restored, err := eventTypeService.UpdateEventType(ctx, userID, run.ID, &models.UpdateEventTypeRequest{Archived: &unarchive})
+	if err != nil {
+		t.Fatalf("UpdateEventType failed: %v", err)
+	}
+	if restored.ArchivedAt != nil {
+		t.Error("expected event type to be unarchived")
+	}
+}
+
+func TestMergeEventType(t *testing.T) {
+	ctx := context.Background()
+	repos := memory.NewRepositories(memory.NewStore())
+	userID := "user-1"
+
+	eventService := NewEventService(repos.Events, repos.EventTypes, repos.PropertyDefinitions, repos.ChangeLog, repos.Transactor)
+	eventTypeService := NewEventTypeService(repos.EventTypes, repos.Events, repos.PropertyDefinitions, repos.Geofences, repos.Insights, repos.Streaks, repos.ChangeLog, repos.Transactor)
+	propertyDefService := NewPropertyDefinitionService(repos.PropertyDefinitions, repos.EventTypes, repos.Events, repos.ChangeLog, repos.Transactor)
+
+	running, _ := eventTypeService.CreateEventType(ctx, userID, &models.CreateEventTypeRequest{Name: "Running", Color: "#f00", Icon: "run"})
+	run, _ := eventTypeService.CreateEventType(ctx, userID, &models.CreateEventTypeRequest{Name: "Run", Color: "#0f0", Icon: "run"})
+
+	defs := []models.CreatePropertyDefinitionRequest{
+		{EventTypeID: running.ID, Key: "distance", Label: "Distance", PropertyType: models.PropertyTypeText},
+		{EventTypeID: running.ID, Key: "route", Label: "Route", PropertyType: models.PropertyTypeSelect, Options: []string{"park", "river"}},
+		{EventTypeID: run.ID, Key: "distance", Label: "Distance", PropertyType: models.PropertyTypeNumber},
+		{EventTypeID: run.ID, Key: "route", Label: "Route", PropertyType: models.PropertyTypeSelect, Options: []string{"park", "track"}},
+	}
+	for i := range defs {
+		if _, err := propertyDefService.CreatePropertyDefinition(ctx, userID, &defs[i]); err != nil {
+			t.Fatalf("CreatePropertyDefinition failed: %v", err)
+		}
+	}
+
+	event, _, err := eventService.CreateEvent(ctx, userID, &models.CreateEventRequest{
+		EventTypeID: running.ID,
+		Timestamp:   time.Now(),
+		Properties: map[string]models.PropertyValue{
+			"distance": {Type: models.PropertyTypeText, Value: "5k"},
+			"route":    {Type: models.PropertyTypeSelect, Value: "river"},
+		},
+	})
+	if err != nil {
+		t.Fatalf("CreateEvent failed: %v", err)
+	}
+	geofence, err := repos.Geofences.Create(ctx, &models.Geofence{
+		ID: "geofence-1", UserID: userID, Name: "Park", Latitude: 1, Longitude: 1, Radius: 100,
+		EventTypeEntryID: &running.ID,
+	})
+	if err != nil {
+		t.Fatalf("Create geofence failed: %v", err)
+	}
+
+	if _, err := eventTypeService.MergeEventType(ctx, userID, run.ID, run.ID); !errors.Is(err, ErrInvalidReassignTarget) {
+		t.Fatalf("expected ErrInvalidReassignTarget, got %v", err)
+	}
+
+	cursor, _ := repos.ChangeLog.GetLatestCursor(ctx, userID)
+	result, err := eventTypeService.MergeEventType(ctx, userID, running.ID, run.ID)
+	if err != nil {
+		t.Fatalf("MergeEventType failed: %v", err)
+	}
+	if result.EventsMoved != 1 || result.PropertyDefinitionsCopied != 1 || result.GeofencesUpdated != 1 {
+		t.Errorf("unexpected merge result %+v", result)
+	}
+	if result.RenamedProperties["distance"] != "distance_2" {
+		t.Errorf("expected conflicting distance renamed to distance_2, got %v", result.RenamedProperties)
+	}
+
+	// The text distance keeps its value under the new key
+	moved, err := eventService.GetEvent(ctx, userID, event.ID)
+	if err != nil {
+		t.Fatalf("GetEvent failed: %v", err)
+	}
+	if moved.EventTypeID != run.ID || moved.Properties["distance_2"].Value != "5k" || moved.Properties["route"].Value != "river" {
+		t.Errorf("unexpected merged event %s %+v", moved.EventTypeID, moved.Properties)
+	}
+	if _, ok := moved.Properties["distance"]; ok {
+		t.Error("conflicting property should no longer be stored under its old key")
+	}
+
+	targetDefs, _ := repos.PropertyDefinitions.GetByEventTypeID(ctx, run.ID)
+	for _, def := range targetDefs {
+		if def.Key == "route" && len(def.Options) != 3 {
+			t.Errorf("expected route options merged, got %v", def.Options)
+		}
+	}
+	if len(targetDefs) != 3 {
+		t.Errorf("expected 3 property definitions on target, got %d", len(targetDefs))
+	}
+
+	if g, _ := repos.Geofences.GetByID(ctx, geofence.ID); g.EventTypeEntryID == nil || *g.EventTypeEntryID != run.ID {
+		t.Error("expected geofence entry to point at the target")
+	}
+	if _, err := eventTypeService.GetEventType(ctx, userID, running.ID); err == nil {
+		t.Error("merged source should be in the trash")
+	}
+
+	changes, _ := repos.ChangeLog.GetSince(ctx, userID, cursor, 100)
+	want := []struct {
+		entity models.EntityType
+		op     models.Operation
+	}{
+		{models.EntityTypePropertyDefinition, models.OperationCreate},
+		{models.EntityTypePropertyDefinition, models.OperationUpdate},
+		{models.EntityTypeEvent, models.OperationUpdate},
+		{models.EntityTypeGeofence, models.OperationUpdate},
+		{models.EntityTypePropertyDefinition, models.OperationDelete},
+		{models.EntityTypePropertyDefinition, models.OperationDelete},
+		{models.EntityTypeEventType, models.OperationDelete},
+	}
+	if len(changes.Changes) != len(want) {
+		t.Fatalf("expected %d change entries, got %d", len(want), len(changes.Changes))
+	}
+	for i, change := range changes.Changes {
+		if change.EntityType != want[i].entity || change.Operation != want[i].op {
+			t.Errorf("change %d: got %s %s, want %s %s", i, change.Operation, change.EntityType, want[i].op, want[i].entity)
+		}
+	}
+}
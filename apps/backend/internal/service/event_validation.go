@@ -0,0 +1,103 @@
+package service
+
+import (
+	"strconv"
+	"time"
+
+	"github.com/JonnyWalker81/trendy/backend/internal/apierror"
+	"github.com/JonnyWalker81/trendy/backend/internal/models"
+)
+
+// ParseCreateEventRequest converts a raw create request into a typed one,
+// collecting every field error instead of stopping at the first. The request
+// is only usable when no errors are returned.
+func ParseCreateEventRequest(raw *models.RawCreateEventRequest) (*models.CreateEventRequest, []apierror.FieldError) {
+	var fieldErrors []apierror.FieldError
+	var req models.CreateEventRequest
+
+	// Validate required fields
+	if raw.EventTypeID == "" {
+		fieldErrors = append(fieldErrors, apierror.FieldError{
+			Field:   "event_type_id",
+			Message: "is required",
+			Code:    "required",
+		})
+	} else {
+		req.EventTypeID = raw.EventTypeID
+	}
+
+	// Parse and validate timestamp (required)
+	if raw.Timestamp == "" {
+		fieldErrors = append(fieldErrors, apierror.FieldError{
+			Field:   "timestamp",
+			Message: "is required",
+			Code:    "required",
+		})
+	} else {
+		ts, err := time.Parse(time.RFC3339, raw.Timestamp)
+		if err != nil {
+			fieldErrors = append(fieldErrors, apierror.FieldError{
+				Field:   "timestamp",
+				Message: "must be a valid RFC3339 timestamp",
+				Code:    "invalid_format",
+			})
+		} else {
+			req.Timestamp = ts
+		}
+	}
+
+	// Parse is_all_day (optional, defaults to false)
+	if raw.IsAllDay != nil {
+		switch v := raw.IsAllDay.(type) {
+		case bool:
+			req.IsAllDay = v
+		case string:
+			b, err := strconv.ParseBool(v)
+			if err != nil {
+				fieldErrors = append(fieldErrors, apierror.FieldError{
+					Field:   "is_all_day",
+					Message: "must be a boolean value",
+					Code:    "invalid_type",
+				})
+			} else {
+				req.IsAllDay = b
+			}
+		default:
+			fieldErrors = append(fieldErrors, apierror.FieldError{
+				Field:   "is_all_day",
+				Message: "must be a boolean value",
+				Code:    "invalid_type",
+			})
+		}
+	}
+
+	// Parse end_date (optional)
+	if raw.EndDate != nil && *raw.EndDate != "" {
+		ed, err := time.Parse(time.RFC3339, *raw.EndDate)
+		if err != nil {
+			fieldErrors = append(fieldErrors, apierror.FieldError{
+				Field:   "end_date",
+				Message: "must be a valid RFC3339 timestamp",
+				Code:    "invalid_format",
+			})
+		} else {
+			req.EndDate = &ed
+		}
+	}
+
+	// Copy remaining fields
+	req.ID = raw.ID
+	req.Notes = raw.Notes
+	req.SourceType = raw.SourceType
+	req.ExternalID = raw.ExternalID
+	req.OriginalTitle = raw.OriginalTitle
+	req.GeofenceID = raw.GeofenceID
+	req.LocationLatitude = raw.LocationLatitude
+	req.LocationLongitude = raw.LocationLongitude
+	req.LocationName = raw.LocationName
+	req.HealthKitSampleID = raw.HealthKitSampleID
+	req.HealthKitCategory = raw.HealthKitCategory
+	req.Properties = raw.Properties
+
+	return &req, fieldErrors
+}
@@ -0,0 +1,99 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"io"
+
+	"github.com/JonnyWalker81/trendy/backend/internal/export"
+	"github.com/JonnyWalker81/trendy/backend/internal/models"
+	"github.com/JonnyWalker81/trendy/backend/internal/repository"
+)
+
+// exportPageSize is how many events are read per query while streaming
+const exportPageSize = 500
+
+type exportService struct {
+	eventRepo       repository.EventRepository
+	eventTypeRepo   repository.EventTypeRepository
+	propertyDefRepo repository.PropertyDefinitionRepository
+}
+
+// NewExportService creates a new export service
+func NewExportService(eventRepo repository.EventRepository, eventTypeRepo repository.EventTypeRepository, propertyDefRepo repository.PropertyDefinitionRepository) ExportService {
+	return &exportService{
+		eventRepo:       eventRepo,
+		eventTypeRepo:   eventTypeRepo,
+		propertyDefRepo: propertyDefRepo,
+	}
+}
+
+func (s *exportService) ExportEvents(ctx context.Context, userID string, req *models.ExportEventsRequest, w io.Writer) error {
+	var columns []export.Column
+	if req.Format == models.ExportFormatCSV {
+		defs, err := s.propertyDefinitions(ctx, userID, req.EventTypeIDs)
+		if err != nil {
+			return err
+		}
+		columns = export.PropertyColumns(defs)
+	}
+
+	// Read the first page before writing so a failing query can still be
+	// reported as an error response
+	events, err := s.eventRepo.GetForExportPage(ctx, userID, req.StartDate, req.EndDate, req.EventTypeIDs, nil, exportPageSize)
+	if err != nil {
+		return err
+	}
+
+	enc, err := export.NewEncoder(req.Format, w, columns)
+	if err != nil {
+		return err
+	}
+
+	for {
+		for i := range events {
+			if err := enc.Encode(&events[i]); err != nil {
+				return fmt.Errorf("failed to encode event: %w", err)
+			}
+		}
+		if len(events) < exportPageSize {
+			break
+		}
+
+		last := events[len(events)-1]
+		after := &models.EventCursor{Timestamp: last.Timestamp, ID: last.ID}
+		if events, err = s.eventRepo.GetForExportPage(ctx, userID, req.StartDate, req.EndDate, req.EventTypeIDs, after, exportPageSize); err != nil {
+			return err
+		}
+	}
+
+	return enc.Close()
+}
+
+// propertyDefinitions returns the property definitions of the exported
+// event types, all of the user's when eventTypeIDs is empty
+func (s *exportService) propertyDefinitions(ctx context.Context, userID string, eventTypeIDs []string) ([]models.PropertyDefinition, error) {
+	eventTypes, err := s.eventTypeRepo.GetByUserID(ctx, userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get event types: %w", err)
+	}
+
+	wanted := make(map[string]bool, len(eventTypeIDs))
+	for _, id := range eventTypeIDs {
+		wanted[id] = true
+	}
+
+	var defs []models.PropertyDefinition
+	for _, et := range eventTypes {
+		if len(wanted) > 0 && !wanted[et.ID] {
+			continue
+		}
+		typeDefs, err := s.propertyDefRepo.GetByEventTypeID(ctx, et.ID)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get property definitions: %w", err)
+		}
+		defs = append(defs, typeDefs...)
+	}
+
+	return defs, nil
+}
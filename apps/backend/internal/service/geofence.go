@@ -5,7 +5,6 @@ import (
 	"fmt"
 	"time"
 
-	"github.com/JonnyWalker81/trendy/backend/internal/logger"
 	"github.com/JonnyWalker81/trendy/backend/internal/models"
 	"github.com/JonnyWalker81/trendy/backend/internal/repository"
 )
@@ -13,13 +12,15 @@ import (
 type geofenceService struct {
 	geofenceRepo  repository.GeofenceRepository
 	changeLogRepo repository.ChangeLogRepository
+	tx            repository.Transactor
 }
 
 // NewGeofenceService creates a new geofence service
-func NewGeofenceService(geofenceRepo repository.GeofenceRepository, changeLogRepo repository.ChangeLogRepository) GeofenceService {
+func NewGeofenceService(geofenceRepo repository.GeofenceRepository, changeLogRepo repository.ChangeLogRepository, tx repository.Transactor) GeofenceService {
 	return &geofenceService{
 		geofenceRepo:  geofenceRepo,
 		changeLogRepo: changeLogRepo,
+		tx:            tx,
 	}
 }
 
@@ -51,23 +52,27 @@ func (s *geofenceService) CreateGeofence(ctx context.Context, userID string, req
 		NotifyOnExit:     &req.NotifyOnExit,
 	}
 
-	created, err := s.geofenceRepo.Create(ctx, geofence)
+	var created *models.Geofence
+	err := s.tx.WithinTx(ctx, func(ctx context.Context) error {
+		var err error
+		created, err = s.geofenceRepo.Create(ctx, geofence)
+		if err != nil {
+			return err
+		}
+
+		_, err = s.changeLogRepo.Append(ctx, &models.ChangeLogInput{
+			EntityType: models.EntityTypeGeofence,
+			Operation:  models.OperationCreate,
+			EntityID:   created.ID,
+			UserID:     userID,
+			Data:       created,
+		})
+		return err
+	})
 	if err != nil {
 		return nil, err
 	}
 
-	// Append to change log
-	if _, err := s.changeLogRepo.Append(ctx, &models.ChangeLogInput{
-		EntityType: models.EntityTypeGeofence,
-		Operation:  models.OperationCreate,
-		EntityID:   created.ID,
-		UserID:     userID,
-		Data:       created,
-	}); err != nil {
-		log := logger.FromContext(ctx)
-		log.Warn("failed to append to change log", logger.Err(err), logger.String("geofence_id", created.ID))
-	}
-
 	return created, nil
 }
 
@@ -154,23 +159,31 @@ func (s *geofenceService) UpdateGeofence(ctx context.Context, userID, geofenceID
 		update.IOSRegionIdentifier = req.IOSRegionIdentifier
 	}
 
-	updated, err := s.geofenceRepo.Update(ctx, geofenceID, update)
+	var updated *models.Geofence
+	err = s.tx.WithinTx(ctx, func(ctx context.Context) error {
+		if err := checkPrecondition(ctx, geofenceID, existingGeofence.UpdatedAt, s.geofenceRepo.ClaimVersion); err != nil {
+			return err
+		}
+
+		var err error
+		updated, err = s.geofenceRepo.Update(ctx, geofenceID, update)
+		if err != nil {
+			return err
+		}
+
+		_, err = s.changeLogRepo.Append(ctx, &models.ChangeLogInput{
+			EntityType: models.EntityTypeGeofence,
+			Operation:  models.OperationUpdate,
+			EntityID:   updated.ID,
+			UserID:     userID,
+			Data:       updated,
+		})
+		return err
+	})
 	if err != nil {
 		return nil, err
 	}
 
-	// Append to change log
-	if _, err := s.changeLogRepo.Append(ctx, &models.ChangeLogInput{
-		EntityType: models.EntityTypeGeofence,
-		Operation:  models.OperationUpdate,
-		EntityID:   updated.ID,
-		UserID:     userID,
-		Data:       updated,
-	}); err != nil {
-		log := logger.FromContext(ctx)
-		log.Warn("failed to append update to change log", logger.Err(err), logger.String("geofence_id", updated.ID))
-	}
-
 	return updated, nil
 }
 
@@ -185,22 +198,23 @@ func (s *geofenceService) DeleteGeofence(ctx context.Context, userID, geofenceID
 		return fmt.Errorf("geofence not found")
 	}
 
-	if err := s.geofenceRepo.Delete(ctx, geofenceID); err != nil {
-		return err
-	}
+	return s.tx.WithinTx(ctx, func(ctx context.Context) error {
+		if err := checkPrecondition(ctx, geofenceID, geofence.UpdatedAt, s.geofenceRepo.ClaimVersion); err != nil {
+			return err
+		}
 
-	// Append to change log
-	now := time.Now()
-	if _, err := s.changeLogRepo.Append(ctx, &models.ChangeLogInput{
-		EntityType: models.EntityTypeGeofence,
-		Operation:  models.OperationDelete,
-		EntityID:   geofenceID,
-		UserID:     userID,
-		DeletedAt:  &now,
-	}); err != nil {
-		log := logger.FromContext(ctx)
-		log.Warn("failed to append delete to change log", logger.Err(err), logger.String("geofence_id", geofenceID))
-	}
+		if err := s.geofenceRepo.Delete(ctx, geofenceID); err != nil {
+			return err
+		}
 
-	return nil
+		now := time.Now()
+		_, err := s.changeLogRepo.Append(ctx, &models.ChangeLogInput{
+			EntityType: models.EntityTypeGeofence,
+			Operation:  models.OperationDelete,
+			EntityID:   geofenceID,
+			UserID:     userID,
+			DeletedAt:  &now,
+		})
+		return err
+	})
 }
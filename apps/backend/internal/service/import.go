@@ -0,0 +1,994 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/JonnyWalker81/trendy/backend/internal/apierror"
+	"github.com/JonnyWalker81/trendy/backend/internal/importer"
+	"github.com/JonnyWalker81/trendy/backend/internal/logger"
+	"github.com/JonnyWalker81/trendy/backend/internal/models"
+	"github.com/JonnyWalker81/trendy/backend/internal/repository"
+)
+
+const (
+	// ImportMaxRows is the most rows accepted in one import file
+	ImportMaxRows = 50000
+	// importBatchSize matches the limit of POST /events/batch
+	importBatchSize = 500
+	// importMaxErrors caps the row errors kept in a report or job
+	importMaxErrors = 1000
+
+	importSourceType     = "import"
+	importEventTypeColor = "#888888"
+	importEventTypeIcon  = "circle"
+)
+
+// ErrInvalidImportFile is returned when an import file cannot be read at all,
+// as opposed to individual rows failing validation
+var ErrInvalidImportFile = errors.New("invalid import file")
+
+// importEventFields are record fields that hold event columns rather than
+// properties. They are never read as property columns.
+var importEventFields = map[string]bool{
+	"id": true, "user_id": true, "event_type_id": true, "event_type": true,
+	"timestamp": true, "end_date": true, "is_all_day": true, "notes": true,
+	"source_type": true, "external_id": true, "original_title": true, "geofence_id": true,
+	"location_name": true, "location_latitude": true, "location_longitude": true,
+	"healthkit_sample_id": true, "healthkit_category": true,
+	"properties": true, "other_properties": true,
+	"created_at": true, "updated_at": true, "deleted_at": true,
+}
+
+type importService struct {
+	eventRepo       repository.EventRepository
+	eventTypeRepo   repository.EventTypeRepository
+	propertyDefRepo repository.PropertyDefinitionRepository
+	geofenceRepo    repository.GeofenceRepository
+	onboardingRepo  repository.OnboardingStatusRepository
+	importJobRepo   repository.ImportJobRepository
+	changeLogRepo   repository.ChangeLogRepository
+	tx              repository.Transactor
+}
+
+// NewImportService creates a new import service. Imports run in a goroutine
+// of the process that accepted them.
+func NewImportService(
+	eventRepo repository.EventRepository,
+	eventTypeRepo repository.EventTypeRepository,
+	propertyDefRepo repository.PropertyDefinitionRepository,
+	geofenceRepo repository.GeofenceRepository,
+	onboardingRepo repository.OnboardingStatusRepository,
+	importJobRepo repository.ImportJobRepository,
+	changeLogRepo repository.ChangeLogRepository,
+	tx repository.Transactor,
+) ImportService {
+	return &importService{
+		eventRepo:       eventRepo,
+		eventTypeRepo:   eventTypeRepo,
+		propertyDefRepo: propertyDefRepo,
+		geofenceRepo:    geofenceRepo,
+		onboardingRepo:  onboardingRepo,
+		importJobRepo:   importJobRepo,
+		changeLogRepo:   changeLogRepo,
+		tx:              tx,
+	}
+}
+
+func (s *importService) DryRun(ctx context.Context, userID string, format models.ImportFormat, mapping *models.ImportMapping, r io.Reader) (*models.ImportReport, error) {
+	plan, err := s.plan(ctx, userID, format, mapping, r)
+	if err != nil {
+		return nil, err
+	}
+	return plan.report, nil
+}
+
+func (s *importService) StartImport(ctx context.Context, userID string, format models.ImportFormat, mapping *models.ImportMapping, r io.Reader) (*models.ImportJob, error) {
+	plan, err := s.plan(ctx, userID, format, mapping, r)
+	if err != nil {
+		return nil, err
+	}
+
+	job, err := s.importJobRepo.Create(ctx, &models.ImportJob{
+		UserID:    userID,
+		Status:    models.ImportJobPending,
+		Format:    format,
+		TotalRows: plan.report.TotalRows,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to create import job: %w", err)
+	}
+
+	// The import outlives the request but keeps its logger and request ID
+	background := *job
+	go s.run(context.WithoutCancel(ctx), &background, plan)
+
+	return job, nil
+}
+
+func (s *importService) GetImportJob(ctx context.Context, userID, jobID string) (*models.ImportJob, error) {
+	job, err := s.importJobRepo.GetByID(ctx, jobID)
+	if err != nil {
+		return nil, err
+	}
+
+	if job.UserID != userID {
+		return nil, fmt.Errorf("import job not found")
+	}
+
+	return job, nil
+}
+
+// run executes a planned import, recording progress on job. Rows that failed
+// validation are counted as processed and failed up front.
+func (s *importService) run(ctx context.Context, job *models.ImportJob, plan *importPlan) {
+	log := logger.Ctx(ctx)
+
+	job.Status = models.ImportJobRunning
+	job.ProcessedRows = plan.report.InvalidRows
+	job.FailedCount = plan.report.InvalidRows
+	job.Errors = plan.report.Errors
+	s.saveProgress(ctx, job)
+
+	if err := s.execute(ctx, job, plan); err != nil {
+		log.Error("import failed",
+			logger.Err(err),
+			logger.String("job_id", job.ID),
+		)
+		message := err.Error()
+		job.Status = models.ImportJobFailed
+		job.Error = &message
+	} else {
+		job.Status = models.ImportJobCompleted
+	}
+
+	completedAt := time.Now().UTC()
+	job.CompletedAt = &completedAt
+	s.saveProgress(ctx, job)
+}
+
+func (s *importService) saveProgress(ctx context.Context, job *models.ImportJob) {
+	if _, err := s.importJobRepo.UpdateProgress(ctx, job); err != nil {
+		logger.Ctx(ctx).Error("failed to update import job",
+			logger.Err(err),
+			logger.String("job_id", job.ID),
+		)
+	}
+}
+
+// execute creates the planned event types and property definitions, then
+// inserts the valid rows in batches
+func (s *importService) execute(ctx context.Context, job *models.ImportJob, plan *importPlan) error {
+	newTypeIDs := make(map[string]string, len(plan.newTypes))
+	for _, name := range plan.newTypes {
+		created, err := s.createEventType(ctx, &models.EventType{
+			UserID: job.UserID,
+			Name:   name,
+			Color:  importEventTypeColor,
+			Icon:   importEventTypeIcon,
+		})
+		if err != nil {
+			return fmt.Errorf("failed to create event type %q: %w", name, err)
+		}
+		newTypeIDs[strings.ToLower(name)] = created.ID
+	}
+
+	for _, def := range plan.newDefs {
+		eventTypeID := def.eventTypeID
+		if def.newType != "" {
+			eventTypeID = newTypeIDs[def.newType]
+		}
+		if _, err := s.createPropertyDefinition(ctx, &models.PropertyDefinition{
+			EventTypeID:  eventTypeID,
+			UserID:       job.UserID,
+			Key:          def.key,
+			Label:        def.label,
+			PropertyType: def.propertyType,
+		}); err != nil {
+			return fmt.Errorf("failed to create property definition %q: %w", def.key, err)
+		}
+	}
+
+	events := make([]models.Event, len(plan.rows))
+	rows := make([]int, len(plan.rows))
+	for i, row := range plan.rows {
+		if row.newType != "" {
+			row.req.EventTypeID = newTypeIDs[row.newType]
+		}
+		events[i] = importedEvent(job.UserID, row.req)
+		rows[i] = row.row
+	}
+
+	s.insertBatches(ctx, job, events, rows)
+	return nil
+}
+
+// insertBatches inserts events in batches, saving the job's progress after
+// each. rows holds the file row of each event for error reporting.
+func (s *importService) insertBatches(ctx context.Context, job *models.ImportJob, events []models.Event, rows []int) {
+	for start := 0; start < len(events); start += importBatchSize {
+		end := min(start+importBatchSize, len(events))
+		batch := events[start:end]
+
+		if err := s.insertEvents(ctx, job.UserID, batch); err == nil {
+			job.CreatedCount += len(batch)
+		} else {
+			// Retry row by row so one bad row does not fail its whole batch
+			for i := range batch {
+				if err := s.insertEvents(ctx, job.UserID, batch[i:i+1]); err != nil {
+					job.FailedCount++
+					job.Errors = appendRowError(job.Errors, models.ImportRowError{
+						Row:    rows[start+i],
+						Errors: []apierror.FieldError{insertError(err)},
+					})
+					continue
+				}
+				job.CreatedCount++
+			}
+		}
+
+		job.ProcessedRows += len(batch)
+		s.saveProgress(ctx, job)
+	}
+}
+
+func (s *importService) createEventType(ctx context.Context, eventType *models.EventType) (*models.EventType, error) {
+	var created *models.EventType
+	err := s.tx.WithinTx(ctx, func(ctx context.Context) error {
+		var err error
+		created, err = s.eventTypeRepo.Create(ctx, eventType)
+		if err != nil {
+			return err
+		}
+
+		_, err = s.changeLogRepo.Append(ctx, &models.ChangeLogInput{
+			EntityType: models.EntityTypeEventType,
+			Operation:  models.OperationCreate,
+			EntityID:   created.ID,
+			UserID:     created.UserID,
+			Data:       created,
+		})
+		return err
+	})
+	return created, err
+}
+
+func (s *importService) createPropertyDefinition(ctx context.Context, def *models.PropertyDefinition) (*models.PropertyDefinition, error) {
+	var created *models.PropertyDefinition
+	err := s.tx.WithinTx(ctx, func(ctx context.Context) error {
+		var err error
+		created, err = s.propertyDefRepo.Create(ctx, def)
+		if err != nil {
+			return err
+		}
+
+		_, err = s.changeLogRepo.Append(ctx, &models.ChangeLogInput{
+			EntityType: models.EntityTypePropertyDefinition,
+			Operation:  models.OperationCreate,
+			EntityID:   created.ID,
+			UserID:     created.UserID,
+			Data:       created,
+		})
+		return err
+	})
+	return created, err
+}
+
+// insertEvents creates events and their change log entries in one transaction
+func (s *importService) insertEvents(ctx context.Context, userID string, events []models.Event) error {
+	return s.tx.WithinTx(ctx, func(ctx context.Context) error {
+		created, err := s.eventRepo.CreateBatch(ctx, events)
+		if err != nil {
+			return fmt.Errorf("failed to create events: %w", err)
+		}
+
+		for _, event := range created {
+			if _, err := s.changeLogRepo.Append(ctx, &models.ChangeLogInput{
+				EntityType: models.EntityTypeEvent,
+				Operation:  models.OperationCreate,
+				EntityID:   event.ID,
+				UserID:     userID,
+				Data:       event,
+			}); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+func importedEvent(userID string, req *models.CreateEventRequest) models.Event {
+	return models.Event{
+		UserID:            userID,
+		EventTypeID:       req.EventTypeID,
+		Timestamp:         req.Timestamp,
+		Notes:             req.Notes,
+		IsAllDay:          req.IsAllDay,
+		EndDate:           req.EndDate,
+		SourceType:        req.SourceType,
+		LocationLatitude:  req.LocationLatitude,
+		LocationLongitude: req.LocationLongitude,
+		LocationName:      req.LocationName,
+		Properties:        req.Properties,
+	}
+}
+
+// insertError describes a row that passed validation but could not be inserted
+func insertError(err error) apierror.FieldError {
+	if strings.Contains(err.Error(), "duplicate") || strings.Contains(err.Error(), "23505") {
+		return apierror.FieldError{
+			Field:   "timestamp",
+			Message: "an event of this type already exists at this time",
+			Code:    "duplicate",
+		}
+	}
+	return apierror.FieldError{
+		Field:   "event",
+		Message: err.Error(),
+		Code:    "insert_failed",
+	}
+}
+
+// appendRowError adds a row error unless the cap has been reached
+func appendRowError(errs []models.ImportRowError, rowErr models.ImportRowError) []models.ImportRowError {
+	if len(errs) >= importMaxErrors {
+		return errs
+	}
+	return append(errs, rowErr)
+}
+
+// importPlan is a validated import file: the rows to insert and the event
+// types and property definitions to create first
+type importPlan struct {
+	report   *models.ImportReport
+	rows     []importRow
+	newTypes []string // Names, in order of first use
+	newDefs  []importDefinition
+}
+
+type importRow struct {
+	row     int
+	newType string // Lower-cased name of a planned event type, if the row uses one
+	req     *models.CreateEventRequest
+}
+
+type importDefinition struct {
+	eventTypeID  string // Set for existing event types
+	newType      string // Set for planned event types
+	typeName     string
+	key          string
+	label        string
+	propertyType models.PropertyType
+}
+
+// plan decodes an import file and validates every row against the user's
+// event types and property definitions without writing anything
+func (s *importService) plan(ctx context.Context, userID string, format models.ImportFormat, mapping *models.ImportMapping, r io.Reader) (*importPlan, error) {
+	records, err := importer.Decode(format, r, ImportMaxRows)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidImportFile, err)
+	}
+
+	eventTypes, err := s.eventTypeRepo.GetByUserID(ctx, userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get event types: %w", err)
+	}
+
+	p := &importPlanner{
+		ctx:             ctx,
+		propertyDefRepo: s.propertyDefRepo,
+		mapping:         withImportDefaults(mapping),
+		typesByID:       make(map[string]*models.EventType, len(eventTypes)),
+		typesByName:     make(map[string]*models.EventType, len(eventTypes)),
+		defs:            make(map[string]map[string]models.PropertyDefinition),
+		newTypes:        make(map[string]bool),
+		newDefs:         make(map[string]int),
+		mapped:          make(map[string]bool),
+		unmapped:        make(map[string]bool),
+		plan: &importPlan{
+			report: &models.ImportReport{
+				TotalRows:                   len(records),
+				EventTypesToCreate:          []string{},
+				PropertyDefinitionsToCreate: []string{},
+				UnmappedFields:              []string{},
+				Errors:                      []models.ImportRowError{},
+			},
+		},
+	}
+	for i := range eventTypes {
+		et := &eventTypes[i]
+		p.typesByID[et.ID] = et
+		if _, exists := p.typesByName[strings.ToLower(et.Name)]; !exists {
+			p.typesByName[strings.ToLower(et.Name)] = et
+		}
+	}
+
+	for i, record := range records {
+		if err := p.planRow(i+1, record); err != nil {
+			return nil, err
+		}
+	}
+
+	report := p.plan.report
+	report.EventTypesToCreate = append(report.EventTypesToCreate, p.plan.newTypes...)
+	for _, def := range p.plan.newDefs {
+		report.PropertyDefinitionsToCreate = append(report.PropertyDefinitionsToCreate, def.typeName+"."+def.key)
+	}
+	for field := range p.unmapped {
+		if p.mapped[field] {
+			continue
+		}
+		report.UnmappedFields = append(report.UnmappedFields, field)
+	}
+	sort.Strings(report.UnmappedFields)
+
+	return p.plan, nil
+}
+
+// importPlanner holds the lookups used while validating the rows of one file
+type importPlanner struct {
+	ctx             context.Context
+	propertyDefRepo repository.PropertyDefinitionRepository
+	mapping         models.ImportMapping
+	typesByID       map[string]*models.EventType
+	typesByName     map[string]*models.EventType // Lower-cased name
+	// defs holds the property definitions of existing event types by key,
+	// loaded on first use
+	defs     map[string]map[string]models.PropertyDefinition
+	newTypes map[string]bool // Lower-cased names of planned event types
+	newDefs  map[string]int  // Index into plan.newDefs by event type and key
+	// mapped and unmapped record the columns read as a property in some row
+	// and those skipped in some row; only columns never read are reported
+	mapped   map[string]bool
+	unmapped map[string]bool
+	plan     *importPlan
+}
+
+// withImportDefaults fills the unset fields of a mapping with the column
+// names of the exports
+func withImportDefaults(mapping *models.ImportMapping) models.ImportMapping {
+	var m models.ImportMapping
+	if mapping != nil {
+		m = *mapping
+	}
+
+	defaults := []struct {
+		field *string
+		name  string
+	}{
+		{&m.EventType, "event_type"},
+		{&m.Timestamp, "timestamp"},
+		{&m.EndDate, "end_date"},
+		{&m.IsAllDay, "is_all_day"},
+		{&m.Notes, "notes"},
+		{&m.LocationName, "location_name"},
+		{&m.LocationLatitude, "location_latitude"},
+		{&m.LocationLongitude, "location_longitude"},
+	}
+	for _, d := range defaults {
+		if *d.field == "" {
+			*d.field = d.name
+		}
+	}
+	return m
+}
+
+// planRow validates one record and adds it to the plan or the report errors
+func (p *importPlanner) planRow(row int, record importer.Record) error {
+	m := p.mapping
+	var fieldErrors []apierror.FieldError
+	raw := models.RawCreateEventRequest{SourceType: importSourceType}
+
+	typeRef, newType, typeName, typeErr := p.resolveEventType(record)
+	raw.EventTypeID = typeRef
+	if typeErr != nil {
+		fieldErrors = append(fieldErrors, *typeErr)
+	}
+
+	timestamp, err := p.timeField(record, m.Timestamp)
+	if err != nil {
+		fieldErrors = append(fieldErrors, apierror.FieldError{
+			Field:   "timestamp",
+			Message: err.Error(),
+			Code:    "invalid_format",
+		})
+	}
+	raw.Timestamp = timestamp
+
+	if endDate, err := p.timeField(record, m.EndDate); err != nil {
+		fieldErrors = append(fieldErrors, apierror.FieldError{
+			Field:   "end_date",
+			Message: err.Error(),
+			Code:    "invalid_format",
+		})
+	} else if endDate != "" {
+		raw.EndDate = &endDate
+	}
+
+	if value, ok := record[m.IsAllDay]; ok && value != "" {
+		raw.IsAllDay = value
+	}
+	raw.Notes = optionalString(stringField(record, m.Notes))
+	raw.LocationName = optionalString(stringField(record, m.LocationName))
+
+	for _, coord := range []struct {
+		field, name string
+		dest        **float64
+	}{
+		{m.LocationLatitude, "location_latitude", &raw.LocationLatitude},
+		{m.LocationLongitude, "location_longitude", &raw.LocationLongitude},
+	} {
+		value := stringField(record, coord.field)
+		if value == "" {
+			continue
+		}
+		f, err := strconv.ParseFloat(strings.TrimSpace(value), 64)
+		if err != nil {
+			fieldErrors = append(fieldErrors, apierror.FieldError{
+				Field:   coord.name,
+				Message: "must be a number",
+				Code:    "invalid_type",
+			})
+			continue
+		}
+		*coord.dest = &f
+	}
+
+	properties, propErrors, err := p.planProperties(record, typeRef, newType, typeName)
+	if err != nil {
+		return err
+	}
+	fieldErrors = append(fieldErrors, propErrors...)
+
+	// Existing property definitions apply as on POST /events
+	defs, err := p.definitions(typeRef, newType)
+	if err != nil {
+		return err
+	}
+	var list []models.PropertyDefinition
+	if len(defs) > 0 {
+		list = make([]models.PropertyDefinition, 0, len(defs))
+		for _, def := range defs {
+			list = append(list, def)
+		}
+		sort.Slice(list, func(i, j int) bool { return list[i].Key < list[j].Key })
+
+		var schemaErrors []apierror.FieldError
+		properties, schemaErrors = applyPropertySchema(properties, list)
+		for _, fe := range schemaErrors {
+			// A value that failed to convert is already reported
+			if !propertyErrorReported(propErrors, fe.Field) {
+				fieldErrors = append(fieldErrors, fe)
+			}
+		}
+	}
+	raw.Properties = properties
+
+	// Run the same validation as POST /events. Fields already reported by
+	// the import checks are not reported twice.
+	req, parseErrors := ParseCreateEventRequest(&raw)
+	reported := make(map[string]bool, len(fieldErrors))
+	for _, fe := range fieldErrors {
+		reported[fe.Field] = true
+	}
+	for _, fe := range parseErrors {
+		if !reported[fe.Field] {
+			fieldErrors = append(fieldErrors, fe)
+		}
+	}
+
+	report := p.plan.report
+	if len(fieldErrors) > 0 {
+		report.InvalidRows++
+		if len(report.Errors) < importMaxErrors {
+			report.Errors = append(report.Errors, models.ImportRowError{Row: row, Errors: fieldErrors})
+		} else {
+			report.ErrorsTruncated = true
+		}
+		return nil
+	}
+
+	report.ValidRows++
+	req.Properties = computeProperties(req.Properties, list, req.Timestamp, req.EndDate)
+	p.plan.rows = append(p.plan.rows, importRow{row: row, newType: newType, req: req})
+	return nil
+}
+
+func propertyErrorReported(fieldErrors []apierror.FieldError, field string) bool {
+	for _, fe := range fieldErrors {
+		if fe.Field == field {
+			return true
+		}
+	}
+	return false
+}
+
+// resolveEventType finds the event type of a record by ID or case-insensitive
+// name. It returns the event type ID, or a placeholder for an event type the
+// import will create. A record without any event type returns an empty ID,
+// which validation reports as missing.
+func (p *importPlanner) resolveEventType(record importer.Record) (ref, newType, name string, fieldErr *apierror.FieldError) {
+	var candidates []string
+	switch value := record[p.mapping.EventType].(type) {
+	case map[string]interface{}:
+		// The event type object of a JSON export
+		candidates = append(candidates, stringValue(value["id"]), stringValue(value["name"]))
+	default:
+		candidates = append(candidates, stringValue(value))
+	}
+	candidates = append(candidates, stringField(record, "event_type_id"), p.mapping.DefaultEventType)
+
+	var given string
+	for _, candidate := range candidates {
+		candidate = strings.TrimSpace(candidate)
+		if candidate == "" {
+			continue
+		}
+		if et, ok := p.typesByID[candidate]; ok {
+			return et.ID, "", et.Name, nil
+		}
+		if et, ok := p.typesByName[strings.ToLower(candidate)]; ok {
+			return et.ID, "", et.Name, nil
+		}
+		if given == "" {
+			given = candidate
+		}
+	}
+
+	if given == "" {
+		return "", "", "", nil
+	}
+
+	lower := strings.ToLower(given)
+	if p.mapping.CreateEventTypes {
+		if !p.newTypes[lower] {
+			p.newTypes[lower] = true
+			p.plan.newTypes = append(p.plan.newTypes, given)
+		}
+		return "new:" + lower, lower, given, nil
+	}
+
+	// Keep a non-empty ID so the missing event type is reported only once
+	return given, "", "", &apierror.FieldError{
+		Field:   "event_type_id",
+		Message: fmt.Sprintf("no event type named %q", given),
+		Code:    "not_found",
+	}
+}
+
+// timeField reads a timestamp field as RFC 3339, converting it with the
+// mapping's layout if one is set
+func (p *importPlanner) timeField(record importer.Record, field string) (string, error) {
+	value := strings.TrimSpace(stringField(record, field))
+	if value == "" || p.mapping.TimestampLayout == "" {
+		return value, nil
+	}
+
+	t, err := time.Parse(p.mapping.TimestampLayout, value)
+	if err != nil {
+		return value, fmt.Errorf("must match the layout %q", p.mapping.TimestampLayout)
+	}
+	return t.Format(time.RFC3339), nil
+}
+
+// importProperty is a property value read from a record
+type importProperty struct {
+	key   string
+	label string
+	value interface{}
+	hint  models.PropertyType // Type recorded in the file, if any
+}
+
+// planProperties reads and type-checks the properties of a record. With
+// explicit property mappings only the mapped fields are read. Otherwise the
+// properties object of a JSON export, the other_properties column of a CSV
+// export, and any column matching a property definition are read.
+func (p *importPlanner) planProperties(record importer.Record, typeRef, newType, typeName string) (map[string]models.PropertyValue, []apierror.FieldError, error) {
+	defs, err := p.definitions(typeRef, newType)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	var props []importProperty
+	if len(p.mapping.Properties) > 0 {
+		for field, key := range p.mapping.Properties {
+			if value, ok := record[field]; ok && value != "" {
+				props = append(props, importProperty{key: key, label: field, value: value})
+			}
+		}
+	} else {
+		props = append(props, embeddedProperties(record["properties"])...)
+		if encoded := stringField(record, "other_properties"); encoded != "" {
+			var decoded interface{}
+			dec := json.NewDecoder(strings.NewReader(encoded))
+			dec.UseNumber()
+			if err := dec.Decode(&decoded); err == nil {
+				props = append(props, embeddedProperties(decoded)...)
+			}
+		}
+
+		for field, value := range record {
+			if importEventFields[field] || p.isMappedField(field) {
+				continue
+			}
+			if key, ok := matchPropertyColumn(field, defs); ok {
+				props = append(props, importProperty{key: key, label: field, value: value})
+				p.mapped[field] = true
+			} else if key := propertyKey(field); p.mapping.CreatePropertyDefinitions && key != "" {
+				props = append(props, importProperty{key: key, label: field, value: value})
+				p.mapped[field] = true
+			} else {
+				p.unmapped[field] = true
+			}
+		}
+	}
+
+	if len(props) == 0 {
+		return nil, nil, nil
+	}
+
+	// Sort for stable error order; map iteration above is random
+	sort.Slice(props, func(i, j int) bool { return props[i].key < props[j].key })
+
+	properties := make(map[string]models.PropertyValue, len(props))
+	var fieldErrors []apierror.FieldError
+	for _, prop := range props {
+		propertyType := p.propertyType(prop, defs, typeRef, newType, typeName)
+
+		value, ok := coercePropertyValue(prop.value, propertyType)
+		if !ok {
+			fieldErrors = append(fieldErrors, apierror.FieldError{
+				Field:   "properties." + prop.key,
+				Message: fmt.Sprintf("must be a %s value", propertyType),
+				Code:    "invalid_type",
+			})
+			continue
+		}
+		properties[prop.key] = models.PropertyValue{Type: propertyType, Value: value}
+	}
+
+	return properties, fieldErrors, nil
+}
+
+// propertyType returns the type a property value must have: that of its
+// definition, existing or planned, or else the type recorded in the file or
+// inferred from the value. A missing definition is planned if the mapping
+// asks for it.
+func (p *importPlanner) propertyType(prop importProperty, defs map[string]models.PropertyDefinition, typeRef, newType, typeName string) models.PropertyType {
+	if def, ok := defs[prop.key]; ok {
+		return def.PropertyType
+	}
+
+	defKey := typeRef + "\x00" + prop.key
+	if i, ok := p.newDefs[defKey]; ok {
+		return p.plan.newDefs[i].propertyType
+	}
+
+	propertyType := prop.hint
+	if propertyType == "" {
+		propertyType = inferPropertyType(prop.value)
+	}
+
+	if p.mapping.CreatePropertyDefinitions && typeRef != "" && (newType != "" || p.typesByID[typeRef] != nil) {
+		def := importDefinition{
+			newType:      newType,
+			typeName:     typeName,
+			key:          prop.key,
+			label:        prop.label,
+			propertyType: propertyType,
+		}
+		if newType == "" {
+			def.eventTypeID = typeRef
+		}
+		p.newDefs[defKey] = len(p.plan.newDefs)
+		p.plan.newDefs = append(p.plan.newDefs, def)
+	}
+
+	return propertyType
+}
+
+// definitions returns the property definitions of an existing event type by
+// key. Planned and unknown event types have none.
+func (p *importPlanner) definitions(typeRef, newType string) (map[string]models.PropertyDefinition, error) {
+	if newType != "" || p.typesByID[typeRef] == nil {
+		return nil, nil
+	}
+	if defs, ok := p.defs[typeRef]; ok {
+		return defs, nil
+	}
+
+	list, err := p.propertyDefRepo.GetByEventTypeID(p.ctx, typeRef)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get property definitions: %w", err)
+	}
+
+	defs := make(map[string]models.PropertyDefinition, len(list))
+	for _, def := range list {
+		defs[def.Key] = def
+	}
+	p.defs[typeRef] = defs
+	return defs, nil
+}
+
+func (p *importPlanner) isMappedField(field string) bool {
+	m := p.mapping
+	switch field {
+	case m.EventType, m.Timestamp, m.EndDate, m.IsAllDay, m.Notes,
+		m.LocationName, m.LocationLatitude, m.LocationLongitude:
+		return true
+	}
+	return false
+}
+
+// embeddedProperties reads a properties object, whose values are either
+// {"type", "value"} pairs as in a JSON export or plain values
+func embeddedProperties(v interface{}) []importProperty {
+	object, ok := v.(map[string]interface{})
+	if !ok {
+		return nil
+	}
+
+	props := make([]importProperty, 0, len(object))
+	for key, value := range object {
+		prop := importProperty{key: key, label: key, value: value}
+		if pair, ok := value.(map[string]interface{}); ok {
+			if _, hasValue := pair["value"]; hasValue {
+				prop.value = pair["value"]
+				if t := models.PropertyType(stringValue(pair["type"])); isImportablePropertyType(t) {
+					prop.hint = t
+				}
+			}
+		}
+		if prop.value == nil || prop.value == "" {
+			continue
+		}
+		props = append(props, prop)
+	}
+	return props
+}
+
+// matchPropertyColumn matches a column header to a property definition by
+// key, by label, or by the "Label (key)" header of a CSV export
+func matchPropertyColumn(header string, defs map[string]models.PropertyDefinition) (string, bool) {
+	if _, ok := defs[header]; ok {
+		return header, true
+	}
+	for key, def := range defs {
+		if strings.EqualFold(header, def.Label) || header == fmt.Sprintf("%s (%s)", def.Label, key) {
+			return key, true
+		}
+	}
+	return "", false
+}
+
+// propertyKey derives a property key from a column header, e.g. "Heart Rate"
+// becomes "heart_rate"
+func propertyKey(header string) string {
+	var b strings.Builder
+	pendingSeparator := false
+	for _, r := range strings.ToLower(header) {
+		if (r >= 'a' && r <= 'z') || (r >= '0' && r <= '9') {
+			if pendingSeparator && b.Len() > 0 {
+				b.WriteByte('_')
+			}
+			pendingSeparator = false
+			b.WriteRune(r)
+		} else {
+			pendingSeparator = true
+		}
+	}
+	return b.String()
+}
+
+func isImportablePropertyType(t models.PropertyType) bool {
+	switch t {
+	case models.PropertyTypeText, models.PropertyTypeNumber, models.PropertyTypeBoolean,
+		models.PropertyTypeDate, models.PropertyTypeSelect, models.PropertyTypeDuration,
+		models.PropertyTypeURL, models.PropertyTypeEmail:
+		return true
+	}
+	return false
+}
+
+// inferPropertyType picks boolean, number or text for a value
+func inferPropertyType(v interface{}) models.PropertyType {
+	switch v := v.(type) {
+	case bool:
+		return models.PropertyTypeBoolean
+	case json.Number, float64:
+		return models.PropertyTypeNumber
+	case string:
+		s := strings.TrimSpace(v)
+		if strings.EqualFold(s, "true") || strings.EqualFold(s, "false") {
+			return models.PropertyTypeBoolean
+		}
+		if _, err := strconv.ParseFloat(s, 64); err == nil {
+			return models.PropertyTypeNumber
+		}
+	}
+	return models.PropertyTypeText
+}
+
+// coercePropertyValue converts a decoded value to the representation of a
+// property type
+func coercePropertyValue(v interface{}, t models.PropertyType) (interface{}, bool) {
+	switch t {
+	case models.PropertyTypeNumber, models.PropertyTypeDuration, models.PropertyTypeRating:
+		switch v := v.(type) {
+		case float64:
+			return v, true
+		case json.Number:
+			f, err := v.Float64()
+			return f, err == nil
+		case string:
+			f, err := strconv.ParseFloat(strings.TrimSpace(v), 64)
+			return f, err == nil
+		}
+		return nil, false
+	case models.PropertyTypeBoolean:
+		switch v := v.(type) {
+		case bool:
+			return v, true
+		case string:
+			b, err := strconv.ParseBool(strings.TrimSpace(v))
+			return b, err == nil
+		}
+		return nil, false
+	case models.PropertyTypeMultiSelect:
+		if values, ok := models.ParseMultiSelect(v); ok {
+			return values, true
+		}
+		// A CSV export joins the options with "; "
+		var values []string
+		for _, s := range strings.Split(stringValue(v), ";") {
+			if s = strings.TrimSpace(s); s != "" {
+				values = append(values, s)
+			}
+		}
+		return values, len(values) > 0
+	case models.PropertyTypeQuantity:
+		q, ok := models.ParseQuantity(v)
+		return q, ok
+	case models.PropertyTypeLocation:
+		l, ok := models.ParseLocation(v)
+		return l, ok
+	default:
+		s := stringValue(v)
+		return s, s != ""
+	}
+}
+
+// stringField returns a record field as a string, or "" if it is missing or
+// not a scalar
+func stringField(record importer.Record, field string) string {
+	return stringValue(record[field])
+}
+
+func stringValue(v interface{}) string {
+	switch v := v.(type) {
+	case string:
+		return v
+	case json.Number:
+		return v.String()
+	case float64:
+		return strconv.FormatFloat(v, 'f', -1, 64)
+	case bool:
+		return strconv.FormatBool(v)
+	default:
+		return ""
+	}
+}
+
+func optionalString(s string) *string {
+	if s == "" {
+		return nil
+	}
+	return &s
+}
@@ -0,0 +1,132 @@
+package service
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/JonnyWalker81/trendy/backend/internal/models"
+	"github.com/JonnyWalker81/trendy/backend/internal/repository/memory"
+)
+
+func TestImportDryRunAndJob(t *testing.T) {
+	ctx := context.Background()
+	repos := memory.NewRepositories(memory.NewStore())
+	userID := "user-1"
+
+	eventTypeService := NewEventTypeService(repos.EventTypes, repos.Events, repos.PropertyDefinitions, repos.Geofences, repos.Insights, repos.Streaks, repos.ChangeLog, repos.Transactor)
+	propertyDefService := NewPropertyDefinitionService(repos.PropertyDefinitions, repos.EventTypes, repos.Events, repos.ChangeLog, repos.Transactor)
+	importService := NewImportService(repos.Events, repos.EventTypes, repos.PropertyDefinitions, repos.Geofences, repos.OnboardingStatus, repos.ImportJobs, repos.ChangeLog, repos.Transactor)
+
+	run, err := eventTypeService.CreateEventType(ctx, userID, &models.CreateEventTypeRequest{Name: "Run", Color: "#0f0", Icon: "run"})
+	if err != nil {
+		t.Fatalf("CreateEventType failed: %v", err)
+	}
+	if _, err := propertyDefService.CreatePropertyDefinition(ctx, userID, &models.CreatePropertyDefinitionRequest{
+		EventTypeID: run.ID, Key: "distance", Label: "Distance", PropertyType: models.PropertyTypeNumber,
+	}); err != nil {
+		t.Fatalf("CreatePropertyDefinition failed: %v", err)
+	}
+
+	file := strings.Join([]string{
+		"Activity,When,Distance,Mood",
+		"run,2026-01-02 08:00,5.5,good",
+		"Swim,2026-01-03 09:00,,great",
+		"Run,yesterday,far,",
+		",2026-01-04 10:00,3,",
+	}, "\n")
+	mapping := &models.ImportMapping{
+		EventType:       "Activity",
+		Timestamp:       "When",
+		TimestampLayout: "2006-01-02 15:04",
+	}
+
+	report, err := importService.DryRun(ctx, userID, models.ImportFormatCSV, mapping, strings.NewReader(file))
+	if err != nil {
+		t.Fatalf("DryRun failed: %v", err)
+	}
+	if report.TotalRows != 4 || report.ValidRows != 1 || report.InvalidRows != 3 {
+		t.Fatalf("unexpected report counts %+v", report)
+	}
+	if len(report.UnmappedFields) != 1 || report.UnmappedFields[0] != "Mood" {
+		t.Errorf("expected Mood to be unmapped, got %v", report.UnmappedFields)
+	}
+
+	// Row 3 reports both bad fields at once; row 4 falls through to the
+	// required check of POST /events
+	codes := make(map[int][]string)
+	for _, rowErr := range report.Errors {
+		for _, fe := range rowErr.Errors {
+			codes[rowErr.Row] = append(codes[rowErr.Row], fe.Field+":"+fe.Code)
+		}
+	}
+	if strings.Join(codes[2], ",") != "event_type_id:not_found" {
+		t.Errorf("unexpected row 2 errors %v", codes[2])
+	}
+	if strings.Join(codes[3], ",") != "timestamp:invalid_format,properties.distance:invalid_type" {
+		t.Errorf("unexpected row 3 errors %v", codes[3])
+	}
+	if strings.Join(codes[4], ",") != "event_type_id:required" {
+		t.Errorf("unexpected row 4 errors %v", codes[4])
+	}
+
+	if events, _ := repos.Events.GetByUserID(ctx, userID, 100, 0); len(events) != 0 {
+		t.Fatalf("dry run must not write events, found %d", len(events))
+	}
+
+	mapping.CreateEventTypes = true
+	mapping.CreatePropertyDefinitions = true
+	report, err = importService.DryRun(ctx, userID, models.ImportFormatCSV, mapping, strings.NewReader(file))
+	if err != nil {
+		t.Fatalf("DryRun failed: %v", err)
+	}
+	if report.ValidRows != 2 || len(report.EventTypesToCreate) != 1 || report.EventTypesToCreate[0] != "Swim" {
+		t.Fatalf("expected Swim to be created, got %+v", report)
+	}
+	if strings.Join(report.PropertyDefinitionsToCreate, ",") != "Run.mood,Swim.mood" {
+		t.Errorf("unexpected definitions to create %v", report.PropertyDefinitionsToCreate)
+	}
+
+	job, err := importService.StartImport(ctx, userID, models.ImportFormatCSV, mapping, strings.NewReader(file))
+	if err != nil {
+		t.Fatalf("StartImport failed: %v", err)
+	}
+
+	deadline := time.Now().Add(5 * time.Second)
+	for job.Status != models.ImportJobCompleted && job.Status != models.ImportJobFailed {
+		if time.Now().After(deadline) {
+			t.Fatalf("import did not finish, last status %s", job.Status)
+		}
+		time.Sleep(10 * time.Millisecond)
+		if job, err = importService.GetImportJob(ctx, userID, job.ID); err != nil {
+			t.Fatalf("GetImportJob failed: %v", err)
+		}
+	}
+
+	if job.Status != models.ImportJobCompleted || job.CreatedCount != 2 || job.FailedCount != 2 || job.ProcessedRows != 4 {
+		t.Fatalf("unexpected job %+v", job)
+	}
+	if len(job.Errors) != 2 || job.CompletedAt == nil {
+		t.Errorf("expected 2 row errors and a completion time, got %+v", job)
+	}
+	if _, err := importService.GetImportJob(ctx, "user-2", job.ID); err == nil {
+		t.Error("another user must not see the job")
+	}
+
+	events, err := repos.Events.GetByUserID(ctx, userID, 100, 0)
+	if err != nil {
+		t.Fatalf("GetByUserID failed: %v", err)
+	}
+	if len(events) != 2 {
+		t.Fatalf("expected 2 imported events, got %d", len(events))
+	}
+	for _, event := range events {
+		if event.SourceType != importSourceType || event.Properties["mood"].Value == nil {
+			t.Errorf("unexpected imported event %+v", event)
+		}
+		if event.EventTypeID == run.ID && event.Properties["distance"].Value != 5.5 {
+			t.Errorf("expected distance 5.5, got %+v", event.Properties["distance"])
+		}
+	}
+}
@@ -36,28 +36,38 @@ type intelligenceService struct {
 	eventRepo     repository.EventRepository
 	eventTypeRepo repository.EventTypeRepository
 	insightRepo   repository.InsightRepository
-	aggregateRepo repository.DailyAggregateRepository
+	aggregates    AggregateService
 	streakRepo    repository.StreakRepository
+	settingsRepo  repository.UserSettingsRepository
+	jobs          JobService
 }
 
-// NewIntelligenceService creates a new intelligence service
+// NewIntelligenceService creates a new intelligence service. Insights are
+// recomputed by insights jobs on jobs; if it is nil they are computed on the
+// request path instead.
 func NewIntelligenceService(
 	eventRepo repository.EventRepository,
 	eventTypeRepo repository.EventTypeRepository,
 	insightRepo repository.InsightRepository,
-	aggregateRepo repository.DailyAggregateRepository,
+	aggregates AggregateService,
 	streakRepo repository.StreakRepository,
+	settingsRepo repository.UserSettingsRepository,
+	jobs JobService,
 ) IntelligenceService {
 	return &intelligenceService{
 		eventRepo:     eventRepo,
 		eventTypeRepo: eventTypeRepo,
 		insightRepo:   insightRepo,
-		aggregateRepo: aggregateRepo,
+		aggregates:    aggregates,
 		streakRepo:    streakRepo,
+		settingsRepo:  settingsRepo,
+		jobs:          jobs,
 	}
 }
 
-// GetInsights returns all insights for a user, computing if necessary
+// GetInsights returns all insights for a user. Stale insights are recomputed
+// in the background while the previous results are returned, or computed
+// first if there are no background jobs.
 func (s *intelligenceService) GetInsights(ctx context.Context, userID string) (*models.InsightsResponse, error) {
 	// Check for valid cached insights
 	cachedInsights, err := s.insightRepo.GetValidByUserID(ctx, userID)
@@ -70,6 +80,25 @@ func (s *intelligenceService) GetInsights(ctx context.Context, userID string) (*
 		return s.buildInsightsResponse(cachedInsights)
 	}
 
+	if s.jobs != nil {
+		previous, err := s.insightRepo.GetByUserID(ctx, userID)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get previous insights: %w", err)
+		}
+
+		refreshing, err := s.scheduleRecompute(ctx, userID, len(previous) > 0)
+		if err != nil {
+			return nil, err
+		}
+
+		response, err := s.buildInsightsResponse(previous)
+		if err != nil {
+			return nil, err
+		}
+		response.Refreshing = refreshing
+		return response, nil
+	}
+
 	// Otherwise, compute new insights
 	if err := s.ComputeInsights(ctx, userID); err != nil {
 		return nil, fmt.Errorf("failed to compute insights: %w", err)
@@ -91,16 +120,32 @@ func (s *intelligenceService) ComputeInsights(ctx context.Context, userID string
 		return fmt.Errorf("failed to delete existing insights: %w", err)
 	}
 
-	// Get all events for the user (last 90 days for correlation analysis)
-	endDate := time.Now()
+	// Days, weeks and hours are the user's own
+	cal, err := loadCalendar(ctx, s.settingsRepo, userID, models.CalendarOptions{})
+	if err != nil {
+		return fmt.Errorf("failed to get user settings: %w", err)
+	}
+
+	// Daily aggregates of the last 90 days, brought up to date with the
+	// changes since the last refresh. While some changes are too recent to
+	// apply, the days are counted from the events instead.
+	endDate := cal.date(time.Now())
 	startDate := endDate.AddDate(0, 0, -90)
 
-	events, err := s.eventRepo.GetByUserIDAndDateRange(ctx, userID, startDate, endDate)
+	if _, err := s.aggregates.Sync(ctx, userID); err != nil {
+		return fmt.Errorf("failed to sync daily aggregates: %w", err)
+	}
+	aggregates, ok, err := s.aggregates.GetDailyAggregates(ctx, userID, cal.loc.String(), startDate, endDate)
 	if err != nil {
-		return fmt.Errorf("failed to get events: %w", err)
+		return fmt.Errorf("failed to get daily aggregates: %w", err)
+	}
+	if !ok {
+		if aggregates, err = loadDailyAggregates(ctx, s.eventRepo, userID, cal, startDate, endDate); err != nil {
+			return err
+		}
 	}
 
-	if len(events) == 0 {
+	if len(aggregates) == 0 {
 		return nil // No events, nothing to compute
 	}
 
@@ -110,22 +155,15 @@ func (s *intelligenceService) ComputeInsights(ctx context.Context, userID string
 		return fmt.Errorf("failed to get event types: %w", err)
 	}
 
-	// Build daily aggregates
-	aggregates := s.buildDailyAggregates(events, userID)
-
-	// Store daily aggregates
-	if err := s.aggregateRepo.BulkUpsert(ctx, aggregates); err != nil {
-		return fmt.Errorf("failed to store daily aggregates: %w", err)
-	}
-
 	// Compute correlations
 	correlationInsights := s.computeCorrelations(ctx, userID, aggregates, eventTypes)
+	correlationInsights = append(correlationInsights, computePropertyCorrelations(userID, aggregates, eventTypes)...)
 
 	// Compute streaks
-	streakInsights := s.computeStreaks(ctx, userID, events, eventTypes)
+	streakInsights := s.computeStreaks(ctx, userID, aggregates, eventTypes, cal)
 
 	// Compute time patterns
-	patternInsights := s.computeTimePatterns(ctx, userID, events, eventTypes)
+	patternInsights := s.computeTimePatterns(ctx, userID, aggregates, eventTypes)
 
 	// Combine all insights
 	allInsights := make([]models.Insight, 0)
@@ -143,18 +181,93 @@ func (s *intelligenceService) ComputeInsights(ctx context.Context, userID string
 	return nil
 }
 
-// RefreshIfStale checks if insights are stale and recomputes if necessary
+// RefreshIfStale checks if insights are stale and recomputes if necessary,
+// in the background when there are background jobs
 func (s *intelligenceService) RefreshIfStale(ctx context.Context, userID string) error {
 	validInsights, err := s.insightRepo.GetValidByUserID(ctx, userID)
 	if err != nil {
 		return fmt.Errorf("failed to check cached insights: %w", err)
 	}
 
-	if len(validInsights) == 0 {
-		return s.ComputeInsights(ctx, userID)
+	if len(validInsights) > 0 {
+		return nil
 	}
 
-	return nil
+	if s.jobs != nil {
+		previous, err := s.insightRepo.GetByUserID(ctx, userID)
+		if err != nil {
+			return fmt.Errorf("failed to get previous insights: %w", err)
+		}
+		_, err = s.scheduleRecompute(ctx, userID, len(previous) > 0)
+		return err
+	}
+
+	return s.ComputeInsights(ctx, userID)
+}
+
+// scheduleRecompute queues a recompute of stale insights unless one is
+// already pending or running, and reports whether one is. A user without
+// insights whose last recompute succeeded within the cache duration has no
+// data to compute them from; their next event change queues a recompute.
+func (s *intelligenceService) scheduleRecompute(ctx context.Context, userID string, hasInsights bool) (bool, error) {
+	latest, err := s.jobs.GetLatest(ctx, models.JobKindInsights, userID)
+	if err != nil {
+		return false, err
+	}
+
+	if latest != nil {
+		switch latest.Status {
+		case models.JobPending, models.JobRunning:
+			return true, nil
+		case models.JobSucceeded:
+			if !hasInsights && latest.CompletedAt != nil && time.Since(*latest.CompletedAt) < InsightCacheDuration {
+				return false, nil
+			}
+		}
+	}
+
+	if _, err := s.jobs.Schedule(ctx, models.JobKindInsights, userID, 0); err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
+// RequestRefresh queues an immediate recompute of the user's insights, or
+// recomputes them now if there are no background jobs
+func (s *intelligenceService) RequestRefresh(ctx context.Context, userID string) (*models.Job, error) {
+	if s.jobs == nil {
+		return nil, s.ComputeInsights(ctx, userID)
+	}
+	return s.jobs.Schedule(ctx, models.JobKindInsights, userID, 0)
+}
+
+// GetStatus reports when the user's insights were computed, whether they are
+// stale and the state of their latest recompute job
+func (s *intelligenceService) GetStatus(ctx context.Context, userID string) (*models.InsightsStatus, error) {
+	insights, err := s.insightRepo.GetByUserID(ctx, userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get insights: %w", err)
+	}
+
+	status := &models.InsightsStatus{}
+	now := time.Now()
+	for _, insight := range insights {
+		if status.ComputedAt == nil || insight.ComputedAt.After(*status.ComputedAt) {
+			computedAt := insight.ComputedAt
+			status.ComputedAt = &computedAt
+		}
+		if !insight.ValidUntil.After(now) {
+			status.Stale = true
+		}
+	}
+
+	if s.jobs != nil {
+		if status.Job, err = s.jobs.GetLatest(ctx, models.JobKindInsights, userID); err != nil {
+			return nil, err
+		}
+	}
+
+	return status, nil
 }
 
 // InvalidateInsights marks all insights as stale (called when events change)
@@ -162,14 +275,19 @@ func (s *intelligenceService) InvalidateInsights(ctx context.Context, userID str
 	return s.insightRepo.InvalidateAll(ctx, userID)
 }
 
-// GetWeeklySummary returns week-over-week comparison
-func (s *intelligenceService) GetWeeklySummary(ctx context.Context, userID string) ([]models.WeeklySummary, error) {
+// GetWeeklySummary returns week-over-week comparison, with weeks in the
+// user's time zone starting on their week start
+func (s *intelligenceService) GetWeeklySummary(ctx context.Context, userID string, opts models.CalendarOptions) ([]models.WeeklySummary, error) {
+	cal, err := loadCalendar(ctx, s.settingsRepo, userID, opts)
+	if err != nil {
+		return nil, err
+	}
+
 	now := time.Now()
-	thisWeekStart := now.AddDate(0, 0, -int(now.Weekday()))
-	lastWeekStart := thisWeekStart.AddDate(0, 0, -7)
-	twoWeeksAgo := lastWeekStart.AddDate(0, 0, -7)
+	thisWeekStart := cal.startOfWeek(now)
+	lastWeekStart := cal.addDays(thisWeekStart, -7)
 
-	events, err := s.eventRepo.GetByUserIDAndDateRange(ctx, userID, twoWeeksAgo, now)
+	events, err := s.eventRepo.GetByUserIDAndDateRange(ctx, userID, lastWeekStart, now)
 	if err != nil {
 		return nil, fmt.Errorf("failed to get events: %w", err)
 	}
@@ -294,35 +412,81 @@ func calculateConsistency(distribution []float64) float64 {
 // Helper Methods
 // =============================================================================
 
-// buildDailyAggregates creates daily aggregates from events
-func (s *intelligenceService) buildDailyAggregates(events []models.Event, userID string) []models.DailyAggregate {
-	// Group events by date and event type
-	aggregateMap := make(map[string]*models.DailyAggregate) // key: "date|eventTypeID"
-
-	for _, event := range events {
-		dateStr := event.Timestamp.Format("2006-01-02")
-		key := fmt.Sprintf("%s|%s", dateStr, event.EventTypeID)
-
-		if agg, exists := aggregateMap[key]; exists {
-			agg.EventCount++
-		} else {
-			date, _ := time.Parse("2006-01-02", dateStr)
-			aggregateMap[key] = &models.DailyAggregate{
-				UserID:      userID,
-				Date:        date,
-				EventTypeID: event.EventTypeID,
-				EventCount:  1,
-			}
+// aggregatePropertyValue adds a property value to its day's aggregate. It
+// returns false for types that are not aggregated.
+func aggregatePropertyValue(prop models.PropAgg, value models.PropertyValue) (models.PropAgg, bool) {
+	addOption := func(option string) {
+		if prop.Options == nil {
+			prop.Options = make(map[string]int)
 		}
+		prop.Options[option]++
 	}
 
-	// Convert map to slice
-	aggregates := make([]models.DailyAggregate, 0, len(aggregateMap))
-	for _, agg := range aggregateMap {
-		aggregates = append(aggregates, *agg)
+	switch value.Type {
+	case models.PropertyTypeNumber, models.PropertyTypeDuration, models.PropertyTypeRating:
+		n, ok := propertyNumber(value.Value)
+		if !ok {
+			return prop, false
+		}
+		return addPropertyStat(prop, n), true
+	case models.PropertyTypeQuantity:
+		q, ok := models.ParseQuantity(value.Value)
+		if !ok {
+			return prop, false
+		}
+		// Statistics are in the unit of the day's first value
+		if prop.Unit == "" {
+			prop.Unit = q.Unit
+		}
+		q, err := models.ConvertQuantity(q, prop.Unit)
+		if err != nil {
+			return prop, false
+		}
+		return addPropertyStat(prop, q.Amount), true
+	case models.PropertyTypeSelect:
+		s, ok := value.Value.(string)
+		if !ok {
+			return prop, false
+		}
+		prop.Count++
+		addOption(s)
+		return prop, true
+	case models.PropertyTypeMultiSelect:
+		values, ok := models.ParseMultiSelect(value.Value)
+		if !ok {
+			return prop, false
+		}
+		prop.Count++
+		for _, v := range values {
+			addOption(v)
+		}
+		return prop, true
+	case models.PropertyTypeLocation:
+		l, ok := models.ParseLocation(value.Value)
+		if !ok {
+			return prop, false
+		}
+		prop.Count++
+		if l.Name != "" {
+			addOption(l.Name)
+		}
+		return prop, true
 	}
+	return prop, false
+}
 
-	return aggregates
+// addPropertyStat adds a number to the statistics of a property aggregate
+func addPropertyStat(prop models.PropAgg, n float64) models.PropAgg {
+	if prop.Count == 0 {
+		prop.Min, prop.Max = n, n
+	} else {
+		prop.Min = math.Min(prop.Min, n)
+		prop.Max = math.Max(prop.Max, n)
+	}
+	prop.Sum += n
+	prop.Count++
+	prop.Avg = prop.Sum / float64(prop.Count)
+	return prop
 }
 
 // computeCorrelations calculates correlations between event types
@@ -451,8 +615,125 @@ func (s *intelligenceService) computeCorrelations(ctx context.Context, userID st
 	return insights
 }
 
+// computePropertyCorrelations correlates the daily average of each numeric
+// property, computed or not, with the daily counts of the other event types
+// on the days the property has a value
+func computePropertyCorrelations(userID string, aggregates []models.DailyAggregate, eventTypes []models.EventType) []models.Insight {
+	if len(eventTypes) < 2 {
+		return nil
+	}
+
+	// Map: eventTypeID -> property key -> date -> daily average
+	propertySeries := make(map[string]map[string]map[string]float64)
+	// Map: eventTypeID -> date -> count
+	counts := make(map[string]map[string]int)
+	for _, agg := range aggregates {
+		dateStr := agg.Date.Format("2006-01-02")
+		if counts[agg.EventTypeID] == nil {
+			counts[agg.EventTypeID] = make(map[string]int)
+		}
+		counts[agg.EventTypeID][dateStr] = agg.EventCount
+
+		for key, prop := range agg.PropertyAggregates {
+			if prop.Options != nil || prop.Count == 0 {
+				continue // Not numeric
+			}
+			if propertySeries[agg.EventTypeID] == nil {
+				propertySeries[agg.EventTypeID] = make(map[string]map[string]float64)
+			}
+			if propertySeries[agg.EventTypeID][key] == nil {
+				propertySeries[agg.EventTypeID][key] = make(map[string]float64)
+			}
+			propertySeries[agg.EventTypeID][key][dateStr] = prop.Avg
+		}
+	}
+
+	insights := make([]models.Insight, 0)
+	now := time.Now()
+	validUntil := now.Add(InsightCacheDuration)
+
+	for _, etA := range eventTypes {
+		keys := make([]string, 0, len(propertySeries[etA.ID]))
+		for key := range propertySeries[etA.ID] {
+			keys = append(keys, key)
+		}
+		sort.Strings(keys)
+
+		for _, key := range keys {
+			series := propertySeries[etA.ID][key]
+			if len(series) < MinDaysForCorrelation {
+				continue
+			}
+			dates := make([]string, 0, len(series))
+			for d := range series {
+				dates = append(dates, d)
+			}
+			sort.Strings(dates)
+
+			for _, etB := range eventTypes {
+				if etB.ID == etA.ID {
+					continue
+				}
+
+				xValues := make([]float64, len(dates))
+				yValues := make([]float64, len(dates))
+				for k, date := range dates {
+					xValues[k] = series[date]
+					yValues[k] = float64(counts[etB.ID][date])
+				}
+
+				r, pValue, err := calculatePearsonCorrelation(xValues, yValues)
+				if err != nil || math.Abs(r) < CorrelationThresholdLow || pValue > PValueThresholdLow {
+					continue
+				}
+
+				direction := models.DirectionNeutral
+				if r > 0 {
+					direction = models.DirectionPositive
+				} else if r < 0 {
+					direction = models.DirectionNegative
+				}
+
+				nameA := fmt.Sprintf("%s %s", etA.Name, key)
+				etA, etB, key := etA, etB, key
+				insights = append(insights, models.Insight{
+					UserID:       userID,
+					InsightType:  models.InsightTypeCorrelation,
+					Category:     models.InsightCategoryProperty,
+					Title:        fmt.Sprintf("%s and %s", nameA, etB.Name),
+					Description:  buildCorrelationDescription(nameA, etB.Name, r, direction),
+					EventTypeAID: &etA.ID,
+					EventTypeBID: &etB.ID,
+					PropertyKey:  &key,
+					MetricValue:  r,
+					PValue:       &pValue,
+					SampleSize:   len(dates),
+					Confidence:   determineConfidence(r, pValue, len(dates)),
+					Direction:    direction,
+					ComputedAt:   now,
+					ValidUntil:   validUntil,
+					EventTypeA:   &etA,
+					EventTypeB:   &etB,
+				})
+			}
+		}
+	}
+
+	// Sort by absolute correlation value (strongest first)
+	sort.Slice(insights, func(i, j int) bool {
+		return math.Abs(insights[i].MetricValue) > math.Abs(insights[j].MetricValue)
+	})
+
+	// Limit to top 10 correlations
+	if len(insights) > 10 {
+		insights = insights[:10]
+	}
+
+	return insights
+}
+
 // computeStreaks calculates current and longest streaks for each event type
-func (s *intelligenceService) computeStreaks(ctx context.Context, userID string, events []models.Event, eventTypes []models.EventType) []models.Insight {
+func (s *intelligenceService) computeStreaks(ctx context.Context, userID string, aggregates []models.DailyAggregate, eventTypes []models.EventType, cal calendar) []models.Insight {
 	insights := make([]models.Insight, 0)
 	now := time.Now()
 	validUntil := now.Add(InsightCacheDuration)
@@ -465,7 +746,7 @@ func (s *intelligenceService) computeStreaks(ctx context.Context, userID string,
 
 	// Calculate streaks for each event type
 	for _, et := range eventTypes {
-		current, longest := calculateStreaksForEventType(events, et.ID)
+		current, longest := calculateStreaksForEventType(aggregates, et.ID, cal)
 
 		// Save current streak if active
 		if current.Length > 0 {
@@ -539,14 +820,15 @@ func (s *intelligenceService) computeStreaks(ctx context.Context, userID string,
 	return insights
 }
 
-// calculateStreaksForEventType finds current and longest streaks
-func calculateStreaksForEventType(events []models.Event, eventTypeID string) (current, longest models.Streak) {
-	// Get unique dates for this event type
-	eventDates := make(map[string]bool)
-	for _, e := range events {
-		if e.EventTypeID == eventTypeID {
-			dateStr := e.Timestamp.Format("2006-01-02")
-			eventDates[dateStr] = true
+// calculateStreaksForEventType finds current and longest streaks of local
+// days with at least one event
+func calculateStreaksForEventType(aggregates []models.DailyAggregate, eventTypeID string, cal calendar) (current, longest models.Streak) {
+	// Get unique dates for this event type. Dates are midnight UTC, so
+	// consecutive ones are exactly 24 hours apart.
+	eventDates := make(map[time.Time]bool)
+	for _, agg := range aggregates {
+		if agg.EventTypeID == eventTypeID && agg.EventCount > 0 {
+			eventDates[agg.Date] = true
 		}
 	}
 
@@ -556,9 +838,8 @@ func calculateStreaksForEventType(events []models.Event, eventTypeID string) (cu
 
 	// Sort dates
 	dates := make([]time.Time, 0, len(eventDates))
-	for dateStr := range eventDates {
-		t, _ := time.Parse("2006-01-02", dateStr)
-		dates = append(dates, t)
+	for date := range eventDates {
+		dates = append(dates, date)
 	}
 	sort.Slice(dates, func(i, j int) bool { return dates[i].Before(dates[j]) })
 
@@ -598,7 +879,7 @@ func calculateStreaksForEventType(events []models.Event, eventTypeID string) (cu
 	}
 
 	// Check if current streak is still active (last event within 48 hours)
-	today := time.Now().Truncate(24 * time.Hour)
+	today := cal.date(time.Now())
 	lastEventDate := dates[len(dates)-1]
 	isActive := today.Sub(lastEventDate).Hours() <= 48
 
@@ -624,7 +905,7 @@ func calculateStreaksForEventType(events []models.Event, eventTypeID string) (cu
 }
 
 // computeTimePatterns analyzes time-of-day and day-of-week patterns
-func (s *intelligenceService) computeTimePatterns(ctx context.Context, userID string, events []models.Event, eventTypes []models.EventType) []models.Insight {
+func (s *intelligenceService) computeTimePatterns(ctx context.Context, userID string, aggregates []models.DailyAggregate, eventTypes []models.EventType) []models.Insight {
 	insights := make([]models.Insight, 0)
 	now := time.Now()
 	validUntil := now.Add(InsightCacheDuration)
@@ -636,20 +917,22 @@ func (s *intelligenceService) computeTimePatterns(ctx context.Context, userID st
 	}
 
 	for _, et := range eventTypes {
-		// Filter events for this type
-		typeEvents := make([]models.Event, 0)
-		for _, e := range events {
-			if e.EventTypeID == et.ID {
-				typeEvents = append(typeEvents, e)
+		// Filter aggregates for this type
+		typeAggregates := make([]models.DailyAggregate, 0)
+		eventCount := 0
+		for _, agg := range aggregates {
+			if agg.EventTypeID == et.ID {
+				typeAggregates = append(typeAggregates, agg)
+				eventCount += agg.EventCount
 			}
 		}
 
-		if len(typeEvents) < MinEventsForPattern {
+		if eventCount < MinEventsForPattern {
 			continue
 		}
 
 		// Day of week pattern
-		dowPattern := calculateDayOfWeekPattern(typeEvents)
+		dowPattern := calculateDayOfWeekPattern(typeAggregates)
 		if dowPattern.Consistency > 0.3 {
 			etID := et.ID
 			insight := models.Insight{
@@ -660,8 +943,8 @@ func (s *intelligenceService) computeTimePatterns(ctx context.Context, userID st
 				Description:  fmt.Sprintf("You're most consistent with %s on %s (%.0f%% of sessions)", et.Name, dowPattern.PeakLabel, dowPattern.PeakPercent),
 				EventTypeAID: &etID,
 				MetricValue:  dowPattern.Consistency,
-				SampleSize:   len(typeEvents),
-				Confidence:   determinePatternConfidence(dowPattern.Consistency, len(typeEvents)),
+				SampleSize:   eventCount,
+				Confidence:   determinePatternConfidence(dowPattern.Consistency, eventCount),
 				Direction:    models.DirectionNeutral,
 				ComputedAt:   now,
 				ValidUntil:   validUntil,
@@ -679,7 +962,7 @@ func (s *intelligenceService) computeTimePatterns(ctx context.Context, userID st
 		}
 
 		// Hour of day pattern
-		hourPattern := calculateHourPattern(typeEvents)
+		hourPattern := calculateHourPattern(typeAggregates)
 		if hourPattern.Consistency > 0.3 {
 			etID := et.ID
 			insight := models.Insight{
@@ -690,8 +973,8 @@ func (s *intelligenceService) computeTimePatterns(ctx context.Context, userID st
 				Description:  fmt.Sprintf("You usually do %s around %s (%.0f%% of sessions)", et.Name, hourPattern.PeakLabel, hourPattern.PeakPercent),
 				EventTypeAID: &etID,
 				MetricValue:  hourPattern.Consistency,
-				SampleSize:   len(typeEvents),
-				Confidence:   determinePatternConfidence(hourPattern.Consistency, len(typeEvents)),
+				SampleSize:   eventCount,
+				Confidence:   determinePatternConfidence(hourPattern.Consistency, eventCount),
 				Direction:    models.DirectionNeutral,
 				ComputedAt:   now,
 				ValidUntil:   validUntil,
@@ -712,15 +995,16 @@ func (s *intelligenceService) computeTimePatterns(ctx context.Context, userID st
 	return insights
 }
 
-// calculateDayOfWeekPattern analyzes day-of-week distribution
-func calculateDayOfWeekPattern(events []models.Event) models.TimePattern {
+// calculateDayOfWeekPattern analyzes the distribution over local days of the
+// week. The distribution always starts on Sunday, whatever the week start.
+func calculateDayOfWeekPattern(aggregates []models.DailyAggregate) models.TimePattern {
 	dayCounts := make([]float64, 7)
 	total := 0
 
-	for _, event := range events {
-		day := int(event.Timestamp.Weekday())
-		dayCounts[day]++
-		total++
+	for _, agg := range aggregates {
+		day := int(agg.Date.Weekday())
+		dayCounts[day] += float64(agg.EventCount)
+		total += agg.EventCount
 	}
 
 	if total == 0 {
@@ -755,15 +1039,16 @@ func calculateDayOfWeekPattern(events []models.Event) models.TimePattern {
 	}
 }
 
-// calculateHourPattern analyzes hour-of-day distribution
-func calculateHourPattern(events []models.Event) models.TimePattern {
+// calculateHourPattern analyzes the distribution over local hours of the day
+func calculateHourPattern(aggregates []models.DailyAggregate) models.TimePattern {
 	hourCounts := make([]float64, 24)
 	total := 0
 
-	for _, event := range events {
-		hour := event.Timestamp.Hour()
-		hourCounts[hour]++
-		total++
+	for _, agg := range aggregates {
+		for hour, count := range agg.HourCounts[:min(len(agg.HourCounts), 24)] {
+			hourCounts[hour] += float64(count)
+			total += count
+		}
 	}
 
 	if total == 0 {
@@ -2,6 +2,7 @@ package service
 
 import (
 	"context"
+	"io"
 	"time"
 
 	"github.com/JonnyWalker81/trendy/backend/internal/models"
@@ -15,25 +16,48 @@ type EventService interface {
 	CreateEventsBatch(ctx context.Context, userID string, req *models.BatchCreateEventsRequest) (*models.BatchCreateEventsResponse, error)
 	GetEvent(ctx context.Context, userID, eventID string) (*models.Event, error)
 	GetUserEvents(ctx context.Context, userID string, limit, offset int) ([]models.Event, error)
-	ExportEvents(ctx context.Context, userID string, startDate, endDate *time.Time, eventTypeIDs []string) ([]models.Event, error)
+	// ListEvents returns one page of the user's events matching the query
+	ListEvents(ctx context.Context, userID string, query *EventListQuery) (*models.EventPage, error)
+	// SearchEvents returns one page of the user's events matching a full-text
+	// search, best match first
+	SearchEvents(ctx context.Context, userID string, query *EventSearchQuery) (*models.EventSearchResponse, error)
 	UpdateEvent(ctx context.Context, userID, eventID string, req *models.UpdateEventRequest) (*models.Event, error)
 	DeleteEvent(ctx context.Context, userID, eventID string) error
+	// RestoreEvent takes a deleted event out of the trash
+	RestoreEvent(ctx context.Context, userID, eventID string) (*models.Event, error)
 }
 
 // EventTypeService defines the interface for event type business logic
 type EventTypeService interface {
 	CreateEventType(ctx context.Context, userID string, req *models.CreateEventTypeRequest) (*models.EventType, error)
 	GetEventType(ctx context.Context, userID, eventTypeID string) (*models.EventType, error)
-	GetUserEventTypes(ctx context.Context, userID string) ([]models.EventType, error)
+	// GetUserEventTypes lists the user's event types, leaving out archived
+	// ones unless includeArchived is set
+	GetUserEventTypes(ctx context.Context, userID string, includeArchived bool) ([]models.EventType, error)
 	UpdateEventType(ctx context.Context, userID, eventTypeID string, req *models.UpdateEventTypeRequest) (*models.EventType, error)
-	DeleteEventType(ctx context.Context, userID, eventTypeID string) error
+	// DeleteEventType deletes an event type using the strategy in req; a nil
+	// req moves it and its events to the trash
+	DeleteEventType(ctx context.Context, userID, eventTypeID string, req *models.DeleteEventTypeRequest) error
+	// MergeEventType moves everything recorded against sourceID into
+	// targetID and moves the source to the trash
+	MergeEventType(ctx context.Context, userID, sourceID, targetID string) (*models.MergeEventTypeResponse, error)
+	// RestoreEventType takes a deleted event type and the events deleted
+	// with it out of the trash
+	RestoreEventType(ctx context.Context, userID, eventTypeID string) (*models.EventType, error)
 }
 
 // AnalyticsService defines the interface for analytics business logic
 type AnalyticsService interface {
 	GetSummary(ctx context.Context, userID string) (*models.AnalyticsSummary, error)
-	GetTrends(ctx context.Context, userID string, period string, startDate, endDate time.Time) ([]models.TrendData, error)
-	GetEventTypeAnalytics(ctx context.Context, userID, eventTypeID string, period string, startDate, endDate time.Time) (*models.TrendData, error)
+	// GetTrends and GetEventTypeAnalytics bucket events by the query's
+	// granularity in the user's calendar, with opts overriding their stored
+	// settings. Invalid queries fail with ErrInvalidTrendQuery.
+	GetTrends(ctx context.Context, userID string, query *models.TrendQuery, opts models.CalendarOptions) ([]models.TrendData, error)
+	GetEventTypeAnalytics(ctx context.Context, userID, eventTypeID string, query *models.TrendQuery, opts models.CalendarOptions) (*models.TrendData, error)
+	// GetPropertyAnalytics summarizes the values of one property of an event
+	// type per bucket and over the whole range. query.Property and
+	// query.Percentiles are ignored.
+	GetPropertyAnalytics(ctx context.Context, userID, eventTypeID, key string, query *models.TrendQuery, bins int, opts models.CalendarOptions) (*models.PropertyAnalytics, error)
 }
 
 // AuthService defines the interface for authentication business logic
@@ -50,6 +74,10 @@ type PropertyDefinitionService interface {
 	GetPropertyDefinitionsByEventType(ctx context.Context, userID, eventTypeID string) ([]models.PropertyDefinition, error)
 	UpdatePropertyDefinition(ctx context.Context, userID, propertyDefID string, req *models.UpdatePropertyDefinitionRequest) (*models.PropertyDefinition, error)
 	DeletePropertyDefinition(ctx context.Context, userID, propertyDefID string) error
+	// MigratePropertyDefinition changes the type or key of a definition and
+	// converts the values stored on events. When values block the migration
+	// it returns the report with ErrUnconvertibleProperties.
+	MigratePropertyDefinition(ctx context.Context, userID, propertyDefID string, req *models.MigratePropertyDefinitionRequest) (*models.MigratePropertyDefinitionResponse, error)
 }
 
 // GeofenceService defines the interface for geofence business logic
@@ -68,13 +96,90 @@ type IntelligenceService interface {
 	ComputeInsights(ctx context.Context, userID string) error
 	RefreshIfStale(ctx context.Context, userID string) error
 	InvalidateInsights(ctx context.Context, userID string) error
-	GetWeeklySummary(ctx context.Context, userID string) ([]models.WeeklySummary, error)
+	GetWeeklySummary(ctx context.Context, userID string, opts models.CalendarOptions) ([]models.WeeklySummary, error)
 	GetStreaks(ctx context.Context, userID string) ([]models.Streak, error)
+	// RequestRefresh queues an immediate recompute of the user's insights and
+	// returns its job. Without background jobs it recomputes them before
+	// returning, and the job is nil.
+	RequestRefresh(ctx context.Context, userID string) (*models.Job, error)
+	// GetStatus reports how current the user's insights are and the state of
+	// their latest recompute job
+	GetStatus(ctx context.Context, userID string) (*models.InsightsStatus, error)
 }
 
 // SyncService provides sync status information for clients
 type SyncService interface {
 	GetSyncStatus(ctx context.Context, userID string) (*SyncStatus, error)
+	// GetSnapshot returns every synced entity and the cursor to resume from
+	GetSnapshot(ctx context.Context, userID string) (*SyncSnapshot, error)
+}
+
+// SyncPushService applies batches of offline mutations from sync clients
+type SyncPushService interface {
+	Push(ctx context.Context, userID string, req *models.SyncPushRequest) (*models.SyncPushResponse, error)
+}
+
+// ChangeLogCompactor removes superseded and expired change log entries
+type ChangeLogCompactor interface {
+	Run(ctx context.Context) (*CompactionResult, error)
+}
+
+// ExportService streams a user's events in an export format
+type ExportService interface {
+	// ExportEvents writes the events matching req to w, newest first. Nothing
+	// is written if the first read fails.
+	ExportEvents(ctx context.Context, userID string, req *models.ExportEventsRequest, w io.Writer) error
+}
+
+// TrashService lists deleted items and purges them once they expire
+type TrashService interface {
+	GetTrash(ctx context.Context, userID string) (*models.Trash, error)
+	Purge(ctx context.Context) (*PurgeResult, error)
+}
+
+// AggregateService keeps daily aggregates up to date from the change log
+type AggregateService interface {
+	// Sync applies the change log entries since the user's aggregate cursor,
+	// recomputing only the days they touch. Entries newer than the settle
+	// window are left for a later sync. Aggregates that were never built,
+	// are in another time zone, or whose entries were pruned are rebuilt.
+	Sync(ctx context.Context, userID string) (*AggregateSyncResult, error)
+	// Backfill rebuilds every daily aggregate of the user from their events
+	Backfill(ctx context.Context, userID string) (*AggregateSyncResult, error)
+	// GetDailyAggregates returns the stored aggregates of local dates from
+	// startDate to endDate inclusive without syncing them. It returns false
+	// if the aggregates' days are not in timezone or the aggregates are
+	// behind the change log.
+	GetDailyAggregates(ctx context.Context, userID, timezone string, startDate, endDate time.Time) ([]models.DailyAggregate, bool, error)
+}
+
+// JobService queues background jobs and runs them on a pool of workers
+type JobService interface {
+	// Handle registers the handler of a job kind. Call it before Run.
+	Handle(kind models.JobKind, handler JobHandler)
+	// Schedule queues a job to run after delay. If the user already has a
+	// pending job of the kind, it is moved to the new time instead.
+	Schedule(ctx context.Context, kind models.JobKind, userID string, delay time.Duration) (*models.Job, error)
+	// Debounce queues a job to run once there have been no calls for the
+	// same kind and user for the debounce period. It never blocks; Run writes
+	// the job to the queue.
+	Debounce(kind models.JobKind, userID string)
+	// GetLatest returns the most recent job of a kind for userID, or nil if
+	// there is none
+	GetLatest(ctx context.Context, kind models.JobKind, userID string) (*models.Job, error)
+	// Run claims and runs due jobs until ctx is done
+	Run(ctx context.Context)
+}
+
+// ChangeLogRelay appends change log entries for writes recorded in the change
+// outbox whose entry was never written
+type ChangeLogRelay interface {
+	Relay(ctx context.Context) (int, error)
+}
+
+// ChangeLogReconciler backfills change log entries missing for synced entities
+type ChangeLogReconciler interface {
+	Reconcile(ctx context.Context, userID string, dryRun bool) (*ReconcileResult, error)
 }
 
 // OnboardingService defines the interface for onboarding business logic
@@ -83,3 +188,44 @@ type OnboardingService interface {
 	UpdateOnboardingStatus(ctx context.Context, userID string, req *models.UpdateOnboardingStatusRequest) (*models.OnboardingStatus, error)
 	ResetOnboardingStatus(ctx context.Context, userID string) (*models.OnboardingStatus, error)
 }
+
+// UserSettingsService manages a user's calendar settings
+type UserSettingsService interface {
+	// GetSettings returns the user's settings, or the defaults if they have
+	// saved none
+	GetSettings(ctx context.Context, userID string) (*models.UserSettings, error)
+	UpdateSettings(ctx context.Context, userID string, req *models.UpdateUserSettingsRequest) (*models.UserSettings, error)
+}
+
+// ImportService validates import files and imports their events in the background
+type ImportService interface {
+	// DryRun validates every row of an import file without writing anything
+	DryRun(ctx context.Context, userID string, format models.ImportFormat, mapping *models.ImportMapping, r io.Reader) (*models.ImportReport, error)
+	// StartImport validates an import file and starts a job importing its
+	// valid rows. The file is read before StartImport returns.
+	StartImport(ctx context.Context, userID string, format models.ImportFormat, mapping *models.ImportMapping, r io.Reader) (*models.ImportJob, error)
+	GetImportJob(ctx context.Context, userID, jobID string) (*models.ImportJob, error)
+	// StartRestore checks an account archive and starts a job restoring it
+	// into the user's account
+	StartRestore(ctx context.Context, userID string, archive []byte) (*models.ImportJob, error)
+}
+
+// AccountDeletionService erases accounts and everything they own
+type AccountDeletionService interface {
+	// DeleteAccount erases the user's data and auth user, resuming a deletion
+	// that was interrupted, and returns the completed tombstone
+	DeleteAccount(ctx context.Context, userID string) (*models.AccountDeletion, error)
+	// ResumePending finishes deletions left incomplete, e.g. by a restart,
+	// and returns how many completed
+	ResumePending(ctx context.Context) (int, error)
+}
+
+// AccountExportService defines the interface for account data archives
+type AccountExportService interface {
+	// StartExport starts building an archive of everything the user owns,
+	// replacing any earlier export
+	StartExport(ctx context.Context, userID string) (*models.AccountExport, error)
+	GetExport(ctx context.Context, userID, exportID string) (*models.AccountExport, error)
+	// GetArchive returns a completed, unexpired export and its zip archive
+	GetArchive(ctx context.Context, userID, exportID string) (*models.AccountExport, []byte, error)
+}
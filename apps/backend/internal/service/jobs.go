@@ -0,0 +1,314 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/JonnyWalker81/trendy/backend/internal/logger"
+	"github.com/JonnyWalker81/trendy/backend/internal/models"
+	"github.com/JonnyWalker81/trendy/backend/internal/repository"
+)
+
+// MaxJobRetryDelay caps the exponential backoff between retries of a job
+const MaxJobRetryDelay = time.Hour
+
+// errJobAbandoned fails a job whose worker stopped before it finished
+var errJobAbandoned = errors.New("job was abandoned by a stopped worker")
+
+// JobHandler does the work of one job. A returned error fails the run, which
+// is retried with backoff until the job's attempts are used up.
+type JobHandler func(ctx context.Context, job *models.Job) error
+
+// JobOptions tunes how background jobs are run
+type JobOptions struct {
+	Workers         int           // Jobs run at once
+	PollInterval    time.Duration // How often due jobs are claimed
+	Debounce        time.Duration // Quiet period after the last Debounce call before its job runs
+	MaxWait         time.Duration // Longest a queued job can be moved back by later calls; zero means no limit
+	MaxAttempts     int           // Runs before a failing job is given up
+	RetryBackoff    time.Duration // Delay before the first retry, doubling for each further one
+	Timeout         time.Duration // Longest a run may take; runs twice as old are presumed abandoned
+	MaintenanceHour int           // Hour of the day, in UTC, of the nightly maintenance job
+}
+
+type debounceKey struct {
+	kind   models.JobKind
+	userID string
+}
+
+type jobService struct {
+	jobRepo  repository.JobRepository
+	opts     JobOptions
+	handlers map[models.JobKind]JobHandler
+
+	mu        sync.Mutex
+	debounced map[debounceKey]struct{}
+	wake      chan struct{}
+}
+
+// NewJobService creates a job service that runs jobs from jobRepo. Register
+// a handler for each job kind with Handle, then start the workers with Run.
+func NewJobService(jobRepo repository.JobRepository, opts JobOptions) JobService {
+	return &jobService{
+		jobRepo:   jobRepo,
+		opts:      opts,
+		handlers:  make(map[models.JobKind]JobHandler),
+		debounced: make(map[debounceKey]struct{}),
+		wake:      make(chan struct{}, 1),
+	}
+}
+
+func (s *jobService) Handle(kind models.JobKind, handler JobHandler) {
+	s.handlers[kind] = handler
+}
+
+func (s *jobService) Schedule(ctx context.Context, kind models.JobKind, userID string, delay time.Duration) (*models.Job, error) {
+	job, err := s.jobRepo.Enqueue(ctx, &models.Job{Kind: kind, UserID: userID, RunAt: time.Now().Add(delay)}, s.opts.MaxWait)
+	if err != nil {
+		return nil, fmt.Errorf("failed to schedule job: %w", err)
+	}
+	if delay <= 0 {
+		s.signal()
+	}
+	return job, nil
+}
+
+func (s *jobService) Debounce(kind models.JobKind, userID string) {
+	s.mu.Lock()
+	s.debounced[debounceKey{kind: kind, userID: userID}] = struct{}{}
+	s.mu.Unlock()
+	s.signal()
+}
+
+func (s *jobService) GetLatest(ctx context.Context, kind models.JobKind, userID string) (*models.Job, error) {
+	job, err := s.jobRepo.GetLatest(ctx, kind, userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get job: %w", err)
+	}
+	return job, nil
+}
+
+// signal wakes Run without blocking
+func (s *jobService) signal() {
+	select {
+	case s.wake <- struct{}{}:
+	default:
+		// A wake-up is already pending
+	}
+}
+
+// Run claims due jobs and runs them on up to Workers goroutines until ctx is
+// done. Jobs still running at shutdown are left running in the queue and
+// retried once they are presumed abandoned.
+func (s *jobService) Run(ctx context.Context) {
+	s.recoverAbandoned(ctx)
+	s.scheduleMaintenance(ctx)
+
+	slots := make(chan struct{}, s.opts.Workers)
+	var wg sync.WaitGroup
+	defer wg.Wait()
+
+	poll := time.NewTicker(s.opts.PollInterval)
+	defer poll.Stop()
+	sweep := time.NewTicker(s.opts.Timeout)
+	defer sweep.Stop()
+
+	for {
+		s.flushDebounced(ctx)
+		s.dispatch(ctx, slots, &wg)
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-poll.C:
+		case <-s.wake:
+		case <-sweep.C:
+			s.recoverAbandoned(ctx)
+			s.scheduleMaintenance(ctx)
+		}
+	}
+}
+
+// flushDebounced writes the jobs of Debounce calls to the queue. Each write
+// moves the pending job back to a full debounce period from now.
+func (s *jobService) flushDebounced(ctx context.Context) {
+	s.mu.Lock()
+	keys := s.debounced
+	s.debounced = make(map[debounceKey]struct{})
+	s.mu.Unlock()
+
+	for key := range keys {
+		if _, err := s.Schedule(ctx, key.kind, key.userID, s.opts.Debounce); err != nil {
+			logger.FromContext(ctx).Error("failed to debounce job",
+				logger.String("kind", string(key.kind)),
+				logger.String("user_id", key.userID),
+				logger.Err(err),
+			)
+		}
+	}
+}
+
+// dispatch claims as many due jobs as there are idle workers and starts them
+func (s *jobService) dispatch(ctx context.Context, slots chan struct{}, wg *sync.WaitGroup) {
+	idle := cap(slots) - len(slots)
+	if idle == 0 {
+		return
+	}
+
+	jobs, err := s.jobRepo.Claim(ctx, idle)
+	if err != nil {
+		logger.FromContext(ctx).Error("failed to claim jobs", logger.Err(err))
+		return
+	}
+
+	for _, job := range jobs {
+		slots <- struct{}{}
+		wg.Add(1)
+		go func() {
+			defer func() {
+				<-slots
+				wg.Done()
+				// Claim the next job as soon as a worker is free
+				s.signal()
+			}()
+			s.run(ctx, &job)
+		}()
+	}
+}
+
+// run runs one claimed job and records its outcome
+func (s *jobService) run(ctx context.Context, job *models.Job) {
+	log := logger.FromContext(ctx).With(
+		logger.String("job_id", job.ID),
+		logger.String("kind", string(job.Kind)),
+		logger.Int("attempt", job.Attempts),
+	)
+
+	err := fmt.Errorf("no handler for job kind %q", job.Kind)
+	if handler, ok := s.handlers[job.Kind]; ok {
+		runCtx, cancel := context.WithTimeout(ctx, s.opts.Timeout)
+		err = handler(runCtx, job)
+		cancel()
+	}
+
+	// A run cut short by shutdown is retried once it is presumed abandoned
+	if ctx.Err() != nil {
+		return
+	}
+
+	if err := s.finish(ctx, job, err); err != nil {
+		log.Error("failed to record job result", logger.Err(err))
+	}
+}
+
+// finish records the outcome of a run. A failed job is retried after a
+// backoff as a new pending job until its attempts are used up.
+func (s *jobService) finish(ctx context.Context, job *models.Job, runErr error) error {
+	log := logger.FromContext(ctx).With(
+		logger.String("job_id", job.ID),
+		logger.String("kind", string(job.Kind)),
+		logger.Int("attempt", job.Attempts),
+	)
+
+	completedAt := time.Now()
+	job.Status = models.JobSucceeded
+	job.LastError = nil
+	job.CompletedAt = &completedAt
+	if runErr != nil {
+		message := runErr.Error()
+		job.Status = models.JobFailed
+		job.LastError = &message
+	}
+
+	if _, err := s.jobRepo.Finish(ctx, job); err != nil {
+		return err
+	}
+
+	if runErr != nil && job.Attempts < s.opts.MaxAttempts {
+		delay := s.retryDelay(job.Attempts)
+		retry := &models.Job{
+			Kind:      job.Kind,
+			UserID:    job.UserID,
+			Attempts:  job.Attempts,
+			RunAt:     completedAt.Add(delay),
+			LastError: job.LastError,
+		}
+		if _, err := s.jobRepo.Enqueue(ctx, retry, 0); err != nil {
+			return fmt.Errorf("failed to queue retry: %w", err)
+		}
+		log.Warn("job failed, retrying", logger.Err(runErr), logger.Duration("delay", delay))
+		return nil
+	}
+
+	if runErr != nil {
+		log.Error("job failed", logger.Err(runErr))
+	}
+
+	// Whatever the outcome, maintenance runs again the next night
+	if job.Kind == models.JobKindMaintenance {
+		s.scheduleMaintenance(ctx)
+	}
+
+	return nil
+}
+
+// retryDelay returns the backoff before retrying a job that has run attempts
+// times
+func (s *jobService) retryDelay(attempts int) time.Duration {
+	delay := s.opts.RetryBackoff
+	for i := 1; i < attempts && delay < MaxJobRetryDelay; i++ {
+		delay *= 2
+	}
+	return min(delay, MaxJobRetryDelay)
+}
+
+// recoverAbandoned fails the jobs that have been running for twice the
+// timeout, whose worker must have stopped, so they are retried
+func (s *jobService) recoverAbandoned(ctx context.Context) {
+	log := logger.FromContext(ctx)
+
+	jobs, err := s.jobRepo.GetStale(ctx, time.Now().Add(-2*s.opts.Timeout))
+	if err != nil {
+		log.Error("failed to get abandoned jobs", logger.Err(err))
+		return
+	}
+
+	for _, job := range jobs {
+		if err := s.finish(ctx, &job, errJobAbandoned); err != nil {
+			log.Error("failed to recover abandoned job", logger.String("job_id", job.ID), logger.Err(err))
+		}
+	}
+}
+
+// scheduleMaintenance queues the next nightly maintenance job unless one is
+// already pending or running
+func (s *jobService) scheduleMaintenance(ctx context.Context) {
+	log := logger.FromContext(ctx)
+
+	latest, err := s.jobRepo.GetLatest(ctx, models.JobKindMaintenance, "")
+	if err != nil {
+		log.Error("failed to get maintenance job", logger.Err(err))
+		return
+	}
+	if latest != nil && (latest.Status == models.JobPending || latest.Status == models.JobRunning) {
+		return
+	}
+
+	runAt := nextMaintenance(time.Now(), s.opts.MaintenanceHour)
+	if _, err := s.jobRepo.Enqueue(ctx, &models.Job{Kind: models.JobKindMaintenance, RunAt: runAt}, 0); err != nil {
+		log.Error("failed to schedule maintenance job", logger.Err(err))
+	}
+}
+
+// nextMaintenance returns the first time after now at hour o'clock UTC
+func nextMaintenance(now time.Time, hour int) time.Time {
+	now = now.UTC()
+	next := time.Date(now.Year(), now.Month(), now.Day(), hour, 0, 0, 0, time.UTC)
+	if !next.After(now) {
+		next = next.AddDate(0, 0, 1)
+	}
+	return next
+}
@@ -0,0 +1,217 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/JonnyWalker81/trendy/backend/internal/models"
+	"github.com/JonnyWalker81/trendy/backend/internal/repository/memory"
+)
+
+func TestJobRetries(t *testing.T) {
+	ctx := context.Background()
+	repos := memory.NewRepositories(memory.NewStore())
+	svc := NewJobService(repos.Jobs, JobOptions{Workers: 1, Debounce: time.Hour, MaxAttempts: 2, RetryBackoff: time.Minute, Timeout: time.Minute}).(*jobService)
+
+	failures := 1
+	svc.Handle(models.JobKindInsights, func(ctx context.Context, job *models.Job) error {
+		if failures > 0 {
+			failures--
+			return errors.New("boom")
+		}
+		return nil
+	})
+	runDue := func() int {
+		t.Helper()
+		jobs, err := repos.Jobs.Claim(ctx, 10)
+		if err != nil {
+			t.Fatalf("Claim failed: %v", err)
+		}
+		for _, job := range jobs {
+			svc.run(ctx, &job)
+		}
+		return len(jobs)
+	}
+	latest := func() *models.Job {
+		t.Helper()
+		job, err := svc.GetLatest(ctx, models.JobKindInsights, "user-1")
+		if err != nil || job == nil {
+			t.Fatalf("GetLatest failed: %v, %v", job, err)
+		}
+		return job
+	}
+	// due makes a pending retry due without treating it as new work
+	due := func(job *models.Job) {
+		t.Helper()
+		if _, err := repos.Jobs.Enqueue(ctx, &models.Job{Kind: job.Kind, UserID: job.UserID, Attempts: job.Attempts, LastError: job.LastError, RunAt: time.Now()}, 0); err != nil {
+			t.Fatalf("Enqueue failed: %v", err)
+		}
+	}
+
+	// Repeated changes collapse into one pending job a debounce period away
+	svc.Debounce(models.JobKindInsights, "user-1")
+	svc.Debounce(models.JobKindInsights, "user-1")
+	svc.flushDebounced(ctx)
+	debounced := latest()
+	if time.Until(debounced.RunAt) < 59*time.Minute {
+		t.Errorf("expected the job to run in an hour, got %v", debounced.RunAt)
+	}
+	if n := runDue(); n != 0 {
+		t.Errorf("expected no due jobs while debouncing, ran %d", n)
+	}
+
+	// Scheduling now moves the same job forward
+	job, err := svc.Schedule(ctx, models.JobKindInsights, "user-1", 0)
+	if err != nil || job.ID != debounced.ID {
+		t.Fatalf("expected the pending job to be moved, got %+v, %v", job, err)
+	}
+
+	// A failed run is retried after the backoff, carrying the attempt forward
+	if n := runDue(); n != 1 {
+		t.Fatalf("expected 1 job to run, ran %d", n)
+	}
+	retry := latest()
+	if retry.Status != models.JobPending || retry.Attempts != 1 || retry.LastError == nil || *retry.LastError != "boom" {
+		t.Errorf("expected a pending retry after 1 attempt, got %+v", retry)
+	}
+	if delay := time.Until(retry.RunAt); delay < 59*time.Second || delay > time.Minute {
+		t.Errorf("expected the retry in a minute, got %v", delay)
+	}
+
+	due(retry)
+	runDue()
+	if done := latest(); done.ID != retry.ID || done.Status != models.JobSucceeded || done.Attempts != 2 || done.LastError != nil {
+		t.Errorf("expected the retry to succeed on attempt 2, got %+v", done)
+	}
+
+	// Once its attempts are used up a failing job is given up
+	failures = 2
+	if _, err := svc.Schedule(ctx, models.JobKindInsights, "user-1", 0); err != nil {
+		t.Fatalf("Schedule failed: %v", err)
+	}
+	runDue()
+	due(latest())
+	runDue()
+	if failed := latest(); failed.Status != models.JobFailed || failed.Attempts != 2 {
+		t.Errorf("expected the job to fail after 2 attempts, got %+v", failed)
+	}
+
+	// New work queued over a pending retry gets its own attempts
+	failures = 1
+	if _, err := svc.Schedule(ctx, models.JobKindInsights, "user-1", 0); err != nil {
+		t.Fatalf("Schedule failed: %v", err)
+	}
+	runDue()
+	retry = latest()
+	job, err = svc.Schedule(ctx, models.JobKindInsights, "user-1", 0)
+	if err != nil || job.ID != retry.ID || job.Attempts != 0 || job.LastError != nil {
+		t.Fatalf("expected the retry to become new work, got %+v, %v", job, err)
+	}
+
+	// A retry meeting a job queued for new work leaves it as it is
+	fresh, err := svc.Schedule(ctx, models.JobKindInsights, "user-2", time.Hour)
+	if err != nil {
+		t.Fatalf("Schedule failed: %v", err)
+	}
+	boom := "boom"
+	if _, err := repos.Jobs.Enqueue(ctx, &models.Job{Kind: models.JobKindInsights, UserID: "user-2", Attempts: 1, LastError: &boom, RunAt: time.Now().Add(time.Minute)}, 0); err != nil {
+		t.Fatalf("Enqueue failed: %v", err)
+	}
+	if kept, _ := svc.GetLatest(ctx, models.JobKindInsights, "user-2"); kept.ID != fresh.ID || kept.Attempts != 0 || kept.LastError != nil || !kept.RunAt.Equal(fresh.RunAt) {
+		t.Errorf("expected the new work's job to keep its attempts and time, got %+v", kept)
+	}
+
+	for attempts, want := range map[int]time.Duration{1: time.Minute, 3: 4 * time.Minute, 10: MaxJobRetryDelay} {
+		if got := svc.retryDelay(attempts); got != want {
+			t.Errorf("retryDelay(%d) = %v, want %v", attempts, got, want)
+		}
+	}
+
+	now := time.Date(2026, 3, 1, 4, 0, 0, 0, time.UTC)
+	if got := nextMaintenance(now, 3); !got.Equal(time.Date(2026, 3, 2, 3, 0, 0, 0, time.UTC)) {
+		t.Errorf("expected maintenance at 03:00 the next day, got %v", got)
+	}
+}
+
+func TestInsightsComputedInBackground(t *testing.T) {
+	ctx := context.Background()
+	repos := memory.NewRepositories(memory.NewStore())
+	jobs := NewJobService(repos.Jobs, JobOptions{Workers: 2, PollInterval: time.Hour, Debounce: time.Hour, MaxAttempts: 3, Timeout: time.Minute})
+	aggregates := NewAggregateService(repos.Events, repos.DailyAggregates, repos.AggregateStates, repos.ChangeLog, repos.UserSettings)
+	svc := NewIntelligenceService(repos.Events, repos.EventTypes, repos.Insights, aggregates, repos.Streaks, repos.UserSettings, jobs)
+
+	jobs.Handle(models.JobKindInsights, func(ctx context.Context, job *models.Job) error {
+		return svc.ComputeInsights(ctx, job.UserID)
+	})
+
+	// Reading insights queues a recompute instead of running it
+	response, err := svc.GetInsights(ctx, "user-1")
+	if err != nil {
+		t.Fatalf("GetInsights failed: %v", err)
+	}
+	if !response.Refreshing {
+		t.Error("expected the insights to be refreshing")
+	}
+	status, err := svc.GetStatus(ctx, "user-1")
+	if err != nil || status.Job == nil || status.Job.Status != models.JobPending {
+		t.Fatalf("expected a pending job, got %+v, %v", status, err)
+	}
+	if _, err := svc.GetInsights(ctx, "user-1"); err != nil {
+		t.Fatalf("GetInsights failed: %v", err)
+	}
+	if again, _ := svc.GetStatus(ctx, "user-1"); again.Job.ID != status.Job.ID {
+		t.Errorf("expected the pending job to be reused, got %+v", again.Job)
+	}
+
+	runCtx, cancel := context.WithCancel(ctx)
+	done := make(chan struct{})
+	go func() {
+		jobs.Run(runCtx)
+		close(done)
+	}()
+	for deadline := time.Now().Add(5 * time.Second); ; time.Sleep(10 * time.Millisecond) {
+		if status, _ := svc.GetStatus(ctx, "user-1"); status.Job.Status == models.JobSucceeded {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatal("timed out waiting for the insights job")
+		}
+	}
+	cancel()
+	<-done
+
+	// With no data to compute from, a recent recompute is not repeated
+	response, err = svc.GetInsights(ctx, "user-1")
+	if err != nil || response.Refreshing {
+		t.Errorf("expected no refresh without data, got %+v, %v", response, err)
+	}
+	if maintenance, _ := jobs.GetLatest(ctx, models.JobKindMaintenance, ""); maintenance == nil || maintenance.Status != models.JobPending {
+		t.Errorf("expected nightly maintenance to be scheduled, got %+v", maintenance)
+	}
+}
+
+func TestDebounceIsCappedByMaxWait(t *testing.T) {
+	ctx := context.Background()
+	repos := memory.NewRepositories(memory.NewStore())
+	svc := NewJobService(repos.Jobs, JobOptions{Workers: 1, Debounce: time.Hour, MaxWait: 2 * time.Hour, MaxAttempts: 2, Timeout: time.Minute}).(*jobService)
+
+	first, err := svc.Schedule(ctx, models.JobKindInsights, "user-1", time.Hour)
+	if err != nil {
+		t.Fatalf("Schedule failed: %v", err)
+	}
+
+	// Changes keep moving the job back, but not past the maximum wait
+	moved, err := svc.Schedule(ctx, models.JobKindInsights, "user-1", 90*time.Minute)
+	if err != nil || moved.ID != first.ID || !moved.RunAt.After(first.RunAt) {
+		t.Fatalf("expected the pending job to be moved back, got %+v, %v", moved, err)
+	}
+	capped, err := svc.Schedule(ctx, models.JobKindInsights, "user-1", 3*time.Hour)
+	if err != nil {
+		t.Fatalf("Schedule failed: %v", err)
+	}
+	if deadline := first.CreatedAt.Add(2 * time.Hour); !capped.RunAt.Equal(deadline) {
+		t.Errorf("expected the job to run by %v, got %v", deadline, capped.RunAt)
+	}
+}
@@ -0,0 +1,45 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/JonnyWalker81/trendy/backend/internal/models"
+	"github.com/JonnyWalker81/trendy/backend/internal/repository"
+)
+
+// maintenanceUserPageSize is how many users the nightly maintenance job reads
+// at a time
+const maintenanceUserPageSize = 100
+
+// NewMaintenanceHandler returns the handler of the nightly maintenance job.
+// It removes finished jobs older than retention and queues an insights
+// recompute for every user, so current streaks and patterns reflect the new
+// day. A zero retention keeps finished jobs forever.
+func NewMaintenanceHandler(jobs JobService, jobRepo repository.JobRepository, userRepo repository.UserRepository, retention time.Duration) JobHandler {
+	return func(ctx context.Context, job *models.Job) error {
+		if retention > 0 {
+			if err := jobRepo.DeleteFinished(ctx, time.Now().Add(-retention)); err != nil {
+				return fmt.Errorf("failed to delete finished jobs: %w", err)
+			}
+		}
+
+		// The recomputes run on the worker pool like any other job; a
+		// retried maintenance run only moves the ones still pending
+		for offset := 0; ; offset += maintenanceUserPageSize {
+			users, err := userRepo.List(ctx, maintenanceUserPageSize, offset)
+			if err != nil {
+				return fmt.Errorf("failed to list users: %w", err)
+			}
+			for _, u := range users {
+				if _, err := jobs.Schedule(ctx, models.JobKindInsights, u.ID, 0); err != nil {
+					return err
+				}
+			}
+			if len(users) < maintenanceUserPageSize {
+				return nil
+			}
+		}
+	}
+}
@@ -0,0 +1,49 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"time"
+)
+
+// ErrPreconditionFailed is returned by an update or delete whose caller's
+// precondition does not hold for the entity's current version
+var ErrPreconditionFailed = errors.New("precondition failed")
+
+type preconditionKey struct{}
+
+type precondition struct {
+	entityID string
+	holds    func(updatedAt time.Time) bool
+}
+
+// WithPrecondition makes the next update or delete of entityID through ctx
+// conditional: it fails with ErrPreconditionFailed unless holds accepts the
+// entity's version. The version is checked and claimed inside the write's
+// transaction, so no other writer can change the entity in between.
+func WithPrecondition(ctx context.Context, entityID string, holds func(updatedAt time.Time) bool) context.Context {
+	return context.WithValue(ctx, preconditionKey{}, precondition{entityID: entityID, holds: holds})
+}
+
+// checkPrecondition enforces ctx's precondition on entityID, last read at
+// version updatedAt. Called inside the write's transaction, claim confirms
+// the entity is still at that version and keeps other writers off it.
+func checkPrecondition(ctx context.Context, entityID string, updatedAt time.Time, claim func(ctx context.Context, id string, updatedAt time.Time) (bool, error)) error {
+	p, ok := ctx.Value(preconditionKey{}).(precondition)
+	if !ok || p.entityID != entityID {
+		return nil
+	}
+
+	if !p.holds(updatedAt) {
+		return ErrPreconditionFailed
+	}
+
+	claimed, err := claim(ctx, entityID, updatedAt)
+	if err != nil {
+		return err
+	}
+	if !claimed {
+		return ErrPreconditionFailed
+	}
+	return nil
+}
@@ -0,0 +1,64 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/JonnyWalker81/trendy/backend/internal/models"
+	"github.com/JonnyWalker81/trendy/backend/internal/repository/memory"
+)
+
+func TestCheckPrecondition(t *testing.T) {
+	version := time.Now()
+	claimed := func(context.Context, string, time.Time) (bool, error) { return true, nil }
+	taken := func(context.Context, string, time.Time) (bool, error) { return false, nil }
+	holds := func(time.Time) bool { return true }
+
+	tests := []struct {
+		name  string
+		ctx   context.Context
+		claim func(context.Context, string, time.Time) (bool, error)
+		want  error
+	}{
+		{"no precondition", context.Background(), taken, nil},
+		{"other entity", WithPrecondition(context.Background(), "other", holds), taken, nil},
+		{"holds and claimed", WithPrecondition(context.Background(), "id", holds), claimed, nil},
+		{"does not hold", WithPrecondition(context.Background(), "id", func(time.Time) bool { return false }), claimed, ErrPreconditionFailed},
+		{"changed since read", WithPrecondition(context.Background(), "id", holds), taken, ErrPreconditionFailed},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := checkPrecondition(tt.ctx, "id", version, tt.claim); !errors.Is(err, tt.want) {
+				t.Errorf("expected %v, got %v", tt.want, err)
+			}
+		})
+	}
+}
+
+func TestUpdateWithFailedPreconditionWritesNothing(t *testing.T) {
+	ctx := context.Background()
+	repos := memory.NewRepositories(memory.NewStore())
+	userID := "user-1"
+	service := NewEventTypeService(repos.EventTypes, repos.Events, repos.PropertyDefinitions, repos.Geofences, repos.Insights, repos.Streaks, repos.ChangeLog, repos.Transactor)
+
+	eventType, err := service.CreateEventType(ctx, userID, &models.CreateEventTypeRequest{Name: "Run", Color: "#f00", Icon: "run"})
+	if err != nil {
+		t.Fatalf("CreateEventType failed: %v", err)
+	}
+
+	stale := WithPrecondition(ctx, eventType.ID, func(updatedAt time.Time) bool {
+		return updatedAt.Before(eventType.UpdatedAt)
+	})
+	name := "Jog"
+	if _, err := service.UpdateEventType(stale, userID, eventType.ID, &models.UpdateEventTypeRequest{Name: &name}); !errors.Is(err, ErrPreconditionFailed) {
+		t.Fatalf("expected ErrPreconditionFailed, got %v", err)
+	}
+
+	current, err := repos.EventTypes.GetByID(ctx, eventType.ID)
+	if err != nil || current.Name != "Run" {
+		t.Errorf("expected the event type to be unchanged, got %+v (%v)", current, err)
+	}
+}
@@ -0,0 +1,231 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"sort"
+
+	"github.com/JonnyWalker81/trendy/backend/internal/models"
+)
+
+// Bounds of the histogram of a numeric property
+const (
+	DefaultHistogramBins = 10
+	MaxHistogramBins     = 50
+)
+
+// GetPropertyAnalytics summarizes the values of one property of an event
+// type. The property's type comes from its definition, or from the values
+// stored on events if it has none; values of another type are skipped.
+func (s *analyticsService) GetPropertyAnalytics(ctx context.Context, userID, eventTypeID, key string, query *models.TrendQuery, bins int, opts models.CalendarOptions) (*models.PropertyAnalytics, error) {
+	// Verify event type belongs to user
+	eventType, err := s.eventTypeRepo.GetByID(ctx, eventTypeID)
+	if err != nil || eventType.UserID != userID {
+		return nil, fmt.Errorf("event type not found")
+	}
+
+	if bins == 0 {
+		bins = DefaultHistogramBins
+	}
+	if bins < 1 || bins > MaxHistogramBins {
+		return nil, fmt.Errorf("%w: bins must be from 1 to %d", ErrInvalidTrendQuery, MaxHistogramBins)
+	}
+
+	bucketQuery := models.TrendQuery{Period: query.Period, Granularity: query.Granularity, StartDate: query.StartDate, EndDate: query.EndDate}
+	cal, buckets, err := s.prepareTrend(ctx, userID, &bucketQuery, opts)
+	if err != nil {
+		return nil, err
+	}
+
+	defs, err := s.propertyDefRepo.GetByEventTypeID(ctx, eventTypeID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get property definitions: %w", err)
+	}
+	var def models.PropertyDefinition
+	for _, d := range defs {
+		if d.Key == key {
+			def = d
+		}
+	}
+
+	allEvents, err := s.eventRepo.GetByUserIDAndDateRange(ctx, userID, buckets[0], query.EndDate)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get events: %w", err)
+	}
+
+	end := cal.next(bucketQuery.Granularity, buckets[len(buckets)-1])
+	events := []models.Event{}
+	for _, event := range allEvents {
+		if event.EventTypeID == eventTypeID && bucketIndex(buckets, end, event.Timestamp) >= 0 {
+			events = append(events, event)
+			if def.PropertyType == "" {
+				def.PropertyType = event.Properties[key].Type
+			}
+		}
+	}
+	if def.PropertyType == "" {
+		return nil, fmt.Errorf("property not found")
+	}
+
+	result := &models.PropertyAnalytics{
+		EventTypeID:  eventTypeID,
+		PropertyKey:  key,
+		PropertyType: def.PropertyType,
+		Granularity:  bucketQuery.Granularity,
+		StartDate:    buckets[0],
+		EndDate:      end,
+		EventCount:   len(events),
+		Series:       make([]models.PropertySeriesPoint, len(buckets)),
+	}
+	for i, bucket := range buckets {
+		result.Series[i].Date = bucket
+	}
+
+	switch def.PropertyType {
+	case models.PropertyTypeNumber, models.PropertyTypeDuration, models.PropertyTypeRating, models.PropertyTypeQuantity:
+		values := make([][]float64, len(buckets))
+		var all []float64
+		for _, event := range events {
+			value, ok := event.Properties[key]
+			if !ok || value.Type != def.PropertyType {
+				continue
+			}
+			if n, ok := trendValue(value, &result.Unit); ok {
+				i := bucketIndex(buckets, end, event.Timestamp)
+				values[i] = append(values[i], n)
+				all = append(all, n)
+			}
+		}
+
+		for i := range result.Series {
+			result.Series[i].Count = len(values[i])
+			result.Series[i].Values = propertyStats(values[i], []float64{50, 90})
+		}
+		result.Distribution = propertyDistribution(all, bins, def)
+	case models.PropertyTypeSelect, models.PropertyTypeMultiSelect, models.PropertyTypeBoolean:
+		counts := make(map[string]int)
+		for _, option := range def.Options {
+			counts[option] = 0
+		}
+		if def.PropertyType == models.PropertyTypeBoolean {
+			counts["true"], counts["false"] = 0, 0
+		}
+
+		withValue := 0
+		for _, event := range events {
+			value, ok := event.Properties[key]
+			if !ok || value.Type != def.PropertyType {
+				continue
+			}
+			categories, ok := propertyCategories(value)
+			if !ok {
+				continue
+			}
+
+			point := &result.Series[bucketIndex(buckets, end, event.Timestamp)]
+			point.Count++
+			withValue++
+			for _, category := range categories {
+				if point.Frequencies == nil {
+					point.Frequencies = make(map[string]int)
+				}
+				point.Frequencies[category]++
+				counts[category]++
+			}
+		}
+		result.Frequencies = propertyFrequencies(counts, withValue)
+	default:
+		return nil, fmt.Errorf("%w: %s properties cannot be analyzed", ErrInvalidTrendQuery, def.PropertyType)
+	}
+
+	return result, nil
+}
+
+// propertyCategories returns the categories a categorical property value
+// counts towards: its option, its options for a multi-select, or "true" or
+// "false"
+func propertyCategories(value models.PropertyValue) ([]string, bool) {
+	switch value.Type {
+	case models.PropertyTypeSelect:
+		s, ok := value.Value.(string)
+		return []string{s}, ok
+	case models.PropertyTypeMultiSelect:
+		return models.ParseMultiSelect(value.Value)
+	case models.PropertyTypeBoolean:
+		b, ok := value.Value.(bool)
+		return []string{fmt.Sprint(b)}, ok
+	}
+	return nil, false
+}
+
+// propertyFrequencies returns counts as a frequency table, most frequent
+// first
+func propertyFrequencies(counts map[string]int, withValue int) []models.PropertyFrequency {
+	frequencies := make([]models.PropertyFrequency, 0, len(counts))
+	for value, count := range counts {
+		frequency := models.PropertyFrequency{Value: value, Count: count}
+		if withValue > 0 {
+			frequency.Percent = float64(count) / float64(withValue) * 100
+		}
+		frequencies = append(frequencies, frequency)
+	}
+	sort.Slice(frequencies, func(i, j int) bool {
+		if frequencies[i].Count != frequencies[j].Count {
+			return frequencies[i].Count > frequencies[j].Count
+		}
+		return frequencies[i].Value < frequencies[j].Value
+	})
+	return frequencies
+}
+
+// propertyDistribution describes values with their median, 90th percentile
+// and a histogram, or returns nil if there are none. Ratings get one bin per
+// point of the scale; other values get bins of equal width.
+func propertyDistribution(values []float64, bins int, def models.PropertyDefinition) *models.PropertyDistribution {
+	stats := propertyStats(values, nil)
+	if stats == nil {
+		return nil
+	}
+
+	sorted := append([]float64(nil), values...)
+	sort.Float64s(sorted)
+	distribution := &models.PropertyDistribution{
+		PropertyStats: *stats,
+		Median:        percentile(sorted, 50),
+		P90:           percentile(sorted, 90),
+	}
+
+	if def.PropertyType == models.PropertyTypeRating {
+		top := max(ratingMax(def), int(stats.Max))
+		for point := 1; point <= top; point++ {
+			bin := models.HistogramBin{Min: float64(point), Max: float64(point)}
+			for _, v := range sorted {
+				if v == bin.Min {
+					bin.Count++
+				}
+			}
+			distribution.Histogram = append(distribution.Histogram, bin)
+		}
+		return distribution
+	}
+
+	if stats.Min == stats.Max {
+		distribution.Histogram = []models.HistogramBin{{Min: stats.Min, Max: stats.Max, Count: stats.Count}}
+		return distribution
+	}
+
+	width := (stats.Max - stats.Min) / float64(bins)
+	distribution.Histogram = make([]models.HistogramBin, bins)
+	for i := range distribution.Histogram {
+		distribution.Histogram[i].Min = stats.Min + float64(i)*width
+		distribution.Histogram[i].Max = stats.Min + float64(i+1)*width
+	}
+	distribution.Histogram[bins-1].Max = stats.Max
+	for _, v := range sorted {
+		i := int(math.Floor((v - stats.Min) / width))
+		distribution.Histogram[min(i, bins-1)].Count++
+	}
+
+	return distribution
+}
@@ -0,0 +1,119 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/JonnyWalker81/trendy/backend/internal/models"
+	"github.com/JonnyWalker81/trendy/backend/internal/repository/memory"
+)
+
+func TestGetPropertyAnalytics(t *testing.T) {
+	ctx := context.Background()
+	repos := memory.NewRepositories(memory.NewStore())
+	aggregates := NewAggregateService(repos.Events, repos.DailyAggregates, repos.AggregateStates, repos.ChangeLog, repos.UserSettings)
+	svc := NewAnalyticsService(repos.Events, repos.EventTypes, repos.PropertyDefinitions, repos.UserSettings, aggregates)
+
+	et, err := repos.EventTypes.Create(ctx, &models.EventType{UserID: "user-1", Name: "Run"})
+	if err != nil {
+		t.Fatalf("Create event type failed: %v", err)
+	}
+	for _, def := range []models.PropertyDefinition{
+		{Key: "distance", PropertyType: models.PropertyTypeNumber},
+		{Key: "effort", PropertyType: models.PropertyTypeRating, RatingMax: 5},
+		{Key: "route", PropertyType: models.PropertyTypeSelect, Options: []string{"park", "river", "track"}},
+		{Key: "notes", PropertyType: models.PropertyTypeText},
+	} {
+		def.EventTypeID, def.UserID, def.Label = et.ID, "user-1", def.Key
+		if _, err := repos.PropertyDefinitions.Create(ctx, &def); err != nil {
+			t.Fatalf("Create property definition failed: %v", err)
+		}
+	}
+
+	base := time.Date(2026, 5, 4, 7, 0, 0, 0, time.UTC)
+	for i, v := range []struct {
+		distance, effort float64
+		route            string
+		indoor           bool
+	}{
+		{2, 3, "park", false},
+		{4, 4, "park", true},
+		{6, 4, "river", false},
+		{8, 5, "park", false},
+		{10, 2, "river", false},
+	} {
+		_, err := repos.Events.Create(ctx, &models.Event{
+			UserID: "user-1", EventTypeID: et.ID, Timestamp: base.AddDate(0, 0, i/2).Add(time.Duration(i) * time.Hour), SourceType: "manual",
+			Properties: map[string]models.PropertyValue{
+				"distance": {Type: models.PropertyTypeNumber, Value: v.distance},
+				"effort":   {Type: models.PropertyTypeRating, Value: v.effort},
+				"route":    {Type: models.PropertyTypeSelect, Value: v.route},
+				"indoor":   {Type: models.PropertyTypeBoolean, Value: v.indoor},
+				"notes":    {Type: models.PropertyTypeText, Value: "easy"},
+			},
+		})
+		if err != nil {
+			t.Fatalf("Create event failed: %v", err)
+		}
+	}
+
+	query := func() *models.TrendQuery {
+		return &models.TrendQuery{Granularity: models.GranularityDay, StartDate: base, EndDate: base.AddDate(0, 0, 4)}
+	}
+	get := func(key string, bins int) *models.PropertyAnalytics {
+		t.Helper()
+		result, err := svc.GetPropertyAnalytics(ctx, "user-1", et.ID, key, query(), bins, models.CalendarOptions{})
+		if err != nil {
+			t.Fatalf("GetPropertyAnalytics(%s) failed: %v", key, err)
+		}
+		return result
+	}
+
+	distance := get("distance", 4)
+	if len(distance.Series) != 5 || distance.Series[0].Count != 2 || distance.Series[0].Values.Sum != 6 || distance.Series[3].Count != 0 {
+		t.Errorf("unexpected series %+v", distance.Series)
+	}
+	d := distance.Distribution
+	if d == nil || d.Count != 5 || d.Sum != 30 || d.Avg != 6 || d.Median != 6 || d.P90 != 9.2 {
+		t.Fatalf("unexpected distribution %+v", d)
+	}
+	if len(d.Histogram) != 4 || d.Histogram[0].Min != 2 || d.Histogram[0].Max != 4 || d.Histogram[0].Count != 1 || d.Histogram[3].Count != 2 {
+		t.Errorf("unexpected histogram %+v", d.Histogram)
+	}
+
+	effort := get("effort", 0)
+	var counts []int
+	for _, bin := range effort.Distribution.Histogram {
+		counts = append(counts, bin.Count)
+	}
+	if len(counts) != 5 || counts[0] != 0 || counts[1] != 1 || counts[3] != 2 {
+		t.Errorf("expected one bin per rating point, got %v", counts)
+	}
+
+	route := get("route", 0)
+	want := []models.PropertyFrequency{{Value: "park", Count: 3, Percent: 60}, {Value: "river", Count: 2, Percent: 40}, {Value: "track"}}
+	if len(route.Frequencies) != 3 || route.Frequencies[0] != want[0] || route.Frequencies[1] != want[1] || route.Frequencies[2] != want[2] {
+		t.Errorf("expected %v, got %v", want, route.Frequencies)
+	}
+	if route.Distribution != nil || route.Series[0].Frequencies["park"] != 2 {
+		t.Errorf("unexpected select series %+v", route.Series[0])
+	}
+
+	// Without a definition the type comes from the stored values
+	indoor := get("indoor", 0)
+	if indoor.PropertyType != models.PropertyTypeBoolean || indoor.Frequencies[0].Value != "false" || indoor.Frequencies[0].Count != 4 {
+		t.Errorf("unexpected boolean frequencies %+v", indoor.Frequencies)
+	}
+
+	if _, err := svc.GetPropertyAnalytics(ctx, "user-1", et.ID, "notes", query(), 0, models.CalendarOptions{}); !errors.Is(err, ErrInvalidTrendQuery) {
+		t.Errorf("expected text properties to be refused, got %v", err)
+	}
+	if _, err := svc.GetPropertyAnalytics(ctx, "user-1", et.ID, "missing", query(), 0, models.CalendarOptions{}); err == nil || err.Error() != "property not found" {
+		t.Errorf("expected property not found, got %v", err)
+	}
+	if _, err := svc.GetPropertyAnalytics(ctx, "user-2", et.ID, "distance", query(), 0, models.CalendarOptions{}); err == nil || err.Error() != "event type not found" {
+		t.Errorf("expected event type not found, got %v", err)
+	}
+}
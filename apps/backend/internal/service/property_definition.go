@@ -3,9 +3,9 @@ package service
 import (
 	"context"
 	"fmt"
+	"strings"
 	"time"
 
-	"github.com/JonnyWalker81/trendy/backend/internal/logger"
 	"github.com/JonnyWalker81/trendy/backend/internal/models"
 	"github.com/JonnyWalker81/trendy/backend/internal/repository"
 )
@@ -13,19 +13,26 @@ import (
 type propertyDefinitionService struct {
 	propertyDefRepo repository.PropertyDefinitionRepository
 	eventTypeRepo   repository.EventTypeRepository
+	eventRepo       repository.EventRepository
 	changeLogRepo   repository.ChangeLogRepository
+	tx              repository.Transactor
 }
 
-// NewPropertyDefinitionService creates a new property definition service
+// NewPropertyDefinitionService creates a new property definition service.
+// eventRepo is used to convert stored values when a definition is migrated.
 func NewPropertyDefinitionService(
 	propertyDefRepo repository.PropertyDefinitionRepository,
 	eventTypeRepo repository.EventTypeRepository,
+	eventRepo repository.EventRepository,
 	changeLogRepo repository.ChangeLogRepository,
+	tx repository.Transactor,
 ) PropertyDefinitionService {
 	return &propertyDefinitionService{
 		propertyDefRepo: propertyDefRepo,
 		eventTypeRepo:   eventTypeRepo,
+		eventRepo:       eventRepo,
 		changeLogRepo:   changeLogRepo,
+		tx:              tx,
 	}
 }
 
@@ -40,11 +47,6 @@ func (s *propertyDefinitionService) CreatePropertyDefinition(ctx context.Context
 		return nil, fmt.Errorf("event type not found")
 	}
 
-	// Validate that select type has options
-	if req.PropertyType == models.PropertyTypeSelect && (req.Options == nil || len(req.Options) == 0) {
-		return nil, fmt.Errorf("select property type requires options")
-	}
-
 	propertyDef := &models.PropertyDefinition{
 		EventTypeID:  req.EventTypeID,
 		UserID:       userID,
@@ -53,31 +55,65 @@ func (s *propertyDefinitionService) CreatePropertyDefinition(ctx context.Context
 		PropertyType: req.PropertyType,
 		Options:      req.Options,
 		DefaultValue: req.DefaultValue,
+		Required:     req.Required,
+		Unit:         req.Unit,
+		RatingMax:    req.RatingMax,
+		Formula:      strings.TrimSpace(req.Formula),
 		DisplayOrder: req.DisplayOrder,
 	}
 
+	if err := checkPropertyDefinition(*propertyDef); err != nil {
+		return nil, err
+	}
+	if propertyDef.Formula != "" {
+		siblings, err := s.propertyDefRepo.GetByEventTypeID(ctx, propertyDef.EventTypeID)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get property definitions: %w", err)
+		}
+		if err := checkFormula(*propertyDef, siblings); err != nil {
+			return nil, err
+		}
+	}
+
+	// The default is filled into events, so it must be a valid value
+	if propertyDef.DefaultValue != nil {
+		if message, code := checkPropertyValue(*propertyDef, propertyDef.DefaultValue); code != "" {
+			return nil, fmt.Errorf("default_value %s", message)
+		}
+	}
+
 	// Use client-provided ID if present (for offline-first/UUIDv7 support)
 	if req.ID != nil && *req.ID != "" {
 		propertyDef.ID = *req.ID
 	}
 
-	created, err := s.propertyDefRepo.Create(ctx, propertyDef)
+	var created *models.PropertyDefinition
+	err = s.tx.WithinTx(ctx, func(ctx context.Context) error {
+		var err error
+		created, err = s.propertyDefRepo.Create(ctx, propertyDef)
+		if err != nil {
+			return err
+		}
+
+		_, err = s.changeLogRepo.Append(ctx, &models.ChangeLogInput{
+			EntityType: models.EntityTypePropertyDefinition,
+			Operation:  models.OperationCreate,
+			EntityID:   created.ID,
+			UserID:     userID,
+			Data:       created,
+		})
+		if err != nil || created.Formula == "" {
+			return err
+		}
+
+		// Existing events get the new property's values
+		_, err = s.recomputeProperties(ctx, userID, created.EventTypeID)
+		return err
+	})
 	if err != nil {
 		return nil, err
 	}
 
-	// Append to change log
-	if _, err := s.changeLogRepo.Append(ctx, &models.ChangeLogInput{
-		EntityType: models.EntityTypePropertyDefinition,
-		Operation:  models.OperationCreate,
-		EntityID:   created.ID,
-		UserID:     userID,
-		Data:       created,
-	}); err != nil {
-		log := logger.FromContext(ctx)
-		log.Warn("failed to append to change log", logger.Err(err), logger.String("property_def_id", created.ID))
-	}
-
 	return created, nil
 }
 
@@ -120,13 +156,6 @@ func (s *propertyDefinitionService) UpdatePropertyDefinition(ctx context.Context
 		return nil, fmt.Errorf("property definition not found")
 	}
 
-	// Validate that select type has options if property type is being set to select
-	if req.PropertyType != nil && *req.PropertyType == models.PropertyTypeSelect {
-		if req.Options != nil && len(*req.Options) == 0 {
-			return nil, fmt.Errorf("select property type requires options")
-		}
-	}
-
 	// Build update object
 	update := &models.PropertyDefinition{}
 	if req.Key != nil {
@@ -144,25 +173,108 @@ func (s *propertyDefinitionService) UpdatePropertyDefinition(ctx context.Context
 	if req.DefaultValue != nil {
 		update.DefaultValue = req.DefaultValue
 	}
+	if req.Unit != nil {
+		update.Unit = *req.Unit
+	}
+	if req.RatingMax != nil {
+		update.RatingMax = *req.RatingMax
+	}
 	if req.DisplayOrder != nil {
 		update.DisplayOrder = *req.DisplayOrder
 	}
+	update.Required = existingPropertyDef.Required
+	if req.Required != nil {
+		update.Required = *req.Required
+	}
+	update.Formula = existingPropertyDef.Formula
+	if req.Formula != nil {
+		update.Formula = strings.TrimSpace(*req.Formula)
+	}
 
-	updated, err := s.propertyDefRepo.Update(ctx, propertyDefID, update)
-	if err != nil {
+	// Check the default against the definition as it will be after the update
+	merged := *existingPropertyDef
+	if update.Key != "" {
+		merged.Key = update.Key
+	}
+	if update.PropertyType != "" {
+		merged.PropertyType = update.PropertyType
+	}
+	if len(update.Options) > 0 {
+		merged.Options = update.Options
+	}
+	if update.DefaultValue != nil {
+		merged.DefaultValue = update.DefaultValue
+	}
+	if update.Unit != "" {
+		merged.Unit = update.Unit
+	}
+	if update.RatingMax > 0 {
+		merged.RatingMax = update.RatingMax
+	}
+	if req.Options != nil && len(*req.Options) == 0 {
+		merged.Options = nil
+	}
+	if err := checkPropertyDefinition(merged); err != nil {
 		return nil, err
 	}
+	// Stored quantities are in the old unit; a migration converts them
+	if existingPropertyDef.PropertyType == models.PropertyTypeQuantity && merged.PropertyType == models.PropertyTypeQuantity &&
+		merged.Unit != existingPropertyDef.Unit {
+		return nil, fmt.Errorf("changing the unit of a quantity requires a migration")
+	}
+	if merged.DefaultValue != nil {
+		if message, code := checkPropertyValue(merged, merged.DefaultValue); code != "" {
+			return nil, fmt.Errorf("default_value %s", message)
+		}
+	}
+
+	merged.Required, merged.Formula = update.Required, update.Formula
+	siblings, err := s.propertyDefRepo.GetByEventTypeID(ctx, existingPropertyDef.EventTypeID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get property definitions: %w", err)
+	}
+	if merged.Key != existingPropertyDef.Key {
+		if dependents := formulaDependents(siblings, existingPropertyDef.Key); len(dependents) > 0 {
+			return nil, fmt.Errorf("property is used by the formula of %s; rename it with a migration", strings.Join(dependents, ", "))
+		}
+	}
+	if merged.Formula != "" {
+		if err := checkFormula(merged, siblings); err != nil {
+			return nil, err
+		}
+	}
+	// Values change when the formula or the type it produces changes
+	recompute := merged.Formula != "" && (merged.Formula != existingPropertyDef.Formula ||
+		merged.PropertyType != existingPropertyDef.PropertyType || merged.Unit != existingPropertyDef.Unit)
+
+	var updated *models.PropertyDefinition
+	err = s.tx.WithinTx(ctx, func(ctx context.Context) error {
+		if err := checkPrecondition(ctx, propertyDefID, existingPropertyDef.UpdatedAt, s.propertyDefRepo.ClaimVersion); err != nil {
+			return err
+		}
 
-	// Append to change log
-	if _, err := s.changeLogRepo.Append(ctx, &models.ChangeLogInput{
-		EntityType: models.EntityTypePropertyDefinition,
-		Operation:  models.OperationUpdate,
-		EntityID:   updated.ID,
-		UserID:     userID,
-		Data:       updated,
-	}); err != nil {
-		log := logger.FromContext(ctx)
-		log.Warn("failed to append update to change log", logger.Err(err), logger.String("property_def_id", updated.ID))
+		var err error
+		updated, err = s.propertyDefRepo.Update(ctx, propertyDefID, update)
+		if err != nil {
+			return err
+		}
+
+		_, err = s.changeLogRepo.Append(ctx, &models.ChangeLogInput{
+			EntityType: models.EntityTypePropertyDefinition,
+			Operation:  models.OperationUpdate,
+			EntityID:   updated.ID,
+			UserID:     userID,
+			Data:       updated,
+		})
+		if err != nil || !recompute {
+			return err
+		}
+
+		_, err = s.recomputeProperties(ctx, userID, updated.EventTypeID)
+		return err
+	})
+	if err != nil {
+		return nil, err
 	}
 
 	return updated, nil
@@ -179,22 +291,31 @@ func (s *propertyDefinitionService) DeletePropertyDefinition(ctx context.Context
 		return fmt.Errorf("property definition not found")
 	}
 
-	if err := s.propertyDefRepo.Delete(ctx, propertyDefID); err != nil {
-		return err
+	siblings, err := s.propertyDefRepo.GetByEventTypeID(ctx, propertyDef.EventTypeID)
+	if err != nil {
+		return fmt.Errorf("failed to get property definitions: %w", err)
 	}
-
-	// Append to change log
-	now := time.Now()
-	if _, err := s.changeLogRepo.Append(ctx, &models.ChangeLogInput{
-		EntityType: models.EntityTypePropertyDefinition,
-		Operation:  models.OperationDelete,
-		EntityID:   propertyDefID,
-		UserID:     userID,
-		DeletedAt:  &now,
-	}); err != nil {
-		log := logger.FromContext(ctx)
-		log.Warn("failed to append delete to change log", logger.Err(err), logger.String("property_def_id", propertyDefID))
+	if dependents := formulaDependents(siblings, propertyDef.Key); len(dependents) > 0 {
+		return fmt.Errorf("property is used by the formula of %s", strings.Join(dependents, ", "))
 	}
 
-	return nil
+	return s.tx.WithinTx(ctx, func(ctx context.Context) error {
+		if err := checkPrecondition(ctx, propertyDefID, propertyDef.UpdatedAt, s.propertyDefRepo.ClaimVersion); err != nil {
+			return err
+		}
+
+		if err := s.propertyDefRepo.Delete(ctx, propertyDefID); err != nil {
+			return err
+		}
+
+		now := time.Now()
+		_, err := s.changeLogRepo.Append(ctx, &models.ChangeLogInput{
+			EntityType: models.EntityTypePropertyDefinition,
+			Operation:  models.OperationDelete,
+			EntityID:   propertyDefID,
+			UserID:     userID,
+			DeletedAt:  &now,
+		})
+		return err
+	})
 }
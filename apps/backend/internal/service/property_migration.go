@@ -0,0 +1,350 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"sort"
+
+	"github.com/JonnyWalker81/trendy/backend/internal/formula"
+	"github.com/JonnyWalker81/trendy/backend/internal/models"
+)
+
+var (
+	// ErrInvalidPropertyMigration indicates a migration request that cannot
+	// be applied to the property definition
+	ErrInvalidPropertyMigration = errors.New("invalid property migration")
+	// ErrUnconvertibleProperties indicates events whose values cannot be
+	// converted; the migration is refused unless they may be cleared
+	ErrUnconvertibleProperties = errors.New("some property values cannot be converted")
+)
+
+// durationUnits are the seconds per unit of a number converted to or from a
+// duration
+var durationUnits = map[string]float64{
+	"":        1,
+	"seconds": 1,
+	"minutes": 60,
+	"hours":   3600,
+}
+
+// MigratePropertyDefinition changes the type and/or key of a property
+// definition and converts the values stored on the events of its event type
+// in one transaction, logging an update for every event it touches. Values
+// that cannot be converted are reported; the migration is refused with
+// ErrUnconvertibleProperties unless req.ClearUnconvertible is set, in which
+// case they are removed. A dry run only reports.
+func (s *propertyDefinitionService) MigratePropertyDefinition(ctx context.Context, userID, propertyDefID string, req *models.MigratePropertyDefinitionRequest) (*models.MigratePropertyDefinitionResponse, error) {
+	def, err := s.propertyDefRepo.GetByID(ctx, propertyDefID)
+	if err != nil {
+		return nil, err
+	}
+	if def.UserID != userID {
+		return nil, fmt.Errorf("property definition not found")
+	}
+
+	if def.Formula != "" {
+		return nil, fmt.Errorf("%w: computed properties are recomputed by changing their formula", ErrInvalidPropertyMigration)
+	}
+
+	unit, ok := durationUnits[req.DurationUnit]
+	if !ok {
+		return nil, fmt.Errorf("%w: duration_unit must be seconds, minutes or hours", ErrInvalidPropertyMigration)
+	}
+
+	target := *def
+	if req.PropertyType != nil {
+		target.PropertyType = *req.PropertyType
+	}
+	if req.Unit != nil {
+		target.Unit = *req.Unit
+	}
+	if req.RatingMax != nil {
+		target.RatingMax = *req.RatingMax
+	}
+	siblings, err := s.propertyDefRepo.GetByEventTypeID(ctx, def.EventTypeID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get property definitions: %w", err)
+	}
+	if req.Key != nil && *req.Key != def.Key {
+		if *req.Key == "" {
+			return nil, fmt.Errorf("%w: key must not be empty", ErrInvalidPropertyMigration)
+		}
+		if slices.ContainsFunc(siblings, func(d models.PropertyDefinition) bool { return d.Key == *req.Key }) {
+			return nil, fmt.Errorf("%w: key %q is already defined on the event type", ErrInvalidPropertyMigration, *req.Key)
+		}
+		target.Key = *req.Key
+	}
+	if hasOptions(target.PropertyType) {
+		base := def.Options
+		if !hasOptions(def.PropertyType) {
+			base = nil
+		}
+		target.Options, _ = unionOptions(base, req.Options)
+	} else {
+		target.Options = nil
+	}
+	if target.PropertyType != models.PropertyTypeQuantity {
+		target.Unit = ""
+	}
+	if target.PropertyType != models.PropertyTypeRating {
+		target.RatingMax = 0
+	}
+	if target.PropertyType == def.PropertyType && target.Key == def.Key && len(target.Options) == len(def.Options) &&
+		target.Unit == def.Unit && target.RatingMax == def.RatingMax {
+		return nil, fmt.Errorf("%w: nothing to change", ErrInvalidPropertyMigration)
+	}
+
+	result := &models.MigratePropertyDefinitionResponse{
+		DryRun:        req.DryRun,
+		Unconvertible: []models.PropertyMigrationFailure{},
+	}
+	err = s.tx.WithinTx(ctx, func(ctx context.Context) error {
+		if err := checkPrecondition(ctx, def.ID, def.UpdatedAt, s.propertyDefRepo.ClaimVersion); err != nil {
+			return err
+		}
+
+		events, err := s.eventRepo.GetForExport(ctx, userID, nil, nil, []string{def.EventTypeID})
+		if err != nil {
+			return fmt.Errorf("failed to get events: %w", err)
+		}
+
+		// Events in the trash are converted too, so a restored event
+		// matches the definition
+		trashed, err := s.eventRepo.GetDeletedByUserID(ctx, userID)
+		if err != nil {
+			return fmt.Errorf("failed to get deleted events: %w", err)
+		}
+		inTrash := make(map[string]bool)
+		for _, event := range trashed {
+			if event.EventTypeID == def.EventTypeID {
+				events = append(events, event)
+				inTrash[event.ID] = true
+			}
+		}
+
+		// Values outside the options become options, so every text value
+		// converts
+		if hasOptions(target.PropertyType) {
+			var found []string
+			for _, event := range events {
+				prop, ok := event.Properties[def.Key]
+				if !ok || prop.Value == nil {
+					continue
+				}
+				values, ok := models.ParseMultiSelect(prop.Value)
+				if !ok {
+					values = []string{stringValue(prop.Value)}
+				}
+				for _, v := range values {
+					if v != "" && !slices.Contains(target.Options, v) && !slices.Contains(found, v) {
+						found = append(found, v)
+					}
+				}
+			}
+			sort.Strings(found)
+			target.Options = append(target.Options, found...)
+			result.DiscoveredOptions = found
+		}
+		if err := checkPropertyDefinition(target); err != nil {
+			return fmt.Errorf("%w: %v", ErrInvalidPropertyMigration, err)
+		}
+
+		if def.DefaultValue != nil {
+			value, err := convertPropertyValue(def.DefaultValue, def.PropertyType, target, unit)
+			if err != nil {
+				return fmt.Errorf("%w: default_value %v", ErrInvalidPropertyMigration, err)
+			}
+			target.DefaultValue = value
+		}
+
+		// Convert everything before writing, so a refused migration writes
+		// nothing even without a database transaction
+		rewritten := make(map[string]map[string]models.PropertyValue)
+		var order []string
+		for _, event := range events {
+			prop, ok := event.Properties[def.Key]
+			if !ok {
+				continue
+			}
+
+			properties := make(map[string]models.PropertyValue, len(event.Properties))
+			for key, value := range event.Properties {
+				if key != def.Key {
+					properties[key] = value
+				}
+			}
+
+			from := prop.Type
+			if from == "" {
+				from = def.PropertyType
+			}
+			if prop.Value != nil {
+				value, err := convertPropertyValue(prop.Value, from, target, unit)
+				if err != nil {
+					result.Unconvertible = append(result.Unconvertible, models.PropertyMigrationFailure{
+						EventID: event.ID,
+						Value:   prop.Value,
+						Message: err.Error(),
+					})
+					result.EventsCleared++
+				} else {
+					properties[target.Key] = models.PropertyValue{Type: target.PropertyType, Value: value}
+					result.EventsConverted++
+				}
+			}
+			rewritten[event.ID] = properties
+			order = append(order, event.ID)
+		}
+
+		if req.DryRun {
+			result.PropertyDefinition = &target
+			return nil
+		}
+		if len(result.Unconvertible) > 0 && !req.ClearUnconvertible {
+			return ErrUnconvertibleProperties
+		}
+
+		entries := make([]models.ChangeLogInput, 0, len(order)+1)
+		for _, id := range order {
+			updated, err := s.eventRepo.UpdateFields(ctx, id, map[string]interface{}{"properties": rewritten[id]})
+			if err != nil {
+				return err
+			}
+			// Clients dropped trashed events; a restore sends them again
+			if inTrash[id] {
+				continue
+			}
+			entries = append(entries, models.ChangeLogInput{
+				EntityType: models.EntityTypeEvent,
+				Operation:  models.OperationUpdate,
+				EntityID:   updated.ID,
+				UserID:     userID,
+				Data:       updated,
+			})
+		}
+
+		updated, err := s.propertyDefRepo.Update(ctx, def.ID, &models.PropertyDefinition{
+			Key:          target.Key,
+			PropertyType: target.PropertyType,
+			Options:      target.Options,
+			Unit:         target.Unit,
+			RatingMax:    target.RatingMax,
+			Formula:      target.Formula,
+			DefaultValue: target.DefaultValue,
+			Required:     target.Required,
+			DisplayOrder: -1, // Unchanged
+		})
+		if err != nil {
+			return err
+		}
+		result.PropertyDefinition = updated
+		entries = append(entries, models.ChangeLogInput{
+			EntityType: models.EntityTypePropertyDefinition,
+			Operation:  models.OperationUpdate,
+			EntityID:   updated.ID,
+			UserID:     userID,
+			Data:       updated,
+		})
+
+		// Formulas follow a renamed property
+		if target.Key != def.Key {
+			for _, key := range formulaDependents(siblings, def.Key) {
+				i := slices.IndexFunc(siblings, func(d models.PropertyDefinition) bool { return d.Key == key })
+				renamed, err := formula.Rename(siblings[i].Formula, map[string]string{def.Key: target.Key})
+				if err != nil {
+					return err
+				}
+				dependent, err := s.propertyDefRepo.Update(ctx, siblings[i].ID, &models.PropertyDefinition{
+					Required:     siblings[i].Required,
+					Formula:      renamed,
+					DisplayOrder: -1, // Unchanged
+				})
+				if err != nil {
+					return err
+				}
+				entries = append(entries, models.ChangeLogInput{
+					EntityType: models.EntityTypePropertyDefinition,
+					Operation:  models.OperationUpdate,
+					EntityID:   dependent.ID,
+					UserID:     userID,
+					Data:       dependent,
+				})
+			}
+		}
+
+		for i := range entries {
+			if _, err := s.changeLogRepo.Append(ctx, &entries[i]); err != nil {
+				return err
+			}
+		}
+
+		// Converted values may change what formulas compute
+		_, err = s.recomputeProperties(ctx, userID, def.EventTypeID)
+		return err
+	})
+	if err != nil {
+		if errors.Is(err, ErrUnconvertibleProperties) {
+			// Nothing was written; the report says what blocked it
+			result.EventsConverted, result.EventsCleared = 0, 0
+			result.PropertyDefinition = def
+			return result, err
+		}
+		return nil, err
+	}
+
+	return result, nil
+}
+
+// convertPropertyValue converts a stored value of type from to the type of
+// the target definition and checks it against the definition. Numbers and
+// durations scale by unit seconds; quantities convert to the target's unit.
+func convertPropertyValue(value interface{}, from models.PropertyType, target models.PropertyDefinition, unit float64) (interface{}, error) {
+	to := target.PropertyType
+	converted := value
+	if from != to {
+		switch to {
+		case models.PropertyTypeText, models.PropertyTypeSelect, models.PropertyTypeDate,
+			models.PropertyTypeURL, models.PropertyTypeEmail:
+			s := stringValue(value)
+			if s == "" {
+				return nil, fmt.Errorf("cannot convert %v to %s", value, to)
+			}
+			converted = s
+		case models.PropertyTypeMultiSelect:
+			if s := stringValue(value); s != "" {
+				converted = []string{s}
+			}
+		case models.PropertyTypeQuantity, models.PropertyTypeLocation:
+			if s := stringValue(value); s != "" {
+				converted = s // Parsed as "72.5 kg" or "47.6, -122.3"
+			}
+		default:
+			v, ok := coercePropertyValue(value, to)
+			if !ok {
+				return nil, fmt.Errorf("cannot convert %v to %s", value, to)
+			}
+			converted = v
+		}
+
+		if n, ok := converted.(float64); ok {
+			switch {
+			case from == models.PropertyTypeNumber && to == models.PropertyTypeDuration:
+				converted = n * unit
+			case from == models.PropertyTypeDuration && to == models.PropertyTypeNumber:
+				converted = n / unit
+			}
+		}
+	}
+
+	if message, code := checkPropertyValue(target, converted); code != "" {
+		return nil, errors.New(message)
+	}
+	return normalizePropertyValue(target, converted), nil
+}
+
+// hasOptions reports whether values of a property type are its options
+func hasOptions(t models.PropertyType) bool {
+	return t == models.PropertyTypeSelect || t == models.PropertyTypeMultiSelect
+}
@@ -0,0 +1,227 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/JonnyWalker81/trendy/backend/internal/models"
+	"github.com/JonnyWalker81/trendy/backend/internal/repository/memory"
+)
+
+func TestMigratePropertyDefinition(t *testing.T) {
+	ctx := context.Background()
+	repos := memory.NewRepositories(memory.NewStore())
+	svc := NewPropertyDefinitionService(repos.PropertyDefinitions, repos.EventTypes, repos.Events, repos.ChangeLog, repos.Transactor)
+
+	et, err := repos.EventTypes.Create(ctx, &models.EventType{UserID: "user-1", Name: "Workout"})
+	if err != nil {
+		t.Fatalf("Create event type failed: %v", err)
+	}
+	newDef := func(key string, propertyType models.PropertyType) *models.PropertyDefinition {
+		def, err := repos.PropertyDefinitions.Create(ctx, &models.PropertyDefinition{
+			EventTypeID: et.ID, UserID: "user-1", Key: key, Label: key, PropertyType: propertyType,
+		})
+		if err != nil {
+			t.Fatalf("Create property definition failed: %v", err)
+		}
+		return def
+	}
+	reps := newDef("reps", models.PropertyTypeText)
+	place := newDef("place", models.PropertyTypeText)
+	length := newDef("length", models.PropertyTypeNumber)
+
+	base := time.Date(2026, 3, 1, 8, 0, 0, 0, time.UTC)
+	var ids []string
+	for i, values := range []struct {
+		reps, place string
+		length      float64
+	}{
+		{"12", "gym", 30},
+		{"twelve", "park", 45},
+		{" 8 ", "gym", 10},
+	} {
+		event, err := repos.Events.Create(ctx, &models.Event{
+			UserID:      "user-1",
+			EventTypeID: et.ID,
+			Timestamp:   base.Add(time.Duration(i) * time.Hour),
+			SourceType:  "manual",
+			Properties: map[string]models.PropertyValue{
+				"reps":   {Type: models.PropertyTypeText, Value: values.reps},
+				"place":  {Type: models.PropertyTypeText, Value: values.place},
+				"length": {Type: models.PropertyTypeNumber, Value: values.length},
+			},
+		})
+		if err != nil {
+			t.Fatalf("Create event failed: %v", err)
+		}
+		ids = append(ids, event.ID)
+	}
+	cursor, _ := repos.ChangeLog.GetLatestCursor(ctx, "user-1")
+
+	number := models.PropertyTypeNumber
+	result, err := svc.MigratePropertyDefinition(ctx, "user-1", reps.ID, &models.MigratePropertyDefinitionRequest{PropertyType: &number})
+	if !errors.Is(err, ErrUnconvertibleProperties) {
+		t.Fatalf("expected the migration to be refused, got %v", err)
+	}
+	if len(result.Unconvertible) != 1 || result.Unconvertible[0].EventID != ids[1] {
+		t.Fatalf("expected the second event to be reported, got %+v", result.Unconvertible)
+	}
+	if def, _ := repos.PropertyDefinitions.GetByID(ctx, reps.ID); def.PropertyType != models.PropertyTypeText {
+		t.Errorf("expected a refused migration to keep the type, got %s", def.PropertyType)
+	}
+
+	result, err = svc.MigratePropertyDefinition(ctx, "user-1", reps.ID, &models.MigratePropertyDefinitionRequest{PropertyType: &number, ClearUnconvertible: true})
+	if err != nil {
+		t.Fatalf("MigratePropertyDefinition failed: %v", err)
+	}
+	if result.EventsConverted != 2 || result.EventsCleared != 1 || result.PropertyDefinition.PropertyType != number {
+		t.Errorf("unexpected result %+v", result)
+	}
+
+	selectType := models.PropertyTypeSelect
+	result, err = svc.MigratePropertyDefinition(ctx, "user-1", place.ID, &models.MigratePropertyDefinitionRequest{PropertyType: &selectType, Options: []string{"home"}})
+	if err != nil {
+		t.Fatalf("MigratePropertyDefinition failed: %v", err)
+	}
+	if !slices.Equal(result.PropertyDefinition.Options, []string{"home", "gym", "park"}) || !slices.Equal(result.DiscoveredOptions, []string{"gym", "park"}) {
+		t.Errorf("expected discovered options, got %v (%v)", result.PropertyDefinition.Options, result.DiscoveredOptions)
+	}
+
+	duration := models.PropertyTypeDuration
+	key := "minutes"
+	dryRun, err := svc.MigratePropertyDefinition(ctx, "user-1", length.ID, &models.MigratePropertyDefinitionRequest{PropertyType: &duration, Key: &key, DurationUnit: "minutes", DryRun: true})
+	if err != nil || dryRun.EventsConverted != 3 {
+		t.Fatalf("expected a dry run converting 3 events, got %+v, %v", dryRun, err)
+	}
+	if def, _ := repos.PropertyDefinitions.GetByID(ctx, length.ID); def.Key != "length" {
+		t.Errorf("expected a dry run to write nothing, got key %q", def.Key)
+	}
+	if _, err := svc.MigratePropertyDefinition(ctx, "user-1", length.ID, &models.MigratePropertyDefinitionRequest{PropertyType: &duration, Key: &key, DurationUnit: "minutes"}); err != nil {
+		t.Fatalf("MigratePropertyDefinition failed: %v", err)
+	}
+
+	first, _ := repos.Events.GetByID(ctx, ids[0])
+	second, _ := repos.Events.GetByID(ctx, ids[1])
+	if got := first.Properties["reps"]; got.Type != number || got.Value != 12.0 {
+		t.Errorf("expected reps converted to a number, got %+v", got)
+	}
+	if _, ok := second.Properties["reps"]; ok {
+		t.Errorf("expected the unconvertible reps to be cleared, got %+v", second.Properties["reps"])
+	}
+	if got := second.Properties["place"]; got.Type != selectType || got.Value != "park" {
+		t.Errorf("expected place converted to a select, got %+v", got)
+	}
+	if got, ok := first.Properties["minutes"]; !ok || got.Type != duration || got.Value != 1800.0 {
+		t.Errorf("expected length renamed to minutes as a duration, got %+v", first.Properties)
+	}
+	if _, ok := first.Properties["length"]; ok {
+		t.Error("expected the old key to be removed")
+	}
+
+	feed, err := repos.ChangeLog.GetSince(ctx, "user-1", cursor, 100)
+	if err != nil {
+		t.Fatalf("GetSince failed: %v", err)
+	}
+	// Three migrations, each updating three events and the definition
+	if len(feed.Changes) != 12 {
+		t.Errorf("expected 12 change log entries, got %d", len(feed.Changes))
+	}
+}
+
+func TestMigrateQuantityUnit(t *testing.T) {
+	ctx := context.Background()
+	repos := memory.NewRepositories(memory.NewStore())
+	svc := NewPropertyDefinitionService(repos.PropertyDefinitions, repos.EventTypes, repos.Events, repos.ChangeLog, repos.Transactor)
+
+	et, err := repos.EventTypes.Create(ctx, &models.EventType{UserID: "user-1", Name: "Weigh-in"})
+	if err != nil {
+		t.Fatalf("Create event type failed: %v", err)
+	}
+	def, err := repos.PropertyDefinitions.Create(ctx, &models.PropertyDefinition{
+		EventTypeID: et.ID, UserID: "user-1", Key: "weight", Label: "Weight", PropertyType: models.PropertyTypeQuantity, Unit: "kg",
+	})
+	if err != nil {
+		t.Fatalf("Create property definition failed: %v", err)
+	}
+	event, err := repos.Events.Create(ctx, &models.Event{
+		UserID: "user-1", EventTypeID: et.ID, Timestamp: time.Now(), SourceType: "manual",
+		Properties: map[string]models.PropertyValue{"weight": {Type: models.PropertyTypeQuantity, Value: models.Quantity{Amount: 1, Unit: "kg"}}},
+	})
+	if err != nil {
+		t.Fatalf("Create event failed: %v", err)
+	}
+
+	unit := "g"
+	if _, err := svc.UpdatePropertyDefinition(ctx, "user-1", def.ID, &models.UpdatePropertyDefinitionRequest{Unit: &unit}); err == nil {
+		t.Fatal("expected a unit change by update to be refused")
+	}
+	if _, err := svc.MigratePropertyDefinition(ctx, "user-1", def.ID, &models.MigratePropertyDefinitionRequest{Unit: &unit}); err != nil {
+		t.Fatalf("MigratePropertyDefinition failed: %v", err)
+	}
+	updated, _ := repos.Events.GetByID(ctx, event.ID)
+	if got, _ := models.ParseQuantity(updated.Properties["weight"].Value); got != (models.Quantity{Amount: 1000, Unit: "g"}) {
+		t.Errorf("expected the weight in g, got %+v", got)
+	}
+}
+
+func TestMigrateConvertsTrashedEvents(t *testing.T) {
+	ctx := context.Background()
+	repos := memory.NewRepositories(memory.NewStore())
+	svc := NewPropertyDefinitionService(repos.PropertyDefinitions, repos.EventTypes, repos.Events, repos.ChangeLog, repos.Transactor)
+	eventService := NewEventService(repos.Events, repos.EventTypes, repos.PropertyDefinitions, repos.ChangeLog, repos.Transactor)
+
+	et, err := repos.EventTypes.Create(ctx, &models.EventType{UserID: "user-1", Name: "Workout"})
+	if err != nil {
+		t.Fatalf("Create event type failed: %v", err)
+	}
+	def, err := repos.PropertyDefinitions.Create(ctx, &models.PropertyDefinition{
+		EventTypeID: et.ID, UserID: "user-1", Key: "reps", Label: "Reps", PropertyType: models.PropertyTypeText,
+	})
+	if err != nil {
+		t.Fatalf("Create property definition failed: %v", err)
+	}
+	event, err := repos.Events.Create(ctx, &models.Event{
+		UserID: "user-1", EventTypeID: et.ID, Timestamp: time.Now(), SourceType: "manual",
+		Properties: map[string]models.PropertyValue{"reps": {Type: models.PropertyTypeText, Value: "12"}},
+	})
+	if err != nil {
+		t.Fatalf("Create event failed: %v", err)
+	}
+	if err := eventService.DeleteEvent(ctx, "user-1", event.ID); err != nil {
+		t.Fatalf("DeleteEvent failed: %v", err)
+	}
+	cursor, _ := repos.ChangeLog.GetLatestCursor(ctx, "user-1")
+
+	number := models.PropertyTypeNumber
+	key := "count"
+	result, err := svc.MigratePropertyDefinition(ctx, "user-1", def.ID, &models.MigratePropertyDefinitionRequest{PropertyType: &number, Key: &key})
+	if err != nil {
+		t.Fatalf("MigratePropertyDefinition failed: %v", err)
+	}
+	if result.EventsConverted != 1 {
+		t.Errorf("expected the trashed event to be converted, got %+v", result)
+	}
+	feed, err := repos.ChangeLog.GetSince(ctx, "user-1", cursor, 100)
+	if err != nil {
+		t.Fatalf("GetSince failed: %v", err)
+	}
+	// Only the definition; clients no longer hold the trashed event
+	if len(feed.Changes) != 1 {
+		t.Errorf("expected 1 change log entry, got %d", len(feed.Changes))
+	}
+
+	restored, err := eventService.RestoreEvent(ctx, "user-1", event.ID)
+	if err != nil {
+		t.Fatalf("RestoreEvent failed: %v", err)
+	}
+	if got, ok := restored.Properties["count"]; !ok || got.Type != number || got.Value != 12.0 {
+		t.Errorf("expected the restored event to match the definition, got %+v", restored.Properties)
+	}
+	timestamp := time.Now()
+	if _, err := eventService.UpdateEvent(ctx, "user-1", event.ID, &models.UpdateEventRequest{Timestamp: &timestamp}); err != nil {
+		t.Errorf("expected the restored event to update, got %v", err)
+	}
+}
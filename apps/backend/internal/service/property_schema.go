@@ -0,0 +1,257 @@
+package service
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"net/mail"
+	"net/url"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/JonnyWalker81/trendy/backend/internal/apierror"
+	"github.com/JonnyWalker81/trendy/backend/internal/models"
+)
+
+// PropertyValidationError reports event properties that do not match the
+// property definitions of their event type. Handlers return its errors as a
+// validation problem.
+type PropertyValidationError struct {
+	Errors []apierror.FieldError
+}
+
+func (e *PropertyValidationError) Error() string {
+	messages := make([]string, len(e.Errors))
+	for i, fe := range e.Errors {
+		messages[i] = fe.Field + " " + fe.Message
+	}
+	return "invalid properties: " + strings.Join(messages, "; ")
+}
+
+// applyPropertySchema checks properties against the definitions of their
+// event type and returns them with the default value filled in for every
+// missing property that has one. A property with a null value counts as
+// missing. Properties without a definition are kept as they are. Values of
+// computed properties are dropped; computeProperties fills them in. The
+// input map is not modified.
+func applyPropertySchema(properties map[string]models.PropertyValue, defs []models.PropertyDefinition) (map[string]models.PropertyValue, []apierror.FieldError) {
+	var fieldErrors []apierror.FieldError
+	result := make(map[string]models.PropertyValue, len(properties))
+	for key, prop := range properties {
+		if prop.Value != nil {
+			result[key] = prop
+		}
+	}
+
+	for _, def := range defs {
+		if def.Formula != "" {
+			delete(result, def.Key)
+			continue
+		}
+
+		field := "properties." + def.Key
+		prop, ok := result[def.Key]
+		if !ok {
+			switch {
+			case def.DefaultValue != nil:
+				result[def.Key] = models.PropertyValue{Type: def.PropertyType, Value: normalizePropertyValue(def, def.DefaultValue)}
+			case def.Required:
+				fieldErrors = append(fieldErrors, apierror.FieldError{Field: field, Message: "is required", Code: "required"})
+			}
+			continue
+		}
+
+		if prop.Type == "" {
+			prop.Type = def.PropertyType
+			result[def.Key] = prop
+		} else if prop.Type != def.PropertyType {
+			fieldErrors = append(fieldErrors, apierror.FieldError{
+				Field:   field,
+				Message: fmt.Sprintf("must have type %s", def.PropertyType),
+				Code:    "type_mismatch",
+			})
+			continue
+		}
+
+		if message, code := checkPropertyValue(def, prop.Value); code != "" {
+			fieldErrors = append(fieldErrors, apierror.FieldError{Field: field, Message: message, Code: code})
+			continue
+		}
+		prop.Value = normalizePropertyValue(def, prop.Value)
+		result[def.Key] = prop
+	}
+
+	if len(result) == 0 && properties == nil {
+		return nil, fieldErrors
+	}
+	return result, fieldErrors
+}
+
+// checkPropertyValue checks a value against the type of its definition. It
+// returns an empty code if the value is valid.
+func checkPropertyValue(def models.PropertyDefinition, value interface{}) (message, code string) {
+	switch def.PropertyType {
+	case models.PropertyTypeText:
+		if _, ok := value.(string); !ok {
+			return "must be a string", "invalid_type"
+		}
+	case models.PropertyTypeNumber:
+		if _, ok := propertyNumber(value); !ok {
+			return "must be a number", "invalid_type"
+		}
+	case models.PropertyTypeDuration:
+		if n, ok := propertyNumber(value); !ok || n < 0 {
+			return "must be a non-negative number of seconds", "invalid_type"
+		}
+	case models.PropertyTypeBoolean:
+		if _, ok := value.(bool); !ok {
+			return "must be a boolean", "invalid_type"
+		}
+	case models.PropertyTypeDate:
+		s, ok := value.(string)
+		if !ok {
+			return "must be a string", "invalid_type"
+		}
+		if _, err := time.Parse(time.RFC3339, s); err != nil {
+			if _, err := time.Parse(time.DateOnly, s); err != nil {
+				return "must be an RFC3339 timestamp or a YYYY-MM-DD date", "invalid_format"
+			}
+		}
+	case models.PropertyTypeSelect:
+		s, ok := value.(string)
+		if !ok {
+			return "must be a string", "invalid_type"
+		}
+		if !slices.Contains(def.Options, s) {
+			return fmt.Sprintf("must be one of %s", strings.Join(def.Options, ", ")), "invalid_option"
+		}
+	case models.PropertyTypeURL:
+		s, ok := value.(string)
+		if !ok {
+			return "must be a string", "invalid_type"
+		}
+		if u, err := url.Parse(s); err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+			return "must be an http or https URL", "invalid_format"
+		}
+	case models.PropertyTypeEmail:
+		s, ok := value.(string)
+		if !ok {
+			return "must be a string", "invalid_type"
+		}
+		if addr, err := mail.ParseAddress(s); err != nil || addr.Address != s {
+			return "must be an email address", "invalid_format"
+		}
+	case models.PropertyTypeMultiSelect:
+		values, ok := models.ParseMultiSelect(value)
+		if !ok {
+			return "must be an array of strings", "invalid_type"
+		}
+		for i, v := range values {
+			if !slices.Contains(def.Options, v) {
+				return fmt.Sprintf("must only contain %s", strings.Join(def.Options, ", ")), "invalid_option"
+			}
+			if slices.Contains(values[:i], v) {
+				return fmt.Sprintf("must not repeat %s", v), "invalid_option"
+			}
+		}
+	case models.PropertyTypeRating:
+		n, ok := propertyNumber(value)
+		if !ok || n != math.Trunc(n) {
+			return "must be a whole number", "invalid_type"
+		}
+		if top := ratingMax(def); n < 1 || n > float64(top) {
+			return fmt.Sprintf("must be from 1 to %d", top), "out_of_range"
+		}
+	case models.PropertyTypeQuantity:
+		q, ok := models.ParseQuantity(value)
+		if !ok {
+			return "must be an object with a numeric amount and a unit", "invalid_type"
+		}
+		if _, err := models.ConvertQuantity(q, def.Unit); err != nil {
+			return fmt.Sprintf("unit must be one of %s", strings.Join(models.CompatibleUnits(def.Unit), ", ")), "invalid_unit"
+		}
+	case models.PropertyTypeLocation:
+		l, ok := models.ParseLocation(value)
+		if !ok {
+			return "must be an object with a numeric latitude and longitude", "invalid_type"
+		}
+		if l.Latitude < -90 || l.Latitude > 90 || l.Longitude < -180 || l.Longitude > 180 {
+			return "must have a latitude from -90 to 90 and a longitude from -180 to 180", "out_of_range"
+		}
+	}
+	return "", ""
+}
+
+// normalizePropertyValue returns a valid value in the form it is stored in:
+// multi-select values as a string array, quantities converted to the
+// definition's unit, and locations as a Location
+func normalizePropertyValue(def models.PropertyDefinition, value interface{}) interface{} {
+	switch def.PropertyType {
+	case models.PropertyTypeMultiSelect:
+		if values, ok := models.ParseMultiSelect(value); ok {
+			return values
+		}
+	case models.PropertyTypeQuantity:
+		if q, ok := models.ParseQuantity(value); ok {
+			if converted, err := models.ConvertQuantity(q, def.Unit); err == nil {
+				return converted
+			}
+		}
+	case models.PropertyTypeLocation:
+		if l, ok := models.ParseLocation(value); ok {
+			return l
+		}
+	}
+	return value
+}
+
+// checkPropertyDefinition checks the settings a property type needs: options
+// for a select or multi-select, a known unit for a quantity and a rating
+// scale within bounds
+func checkPropertyDefinition(def models.PropertyDefinition) error {
+	switch def.PropertyType {
+	case models.PropertyTypeText, models.PropertyTypeNumber, models.PropertyTypeBoolean,
+		models.PropertyTypeDate, models.PropertyTypeDuration, models.PropertyTypeURL,
+		models.PropertyTypeEmail, models.PropertyTypeRating, models.PropertyTypeLocation:
+	case models.PropertyTypeSelect, models.PropertyTypeMultiSelect:
+		if len(def.Options) == 0 {
+			return fmt.Errorf("%s property type requires options", def.PropertyType)
+		}
+	case models.PropertyTypeQuantity:
+		if !models.IsQuantityUnit(def.Unit) {
+			return fmt.Errorf("quantity property type requires a unit")
+		}
+	default:
+		return fmt.Errorf("unknown property type %q", def.PropertyType)
+	}
+
+	if def.RatingMax != 0 && (def.RatingMax < 2 || def.RatingMax > models.MaxRatingMax) {
+		return fmt.Errorf("rating_max must be from 2 to %d", models.MaxRatingMax)
+	}
+	return nil
+}
+
+// ratingMax returns the top of a definition's rating scale
+func ratingMax(def models.PropertyDefinition) int {
+	if def.RatingMax == 0 {
+		return models.DefaultRatingMax
+	}
+	return def.RatingMax
+}
+
+// propertyNumber returns a decoded JSON number as a float64
+func propertyNumber(v interface{}) (float64, bool) {
+	switch n := v.(type) {
+	case float64:
+		return n, !math.IsNaN(n) && !math.IsInf(n, 0)
+	case int:
+		return float64(n), true
+	case int64:
+		return float64(n), true
+	case json.Number:
+		f, err := n.Float64()
+		return f, err == nil
+	}
+	return 0, false
+}
@@ -0,0 +1,219 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/JonnyWalker81/trendy/backend/internal/models"
+	"github.com/JonnyWalker81/trendy/backend/internal/repository/memory"
+)
+
+func TestApplyPropertySchema(t *testing.T) {
+	defs := []models.PropertyDefinition{
+		{Key: "mood", PropertyType: models.PropertyTypeNumber, Required: true},
+		{Key: "place", PropertyType: models.PropertyTypeSelect, Options: []string{"home", "gym"}, DefaultValue: "home"},
+		{Key: "length", PropertyType: models.PropertyTypeDuration},
+		{Key: "day", PropertyType: models.PropertyTypeDate},
+		{Key: "link", PropertyType: models.PropertyTypeURL},
+		{Key: "contact", PropertyType: models.PropertyTypeEmail},
+	}
+	value := func(t models.PropertyType, v interface{}) models.PropertyValue {
+		return models.PropertyValue{Type: t, Value: v}
+	}
+
+	tests := []struct {
+		name       string
+		properties map[string]models.PropertyValue
+		wantErrors map[string]string // field -> code
+	}{
+		{
+			name: "valid values",
+			properties: map[string]models.PropertyValue{
+				"mood":    {Value: 4.0},
+				"place":   value(models.PropertyTypeSelect, "gym"),
+				"length":  value(models.PropertyTypeDuration, 1800.0),
+				"day":     value(models.PropertyTypeDate, "2026-03-01"),
+				"link":    value(models.PropertyTypeURL, "https://example.com/run"),
+				"contact": value(models.PropertyTypeEmail, "coach@example.com"),
+				"extra":   value(models.PropertyTypeText, "no definition"),
+			},
+		},
+		{
+			name:       "missing required value",
+			properties: map[string]models.PropertyValue{"mood": {Type: models.PropertyTypeNumber, Value: nil}},
+			wantErrors: map[string]string{"properties.mood": "required"},
+		},
+		{
+			name: "invalid values",
+			properties: map[string]models.PropertyValue{
+				"mood":    value(models.PropertyTypeText, "4"),
+				"place":   value(models.PropertyTypeSelect, "park"),
+				"length":  value(models.PropertyTypeDuration, -5.0),
+				"day":     value(models.PropertyTypeDate, "March 1st"),
+				"link":    value(models.PropertyTypeURL, "ftp://example.com"),
+				"contact": value(models.PropertyTypeEmail, "Coach <coach@example.com>"),
+			},
+			wantErrors: map[string]string{
+				"properties.mood":    "type_mismatch",
+				"properties.place":   "invalid_option",
+				"properties.length":  "invalid_type",
+				"properties.day":     "invalid_format",
+				"properties.link":    "invalid_format",
+				"properties.contact": "invalid_format",
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			checked, fieldErrors := applyPropertySchema(tt.properties, defs)
+			got := make(map[string]string, len(fieldErrors))
+			for _, fe := range fieldErrors {
+				got[fe.Field] = fe.Code
+			}
+			if len(got) != len(tt.wantErrors) {
+				t.Fatalf("expected errors %v, got %v", tt.wantErrors, got)
+			}
+			for field, code := range tt.wantErrors {
+				if got[field] != code {
+					t.Errorf("%s: expected code %q, got %q", field, code, got[field])
+				}
+			}
+			if len(fieldErrors) == 0 && checked["mood"].Type != models.PropertyTypeNumber {
+				t.Errorf("expected the missing type to be filled in, got %+v", checked["mood"])
+			}
+		})
+	}
+}
+
+func TestEventWritesApplyPropertyDefinitions(t *testing.T) {
+	ctx := context.Background()
+	repos := memory.NewRepositories(memory.NewStore())
+	eventService := NewEventService(repos.Events, repos.EventTypes, repos.PropertyDefinitions, repos.ChangeLog, repos.Transactor)
+
+	et, err := repos.EventTypes.Create(ctx, &models.EventType{UserID: "user-1", Name: "Workout"})
+	if err != nil {
+		t.Fatalf("Create event type failed: %v", err)
+	}
+	for _, def := range []models.PropertyDefinition{
+		{EventTypeID: et.ID, UserID: "user-1", Key: "effort", Label: "Effort", PropertyType: models.PropertyTypeNumber, Required: true},
+		{EventTypeID: et.ID, UserID: "user-1", Key: "place", Label: "Place", PropertyType: models.PropertyTypeSelect, Options: []string{"home", "gym"}, DefaultValue: "gym"},
+	} {
+		if _, err := repos.PropertyDefinitions.Create(ctx, &def); err != nil {
+			t.Fatalf("Create property definition failed: %v", err)
+		}
+	}
+
+	_, _, err = eventService.CreateEvent(ctx, "user-1", &models.CreateEventRequest{EventTypeID: et.ID, Timestamp: time.Now()})
+	var propErr *PropertyValidationError
+	if !errors.As(err, &propErr) || len(propErr.Errors) != 1 || propErr.Errors[0].Field != "properties.effort" {
+		t.Fatalf("expected effort to be required, got %v", err)
+	}
+
+	event, _, err := eventService.CreateEvent(ctx, "user-1", &models.CreateEventRequest{
+		EventTypeID: et.ID,
+		Timestamp:   time.Now(),
+		Properties:  map[string]models.PropertyValue{"effort": {Type: models.PropertyTypeNumber, Value: 7.0}},
+	})
+	if err != nil {
+		t.Fatalf("CreateEvent failed: %v", err)
+	}
+	if got := event.Properties["place"]; got.Value != "gym" || got.Type != models.PropertyTypeSelect {
+		t.Errorf("expected the default place, got %+v", got)
+	}
+
+	props := map[string]models.PropertyValue{
+		"effort": {Type: models.PropertyTypeNumber, Value: 7.0},
+		"place":  {Type: models.PropertyTypeSelect, Value: "park"},
+	}
+	_, err = eventService.UpdateEvent(ctx, "user-1", event.ID, &models.UpdateEventRequest{Properties: &props})
+	if !errors.As(err, &propErr) || propErr.Errors[0].Code != "invalid_option" {
+		t.Fatalf("expected an invalid option error, got %v", err)
+	}
+
+	response, err := eventService.CreateEventsBatch(ctx, "user-1", &models.BatchCreateEventsRequest{Events: []models.CreateEventRequest{
+		{EventTypeID: et.ID, Timestamp: time.Now().Add(-time.Hour), Properties: map[string]models.PropertyValue{"effort": {Value: "hard"}}},
+		{EventTypeID: et.ID, Timestamp: time.Now().Add(-2 * time.Hour), Properties: map[string]models.PropertyValue{"effort": {Value: 3.0}}},
+	}})
+	if err != nil {
+		t.Fatalf("CreateEventsBatch failed: %v", err)
+	}
+	if len(response.Created) != 1 || len(response.Errors) != 1 || response.Errors[0].Index != 0 {
+		t.Errorf("expected the first event to fail, got %d created and errors %+v", len(response.Created), response.Errors)
+	}
+}
+
+func TestStructuredPropertyTypes(t *testing.T) {
+	defs := []models.PropertyDefinition{
+		{Key: "tags", PropertyType: models.PropertyTypeMultiSelect, Options: []string{"easy", "outdoor", "group"}},
+		{Key: "enjoyment", PropertyType: models.PropertyTypeRating, RatingMax: 10},
+		{Key: "weight", PropertyType: models.PropertyTypeQuantity, Unit: "kg"},
+		{Key: "where", PropertyType: models.PropertyTypeLocation},
+	}
+
+	checked, fieldErrors := applyPropertySchema(map[string]models.PropertyValue{
+		"tags":      {Value: []interface{}{"outdoor", "easy"}},
+		"enjoyment": {Value: 8.0},
+		"weight":    {Value: map[string]interface{}{"amount": 150.0, "unit": "lb"}},
+		"where":     {Value: map[string]interface{}{"latitude": 47.6, "longitude": -122.3, "name": "Seattle"}},
+	}, defs)
+	if len(fieldErrors) != 0 {
+		t.Fatalf("expected valid values, got %v", fieldErrors)
+	}
+	if got := checked["weight"].Value; got != (models.Quantity{Amount: 68.0388555, Unit: "kg"}) {
+		t.Errorf("expected the weight in kg, got %+v", got)
+	}
+	if got := checked["where"].Value; got != (models.Location{Latitude: 47.6, Longitude: -122.3, Name: "Seattle"}) {
+		t.Errorf("expected a location, got %+v", got)
+	}
+
+	_, fieldErrors = applyPropertySchema(map[string]models.PropertyValue{
+		"tags":      {Value: []interface{}{"easy", "easy"}},
+		"enjoyment": {Value: 11.0},
+		"weight":    {Value: map[string]interface{}{"amount": 5.0, "unit": "km"}},
+		"where":     {Value: map[string]interface{}{"latitude": 91.0, "longitude": 0.0}},
+	}, defs)
+	got := make(map[string]string, len(fieldErrors))
+	for _, fe := range fieldErrors {
+		got[fe.Field] = fe.Code
+	}
+	want := map[string]string{
+		"properties.tags":      "invalid_option",
+		"properties.enjoyment": "out_of_range",
+		"properties.weight":    "invalid_unit",
+		"properties.where":     "out_of_range",
+	}
+	for field, code := range want {
+		if got[field] != code {
+			t.Errorf("%s: expected code %q, got %q", field, code, got[field])
+		}
+	}
+
+	if err := checkPropertyDefinition(models.PropertyDefinition{PropertyType: models.PropertyTypeQuantity, Unit: "stone"}); err == nil {
+		t.Error("expected an unknown unit to be rejected")
+	}
+}
+
+func TestAggregatePropertyValue(t *testing.T) {
+	var weight models.PropAgg
+	for _, q := range []models.Quantity{{Amount: 70, Unit: "kg"}, {Amount: 2000, Unit: "g"}} {
+		weight, _ = aggregatePropertyValue(weight, models.PropertyValue{Type: models.PropertyTypeQuantity, Value: q})
+	}
+	if weight.Unit != "kg" || weight.Sum != 72 || weight.Min != 2 || weight.Avg != 36 {
+		t.Errorf("unexpected quantity aggregate %+v", weight)
+	}
+
+	var tags models.PropAgg
+	for _, v := range [][]string{{"easy", "outdoor"}, {"easy"}} {
+		tags, _ = aggregatePropertyValue(tags, models.PropertyValue{Type: models.PropertyTypeMultiSelect, Value: v})
+	}
+	if tags.Count != 2 || tags.Options["easy"] != 2 || tags.Options["outdoor"] != 1 {
+		t.Errorf("unexpected multi-select aggregate %+v", tags)
+	}
+
+	if _, ok := aggregatePropertyValue(models.PropAgg{}, models.PropertyValue{Type: models.PropertyTypeText, Value: "x"}); ok {
+		t.Error("expected text not to be aggregated")
+	}
+}
@@ -2,9 +2,11 @@ package service
 
 import (
 	"context"
+	"fmt"
 	"sync"
 	"time"
 
+	"github.com/JonnyWalker81/trendy/backend/internal/models"
 	"github.com/JonnyWalker81/trendy/backend/internal/repository"
 )
 
@@ -30,18 +32,114 @@ type HealthKitStatus struct {
 	Count    int64      `json:"count"`
 }
 
+// SyncSnapshot is the full state of a user's synced entities. Clients that
+// apply it and then read changes after Cursor converge with the server.
+type SyncSnapshot struct {
+	Cursor              int64                       `json:"cursor"`
+	EventTypes          []models.EventType          `json:"event_types"`
+	PropertyDefinitions []models.PropertyDefinition `json:"property_definitions"`
+	Geofences           []models.Geofence           `json:"geofences"`
+	Events              []models.Event              `json:"events"`
+}
+
+// snapshotEventPageSize is the number of events loaded per query
+const snapshotEventPageSize = 1000
+
 type syncService struct {
-	eventRepo     repository.EventRepository
-	eventTypeRepo repository.EventTypeRepository
-	changeLogRepo repository.ChangeLogRepository
+	eventRepo       repository.EventRepository
+	eventTypeRepo   repository.EventTypeRepository
+	propertyDefRepo repository.PropertyDefinitionRepository
+	geofenceRepo    repository.GeofenceRepository
+	changeLogRepo   repository.ChangeLogRepository
+	tx              repository.Transactor
 }
 
 func NewSyncService(
 	eventRepo repository.EventRepository,
 	eventTypeRepo repository.EventTypeRepository,
+	propertyDefRepo repository.PropertyDefinitionRepository,
+	geofenceRepo repository.GeofenceRepository,
 	changeLogRepo repository.ChangeLogRepository,
+	tx repository.Transactor,
 ) SyncService {
-	return &syncService{eventRepo: eventRepo, eventTypeRepo: eventTypeRepo, changeLogRepo: changeLogRepo}
+	return &syncService{
+		eventRepo:       eventRepo,
+		eventTypeRepo:   eventTypeRepo,
+		propertyDefRepo: propertyDefRepo,
+		geofenceRepo:    geofenceRepo,
+		changeLogRepo:   changeLogRepo,
+		tx:              tx,
+	}
+}
+
+func (s *syncService) GetSnapshot(ctx context.Context, userID string) (*SyncSnapshot, error) {
+	var snapshot *SyncSnapshot
+	err := s.tx.WithinSnapshot(ctx, func(ctx context.Context) error {
+		var err error
+		snapshot, err = s.loadSnapshot(ctx, userID)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	return snapshot, nil
+}
+
+// loadSnapshot reads the cursor and then every entity. Where the backend
+// reads them all from one snapshot they match the cursor exactly; otherwise
+// a write that lands while the snapshot loads is either included or replayed
+// from the cursor, and replaying an already-applied change is harmless.
+// Events are paged by keyset rather than offset, so concurrent inserts and
+// deletes cannot make an unchanged event be skipped or returned twice.
+func (s *syncService) loadSnapshot(ctx context.Context, userID string) (*SyncSnapshot, error) {
+	cursor, err := s.changeLogRepo.GetLatestCursor(ctx, userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get latest cursor: %w", err)
+	}
+
+	// Empty collections are encoded as [] so clients can replace local state
+	snapshot := &SyncSnapshot{
+		Cursor:              cursor,
+		EventTypes:          []models.EventType{},
+		PropertyDefinitions: []models.PropertyDefinition{},
+		Geofences:           []models.Geofence{},
+		Events:              []models.Event{},
+	}
+
+	eventTypes, err := s.eventTypeRepo.GetByUserID(ctx, userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get event types: %w", err)
+	}
+	snapshot.EventTypes = append(snapshot.EventTypes, eventTypes...)
+	for _, et := range eventTypes {
+		defs, err := s.propertyDefRepo.GetByEventTypeID(ctx, et.ID)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get property definitions: %w", err)
+		}
+		snapshot.PropertyDefinitions = append(snapshot.PropertyDefinitions, defs...)
+	}
+
+	geofences, err := s.geofenceRepo.GetByUserID(ctx, userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get geofences: %w", err)
+	}
+	snapshot.Geofences = append(snapshot.Geofences, geofences...)
+
+	var after *models.EventCursor
+	for {
+		events, err := s.eventRepo.GetForExportPage(ctx, userID, nil, nil, nil, after, snapshotEventPageSize)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get events: %w", err)
+		}
+		snapshot.Events = append(snapshot.Events, events...)
+		if len(events) < snapshotEventPageSize {
+			break
+		}
+		last := events[len(events)-1]
+		after = &models.EventCursor{Timestamp: last.Timestamp, ID: last.ID}
+	}
+
+	return snapshot, nil
 }
 
 func (s *syncService) GetSyncStatus(ctx context.Context, userID string) (*SyncStatus, error) {
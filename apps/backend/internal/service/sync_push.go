@@ -0,0 +1,402 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/JonnyWalker81/trendy/backend/internal/models"
+	"github.com/JonnyWalker81/trendy/backend/internal/repository"
+	"github.com/gin-gonic/gin/binding"
+	"github.com/google/uuid"
+)
+
+type syncPushService struct {
+	eventService       EventService
+	eventTypeService   EventTypeService
+	propertyDefService PropertyDefinitionService
+	geofenceService    GeofenceService
+	eventRepo          repository.EventRepository
+	eventTypeRepo      repository.EventTypeRepository
+	tx                 repository.Transactor
+}
+
+// NewSyncPushService creates a service that applies batches of offline
+// mutations through the entity services, so each write is validated and
+// logged exactly as it would be through the entity's own route. The event
+// and event type repositories are used to find IDs in the trash.
+func NewSyncPushService(
+	eventService EventService,
+	eventTypeService EventTypeService,
+	propertyDefService PropertyDefinitionService,
+	geofenceService GeofenceService,
+	eventRepo repository.EventRepository,
+	eventTypeRepo repository.EventTypeRepository,
+	tx repository.Transactor,
+) SyncPushService {
+	return &syncPushService{
+		eventService:       eventService,
+		eventTypeService:   eventTypeService,
+		propertyDefService: propertyDefService,
+		geofenceService:    geofenceService,
+		eventRepo:          eventRepo,
+		eventTypeRepo:      eventTypeRepo,
+		tx:                 tx,
+	}
+}
+
+func (s *syncPushService) Push(ctx context.Context, userID string, req *models.SyncPushRequest) (*models.SyncPushResponse, error) {
+	response := &models.SyncPushResponse{
+		Results: make([]models.SyncMutationResult, 0, len(req.Mutations)),
+		Total:   len(req.Mutations),
+	}
+
+	// Mutations are applied in order, so a batch can create an event type and
+	// then events that reference it
+	for i, m := range req.Mutations {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+
+		result, err := s.apply(ctx, userID, m)
+		if err != nil {
+			// Earlier mutations stay applied; resending them is harmless
+			return nil, fmt.Errorf("failed to apply mutation %d: %w", i, err)
+		}
+		result.Index = i
+
+		switch result.Status {
+		case models.SyncMutationApplied:
+			response.Applied++
+		case models.SyncMutationConflict:
+			response.Conflicts++
+		case models.SyncMutationRejected:
+			response.Rejected++
+		}
+		response.Results = append(response.Results, result)
+	}
+
+	return response, nil
+}
+
+// apply checks the mutation's preconditions against the server copy and
+// writes it in one transaction. Conflicts resolve server-wins: nothing is
+// written and the current server version is returned. The entity's service
+// re-checks base_updated_at against the version it claims for the write, so
+// a concurrent writer cannot slip in between the check and the write. Only
+// an invalid mutation is rejected; a write that fails for any other reason,
+// such as a lost connection or a timeout, is returned as an error so the
+// client retries it rather than discarding it.
+func (s *syncPushService) apply(ctx context.Context, userID string, m models.SyncMutation) (models.SyncMutationResult, error) {
+	result := models.SyncMutationResult{EntityType: m.EntityType, EntityID: m.EntityID}
+
+	if err := validateMutation(m); err != nil {
+		result.Status = models.SyncMutationRejected
+		result.Error = err.Error()
+		return result, nil
+	}
+
+	err := s.tx.WithinTx(ctx, func(ctx context.Context) error {
+		current, updatedAt, err := s.get(ctx, userID, m.EntityType, m.EntityID)
+		if err != nil {
+			return err
+		}
+
+		switch m.Operation {
+		case models.OperationCreate:
+			if current != nil {
+				result.Status = models.SyncMutationConflict
+				result.Entity = current
+				return nil
+			}
+			// Creating over a trashed entity would overwrite it in place
+			trashed, inTrash, err := s.getTrashed(ctx, userID, m.EntityType, m.EntityID)
+			if err != nil {
+				return err
+			}
+			if inTrash {
+				result.Status = models.SyncMutationConflict
+				result.Entity = trashed
+				result.Error = "entity is in the trash; restore it instead"
+				return nil
+			}
+		case models.OperationUpdate:
+			if current == nil || changedSince(updatedAt, m.BaseUpdatedAt) {
+				result.Status = models.SyncMutationConflict
+				result.Entity = current
+				return nil
+			}
+		case models.OperationDelete:
+			if current == nil {
+				// Already deleted
+				result.Status = models.SyncMutationApplied
+				return nil
+			}
+			if changedSince(updatedAt, m.BaseUpdatedAt) {
+				result.Status = models.SyncMutationConflict
+				result.Entity = current
+				return nil
+			}
+		}
+
+		if m.Operation != models.OperationCreate {
+			ctx = WithPrecondition(ctx, m.EntityID, func(updatedAt time.Time) bool {
+				return !changedSince(updatedAt, m.BaseUpdatedAt)
+			})
+		}
+
+		entity, err := s.write(ctx, userID, m)
+		if err != nil {
+			return err
+		}
+		result.Status = models.SyncMutationApplied
+		result.Entity = entity
+		return nil
+	})
+	if errors.Is(err, ErrPreconditionFailed) {
+		// Changed by a concurrent writer since it was read
+		result.Status = models.SyncMutationConflict
+		result.Entity, _, err = s.get(ctx, userID, m.EntityType, m.EntityID)
+	}
+	if err != nil {
+		message, rejected := rejection(err)
+		if !rejected {
+			return result, err
+		}
+		result.Status = models.SyncMutationRejected
+		result.Entity = nil
+		result.Error = message
+	}
+
+	return result, nil
+}
+
+// rejection returns the message reported to the client when err means the
+// mutation itself is invalid. Storage failures are wrapped as "failed to ..."
+// throughout the services and, like cancellations and timeouts, are not
+// rejections: the same mutation may succeed when retried.
+func rejection(err error) (string, bool) {
+	var propErr *PropertyValidationError
+	switch {
+	case errors.Is(err, context.Canceled), errors.Is(err, context.DeadlineExceeded):
+		return "", false
+	case errors.As(err, &propErr):
+		return err.Error(), true
+	case strings.Contains(err.Error(), "23505"):
+		// A unique constraint other than the ID, e.g. a duplicate manual event
+		return "conflicts with an existing entity", true
+	case strings.HasPrefix(err.Error(), "failed to "):
+		return "", false
+	}
+	return err.Error(), true
+}
+
+// validateMutation checks the parts of a mutation that do not depend on
+// server state
+func validateMutation(m models.SyncMutation) error {
+	switch m.EntityType {
+	case models.EntityTypeEvent, models.EntityTypeEventType, models.EntityTypeGeofence, models.EntityTypePropertyDefinition:
+	default:
+		return fmt.Errorf("unknown entity type: %s", m.EntityType)
+	}
+
+	switch m.Operation {
+	case models.OperationCreate:
+		// New entities must use client-generated UUIDv7s
+		return ValidateUUIDv7(m.EntityID)
+	case models.OperationUpdate, models.OperationDelete:
+		// Existing entities may predate UUIDv7 IDs
+		if _, err := uuid.Parse(m.EntityID); err != nil {
+			return fmt.Errorf("%w: %v", ErrInvalidUUID, err)
+		}
+		return nil
+	default:
+		return fmt.Errorf("unknown operation: %s", m.Operation)
+	}
+}
+
+// changedSince reports whether the server copy was updated after the version
+// the client based its change on. Timestamps are compared at the database's
+// microsecond precision.
+func changedSince(updatedAt time.Time, base *time.Time) bool {
+	if base == nil {
+		return false
+	}
+	return updatedAt.Truncate(time.Microsecond).After(base.Truncate(time.Microsecond))
+}
+
+// get returns the user's current copy of an entity and its updated_at, or a
+// nil entity if it does not exist
+func (s *syncPushService) get(ctx context.Context, userID string, entityType models.EntityType, id string) (interface{}, time.Time, error) {
+	var entity interface{}
+	var updatedAt time.Time
+	var err error
+
+	switch entityType {
+	case models.EntityTypeEvent:
+		var e *models.Event
+		if e, err = s.eventService.GetEvent(ctx, userID, id); err == nil {
+			entity, updatedAt = e, e.UpdatedAt
+		}
+	case models.EntityTypeEventType:
+		var et *models.EventType
+		if et, err = s.eventTypeService.GetEventType(ctx, userID, id); err == nil {
+			entity, updatedAt = et, et.UpdatedAt
+		}
+	case models.EntityTypeGeofence:
+		var g *models.Geofence
+		if g, err = s.geofenceService.GetGeofence(ctx, userID, id); err == nil {
+			entity, updatedAt = g, g.UpdatedAt
+		}
+	case models.EntityTypePropertyDefinition:
+		var def *models.PropertyDefinition
+		if def, err = s.propertyDefService.GetPropertyDefinition(ctx, userID, id); err == nil {
+			entity, updatedAt = def, def.UpdatedAt
+		}
+	}
+
+	if err != nil {
+		if strings.HasSuffix(err.Error(), "not found") {
+			return nil, time.Time{}, nil
+		}
+		return nil, time.Time{}, fmt.Errorf("failed to get %s: %w", entityType, err)
+	}
+
+	return entity, updatedAt, nil
+}
+
+// getTrashed reports whether an entity ID is in the trash and returns the
+// trashed copy if it is the user's. Only events and event types have a trash.
+func (s *syncPushService) getTrashed(ctx context.Context, userID string, entityType models.EntityType, id string) (interface{}, bool, error) {
+	var entity interface{}
+	var owner string
+	var err error
+
+	switch entityType {
+	case models.EntityTypeEvent:
+		var e *models.Event
+		if e, err = s.eventRepo.GetDeletedByID(ctx, id); err == nil {
+			entity, owner = e, e.UserID
+		}
+	case models.EntityTypeEventType:
+		var et *models.EventType
+		if et, err = s.eventTypeRepo.GetDeletedByID(ctx, id); err == nil {
+			entity, owner = et, et.UserID
+		}
+	default:
+		return nil, false, nil
+	}
+
+	if err != nil {
+		if strings.HasSuffix(err.Error(), "not found") {
+			return nil, false, nil
+		}
+		return nil, false, fmt.Errorf("failed to get %s: %w", entityType, err)
+	}
+	if owner != userID {
+		return nil, true, nil
+	}
+
+	return entity, true, nil
+}
+
+// write applies the mutation through the entity's service, returning the
+// written entity (nil for deletes)
+func (s *syncPushService) write(ctx context.Context, userID string, m models.SyncMutation) (interface{}, error) {
+	id := m.EntityID
+
+	switch m.EntityType {
+	case models.EntityTypeEvent:
+		switch m.Operation {
+		case models.OperationCreate:
+			var req models.CreateEventRequest
+			if err := decodeMutationData(m.Data, &req); err != nil {
+				return nil, err
+			}
+			req.ID = &id
+			event, _, err := s.eventService.CreateEvent(ctx, userID, &req)
+			return event, err
+		case models.OperationUpdate:
+			var req models.UpdateEventRequest
+			if err := decodeMutationData(m.Data, &req); err != nil {
+				return nil, err
+			}
+			return s.eventService.UpdateEvent(ctx, userID, id, &req)
+		default:
+			return nil, s.eventService.DeleteEvent(ctx, userID, id)
+		}
+
+	case models.EntityTypeEventType:
+		switch m.Operation {
+		case models.OperationCreate:
+			var req models.CreateEventTypeRequest
+			if err := decodeMutationData(m.Data, &req); err != nil {
+				return nil, err
+			}
+			req.ID = &id
+			return s.eventTypeService.CreateEventType(ctx, userID, &req)
+		case models.OperationUpdate:
+			var req models.UpdateEventTypeRequest
+			if err := decodeMutationData(m.Data, &req); err != nil {
+				return nil, err
+			}
+			return s.eventTypeService.UpdateEventType(ctx, userID, id, &req)
+		default:
+			return nil, s.eventTypeService.DeleteEventType(ctx, userID, id, nil)
+		}
+
+	case models.EntityTypeGeofence:
+		switch m.Operation {
+		case models.OperationCreate:
+			var req models.CreateGeofenceRequest
+			req.ID = id // Satisfies the required binding when data omits id
+			if err := decodeMutationData(m.Data, &req); err != nil {
+				return nil, err
+			}
+			req.ID = id
+			return s.geofenceService.CreateGeofence(ctx, userID, &req)
+		case models.OperationUpdate:
+			var req models.UpdateGeofenceRequest
+			if err := decodeMutationData(m.Data, &req); err != nil {
+				return nil, err
+			}
+			return s.geofenceService.UpdateGeofence(ctx, userID, id, &req)
+		default:
+			return nil, s.geofenceService.DeleteGeofence(ctx, userID, id)
+		}
+
+	default:
+		switch m.Operation {
+		case models.OperationCreate:
+			var req models.CreatePropertyDefinitionRequest
+			if err := decodeMutationData(m.Data, &req); err != nil {
+				return nil, err
+			}
+			req.ID = &id
+			return s.propertyDefService.CreatePropertyDefinition(ctx, userID, &req)
+		case models.OperationUpdate:
+			var req models.UpdatePropertyDefinitionRequest
+			if err := decodeMutationData(m.Data, &req); err != nil {
+				return nil, err
+			}
+			return s.propertyDefService.UpdatePropertyDefinition(ctx, userID, id, &req)
+		default:
+			return nil, s.propertyDefService.DeletePropertyDefinition(ctx, userID, id)
+		}
+	}
+}
+
+// decodeMutationData decodes a create or update body and applies the same
+// binding validation as the entity's own route
+func decodeMutationData(data json.RawMessage, req interface{}) error {
+	if len(data) == 0 {
+		return fmt.Errorf("data is required for create and update mutations")
+	}
+	if err := json.Unmarshal(data, req); err != nil {
+		return fmt.Errorf("invalid data: %w", err)
+	}
+	return binding.Validator.ValidateStruct(req)
+}
@@ -0,0 +1,186 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/JonnyWalker81/trendy/backend/internal/models"
+	"github.com/JonnyWalker81/trendy/backend/internal/repository/memory"
+	"github.com/google/uuid"
+)
+
+func TestPushAppliesMutationsWithConflicts(t *testing.T) {
+	ctx := context.Background()
+	repos := memory.NewRepositories(memory.NewStore())
+	userID := "user-1"
+
+	eventService := NewEventService(repos.Events, repos.EventTypes, repos.PropertyDefinitions, repos.ChangeLog, repos.Transactor)
+	eventTypeService := NewEventTypeService(repos.EventTypes, repos.Events, repos.PropertyDefinitions, repos.Geofences, repos.Insights, repos.Streaks, repos.ChangeLog, repos.Transactor)
+	propertyDefService := NewPropertyDefinitionService(repos.PropertyDefinitions, repos.EventTypes, repos.Events, repos.ChangeLog, repos.Transactor)
+	geofenceService := NewGeofenceService(repos.Geofences, repos.ChangeLog, repos.Transactor)
+	push := NewSyncPushService(eventService, eventTypeService, propertyDefService, geofenceService, repos.Events, repos.EventTypes, repos.Transactor)
+
+	// An event type the client last saw before another device renamed it
+	stale, err := eventTypeService.CreateEventType(ctx, userID, &models.CreateEventTypeRequest{Name: "Run", Color: "#f00", Icon: "run"})
+	if err != nil {
+		t.Fatalf("CreateEventType failed: %v", err)
+	}
+	seen := stale.UpdatedAt
+	time.Sleep(time.Millisecond)
+	renamed := "Running"
+	if _, err := eventTypeService.UpdateEventType(ctx, userID, stale.ID, &models.UpdateEventTypeRequest{Name: &renamed}); err != nil {
+		t.Fatalf("UpdateEventType failed: %v", err)
+	}
+
+	typeID := uuid.Must(uuid.NewV7()).String()
+	eventID := uuid.Must(uuid.NewV7()).String()
+	data := func(v interface{}) json.RawMessage {
+		b, _ := json.Marshal(v)
+		return b
+	}
+
+	resp, err := push.Push(ctx, userID, &models.SyncPushRequest{Mutations: []models.SyncMutation{
+		// Created in order: the event references the event type
+		{EntityType: models.EntityTypeEventType, Operation: models.OperationCreate, EntityID: typeID,
+			Data: data(models.CreateEventTypeRequest{Name: "Swim", Color: "#00f", Icon: "swim"})},
+		{EntityType: models.EntityTypeEvent, Operation: models.OperationCreate, EntityID: eventID,
+			Data: data(models.CreateEventRequest{EventTypeID: typeID, Timestamp: time.Now()})},
+		// Based on the version before the rename: server wins
+		{EntityType: models.EntityTypeEventType, Operation: models.OperationUpdate, EntityID: stale.ID, BaseUpdatedAt: &seen,
+			Data: data(map[string]string{"name": "Jog"})},
+		// Deleting something already gone succeeds
+		{EntityType: models.EntityTypeGeofence, Operation: models.OperationDelete, EntityID: uuid.Must(uuid.NewV7()).String()},
+		// Missing required fields
+		{EntityType: models.EntityTypeEventType, Operation: models.OperationCreate, EntityID: uuid.Must(uuid.NewV7()).String(),
+			Data: data(map[string]string{"name": "No color"})},
+	}})
+	if err != nil {
+		t.Fatalf("Push failed: %v", err)
+	}
+
+	want := []models.SyncMutationStatus{
+		models.SyncMutationApplied,
+		models.SyncMutationApplied,
+		models.SyncMutationConflict,
+		models.SyncMutationApplied,
+		models.SyncMutationRejected,
+	}
+	for i, status := range want {
+		if resp.Results[i].Status != status {
+			t.Errorf("mutation %d: expected %s, got %s (%s)", i, status, resp.Results[i].Status, resp.Results[i].Error)
+		}
+	}
+	if resp.Applied != 3 || resp.Conflicts != 1 || resp.Rejected != 1 {
+		t.Errorf("unexpected counts: %+v", resp)
+	}
+
+	if et, ok := resp.Results[2].Entity.(*models.EventType); !ok || et.Name != "Running" {
+		t.Errorf("expected conflict to return the server version, got %+v", resp.Results[2].Entity)
+	}
+	if _, err := eventService.GetEvent(ctx, userID, eventID); err != nil {
+		t.Errorf("expected pushed event to exist: %v", err)
+	}
+}
+
+func TestPushCreateOverTrashedEntityConflicts(t *testing.T) {
+	ctx := context.Background()
+	repos := memory.NewRepositories(memory.NewStore())
+	userID := "user-1"
+
+	eventService := NewEventService(repos.Events, repos.EventTypes, repos.PropertyDefinitions, repos.ChangeLog, repos.Transactor)
+	eventTypeService := NewEventTypeService(repos.EventTypes, repos.Events, repos.PropertyDefinitions, repos.Geofences, repos.Insights, repos.Streaks, repos.ChangeLog, repos.Transactor)
+	propertyDefService := NewPropertyDefinitionService(repos.PropertyDefinitions, repos.EventTypes, repos.Events, repos.ChangeLog, repos.Transactor)
+	geofenceService := NewGeofenceService(repos.Geofences, repos.ChangeLog, repos.Transactor)
+	push := NewSyncPushService(eventService, eventTypeService, propertyDefService, geofenceService, repos.Events, repos.EventTypes, repos.Transactor)
+
+	typeID := uuid.Must(uuid.NewV7()).String()
+	if _, err := eventTypeService.CreateEventType(ctx, userID, &models.CreateEventTypeRequest{ID: &typeID, Name: "Run", Color: "#f00", Icon: "run"}); err != nil {
+		t.Fatalf("CreateEventType failed: %v", err)
+	}
+	if err := eventTypeService.DeleteEventType(ctx, userID, typeID, nil); err != nil {
+		t.Fatalf("DeleteEventType failed: %v", err)
+	}
+
+	data, _ := json.Marshal(models.CreateEventTypeRequest{Name: "Swim", Color: "#00f", Icon: "swim"})
+	resp, err := push.Push(ctx, userID, &models.SyncPushRequest{Mutations: []models.SyncMutation{
+		{EntityType: models.EntityTypeEventType, Operation: models.OperationCreate, EntityID: typeID, Data: data},
+	}})
+	if err != nil {
+		t.Fatalf("Push failed: %v", err)
+	}
+
+	result := resp.Results[0]
+	if result.Status != models.SyncMutationConflict {
+		t.Fatalf("expected conflict, got %s (%s)", result.Status, result.Error)
+	}
+	if et, ok := result.Entity.(*models.EventType); !ok || et.Name != "Run" || et.DeletedAt == nil {
+		t.Errorf("expected the trashed event type, got %+v", result.Entity)
+	}
+
+	trashed, err := repos.EventTypes.GetDeletedByID(ctx, typeID)
+	if err != nil || trashed.Name != "Run" {
+		t.Errorf("expected the trashed event type to be untouched, got %+v (%v)", trashed, err)
+	}
+}
+
+func TestPushStopsOnStorageFailure(t *testing.T) {
+	ctx := context.Background()
+	repos := memory.NewRepositories(memory.NewStore())
+	userID := "user-1"
+
+	eventService := NewEventService(repos.Events, repos.EventTypes, repos.PropertyDefinitions, repos.ChangeLog, repos.Transactor)
+	eventTypeService := NewEventTypeService(repos.EventTypes, repos.Events, repos.PropertyDefinitions, repos.Geofences, repos.Insights, repos.Streaks, repos.ChangeLog, repos.Transactor)
+	propertyDefService := NewPropertyDefinitionService(repos.PropertyDefinitions, repos.EventTypes, repos.Events, repos.ChangeLog, repos.Transactor)
+	geofenceService := NewGeofenceService(repos.Geofences, failingChangeLog{repos.ChangeLog}, repos.Transactor)
+	push := NewSyncPushService(eventService, eventTypeService, propertyDefService, geofenceService, repos.Events, repos.EventTypes, repos.Transactor)
+
+	typeID := uuid.Must(uuid.NewV7()).String()
+	geofenceID := uuid.Must(uuid.NewV7()).String()
+	data := func(v interface{}) json.RawMessage {
+		b, _ := json.Marshal(v)
+		return b
+	}
+
+	// The geofence's change log entry cannot be written, which the client
+	// must retry rather than discard
+	_, err := push.Push(ctx, userID, &models.SyncPushRequest{Mutations: []models.SyncMutation{
+		{EntityType: models.EntityTypeEventType, Operation: models.OperationCreate, EntityID: typeID,
+			Data: data(models.CreateEventTypeRequest{Name: "Swim", Color: "#00f", Icon: "swim"})},
+		{EntityType: models.EntityTypeGeofence, Operation: models.OperationCreate, EntityID: geofenceID,
+			Data: data(map[string]interface{}{"name": "Pool", "latitude": 1, "longitude": 1, "radius": 100})},
+	}})
+	if err == nil {
+		t.Fatalf("expected the storage failure to be returned, got %v", err)
+	}
+
+	if _, err := eventTypeService.GetEventType(ctx, userID, typeID); err != nil {
+		t.Errorf("expected the mutation before the failure to stay applied: %v", err)
+	}
+}
+
+func TestRejectionClassifiesErrors(t *testing.T) {
+	tests := []struct {
+		err      error
+		rejected bool
+	}{
+		{fmt.Errorf("radius must be between 50 and 10000 meters"), true},
+		{&PropertyValidationError{}, true},
+		{fmt.Errorf("failed to create event: %w", errors.New(`duplicate key value violates unique constraint "idx_events_manual_dedupe" (SQLSTATE 23505)`)), true},
+		{fmt.Errorf("failed to create event: %w", errors.New("connection refused")), false},
+		{fmt.Errorf("event type not found: %w", context.DeadlineExceeded), false},
+	}
+	for _, tt := range tests {
+		message, rejected := rejection(tt.err)
+		if rejected != tt.rejected {
+			t.Errorf("%v: expected rejected=%v, got %v", tt.err, tt.rejected, rejected)
+		}
+		if rejected && strings.Contains(message, "SQLSTATE") {
+			t.Errorf("%v: storage error text reported to the client: %s", tt.err, message)
+		}
+	}
+}
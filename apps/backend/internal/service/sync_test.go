@@ -0,0 +1,40 @@
+package service
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/JonnyWalker81/trendy/backend/internal/models"
+	"github.com/JonnyWalker81/trendy/backend/internal/repository/memory"
+)
+
+func TestGetSnapshotReturnsEveryEventOnce(t *testing.T) {
+	ctx := context.Background()
+	repos := memory.NewRepositories(memory.NewStore())
+	svc := NewSyncService(repos.Events, repos.EventTypes, repos.PropertyDefinitions, repos.Geofences, repos.ChangeLog, repos.Transactor)
+
+	// Events sharing a timestamp straddle the page boundary
+	start := time.Date(2026, 3, 1, 0, 0, 0, 0, time.UTC)
+	for i := 0; i < snapshotEventPageSize+5; i++ {
+		timestamp := start.Add(time.Duration(i/10) * time.Minute)
+		if _, err := repos.Events.Create(ctx, &models.Event{UserID: "user-1", EventTypeID: "run", Timestamp: timestamp, SourceType: "healthkit"}); err != nil {
+			t.Fatalf("Create failed: %v", err)
+		}
+	}
+
+	snapshot, err := svc.GetSnapshot(ctx, "user-1")
+	if err != nil {
+		t.Fatalf("GetSnapshot failed: %v", err)
+	}
+	seen := make(map[string]bool, len(snapshot.Events))
+	for _, event := range snapshot.Events {
+		if seen[event.ID] {
+			t.Fatalf("event %s returned twice", event.ID)
+		}
+		seen[event.ID] = true
+	}
+	if len(seen) != snapshotEventPageSize+5 {
+		t.Errorf("expected %d events, got %d", snapshotEventPageSize+5, len(seen))
+	}
+}
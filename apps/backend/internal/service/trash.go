@@ -0,0 +1,125 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/JonnyWalker81/trendy/backend/internal/logger"
+	"github.com/JonnyWalker81/trendy/backend/internal/models"
+	"github.com/JonnyWalker81/trendy/backend/internal/repository"
+)
+
+// ErrEventTypeInTrash is returned when restoring an event whose event type
+// is still in the trash
+var ErrEventTypeInTrash = errors.New("event type is in the trash")
+
+// trashTimestamp returns the deleted_at value for items moved to the trash.
+// It has the microsecond precision of Postgres so an event type's restore can
+// match the events deleted with it exactly.
+func trashTimestamp() time.Time {
+	return time.Now().UTC().Truncate(time.Microsecond)
+}
+
+// PurgeResult summarizes one trash purge run
+type PurgeResult struct {
+	EventTypes int64 `json:"event_types"` // Event types removed, with their events
+	Events     int64 `json:"events"`      // Events removed on their own
+}
+
+type trashService struct {
+	eventRepo     repository.EventRepository
+	eventTypeRepo repository.EventTypeRepository
+	retention     time.Duration
+}
+
+// NewTrashService creates a trash service. Items stay restorable for
+// retention after they are deleted; a zero retention never purges.
+func NewTrashService(eventRepo repository.EventRepository, eventTypeRepo repository.EventTypeRepository, retention time.Duration) TrashService {
+	return &trashService{
+		eventRepo:     eventRepo,
+		eventTypeRepo: eventTypeRepo,
+		retention:     retention,
+	}
+}
+
+func (s *trashService) GetTrash(ctx context.Context, userID string) (*models.Trash, error) {
+	eventTypes, err := s.eventTypeRepo.GetDeletedByUserID(ctx, userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get deleted event types: %w", err)
+	}
+
+	events, err := s.eventRepo.GetDeletedByUserID(ctx, userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get deleted events: %w", err)
+	}
+
+	trash := &models.Trash{
+		EventTypes: make([]models.EventType, 0, len(eventTypes)),
+		Events:     make([]models.Event, 0, len(events)),
+	}
+	trash.EventTypes = append(trash.EventTypes, eventTypes...)
+
+	// Events of a trashed event type are restored with it
+	trashedTypes := make(map[string]bool, len(eventTypes))
+	for _, et := range eventTypes {
+		trashedTypes[et.ID] = true
+	}
+	for _, event := range events {
+		if !trashedTypes[event.EventTypeID] {
+			trash.Events = append(trash.Events, event)
+		}
+	}
+
+	return trash, nil
+}
+
+func (s *trashService) Purge(ctx context.Context) (*PurgeResult, error) {
+	result := &PurgeResult{}
+	if s.retention <= 0 {
+		return result, nil
+	}
+
+	before := time.Now().Add(-s.retention)
+
+	// Event types first, so their events go with them in the cascade
+	eventTypes, err := s.eventTypeRepo.PurgeDeleted(ctx, before)
+	if err != nil {
+		return nil, fmt.Errorf("failed to purge event types: %w", err)
+	}
+	result.EventTypes = eventTypes
+
+	events, err := s.eventRepo.PurgeDeleted(ctx, before)
+	if err != nil {
+		return nil, fmt.Errorf("failed to purge events: %w", err)
+	}
+	result.Events = events
+
+	return result, nil
+}
+
+// RunTrashPurge purges expired trash every interval until ctx is done.
+// Failures are logged and retried on the next tick.
+func RunTrashPurge(ctx context.Context, trash TrashService, interval time.Duration) {
+	log := logger.FromContext(ctx)
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			result, err := trash.Purge(ctx)
+			if err != nil {
+				log.Error("trash purge failed", logger.Err(err))
+				continue
+			}
+			log.Info("trash purge complete",
+				logger.Int64("event_types", result.EventTypes),
+				logger.Int64("events", result.Events),
+			)
+		}
+	}
+}
@@ -0,0 +1,135 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/JonnyWalker81/trendy/backend/internal/models"
+	"github.com/JonnyWalker81/trendy/backend/internal/repository/memory"
+)
+
+func TestTrashRestoreAndPurge(t *testing.T) {
+	ctx := context.Background()
+	repos := memory.NewRepositories(memory.NewStore())
+	userID := "user-1"
+
+	eventService := NewEventService(repos.Events, repos.EventTypes, repos.PropertyDefinitions, repos.ChangeLog, repos.Transactor)
+	eventTypeService := NewEventTypeService(repos.EventTypes, repos.Events, repos.PropertyDefinitions, repos.Geofences, repos.Insights, repos.Streaks, repos.ChangeLog, repos.Transactor)
+	propertyDefService := NewPropertyDefinitionService(repos.PropertyDefinitions, repos.EventTypes, repos.Events, repos.ChangeLog, repos.Transactor)
+	trash := NewTrashService(repos.Events, repos.EventTypes, time.Hour)
+
+	run, err := eventTypeService.CreateEventType(ctx, userID, &models.CreateEventTypeRequest{Name: "Run", Color: "#f00", Icon: "run"})
+	if err != nil {
+		t.Fatalf("CreateEventType failed: %v", err)
+	}
+	if _, err := propertyDefService.CreatePropertyDefinition(ctx, userID, &models.CreatePropertyDefinitionRequest{
+		EventTypeID: run.ID, Key: "distance", Label: "Distance", PropertyType: models.PropertyTypeNumber,
+	}); err != nil {
+		t.Fatalf("CreatePropertyDefinition failed: %v", err)
+	}
+	morning, _, err := eventService.CreateEvent(ctx, userID, &models.CreateEventRequest{EventTypeID: run.ID, Timestamp: time.Now().Add(-time.Hour)})
+	if err != nil {
+		t.Fatalf("CreateEvent failed: %v", err)
+	}
+	evening, _, err := eventService.CreateEvent(ctx, userID, &models.CreateEventRequest{EventTypeID: run.ID, Timestamp: time.Now()})
+	if err != nil {
+		t.Fatalf("CreateEvent failed: %v", err)
+	}
+
+	// The evening event is deleted on its own before the whole type
+	if err := eventService.DeleteEvent(ctx, userID, evening.ID); err != nil {
+		t.Fatalf("DeleteEvent failed: %v", err)
+	}
+	time.Sleep(time.Millisecond)
+	if err := eventTypeService.DeleteEventType(ctx, userID, run.ID, nil); err != nil {
+		t.Fatalf("DeleteEventType failed: %v", err)
+	}
+
+	if _, err := eventService.GetEvent(ctx, userID, morning.ID); err == nil {
+		t.Error("trashed event should not be readable")
+	}
+	if types, _ := eventTypeService.GetUserEventTypes(ctx, userID, false); len(types) != 0 {
+		t.Errorf("expected no live event types, got %d", len(types))
+	}
+
+	// The name is free while the original is in the trash
+	if _, err := eventTypeService.CreateEventType(ctx, userID, &models.CreateEventTypeRequest{Name: "Run", Color: "#0f0", Icon: "run"}); err != nil {
+		t.Fatalf("CreateEventType with a trashed name failed: %v", err)
+	}
+
+	contents, err := trash.GetTrash(ctx, userID)
+	if err != nil {
+		t.Fatalf("GetTrash failed: %v", err)
+	}
+	if len(contents.EventTypes) != 1 || len(contents.Events) != 0 {
+		t.Fatalf("expected 1 event type and no separate events in trash, got %d and %d",
+			len(contents.EventTypes), len(contents.Events))
+	}
+
+	if _, err := eventService.RestoreEvent(ctx, userID, evening.ID); !errors.Is(err, ErrEventTypeInTrash) {
+		t.Fatalf("expected ErrEventTypeInTrash, got %v", err)
+	}
+	if _, err := eventTypeService.RestoreEventType(ctx, userID, run.ID); err == nil {
+		t.Fatal("restoring over a live event type with the same name should fail")
+	}
+
+	// Free the name again and restore
+	live, _ := eventTypeService.GetUserEventTypes(ctx, userID, false)
+	if err := eventTypeService.DeleteEventType(ctx, userID, live[0].ID, nil); err != nil {
+		t.Fatalf("DeleteEventType failed: %v", err)
+	}
+	cursor, _ := repos.ChangeLog.GetLatestCursor(ctx, userID)
+	if _, err := eventTypeService.RestoreEventType(ctx, userID, run.ID); err != nil {
+		t.Fatalf("RestoreEventType failed: %v", err)
+	}
+
+	// Only the event deleted with the type comes back with it
+	if _, err := eventService.GetEvent(ctx, userID, morning.ID); err != nil {
+		t.Errorf("expected event deleted with its type to be restored: %v", err)
+	}
+	if _, err := eventService.GetEvent(ctx, userID, evening.ID); err == nil {
+		t.Error("event deleted separately should stay in the trash")
+	}
+
+	changes, err := repos.ChangeLog.GetSince(ctx, userID, cursor, 100)
+	if err != nil {
+		t.Fatalf("GetSince failed: %v", err)
+	}
+	wantEntities := []models.EntityType{models.EntityTypeEventType, models.EntityTypePropertyDefinition, models.EntityTypeEvent}
+	if len(changes.Changes) != len(wantEntities) {
+		t.Fatalf("expected %d change entries, got %d", len(wantEntities), len(changes.Changes))
+	}
+	for i, change := range changes.Changes {
+		if change.Operation != models.OperationCreate || change.EntityType != wantEntities[i] {
+			t.Errorf("change %d: got %s %s, want create %s", i, change.Operation, change.EntityType, wantEntities[i])
+		}
+	}
+
+	restored, err := eventService.RestoreEvent(ctx, userID, evening.ID)
+	if err != nil {
+		t.Fatalf("RestoreEvent failed: %v", err)
+	}
+	if restored.DeletedAt != nil {
+		t.Error("restored event should not have deleted_at")
+	}
+
+	// Only items deleted before the retention window are purged
+	if result, err := trash.Purge(ctx); err != nil || result.EventTypes != 0 || result.Events != 0 {
+		t.Fatalf("expected nothing to purge yet, got %+v, %v", result, err)
+	}
+	if err := eventService.DeleteEvent(ctx, userID, evening.ID); err != nil {
+		t.Fatalf("DeleteEvent failed: %v", err)
+	}
+	result, err := NewTrashService(repos.Events, repos.EventTypes, time.Nanosecond).Purge(ctx)
+	if err != nil {
+		t.Fatalf("Purge failed: %v", err)
+	}
+	if result.EventTypes != 1 || result.Events != 1 {
+		t.Errorf("expected 1 event type and 1 event purged, got %+v", result)
+	}
+	if _, err := repos.Events.GetDeletedByID(ctx, evening.ID); err == nil {
+		t.Error("purged event should be gone")
+	}
+}
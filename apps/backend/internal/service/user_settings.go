@@ -0,0 +1,74 @@
+package service
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/JonnyWalker81/trendy/backend/internal/models"
+	"github.com/JonnyWalker81/trendy/backend/internal/repository"
+)
+
+type userSettingsService struct {
+	settingsRepo repository.UserSettingsRepository
+	insightRepo  repository.InsightRepository
+}
+
+// NewUserSettingsService creates a new user settings service
+func NewUserSettingsService(settingsRepo repository.UserSettingsRepository, insightRepo repository.InsightRepository) UserSettingsService {
+	return &userSettingsService{
+		settingsRepo: settingsRepo,
+		insightRepo:  insightRepo,
+	}
+}
+
+func (s *userSettingsService) GetSettings(ctx context.Context, userID string) (*models.UserSettings, error) {
+	settings, err := s.settingsRepo.GetByUserID(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	if settings == nil {
+		settings = &models.UserSettings{
+			UserID:    userID,
+			Timezone:  models.DefaultTimezone,
+			WeekStart: models.DefaultWeekStart,
+		}
+	}
+
+	return settings, nil
+}
+
+func (s *userSettingsService) UpdateSettings(ctx context.Context, userID string, req *models.UpdateUserSettingsRequest) (*models.UserSettings, error) {
+	current, err := s.GetSettings(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	settings := *current
+	if req.Timezone != nil {
+		settings.Timezone = *req.Timezone
+	}
+	if req.WeekStart != nil {
+		settings.WeekStart = *req.WeekStart
+	}
+	if settings.Timezone == "" || settings.WeekStart == "" {
+		return nil, fmt.Errorf("%w: timezone and week_start cannot be empty", ErrInvalidUserSettings)
+	}
+	if _, err := newCalendar(settings.Timezone, settings.WeekStart); err != nil {
+		return nil, err
+	}
+
+	updated, err := s.settingsRepo.Upsert(ctx, &settings)
+	if err != nil {
+		return nil, err
+	}
+
+	// Cached insights were computed on the old days and weeks
+	if settings.Timezone != current.Timezone || settings.WeekStart != current.WeekStart {
+		if err := s.insightRepo.InvalidateAll(ctx, userID); err != nil {
+			return nil, fmt.Errorf("failed to invalidate insights: %w", err)
+		}
+	}
+
+	return updated, nil
+}
@@ -242,6 +242,36 @@ func (c *Client) VerifyToken(token string) (*User, error) {
 	return &user, nil
 }
 
+// DeleteUser deletes a user from Supabase Auth with the admin API. A user
+// that does not exist is treated as already deleted.
+func (c *Client) DeleteUser(userID string) error {
+	url := fmt.Sprintf("%s/auth/v1/admin/users/%s", c.URL, userID)
+
+	req, err := http.NewRequest("DELETE", url, nil)
+	if err != nil {
+		return err
+	}
+
+	req.Header.Set("apikey", c.ServiceKey)
+	req.Header.Set("Authorization", fmt.Sprintf("Bearer %s", c.ServiceKey))
+
+	resp, err := c.HTTPClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to delete user: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode == http.StatusNotFound {
+		return nil
+	}
+	if resp.StatusCode >= 400 {
+		body, _ := io.ReadAll(resp.Body)
+		return fmt.Errorf("user deletion failed (status %d): %s", resp.StatusCode, string(body))
+	}
+
+	return nil
+}
+
 // User represents a Supabase user
 type User struct {
 	ID    string `json:"id"`
@@ -403,3 +433,42 @@ func (c *Client) UpdateWhereWithToken(table string, query map[string]interface{}
 
 	return body, nil
 }
+
+// RPC calls a Postgres function exposed by PostgREST and returns its result
+func (c *Client) RPC(function string, params interface{}) ([]byte, error) {
+	url := fmt.Sprintf("%s/rest/v1/rpc/%s", c.URL, function)
+
+	if params == nil {
+		params = map[string]interface{}{}
+	}
+	jsonData, err := json.Marshal(params)
+	if err != nil {
+		return nil, err
+	}
+
+	req, err := http.NewRequest("POST", url, bytes.NewBuffer(jsonData))
+	if err != nil {
+		return nil, err
+	}
+
+	req.Header.Set("apikey", c.ServiceKey)
+	req.Header.Set("Authorization", fmt.Sprintf("Bearer %s", c.ServiceKey))
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := c.HTTPClient.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, err
+	}
+
+	if resp.StatusCode >= 400 {
+		return nil, fmt.Errorf("supabase error: %s", string(body))
+	}
+
+	return body, nil
+}
@@ -0,0 +1,95 @@
+-- Migration: Change log compaction and retention
+-- This migration adds:
+-- 1. change_log_horizons table recording the newest entry removed per user
+-- 2. compact_change_log() to remove entries superseded by a later entry
+-- 3. prune_change_log() to remove entries older than the retention window
+
+-- ============================================================================
+-- Change Log Horizons Table
+-- ============================================================================
+-- Compaction only removes entries whose entity has a later entry, so a client
+-- at any cursor still converges. Pruning by age loses changes: a client whose
+-- cursor is below the user's horizon must bootstrap from a snapshot.
+
+CREATE TABLE IF NOT EXISTS public.change_log_horizons (
+    user_id UUID PRIMARY KEY REFERENCES public.users(id) ON DELETE CASCADE,
+    cursor BIGINT NOT NULL,                                     -- Highest change_log id removed by pruning
+    updated_at TIMESTAMP WITH TIME ZONE DEFAULT NOW() NOT NULL
+);
+
+ALTER TABLE public.change_log_horizons ENABLE ROW LEVEL SECURITY;
+
+CREATE POLICY "Users can view own change log horizon"
+    ON public.change_log_horizons FOR SELECT
+    USING (auth.uid() = user_id);
+
+CREATE POLICY "Service role can manage change log horizons"
+    ON public.change_log_horizons FOR ALL
+    USING (true)
+    WITH CHECK (true);
+
+-- Supports finding later entries for the same entity during compaction
+CREATE INDEX IF NOT EXISTS idx_change_log_compaction
+    ON public.change_log(user_id, entity_type, entity_id, id);
+
+-- ============================================================================
+-- Compaction
+-- ============================================================================
+
+CREATE OR REPLACE FUNCTION public.compact_change_log()
+RETURNS INTEGER AS $$
+DECLARE
+    v_deleted_count INTEGER;
+BEGIN
+    DELETE FROM public.change_log c
+    WHERE EXISTS (
+        SELECT 1 FROM public.change_log n
+        WHERE n.user_id = c.user_id
+          AND n.entity_type = c.entity_type
+          AND n.entity_id = c.entity_id
+          AND n.id > c.id
+    );
+
+    GET DIAGNOSTICS v_deleted_count = ROW_COUNT;
+    RETURN v_deleted_count;
+END;
+$$ LANGUAGE plpgsql SECURITY DEFINER;
+
+GRANT EXECUTE ON FUNCTION public.compact_change_log TO service_role;
+
+-- ============================================================================
+-- Retention
+-- ============================================================================
+
+CREATE OR REPLACE FUNCTION public.prune_change_log(
+    p_before TIMESTAMP WITH TIME ZONE
+) RETURNS INTEGER AS $$
+DECLARE
+    v_deleted_count INTEGER;
+BEGIN
+    WITH pruned AS (
+        DELETE FROM public.change_log
+        WHERE created_at < p_before
+        RETURNING user_id, id
+    ), horizons AS (
+        INSERT INTO public.change_log_horizons (user_id, cursor)
+        SELECT user_id, MAX(id) FROM pruned GROUP BY user_id
+        ON CONFLICT (user_id) DO UPDATE
+            SET cursor = GREATEST(public.change_log_horizons.cursor, EXCLUDED.cursor),
+                updated_at = NOW()
+    )
+    SELECT COUNT(*) INTO v_deleted_count FROM pruned;
+
+    RETURN v_deleted_count;
+END;
+$$ LANGUAGE plpgsql SECURITY DEFINER;
+
+GRANT EXECUTE ON FUNCTION public.prune_change_log TO service_role;
+
+-- ============================================================================
+-- Comments for documentation
+-- ============================================================================
+
+COMMENT ON TABLE public.change_log_horizons IS 'Highest change_log id removed by retention per user. Cursors below it must re-bootstrap from a snapshot.';
+COMMENT ON FUNCTION public.compact_change_log IS 'Removes change log entries superseded by a later entry for the same entity.';
+COMMENT ON FUNCTION public.prune_change_log IS 'Removes change log entries created before p_before and advances each user''s horizon.';
@@ -0,0 +1,95 @@
+-- Migration: Soft delete for events and event types
+-- This migration adds:
+-- 1. deleted_at columns on events and event_types
+-- 2. Uniqueness that only applies to rows that are not in the trash
+-- 3. purge_deleted_event_types() and purge_deleted_events() for the purge job
+
+-- ============================================================================
+-- Deleted At Columns
+-- ============================================================================
+-- Deleting an event or event type moves it to the trash by setting deleted_at.
+-- Trashed rows are hidden from every read except the trash listing and are
+-- removed for good once they are older than the trash retention window.
+
+ALTER TABLE public.events ADD COLUMN IF NOT EXISTS deleted_at TIMESTAMP WITH TIME ZONE;
+ALTER TABLE public.event_types ADD COLUMN IF NOT EXISTS deleted_at TIMESTAMP WITH TIME ZONE;
+
+-- Supports the trash listing and the purge job
+CREATE INDEX IF NOT EXISTS idx_events_trash
+    ON public.events(user_id, deleted_at)
+    WHERE deleted_at IS NOT NULL;
+
+CREATE INDEX IF NOT EXISTS idx_event_types_trash
+    ON public.event_types(user_id, deleted_at)
+    WHERE deleted_at IS NOT NULL;
+
+-- ============================================================================
+-- Uniqueness
+-- ============================================================================
+-- A trashed event type must not block creating a new one with the same name,
+-- and a trashed manual event must not block logging the same moment again.
+-- Restoring fails with a unique violation if a live row has taken its place.
+-- HealthKit dedupe still covers trashed rows so a re-import does not bring
+-- back samples the user deleted.
+
+ALTER TABLE public.event_types DROP CONSTRAINT IF EXISTS event_types_user_id_name_key;
+
+CREATE UNIQUE INDEX IF NOT EXISTS event_types_user_id_name_key
+    ON public.event_types(user_id, name)
+    WHERE deleted_at IS NULL;
+
+DROP INDEX IF EXISTS public.idx_events_manual_dedupe;
+
+CREATE UNIQUE INDEX IF NOT EXISTS idx_events_manual_dedupe
+    ON public.events(user_id, event_type_id, timestamp)
+    WHERE source_type != 'healthkit' AND deleted_at IS NULL;
+
+-- ============================================================================
+-- Purge
+-- ============================================================================
+
+CREATE OR REPLACE FUNCTION public.purge_deleted_event_types(
+    p_before TIMESTAMP WITH TIME ZONE
+) RETURNS INTEGER AS $$
+DECLARE
+    v_deleted_count INTEGER;
+BEGIN
+    -- Events, property definitions and analytics rows cascade
+    DELETE FROM public.event_types
+    WHERE deleted_at IS NOT NULL AND deleted_at < p_before;
+
+    GET DIAGNOSTICS v_deleted_count = ROW_COUNT;
+    RETURN v_deleted_count;
+END;
+$$ LANGUAGE plpgsql SECURITY DEFINER;
+
+GRANT EXECUTE ON FUNCTION public.purge_deleted_event_types TO service_role;
+
+CREATE OR REPLACE FUNCTION public.purge_deleted_events(
+    p_before TIMESTAMP WITH TIME ZONE
+) RETURNS INTEGER AS $$
+DECLARE
+    v_deleted_count INTEGER;
+BEGIN
+    DELETE FROM public.events
+    WHERE deleted_at IS NOT NULL AND deleted_at < p_before;
+
+    GET DIAGNOSTICS v_deleted_count = ROW_COUNT;
+    RETURN v_deleted_count;
+END;
+$$ LANGUAGE plpgsql SECURITY DEFINER;
+
+GRANT EXECUTE ON FUNCTION public.purge_deleted_events TO service_role;
+
+-- ============================================================================
+-- Comments for documentation
+-- ============================================================================
+
+COMMENT ON COLUMN public.events.deleted_at IS 'When the event was moved to the trash. NULL for live events.';
+COMMENT ON COLUMN public.event_types.deleted_at IS 'When the event type was moved to the trash. NULL for live event types.';
+COMMENT ON INDEX event_types_user_id_name_key IS
+    'Ensures live event type names are unique within a user.';
+COMMENT ON INDEX idx_events_manual_dedupe IS
+    'Ensures live non-HealthKit events are unique by event type and timestamp within a user.';
+COMMENT ON FUNCTION public.purge_deleted_event_types IS 'Permanently removes event types trashed before p_before, cascading to their events.';
+COMMENT ON FUNCTION public.purge_deleted_events IS 'Permanently removes events trashed before p_before.';
@@ -0,0 +1,18 @@
+-- Migration: Archived event types
+-- This migration adds:
+-- 1. archived_at column on event_types
+
+-- ============================================================================
+-- Archived At Column
+-- ============================================================================
+-- An archived event type keeps its events and still syncs to clients, but is
+-- left out of the event type list used by pickers. Unlike a deleted event
+-- type it never expires.
+
+ALTER TABLE public.event_types ADD COLUMN IF NOT EXISTS archived_at TIMESTAMP WITH TIME ZONE;
+
+-- ============================================================================
+-- Comments for documentation
+-- ============================================================================
+
+COMMENT ON COLUMN public.event_types.archived_at IS 'When the event type was archived. NULL for active event types.';
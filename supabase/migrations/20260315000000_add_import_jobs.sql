@@ -0,0 +1,56 @@
+-- Migration: Bulk import jobs
+-- This migration adds:
+-- 1. import_jobs table tracking the progress of background event imports
+-- 2. RLS policies so users can only read their own jobs
+-- 3. Trigger for automatic updated_at timestamp
+
+-- ============================================================================
+-- Import Jobs Table
+-- ============================================================================
+-- An import is validated when it is uploaded and then runs in the background.
+-- The job records progress and the rows that could not be imported.
+
+CREATE TABLE IF NOT EXISTS public.import_jobs (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    user_id UUID NOT NULL REFERENCES public.users(id) ON DELETE CASCADE,
+    status TEXT NOT NULL DEFAULT 'pending',
+    format TEXT NOT NULL,
+    total_rows INTEGER NOT NULL DEFAULT 0,
+    processed_rows INTEGER NOT NULL DEFAULT 0,
+    created_count INTEGER NOT NULL DEFAULT 0,
+    failed_count INTEGER NOT NULL DEFAULT 0,
+    errors JSONB NOT NULL DEFAULT '[]'::jsonb,                  -- Per-row validation and insert errors
+    error TEXT,                                                  -- Set when the job failed as a whole
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT NOW() NOT NULL,
+    updated_at TIMESTAMP WITH TIME ZONE DEFAULT NOW() NOT NULL,
+    completed_at TIMESTAMP WITH TIME ZONE,
+
+    CONSTRAINT check_import_job_status
+        CHECK (status IN ('pending', 'running', 'completed', 'failed')),
+    CONSTRAINT check_import_job_format
+        CHECK (format IN ('csv', 'json', 'ndjson', 'ics'))
+);
+
+CREATE INDEX IF NOT EXISTS idx_import_jobs_user_id
+    ON public.import_jobs(user_id, created_at DESC);
+
+ALTER TABLE public.import_jobs ENABLE ROW LEVEL SECURITY;
+
+CREATE POLICY "Users can view own import jobs"
+    ON public.import_jobs FOR SELECT
+    USING (auth.uid() = user_id);
+
+CREATE POLICY "Service role can manage import jobs"
+    ON public.import_jobs FOR ALL
+    USING (true)
+    WITH CHECK (true);
+
+CREATE TRIGGER update_import_jobs_updated_at
+    BEFORE UPDATE ON public.import_jobs
+    FOR EACH ROW EXECUTE FUNCTION public.update_updated_at_column();
+
+-- ============================================================================
+-- Comments for documentation
+-- ============================================================================
+
+COMMENT ON TABLE public.import_jobs IS 'Progress and per-row errors of background event imports.';
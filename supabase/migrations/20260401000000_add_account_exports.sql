@@ -0,0 +1,64 @@
+-- Migration: Account exports
+-- This migration adds:
+-- 1. account_exports table holding zip archives of everything a user owns
+-- 2. RLS policies so users can only read their own exports
+-- 3. Trigger for automatic updated_at timestamp
+-- 4. 'archive' as an import job format, for restores from an account export
+
+-- ============================================================================
+-- Account Exports Table
+-- ============================================================================
+-- An export is built in the background. Only the latest export of a user is
+-- kept, and its archive can be downloaded until expires_at.
+
+CREATE TABLE IF NOT EXISTS public.account_exports (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    user_id UUID NOT NULL REFERENCES public.users(id) ON DELETE CASCADE,
+    status TEXT NOT NULL DEFAULT 'pending',
+    archive BYTEA,                                               -- Zip archive, set once completed
+    size_bytes BIGINT NOT NULL DEFAULT 0,
+    error TEXT,                                                  -- Set when the export failed
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT NOW() NOT NULL,
+    updated_at TIMESTAMP WITH TIME ZONE DEFAULT NOW() NOT NULL,
+    completed_at TIMESTAMP WITH TIME ZONE,
+    expires_at TIMESTAMP WITH TIME ZONE,
+
+    CONSTRAINT check_account_export_status
+        CHECK (status IN ('pending', 'running', 'completed', 'failed'))
+);
+
+CREATE INDEX IF NOT EXISTS idx_account_exports_user_id
+    ON public.account_exports(user_id, created_at DESC);
+
+ALTER TABLE public.account_exports ENABLE ROW LEVEL SECURITY;
+
+CREATE POLICY "Users can view own account exports"
+    ON public.account_exports FOR SELECT
+    USING (auth.uid() = user_id);
+
+CREATE POLICY "Service role can manage account exports"
+    ON public.account_exports FOR ALL
+    USING (true)
+    WITH CHECK (true);
+
+CREATE TRIGGER update_account_exports_updated_at
+    BEFORE UPDATE ON public.account_exports
+    FOR EACH ROW EXECUTE FUNCTION public.update_updated_at_column();
+
+-- ============================================================================
+-- Archive Restores
+-- ============================================================================
+
+ALTER TABLE public.import_jobs
+    DROP CONSTRAINT IF EXISTS check_import_job_format;
+
+ALTER TABLE public.import_jobs
+    ADD CONSTRAINT check_import_job_format
+        CHECK (format IN ('csv', 'json', 'ndjson', 'ics', 'archive'));
+
+-- ============================================================================
+-- Comments for documentation
+-- ============================================================================
+
+COMMENT ON TABLE public.account_exports IS 'Zip archives of all data owned by a user, built in the background.';
+COMMENT ON COLUMN public.account_exports.archive IS 'Versioned zip archive with a manifest.json describing its files.';
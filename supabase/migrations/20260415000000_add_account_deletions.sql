@@ -0,0 +1,45 @@
+-- Migration: Account deletions
+-- This migration adds:
+-- 1. account_deletions table holding the audit tombstone of each account deletion
+-- 2. RLS without policies so only the service role can read or write tombstones
+-- 3. Trigger for automatic updated_at timestamp
+
+-- ============================================================================
+-- Account Deletions Table
+-- ============================================================================
+-- A deletion erases the user's rows one table at a time and records each
+-- completed step, so an interrupted deletion can resume where it stopped.
+-- The row is deliberately not a foreign key to users: it outlives the account
+-- and holds nothing but the user ID and timestamps.
+
+CREATE TABLE IF NOT EXISTS public.account_deletions (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    user_id UUID NOT NULL,
+    status TEXT NOT NULL DEFAULT 'pending',
+    completed_steps TEXT[] NOT NULL DEFAULT '{}',
+    requested_at TIMESTAMP WITH TIME ZONE DEFAULT NOW() NOT NULL,
+    updated_at TIMESTAMP WITH TIME ZONE DEFAULT NOW() NOT NULL,
+    completed_at TIMESTAMP WITH TIME ZONE,
+
+    CONSTRAINT check_account_deletion_status
+        CHECK (status IN ('pending', 'completed'))
+);
+
+-- At most one deletion in progress per user
+CREATE UNIQUE INDEX IF NOT EXISTS idx_account_deletions_pending_user
+    ON public.account_deletions(user_id)
+    WHERE status = 'pending';
+
+-- No policies: only the service role, which bypasses RLS, can access tombstones
+ALTER TABLE public.account_deletions ENABLE ROW LEVEL SECURITY;
+
+CREATE TRIGGER update_account_deletions_updated_at
+    BEFORE UPDATE ON public.account_deletions
+    FOR EACH ROW EXECUTE FUNCTION public.update_updated_at_column();
+
+-- ============================================================================
+-- Comments for documentation
+-- ============================================================================
+
+COMMENT ON TABLE public.account_deletions IS 'Audit tombstones of account deletions; kept after the account is erased.';
+COMMENT ON COLUMN public.account_deletions.completed_steps IS 'Erasure steps finished so far, in the order they ran.';
@@ -0,0 +1,41 @@
+-- Migration: Full-text search over events
+-- This migration adds:
+-- 1. event_search_text(), the searchable text of an event
+-- 2. A generated search_vector column on events with a GIN index
+
+-- ============================================================================
+-- Searchable Text
+-- ============================================================================
+-- An event is searched by its original title, notes, location name and the
+-- values of its text and select properties, in that order with properties
+-- sorted by key. The backend's Event.SearchFields lists the same texts.
+-- The "simple" configuration only lowercases words, so a search matches the
+-- words as typed, without stemming or stop words.
+
+CREATE OR REPLACE FUNCTION public.event_search_text(
+    p_original_title TEXT,
+    p_notes TEXT,
+    p_location_name TEXT,
+    p_properties JSONB
+) RETURNS TEXT AS $$
+    SELECT concat_ws(' ',
+        p_original_title,
+        p_notes,
+        p_location_name,
+        (SELECT string_agg(value->>'value', ' ' ORDER BY key COLLATE "C")
+         FROM jsonb_each(COALESCE(p_properties, '{}'::jsonb))
+         WHERE value->>'type' IN ('text', 'select')
+           AND jsonb_typeof(value->'value') = 'string'));
+$$ LANGUAGE sql IMMUTABLE;
+
+-- ============================================================================
+-- Search Vector
+-- ============================================================================
+
+ALTER TABLE public.events ADD COLUMN IF NOT EXISTS search_vector TSVECTOR
+    GENERATED ALWAYS AS (
+        to_tsvector('simple'::regconfig, public.event_search_text(original_title, notes, location_name, properties))
+    ) STORED;
+
+CREATE INDEX IF NOT EXISTS idx_events_search
+    ON public.events USING GIN (search_vector);
@@ -0,0 +1,8 @@
+-- Migration: Required property definitions
+-- Events written through the API must have a value for every required
+-- property of their event type, unless the definition has a default value,
+-- which is filled in instead. Existing events are not checked until they
+-- are next updated.
+
+ALTER TABLE public.property_definitions
+    ADD COLUMN IF NOT EXISTS required BOOLEAN NOT NULL DEFAULT false;
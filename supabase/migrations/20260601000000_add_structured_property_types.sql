@@ -0,0 +1,32 @@
+-- Migration: Structured property types
+-- This migration adds:
+-- 1. The multi_select, rating, quantity and location property types
+-- 2. unit and rating_max columns on property_definitions
+
+-- ============================================================================
+-- Property Types
+-- ============================================================================
+-- multi_select values are arrays of options, rating values whole numbers
+-- from 1 to rating_max, quantity values {"amount", "unit"} objects stored in
+-- the definition's unit, and location values {"latitude", "longitude",
+-- "name"} objects.
+
+ALTER TABLE public.property_definitions DROP CONSTRAINT IF EXISTS check_property_type;
+
+ALTER TABLE public.property_definitions
+ADD CONSTRAINT check_property_type
+CHECK (property_type IN ('text', 'number', 'boolean', 'date', 'select', 'duration', 'url', 'email',
+                         'multi_select', 'rating', 'quantity', 'location'));
+
+COMMENT ON COLUMN public.property_definitions.property_type IS 'Data type: text, number, boolean, date, select, duration, url, email, multi_select, rating, quantity, location';
+
+-- ============================================================================
+-- Type Settings
+-- ============================================================================
+
+ALTER TABLE public.property_definitions
+    ADD COLUMN IF NOT EXISTS unit TEXT,
+    ADD COLUMN IF NOT EXISTS rating_max INTEGER CHECK (rating_max BETWEEN 2 AND 10);
+
+COMMENT ON COLUMN public.property_definitions.unit IS 'Unit quantity values are stored in: g, kg, lb, m, km, mi, ml, l or oz';
+COMMENT ON COLUMN public.property_definitions.rating_max IS 'Top of a rating scale; 5 if null';
@@ -0,0 +1,11 @@
+-- Migration: Computed properties
+-- A property definition with a formula is computed: the backend evaluates
+-- the formula from the event's other properties and times on every write
+-- and stores the result on the event like any other value, so analytics and
+-- correlations read computed values without evaluating formulas. Changing a
+-- formula recomputes the stored values of the event type's events.
+
+ALTER TABLE public.property_definitions
+    ADD COLUMN IF NOT EXISTS formula TEXT CHECK (char_length(formula) <= 500);
+
+COMMENT ON COLUMN public.property_definitions.formula IS 'Arithmetic over other property keys and the event''s timestamp and end_date, e.g. distance / (duration / 3600)';
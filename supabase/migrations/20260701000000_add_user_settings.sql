@@ -0,0 +1,51 @@
+-- Migration: Per-user calendar settings
+-- Analytics, streaks and insights place events on the user's own days,
+-- weeks and hours. This migration adds:
+-- 1. user_settings table with the user's IANA time zone and week start
+-- 2. RLS policies to ensure users can only access their own settings
+-- 3. Trigger for automatic updated_at timestamp
+-- Users without a row use UTC and weeks starting on Sunday.
+
+-- NOTE: user_id is the PRIMARY KEY (one settings record per user)
+CREATE TABLE IF NOT EXISTS public.user_settings (
+    user_id UUID PRIMARY KEY REFERENCES auth.users(id) ON DELETE CASCADE,
+    timezone TEXT NOT NULL DEFAULT 'UTC',
+    week_start TEXT NOT NULL DEFAULT 'sunday',
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT NOW(),
+    updated_at TIMESTAMP WITH TIME ZONE DEFAULT NOW(),
+
+    CONSTRAINT check_week_start CHECK (week_start IN ('sunday', 'monday'))
+);
+
+-- Enable Row Level Security
+ALTER TABLE public.user_settings ENABLE ROW LEVEL SECURITY;
+
+CREATE POLICY "Users can view own settings"
+    ON public.user_settings FOR SELECT
+    USING (auth.uid() = user_id);
+
+CREATE POLICY "Users can insert own settings"
+    ON public.user_settings FOR INSERT
+    WITH CHECK (auth.uid() = user_id);
+
+CREATE POLICY "Users can update own settings"
+    ON public.user_settings FOR UPDATE
+    USING (auth.uid() = user_id);
+
+CREATE POLICY "Users can delete own settings"
+    ON public.user_settings FOR DELETE
+    USING (auth.uid() = user_id);
+
+-- Trigger for automatic updated_at timestamp
+CREATE TRIGGER update_user_settings_updated_at
+    BEFORE UPDATE ON public.user_settings
+    FOR EACH ROW EXECUTE FUNCTION public.update_updated_at_column();
+
+-- Grant permissions to authenticated users and service role
+GRANT ALL ON public.user_settings TO authenticated;
+GRANT ALL ON public.user_settings TO service_role;
+
+-- Documentation comments
+COMMENT ON TABLE public.user_settings IS 'Per-user calendar settings used by analytics, streaks and insights';
+COMMENT ON COLUMN public.user_settings.timezone IS 'IANA time zone name, e.g. Asia/Tokyo; validated by the backend';
+COMMENT ON COLUMN public.user_settings.week_start IS 'First day of the week: sunday or monday';
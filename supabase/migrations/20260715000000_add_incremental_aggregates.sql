@@ -0,0 +1,78 @@
+-- Migration: Incremental daily aggregate maintenance
+-- Daily aggregates are kept up to date from the change log instead of being
+-- rebuilt from 90 days of events on every insight refresh. This migration adds:
+-- 1. hour_counts on daily_aggregates, so time-of-day patterns need no events
+-- 2. aggregate_states table with each user's change log cursor and time zone
+-- 3. aggregated_events table recording the day each event is counted under
+-- Users without an aggregate_states row are backfilled on their next sync.
+
+-- ============================================================================
+-- Hour Counts
+-- ============================================================================
+
+ALTER TABLE public.daily_aggregates
+    ADD COLUMN IF NOT EXISTS hour_counts INTEGER[];
+
+COMMENT ON COLUMN public.daily_aggregates.hour_counts IS 'Events per local hour of the day, 24 entries';
+
+-- ============================================================================
+-- Aggregate States Table
+-- ============================================================================
+-- Aggregates are days in the user's time zone; when it changes, or the change
+-- log has been pruned past the cursor, every aggregate is rebuilt.
+
+CREATE TABLE IF NOT EXISTS public.aggregate_states (
+    user_id UUID PRIMARY KEY REFERENCES public.users(id) ON DELETE CASCADE,
+    cursor BIGINT NOT NULL DEFAULT 0,                           -- Highest change_log id applied
+    timezone TEXT NOT NULL DEFAULT 'UTC',                       -- Time zone of the aggregates' days
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT NOW(),
+    updated_at TIMESTAMP WITH TIME ZONE DEFAULT NOW()
+);
+
+ALTER TABLE public.aggregate_states ENABLE ROW LEVEL SECURITY;
+
+CREATE POLICY "Users can view own aggregate state"
+    ON public.aggregate_states FOR SELECT
+    USING (auth.uid() = user_id);
+
+CREATE POLICY "Service role can manage aggregate states"
+    ON public.aggregate_states FOR ALL
+    USING (true)
+    WITH CHECK (true);
+
+CREATE TRIGGER update_aggregate_states_updated_at
+    BEFORE UPDATE ON public.aggregate_states
+    FOR EACH ROW EXECUTE FUNCTION public.update_updated_at_column();
+
+-- ============================================================================
+-- Aggregated Events Table
+-- ============================================================================
+-- A change log entry only carries an event's new state. This records where
+-- the event was counted, so an update or delete can recompute that day too.
+-- event_id has no foreign key: the row must outlive the event until its
+-- delete entry is applied.
+
+CREATE TABLE IF NOT EXISTS public.aggregated_events (
+    event_id UUID PRIMARY KEY,
+    user_id UUID NOT NULL REFERENCES public.users(id) ON DELETE CASCADE,
+    event_type_id UUID NOT NULL,
+    date DATE NOT NULL
+);
+
+CREATE INDEX IF NOT EXISTS idx_aggregated_events_user_id
+    ON public.aggregated_events(user_id);
+
+ALTER TABLE public.aggregated_events ENABLE ROW LEVEL SECURITY;
+
+CREATE POLICY "Service role can manage aggregated events"
+    ON public.aggregated_events FOR ALL
+    USING (true)
+    WITH CHECK (true);
+
+-- Grant permissions to service role
+GRANT ALL ON public.aggregate_states TO service_role;
+GRANT ALL ON public.aggregated_events TO service_role;
+
+-- Documentation comments
+COMMENT ON TABLE public.aggregate_states IS 'How far each user''s daily aggregates have applied the change log';
+COMMENT ON TABLE public.aggregated_events IS 'The local day and event type each event is counted under in daily_aggregates';
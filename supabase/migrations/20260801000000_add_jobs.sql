@@ -0,0 +1,120 @@
+-- Migration: Background jobs
+-- Insights are recomputed by background workers instead of on the request
+-- path. This migration adds:
+-- 1. jobs table, the durable queue the workers claim work from
+-- 2. enqueue_job function, which debounces repeated requests for the same work
+-- 3. claim_jobs function, which hands due jobs to one worker each
+
+-- ============================================================================
+-- Jobs Table
+-- ============================================================================
+-- A user has at most one pending job of each kind. Queueing another moves its
+-- run time, so a burst of event changes leads to a single recompute. A failed
+-- run is retried as a new pending job carrying the attempt count forward.
+
+CREATE TABLE IF NOT EXISTS public.jobs (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    kind TEXT NOT NULL,
+    user_id UUID REFERENCES public.users(id) ON DELETE CASCADE,  -- NULL for jobs that belong to no user
+    status TEXT NOT NULL DEFAULT 'pending',
+    attempts INTEGER NOT NULL DEFAULT 0,                         -- Runs so far, including earlier failed jobs
+    run_at TIMESTAMP WITH TIME ZONE DEFAULT NOW() NOT NULL,      -- When a pending job becomes due
+    last_error TEXT,
+    started_at TIMESTAMP WITH TIME ZONE,
+    completed_at TIMESTAMP WITH TIME ZONE,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT NOW() NOT NULL,
+    updated_at TIMESTAMP WITH TIME ZONE DEFAULT NOW() NOT NULL,
+
+    CONSTRAINT check_job_status
+        CHECK (status IN ('pending', 'running', 'succeeded', 'failed')),
+    CONSTRAINT check_job_kind
+        CHECK (kind IN ('insights', 'maintenance'))
+);
+
+CREATE UNIQUE INDEX IF NOT EXISTS idx_jobs_pending
+    ON public.jobs(kind, (COALESCE(user_id, '00000000-0000-0000-0000-000000000000'::uuid)))
+    WHERE status = 'pending';
+
+CREATE INDEX IF NOT EXISTS idx_jobs_due
+    ON public.jobs(run_at)
+    WHERE status = 'pending';
+
+CREATE INDEX IF NOT EXISTS idx_jobs_user_id
+    ON public.jobs(user_id, kind, created_at DESC);
+
+ALTER TABLE public.jobs ENABLE ROW LEVEL SECURITY;
+
+CREATE POLICY "Users can view own jobs"
+    ON public.jobs FOR SELECT
+    USING (auth.uid() = user_id);
+
+CREATE POLICY "Service role can manage jobs"
+    ON public.jobs FOR ALL
+    USING (true)
+    WITH CHECK (true);
+
+CREATE TRIGGER update_jobs_updated_at
+    BEFORE UPDATE ON public.jobs
+    FOR EACH ROW EXECUTE FUNCTION public.update_updated_at_column();
+
+-- ============================================================================
+-- Queue Functions
+-- ============================================================================
+
+CREATE OR REPLACE FUNCTION public.enqueue_job(
+    p_kind TEXT,
+    p_user_id UUID,
+    p_run_at TIMESTAMP WITH TIME ZONE,
+    p_attempts INTEGER DEFAULT 0,
+    p_last_error TEXT DEFAULT NULL
+) RETURNS SETOF public.jobs AS $$
+    INSERT INTO public.jobs AS j (kind, user_id, run_at, attempts, last_error)
+    VALUES (p_kind, p_user_id, p_run_at, p_attempts, p_last_error)
+    ON CONFLICT (kind, (COALESCE(user_id, '00000000-0000-0000-0000-000000000000'::uuid)))
+        WHERE status = 'pending'
+    DO UPDATE SET
+        run_at = EXCLUDED.run_at,
+        attempts = GREATEST(j.attempts, EXCLUDED.attempts),
+        last_error = COALESCE(EXCLUDED.last_error, j.last_error)
+    RETURNING *;
+$$ LANGUAGE sql SECURITY DEFINER;
+
+GRANT EXECUTE ON FUNCTION public.enqueue_job TO service_role;
+
+-- Several server instances may claim at once; SKIP LOCKED gives each due job
+-- to exactly one of them.
+CREATE OR REPLACE FUNCTION public.claim_jobs(
+    p_limit INTEGER
+) RETURNS SETOF public.jobs AS $$
+    UPDATE public.jobs
+    SET status = 'running', attempts = attempts + 1, started_at = NOW()
+    WHERE id IN (
+        SELECT j.id FROM public.jobs j
+        WHERE j.status = 'pending'
+          AND j.run_at <= NOW()
+          AND NOT EXISTS (
+              SELECT 1 FROM public.jobs r
+              WHERE r.status = 'running'
+                AND r.kind = j.kind
+                AND r.user_id IS NOT DISTINCT FROM j.user_id
+          )
+        ORDER BY j.run_at
+        LIMIT p_limit
+        FOR UPDATE SKIP LOCKED
+    )
+    RETURNING *;
+$$ LANGUAGE sql SECURITY DEFINER;
+
+GRANT EXECUTE ON FUNCTION public.claim_jobs TO service_role;
+
+-- Grant permissions to service role
+GRANT ALL ON public.jobs TO service_role;
+
+-- ============================================================================
+-- Comments for documentation
+-- ============================================================================
+
+COMMENT ON TABLE public.jobs IS 'Durable queue of background jobs such as insight recomputation and nightly maintenance.';
+COMMENT ON INDEX idx_jobs_pending IS 'Ensures a user has at most one pending job of each kind, which debounces repeated requests.';
+COMMENT ON FUNCTION public.enqueue_job IS 'Queues a job, or moves the run time of the pending job of the same kind and user.';
+COMMENT ON FUNCTION public.claim_jobs IS 'Marks up to p_limit due jobs as running and returns them, skipping users with a job of the same kind already running.';
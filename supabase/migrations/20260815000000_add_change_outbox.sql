@@ -0,0 +1,133 @@
+-- Migration: Change outbox
+-- The supabase backend writes an entity and its change_log entry in separate
+-- REST calls, so an entry can be lost when the second call fails. This
+-- migration adds:
+-- 1. change_outbox table, filled by triggers in the same transaction as every
+--    write to a synced table, so no committed change goes unrecorded
+-- 2. claim_change_outbox function, which the server's relay uses to find the
+--    changes whose change_log entry was never written
+
+-- ============================================================================
+-- Change Outbox Table
+-- ============================================================================
+
+CREATE TABLE IF NOT EXISTS public.change_outbox (
+    id BIGSERIAL PRIMARY KEY,
+    entity_type TEXT NOT NULL,
+    entity_id UUID NOT NULL,
+    user_id UUID NOT NULL,                                        -- No foreign key: rows are written while a user's data is cascaded away
+    changed_at TIMESTAMP WITH TIME ZONE DEFAULT NOW() NOT NULL,   -- Start of the writing transaction, comparable with change_log.created_at
+    claimed_until TIMESTAMP WITH TIME ZONE,                       -- Lease of the relay working on the row
+
+    CONSTRAINT check_outbox_entity_type
+        CHECK (entity_type IN ('event', 'event_type', 'geofence', 'property_definition'))
+);
+
+CREATE INDEX IF NOT EXISTS idx_change_outbox_changed_at
+    ON public.change_outbox(changed_at);
+
+CREATE INDEX IF NOT EXISTS idx_change_outbox_user_id
+    ON public.change_outbox(user_id);
+
+ALTER TABLE public.change_outbox ENABLE ROW LEVEL SECURITY;
+
+CREATE POLICY "Service role can manage change outbox"
+    ON public.change_outbox FOR ALL
+    USING (true)
+    WITH CHECK (true);
+
+GRANT ALL ON public.change_outbox TO service_role;
+GRANT USAGE, SELECT ON SEQUENCE public.change_outbox_id_seq TO service_role;
+
+-- ============================================================================
+-- Outbox Triggers
+-- ============================================================================
+
+CREATE OR REPLACE FUNCTION public.record_change_outbox()
+RETURNS TRIGGER AS $$
+DECLARE
+    changed RECORD;
+BEGIN
+    IF TG_OP = 'DELETE' THEN
+        changed := OLD;
+    ELSE
+        changed := NEW;
+    END IF;
+
+    INSERT INTO public.change_outbox (entity_type, entity_id, user_id)
+    VALUES (TG_ARGV[0], changed.id, changed.user_id);
+
+    RETURN NULL;
+END;
+$$ LANGUAGE plpgsql SECURITY DEFINER;
+
+CREATE TRIGGER record_events_change_outbox
+    AFTER INSERT OR UPDATE OR DELETE ON public.events
+    FOR EACH ROW EXECUTE FUNCTION public.record_change_outbox('event');
+
+CREATE TRIGGER record_event_types_change_outbox
+    AFTER INSERT OR UPDATE OR DELETE ON public.event_types
+    FOR EACH ROW EXECUTE FUNCTION public.record_change_outbox('event_type');
+
+CREATE TRIGGER record_property_definitions_change_outbox
+    AFTER INSERT OR UPDATE OR DELETE ON public.property_definitions
+    FOR EACH ROW EXECUTE FUNCTION public.record_change_outbox('property_definition');
+
+CREATE TRIGGER record_geofences_change_outbox
+    AFTER INSERT OR UPDATE OR DELETE ON public.geofences
+    FOR EACH ROW EXECUTE FUNCTION public.record_change_outbox('geofence');
+
+-- ============================================================================
+-- Relay Function
+-- ============================================================================
+
+-- A change is covered once the entity has a change_log entry created in or
+-- after the writing transaction. On the postgres backend every entry is
+-- written in the same transaction, so its rows are simply cleared here.
+CREATE OR REPLACE FUNCTION public.claim_change_outbox(
+    p_settled_before TIMESTAMP WITH TIME ZONE,
+    p_lease_seconds INTEGER,
+    p_limit INTEGER
+) RETURNS SETOF JSONB AS $$
+    DELETE FROM public.change_outbox o
+    WHERE o.changed_at < p_settled_before
+      AND EXISTS (
+          SELECT 1 FROM public.change_log c
+          WHERE c.entity_type = o.entity_type
+            AND c.entity_id = o.entity_id
+            AND c.created_at >= o.changed_at
+      );
+
+    UPDATE public.change_outbox o
+    SET claimed_until = NOW() + make_interval(secs => p_lease_seconds)
+    WHERE o.id IN (
+        SELECT d.id FROM public.change_outbox d
+        WHERE d.changed_at < p_settled_before
+          AND (d.claimed_until IS NULL OR d.claimed_until < NOW())
+        ORDER BY d.id
+        LIMIT p_limit
+        FOR UPDATE SKIP LOCKED
+    )
+    RETURNING jsonb_build_object(
+        'id', o.id,
+        'entity_type', o.entity_type,
+        'entity_id', o.entity_id,
+        'user_id', o.user_id,
+        'changed_at', o.changed_at,
+        'logged_operation', (
+            SELECT c.operation FROM public.change_log c
+            WHERE c.entity_type = o.entity_type AND c.entity_id = o.entity_id
+            ORDER BY c.id DESC
+            LIMIT 1
+        )
+    );
+$$ LANGUAGE sql SECURITY DEFINER;
+
+GRANT EXECUTE ON FUNCTION public.claim_change_outbox TO service_role;
+
+-- ============================================================================
+-- Comments for documentation
+-- ============================================================================
+
+COMMENT ON TABLE public.change_outbox IS 'Writes to synced tables, recorded atomically by triggers so the relay can append change_log entries the server failed to write.';
+COMMENT ON FUNCTION public.claim_change_outbox IS 'Clears outbox rows covered by a change_log entry, then leases and returns up to p_limit uncovered rows older than p_settled_before with the operation of the entity''s latest change_log entry.';
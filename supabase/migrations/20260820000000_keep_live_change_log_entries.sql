@@ -0,0 +1,41 @@
+-- Migration: Keep live entities in the change log
+-- prune_change_log removed every entry older than the retention window,
+-- including the only entry of an entity unchanged for that long. The log then
+-- no longer recorded the entity, and reconciling or relaying it appended a
+-- fresh create. Pruning now keeps the latest entry of each entity that has
+-- not been deleted; superseded entries and deletes still age out.
+
+CREATE OR REPLACE FUNCTION public.prune_change_log(
+    p_before TIMESTAMP WITH TIME ZONE
+) RETURNS INTEGER AS $$
+DECLARE
+    v_deleted_count INTEGER;
+BEGIN
+    WITH pruned AS (
+        DELETE FROM public.change_log c
+        WHERE c.created_at < p_before
+          AND (
+              c.operation = 'delete'
+              OR EXISTS (
+                  SELECT 1 FROM public.change_log n
+                  WHERE n.user_id = c.user_id
+                    AND n.entity_type = c.entity_type
+                    AND n.entity_id = c.entity_id
+                    AND n.id > c.id
+              )
+          )
+        RETURNING user_id, id
+    ), horizons AS (
+        INSERT INTO public.change_log_horizons (user_id, cursor)
+        SELECT user_id, MAX(id) FROM pruned GROUP BY user_id
+        ON CONFLICT (user_id) DO UPDATE
+            SET cursor = GREATEST(public.change_log_horizons.cursor, EXCLUDED.cursor),
+                updated_at = NOW()
+    )
+    SELECT COUNT(*) INTO v_deleted_count FROM pruned;
+
+    RETURN v_deleted_count;
+END;
+$$ LANGUAGE plpgsql SECURITY DEFINER;
+
+COMMENT ON FUNCTION public.prune_change_log IS 'Removes change log entries created before p_before, except the latest entry of each entity that has not been deleted, and advances each user''s horizon.';
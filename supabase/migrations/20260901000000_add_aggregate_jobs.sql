@@ -0,0 +1,25 @@
+-- Migration: Aggregate jobs
+-- Daily aggregates are brought up to date from the change log by a background
+-- job instead of on every analytics read. This migration:
+-- 1. stamps change log entries with the time they were inserted
+-- 2. allows the aggregates job kind
+
+-- ============================================================================
+-- Change Log Insert Time
+-- ============================================================================
+-- change_log IDs come from a sequence, so a transaction that commits late can
+-- add an entry below IDs that are already visible. Readers that advance a
+-- cursor only pass entries older than any open transaction. NOW() is the
+-- start of the transaction, which can be long before the ID is taken, so
+-- entries record the clock time of the insert instead.
+
+ALTER TABLE public.change_log
+    ALTER COLUMN created_at SET DEFAULT clock_timestamp();
+
+-- ============================================================================
+-- Job Kinds
+-- ============================================================================
+
+ALTER TABLE public.jobs DROP CONSTRAINT IF EXISTS check_job_kind;
+ALTER TABLE public.jobs ADD CONSTRAINT check_job_kind
+    CHECK (kind IN ('insights', 'maintenance', 'aggregates'));
@@ -0,0 +1,42 @@
+-- Migration: Cap job debounce
+-- Queueing a job again used to move a pending job to the new run time with
+-- no limit, so a user who kept making changes never had their insights
+-- recomputed. A retry that met a newly queued job also gave it the failed
+-- job's attempts, leaving the new work a single try. This migration:
+-- 1. caps how far a pending job can be moved, measured from when it was queued
+-- 2. keeps the attempts of the job queued for new work when it meets a retry
+
+DROP FUNCTION IF EXISTS public.enqueue_job(TEXT, UUID, TIMESTAMP WITH TIME ZONE, INTEGER, TEXT);
+
+-- A retry has more attempts than a job queued for new work. When the two
+-- meet, the new work's job wins: it runs when it was going to, with its own
+-- attempts, and the retry is dropped since that run does its work too.
+CREATE OR REPLACE FUNCTION public.enqueue_job(
+    p_kind TEXT,
+    p_user_id UUID,
+    p_run_at TIMESTAMP WITH TIME ZONE,
+    p_attempts INTEGER DEFAULT 0,
+    p_last_error TEXT DEFAULT NULL,
+    p_max_wait INTERVAL DEFAULT NULL
+) RETURNS SETOF public.jobs AS $$
+    INSERT INTO public.jobs AS j (kind, user_id, run_at, attempts, last_error)
+    VALUES (p_kind, p_user_id, p_run_at, p_attempts, p_last_error)
+    ON CONFLICT (kind, (COALESCE(user_id, '00000000-0000-0000-0000-000000000000'::uuid)))
+        WHERE status = 'pending'
+    DO UPDATE SET
+        run_at = CASE
+            WHEN EXCLUDED.attempts > j.attempts THEN j.run_at
+            WHEN p_max_wait IS NULL THEN EXCLUDED.run_at
+            ELSE LEAST(EXCLUDED.run_at, j.created_at + p_max_wait)
+        END,
+        attempts = LEAST(j.attempts, EXCLUDED.attempts),
+        last_error = CASE
+            WHEN EXCLUDED.attempts > j.attempts THEN j.last_error
+            ELSE EXCLUDED.last_error
+        END
+    RETURNING *;
+$$ LANGUAGE sql SECURITY DEFINER;
+
+GRANT EXECUTE ON FUNCTION public.enqueue_job TO service_role;
+
+COMMENT ON FUNCTION public.enqueue_job IS 'Queues a job, or moves the run time of the pending job of the same kind and user, at most p_max_wait after it was queued.';
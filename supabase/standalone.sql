@@ -0,0 +1,53 @@
+-- Standalone PostgreSQL setup
+-- The migrations in supabase/migrations target a Supabase project. To run the
+-- API's postgres storage backend on a plain PostgreSQL database instead, apply
+-- this file first and then every migration in order. It creates the parts of
+-- Supabase the schema refers to:
+-- 1. anon, authenticated and service_role roles, named by grants and policies
+-- 2. extensions schema with pgcrypto, used to generate UUIDv7s
+-- 3. auth.users and auth.uid(). The server adds a user's auth.users row the
+--    first time it sees them, and the schema's triggers create the rest.
+
+-- ============================================================================
+-- Roles
+-- ============================================================================
+
+DO $$
+DECLARE
+    role_name TEXT;
+BEGIN
+    FOREACH role_name IN ARRAY ARRAY['anon', 'authenticated', 'service_role'] LOOP
+        IF NOT EXISTS (SELECT 1 FROM pg_roles WHERE rolname = role_name) THEN
+            EXECUTE format('CREATE ROLE %I NOLOGIN', role_name);
+        END IF;
+    END LOOP;
+END;
+$$;
+
+-- ============================================================================
+-- Extensions
+-- ============================================================================
+
+CREATE SCHEMA IF NOT EXISTS extensions;
+CREATE EXTENSION IF NOT EXISTS pgcrypto WITH SCHEMA extensions;
+
+-- ============================================================================
+-- Auth
+-- ============================================================================
+
+CREATE SCHEMA IF NOT EXISTS auth;
+
+CREATE TABLE IF NOT EXISTS auth.users (
+    id UUID PRIMARY KEY,
+    email TEXT,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT NOW() NOT NULL
+);
+
+-- The server connects as a trusted role and checks ownership itself, so
+-- row level security policies only need a user ID when one is set
+CREATE OR REPLACE FUNCTION auth.uid()
+RETURNS UUID AS $$
+    SELECT NULLIF(current_setting('request.jwt.claim.sub', true), '')::uuid;
+$$ LANGUAGE sql STABLE;
+
+COMMENT ON TABLE auth.users IS 'Stand-in for Supabase Auth users on a standalone database; rows are added by the API server.';